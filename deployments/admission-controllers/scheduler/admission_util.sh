@@ -46,6 +46,9 @@ REGISTERED_ADMISSIONS=${REGISTERED_ADMISSIONS//,/ }
 if [ -z "$SCHEDULER_SERVICE_NAME" ]; then
   SCHEDULER_SERVICE_NAME=`cat ${CONF_FILE} | grep ^schedulerServiceName | cut -d "=" -f 2`
 fi
+if [ -z "$SCHEDULER_SHIM_URL" ]; then
+  SCHEDULER_SHIM_URL=`cat ${CONF_FILE} | grep ^schedulerShimURL | cut -d "=" -f 2`
+fi
 if [ -z "$ADMISSION_CONTROLLER_IMAGE_REGISTRY" ]; then
   ADMISSION_CONTROLLER_IMAGE_REGISTRY=`cat ${CONF_FILE} | grep ^dockerImageRegistry | cut -d "=" -f 2`
 fi
@@ -138,6 +141,7 @@ create_resources() {
     -e 's@${POLICY_GROUP}@'"$POLICY_GROUP"'@g' \
     -e 's@${SERVICE_ACCOUNT_NAME}@'"$SERVICE_ACCOUNT_NAME"'@g' \
     -e 's@${SCHEDULER_SERVICE_ADDRESS}@'"$SCHEDULER_SERVICE_ADDRESS"'@g' \
+    -e 's@${SCHEDULER_SHIM_URL}@'"$SCHEDULER_SHIM_URL"'@g' \
     -e 's@${ADMISSION_CONTROLLER_IMAGE_REGISTRY}@'"$ADMISSION_CONTROLLER_IMAGE_REGISTRY"'@g' \
     -e 's@${ADMISSION_CONTROLLER_IMAGE_TAG}@'"$ADMISSION_CONTROLLER_IMAGE_TAG"'@g' \
     -e 's@${ADMISSION_CONTROLLER_IMAGE_PULL_POLICY}@'"$ADMISSION_CONTROLLER_IMAGE_PULL_POLICY"'@g' \
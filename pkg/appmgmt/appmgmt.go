@@ -47,10 +47,10 @@ func NewAMService(amProtocol interfaces.ApplicationManagementProtocol,
 		managers:    make([]interfaces.AppManager, 0),
 	}
 
-	log.Logger().Info("Initializing new AppMgmt service")
+	log.Log(log.AppMgmt).Info("Initializing new AppMgmt service")
 
 	if !apiProvider.IsTestingMode() {
-		log.Logger().Info("Registering Spark operator with the AppMgmt service")
+		log.Log(log.AppMgmt).Info("Registering Spark operator with the AppMgmt service")
 		appManager.register(
 			// registered app plugins
 			// for general apps
@@ -80,11 +80,11 @@ func (svc *AppManagementService) GetManagerByName(name string) interfaces.AppMan
 func (svc *AppManagementService) register(managers ...interfaces.AppManager) {
 	for _, mgr := range managers {
 		if conf.GetSchedulerConf().IsOperatorPluginEnabled(mgr.Name()) {
-			log.Logger().Info("registering app management service",
+			log.Log(log.AppMgmt).Info("registering app management service",
 				zap.String("serviceName", mgr.Name()))
 			svc.managers = append(svc.managers, mgr)
 		} else {
-			log.Logger().Info("skip registering app management service",
+			log.Log(log.AppMgmt).Info("skip registering app management service",
 				zap.String("serviceName", mgr.Name()))
 		}
 	}
@@ -94,22 +94,22 @@ func (svc *AppManagementService) Start() error {
 	for _, optService := range svc.managers {
 		// init service before starting
 		if err := optService.ServiceInit(); err != nil {
-			log.Logger().Error("service init fails",
+			log.Log(log.AppMgmt).Error("service init fails",
 				zap.String("serviceName", optService.Name()),
 				zap.Error(err))
 			return err
 		}
 
-		log.Logger().Info("starting app management service",
+		log.Log(log.AppMgmt).Info("starting app management service",
 			zap.String("serviceName", optService.Name()))
 		if err := optService.Start(); err != nil {
-			log.Logger().Error("failed to start management service",
+			log.Log(log.AppMgmt).Error("failed to start management service",
 				zap.String("serviceName", optService.Name()),
 				zap.Error(err))
 			return err
 		}
 
-		log.Logger().Info("app management service started",
+		log.Log(log.AppMgmt).Info("app management service started",
 			zap.String("serviceName", optService.Name()))
 	}
 
@@ -117,7 +117,7 @@ func (svc *AppManagementService) Start() error {
 }
 
 func (svc *AppManagementService) Stop() {
-	log.Logger().Info("shutting down app management services")
+	log.Log(log.AppMgmt).Info("shutting down app management services")
 	for _, optService := range svc.managers {
 		optService.Stop()
 	}
@@ -130,7 +130,7 @@ func (svc *AppManagementService) ApplicationStateUpdateEventHandler() func(obj i
 	if appMgr, ok := mgr.(*application.AppManager); ok {
 		return appMgr.HandleApplicationStateUpdate()
 	}
-	log.Logger().Warn("App manager is not registered",
+	log.Log(log.AppMgmt).Warn("App manager is not registered",
 		zap.String("app manager name", constants.AppManagerHandlerName))
 	return func(obj interface{}) {
 		// noop
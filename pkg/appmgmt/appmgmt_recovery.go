@@ -19,15 +19,20 @@
 package appmgmt
 
 import (
+	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
+	"k8s.io/client-go/util/workqueue"
 
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt/interfaces"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
 )
 
 func (svc *AppManagementService) WaitForRecovery(maxTimeout time.Duration) error {
@@ -43,45 +48,69 @@ func (svc *AppManagementService) WaitForRecovery(maxTimeout time.Duration) error
 }
 
 func (svc *AppManagementService) recoverApps() (map[string]interfaces.ManagedApp, error) {
-	log.Logger().Info("Starting app recovery")
+	log.Log(log.AppMgmt).Info("Starting app recovery")
 	recoveringApps := make(map[string]interfaces.ManagedApp)
 	for _, mgr := range svc.managers {
 		if m, ok := mgr.(interfaces.Recoverable); ok {
 			appMetas, err := m.ListApplications()
 			if err != nil {
-				log.Logger().Error("failed to list apps", zap.Error(err))
+				log.Log(log.AppMgmt).Error("failed to list apps", zap.Error(err))
 				return recoveringApps, err
 			}
-
-			// trigger recovery of the apps
-			// this is simply submit the app again
-			for _, appMeta := range appMetas {
-				if app := svc.amProtocol.AddApplication(
-					&interfaces.AddApplicationRequest{
-						Metadata: appMeta,
-					}); app != nil {
-					recoveringApps[app.GetApplicationID()] = app
-					if err := app.TriggerAppRecovery(); err != nil {
-						log.Logger().Error("failed to recover app", zap.Error(err))
-						return recoveringApps, fmt.Errorf("failed to recover app %s, reason: %v",
-							app.GetApplicationID(), err)
-					}
-				}
+			if err = svc.triggerAppRecovery(appMetas, recoveringApps); err != nil {
+				return recoveringApps, err
 			}
 		}
 	}
 	return recoveringApps, nil
 }
 
+// trigger recovery of the apps, this is simply submit the app again.
+// The apps are submitted by a bounded number of workers, the first failure stops the recovery.
+func (svc *AppManagementService) triggerAppRecovery(appMetas map[string]interfaces.ApplicationMetadata,
+	recoveringApps map[string]interfaces.ManagedApp) error {
+	metas := make([]interfaces.ApplicationMetadata, 0, len(appMetas))
+	for _, appMeta := range appMetas {
+		metas = append(metas, appMeta)
+	}
+	var lock sync.Mutex
+	var recoverErr error
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	workqueue.ParallelizeUntil(ctx, conf.GetSchedulerConf().GetRecoveryWorkers(), len(metas), func(i int) {
+		app := svc.amProtocol.AddApplication(&interfaces.AddApplicationRequest{
+			Metadata: metas[i],
+		})
+		if app == nil {
+			return
+		}
+		lock.Lock()
+		recoveringApps[app.GetApplicationID()] = app
+		lock.Unlock()
+		if err := app.TriggerAppRecovery(); err != nil {
+			log.Log(log.AppMgmt).Error("failed to recover app", zap.Error(err))
+			lock.Lock()
+			if recoverErr == nil {
+				recoverErr = fmt.Errorf("failed to recover app %s, reason: %v", app.GetApplicationID(), err)
+			}
+			lock.Unlock()
+			cancel()
+		}
+	})
+	return recoverErr
+}
+
 func (svc *AppManagementService) waitForAppRecovery(
 	recoveringApps map[string]interfaces.ManagedApp, maxTimeout time.Duration) error {
-	if len(recoveringApps) > 0 {
-		log.Logger().Info("wait for app recovery",
-			zap.Int("appToRecover", len(recoveringApps)))
+	total := len(recoveringApps)
+	metrics.GetRecoveryMetrics().SetProgress(metrics.ObjectApplication, 0, total)
+	if total > 0 {
+		log.Log(log.AppMgmt).Info("wait for app recovery",
+			zap.Int("appToRecover", total))
 		// check app states periodically, ensure all apps exit from recovering state
 		if err := utils.WaitForCondition(func() bool {
 			for _, app := range recoveringApps {
-				log.Logger().Debug("appInfo",
+				log.Log(log.AppMgmt).Debug("appInfo",
 					zap.String("appId", app.GetApplicationID()),
 					zap.String("state", app.GetApplicationState()))
 				if app.GetApplicationState() == events.States().Application.Accepted {
@@ -89,15 +118,18 @@ func (svc *AppManagementService) waitForAppRecovery(
 				}
 			}
 
+			metrics.GetRecoveryMetrics().SetProgress(metrics.ObjectApplication, total-len(recoveringApps), total)
 			if len(recoveringApps) == 0 {
-				log.Logger().Info("app recovery is successful")
+				log.Log(log.AppMgmt).Info("app recovery is successful")
 				return true
 			}
 
+			log.Log(log.AppMgmt).Info("still waiting for recovering apps",
+				zap.Int("totalApps", total),
+				zap.Int("recoveredApps", total-len(recoveringApps)))
 			return false
 		}, 1*time.Second, maxTimeout); err != nil {
-			return fmt.Errorf("timeout waiting for app recovery in %s",
-				maxTimeout.String())
+			return &interfaces.RecoveryTimeoutError{Object: "app", Timeout: maxTimeout}
 		}
 	}
 
@@ -19,6 +19,7 @@
 package appmgmt
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -32,6 +33,7 @@ import (
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/dispatcher"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
 	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
 )
 
@@ -65,6 +67,11 @@ func TestAppManagerRecoveryTimeout(t *testing.T) {
 
 	err = amService.waitForAppRecovery(apps, 3*time.Second)
 	assert.ErrorContains(t, err, "timeout waiting for app recovery")
+	var timeout *interfaces.RecoveryTimeoutError
+	assert.Assert(t, errors.As(err, &timeout))
+	assert.Equal(t, timeout.Object, "app")
+	assert.Equal(t, metrics.GetRecoveryMetrics().GetProgress(metrics.ObjectApplication),
+		metrics.RecoveryProgress{Recovered: 0, Total: 2})
 }
 
 func TestAppManagerRecoveryExitCondition(t *testing.T) {
@@ -86,6 +93,8 @@ func TestAppManagerRecoveryExitCondition(t *testing.T) {
 	// this should not timeout
 	err = amService.waitForAppRecovery(apps, 3*time.Second)
 	assert.NilError(t, err)
+	assert.Equal(t, metrics.GetRecoveryMetrics().GetProgress(metrics.ObjectApplication),
+		metrics.RecoveryProgress{Recovered: 2, Total: 2})
 }
 
 // test app state transition during recovery
@@ -92,7 +92,7 @@ func (os *Manager) Stop() {
 func (os *Manager) getTaskMetadata(pod *v1.Pod) (interfaces.TaskMetadata, bool) {
 	appID, err := utils.GetApplicationIDFromPod(pod)
 	if err != nil {
-		log.Logger().Debug("unable to get task by given pod", zap.Error(err))
+		log.Log(log.AppMgmt).Debug("unable to get task by given pod", zap.Error(err))
 		return interfaces.TaskMetadata{}, false
 	}
 
@@ -115,7 +115,7 @@ func (os *Manager) getTaskMetadata(pod *v1.Pod) (interfaces.TaskMetadata, bool)
 func (os *Manager) getAppMetadata(pod *v1.Pod) (interfaces.ApplicationMetadata, bool) {
 	appID, err := utils.GetApplicationIDFromPod(pod)
 	if err != nil {
-		log.Logger().Debug("unable to get application for pod",
+		log.Log(log.AppMgmt).Debug("unable to get application for pod",
 			zap.String("namespace", pod.Namespace),
 			zap.String("name", pod.Name),
 			zap.Error(err))
@@ -134,15 +134,19 @@ func (os *Manager) getAppMetadata(pod *v1.Pod) (interfaces.ApplicationMetadata,
 	if isStateAwareDisabled(pod) {
 		tags[constants.AppTagStateAwareDisable] = "true"
 	}
+	// the core can preempt the tasks of the application for its other tasks
+	if isIntraAppPreemptionAllowed(pod) {
+		tags[constants.AppTagIntraAppPreemption] = "true"
+	}
 
-	// get the user from Pod Labels
-	user := utils.GetUserFromPod(pod)
+	// get the user and the groups from the sources of the user resolution
+	userGroup := utils.GetUserGroupFromPod(pod)
 
 	var taskGroups []v1alpha1.TaskGroup = nil
 	if !os.gangSchedulingDisabled {
 		taskGroups, err = utils.GetTaskGroupsFromAnnotation(pod)
 		if err != nil {
-			log.Logger().Error("unable to get taskGroups for pod",
+			log.Log(log.AppMgmt).Error("unable to get taskGroups for pod",
 				zap.String("namespace", pod.Namespace),
 				zap.String("name", pod.Name),
 				zap.Error(err))
@@ -154,7 +158,8 @@ func (os *Manager) getAppMetadata(pod *v1.Pod) (interfaces.ApplicationMetadata,
 	return interfaces.ApplicationMetadata{
 		ApplicationID:              appID,
 		QueueName:                  utils.GetQueueNameFromPod(pod),
-		User:                       user,
+		User:                       userGroup.User,
+		Groups:                     userGroup.Groups,
 		Tags:                       tags,
 		TaskGroups:                 taskGroups,
 		OwnerReferences:            ownerReferences,
@@ -169,7 +174,7 @@ func isStateAwareDisabled(pod *v1.Pod) bool {
 	}
 	result, err := strconv.ParseBool(value)
 	if err != nil {
-		log.Logger().Debug("unable to parse label for pod",
+		log.Log(log.AppMgmt).Debug("unable to parse label for pod",
 			zap.String("namespace", pod.Namespace),
 			zap.String("name", pod.Name),
 			zap.String("label", constants.LabelDisableStateAware),
@@ -179,6 +184,23 @@ func isStateAwareDisabled(pod *v1.Pod) bool {
 	return result
 }
 
+func isIntraAppPreemptionAllowed(pod *v1.Pod) bool {
+	value, ok := pod.Annotations[constants.AnnotationAllowIntraAppPreemption]
+	if !ok {
+		return false
+	}
+	result, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Log(log.AppMgmt).Debug("unable to parse annotation for pod",
+			zap.String("namespace", pod.Namespace),
+			zap.String("name", pod.Name),
+			zap.String("annotation", constants.AnnotationAllowIntraAppPreemption),
+			zap.Error(err))
+		return false
+	}
+	return result
+}
+
 func getOwnerReferences(pod *v1.Pod) []metav1.OwnerReference {
 	if len(pod.OwnerReferences) > 0 {
 		return pod.OwnerReferences
@@ -216,11 +238,11 @@ func (os *Manager) filterPods(obj interface{}) bool {
 func (os *Manager) addPod(obj interface{}) {
 	pod, err := utils.Convert2Pod(obj)
 	if err != nil {
-		log.Logger().Error("failed to add pod", zap.Error(err))
+		log.Log(log.AppMgmt).Error("failed to add pod", zap.Error(err))
 		return
 	}
 
-	log.Logger().Debug("pod added",
+	log.Sampled(log.AppMgmt).Debug("pod added",
 		zap.String("appType", os.Name()),
 		zap.String("Name", pod.Name),
 		zap.String("Namespace", pod.Namespace))
@@ -252,13 +274,16 @@ func (os *Manager) addPod(obj interface{}) {
 func (os *Manager) updatePod(old, new interface{}) {
 	oldPod, err := utils.Convert2Pod(old)
 	if err != nil {
-		log.Logger().Error("expecting a pod object", zap.Error(err))
+		log.Log(log.AppMgmt).Error("expecting a pod object", zap.Error(err))
 		return
 	}
 
 	newPod, err := utils.Convert2Pod(new)
 	if err != nil {
-		log.Logger().Error("expecting a pod object", zap.Error(err))
+		log.Log(log.AppMgmt).Error("expecting a pod object", zap.Error(err))
+		return
+	}
+	if !utils.IsPodUpdateRelevant(oldPod, newPod) {
 		return
 	}
 
@@ -268,7 +293,7 @@ func (os *Manager) updatePod(old, new interface{}) {
 		// and these container won't be restarted. In this case, we can safely release
 		// the resources for this allocation. And mark the task is done.
 		if utils.IsPodTerminated(newPod) {
-			log.Logger().Info("task completes",
+			log.Log(log.AppMgmt).Info("task completes",
 				zap.String("appType", os.Name()),
 				zap.String("namespace", newPod.Namespace),
 				zap.String("podName", newPod.Name),
@@ -298,15 +323,15 @@ func (os *Manager) deletePod(obj interface{}) {
 		var err error
 		pod, err = utils.Convert2Pod(t.Obj)
 		if err != nil {
-			log.Logger().Error(err.Error())
+			log.Log(log.AppMgmt).Error(err.Error())
 			return
 		}
 	default:
-		log.Logger().Error("cannot convert to pod")
+		log.Log(log.AppMgmt).Error("cannot convert to pod")
 		return
 	}
 
-	log.Logger().Info("delete pod",
+	log.Log(log.AppMgmt).Info("delete pod",
 		zap.String("appType", os.Name()),
 		zap.String("namespace", pod.Namespace),
 		zap.String("podName", pod.Name),
@@ -320,26 +345,26 @@ func (os *Manager) deletePod(obj interface{}) {
 }
 
 func (os *Manager) ListApplications() (map[string]interfaces.ApplicationMetadata, error) {
-	log.Logger().Info("Retrieving pod list")
+	log.Log(log.AppMgmt).Info("Retrieving pod list")
 	// list all pods on this cluster
 	slt := labels.NewSelector()
 	appPods, err := os.apiProvider.GetAPIs().PodInformer.Lister().List(slt)
 	if err != nil {
 		return nil, err
 	}
-	log.Logger().Info("Pod list retrieved from api server", zap.Int("nr of pods", len(appPods)))
+	log.Log(log.AppMgmt).Info("Pod list retrieved from api server", zap.Int("nr of pods", len(appPods)))
 	// get existing apps
 	existingApps := make(map[string]interfaces.ApplicationMetadata)
 	podsRecovered := 0
 	podsWithoutMetaData := 0
 	for _, pod := range appPods {
-		log.Logger().Debug("Looking at pod for recovery candidates", zap.String("podNamespace", pod.Namespace), zap.String("podName", pod.Name))
+		log.Sampled(log.AppMgmt).Debug("Looking at pod for recovery candidates", zap.String("podNamespace", pod.Namespace), zap.String("podName", pod.Name))
 		// general filter passes, and pod is assigned
 		// this means the pod is already scheduled by scheduler for an existing app
 		if utils.GeneralPodFilter(pod) && utils.IsAssignedPod(pod) {
 			if meta, ok := os.getAppMetadata(pod); ok {
 				podsRecovered++
-				log.Logger().Debug("Adding appID as recovery candidate", zap.String("appID", meta.ApplicationID))
+				log.Log(log.AppMgmt).Debug("Adding appID as recovery candidate", zap.String("appID", meta.ApplicationID))
 				if _, exist := existingApps[meta.ApplicationID]; !exist {
 					existingApps[meta.ApplicationID] = meta
 				}
@@ -348,7 +373,7 @@ func (os *Manager) ListApplications() (map[string]interfaces.ApplicationMetadata
 			}
 		}
 	}
-	log.Logger().Info("Application recovery statistics",
+	log.Log(log.AppMgmt).Info("Application recovery statistics",
 		zap.Int("nr of recoverable apps", len(existingApps)),
 		zap.Int("nr of total pods", len(appPods)),
 		zap.Int("nr of pods without application metadata", podsWithoutMetaData),
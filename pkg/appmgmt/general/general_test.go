@@ -222,6 +222,51 @@ func TestGetAppMetadata(t *testing.T) {
 	assert.Equal(t, ok, false)
 }
 
+func TestGetAppMetadataIntraAppPreemption(t *testing.T) {
+	am := NewManager(cache.NewMockedAMProtocol(), client.NewMockedAPIProvider())
+
+	pod := v1.Pod{
+		TypeMeta: apis.TypeMeta{
+			Kind:       "Pod",
+			APIVersion: "v1",
+		},
+		ObjectMeta: apis.ObjectMeta{
+			Name:      "pod00001",
+			Namespace: "app-namespace-01",
+			UID:       "UID-POD-00001",
+			Labels: map[string]string{
+				"applicationId": "app00001",
+				"queue":         "root.a",
+			},
+			Annotations: map[string]string{
+				constants.AnnotationAllowIntraAppPreemption: "true",
+			},
+		},
+		Spec: v1.PodSpec{
+			SchedulerName: constants.SchedulerName,
+		},
+		Status: v1.PodStatus{
+			Phase: v1.PodPending,
+		},
+	}
+
+	app, ok := am.getAppMetadata(&pod)
+	assert.Equal(t, ok, true)
+	assert.Equal(t, app.ApplicationID, "app00001")
+	assert.DeepEqual(t, app.Tags, map[string]string{
+		"application.preemption.intra": "true",
+		"namespace":                    "app-namespace-01",
+	})
+
+	// a value that is not a bool does not opt in
+	pod.Annotations[constants.AnnotationAllowIntraAppPreemption] = "yes"
+	app, ok = am.getAppMetadata(&pod)
+	assert.Equal(t, ok, true)
+	assert.DeepEqual(t, app.Tags, map[string]string{
+		"namespace": "app-namespace-01",
+	})
+}
+
 func TestGetTaskMetadata(t *testing.T) {
 	am := NewManager(&cache.MockedAMProtocol{}, client.NewMockedAPIProvider())
 
@@ -76,6 +76,7 @@ type ApplicationMetadata struct {
 	ApplicationID              string
 	QueueName                  string
 	User                       string
+	Groups                     []string
 	Tags                       map[string]string
 	TaskGroups                 []v1alpha1.TaskGroup
 	OwnerReferences            []metav1.OwnerReference
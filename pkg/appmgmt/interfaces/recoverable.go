@@ -19,6 +19,9 @@
 package interfaces
 
 import (
+	"fmt"
+	"time"
+
 	"k8s.io/api/core/v1"
 
 	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
@@ -41,3 +44,15 @@ type Recoverable interface {
 	// for a given pod, return an allocation if found
 	GetExistingAllocation(pod *v1.Pod) *si.Allocation
 }
+
+// RecoveryTimeoutError is returned when the recovery of the apps or the nodes does not finish in time,
+// the objects recovered so far are known by the scheduler core.
+type RecoveryTimeoutError struct {
+	// the kind of objects that were recovered: app or node
+	Object  string
+	Timeout time.Duration
+}
+
+func (e *RecoveryTimeoutError) Error() string {
+	return fmt.Sprintf("timeout waiting for %s recovery in %s", e.Object, e.Timeout.String())
+}
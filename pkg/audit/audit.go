@@ -0,0 +1,141 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package audit
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// the actions on the allocations that are audited
+const (
+	ActionAllocate = "allocate"
+	ActionBind     = "bind"
+	ActionRelease  = "release"
+	ActionPreempt  = "preempt"
+)
+
+// the admin operations that are audited, these apply to an application or to the scheduler and not to a single pod
+const (
+	ActionForceComplete    = "force-complete"
+	ActionForceFail        = "force-fail"
+	ActionMaintenanceStart = "maintenance-start"
+	ActionMaintenanceEnd   = "maintenance-end"
+	ActionResync           = "resync"
+	ActionNamespaceRefresh = "namespace-refresh"
+)
+
+// Record is an action the shim performed on the allocation of a pod, or an admin operation.
+// The records are written to the audit log as JSON lines.
+type Record struct {
+	Time            time.Time        `json:"time"`
+	Action          string           `json:"action"`
+	ApplicationID   string           `json:"applicationID"`
+	TaskID          string           `json:"taskID"`
+	Namespace       string           `json:"namespace"`
+	PodName         string           `json:"podName"`
+	PodUID          string           `json:"podUID"`
+	Queue           string           `json:"queue,omitempty"`
+	Partition       string           `json:"partition,omitempty"`
+	NodeID          string           `json:"nodeID,omitempty"`
+	AllocationUUID  string           `json:"allocationUUID,omitempty"`
+	Placeholder     bool             `json:"placeholder,omitempty"`
+	TerminationType string           `json:"terminationType,omitempty"`
+	Resource        map[string]int64 `json:"resource,omitempty"`
+	// when the pod was created and how long it has held its allocation, set on releases
+	PodCreationTime *time.Time `json:"podCreationTime,omitempty"`
+	AllocatedTime   *time.Time `json:"allocatedTime,omitempty"`
+	DurationSeconds float64    `json:"durationSeconds,omitempty"`
+	// why the admin operation was performed, who requested it, and the state of the application before it
+	Reason    string `json:"reason,omitempty"`
+	Caller    string `json:"caller,omitempty"`
+	FromState string `json:"fromState,omitempty"`
+}
+
+var current = struct {
+	writer *rotatingWriter
+	sync.Mutex
+}{}
+
+// Start writes the audit log to the file, the file is rotated when it grows beyond maxSize bytes
+// and the maxBackups most recent rotated files are kept. Any audit log already open is closed.
+func Start(path string, maxSize int64, maxBackups int) error {
+	writer, err := newRotatingWriter(path, maxSize, maxBackups)
+	if err != nil {
+		return err
+	}
+	Stop()
+	current.Lock()
+	defer current.Unlock()
+	current.writer = writer
+	log.Logger().Info("writing the allocation audit log",
+		zap.String("path", path),
+		zap.Int64("maxSize", maxSize),
+		zap.Int("maxBackups", maxBackups))
+	return nil
+}
+
+// Stop closes the audit log, the records are dropped until it is started again
+func Stop() {
+	current.Lock()
+	defer current.Unlock()
+	if current.writer == nil {
+		return
+	}
+	if err := current.writer.Close(); err != nil {
+		log.Logger().Warn("failed to close the audit log", zap.Error(err))
+	}
+	current.writer = nil
+}
+
+// Enabled returns true when the audit log is written
+func Enabled() bool {
+	current.Lock()
+	defer current.Unlock()
+	return current.writer != nil
+}
+
+// Log writes the record to the audit log. The record is written before Log returns so that
+// no record is lost when the shim exits, a write error is logged and the record is dropped.
+func Log(record *Record) {
+	current.Lock()
+	defer current.Unlock()
+	if current.writer == nil {
+		return
+	}
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		log.Logger().Warn("failed to encode the audit record", zap.Error(err))
+		return
+	}
+	if _, err = current.writer.Write(append(line, '\n')); err != nil {
+		log.Logger().Warn("failed to write the audit record",
+			zap.String("action", record.Action),
+			zap.String("podUID", record.PodUID),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,108 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func readRecords(t *testing.T, path string) []*Record {
+	f, err := os.Open(path)
+	assert.NilError(t, err)
+	defer f.Close()
+	records := make([]*Record, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		record := &Record{}
+		assert.NilError(t, json.Unmarshal(scanner.Bytes(), record))
+		records = append(records, record)
+	}
+	assert.NilError(t, scanner.Err())
+	return records
+}
+
+func TestLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	// nothing is written before the audit log is started
+	Log(&Record{Action: ActionAllocate})
+	assert.Assert(t, !Enabled())
+
+	assert.NilError(t, Start(path, 1024*1024, 1))
+	defer Stop()
+	assert.Assert(t, Enabled())
+	allocated := time.Unix(1000, 0).UTC()
+	Log(&Record{
+		Action:         ActionAllocate,
+		ApplicationID:  "app-1",
+		TaskID:         "task-1",
+		Namespace:      "default",
+		PodName:        "pod-1",
+		PodUID:         "uid-1",
+		Queue:          "root.a",
+		NodeID:         "node-1",
+		AllocationUUID: "alloc-1",
+		Resource:       map[string]int64{"memory": 100, "vcore": 10},
+	})
+	Log(&Record{
+		Time:            allocated.Add(time.Minute),
+		Action:          ActionPreempt,
+		ApplicationID:   "app-1",
+		PodUID:          "uid-1",
+		TerminationType: "PREEMPTED_BY_SCHEDULER",
+		AllocatedTime:   &allocated,
+		DurationSeconds: 60,
+	})
+	Stop()
+	assert.Assert(t, !Enabled())
+	Log(&Record{Action: ActionRelease})
+
+	records := readRecords(t, path)
+	assert.Equal(t, len(records), 2)
+	assert.Equal(t, records[0].Action, ActionAllocate)
+	assert.Assert(t, !records[0].Time.IsZero(), "the time of the record was not set")
+	assert.Equal(t, records[0].Queue, "root.a")
+	assert.Equal(t, records[0].NodeID, "node-1")
+	assert.DeepEqual(t, records[0].Resource, map[string]int64{"memory": 100, "vcore": 10})
+	assert.Equal(t, records[1].Action, ActionPreempt)
+	assert.Assert(t, records[1].Time.Equal(allocated.Add(time.Minute)))
+	assert.Assert(t, records[1].AllocatedTime.Equal(allocated))
+	assert.Equal(t, records[1].DurationSeconds, float64(60))
+}
+
+func TestStartInvalidPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = Start(filepath.Join(dir, "missing", "audit.log"), 1024, 1)
+	assert.Assert(t, err != nil, "the audit log was started in a missing directory")
+	assert.Assert(t, !Enabled())
+}
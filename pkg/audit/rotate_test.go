@@ -0,0 +1,99 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package audit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func readFile(t *testing.T, path string) string {
+	content, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	return string(content)
+}
+
+func TestRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	w, err := newRotatingWriter(path, 10, 2)
+	assert.NilError(t, err)
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err = w.Write([]byte(line))
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, w.Close())
+
+	// each line exceeds the size with the previous one: only the two most recent backups are kept
+	assert.Equal(t, readFile(t, path), "fourth\n")
+	assert.Equal(t, readFile(t, path+".1"), "third\n")
+	assert.Equal(t, readFile(t, path+".2"), "second\n")
+	_, err = os.Stat(path + ".3")
+	assert.Assert(t, os.IsNotExist(err), "more backups than configured were kept")
+
+	// the size of an existing file counts when it is opened again
+	w, err = newRotatingWriter(path, 10, 2)
+	assert.NilError(t, err)
+	_, err = w.Write([]byte("fifth\n"))
+	assert.NilError(t, err)
+	assert.NilError(t, w.Close())
+	assert.Equal(t, readFile(t, path), "fifth\n")
+	assert.Equal(t, readFile(t, path+".1"), "fourth\n")
+	assert.Equal(t, readFile(t, path+".2"), "third\n")
+}
+
+func TestRotationWithoutBackups(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	w, err := newRotatingWriter(path, 10, 0)
+	assert.NilError(t, err)
+	for _, line := range []string{"first\n", "second\n"} {
+		_, err = w.Write([]byte(line))
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, w.Close())
+	assert.Equal(t, readFile(t, path), "second\n")
+	_, err = os.Stat(path + ".1")
+	assert.Assert(t, os.IsNotExist(err), "a backup was kept")
+}
+
+func TestWriteLargerThanMaxSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	// a record larger than the maximum size is still written, to a file of its own
+	w, err := newRotatingWriter(path, 4, 1)
+	assert.NilError(t, err)
+	_, err = w.Write([]byte("large record\n"))
+	assert.NilError(t, err)
+	assert.NilError(t, w.Close())
+	assert.Equal(t, readFile(t, path), "large record\n")
+}
@@ -0,0 +1,124 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/profiling"
+)
+
+const (
+	coreModule = "github.com/apache/incubator-yunikorn-core"
+	siModule   = "github.com/apache/incubator-yunikorn-scheduler-interface"
+	// the versions the shim is built against, used when the binary has no module information
+	defaultCoreVersion = "v0.12.1"
+	defaultSIVersion   = "v0.12.1"
+)
+
+// Info describes the build of the shim and the features it runs with, to detect the
+// deployments that mix incompatible versions of the shim and the core
+type Info struct {
+	Version                   string          `json:"version"`
+	BuildDate                 string          `json:"buildDate"`
+	GoVersion                 string          `json:"goVersion"`
+	SchedulerInterfaceVersion string          `json:"schedulerInterfaceVersion"`
+	CoreVersion               string          `json:"coreVersion"`
+	CompatibleCoreVersions    []string        `json:"compatibleCoreVersions"`
+	Features                  map[string]bool `json:"features"`
+}
+
+var build = struct {
+	version string
+	date    string
+	sync.RWMutex
+}{}
+
+// SetBuildInfo records the version and the build date the binary was built with
+func SetBuildInfo(version, date string) {
+	build.Lock()
+	defer build.Unlock()
+	build.version = version
+	build.date = date
+}
+
+// Get returns the build information and the features enabled in the current configuration
+func Get() *Info {
+	build.RLock()
+	info := &Info{
+		Version:                   build.version,
+		BuildDate:                 build.date,
+		GoVersion:                 runtime.Version(),
+		SchedulerInterfaceVersion: defaultSIVersion,
+		CoreVersion:               defaultCoreVersion,
+	}
+	build.RUnlock()
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range buildInfo.Deps {
+			if dep.Version == "" {
+				continue
+			}
+			switch dep.Path {
+			case siModule:
+				info.SchedulerInterfaceVersion = dep.Version
+			case coreModule:
+				info.CoreVersion = dep.Version
+			}
+		}
+	}
+	info.CompatibleCoreVersions = compatibleCoreVersions(info.SchedulerInterfaceVersion)
+	info.Features = getFeatures()
+	return info
+}
+
+// the core and the shim are compatible when they implement the same minor version of the
+// scheduler interface, the core is released with the scheduler interface
+func compatibleCoreVersions(siVersion string) []string {
+	parts := strings.SplitN(strings.TrimPrefix(siVersion, "v"), ".", 3)
+	if len(parts) < 2 {
+		return []string{}
+	}
+	return []string{parts[0] + "." + parts[1] + ".x"}
+}
+
+// the features the shim supports and whether they are enabled in the current configuration
+func getFeatures() map[string]bool {
+	configs := conf.GetSchedulerConf()
+	configs.RLock()
+	defer configs.RUnlock()
+	return map[string]bool{
+		"gangScheduling":       !configs.DisableGangScheduling,
+		"namespaceAnnotations": configs.EnableNamespaceAnnotations,
+		"configHotRefresh":     configs.EnableConfigHotRefresh,
+		"directConfigDelivery": configs.ConfigDelivery == conf.ConfigDeliveryDirect,
+		"dryRun":               configs.DryRun,
+		"eventSinks":           configs.EventSinks != "",
+		"tracing":              configs.TracingEndpoint != "",
+		"auditLog":             configs.AuditLogPath != "",
+		"profiling":            profiling.Enabled(),
+		"remoteCore":           configs.CoreServiceURL != "",
+		// the shim always runs as a separate scheduler and does not preempt pods
+		"preemption": false,
+		"pluginMode": false,
+	}
+}
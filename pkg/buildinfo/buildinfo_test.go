@@ -0,0 +1,53 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package buildinfo
+
+import (
+	"runtime"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+)
+
+func TestGet(t *testing.T) {
+	SetBuildInfo("0.12.0", "2021-10-01T00:00:00+0000")
+	defer SetBuildInfo("", "")
+	conf.GetSchedulerConf().DisableGangScheduling = true
+	defer func() { conf.GetSchedulerConf().DisableGangScheduling = false }()
+
+	info := Get()
+	assert.Equal(t, info.Version, "0.12.0")
+	assert.Equal(t, info.BuildDate, "2021-10-01T00:00:00+0000")
+	assert.Equal(t, info.GoVersion, runtime.Version())
+	assert.Assert(t, info.SchedulerInterfaceVersion != "")
+	assert.Assert(t, info.CoreVersion != "")
+	assert.Equal(t, len(info.CompatibleCoreVersions), 1)
+	assert.Assert(t, !info.Features["gangScheduling"])
+	assert.Assert(t, !info.Features["preemption"])
+	_, ok := info.Features["pluginMode"]
+	assert.Assert(t, ok)
+}
+
+func TestCompatibleCoreVersions(t *testing.T) {
+	assert.DeepEqual(t, compatibleCoreVersions("v0.12.1"), []string{"0.12.x"})
+	assert.DeepEqual(t, compatibleCoreVersions("v1.0.0-20210901120000-abcdef123456"), []string{"1.0.x"})
+	assert.DeepEqual(t, compatibleCoreVersions("(devel)"), []string{})
+}
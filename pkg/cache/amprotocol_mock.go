@@ -19,6 +19,7 @@ package cache
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt/interfaces"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
@@ -28,6 +29,7 @@ import (
 // implements ApplicationManagementProtocol
 type MockedAMProtocol struct {
 	applications map[string]*Application
+	lock         sync.RWMutex
 }
 
 func NewMockedAMProtocol() *MockedAMProtocol {
@@ -36,6 +38,8 @@ func NewMockedAMProtocol() *MockedAMProtocol {
 }
 
 func (m *MockedAMProtocol) GetApplication(appID string) interfaces.ManagedApp {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	if app, ok := m.applications[appID]; ok {
 		return app
 	}
@@ -43,7 +47,9 @@ func (m *MockedAMProtocol) GetApplication(appID string) interfaces.ManagedApp {
 }
 
 func (m *MockedAMProtocol) AddApplication(request *interfaces.AddApplicationRequest) interfaces.ManagedApp {
-	if app := m.GetApplication(request.Metadata.ApplicationID); app != nil {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if app, ok := m.applications[request.Metadata.ApplicationID]; ok {
 		return app
 	}
 
@@ -61,7 +67,9 @@ func (m *MockedAMProtocol) AddApplication(request *interfaces.AddApplicationRequ
 }
 
 func (m *MockedAMProtocol) RemoveApplication(appID string) error {
-	if app := m.GetApplication(appID); app != nil {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.applications[appID]; ok {
 		delete(m.applications, appID)
 		return nil
 	}
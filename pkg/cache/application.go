@@ -23,6 +23,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/looplab/fsm"
 	"go.uber.org/zap"
@@ -37,7 +38,9 @@ import (
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/dispatcher"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/eventsink"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
 	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/api"
 	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
 )
@@ -47,7 +50,9 @@ type Application struct {
 	queue                      string
 	partition                  string
 	user                       string
+	groups                     []string
 	taskMap                    map[string]*Task
+	taskLock                   *sync.RWMutex // protects the task map only, never held while taking another lock
 	tags                       map[string]string
 	schedulingPolicy           v1alpha1.SchedulingPolicy
 	taskGroups                 []v1alpha1.TaskGroup
@@ -58,12 +63,17 @@ type Application struct {
 	placeholderAsk             *si.Resource // total placeholder request for the app (all task groups)
 	placeholderTimeoutInSec    int64
 	schedulingStyle            string
+	placeholderImage           string
+	stateSince                 time.Time
+	provisioningRequest        string // the ProvisioningRequest of the gang, empty until the gang could not fit
+	submissionDenied           string // why the namespace denies the submission, the app is rejected instead
+	submissionCheck            func() (string, bool)
 }
 
 func (app *Application) String() string {
 	return fmt.Sprintf("applicationID: %s, queue: %s, partition: %s,"+
 		" totalNumOfTasks: %d, currentState: %s",
-		app.applicationID, app.queue, app.partition, len(app.taskMap), app.GetApplicationState())
+		app.applicationID, app.queue, app.partition, app.taskCount(), app.GetApplicationState())
 }
 
 func NewApplication(appID, queueName, user string, tags map[string]string, scheduler api.SchedulerAPI) *Application {
@@ -74,6 +84,7 @@ func NewApplication(appID, queueName, user string, tags map[string]string, sched
 		partition:               constants.DefaultPartition,
 		user:                    user,
 		taskMap:                 taskMap,
+		taskLock:                &sync.RWMutex{},
 		tags:                    tags,
 		schedulingPolicy:        v1alpha1.SchedulingPolicy{},
 		taskGroups:              make([]v1alpha1.TaskGroup, 0),
@@ -81,6 +92,7 @@ func NewApplication(appID, queueName, user string, tags map[string]string, sched
 		schedulerAPI:            scheduler,
 		placeholderTimeoutInSec: 0,
 		schedulingStyle:         constants.SchedulingPolicyStyleParamDefault,
+		stateSince:              time.Now(),
 	}
 
 	var states = events.States().Application
@@ -166,6 +178,11 @@ func NewApplication(appID, queueName, user string, tags map[string]string, sched
 	return app
 }
 
+// the logger of the application, the logs carry the correlation ID of the application
+func (app *Application) logger() *zap.Logger {
+	return log.Log(log.Cache).With(log.CorrelationID(app.applicationID, ""))
+}
+
 func (app *Application) handle(ev events.ApplicationEvent) error {
 	// Locking mechanism:
 	// 1) when handle event transitions, we first obtain the object's lock,
@@ -193,18 +210,16 @@ func (app *Application) canHandle(ev events.ApplicationEvent) bool {
 }
 
 func (app *Application) GetTask(taskID string) (interfaces.ManagedTask, error) {
-	app.lock.RLock()
-	defer app.lock.RUnlock()
-	if task, ok := app.taskMap[taskID]; ok {
+	if task, ok := app.getTask(taskID); ok {
 		return task, nil
 	}
 	return nil, fmt.Errorf("task %s doesn't exist in application %s",
 		taskID, app.applicationID)
 }
 
+// the application ID never changes, it is read without the lock so that creating the
+// tasks of the application does not wait for the handling of the application state
 func (app *Application) GetApplicationID() string {
-	app.lock.RLock()
-	defer app.lock.RUnlock()
 	return app.applicationID
 }
 
@@ -214,12 +229,24 @@ func (app *Application) GetQueue() string {
 	return app.queue
 }
 
+func (app *Application) getPartition() string {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	return app.partition
+}
+
 func (app *Application) GetUser() string {
 	app.lock.RLock()
 	defer app.lock.RUnlock()
 	return app.user
 }
 
+func (app *Application) setGroups(groups []string) {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	app.groups = groups
+}
+
 func (app *Application) setSchedulingPolicy(policy v1alpha1.SchedulingPolicy) {
 	app.lock.Lock()
 	defer app.lock.Unlock()
@@ -265,9 +292,34 @@ func (app *Application) setSchedulingStyle(schedulingStyle string) {
 	app.schedulingStyle = schedulingStyle
 }
 
-func (app *Application) addTask(task *Task) {
+func (app *Application) getSchedulingStyle() string {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	return app.schedulingStyle
+}
+
+func (app *Application) setPlaceholderImage(image string) {
 	app.lock.Lock()
 	defer app.lock.Unlock()
+	app.placeholderImage = image
+}
+
+// returns the image used by the placeholder pods of this app,
+// this falls back to the default image if it was not overridden for the app's namespace
+func (app *Application) getPlaceholderImage() string {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	if app.placeholderImage == "" {
+		return constants.PlaceholderContainerImage
+	}
+	return app.placeholderImage
+}
+
+// the tasks are added and removed under the task lock only: the tasks of a large application
+// are not serialized with the handling of the application state
+func (app *Application) addTask(task *Task) {
+	app.taskLock.Lock()
+	defer app.taskLock.Unlock()
 	if _, ok := app.taskMap[task.taskID]; ok {
 		// skip adding duplicate task
 		return
@@ -276,11 +328,12 @@ func (app *Application) addTask(task *Task) {
 }
 
 func (app *Application) removeTask(taskID string) error {
-	app.lock.Lock()
-	defer app.lock.Unlock()
-	if _, ok := app.taskMap[taskID]; ok {
-		delete(app.taskMap, taskID)
-		log.Logger().Info("task removed",
+	app.taskLock.Lock()
+	_, ok := app.taskMap[taskID]
+	delete(app.taskMap, taskID)
+	app.taskLock.Unlock()
+	if ok {
+		app.logger().Info("task removed",
 			zap.String("appID", app.applicationID),
 			zap.String("taskID", taskID))
 		return nil
@@ -294,30 +347,46 @@ func (app *Application) GetApplicationState() string {
 }
 
 func (app *Application) GetPendingTasks() []*Task {
-	app.lock.RLock()
-	defer app.lock.RUnlock()
 	return app.getTasks(events.States().Task.Pending)
 }
 
 func (app *Application) GetNewTasks() []*Task {
-	app.lock.RLock()
-	defer app.lock.RUnlock()
 	return app.getTasks(events.States().Task.New)
 }
 
 func (app *Application) GetAllocatedTasks() []*Task {
-	app.lock.RLock()
-	defer app.lock.RUnlock()
 	return app.getTasks(events.States().Task.Allocated)
 }
 
+func (app *Application) getTask(taskID string) (*Task, bool) {
+	app.taskLock.RLock()
+	defer app.taskLock.RUnlock()
+	task, ok := app.taskMap[taskID]
+	return task, ok
+}
+
+// returns a snapshot of the tasks, the state of the tasks is read after the task lock is released
+func (app *Application) getAllTasks() []*Task {
+	app.taskLock.RLock()
+	defer app.taskLock.RUnlock()
+	tasks := make([]*Task, 0, len(app.taskMap))
+	for _, task := range app.taskMap {
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
+func (app *Application) taskCount() int {
+	app.taskLock.RLock()
+	defer app.taskLock.RUnlock()
+	return len(app.taskMap)
+}
+
 func (app *Application) getTasks(state string) []*Task {
 	taskList := make([]*Task, 0)
-	if len(app.taskMap) > 0 {
-		for _, task := range app.taskMap {
-			if task.GetTaskState() == state {
-				taskList = append(taskList, task)
-			}
+	for _, task := range app.getAllTasks() {
+		if task.GetTaskState() == state {
+			taskList = append(taskList, task)
 		}
 	}
 
@@ -337,7 +406,7 @@ func (app *Application) GetTags() map[string]string {
 
 func (app *Application) getNonTerminatedTaskAlias() []string {
 	var nonTerminatedTaskAlias []string
-	for _, task := range app.taskMap {
+	for _, task := range app.getAllTasks() {
 		if !task.isTerminated() {
 			nonTerminatedTaskAlias = append(nonTerminatedTaskAlias, task.alias)
 		}
@@ -368,9 +437,12 @@ func (app *Application) Schedule() bool {
 	var states = events.States().Application
 	switch app.GetApplicationState() {
 	case states.New:
+		if !app.checkSubmission() {
+			return true
+		}
 		ev := NewSubmitApplicationEvent(app.GetApplicationID())
 		if err := app.handle(ev); err != nil {
-			log.Logger().Warn("failed to handle SUBMIT app event",
+			app.logger().Warn("failed to handle SUBMIT app event",
 				zap.Error(err))
 		}
 	case states.Accepted:
@@ -398,7 +470,7 @@ func (app *Application) Schedule() bool {
 			return false
 		}
 	default:
-		log.Logger().Debug("skipping scheduling application",
+		app.logger().Debug("skipping scheduling application",
 			zap.String("appState", app.GetApplicationState()),
 			zap.String("appID", app.GetApplicationID()),
 			zap.String("appState", app.GetApplicationState()))
@@ -419,11 +491,11 @@ func (app *Application) scheduleTasks(taskScheduleCondition func(t *Task) bool)
 					// something goes wrong when transit task to PENDING state,
 					// this should not happen because we already checked the state
 					// before calling the transition. Nowhere to go, just log the error.
-					log.Logger().Warn("init task failed", zap.Error(err))
+					app.logger().Warn("init task failed", zap.Error(err))
 				}
 			} else {
 				events.GetRecorder().Event(task.GetTaskPod(), v1.EventTypeWarning, "FailedScheduling", err.Error())
-				log.Logger().Debug("task is not ready for scheduling",
+				app.logger().Debug("task is not ready for scheduling",
 					zap.String("appID", task.applicationID),
 					zap.String("taskID", task.taskID),
 					zap.Error(err))
@@ -433,7 +505,11 @@ func (app *Application) scheduleTasks(taskScheduleCondition func(t *Task) bool)
 }
 
 func (app *Application) handleSubmitApplicationEvent(event *fsm.Event) {
-	log.Logger().Info("handle app submission",
+	if app.submissionDenied != "" {
+		app.rejectDeniedSubmission()
+		return
+	}
+	app.logger().Info("handle app submission",
 		zap.String("app", app.String()),
 		zap.String("clusterID", conf.GetSchedulerConf().ClusterID))
 	err := app.schedulerAPI.UpdateApplication(
@@ -444,7 +520,8 @@ func (app *Application) handleSubmitApplicationEvent(event *fsm.Event) {
 					QueueName:     app.queue,
 					PartitionName: app.partition,
 					Ugi: &si.UserGroupInformation{
-						User: app.user,
+						User:   app.user,
+						Groups: app.groups,
 					},
 					Tags:                         app.tags,
 					PlaceholderAsk:               app.placeholderAsk,
@@ -457,13 +534,13 @@ func (app *Application) handleSubmitApplicationEvent(event *fsm.Event) {
 
 	if err != nil {
 		// submission failed
-		log.Logger().Warn("failed to submit app", zap.Error(err))
+		app.logger().Warn("failed to submit app", zap.Error(err))
 		dispatcher.Dispatch(NewFailApplicationEvent(app.applicationID, err.Error()))
 	}
 }
 
 func (app *Application) handleRecoverApplicationEvent(event *fsm.Event) {
-	log.Logger().Info("handle app recovering",
+	app.logger().Info("handle app recovering",
 		zap.String("app", app.String()),
 		zap.String("clusterID", conf.GetSchedulerConf().ClusterID))
 	err := app.schedulerAPI.UpdateApplication(
@@ -474,7 +551,8 @@ func (app *Application) handleRecoverApplicationEvent(event *fsm.Event) {
 					QueueName:     app.queue,
 					PartitionName: app.partition,
 					Ugi: &si.UserGroupInformation{
-						User: app.user,
+						User:   app.user,
+						Groups: app.groups,
 					},
 					Tags:                         app.tags,
 					ExecutionTimeoutMilliSeconds: app.placeholderTimeoutInSec * 1000,
@@ -486,7 +564,7 @@ func (app *Application) handleRecoverApplicationEvent(event *fsm.Event) {
 
 	if err != nil {
 		// submission failed
-		log.Logger().Warn("failed to submit app", zap.Error(err))
+		app.logger().Warn("failed to submit app", zap.Error(err))
 		dispatcher.Dispatch(NewFailApplicationEvent(app.applicationID, err.Error()))
 	}
 }
@@ -494,7 +572,7 @@ func (app *Application) handleRecoverApplicationEvent(event *fsm.Event) {
 func (app *Application) skipReservationStage() bool {
 	// no task groups defined, skip reservation
 	if len(app.taskGroups) == 0 {
-		log.Logger().Debug("Skip reservation stage: no task groups defined",
+		app.logger().Debug("Skip reservation stage: no task groups defined",
 			zap.String("appID", app.applicationID))
 		return true
 	}
@@ -502,15 +580,13 @@ func (app *Application) skipReservationStage() bool {
 	// if there is any task already passed New state,
 	// that means the scheduler has already tried to schedule it
 	// in this case, we should skip the reservation stage
-	if len(app.taskMap) > 0 {
-		for _, task := range app.taskMap {
-			if task.GetTaskState() != events.States().Task.New {
-				log.Logger().Debug("Skip reservation stage: found task already has been scheduled before.",
-					zap.String("appID", app.applicationID),
-					zap.String("taskID", task.GetTaskID()),
-					zap.String("taskState", task.GetTaskState()))
-				return true
-			}
+	for _, task := range app.getAllTasks() {
+		if task.GetTaskState() != events.States().Task.New {
+			app.logger().Debug("Skip reservation stage: found task already has been scheduled before.",
+				zap.String("appID", app.applicationID),
+				zap.String("taskID", task.GetTaskID()),
+				zap.String("taskState", task.GetTaskState()))
+			return true
 		}
 	}
 	return false
@@ -522,17 +598,17 @@ func (app *Application) postAppAccepted() {
 	// app could have allocated tasks upon a recovery, and in that case,
 	// the reserving phase has already passed, no need to trigger that again.
 	var ev events.SchedulingEvent
-	log.Logger().Debug("postAppAccepted on cached app",
+	app.logger().Debug("postAppAccepted on cached app",
 		zap.String("appID", app.applicationID),
 		zap.Int("numTaskGroups", len(app.taskGroups)),
 		zap.Int("numAllocatedTasks", len(app.getTasks(events.States().Task.Allocated))))
 	if app.skipReservationStage() {
 		ev = NewRunApplicationEvent(app.applicationID)
-		log.Logger().Info("Skip the reservation stage",
+		app.logger().Info("Skip the reservation stage",
 			zap.String("appID", app.applicationID))
 	} else {
 		ev = NewSimpleApplicationEvent(app.applicationID, events.TryReserve)
-		log.Logger().Info("app has taskGroups defined, trying to reserve resources for gang members",
+		app.logger().Info("app has taskGroups defined, trying to reserve resources for gang members",
 			zap.String("appID", app.applicationID))
 	}
 	dispatcher.Dispatch(ev)
@@ -573,10 +649,10 @@ func (app *Application) onReservationStateChange(event *fsm.Event) {
 }
 
 func (app *Application) handleRejectApplicationEvent(event *fsm.Event) {
-	log.Logger().Info("app is rejected by scheduler", zap.String("appID", app.applicationID))
+	app.logger().Info("app is rejected by scheduler", zap.String("appID", app.applicationID))
 	eventArgs := make([]string, 1)
 	if err := events.GetEventArgsAsStrings(eventArgs, event.Args); err != nil {
-		log.Logger().Error("fail to parse event arg", zap.Error(err))
+		app.logger().Error("fail to parse event arg", zap.Error(err))
 		return
 	}
 	reason := eventArgs[0]
@@ -599,12 +675,11 @@ func failTaskPodWithReasonAndMsg(task *Task, reason string, msg string) {
 		Reason:  reason,
 		Message: msg,
 	}
-	log.Logger().Info("setting pod to failed", zap.String("podName", task.GetTaskPod().Name))
-	pod, err := task.UpdateTaskPodStatus(podCopy)
-	if err != nil {
-		log.Logger().Error("failed to update task pod status", zap.Error(err))
+	task.logger().Info("setting pod to failed", zap.String("podName", task.GetTaskPod().Name))
+	if err := task.UpdateTaskPodStatus(podCopy); err != nil {
+		task.logger().Error("failed to update task pod status", zap.Error(err))
 	} else {
-		log.Logger().Info("new pod status", zap.String("status", string(pod.Status.Phase)))
+		task.logger().Info("new pod status", zap.String("status", string(podCopy.Status.Phase)))
 	}
 }
 
@@ -614,11 +689,11 @@ func (app *Application) handleFailApplicationEvent(event *fsm.Event) {
 	}()
 	eventArgs := make([]string, 1)
 	if err := events.GetEventArgsAsStrings(eventArgs, event.Args); err != nil {
-		log.Logger().Error("fail to parse event arg", zap.Error(err))
+		app.logger().Error("fail to parse event arg", zap.Error(err))
 		return
 	}
 	errMsg := eventArgs[0]
-	log.Logger().Info("failApplication reason", zap.String("applicationID", app.applicationID), zap.String("errMsg", errMsg))
+	app.logger().Info("failApplication reason", zap.String("applicationID", app.applicationID), zap.String("errMsg", errMsg))
 	// unallocated task states include New, Pending and Scheduling
 	unalloc := app.getTasks(events.States().Task.New)
 	unalloc = append(unalloc, app.getTasks(events.States().Task.Pending)...)
@@ -639,24 +714,30 @@ func (app *Application) handleFailApplicationEvent(event *fsm.Event) {
 }
 
 func (app *Application) handleReleaseAppAllocationEvent(event *fsm.Event) {
-	eventArgs := make([]string, 2)
+	eventArgs := make([]string, 3)
 	if err := events.GetEventArgsAsStrings(eventArgs, event.Args); err != nil {
-		log.Logger().Error("fail to parse event arg", zap.Error(err))
+		app.logger().Error("fail to parse event arg", zap.Error(err))
 		return
 	}
 	allocUUID := eventArgs[0]
 	terminationTypeStr := eventArgs[1]
-	log.Logger().Info("try to release pod from application",
+	message := eventArgs[2]
+	app.logger().Info("try to release pod from application",
 		zap.String("appID", app.applicationID),
 		zap.String("allocationUUID", allocUUID),
 		zap.String("terminationType", terminationTypeStr))
 
-	for _, task := range app.taskMap {
+	for _, task := range app.getAllTasks() {
 		if task.allocationUUID == allocUUID {
+			// preempted pods are evicted, the allocation is released once the pod is gone
+			if terminationTypeStr == preemptedTerminationType {
+				task.preempt(app.queue, app.tags[constants.AppTagIntraAppPreemption] == "true", message)
+				continue
+			}
 			task.setTaskTerminationType(terminationTypeStr)
 			err := task.DeleteTaskPod(task.pod)
 			if err != nil {
-				log.Logger().Error("failed to release allocation from application", zap.Error(err))
+				app.logger().Error("failed to release allocation from application", zap.Error(err))
 			}
 		}
 	}
@@ -665,51 +746,60 @@ func (app *Application) handleReleaseAppAllocationEvent(event *fsm.Event) {
 func (app *Application) handleReleaseAppAllocationAskEvent(event *fsm.Event) {
 	eventArgs := make([]string, 2)
 	if err := events.GetEventArgsAsStrings(eventArgs, event.Args); err != nil {
-		log.Logger().Error("fail to parse event arg", zap.Error(err))
+		app.logger().Error("fail to parse event arg", zap.Error(err))
 		return
 	}
 	taskID := eventArgs[0]
 	terminationTypeStr := eventArgs[1]
-	log.Logger().Info("try to release pod from application",
+	app.logger().Info("try to release pod from application",
 		zap.String("appID", app.applicationID),
 		zap.String("taskID", taskID),
 		zap.String("terminationType", terminationTypeStr))
-	if task, ok := app.taskMap[taskID]; ok {
+	if task, ok := app.getTask(taskID); ok {
 		task.setTaskTerminationType(terminationTypeStr)
 		if task.IsPlaceholder() {
 			err := task.DeleteTaskPod(task.pod)
 			if err != nil {
-				log.Logger().Error("failed to release allocation ask from application", zap.Error(err))
+				app.logger().Error("failed to release allocation ask from application", zap.Error(err))
 			}
 		} else {
-			log.Logger().Warn("skip to release allocation ask, ask is not a placeholder",
+			app.logger().Warn("skip to release allocation ask, ask is not a placeholder",
 				zap.String("appID", app.applicationID),
 				zap.String("taskID", taskID))
 		}
 	} else {
-		log.Logger().Warn("task not found",
+		app.logger().Warn("task not found",
 			zap.String("appID", app.applicationID),
 			zap.String("taskID", taskID))
 	}
 }
 
 func (app *Application) handleAppTaskCompletedEvent(event *fsm.Event) {
-	for _, task := range app.taskMap {
+	for _, task := range app.getAllTasks() {
 		if task.placeholder && task.GetTaskState() != events.States().Task.Completed {
 			return
 		}
 	}
-	log.Logger().Info("Resuming completed, start to run the app",
+	app.logger().Info("Resuming completed, start to run the app",
 		zap.String("appID", app.applicationID))
 	dispatcher.Dispatch(NewRunApplicationEvent(app.applicationID))
 }
 
 func (app *Application) enterState(event *fsm.Event) {
-	log.Logger().Debug("shim app state transition",
+	app.logger().Debug("shim app state transition",
 		zap.String("app", app.applicationID),
 		zap.String("source", event.Src),
 		zap.String("destination", event.Dst),
 		zap.String("event", event.Event))
+	observeTransition(metrics.ObjectApplication, &app.stateSince, event, events.States().Application.Failed)
+	eventsink.Publish(&eventsink.LifecycleEvent{
+		Kind:          eventsink.KindApplication,
+		ApplicationID: app.applicationID,
+		Queue:         app.queue,
+		Event:         event.Event,
+		From:          event.Src,
+		To:            event.Dst,
+	})
 }
 
 func (app *Application) SetPlaceholderTimeout(timeout int64) {
@@ -717,3 +807,9 @@ func (app *Application) SetPlaceholderTimeout(timeout int64) {
 	defer app.lock.Unlock()
 	app.placeholderTimeoutInSec = timeout
 }
+
+func (app *Application) getPlaceholderTimeout() int64 {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	return app.placeholderTimeoutInSec
+}
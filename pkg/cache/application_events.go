@@ -228,6 +228,7 @@ type ReleaseAppAllocationEvent struct {
 	applicationID   string
 	allocationUUID  string
 	terminationType string
+	message         string
 	event           events.ApplicationEventType
 }
 
@@ -240,14 +241,26 @@ func NewReleaseAppAllocationEvent(appID string, allocTermination si.TerminationT
 	}
 }
 
+// the release of an allocation the core preempted, the message of the core is shown on the victim
+func NewPreemptAppAllocationEvent(appID string, uuid string, message string) ReleaseAppAllocationEvent {
+	return ReleaseAppAllocationEvent{
+		applicationID:   appID,
+		allocationUUID:  uuid,
+		terminationType: preemptedTerminationType,
+		message:         message,
+		event:           events.ReleaseAppAllocation,
+	}
+}
+
 func (re ReleaseAppAllocationEvent) GetApplicationID() string {
 	return re.applicationID
 }
 
 func (re ReleaseAppAllocationEvent) GetArgs() []interface{} {
-	args := make([]interface{}, 2)
+	args := make([]interface{}, 3)
 	args[0] = re.allocationUUID
 	args[1] = re.terminationType
+	args[2] = re.message
 	return args
 }
 
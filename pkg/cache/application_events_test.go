@@ -723,7 +723,7 @@ func TestReleaseAppAllocationEventGetArgs(t *testing.T) {
 		castOk                []bool
 		wantArg               []string
 	}{
-		{TestArgsName, "testAppId001", "testUUID001", si.TerminationType_TIMEOUT, 2, []bool{true, true}, []string{"testUUID001", "TIMEOUT"}},
+		{TestArgsName, "testAppId001", "testUUID001", si.TerminationType_TIMEOUT, 3, []bool{true, true, true}, []string{"testUUID001", "TIMEOUT", ""}},
 	}
 
 	for _, tt := range tests {
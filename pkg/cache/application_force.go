@@ -0,0 +1,110 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/audit"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
+)
+
+// the termination type of the allocations released when an application is forced to a terminal state
+var forcedTerminationType = si.TerminationType_name[int32(si.TerminationType_STOPPED_BY_RM)]
+
+// ForceApplicationState moves an application that is wedged to the Completed or the Failed state,
+// without going through the state machine. The asks and the allocations of the tasks that are not
+// terminated are released in the core, and the tasks are moved to the Completed state. The pods
+// are not deleted. The operation is written to the audit log with the caller that requested it.
+func (ctx *Context) ForceApplicationState(appID, state, reason, caller string) error {
+	appStates := events.States().Application
+	var action string
+	switch state {
+	case appStates.Completed:
+		action = audit.ActionForceComplete
+	case appStates.Failed:
+		action = audit.ActionForceFail
+	default:
+		return fmt.Errorf("application can only be forced to %s or %s, got %s",
+			appStates.Completed, appStates.Failed, state)
+	}
+	app, ok := ctx.getApplication(appID)
+	if !ok {
+		return fmt.Errorf("application %s is not found in the context", appID)
+	}
+
+	// the task lock cannot be taken while the application lock is held
+	app.lock.RLock()
+	from := app.sm.Current()
+	app.lock.RUnlock()
+	tasks := make([]*Task, 0)
+	for _, task := range app.getAllTasks() {
+		if !task.isTerminated() {
+			tasks = append(tasks, task)
+		}
+	}
+	if from == state {
+		return fmt.Errorf("application %s is already in state %s", appID, state)
+	}
+	now := time.Now()
+	for _, task := range tasks {
+		task.forceComplete(state, reason, now)
+	}
+	app.lock.Lock()
+	app.sm.SetState(state)
+	app.lock.Unlock()
+
+	log.Log(log.Cache).Warn("application forced to a terminal state",
+		zap.String("appID", appID),
+		zap.String("from", from),
+		zap.String("to", state),
+		zap.Int("releasedTasks", len(tasks)),
+		zap.String("reason", reason),
+		zap.String("caller", caller))
+	audit.Log(&audit.Record{
+		Time:          now,
+		Action:        action,
+		ApplicationID: appID,
+		Queue:         app.GetQueue(),
+		Partition:     app.getPartition(),
+		Reason:        reason,
+		Caller:        caller,
+		FromState:     from,
+	})
+	return nil
+}
+
+// releases the ask or the allocation of the task in the core and moves the task to the Completed state
+func (task *Task) forceComplete(appState, reason string, now time.Time) {
+	task.lock.Lock()
+	defer task.lock.Unlock()
+	task.terminationType = forcedTerminationType
+	task.releaseAllocation()
+	task.sm.SetState(events.States().Task.Completed)
+	task.endTrace(now, fmt.Errorf("application forced to %s", appState))
+	events.GetRecorder().Eventf(task.pod, v1.EventTypeWarning, "ApplicationForced",
+		"Application %s forced to %s, the allocation of task %s is released: %s",
+		task.applicationID, appState, task.alias, reason)
+}
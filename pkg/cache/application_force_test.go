@@ -0,0 +1,105 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"sync"
+	"testing"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
+)
+
+func TestForceApplicationState(t *testing.T) {
+	context := initContextForTest()
+	var lock sync.Mutex
+	var asksReleased, allocationsReleased []string
+	context.apiProvider.(*client.MockedAPIProvider).MockSchedulerAPIUpdateAllocationFn(func(request *si.AllocationRequest) error {
+		lock.Lock()
+		defer lock.Unlock()
+		for _, ask := range request.Releases.GetAllocationAsksToRelease() {
+			asksReleased = append(asksReleased, ask.Allocationkey)
+		}
+		for _, allocation := range request.Releases.GetAllocationsToRelease() {
+			allocationsReleased = append(allocationsReleased, allocation.UUID)
+			assert.Equal(t, allocation.TerminationType, si.TerminationType_STOPPED_BY_RM)
+		}
+		return nil
+	})
+	context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: "app01",
+			QueueName:     "root.a",
+			User:          "test-user",
+		},
+	})
+	taskStates := events.States().Task
+	addTask := func(taskID, state string) *Task {
+		task := context.AddTask(&interfaces.AddTaskRequest{
+			Metadata: interfaces.TaskMetadata{
+				ApplicationID: "app01",
+				TaskID:        taskID,
+				Pod: &v1.Pod{
+					ObjectMeta: apis.ObjectMeta{
+						Name:      "pod-" + taskID,
+						Namespace: "default",
+						UID:       types.UID(taskID),
+						Labels:    map[string]string{constants.LabelApplicationID: "app01"},
+					},
+				},
+			},
+		}).(*Task)
+		task.sm.SetState(state)
+		return task
+	}
+	pending := addTask("task01", taskStates.Pending)
+	bound := addTask("task02", taskStates.Bound)
+	bound.allocationUUID = "alloc-02"
+	done := addTask("task03", taskStates.Completed)
+	app := context.GetApplication("app01").(*Application)
+	app.sm.SetState(events.States().Application.Running)
+
+	err := context.ForceApplicationState("app01", events.States().Application.Killed, "wedged", "admin")
+	assert.ErrorContains(t, err, "application can only be forced to Completed or Failed, got Killed")
+	err = context.ForceApplicationState("unknown", events.States().Application.Failed, "wedged", "admin")
+	assert.ErrorContains(t, err, "application unknown is not found in the context")
+
+	assert.NilError(t, context.ForceApplicationState("app01", events.States().Application.Failed, "wedged", "admin"))
+	assert.Equal(t, app.GetApplicationState(), events.States().Application.Failed)
+	assert.Equal(t, pending.GetTaskState(), taskStates.Completed)
+	assert.Equal(t, bound.GetTaskState(), taskStates.Completed)
+	assert.Equal(t, done.GetTaskState(), taskStates.Completed)
+	lock.Lock()
+	assert.DeepEqual(t, asksReleased, []string{"task01"})
+	assert.DeepEqual(t, allocationsReleased, []string{"alloc-02"})
+	lock.Unlock()
+	// all the tasks are terminated, the application can be removed
+	assert.Equal(t, len(app.getNonTerminatedTaskAlias()), 0)
+
+	err = context.ForceApplicationState("app01", events.States().Application.Failed, "wedged", "admin")
+	assert.ErrorContains(t, err, "application app01 is already in state Failed")
+}
@@ -0,0 +1,148 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"sort"
+	"time"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+)
+
+// ApplicationInfo is the state of an application in the shim
+type ApplicationInfo struct {
+	ApplicationID string         `json:"applicationID"`
+	Queue         string         `json:"queue"`
+	Partition     string         `json:"partition"`
+	User          string         `json:"user"`
+	Groups        []string       `json:"groups,omitempty"`
+	State         string         `json:"state"`
+	StateSince    time.Time      `json:"stateSince"`
+	TaskStates    map[string]int `json:"taskStates"`
+	Tasks         []*TaskInfo    `json:"tasks,omitempty"`
+}
+
+// TaskInfo is the state of a task in the shim, the reason is set for a task that cannot be scheduled
+type TaskInfo struct {
+	TaskID        string    `json:"taskID"`
+	ApplicationID string    `json:"applicationID"`
+	Namespace     string    `json:"namespace"`
+	PodName       string    `json:"podName"`
+	State         string    `json:"state"`
+	StateSince    time.Time `json:"stateSince"`
+	NodeName      string    `json:"nodeName,omitempty"`
+	Placeholder   bool      `json:"placeholder,omitempty"`
+	TaskGroup     string    `json:"taskGroup,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+	Message       string    `json:"message,omitempty"`
+}
+
+// GetApplicationsInfo returns all the applications without their tasks, ordered by ID
+func (ctx *Context) GetApplicationsInfo() []*ApplicationInfo {
+	apps := ctx.listApplications()
+	infos := make([]*ApplicationInfo, 0, len(apps))
+	for _, app := range apps {
+		info, _ := app.getInfo()
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ApplicationID < infos[j].ApplicationID
+	})
+	return infos
+}
+
+// GetApplicationInfo returns the application with its tasks ordered by creation time,
+// nil when the application is not found
+func (ctx *Context) GetApplicationInfo(appID string) *ApplicationInfo {
+	app, ok := ctx.getApplication(appID)
+	if !ok {
+		return nil
+	}
+	info, tasks := app.getInfo()
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].createTime.Before(tasks[j].createTime)
+	})
+	info.Tasks = make([]*TaskInfo, 0, len(tasks))
+	for _, task := range tasks {
+		info.Tasks = append(info.Tasks, task.getInfo())
+	}
+	return info
+}
+
+// GetPodInfo returns the task of the pod, nil when the pod is not known by the shim
+func (ctx *Context) GetPodInfo(namespace, name string) *TaskInfo {
+	if task := ctx.findPodTask(namespace, name); task != nil {
+		return task.getInfo()
+	}
+	return nil
+}
+
+func (ctx *Context) findPodTask(namespace, name string) *Task {
+	for _, app := range ctx.listApplications() {
+		for _, task := range app.getAllTasks() {
+			if task.pod.Namespace == namespace && task.pod.Name == name {
+				return task
+			}
+		}
+	}
+	return nil
+}
+
+// returns the application without its tasks, and the tasks of the application
+func (app *Application) getInfo() (*ApplicationInfo, []*Task) {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	info := &ApplicationInfo{
+		ApplicationID: app.applicationID,
+		Queue:         app.queue,
+		Partition:     app.partition,
+		User:          app.user,
+		Groups:        app.groups,
+		State:         app.sm.Current(),
+		StateSince:    app.stateSince,
+		TaskStates:    make(map[string]int),
+	}
+	tasks := app.getAllTasks()
+	for _, task := range tasks {
+		info.TaskStates[task.GetTaskState()]++
+	}
+	return info, tasks
+}
+
+func (task *Task) getInfo() *TaskInfo {
+	task.lock.RLock()
+	defer task.lock.RUnlock()
+	info := &TaskInfo{
+		TaskID:        task.taskID,
+		ApplicationID: task.applicationID,
+		Namespace:     task.pod.Namespace,
+		PodName:       task.pod.Name,
+		State:         task.sm.Current(),
+		StateSince:    task.stateSince,
+		NodeName:      task.nodeName,
+		Placeholder:   task.placeholder,
+		TaskGroup:     task.taskGroupName,
+	}
+	states := events.States().Task
+	if recorded := task.getUnschedulable(); recorded != nil && (info.State == states.Pending || info.State == states.Scheduling) {
+		info.Reason = recorded.reason
+		info.Message = recorded.message
+	}
+	return info
+}
@@ -0,0 +1,97 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+)
+
+func TestGetApplicationInfo(t *testing.T) {
+	context := initContextForTest()
+	states := events.States().Task
+	for _, appID := range []string{"app02", "app01"} {
+		context.AddApplication(&interfaces.AddApplicationRequest{
+			Metadata: interfaces.ApplicationMetadata{
+				ApplicationID: appID,
+				QueueName:     "root.a",
+				User:          "test-user",
+			},
+		})
+	}
+	addTask := func(taskID, state string) *Task {
+		task := context.AddTask(&interfaces.AddTaskRequest{
+			Metadata: interfaces.TaskMetadata{
+				ApplicationID: "app01",
+				TaskID:        taskID,
+				Pod: &v1.Pod{
+					ObjectMeta: apis.ObjectMeta{
+						Name:      "pod-" + taskID,
+						Namespace: "default",
+						UID:       types.UID(taskID),
+					},
+				},
+			},
+		}).(*Task)
+		task.sm.SetState(state)
+		return task
+	}
+	bound := addTask("task01", states.Bound)
+	bound.nodeName = "node01"
+	pending := addTask("task02", states.Pending)
+	pending.createTime = bound.createTime.Add(time.Second)
+	pending.setUnschedulable(UnschedulableQuotaExceeded, "queue root.a is full")
+
+	apps := context.GetApplicationsInfo()
+	assert.Equal(t, len(apps), 2)
+	assert.Equal(t, apps[0].ApplicationID, "app01")
+	assert.Equal(t, apps[0].Queue, "root.a")
+	assert.DeepEqual(t, apps[0].TaskStates, map[string]int{states.Bound: 1, states.Pending: 1})
+	assert.Assert(t, apps[0].Tasks == nil)
+	assert.Equal(t, apps[1].ApplicationID, "app02")
+	assert.Equal(t, len(apps[1].TaskStates), 0)
+
+	info := context.GetApplicationInfo("app01")
+	assert.Assert(t, info != nil)
+	assert.Equal(t, len(info.Tasks), 2)
+	assert.Equal(t, info.Tasks[0].TaskID, "task01")
+	assert.Equal(t, info.Tasks[0].NodeName, "node01")
+	assert.Equal(t, info.Tasks[0].Reason, "")
+	assert.Equal(t, info.Tasks[1].TaskID, "task02")
+	assert.Equal(t, info.Tasks[1].Reason, UnschedulableQuotaExceeded)
+	assert.Assert(t, context.GetApplicationInfo("unknown") == nil)
+
+	pod := context.GetPodInfo("default", "pod-task02")
+	assert.Assert(t, pod != nil)
+	assert.Equal(t, pod.ApplicationID, "app01")
+	assert.Equal(t, pod.Message, "queue root.a is full")
+	assert.Assert(t, context.GetPodInfo("kube-system", "pod-task02") == nil)
+
+	// the reason is only reported while the task waits
+	pending.sm.SetState(states.Bound)
+	assert.Equal(t, context.GetPodInfo("default", "pod-task02").Reason, "")
+}
@@ -1077,3 +1077,36 @@ func TestResumingStateTransitions(t *testing.T) {
 	assert.NilError(t, err)
 	assertAppState(t, app, events.States().Application.Running, 3*time.Second)
 }
+
+func TestTasksNotBlockedByAppLock(t *testing.T) {
+	context := initContextForTest()
+	appID := "app00001"
+	app := NewApplication(appID, "root.a", "testuser", map[string]string{}, newMockSchedulerAPI())
+	context.applications[appID] = app
+
+	// the application lock is held like during the handling of an application event,
+	// the tasks can still be added, looked up and removed
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pod := newPodHelper("pod1", "default", "UID-00001", "", v1.PodPending)
+		app.addTask(NewTask("task01", app, context, pod))
+		if _, err := app.GetTask("task01"); err != nil {
+			t.Errorf("task not found: %v", err)
+		}
+		if count := len(app.GetNewTasks()); count != 1 {
+			t.Errorf("expected 1 new task, got %d", count)
+		}
+		if err := app.removeTask("task01"); err != nil {
+			t.Errorf("task not removed: %v", err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("task map access blocked by the application lock")
+	}
+	assert.Equal(t, app.taskCount(), 0)
+}
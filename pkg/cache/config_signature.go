@@ -0,0 +1,70 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+)
+
+// the comment line that carries the signature of a configuration update, the signature is a YAML
+// comment so that the core accepts the signed configuration as it is
+const configSignaturePrefix = "# signature: "
+
+// verifies the HMAC-SHA256 signature of the configuration with the configSigningKey of the config Secret.
+// The signature line must be the last line of the configuration, only followed by the line break. The
+// signature is computed over exactly the bytes before the signature line: nothing can be added to a signed
+// configuration without invalidating the signature.
+func verifyConfigSignature(config string) error {
+	key, ok := conf.GetSchedulerConf().GetSecretValue(conf.SecretConfigSignKey)
+	if !ok || len(key) == 0 {
+		return fmt.Errorf("a signed configuration is required, but no %s is set", conf.SecretConfigSignKey)
+	}
+	body := strings.TrimSuffix(config, "\n")
+	unsigned := body[:strings.LastIndex(body, "\n")+1]
+	last := body[len(unsigned):]
+	if !strings.HasPrefix(last, configSignaturePrefix) {
+		if strings.HasPrefix(config, configSignaturePrefix) || strings.Contains(config, "\n"+configSignaturePrefix) {
+			return fmt.Errorf("the signature must be the last line of the configuration")
+		}
+		return fmt.Errorf("the configuration is not signed")
+	}
+	if strings.HasPrefix(unsigned, configSignaturePrefix) || strings.Contains(unsigned, "\n"+configSignaturePrefix) {
+		return fmt.Errorf("the configuration has more than one signature")
+	}
+	expected, err := hex.DecodeString(strings.TrimSpace(strings.TrimPrefix(last, configSignaturePrefix)))
+	if err != nil {
+		return fmt.Errorf("the signature of the configuration is not valid: %v", err)
+	}
+	if !hmac.Equal(expected, signConfig(unsigned, key)) {
+		return fmt.Errorf("the signature of the configuration does not match")
+	}
+	return nil
+}
+
+func signConfig(config string, key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(config))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,92 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	ctx "context"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
+)
+
+func TestVerifyConfigSignature(t *testing.T) {
+	const config = "partitions:\n  - name: default\n"
+	key := []byte("signing-key")
+	signed := config + configSignaturePrefix + hex.EncodeToString(signConfig(config, key)) + "\n"
+
+	assert.ErrorContains(t, verifyConfigSignature(signed), "a signed configuration is required, but no configSigningKey is set")
+	conf.GetSchedulerConf().UpdateSecretValues(map[string][]byte{conf.SecretConfigSignKey: key})
+	defer conf.GetSchedulerConf().UpdateSecretValues(nil)
+
+	assert.NilError(t, verifyConfigSignature(signed))
+	assert.ErrorContains(t, verifyConfigSignature(config), "the configuration is not signed")
+	assert.ErrorContains(t, verifyConfigSignature("partitions: []\n"+configSignaturePrefix+"zz"), "the signature of the configuration is not valid")
+	assert.ErrorContains(t, verifyConfigSignature(signed+signed), "the configuration has more than one signature")
+	// the signed bytes end at the signature line, the line break before it is signed
+	assert.ErrorContains(t, verifyConfigSignature(config+"\n"+signed[len(config):]), "the signature of the configuration does not match")
+	assert.NilError(t, verifyConfigSignature(strings.TrimSuffix(signed, "\n")))
+}
+
+func TestVerifyConfigSignatureTrailingContent(t *testing.T) {
+	const config = "partitions:\n  - name: default\n"
+	key := []byte("signing-key")
+	signed := config + configSignaturePrefix + hex.EncodeToString(signConfig(config, key)) + "\n"
+	conf.GetSchedulerConf().UpdateSecretValues(map[string][]byte{conf.SecretConfigSignKey: key})
+	defer conf.GetSchedulerConf().UpdateSecretValues(nil)
+
+	// nothing may follow the signature, not even a comment or an empty line
+	for _, trailing := range []string{"  - name: tampered\n", "# a comment", "\n", "partitions: []\n"} {
+		assert.ErrorContains(t, verifyConfigSignature(signed+trailing), "the signature must be the last line of the configuration",
+			"trailing %q", trailing)
+	}
+	other := config + configSignaturePrefix + hex.EncodeToString(signConfig(config, []byte("other-key")))
+	assert.ErrorContains(t, verifyConfigSignature(other), "the signature of the configuration does not match")
+}
+
+func TestSaveConfigmapSignature(t *testing.T) {
+	context := initContextForTest()
+	configMaps, err := context.apiProvider.GetAPIs().ConfigMapInformer.Lister().List(nil)
+	assert.NilError(t, err, "No error expected")
+	clientSet := context.apiProvider.GetAPIs().KubeClient.GetClientSet()
+	_, err = clientSet.CoreV1().ConfigMaps(configMaps[0].Namespace).Create(ctx.Background(), configMaps[0], apis.CreateOptions{})
+	assert.NilError(t, err, "No error expected")
+	context.apiProvider.GetAPIs().Conf.RequireConfigSignature = true
+	defer func() { context.apiProvider.GetAPIs().Conf.RequireConfigSignature = false }()
+	key := []byte("signing-key")
+	conf.GetSchedulerConf().UpdateSecretValues(map[string][]byte{conf.SecretConfigSignKey: key})
+	defer conf.GetSchedulerConf().UpdateSecretValues(nil)
+
+	resp := context.SaveConfigmap(&si.UpdateConfigurationRequest{Configs: "newConfig"})
+	assert.Equal(t, resp.Success, false)
+	assert.Equal(t, resp.Reason, "the configuration is not signed")
+
+	// the line endings are normalised before the signature is verified
+	signed := "newConfig\r\n" + configSignaturePrefix + hex.EncodeToString(signConfig("newConfig\n", key))
+	resp = context.SaveConfigmap(&si.UpdateConfigurationRequest{Configs: signed})
+	assert.Equal(t, resp.Success, true, resp.Reason)
+	saved, err := clientSet.CoreV1().ConfigMaps(configMaps[0].Namespace).Get(ctx.Background(), configMaps[0].Name, apis.GetOptions{})
+	assert.NilError(t, err, "No error expected")
+	assert.Equal(t, saved.Data["queues.yaml"], "newConfig\n"+configSignaturePrefix+hex.EncodeToString(signConfig("newConfig\n", key)))
+}
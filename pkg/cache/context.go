@@ -19,15 +19,14 @@
 package cache
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/tools/cache"
 	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
@@ -41,6 +40,7 @@ import (
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/dispatcher"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/plugin/predicates"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/plugin/support"
 	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
@@ -49,11 +49,22 @@ import (
 // context maintains scheduling state, like apps and apps' tasks.
 type Context struct {
 	applications   map[string]*Application        // apps
+	appsLock       *sync.RWMutex                  // guards the apps for the readers without the context lock
 	nodes          *schedulerNodes                // nodes
 	schedulerCache *schedulercache.SchedulerCache // external cache
 	apiProvider    client.APIProvider             // apis to interact with api-server, scheduler-core, etc
 	predManager    predicates.PredicateManager    // K8s predicates
+	nodeStats      *nodeStatsTracker              // scheduling statistics per node
+	maintenance    *maintenance                   // scheduling paused by an admin
+	bindWorkers    *workerPool                    // bounds the bindings in flight
+	statusWriter   *podStatusWriter               // dedups and rate limits the pod status writes
+	scaleDown      *scaleDownProtection           // nodes annotated to keep the autoscaler from removing them
+	delayedSignals sync.Map                       // tasks whose unschedulable condition waits for the delay
 	lock           *sync.RWMutex                  // lock
+
+	queuesConfigPushed bool          // queue configuration is delivered to the core directly
+	appliedConfig      *v1.ConfigMap // scheduler ConfigMap the running configuration came from
+	appliedConfigTime  time.Time     // time the configuration was applied
 }
 
 // Create a new context for the scheduler.
@@ -66,6 +77,10 @@ func NewContext(apis client.APIProvider) *Context {
 	ctx := &Context{
 		applications: make(map[string]*Application),
 		apiProvider:  apis,
+		nodeStats:    newNodeStatsTracker(),
+		maintenance:  newMaintenance(),
+		scaleDown:    newScaleDownProtection(),
+		appsLock:     &sync.RWMutex{},
 		lock:         &sync.RWMutex{},
 	}
 
@@ -74,6 +89,12 @@ func NewContext(apis client.APIProvider) *Context {
 
 	// init the controllers and plugins (need the cache)
 	ctx.nodes = newSchedulerNodes(apis.GetAPIs().SchedulerAPI, ctx.schedulerCache)
+	ctx.nodes.maxStaleness = apis.GetAPIs().Conf.NodeUpdateMaxStaleness
+	ctx.statusWriter = newPodStatusWriter(apis.GetAPIs().Conf.PodStatusWriteInterval)
+	ctx.bindWorkers = newWorkerPool(func() int {
+		return scaledWorkers(apis.GetAPIs().Conf.BindWorkers, ctx.nodes.count(),
+			nodesPerBindWorker, minBindWorkers, maxBindWorkers)
+	})
 
 	// create the predicate manager
 	if !apis.IsTestingMode() {
@@ -83,10 +104,31 @@ func NewContext(apis client.APIProvider) *Context {
 		ctx.predManager = predicates.NewPredicateManager(support.NewFrameworkHandle(sharedLister, informerFactory, clientSet))
 	}
 
+	metrics.GetCacheMetrics().SetSource(metrics.CacheScheduler, ctx.schedulerCache.GetObjectCounts)
+	metrics.GetCacheMetrics().SetSource(metrics.CacheContext, ctx.getObjectCounts)
+	metrics.GetQueueMetrics().SetSource(ctx.getQueuePodCounts)
+	metrics.GetDemandMetrics().SetSource(ctx.GetPendingDemand)
+
 	return ctx
 }
 
+// returns the number of applications and tasks in the context
+func (ctx *Context) getObjectCounts() map[string]int {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	tasks := 0
+	for _, app := range ctx.applications {
+		tasks += app.taskCount()
+	}
+	return map[string]int{
+		"applications": len(ctx.applications),
+		"tasks":        tasks,
+	}
+}
+
 func (ctx *Context) AddSchedulingEventHandlers() {
+	ctx.bulkLoadCache()
+
 	ctx.apiProvider.AddEventHandler(&client.ResourceEventHandlers{
 		Type:     client.NodeInformerHandlers,
 		AddFn:    ctx.addNode,
@@ -117,17 +159,91 @@ func (ctx *Context) AddSchedulingEventHandlers() {
 		UpdateFn: ctx.updateConfigMaps,
 		DeleteFn: ctx.deleteConfigMaps,
 	})
+
+	if ctx.apiProvider.GetAPIs().NamespaceInformer != nil {
+		ctx.apiProvider.AddEventHandler(&client.ResourceEventHandlers{
+			Type:     client.NamespaceInformerHandlers,
+			UpdateFn: ctx.updateNamespace,
+		})
+	}
+
+	if ctx.apiProvider.GetAPIs().Conf.ConfigSecret != "" {
+		ctx.apiProvider.AddEventHandler(&client.ResourceEventHandlers{
+			Type:     client.SecretInformerHandlers,
+			AddFn:    ctx.addConfigSecret,
+			UpdateFn: ctx.updateConfigSecret,
+			DeleteFn: ctx.deleteConfigSecret,
+		})
+	}
+}
+
+// loads the nodes and pods known to the informers into the caches before the handlers are registered.
+// A handler registered on a started informer gets an add event for every object, these events find
+// the objects already cached and return early.
+func (ctx *Context) bulkLoadCache() {
+	// the mocked informers are not synced, the tests add the objects themselves
+	if ctx.apiProvider.IsTestingMode() {
+		return
+	}
+	apis := ctx.apiProvider.GetAPIs()
+	nodes, err := apis.NodeInformer.Lister().List(labels.Everything())
+	if err != nil {
+		log.Log(log.Cache).Warn("failed to list nodes, objects are added one at a time", zap.Error(err))
+		return
+	}
+	pods, err := apis.PodInformer.Lister().List(labels.Everything())
+	if err != nil {
+		log.Log(log.Cache).Warn("failed to list pods, objects are added one at a time", zap.Error(err))
+		return
+	}
+	ctx.loadCache(nodes, pods)
+}
+
+func (ctx *Context) loadCache(nodes []*v1.Node, pods []*v1.Pod) {
+	start := time.Now()
+	filtered := make([]*v1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		if ctx.filterPods(pod) {
+			filtered = append(filtered, pod)
+		}
+	}
+	added := ctx.schedulerCache.LoadNodesAndPods(nodes, filtered)
+	ctx.nodes.addNodes(nodes)
+	for _, node := range nodes {
+		events.GetRecorder().Eventf(node, v1.EventTypeNormal, "NodeAccepted",
+			fmt.Sprintf("node %s is accepted by the scheduler", node.Name))
+	}
+	log.Log(log.Cache).Info("loaded the nodes and pods into the cache",
+		zap.Int("nodes", len(nodes)),
+		zap.Int("pods", added),
+		zap.Duration("duration", time.Since(start)))
+}
+
+// returns true if the same version of the node is in both caches
+func (ctx *Context) isNodeCached(node *v1.Node) bool {
+	if node.ResourceVersion == "" {
+		return false
+	}
+	cached := ctx.schedulerCache.GetNode(node.Name)
+	if cached == nil || cached.Node() == nil || cached.Node().ResourceVersion != node.ResourceVersion {
+		return false
+	}
+	return ctx.nodes.getNode(node.Name) != nil
 }
 
 func (ctx *Context) addNode(obj interface{}) {
 	node, err := convertToNode(obj)
 	if err != nil {
-		log.Logger().Error("node conversion failed", zap.Error(err))
+		log.Log(log.Cache).Error("node conversion failed", zap.Error(err))
+		return
+	}
+	// loaded at startup, the informer replays the add
+	if ctx.isNodeCached(node) {
 		return
 	}
 
 	// add node to secondary scheduler cache
-	log.Logger().Debug("adding node to cache", zap.String("NodeName", node.Name))
+	log.Log(log.Cache).Debug("adding node to cache", zap.String("NodeName", node.Name))
 	ctx.schedulerCache.AddNode(node)
 
 	// add node to internal cache
@@ -142,21 +258,21 @@ func (ctx *Context) updateNode(oldObj, newObj interface{}) {
 	// we only trigger update when resource changes
 	oldNode, err := convertToNode(oldObj)
 	if err != nil {
-		log.Logger().Error("old node conversion failed",
+		log.Log(log.Cache).Error("old node conversion failed",
 			zap.Error(err))
 		return
 	}
 
 	newNode, err := convertToNode(newObj)
 	if err != nil {
-		log.Logger().Error("new node conversion failed",
+		log.Log(log.Cache).Error("new node conversion failed",
 			zap.Error(err))
 		return
 	}
 
 	// update secondary cache
 	if err := ctx.schedulerCache.UpdateNode(oldNode, newNode); err != nil {
-		log.Logger().Error("unable to update node in scheduler cache",
+		log.Log(log.Cache).Error("unable to update node in scheduler cache",
 			zap.Error(err))
 		return
 	}
@@ -174,24 +290,25 @@ func (ctx *Context) deleteNode(obj interface{}) {
 		var ok bool
 		node, ok = t.Obj.(*v1.Node)
 		if !ok {
-			log.Logger().Error("cannot convert to *v1.Node", zap.Any("object", t.Obj))
+			log.Log(log.Cache).Error("cannot convert to *v1.Node", zap.Any("object", t.Obj))
 			return
 		}
 	default:
-		log.Logger().Error("cannot convert to *v1.Node", zap.Any("object", t))
+		log.Log(log.Cache).Error("cannot convert to *v1.Node", zap.Any("object", t))
 		return
 	}
 
 	// delete node from secondary cache
-	log.Logger().Debug("delete node from cache", zap.String("nodeName", node.Name))
+	log.Log(log.Cache).Debug("delete node from cache", zap.String("nodeName", node.Name))
 	if err := ctx.schedulerCache.RemoveNode(node); err != nil {
-		log.Logger().Error("unable to delete node from scheduler cache",
+		log.Log(log.Cache).Error("unable to delete node from scheduler cache",
 			zap.Error(err))
 		return
 	}
 
 	// delete node from primary cache
 	ctx.nodes.deleteNode(node)
+	ctx.nodeStats.remove(node.Name)
 
 	// post the event
 	events.GetRecorder().Eventf(node, v1.EventTypeNormal, "NodeDeleted",
@@ -201,13 +318,18 @@ func (ctx *Context) deleteNode(obj interface{}) {
 func (ctx *Context) addPodToCache(obj interface{}) {
 	pod, err := utils.Convert2Pod(obj)
 	if err != nil {
-		log.Logger().Error("failed to add pod to cache", zap.Error(err))
+		log.Log(log.Cache).Error("failed to add pod to cache", zap.Error(err))
+		return
+	}
+	// loaded at startup, the informer replays the add
+	if cached, ok := ctx.schedulerCache.GetPod(string(pod.UID)); ok &&
+		pod.ResourceVersion != "" && cached.ResourceVersion == pod.ResourceVersion {
 		return
 	}
 
-	log.Logger().Debug("adding pod to cache", zap.String("podName", pod.Name))
+	log.Sampled(log.Cache).Debug("adding pod to cache", zap.String("podName", pod.Name))
 	if err := ctx.schedulerCache.AddPod(pod); err != nil {
-		log.Logger().Error("add pod to scheduler cache failed",
+		log.Log(log.Cache).Error("add pod to scheduler cache failed",
 			zap.String("podName", pod.Name),
 			zap.Error(err))
 	}
@@ -222,39 +344,59 @@ func (ctx *Context) removePodFromCache(obj interface{}) {
 		var ok bool
 		pod, ok = t.Obj.(*v1.Pod)
 		if !ok {
-			log.Logger().Error("Cannot convert to *v1.Pod", zap.Any("pod", obj))
+			log.Log(log.Cache).Error("Cannot convert to *v1.Pod", zap.Any("pod", obj))
 			return
 		}
 	default:
-		log.Logger().Error("Cannot convert to *v1.Pod", zap.Any("pod", obj))
+		log.Log(log.Cache).Error("Cannot convert to *v1.Pod", zap.Any("pod", obj))
 		return
 	}
 
-	log.Logger().Debug("removing pod from cache", zap.String("podName", pod.Name))
+	log.Sampled(log.Cache).Debug("removing pod from cache", zap.String("podName", pod.Name))
 	if err := ctx.schedulerCache.RemovePod(pod); err != nil {
-		log.Logger().Debug("failed to remove pod from scheduler cache",
+		log.Sampled(log.Cache).Debug("failed to remove pod from scheduler cache",
 			zap.String("podName", pod.Name),
 			zap.Error(err))
 	}
+	ctx.statusWriter.forget(pod)
 }
 
 func (ctx *Context) updatePodInCache(oldObj, newObj interface{}) {
 	oldPod, err := utils.Convert2Pod(oldObj)
 	if err != nil {
-		log.Logger().Error("failed to update pod in cache", zap.Error(err))
+		log.Log(log.Cache).Error("failed to update pod in cache", zap.Error(err))
 		return
 	}
 	newPod, err := utils.Convert2Pod(newObj)
 	if err != nil {
-		log.Logger().Error("failed to update pod in cache", zap.Error(err))
+		log.Log(log.Cache).Error("failed to update pod in cache", zap.Error(err))
+		return
+	}
+	if !utils.IsPodUpdateRelevant(oldPod, newPod) {
 		return
 	}
 
 	if err := ctx.schedulerCache.UpdatePod(oldPod, newPod); err != nil {
-		log.Logger().Debug("failed to update pod in cache",
+		log.Sampled(log.Cache).Debug("failed to update pod in cache",
 			zap.String("podName", oldPod.Name),
 			zap.Error(err))
 	}
+
+	if oldPod.Status.Phase != v1.PodRunning && newPod.Status.Phase == v1.PodRunning {
+		ctx.markTaskRunning(newPod)
+	}
+}
+
+// records the scheduling latency of the task of a pod that started running, ends tracking its binding
+func (ctx *Context) markTaskRunning(pod *v1.Pod) {
+	appID, err := utils.GetApplicationIDFromPod(pod)
+	if err != nil {
+		return
+	}
+	if task, err := ctx.getTask(appID, string(pod.UID)); err == nil {
+		task.markRunning(time.Now())
+		task.endBinding()
+	}
 }
 
 // filter pods by scheduler name and state
@@ -277,7 +419,7 @@ func (ctx *Context) filterPods(obj interface{}) bool {
 func (ctx *Context) filterConfigMaps(obj interface{}) bool {
 	switch obj := obj.(type) {
 	case *v1.ConfigMap:
-		return obj.Name == constants.DefaultConfigMapName
+		return obj.Name == constants.DefaultConfigMapName || isQueuesFragment(obj)
 	default:
 		return false
 	}
@@ -285,14 +427,20 @@ func (ctx *Context) filterConfigMaps(obj interface{}) bool {
 
 // when detects the configMap for the scheduler is added, trigger hot-refresh
 func (ctx *Context) addConfigMaps(obj interface{}) {
-	log.Logger().Debug("configMap added")
-	ctx.triggerReloadConfig()
+	log.Log(log.Cache).Debug("configMap added")
+	applyConfigLogLevels(nil, obj)
+	applyConfigProfiling(nil, obj)
+	if err := ctx.triggerReloadConfig(); err == nil {
+		ctx.recordAppliedConfig(obj)
+	}
 }
 
 // when detects the configMap for the scheduler is updated, trigger hot-refresh
 func (ctx *Context) updateConfigMaps(obj, newObj interface{}) {
+	applyConfigLogLevels(obj, newObj)
+	applyConfigProfiling(obj, newObj)
 	if ctx.apiProvider.GetAPIs().Conf.EnableConfigHotRefresh {
-		log.Logger().Debug("trigger scheduler to reload configuration")
+		log.Log(log.Cache).Debug("trigger scheduler to reload configuration")
 		// When update event is received, it is not guaranteed the data mounted to the pod
 		// is also updated. This is because the actual update in pod's volume is ensured
 		// by kubelet, kubelet is checking whether the mounted ConfigMap is fresh on every
@@ -302,24 +450,39 @@ func (ctx *Context) updateConfigMaps(obj, newObj interface{}) {
 		// We trigger configuration reload, on yunikorn-core side, it keeps checking config
 		// file state once this is called. And the actual reload happens when it detects
 		// actual changes on the content.
-		ctx.triggerReloadConfig()
+		// With the direct config delivery the content is pushed to the core and there is no delay.
+		if err := ctx.triggerReloadConfig(); err == nil {
+			ctx.recordAppliedConfig(newObj)
+		}
+		ctx.publishConfigChangeEvents(obj, newObj)
 	} else {
-		log.Logger().Warn("Skip to reload scheduler configuration")
+		log.Log(log.Cache).Warn("Skip to reload scheduler configuration")
 	}
 }
 
 // when detects the configMap for the scheduler is deleted, no operation needed here
 // we assume there will be a consequent add operation after delete, so we treat it like a update.
+// a deleted queues fragment removes its queues from the configuration, this needs a reload.
 func (ctx *Context) deleteConfigMaps(obj interface{}) {
-	log.Logger().Debug("configMap deleted")
+	log.Log(log.Cache).Debug("configMap deleted")
+	if configMap, ok := obj.(*v1.ConfigMap); ok && isQueuesFragment(configMap) &&
+		ctx.apiProvider.GetAPIs().Conf.EnableConfigHotRefresh {
+		_ = ctx.triggerReloadConfig()
+	}
 }
 
-func (ctx *Context) triggerReloadConfig() {
-	log.Logger().Info("trigger scheduler configuration reloading")
+func (ctx *Context) triggerReloadConfig() error {
+	log.Log(log.Cache).Info("trigger scheduler configuration reloading")
+	if err := ctx.pushMergedQueuesConfig(); err != nil {
+		log.Log(log.Cache).Error("failed to merge queues fragments, reloading the mounted configuration",
+			zap.Error(err))
+	}
 	clusterId := ctx.apiProvider.GetAPIs().Conf.ClusterID
 	if err := ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateConfiguration(clusterId); err != nil {
-		log.Logger().Error("reload configuration failed", zap.Error(err))
+		log.Log(log.Cache).Error("reload configuration failed", zap.Error(err))
+		return err
 	}
+	return nil
 }
 
 // evaluate given predicates based on current context
@@ -329,12 +492,15 @@ func (ctx *Context) IsPodFitNode(name, node string, allocate bool) error {
 		return nil
 	}
 
-	ctx.lock.RLock()
-	defer ctx.lock.RUnlock()
+	// the cached node infos are not modified, the predicates run without the context lock
 	if pod, ok := ctx.schedulerCache.GetPod(name); ok {
 		// if pod exists in cache, try to run predicates
 		if targetNode := ctx.schedulerCache.GetNode(node); targetNode != nil {
-			_, err := ctx.predManager.Predicates(pod, targetNode, allocate)
+			plugin, err := ctx.predManager.Predicates(pod, targetNode, allocate)
+			ctx.nodeStats.recordPredicates(node, err)
+			if err != nil {
+				ctx.setPredicateUnschedulable(pod, plugin, err)
+			}
 			return err
 		}
 	}
@@ -352,10 +518,10 @@ func (ctx *Context) bindPodVolumes(pod *v1.Pod) error {
 	// then here we just need to retrieve that value from cache, to skip bindings if volumes are already bound.
 	if assumedPod, exist := ctx.schedulerCache.GetPod(podKey); exist {
 		if ctx.schedulerCache.ArePodVolumesAllBound(podKey) {
-			log.Logger().Info("Binding Pod Volumes skipped: all volumes already bound",
+			log.Log(log.Cache).Info("Binding Pod Volumes skipped: all volumes already bound",
 				zap.String("podName", pod.Name))
 		} else {
-			log.Logger().Info("Binding Pod Volumes", zap.String("podName", pod.Name))
+			log.Log(log.Cache).Info("Binding Pod Volumes", zap.String("podName", pod.Name))
 			boundClaims, claimsToBind, _, err := ctx.apiProvider.GetAPIs().VolumeBinder.GetPodVolumes(assumedPod)
 			if err != nil {
 				return err
@@ -383,11 +549,9 @@ func (ctx *Context) AssumePod(name string, node string) error {
 	ctx.lock.Lock()
 	defer ctx.lock.Unlock()
 	if pod, ok := ctx.schedulerCache.GetPod(name); ok {
-		// when add assumed pod, we make a copy of the pod to avoid
-		// modifying its original reference. otherwise, it may have
-		// race when some other go-routines accessing it in parallel.
+		// the cache assumes a copy of the pod, the original reference is not modified.
+		// otherwise, it may have race when some other go-routines accessing it in parallel.
 		if targetNode := ctx.schedulerCache.GetNode(node); targetNode != nil {
-			assumedPod := pod.DeepCopy()
 			// assume pod volumes, this will update bindings info in cache
 			// assume pod volumes before assuming the pod
 			// this will update scheduler cache with essential PV/PVC binding info
@@ -395,7 +559,7 @@ func (ctx *Context) AssumePod(name string, node string) error {
 			// volume builder might be null in UTs
 			if ctx.apiProvider.GetAPIs().VolumeBinder != nil {
 				var err error
-				boundClaims, claimsToBind, _, err := ctx.apiProvider.GetAPIs().VolumeBinder.GetPodVolumes(assumedPod)
+				boundClaims, claimsToBind, _, err := ctx.apiProvider.GetAPIs().VolumeBinder.GetPodVolumes(pod)
 				if err != nil {
 					return err
 				}
@@ -409,8 +573,7 @@ func (ctx *Context) AssumePod(name string, node string) error {
 				}
 			}
 			// assign the node name for pod
-			assumedPod.Spec.NodeName = node
-			return ctx.schedulerCache.AssumePod(assumedPod, allBound)
+			return ctx.schedulerCache.AssumePodOnNode(pod, node, allBound)
 		}
 	}
 	return nil
@@ -423,18 +586,52 @@ func (ctx *Context) ForgetPod(name string) error {
 	defer ctx.lock.Unlock()
 
 	if pod, ok := ctx.schedulerCache.GetPod(name); ok {
-		log.Logger().Debug("forget pod", zap.String("pod", pod.Name))
+		log.Log(log.Cache).Debug("forget pod", zap.String("pod", pod.Name))
 		return ctx.schedulerCache.ForgetPod(pod)
 	}
-	log.Logger().Debug("unable to forget pod",
+	log.Log(log.Cache).Debug("unable to forget pod",
 		zap.String("reason", fmt.Sprintf("pod %s not found in scheduler cache", name)))
 	return nil
 }
 
+// the hot read paths look the applications up under the applications lock only, the context lock
+// is not taken. The map is changed in place, the writers hold the context lock and the applications lock.
+func (ctx *Context) getApplication(appID string) (*Application, bool) {
+	ctx.appsLock.RLock()
+	defer ctx.appsLock.RUnlock()
+	app, ok := ctx.applications[appID]
+	return app, ok
+}
+
+// returns the applications to iterate over without holding a lock
+func (ctx *Context) listApplications() []*Application {
+	ctx.appsLock.RLock()
+	defer ctx.appsLock.RUnlock()
+	apps := make([]*Application, 0, len(ctx.applications))
+	for _, app := range ctx.applications {
+		apps = append(apps, app)
+	}
+	return apps
+}
+
+// the context lock must be held
+func (ctx *Context) putApplication(app *Application) {
+	ctx.appsLock.Lock()
+	defer ctx.appsLock.Unlock()
+	ctx.applications[app.applicationID] = app
+}
+
+// the context lock must be held
+func (ctx *Context) removeApplication(appID string) {
+	ctx.appsLock.Lock()
+	defer ctx.appsLock.Unlock()
+	delete(ctx.applications, appID)
+}
+
 func (ctx *Context) UpdateApplication(app *Application) {
 	ctx.lock.Lock()
 	defer ctx.lock.Unlock()
-	ctx.applications[app.applicationID] = app
+	ctx.putApplication(app)
 }
 
 // inform the scheduler that the application is completed,
@@ -442,7 +639,7 @@ func (ctx *Context) UpdateApplication(app *Application) {
 // either way we need to release all allocations (if exists) for this application
 func (ctx *Context) NotifyApplicationComplete(appID string) {
 	if app := ctx.GetApplication(appID); app != nil {
-		log.Logger().Debug("NotifyApplicationComplete",
+		log.Log(log.Cache).Debug("NotifyApplicationComplete",
 			zap.String("appID", appID),
 			zap.String("currentAppState", app.GetApplicationState()))
 		ev := NewSimpleApplicationEvent(appID, events.CompleteApplication)
@@ -452,7 +649,7 @@ func (ctx *Context) NotifyApplicationComplete(appID string) {
 
 func (ctx *Context) NotifyApplicationFail(appID string) {
 	if app := ctx.GetApplication(appID); app != nil {
-		log.Logger().Debug("NotifyApplicationFail",
+		log.Log(log.Cache).Debug("NotifyApplicationFail",
 			zap.String("appID", appID),
 			zap.String("currentAppState", app.GetApplicationState()))
 		ev := NewSimpleApplicationEvent(appID, events.FailApplication)
@@ -461,11 +658,11 @@ func (ctx *Context) NotifyApplicationFail(appID string) {
 }
 
 func (ctx *Context) NotifyTaskComplete(appID, taskID string) {
-	log.Logger().Debug("NotifyTaskComplete",
+	log.Log(log.Cache).Debug("NotifyTaskComplete",
 		zap.String("appID", appID),
 		zap.String("taskID", taskID))
 	if app := ctx.GetApplication(appID); app != nil {
-		log.Logger().Debug("release allocation",
+		log.Log(log.Cache).Debug("release allocation",
 			zap.String("appID", appID),
 			zap.String("taskID", taskID))
 		ev := NewSimpleTaskEvent(appID, taskID, events.CompleteTask)
@@ -479,47 +676,96 @@ func (ctx *Context) NotifyTaskComplete(appID, taskID string) {
 // adds the following tags to the request based on annotations (if exist):
 //    - namespace.resourcequota
 //    - namespace.parentqueue
+//    - namespace.priority.offset
+//    - namespace.priority.fence
+//    - namespace.userquota
+//    - namespace.groupquota
 func (ctx *Context) updateApplicationTags(request *interfaces.AddApplicationRequest, namespace string) {
 	namespaceObj := ctx.getNamespaceObject(namespace)
 	if namespaceObj == nil {
 		return
 	}
+	for tag, value := range getNamespaceTags(namespaceObj) {
+		request.Metadata.Tags[tag] = value
+	}
+}
+
+// returns the app tags that come from the namespace annotations: the resource quota, the parent queue,
+// the priority offset and fence, and the user and group quotas
+func getNamespaceTags(namespaceObj *v1.Namespace) map[string]string {
+	tags := make(map[string]string)
 	// add resource quota info as an app tag
 	resourceQuota := utils.GetNamespaceQuotaFromAnnotation(namespaceObj)
 	if resourceQuota != nil && !common.IsZero(resourceQuota) {
 		if quotaStr, err := json.Marshal(resourceQuota); err == nil {
-			request.Metadata.Tags[constants.AppTagNamespaceResourceQuota] = string(quotaStr)
+			tags[constants.AppTagNamespaceResourceQuota] = string(quotaStr)
 		}
 	}
 	// add parent queue info as an app tag
 	parentQueue := namespaceObj.Annotations["yunikorn.apache.org/parentqueue"]
 	if parentQueue != "" {
-		request.Metadata.Tags[constants.AppTagNamespaceParentQueue] = parentQueue
+		tags[constants.AppTagNamespaceParentQueue] = parentQueue
+	}
+	for tag, value := range utils.GetNamespacePriorityTags(namespaceObj) {
+		tags[tag] = value
 	}
+	for tag, value := range utils.GetNamespaceUserQuotaTags(namespaceObj) {
+		tags[tag] = value
+	}
+	return tags
+}
+
+// apply the scheduling policy overrides from the namespace annotations to the app,
+// the namespace only changes the defaults: a placeholder timeout or a non-default
+// gang scheduling style set on the pod always takes precedence.
+func (ctx *Context) applyNamespaceSchedulingPolicy(app *Application, params *interfaces.SchedulingPolicyParameters, namespace string) {
+	namespaceObj := ctx.getNamespaceObject(namespace)
+	if namespaceObj == nil {
+		return
+	}
+	policy := utils.GetNamespaceSchedulingPolicy(namespaceObj)
+	if policy.GangSchedulingStyle != "" &&
+		(params == nil || params.GetGangSchedulingStyle() == constants.SchedulingPolicyStyleParamDefault) {
+		app.setSchedulingStyle(policy.GangSchedulingStyle)
+	}
+	if policy.PlaceholderTimeout > 0 && (params == nil || params.GetPlaceholderTimeout() == 0) {
+		app.SetPlaceholderTimeout(policy.PlaceholderTimeout)
+	}
+	if policy.PlaceholderImage != "" {
+		app.setPlaceholderImage(policy.PlaceholderImage)
+	}
+	log.Log(log.Cache).Debug("namespace scheduling policy applied",
+		zap.String("appID", app.applicationID),
+		zap.String("namespace", namespace),
+		zap.Any("policy", policy))
 }
 
 // returns the namespace object from the namespace's name
 // if the namespace is unable to be listed from api-server, a nil is returned
 func (ctx *Context) getNamespaceObject(namespace string) *v1.Namespace {
 	if namespace == "" {
-		log.Logger().Debug("could not get namespace from empty string")
+		log.Log(log.Cache).Debug("could not get namespace from empty string")
 		return nil
 	}
 
+	// namespaces are not watched when the namespace annotations are disabled
+	if ctx.apiProvider.GetAPIs().NamespaceInformer == nil {
+		return nil
+	}
 	nsLister := ctx.apiProvider.GetAPIs().NamespaceInformer.Lister()
 	namespaceObj, err := nsLister.Get(namespace)
 	if err != nil {
 		// every app should belong to a namespace,
 		// if we cannot list the namespace here, probably something is wrong
 		// log an error here and skip retrieving the resource quota
-		log.Logger().Error("failed to get app namespace", zap.Error(err))
+		log.Log(log.Cache).Error("failed to get app namespace", zap.Error(err))
 		return nil
 	}
 	return namespaceObj
 }
 
 func (ctx *Context) AddApplication(request *interfaces.AddApplicationRequest) interfaces.ManagedApp {
-	log.Logger().Debug("AddApplication", zap.Any("Request", request))
+	log.Log(log.Cache).Debug("AddApplication", zap.Any("Request", request))
 	if app := ctx.GetApplication(request.Metadata.ApplicationID); app != nil {
 		return app
 	}
@@ -528,7 +774,7 @@ func (ctx *Context) AddApplication(request *interfaces.AddApplicationRequest) in
 	defer ctx.lock.Unlock()
 
 	if ns, ok := request.Metadata.Tags[constants.AppTagNamespace]; ok {
-		log.Logger().Debug("app namespace info",
+		log.Log(log.Cache).Debug("app namespace info",
 			zap.String("appID", request.Metadata.ApplicationID),
 			zap.String("namespace", ns))
 		ctx.updateApplicationTags(request, ns)
@@ -540,25 +786,35 @@ func (ctx *Context) AddApplication(request *interfaces.AddApplicationRequest) in
 		request.Metadata.User,
 		request.Metadata.Tags,
 		ctx.apiProvider.GetAPIs().SchedulerAPI)
+	app.setGroups(request.Metadata.Groups)
 	app.setTaskGroups(request.Metadata.TaskGroups)
 	if request.Metadata.SchedulingPolicyParameters != nil {
 		app.SetPlaceholderTimeout(request.Metadata.SchedulingPolicyParameters.GetPlaceholderTimeout())
 		app.setSchedulingStyle(request.Metadata.SchedulingPolicyParameters.GetGangSchedulingStyle())
 	}
 	app.setOwnReferences(request.Metadata.OwnerReferences)
+	if ns, ok := request.Metadata.Tags[constants.AppTagNamespace]; ok {
+		ctx.applyNamespaceSchedulingPolicy(app, request.Metadata.SchedulingPolicyParameters, ns)
+		app.submissionCheck = func() (string, bool) {
+			return ctx.checkNamespaceQueueACL(app, ns)
+		}
+	}
 
 	// add into cache
-	ctx.applications[app.applicationID] = app
-	log.Logger().Info("app added",
+	ctx.putApplication(app)
+	log.Log(log.Cache).Info("app added",
 		zap.String("appID", app.applicationID))
 
 	return app
 }
 
+// GetKubeClient returns the client of the api-server
+func (ctx *Context) GetKubeClient() client.KubeClient {
+	return ctx.apiProvider.GetAPIs().KubeClient
+}
+
 func (ctx *Context) GetApplication(appID string) interfaces.ManagedApp {
-	ctx.lock.RLock()
-	defer ctx.lock.RUnlock()
-	if app, ok := ctx.applications[appID]; ok {
+	if app, ok := ctx.getApplication(appID); ok {
 		return app
 	}
 	return nil
@@ -577,10 +833,10 @@ func (ctx *Context) RemoveApplication(appID string) error {
 		// send the update request to scheduler core
 		rr := common.CreateUpdateRequestForRemoveApplication(app.applicationID, app.partition)
 		if err := ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateApplication(&rr); err != nil {
-			log.Logger().Error("failed to send remove application request to core", zap.Error(err))
+			log.Log(log.Cache).Error("failed to send remove application request to core", zap.Error(err))
 		}
-		delete(ctx.applications, appID)
-		log.Logger().Info("app removed",
+		ctx.removeApplication(appID)
+		log.Log(log.Cache).Info("app removed",
 			zap.String("appID", appID))
 
 		return nil
@@ -592,7 +848,7 @@ func (ctx *Context) RemoveApplicationInternal(appID string) error {
 	ctx.lock.Lock()
 	defer ctx.lock.Unlock()
 	if _, exist := ctx.applications[appID]; exist {
-		delete(ctx.applications, appID)
+		ctx.removeApplication(appID)
 		return nil
 	}
 	return fmt.Errorf("application %s is not found in the context", appID)
@@ -600,7 +856,7 @@ func (ctx *Context) RemoveApplicationInternal(appID string) error {
 
 // this implements ApplicationManagementProtocol
 func (ctx *Context) AddTask(request *interfaces.AddTaskRequest) interfaces.ManagedTask {
-	log.Logger().Debug("AddTask",
+	log.Log(log.Cache).Debug("AddTask",
 		zap.String("appID", request.Metadata.ApplicationID),
 		zap.String("taskID", request.Metadata.TaskID))
 	if managedApp := ctx.GetApplication(request.Metadata.ApplicationID); managedApp != nil {
@@ -609,7 +865,8 @@ func (ctx *Context) AddTask(request *interfaces.AddTaskRequest) interfaces.Manag
 			if err != nil {
 				task := NewFromTaskMeta(request.Metadata.TaskID, app, ctx, request.Metadata)
 				app.addTask(task)
-				log.Logger().Info("task added",
+				ctx.checkTaskSubmission(app, task)
+				log.Log(log.Cache).Info("task added",
 					zap.String("appID", app.applicationID),
 					zap.String("taskID", task.taskID),
 					zap.String("taskState", task.GetTaskState()))
@@ -623,18 +880,14 @@ func (ctx *Context) AddTask(request *interfaces.AddTaskRequest) interfaces.Manag
 }
 
 func (ctx *Context) RemoveTask(appID, taskID string) error {
-	ctx.lock.RLock()
-	defer ctx.lock.RUnlock()
-	if app, ok := ctx.applications[appID]; ok {
+	if app, ok := ctx.getApplication(appID); ok {
 		return app.removeTask(taskID)
 	}
 	return fmt.Errorf("application %s is not found in the context", appID)
 }
 
 func (ctx *Context) getTask(appID string, taskID string) (*Task, error) {
-	ctx.lock.RLock()
-	defer ctx.lock.RUnlock()
-	if app, ok := ctx.applications[appID]; ok {
+	if app, ok := ctx.getApplication(appID); ok {
 		if managedTask, err := app.GetTask(taskID); err == nil {
 			if task, valid := managedTask.(*Task); valid {
 				return task, nil
@@ -645,11 +898,8 @@ func (ctx *Context) getTask(appID string, taskID string) (*Task, error) {
 }
 
 func (ctx *Context) SelectApplications(filter func(app *Application) bool) []*Application {
-	ctx.lock.RLock()
-	defer ctx.lock.RUnlock()
-
 	apps := make([]*Application, 0)
-	for _, app := range ctx.applications {
+	for _, app := range ctx.listApplications() {
 		if filter != nil && !filter(app) {
 			continue
 		}
@@ -662,6 +912,8 @@ func (ctx *Context) SelectApplications(filter func(app *Application) bool) []*Ap
 func (ctx *Context) PublishEvents(eventRecords []*si.EventRecord) {
 	if len(eventRecords) > 0 {
 		for _, record := range eventRecords {
+			recordType := record.Type.String()
+			metrics.GetCoreEventMetrics().IncReceived(recordType)
 			switch record.Type {
 			case si.EventRecord_REQUEST:
 				taskID := record.ObjectID
@@ -669,33 +921,39 @@ func (ctx *Context) PublishEvents(eventRecords []*si.EventRecord) {
 				if task, err := ctx.getTask(appID, taskID); err == nil {
 					events.GetRecorder().Event(task.GetTaskPod(),
 						v1.EventTypeNormal, record.Reason, record.Message)
+					metrics.GetCoreEventMetrics().IncPublished(recordType)
 				} else {
-					log.Logger().Warn("task event is not published because task is not found",
+					log.Log(log.Cache).Warn("task event is not published because task is not found",
 						zap.String("appID", appID),
 						zap.String("taskID", taskID),
 						zap.String("event", record.String()))
+					metrics.GetCoreEventMetrics().IncDropped(recordType, metrics.EventDropTaskNotFound)
 				}
 			case si.EventRecord_NODE:
 				nodeID := record.ObjectID
 				nodeInfo := ctx.schedulerCache.GetNode(nodeID)
 				if nodeInfo == nil {
-					log.Logger().Warn("node event is not published because nodeInfo is not found",
+					log.Log(log.Cache).Warn("node event is not published because nodeInfo is not found",
 						zap.String("nodeID", nodeID),
 						zap.String("event", record.String()))
+					metrics.GetCoreEventMetrics().IncDropped(recordType, metrics.EventDropNodeNotFound)
 					continue
 				}
 				node := nodeInfo.Node()
 				if node == nil {
-					log.Logger().Warn("node event is not published because node is not found",
+					log.Log(log.Cache).Warn("node event is not published because node is not found",
 						zap.String("nodeID", nodeID),
 						zap.String("event", record.String()))
+					metrics.GetCoreEventMetrics().IncDropped(recordType, metrics.EventDropNodeNotFound)
 					continue
 				}
 				events.GetRecorder().Event(node,
 					v1.EventTypeNormal, record.Reason, record.Message)
+				metrics.GetCoreEventMetrics().IncPublished(recordType)
 			default:
-				log.Logger().Warn("Unsupported event type, currently only supports to publish request event records",
+				log.Log(log.Cache).Warn("Unsupported event type, currently only supports to publish request event records",
 					zap.String("type", record.Type.String()))
+				metrics.GetCoreEventMetrics().IncDropped(recordType, metrics.EventDropUnsupportedType)
 			}
 		}
 	}
@@ -708,20 +966,25 @@ func (ctx *Context) updatePodCondition(task *Task, podCondition *v1.PodCondition
 		// only update the pod when pod condition changes
 		// minimize the overhead added to the api-server/etcd
 		if !utils.PodUnderCondition(task.pod, podCondition) {
-			log.Logger().Debug("updating pod condition",
+			log.Log(log.Cache).Debug("updating pod condition",
 				zap.String("namespace", task.pod.Namespace),
 				zap.String("name", task.pod.Name),
 				zap.Any("podCondition", podCondition))
 			// call api-server to do the pod condition update
 			if podutil.UpdatePodCondition(&task.pod.Status, podCondition) {
+				if client.SkipMutation("updateStatus", "pods", task.pod.Namespace, task.pod.Name) {
+					return true
+				}
 				if !ctx.apiProvider.IsTestingMode() {
-					_, err := ctx.apiProvider.GetAPIs().KubeClient.GetClientSet().CoreV1().
-						Pods(task.pod.Namespace).UpdateStatus(context.Background(), task.pod, metav1.UpdateOptions{})
+					written, err := ctx.statusWriter.write(newPodConditionWrite(task.pod, podCondition, func(pod *v1.Pod) error {
+						_, err := client.ApplyPodCondition(ctx.apiProvider.GetAPIs().KubeClient.GetClientSet(), pod, podCondition)
+						return err
+					}))
 					if err == nil {
-						return true
+						return written
 					}
 					// only log the error here, no need to handle it if the update failed
-					log.Logger().Error("update pod condition failed",
+					log.Log(log.Cache).Error("update pod condition failed",
 						zap.Error(err))
 				}
 			}
@@ -740,54 +1003,55 @@ func (ctx *Context) HandleContainerStateUpdate(request *si.UpdateContainerSchedu
 		case si.UpdateContainerSchedulingStateRequest_SKIPPED:
 			// auto-scaler scans pods whose pod condition is PodScheduled=false && reason=Unschedulable
 			// if the pod is skipped because the queue quota has been exceed, we do not trigger the auto-scaling
-			if ctx.updatePodCondition(task,
-				&v1.PodCondition{
-					Type:    v1.PodScheduled,
-					Status:  v1.ConditionFalse,
-					Reason:  "SchedulingSkipped",
-					Message: request.Reason,
-				}) {
+			task.setUnschedulable(UnschedulableQuotaExceeded, request.Reason)
+			if ctx.updatePodCondition(task, ctx.unschedulableCondition(task, "SchedulingSkipped", request.Reason)) {
 				events.GetRecorder().Eventf(task.pod,
 					v1.EventTypeNormal, "PodUnschedulable",
 					"Task %s is skipped from scheduling because the queue quota has been exceed", task.alias)
 			}
 		case si.UpdateContainerSchedulingStateRequest_FAILED:
 			// set pod condition to Unschedulable in order to trigger auto-scaling
-			if ctx.updatePodCondition(task,
-				&v1.PodCondition{
-					Type:    v1.PodScheduled,
-					Status:  v1.ConditionFalse,
-					Reason:  v1.PodReasonUnschedulable,
-					Message: request.Reason,
-				}) {
-				events.GetRecorder().Eventf(task.pod,
-					v1.EventTypeNormal, "PodUnschedulable",
-					"Task %s is pending for the requested resources become available", task.alias)
-			}
+			task.setUnschedulable(UnschedulableInsufficientResources, request.Reason)
+			ctx.signalUnschedulable(task, request.Reason)
+			ctx.requestProvisioning(task)
 		default:
-			log.Logger().Warn("no handler for container scheduling state",
+			log.Log(log.Cache).Warn("no handler for container scheduling state",
 				zap.String("state", request.State.String()))
 		}
 	}
 }
 
+// the events that cannot be handled are not dropped silently,
+// these are kept in the dead-letter store of the dispatcher.
 func (ctx *Context) ApplicationEventHandler() func(obj interface{}) {
 	return func(obj interface{}) {
 		if event, ok := obj.(events.ApplicationEvent); ok {
 			managedApp := ctx.GetApplication(event.GetApplicationID())
 			if managedApp == nil {
-				log.Logger().Error("failed to handle application event",
-					zap.String("reason", "application not exist"))
+				dispatcher.AddDeadLetter(&dispatcher.DeadLetter{
+					EventType:     "app",
+					Event:         string(event.GetEvent()),
+					ApplicationID: event.GetApplicationID(),
+					Error:         "application not exist",
+				})
 				return
 			}
 
 			if app, ok := managedApp.(*Application); ok {
-				if app.canHandle(event) {
-					if err := app.handle(event); err != nil {
-						log.Logger().Error("failed to handle application event",
-							zap.String("event", string(event.GetEvent())),
-							zap.Error(err))
-					}
+				var err error
+				if !app.canHandle(event) {
+					err = fmt.Errorf("event not allowed in the application state")
+				} else {
+					err = app.handle(event)
+				}
+				if err != nil {
+					dispatcher.AddDeadLetter(&dispatcher.DeadLetter{
+						EventType:     "app",
+						Event:         string(event.GetEvent()),
+						ApplicationID: event.GetApplicationID(),
+						State:         app.GetApplicationState(),
+						Error:         err.Error(),
+					})
 				}
 			}
 		}
@@ -799,23 +1063,62 @@ func (ctx *Context) TaskEventHandler() func(obj interface{}) {
 		if event, ok := obj.(events.TaskEvent); ok {
 			task, err := ctx.getTask(event.GetApplicationID(), event.GetTaskID())
 			if err != nil {
-				log.Logger().Error("failed to handle application event", zap.Error(err))
+				dispatcher.AddDeadLetter(&dispatcher.DeadLetter{
+					EventType:     "task",
+					Event:         string(event.GetEvent()),
+					ApplicationID: event.GetApplicationID(),
+					TaskID:        event.GetTaskID(),
+					Error:         err.Error(),
+				})
 				return
 			}
 
-			if task.canHandle(event) {
-				if err = task.handle(event); err != nil {
-					log.Logger().Error("failed to handle task event",
-						zap.String("applicationID", task.applicationID),
-						zap.String("taskID", task.taskID),
-						zap.String("event", string(event.GetEvent())),
-						zap.Error(err))
-				}
+			if !task.canHandle(event) {
+				err = fmt.Errorf("event not allowed in the task state")
+			} else {
+				err = task.handle(event)
+			}
+			if err != nil {
+				dispatcher.AddDeadLetter(&dispatcher.DeadLetter{
+					EventType:     "task",
+					Event:         string(event.GetEvent()),
+					ApplicationID: task.applicationID,
+					TaskID:        task.taskID,
+					State:         task.GetTaskState(),
+					Error:         err.Error(),
+				})
 			}
 		}
 	}
 }
 
+// EventPanicHandler fails the task or the application of the event a handler panicked on, the
+// other applications are not affected. The failure events themselves are not retried.
+func (ctx *Context) EventPanicHandler() func(event events.SchedulingEvent, recovered interface{}) {
+	return func(event events.SchedulingEvent, recovered interface{}) {
+		message := fmt.Sprintf("event handler panicked: %v", recovered)
+		switch e := event.(type) {
+		case events.ApplicationStatusEvent:
+			// the status of the application CRD is updated again on the next state change
+			return
+		case events.TaskEvent:
+			if e.GetEvent() == events.TaskFail {
+				return
+			}
+			if task, err := ctx.getTask(e.GetApplicationID(), e.GetTaskID()); err == nil {
+				events.GetRecorder().Eventf(task.GetTaskPod(), v1.EventTypeWarning, "TaskFailed",
+					"%s failed, %s", task.alias, message)
+			}
+			dispatcher.Dispatch(NewFailTaskEvent(e.GetApplicationID(), e.GetTaskID(), message))
+		case events.ApplicationEvent:
+			if e.GetEvent() == events.FailApplication {
+				return
+			}
+			dispatcher.Dispatch(NewFailApplicationEvent(e.GetApplicationID(), message))
+		}
+	}
+}
+
 func (ctx *Context) SchedulerNodeEventHandler() func(obj interface{}) {
 	if ctx != nil && ctx.nodes != nil {
 		return ctx.nodes.schedulerNodeEventHandler()
@@ -848,6 +1151,17 @@ func (ctx *Context) SaveConfigmap(request *si.UpdateConfigurationRequest) *si.Up
 				"set enableConfigHotRefresh = false and restart the scheduler"),
 		}
 	}
+	newConfig := strings.ReplaceAll(request.Configs, "\r\n", "\n")
+	// the update API can be exposed through the core REST endpoint, only the signed updates are saved
+	if ctx.apiProvider.GetAPIs().Conf.RequireConfigSignature {
+		if err := verifyConfigSignature(newConfig); err != nil {
+			log.Log(log.Cache).Warn("configuration update rejected", zap.Error(err))
+			return &si.UpdateConfigurationResponse{
+				Success: false,
+				Reason:  err.Error(),
+			}
+		}
+	}
 	slt := labels.SelectorFromSet(labels.Set{constants.LabelApp: "yunikorn"})
 
 	configMaps, err := ctx.apiProvider.GetAPIs().ConfigMapInformer.Lister().List(slt)
@@ -865,18 +1179,40 @@ func (ctx *Context) SaveConfigmap(request *si.UpdateConfigurationRequest) *si.Up
 		}
 	}
 
-	newConfData := map[string]string{"queues.yaml": strings.ReplaceAll(request.Configs, "\r\n", "\n")}
-	newConf := ykconf.DeepCopy()
+	newConfData := map[string]string{"queues.yaml": newConfig}
 	oldConfData := ykconf.Data["queues.yaml"]
-	newConf.Data = newConfData
-	_, err = ctx.apiProvider.GetAPIs().KubeClient.GetClientSet().CoreV1().ConfigMaps(ykconf.Namespace).Update(context.Background(), newConf, metav1.UpdateOptions{})
+	if ctx.apiProvider.GetAPIs().Conf.ProtectQueuesWithApps {
+		if protected := ctx.getRemovedQueuesWithApps(oldConfData, newConfData["queues.yaml"]); len(protected) > 0 {
+			return &si.UpdateConfigurationResponse{
+				Success: false,
+				Reason: fmt.Sprintf("queues with running applications cannot be removed: %s",
+					strings.Join(protected, ", ")),
+			}
+		}
+	}
+	if client.SkipMutation("update", "configmaps", ykconf.Namespace, ykconf.Name) {
+		return &si.UpdateConfigurationResponse{
+			Success:   true,
+			OldConfig: oldConfData,
+		}
+	}
+	var updated *v1.ConfigMap
+	err = client.RetryOnTransientError("UpdateConfigMap", func() error {
+		var updateErr error
+		// only the queues are owned by the shim, the other keys of the configmap are not changed
+		updated, updateErr = client.ApplyConfigMapData(ctx.apiProvider.GetAPIs().KubeClient.GetClientSet(),
+			ykconf.Namespace, ykconf.Name, newConfData)
+		return updateErr
+	})
 	if err != nil {
 		return &si.UpdateConfigurationResponse{
 			Success: false,
 			Reason:  err.Error(),
 		}
 	}
-	log.Logger().Info("ConfigMap updated successfully")
+	// the core has already applied the configuration before it asks to save it
+	ctx.recordAppliedConfig(updated)
+	log.Log(log.Cache).Info("ConfigMap updated successfully")
 	return &si.UpdateConfigurationResponse{
 		Success:   true,
 		OldConfig: oldConfData,
@@ -0,0 +1,409 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/apache/incubator-yunikorn-core/pkg/common/configs"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/profiling"
+)
+
+// returns the key of the queue configuration in the scheduler ConfigMap
+func getQueuesConfigKey() string {
+	return conf.GetSchedulerConf().PolicyGroup + ".yaml"
+}
+
+// returns the object the config change events are published on: this is the scheduler pod
+// when the pod name and namespace are exposed via the downward API, otherwise the ConfigMap.
+func getConfigEventTarget(configMap *v1.ConfigMap) runtime.Object {
+	name := os.Getenv(constants.EnvSchedulerPodName)
+	namespace := os.Getenv(constants.EnvSchedulerPodNamespace)
+	if name == "" || namespace == "" {
+		return configMap
+	}
+	return &v1.ObjectReference{
+		Kind:       "Pod",
+		APIVersion: "v1",
+		Name:       name,
+		Namespace:  namespace,
+	}
+}
+
+// publish Kubernetes events that summarize the queue changes between the old and the new
+// version of the scheduler ConfigMap, this leaves an audit trail of the reloads in the cluster.
+func (ctx *Context) publishConfigChangeEvents(oldObj, newObj interface{}) {
+	oldConfigMap, ok := oldObj.(*v1.ConfigMap)
+	if !ok {
+		return
+	}
+	newConfigMap, ok := newObj.(*v1.ConfigMap)
+	if !ok {
+		return
+	}
+	key := getQueuesConfigKey()
+	target := getConfigEventTarget(newConfigMap)
+	oldConfig, err := conf.ParseQueuesConfig(oldConfigMap.Data[key])
+	if err != nil {
+		oldConfig = &conf.QueuesConfig{}
+	}
+	newConfig, err := conf.ParseQueuesConfig(newConfigMap.Data[key])
+	if err != nil {
+		log.Log(log.Cache).Warn("unable to summarize the configuration changes", zap.Error(err))
+		events.GetRecorder().Eventf(target, v1.EventTypeWarning, "ConfigParseFailure",
+			"scheduler configuration %s could not be parsed: %v", key, err)
+		return
+	}
+
+	diff := conf.DiffQueuesConfig(oldConfig, newConfig)
+	log.Log(log.Cache).Info("scheduler configuration changed",
+		zap.String("configMap", newConfigMap.Name),
+		zap.String("resourceVersion", newConfigMap.ResourceVersion),
+		zap.Strings("queuesAdded", diff.Added),
+		zap.Strings("queuesRemoved", diff.Removed),
+		zap.Strings("queuesChanged", diff.Changed))
+	if diff.IsEmpty() {
+		events.GetRecorder().Eventf(target, v1.EventTypeNormal, "ConfigReloaded",
+			"scheduler configuration %s reloaded without queue changes", key)
+		return
+	}
+	if len(diff.Added) > 0 {
+		events.GetRecorder().Eventf(target, v1.EventTypeNormal, "QueuesAdded",
+			"queues added: %s", strings.Join(diff.Added, ", "))
+	}
+	if len(diff.Removed) > 0 {
+		events.GetRecorder().Eventf(target, v1.EventTypeNormal, "QueuesRemoved",
+			"queues removed: %s", strings.Join(diff.Removed, ", "))
+	}
+	if len(diff.Changed) > 0 {
+		events.GetRecorder().Eventf(target, v1.EventTypeNormal, "QueueLimitsChanged",
+			"queue resources or limits changed: %s", strings.Join(diff.Changed, ", "))
+	}
+}
+
+// applies the log levels of the subsystems set in the scheduler ConfigMap. The levels are only
+// applied when they changed: a level changed through the web service is kept until then.
+func applyConfigLogLevels(oldObj, newObj interface{}) {
+	newConfigMap, ok := newObj.(*v1.ConfigMap)
+	if !ok || newConfigMap.Name != constants.DefaultConfigMapName {
+		return
+	}
+	newLevels := log.GetConfigLevels(newConfigMap.Data)
+	if oldConfigMap, ok := oldObj.(*v1.ConfigMap); ok {
+		if reflect.DeepEqual(log.GetConfigLevels(oldConfigMap.Data), newLevels) {
+			return
+		}
+	} else if len(newLevels) == 0 {
+		return
+	}
+	log.SetConfigLevels(newLevels)
+}
+
+// enables or disables the profiling endpoints as set in the scheduler ConfigMap, the value is
+// only applied when it changed: a change made through the web service is kept until then.
+func applyConfigProfiling(oldObj, newObj interface{}) {
+	newConfigMap, ok := newObj.(*v1.ConfigMap)
+	if !ok || newConfigMap.Name != constants.DefaultConfigMapName {
+		return
+	}
+	value, set := newConfigMap.Data[profiling.ConfigKey]
+	if oldConfigMap, ok := oldObj.(*v1.ConfigMap); ok {
+		if oldConfigMap.Data[profiling.ConfigKey] == value {
+			return
+		}
+	} else if !set {
+		return
+	}
+	if err := profiling.ApplyConfig(value); err != nil {
+		log.Log(log.Cache).Warn("profiling configuration not applied", zap.Error(err))
+	}
+}
+
+// delivers the queue configuration to the core: the core runs in the same process and uses the
+// loader on the next configuration reload, instead of reading the file mounted from the ConfigMap.
+var pushQueuesConfigToCore = func(content string) {
+	configs.SchedulerConfigLoader = func(policyGroup string) (*configs.SchedulerConfig, error) {
+		return configs.ParseAndValidateConfig([]byte(content))
+	}
+}
+
+// ConfigMaps with the fragment label contribute queue subtrees to the scheduler configuration
+func isQueuesFragment(configMap *v1.ConfigMap) bool {
+	return configMap.Labels[constants.LabelQueuesFragment] == "true"
+}
+
+// returns the namespace the scheduler runs in, exposed to the pod through the downward API
+func getSchedulerNamespace() string {
+	if namespace := os.Getenv(constants.EnvSchedulerPodNamespace); namespace != "" {
+		return namespace
+	}
+	return constants.DefaultSchedulerNamespace
+}
+
+// returns the queues fragments that are read from a trusted namespace. The ConfigMap informer watches all
+// the namespaces: a fragment is only merged from the scheduler namespace or an explicitly allowed namespace,
+// the users that can create ConfigMaps in their own namespace can not change the queues of the scheduler.
+func filterQueuesFragments(schedulerConf *conf.SchedulerConf, configMaps []*v1.ConfigMap) []*v1.ConfigMap {
+	schedulerNamespace := getSchedulerNamespace()
+	trusted := make([]*v1.ConfigMap, 0, len(configMaps))
+	for _, configMap := range configMaps {
+		if !schedulerConf.IsQueuesFragmentNamespace(configMap.Namespace, schedulerNamespace) {
+			log.Log(log.Cache).Warn("ignoring queues fragment from a namespace that is not allowed",
+				zap.String("namespace", configMap.Namespace),
+				zap.String("configMap", configMap.Name))
+			continue
+		}
+		trusted = append(trusted, configMap)
+	}
+	return trusted
+}
+
+// merge the queue fragments from all the labeled ConfigMaps into the queue configuration of
+// the scheduler ConfigMap and push the result to the core. Once the configuration has been
+// pushed the core no longer reads the mounted file, so the configuration is pushed on every
+// reload from then on, even when all the fragments are removed. With the direct delivery
+// mode the configuration is always pushed, which avoids waiting for kubelet to project the
+// updated ConfigMap into the mounted volume.
+func (ctx *Context) pushMergedQueuesConfig() error {
+	lister := ctx.apiProvider.GetAPIs().ConfigMapInformer.Lister()
+	fragmentMaps, err := lister.List(labels.SelectorFromSet(labels.Set{constants.LabelQueuesFragment: "true"}))
+	if err != nil {
+		return err
+	}
+	fragmentMaps = filterQueuesFragments(ctx.apiProvider.GetAPIs().Conf, fragmentMaps)
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+	direct := ctx.apiProvider.GetAPIs().Conf.ConfigDelivery == conf.ConfigDeliveryDirect
+	if len(fragmentMaps) == 0 && !direct && !ctx.queuesConfigPushed {
+		return nil
+	}
+
+	configMaps, err := lister.List(labels.SelectorFromSet(labels.Set{constants.LabelApp: "yunikorn"}))
+	if err != nil {
+		return err
+	}
+	ykconf, err := findYKConfigMap(configMaps)
+	if err != nil {
+		return err
+	}
+	key := getQueuesConfigKey()
+	content, ok := ykconf.Data[key]
+	if !ok {
+		return fmt.Errorf("key %s not found in ConfigMap %s", key, ykconf.Name)
+	}
+	// nothing to merge, push the content as is and leave the validation to the core
+	if len(fragmentMaps) == 0 {
+		log.Log(log.Cache).Info("pushing queue configuration to the core",
+			zap.String("configMap", ykconf.Name))
+		pushQueuesConfigToCore(content)
+		ctx.queuesConfigPushed = true
+		return nil
+	}
+	config, err := conf.ParseQueuesConfig(content)
+	if err != nil {
+		return err
+	}
+
+	fragments := make(map[string]*conf.QueuesFragment)
+	fragmentObjs := make(map[string]*v1.ConfigMap)
+	for _, configMap := range fragmentMaps {
+		name := fmt.Sprintf("%s/%s", configMap.Namespace, configMap.Name)
+		fragment, err := conf.ParseQueuesFragment(configMap.Data[key])
+		if err == nil && !ctx.apiProvider.GetAPIs().Conf.IsQueuesFragmentParent(fragment.Parent) {
+			err = fmt.Errorf("parent queue %s is not allowed", fragment.Parent)
+		}
+		if err != nil {
+			events.GetRecorder().Eventf(configMap, v1.EventTypeWarning, "QueuesFragmentRejected",
+				"queues fragment %s is not merged: %v", name, err)
+			continue
+		}
+		fragments[name] = fragment
+		fragmentObjs[name] = configMap
+	}
+	for name, err := range config.MergeQueuesFragments(fragments) {
+		events.GetRecorder().Eventf(fragmentObjs[name], v1.EventTypeWarning, "QueuesFragmentRejected",
+			"queues fragment %s is not merged: %v", name, err)
+		delete(fragments, name)
+	}
+
+	content, err = config.Marshal()
+	if err != nil {
+		return err
+	}
+	log.Log(log.Cache).Info("pushing merged queue configuration to the core",
+		zap.Int("mergedFragments", len(fragments)))
+	pushQueuesConfigToCore(content)
+	ctx.queuesConfigPushed = true
+	return nil
+}
+
+// LoadQueuesConfig delivers the queue configuration to the core before the shim registers,
+// the core loads the configuration as part of the registration.
+// This is a no-op when the core reads the configuration from the mounted file.
+func (ctx *Context) LoadQueuesConfig() error {
+	return ctx.pushMergedQueuesConfig()
+}
+
+// the sensitive configuration values are read from the config Secret, changes in the Secret
+// are applied immediately, the consumers of the values are notified through the conf listeners.
+func (ctx *Context) addConfigSecret(obj interface{}) {
+	if secret, ok := obj.(*v1.Secret); ok {
+		ctx.applyConfigSecret(secret.Data)
+	}
+}
+
+func (ctx *Context) updateConfigSecret(_, newObj interface{}) {
+	if secret, ok := newObj.(*v1.Secret); ok {
+		ctx.applyConfigSecret(secret.Data)
+	}
+}
+
+func (ctx *Context) deleteConfigSecret(obj interface{}) {
+	log.Log(log.Cache).Warn("config Secret deleted, removing the sensitive configuration values",
+		zap.String("secret", ctx.apiProvider.GetAPIs().Conf.ConfigSecret))
+	ctx.applyConfigSecret(nil)
+}
+
+func (ctx *Context) applyConfigSecret(data map[string][]byte) {
+	// only the keys are logged, never the values
+	changed, ignored := ctx.apiProvider.GetAPIs().Conf.UpdateSecretValues(data)
+	if len(ignored) > 0 {
+		log.Log(log.Cache).Warn("ignoring unknown keys in the config Secret", zap.Strings("keys", ignored))
+	}
+	if len(changed) > 0 {
+		log.Log(log.Cache).Info("sensitive configuration values updated", zap.Strings("keys", changed))
+	}
+}
+
+// ConfigState describes the scheduler ConfigMap version the running configuration came from,
+// and whether a newer version exists that has not been applied.
+type ConfigState struct {
+	ConfigMap              string            `json:"configMap"`
+	HotRefreshEnabled      bool              `json:"hotRefreshEnabled"`
+	AppliedResourceVersion string            `json:"appliedResourceVersion"`
+	AppliedTime            *time.Time        `json:"appliedTime,omitempty"`
+	AppliedData            map[string]string `json:"appliedData"`
+	LatestResourceVersion  string            `json:"latestResourceVersion"`
+	Drift                  bool              `json:"drift"`
+}
+
+// remember the version of the scheduler ConfigMap that was applied by the core
+func (ctx *Context) recordAppliedConfig(obj interface{}) {
+	configMap, ok := obj.(*v1.ConfigMap)
+	if !ok || isQueuesFragment(configMap) {
+		return
+	}
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+	ctx.appliedConfig = configMap.DeepCopy()
+	ctx.appliedConfigTime = time.Now()
+}
+
+// GetConfigState compares the applied scheduler ConfigMap with the latest version known
+// by the informer, a drift means the ConfigMap was changed but the change was not applied.
+func (ctx *Context) GetConfigState() *ConfigState {
+	state := &ConfigState{
+		ConfigMap:         constants.DefaultConfigMapName,
+		HotRefreshEnabled: ctx.apiProvider.GetAPIs().Conf.EnableConfigHotRefresh,
+	}
+	configMaps, err := ctx.apiProvider.GetAPIs().ConfigMapInformer.Lister().List(
+		labels.SelectorFromSet(labels.Set{constants.LabelApp: "yunikorn"}))
+	if err == nil {
+		if latest, err := findYKConfigMap(configMaps); err == nil {
+			state.LatestResourceVersion = latest.ResourceVersion
+		}
+	}
+
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	if ctx.appliedConfig != nil {
+		appliedTime := ctx.appliedConfigTime
+		state.AppliedResourceVersion = ctx.appliedConfig.ResourceVersion
+		state.AppliedTime = &appliedTime
+		state.AppliedData = ctx.appliedConfig.Data
+	}
+	state.Drift = state.LatestResourceVersion != state.AppliedResourceVersion
+	return state
+}
+
+// returns the queues removed by the new queue configuration which still have applications
+// that are not finished, removing these queues would leave the applications stranded in the core.
+// The check is skipped when either configuration cannot be parsed, the core validates the content.
+func (ctx *Context) getRemovedQueuesWithApps(oldContent, newContent string) []string {
+	oldConfig, err := conf.ParseQueuesConfig(oldContent)
+	if err != nil {
+		log.Log(log.Cache).Warn("skipping the removed queues check, current configuration cannot be parsed",
+			zap.Error(err))
+		return nil
+	}
+	newConfig, err := conf.ParseQueuesConfig(newContent)
+	if err != nil {
+		log.Log(log.Cache).Warn("skipping the removed queues check, new configuration cannot be parsed",
+			zap.Error(err))
+		return nil
+	}
+	removed := conf.DiffQueuesConfig(oldConfig, newConfig).Removed
+	if len(removed) == 0 {
+		return nil
+	}
+	removedSet := make(map[string]bool, len(removed))
+	for _, queue := range removed {
+		removedSet[strings.ToLower(queue)] = true
+	}
+
+	states := events.States().Application
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	protected := make(map[string]bool)
+	for _, app := range ctx.applications {
+		switch app.GetApplicationState() {
+		case states.Completed, states.Killed, states.Failed, states.Rejected:
+			continue
+		}
+		name := app.GetQueue()
+		if name == "" {
+			continue
+		}
+		queue := fmt.Sprintf("[%s]%s", strings.ToLower(app.getPartition()), utils.NormalizeQueueName(name))
+		if removedSet[queue] {
+			protected[queue] = true
+		}
+	}
+	result := make([]string, 0, len(protected))
+	for queue := range protected {
+		result = append(result, queue)
+	}
+	sort.Strings(result)
+	return result
+}
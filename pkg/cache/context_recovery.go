@@ -20,36 +20,67 @@ package cache
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"sort"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/util/workqueue"
 
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt/interfaces"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/dispatcher"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
 	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
 )
 
-func (ctx *Context) WaitForRecovery(recoverableAppManagers []interfaces.Recoverable, maxTimeout time.Duration) error {
+// WaitForRecovery recovers the nodes and the existing allocations while the apps are recovered by recoverApps.
+// The nodes are listed and the existing allocations are collected at the same time as the apps are recovered,
+// the nodes are sent to the core once recoverApps returns because the core needs the apps to recover the
+// allocations. The apps and the nodes share the deadline: when the apps are not recovered in time the nodes
+// are still sent, and the timeout of the apps is returned once the nodes are recovered.
+func (ctx *Context) WaitForRecovery(recoverableAppManagers []interfaces.Recoverable, recoverApps func() error,
+	maxTimeout time.Duration) error {
 	// Currently, disable recovery when testing in a mocked cluster,
 	// because mock pod/node lister is not easy. We do have unit tests for
 	// waitForAppRecovery/recover separately.
-	if !ctx.apiProvider.IsTestingMode() {
-		if err := ctx.recover(recoverableAppManagers, maxTimeout); err != nil {
-			log.Logger().Error("nodes recovery failed", zap.Error(err))
-			return err
-		}
+	if ctx.apiProvider.IsTestingMode() {
+		return recoverApps()
 	}
 
-	return nil
+	deadline := time.Now().Add(maxTimeout)
+	appsRecovered := make(chan error, 1)
+	go func() {
+		appsRecovered <- recoverApps()
+	}()
+	allNodes, err := ctx.prepareRecovery(recoverableAppManagers)
+	appErr := <-appsRecovered
+	if err != nil {
+		log.Log(log.Cache).Error("nodes recovery failed", zap.Error(err))
+		return err
+	}
+	var timeout *interfaces.RecoveryTimeoutError
+	if appErr != nil && !errors.As(appErr, &timeout) {
+		return appErr
+	}
+	remaining := time.Until(deadline)
+	if remaining < 0 {
+		remaining = 0
+	}
+	if err = ctx.recoverNodes(allNodes, remaining); err != nil {
+		log.Log(log.Cache).Error("nodes recovery failed", zap.Error(err))
+		return err
+	}
+	return appErr
 }
 
 // for a given pod, return an allocation if found
@@ -71,10 +102,21 @@ func getExistingAllocation(recoverableAppManagers []interfaces.Recoverable, pod
 // node state plus the allocations. If a node is recovered successfully, its state is marked as
 // healthy. Only healthy nodes can be used for scheduling.
 func (ctx *Context) recover(mgr []interfaces.Recoverable, due time.Duration) error {
-	allNodes, err := waitAndListNodes(ctx.apiProvider)
+	allNodes, err := ctx.prepareRecovery(mgr)
 	if err != nil {
 		return err
 	}
+	return ctx.recoverNodes(allNodes, due)
+}
+
+// lists the nodes and adds them to the cache, and collects the existing allocations and the occupied
+// resources of the nodes from the pods. The pods are handled by a bounded number of workers.
+func (ctx *Context) prepareRecovery(mgr []interfaces.Recoverable) ([]*corev1.Node, error) {
+	allNodes, err := waitAndListNodes(ctx.apiProvider)
+	if err != nil {
+		return nil, err
+	}
+	metrics.GetRecoveryMetrics().SetProgress(metrics.ObjectNode, 0, len(allNodes))
 
 	// add all known nodes to cache, waiting for recover
 	for _, node := range allNodes {
@@ -90,45 +132,49 @@ func (ctx *Context) recover(mgr []interfaces.Recoverable, due time.Duration) err
 			CoreV1().Pods("").
 			List(context.Background(), metav1.ListOptions{})
 		if err != nil {
-			return err
+			return nil, err
 		}
 
+		var lock sync.Mutex
 		nodeOccupiedResources := make(map[string]*si.Resource)
-		for i := 0; i < len(podList.Items); i++ {
-			pod := podList.Items[i]
-			// only handle assigned pods
-			if !utils.IsAssignedPod(&pod) {
-				continue
-			}
-			// yunikorn scheduled pods add to existing allocations
-			if utils.GeneralPodFilter(&pod) {
-				if existingAlloc := getExistingAllocation(mgr, &pod); existingAlloc != nil {
-					log.Logger().Debug("existing allocation",
-						zap.String("appID", existingAlloc.ApplicationID),
-						zap.String("podUID", string(pod.UID)),
-						zap.String("podNodeName", existingAlloc.NodeID))
-					existingAlloc.AllocationTags = common.CreateTagsForTask(&pod)
-					if err = ctx.nodes.addExistingAllocation(existingAlloc); err != nil {
-						log.Logger().Warn("add existing allocation failed", zap.Error(err))
-					}
-				}
-			} else if !utils.IsPodTerminated(&pod) {
-				// pod is not terminated (succeed or failed) state,
-				// and it has a node assigned, that means the scheduler
-				// has already allocated the pod onto a node
-				// we should report this occupied resource to scheduler-core
-				occupiedResource := nodeOccupiedResources[pod.Spec.NodeName]
-				if occupiedResource == nil {
-					occupiedResource = common.NewResourceBuilder().Build()
+		workqueue.ParallelizeUntil(context.Background(), conf.GetSchedulerConf().GetRecoveryWorkers(),
+			len(podList.Items), func(i int) {
+				pod := &podList.Items[i]
+				// only handle assigned pods
+				if !utils.IsAssignedPod(pod) {
+					return
 				}
-				occupiedResource = common.Add(occupiedResource, common.GetPodResource(&pod))
-				nodeOccupiedResources[pod.Spec.NodeName] = occupiedResource
-				if err = ctx.nodes.cache.AddPod(&pod); err != nil {
-					log.Logger().Warn("failed to update scheduler-cache",
-						zap.Error(err))
+				// yunikorn scheduled pods add to existing allocations
+				if utils.GeneralPodFilter(pod) {
+					if existingAlloc := getExistingAllocation(mgr, pod); existingAlloc != nil {
+						log.Log(log.Cache).Debug("existing allocation",
+							zap.String("appID", existingAlloc.ApplicationID),
+							zap.String("podUID", string(pod.UID)),
+							zap.String("podNodeName", existingAlloc.NodeID))
+						existingAlloc.AllocationTags = common.CreateTagsForTask(pod)
+						if err := ctx.nodes.addExistingAllocation(existingAlloc); err != nil {
+							log.Log(log.Cache).Warn("add existing allocation failed", zap.Error(err))
+						}
+					}
+				} else if !utils.IsPodTerminated(pod) {
+					// pod is not terminated (succeed or failed) state,
+					// and it has a node assigned, that means the scheduler
+					// has already allocated the pod onto a node
+					// we should report this occupied resource to scheduler-core
+					podResource := common.GetPodResource(pod)
+					lock.Lock()
+					occupiedResource := nodeOccupiedResources[pod.Spec.NodeName]
+					if occupiedResource == nil {
+						occupiedResource = common.NewResourceBuilder().Build()
+					}
+					nodeOccupiedResources[pod.Spec.NodeName] = common.Add(occupiedResource, podResource)
+					lock.Unlock()
+					if err := ctx.nodes.cache.AddPod(pod); err != nil {
+						log.Log(log.Cache).Warn("failed to update scheduler-cache",
+							zap.Error(err))
+					}
 				}
-			}
-		}
+			})
 
 		// why we need to calculate the occupied resources here? why not add an event-handler
 		// in node_coordinator#addPod?
@@ -143,11 +189,19 @@ func (ctx *Context) recover(mgr []interfaces.Recoverable, due time.Duration) err
 			}
 		}
 	}
+	return allNodes, nil
+}
+
+// sends the nodes to the core and waits until the core accepted or rejected all of them
+func (ctx *Context) recoverNodes(allNodes []*corev1.Node, due time.Duration) error {
+	if conf.GetSchedulerConf().SyncRecovery {
+		ctx.recoverNodesInOrder(allNodes)
+	}
 
-	if err = utils.WaitForCondition(func() bool {
+	if err := utils.WaitForCondition(func() bool {
 		nodesRecovered := 0
 		for _, node := range ctx.nodes.nodesMap {
-			log.Logger().Info("node state",
+			log.Log(log.Cache).Debug("node state",
 				zap.String("nodeName", node.name),
 				zap.String("nodeState", node.getNodeState()))
 			switch node.getNodeState() {
@@ -165,22 +219,49 @@ func (ctx *Context) recover(mgr []interfaces.Recoverable, due time.Duration) err
 			}
 		}
 
+		metrics.GetRecoveryMetrics().SetProgress(metrics.ObjectNode, nodesRecovered, len(allNodes))
 		if nodesRecovered == len(allNodes) {
-			log.Logger().Info("nodes recovery is successful",
+			log.Log(log.Cache).Info("nodes recovery is successful",
 				zap.Int("recoveredNodes", nodesRecovered))
 			return true
 		}
-		log.Logger().Info("still waiting for recovering nodes",
+		log.Log(log.Cache).Info("still waiting for recovering nodes",
 			zap.Int("totalNodes", len(allNodes)),
 			zap.Int("recoveredNodes", nodesRecovered))
 		return false
 	}, time.Second, due); err != nil {
-		return fmt.Errorf("timeout waiting for app recovery in %s", due.String())
+		return &interfaces.RecoveryTimeoutError{Object: "node", Timeout: due}
 	}
 
 	return nil
 }
 
+// sends the nodes to the core, started in the order of their names, on a bounded number of workers.
+// Returns when all the nodes are sent, the nodes left in the new state are dispatched again while
+// waiting for the recovery.
+func (ctx *Context) recoverNodesInOrder(allNodes []*corev1.Node) {
+	names := make([]string, 0, len(allNodes))
+	for _, node := range allNodes {
+		names = append(names, node.Name)
+	}
+	sort.Strings(names)
+	nodeEvents := make([]events.SchedulingEvent, 0, len(names))
+	for _, name := range names {
+		if cachedNode := ctx.nodes.getNode(name); cachedNode != nil &&
+			cachedNode.getNodeState() == events.States().Node.New {
+			nodeEvents = append(nodeEvents, CachedSchedulerNodeEvent{
+				NodeID: name,
+				Event:  events.RecoverNode,
+			})
+		}
+	}
+	if err := dispatcher.DispatchSyncAll(nodeEvents, conf.GetSchedulerConf().GetRecoveryWorkers()); err != nil {
+		log.Log(log.Cache).Warn("failed to recover nodes",
+			zap.Int("nodes", len(nodeEvents)),
+			zap.Error(err))
+	}
+}
+
 func waitAndListNodes(apiProvider client.APIProvider) ([]*corev1.Node, error) {
 	var allNodes []*corev1.Node
 	var listErr error
@@ -35,6 +35,7 @@ import (
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/test"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/dispatcher"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
 )
 
 func TestNodeRecoveringState(t *testing.T) {
@@ -183,6 +184,59 @@ func TestNodesRecovery(t *testing.T) {
 	err = context.recover([]interfaces.Recoverable{mockedAppRecover}, 3*time.Second)
 	assert.NilError(t, err, "recovery should be successful, however got error")
 	assert.DeepEqual(t, getNodeStates(schedulerNodes), expectedStates)
+	assert.Equal(t, metrics.GetRecoveryMetrics().GetProgress(metrics.ObjectNode),
+		metrics.RecoveryProgress{Recovered: 3, Total: 3})
+}
+
+func TestWaitForRecoveryInTestingMode(t *testing.T) {
+	context := NewContext(client.NewMockedAPIProvider())
+	// only the apps are recovered, the error of the apps is returned
+	appsRecovered := false
+	err := context.WaitForRecovery(nil, func() error {
+		appsRecovered = true
+		return &interfaces.RecoveryTimeoutError{Object: "app", Timeout: time.Second}
+	}, time.Second)
+	assert.Assert(t, appsRecovered)
+	assert.Error(t, err, "timeout waiting for app recovery in 1s")
+}
+
+func TestRecoverNodesInOrder(t *testing.T) {
+	apiProvider4test := client.NewMockedAPIProvider()
+	context := NewContext(apiProvider4test)
+	dispatcher.RegisterEventHandler(dispatcher.EventTypeNode, context.nodes.schedulerNodeEventHandler())
+	dispatcher.Start()
+	defer dispatcher.Stop()
+
+	nodes := make([]*v1.Node, 0)
+	for _, name := range []string{"host0003", "host0001", "host0002"} {
+		node := &v1.Node{
+			ObjectMeta: apis.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				UID:       types.UID("uid_" + name),
+			},
+			Status: v1.NodeStatus{
+				Capacity: utils.NewK8sResourceList(
+					utils.K8sResource{
+						ResourceName: v1.ResourceMemory,
+						Value:        1024,
+					}, utils.K8sResource{
+						ResourceName: v1.ResourceCPU,
+						Value:        10,
+					}),
+			},
+		}
+		nodes = append(nodes, node)
+		context.nodes.addAndReportNode(node, false)
+	}
+
+	// the recovery events are handled before the call returns
+	context.recoverNodesInOrder(nodes)
+	for _, node := range nodes {
+		schedulerNode := context.nodes.getNode(node.Name)
+		assert.Assert(t, schedulerNode != nil)
+		assert.Equal(t, schedulerNode.getNodeState(), events.States().Node.Recovering)
+	}
 }
 
 func getNodeStates(schedulerNodes []*SchedulerNode) []string {
@@ -23,6 +23,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -42,6 +43,7 @@ import (
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/dispatcher"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/profiling"
 	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
 )
 
@@ -139,6 +141,31 @@ func TestGetApplication(t *testing.T) {
 	assert.Assert(t, app == nil)
 }
 
+func TestApplicationsWithoutContextLock(t *testing.T) {
+	context := initContextForTest()
+	context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: "app00001",
+			QueueName:     "root.a",
+			User:          "test-user",
+		},
+	})
+	// the readers do not wait for a writer holding the context lock
+	context.lock.Lock()
+	app, ok := context.getApplication("app00001")
+	assert.Assert(t, ok)
+	assert.Equal(t, app.applicationID, "app00001")
+	assert.Equal(t, len(context.listApplications()), 1)
+	assert.Equal(t, len(context.SelectApplications(nil)), 1)
+	context.lock.Unlock()
+
+	listed := context.listApplications()
+	assert.NilError(t, context.RemoveApplicationInternal("app00001"))
+	assert.Equal(t, len(listed), 1)
+	assert.Equal(t, len(context.listApplications()), 0)
+	assert.Assert(t, context.GetApplication("app00001") == nil)
+}
+
 func TestRemoveApplication(t *testing.T) {
 	// add 3 applications
 	context := initContextForTest()
@@ -835,6 +862,404 @@ func TestAddApplicationsWithTags(t *testing.T) {
 	assert.Equal(t, parentQueue, "root.test")
 }
 
+func TestGetNamespaceObjectDisabled(t *testing.T) {
+	context := initContextForTest()
+	lister, ok := context.apiProvider.GetAPIs().NamespaceInformer.Lister().(*test.MockNamespaceLister)
+	assert.Assert(t, ok)
+	lister.Add(&v1.Namespace{ObjectMeta: apis.ObjectMeta{Name: "ns"}})
+	assert.Assert(t, context.getNamespaceObject("ns") != nil)
+
+	// namespaces are not watched with the namespace annotations disabled
+	context.apiProvider.GetAPIs().NamespaceInformer = nil
+	assert.Assert(t, context.getNamespaceObject("ns") == nil)
+}
+
+func TestAddApplicationsWithNamespacePolicy(t *testing.T) {
+	context := initContextForTest()
+
+	lister, ok := context.apiProvider.GetAPIs().NamespaceInformer.Lister().(*test.MockNamespaceLister)
+	if !ok {
+		t.Fatalf("could not mock NamespaceLister")
+	}
+	lister.Add(&v1.Namespace{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "gang",
+			Annotations: map[string]string{
+				constants.AnnotationNamespaceGangSchedulingStyle: "Hard",
+				constants.AnnotationNamespacePlaceholderTimeout:  "120",
+				constants.AnnotationNamespacePlaceholderImage:    "registry.local/pause:3.2",
+			},
+		},
+	})
+
+	// app without scheduling policy parameters picks up the namespace overrides
+	managedApp := context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: "app00001",
+			QueueName:     "root.a",
+			User:          "test-user",
+			Tags: map[string]string{
+				constants.AppTagNamespace: "gang",
+			},
+			SchedulingPolicyParameters: interfaces.NewSchedulingPolicyParameters(0, constants.SchedulingPolicyStyleParamDefault),
+		},
+	})
+	app, ok := managedApp.(*Application)
+	assert.Assert(t, ok)
+	assert.Equal(t, app.getSchedulingStyle(), "Hard")
+	assert.Equal(t, app.getPlaceholderTimeout(), int64(120))
+	assert.Equal(t, app.getPlaceholderImage(), "registry.local/pause:3.2")
+
+	// the placeholder timeout set on the pod wins
+	managedApp = context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: "app00002",
+			QueueName:     "root.a",
+			User:          "test-user",
+			Tags: map[string]string{
+				constants.AppTagNamespace: "gang",
+			},
+			SchedulingPolicyParameters: interfaces.NewSchedulingPolicyParameters(30, constants.SchedulingPolicyStyleParamDefault),
+		},
+	})
+	app, ok = managedApp.(*Application)
+	assert.Assert(t, ok)
+	assert.Equal(t, app.getPlaceholderTimeout(), int64(30))
+
+	// apps in namespaces without annotations keep the defaults
+	managedApp = context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: "app00003",
+			QueueName:     "root.a",
+			User:          "test-user",
+			Tags: map[string]string{
+				constants.AppTagNamespace: "non-existing",
+			},
+		},
+	})
+	app, ok = managedApp.(*Application)
+	assert.Assert(t, ok)
+	assert.Equal(t, app.getSchedulingStyle(), constants.SchedulingPolicyStyleParamDefault)
+	assert.Equal(t, app.getPlaceholderTimeout(), int64(0))
+	assert.Equal(t, app.getPlaceholderImage(), constants.PlaceholderContainerImage)
+}
+
+func TestPublishConfigChangeEvents(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	events.SetRecorderForTest(recorder)
+	defer events.SetRecorderForTest(record.NewFakeRecorder(1024))
+	context := initContextForTest()
+
+	oldConfigMap := &v1.ConfigMap{
+		ObjectMeta: apis.ObjectMeta{
+			Name: constants.DefaultConfigMapName,
+		},
+		Data: map[string]string{
+			"queues.yaml": "partitions:\n  - name: default\n    queues:\n      - name: root\n        queues:\n          - name: a\n",
+		},
+	}
+	newConfigMap := oldConfigMap.DeepCopy()
+	newConfigMap.Data["queues.yaml"] = "partitions:\n  - name: default\n    queues:\n      - name: root\n        queues:\n          - name: b\n"
+
+	context.publishConfigChangeEvents(oldConfigMap, newConfigMap)
+	assert.Equal(t, len(recorder.Events), 2)
+	assert.Assert(t, strings.Contains(<-recorder.Events, "QueuesAdded queues added: [default]root.b"))
+	assert.Assert(t, strings.Contains(<-recorder.Events, "QueuesRemoved queues removed: [default]root.a"))
+
+	// no queue changes
+	context.publishConfigChangeEvents(newConfigMap, newConfigMap)
+	assert.Equal(t, len(recorder.Events), 1)
+	assert.Assert(t, strings.Contains(<-recorder.Events, "ConfigReloaded"))
+}
+
+func TestPushMergedQueuesConfig(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	events.SetRecorderForTest(recorder)
+	defer events.SetRecorderForTest(record.NewFakeRecorder(1024))
+	var pushed []string
+	pushFn := pushQueuesConfigToCore
+	pushQueuesConfigToCore = func(content string) {
+		pushed = append(pushed, content)
+	}
+	defer func() { pushQueuesConfigToCore = pushFn }()
+	context := initContextForTest()
+	context.apiProvider.GetAPIs().Conf.QueuesFragmentNamespaces = "team1,team2"
+
+	// no fragments, the core reads the mounted file
+	assert.NilError(t, context.pushMergedQueuesConfig())
+	assert.Equal(t, len(pushed), 0)
+
+	lister := context.apiProvider.GetAPIs().ConfigMapInformer.Lister()
+	configMaps, err := lister.List(nil)
+	assert.NilError(t, err)
+	configMaps[0].Data["queues.yaml"] = "partitions:\n  - name: default\n    queues:\n      - name: root\n        queues:\n          - name: a\n"
+	listerMock, ok := lister.(*test.ConfigMapListerMock)
+	assert.Assert(t, ok)
+	listerMock.Add(&v1.ConfigMap{
+		ObjectMeta: apis.ObjectMeta{
+			Name:      "team1-queues",
+			Namespace: "team1",
+			Labels:    map[string]string{constants.LabelQueuesFragment: "true"},
+		},
+		Data: map[string]string{"queues.yaml": "parent: root.a\nqueues:\n  - name: team1\n"},
+	})
+	listerMock.Add(&v1.ConfigMap{
+		ObjectMeta: apis.ObjectMeta{
+			Name:      "team2-queues",
+			Namespace: "team2",
+			Labels:    map[string]string{constants.LabelQueuesFragment: "true"},
+		},
+		Data: map[string]string{"queues.yaml": "parent: root.b\nqueues:\n  - name: team2\n"},
+	})
+
+	assert.NilError(t, context.pushMergedQueuesConfig())
+	assert.Equal(t, len(pushed), 1)
+	merged, err := conf.ParseQueuesConfig(pushed[0])
+	assert.NilError(t, err)
+	diff := conf.DiffQueuesConfig(&conf.QueuesConfig{}, merged)
+	assert.DeepEqual(t, diff.Added, []string{"[default]root", "[default]root.a", "[default]root.a.team1"})
+	assert.Equal(t, len(recorder.Events), 1)
+	assert.Assert(t, strings.Contains(<-recorder.Events, "QueuesFragmentRejected queues fragment team2/team2-queues is not merged"))
+}
+
+func TestPushMergedQueuesConfigUntrusted(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	events.SetRecorderForTest(recorder)
+	defer events.SetRecorderForTest(record.NewFakeRecorder(1024))
+	var pushed []string
+	pushFn := pushQueuesConfigToCore
+	pushQueuesConfigToCore = func(content string) {
+		pushed = append(pushed, content)
+	}
+	defer func() { pushQueuesConfigToCore = pushFn }()
+	context := initContextForTest()
+	context.apiProvider.GetAPIs().Conf.QueuesFragmentParents = "root.a"
+
+	lister := context.apiProvider.GetAPIs().ConfigMapInformer.Lister()
+	configMaps, err := lister.List(nil)
+	assert.NilError(t, err)
+	configMaps[0].Data["queues.yaml"] = "partitions:\n  - name: default\n    queues:\n      - name: root\n        queues:\n          - name: a\n"
+	listerMock, ok := lister.(*test.ConfigMapListerMock)
+	assert.Assert(t, ok)
+	// a fragment from a tenant namespace is ignored
+	listerMock.Add(&v1.ConfigMap{
+		ObjectMeta: apis.ObjectMeta{
+			Name:      "tenant-queues",
+			Namespace: "tenant",
+			Labels:    map[string]string{constants.LabelQueuesFragment: "true"},
+		},
+		Data: map[string]string{"queues.yaml": "parent: root.a\nqueues:\n  - name: tenant\n"},
+	})
+	assert.NilError(t, context.pushMergedQueuesConfig())
+	assert.Equal(t, len(pushed), 0)
+
+	// a fragment from the scheduler namespace is only merged below the allowed parents
+	listerMock.Add(&v1.ConfigMap{
+		ObjectMeta: apis.ObjectMeta{
+			Name:      "root-queues",
+			Namespace: constants.DefaultSchedulerNamespace,
+			Labels:    map[string]string{constants.LabelQueuesFragment: "true"},
+		},
+		Data: map[string]string{"queues.yaml": "parent: root\nqueues:\n  - name: b\n"},
+	})
+	listerMock.Add(&v1.ConfigMap{
+		ObjectMeta: apis.ObjectMeta{
+			Name:      "team1-queues",
+			Namespace: constants.DefaultSchedulerNamespace,
+			Labels:    map[string]string{constants.LabelQueuesFragment: "true"},
+		},
+		Data: map[string]string{"queues.yaml": "parent: root.a\nqueues:\n  - name: team1\n"},
+	})
+	assert.NilError(t, context.pushMergedQueuesConfig())
+	assert.Equal(t, len(pushed), 1)
+	merged, err := conf.ParseQueuesConfig(pushed[0])
+	assert.NilError(t, err)
+	diff := conf.DiffQueuesConfig(&conf.QueuesConfig{}, merged)
+	assert.DeepEqual(t, diff.Added, []string{"[default]root", "[default]root.a", "[default]root.a.team1"})
+	assert.Equal(t, len(recorder.Events), 1)
+	assert.Assert(t, strings.Contains(<-recorder.Events, "QueuesFragmentRejected queues fragment default/root-queues "+
+		"is not merged: parent queue root is not allowed"))
+}
+
+func TestPushQueuesConfigDirect(t *testing.T) {
+	var pushed []string
+	pushFn := pushQueuesConfigToCore
+	pushQueuesConfigToCore = func(content string) {
+		pushed = append(pushed, content)
+	}
+	defer func() { pushQueuesConfigToCore = pushFn }()
+	context := initContextForTest()
+	context.apiProvider.GetAPIs().Conf.ConfigDelivery = conf.ConfigDeliveryDirect
+	defer func() { context.apiProvider.GetAPIs().Conf.ConfigDelivery = "" }()
+
+	// the content is pushed as is when there is nothing to merge
+	assert.NilError(t, context.LoadQueuesConfig())
+	assert.DeepEqual(t, pushed, []string{"OldData"})
+
+	configMaps, err := context.apiProvider.GetAPIs().ConfigMapInformer.Lister().List(nil)
+	assert.NilError(t, err)
+	newConfigMap := configMaps[0].DeepCopy()
+	newConfigMap.Data["queues.yaml"] = "NewData"
+	configMaps[0].Data["queues.yaml"] = "NewData"
+	defer func() { configMaps[0].Data["queues.yaml"] = "OldData" }()
+	context.apiProvider.GetAPIs().Conf.EnableConfigHotRefresh = true
+	defer func() { context.apiProvider.GetAPIs().Conf.EnableConfigHotRefresh = false }()
+	context.updateConfigMaps(configMaps[0], newConfigMap)
+	assert.DeepEqual(t, pushed, []string{"OldData", "NewData"})
+}
+
+func TestConfigSecretHandlers(t *testing.T) {
+	context := initContextForTest()
+	schedulerConf := context.apiProvider.GetAPIs().Conf
+	secret := &v1.Secret{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "yunikorn-secrets",
+		},
+		Data: map[string][]byte{conf.SecretEventSinkToken: []byte("token")},
+	}
+	context.addConfigSecret(secret)
+	value, ok := schedulerConf.GetSecretValue(conf.SecretEventSinkToken)
+	assert.Assert(t, ok)
+	assert.Equal(t, string(value), "token")
+
+	newSecret := secret.DeepCopy()
+	newSecret.Data[conf.SecretEventSinkToken] = []byte("rotated")
+	context.updateConfigSecret(secret, newSecret)
+	value, ok = schedulerConf.GetSecretValue(conf.SecretEventSinkToken)
+	assert.Assert(t, ok)
+	assert.Equal(t, string(value), "rotated")
+
+	context.deleteConfigSecret(newSecret)
+	_, ok = schedulerConf.GetSecretValue(conf.SecretEventSinkToken)
+	assert.Assert(t, !ok)
+}
+
+func TestGetConfigState(t *testing.T) {
+	context := initContextForTest()
+	configMaps, err := context.apiProvider.GetAPIs().ConfigMapInformer.Lister().List(nil)
+	assert.NilError(t, err)
+	configMap := configMaps[0]
+	configMap.ResourceVersion = "1"
+	defer func() { configMap.ResourceVersion = "" }()
+
+	// nothing applied yet
+	state := context.GetConfigState()
+	assert.Equal(t, state.LatestResourceVersion, "1")
+	assert.Equal(t, state.AppliedResourceVersion, "")
+	assert.Assert(t, state.Drift)
+
+	context.addConfigMaps(configMap)
+	state = context.GetConfigState()
+	assert.Equal(t, state.AppliedResourceVersion, "1")
+	assert.Equal(t, state.AppliedData["queues.yaml"], "OldData")
+	assert.Assert(t, state.AppliedTime != nil)
+	assert.Assert(t, !state.Drift)
+
+	// hot-refresh is disabled, the update is not applied
+	newConfigMap := configMap.DeepCopy()
+	newConfigMap.ResourceVersion = "2"
+	configMap.ResourceVersion = "2"
+	context.updateConfigMaps(configMap, newConfigMap)
+	state = context.GetConfigState()
+	assert.Equal(t, state.AppliedResourceVersion, "1")
+	assert.Equal(t, state.LatestResourceVersion, "2")
+	assert.Assert(t, state.Drift)
+
+	// fragments do not change the applied version
+	context.recordAppliedConfig(&v1.ConfigMap{
+		ObjectMeta: apis.ObjectMeta{
+			Name:            "fragment",
+			ResourceVersion: "3",
+			Labels:          map[string]string{constants.LabelQueuesFragment: "true"},
+		},
+	})
+	assert.Equal(t, context.GetConfigState().AppliedResourceVersion, "1")
+}
+
+func TestApplyConfigProfiling(t *testing.T) {
+	defer profiling.SetEnabled(false)
+	configMap := &v1.ConfigMap{
+		ObjectMeta: apis.ObjectMeta{Name: constants.DefaultConfigMapName},
+		Data: map[string]string{
+			"queues.yaml":       "OldData",
+			"profiling.enabled": "true",
+		},
+	}
+	applyConfigProfiling(nil, configMap)
+	assert.Assert(t, profiling.Enabled())
+
+	// a change made at runtime is kept while the ConfigMap value does not change
+	profiling.SetEnabled(false)
+	newConfigMap := configMap.DeepCopy()
+	newConfigMap.Data["queues.yaml"] = "NewData"
+	applyConfigProfiling(configMap, newConfigMap)
+	assert.Assert(t, !profiling.Enabled())
+
+	// removing the key applies the startup configuration
+	profiling.SetEnabled(true)
+	configMap = newConfigMap
+	newConfigMap = configMap.DeepCopy()
+	delete(newConfigMap.Data, "profiling.enabled")
+	applyConfigProfiling(configMap, newConfigMap)
+	assert.Assert(t, !profiling.Enabled())
+
+	// other ConfigMaps are ignored
+	other := &v1.ConfigMap{
+		ObjectMeta: apis.ObjectMeta{Name: "other"},
+		Data:       map[string]string{"profiling.enabled": "true"},
+	}
+	applyConfigProfiling(nil, other)
+	assert.Assert(t, !profiling.Enabled())
+}
+
+func TestApplyConfigLogLevels(t *testing.T) {
+	defer log.SetConfigLevels(nil)
+	levelOf := func(subsystem string) *log.LevelInfo {
+		for _, level := range log.GetLevels() {
+			if level.Subsystem == subsystem {
+				return level
+			}
+		}
+		return nil
+	}
+	configMap := &v1.ConfigMap{
+		ObjectMeta: apis.ObjectMeta{Name: constants.DefaultConfigMapName},
+		Data: map[string]string{
+			"queues.yaml":            "OldData",
+			"log.cache.level":        "debug",
+			"log.dispatcher.level":   "warn",
+			"log.dispatcher.enabled": "true",
+		},
+	}
+	applyConfigLogLevels(nil, configMap)
+	assert.Equal(t, levelOf(log.Cache).Level, "debug")
+	assert.Equal(t, levelOf(log.Dispatcher).Level, "warn")
+	assert.Assert(t, levelOf(log.Client).Inherited)
+
+	// a level changed at runtime is kept while the levels in the ConfigMap do not change
+	assert.NilError(t, log.SetLevel(log.Client, "error"))
+	newConfigMap := configMap.DeepCopy()
+	newConfigMap.Data["queues.yaml"] = "NewData"
+	applyConfigLogLevels(configMap, newConfigMap)
+	assert.Equal(t, levelOf(log.Client).Level, "error")
+
+	// the subsystems removed from the ConfigMap follow the level of the shim again
+	configMap = newConfigMap
+	newConfigMap = configMap.DeepCopy()
+	delete(newConfigMap.Data, "log.dispatcher.level")
+	applyConfigLogLevels(configMap, newConfigMap)
+	assert.Equal(t, levelOf(log.Cache).Level, "debug")
+	assert.Assert(t, levelOf(log.Dispatcher).Inherited)
+	assert.Assert(t, levelOf(log.Client).Inherited)
+
+	// the fragments do not set log levels
+	applyConfigLogLevels(nil, &v1.ConfigMap{
+		ObjectMeta: apis.ObjectMeta{Name: "fragment"},
+		Data:       map[string]string{"log.appmgmt.level": "debug"},
+	})
+	assert.Assert(t, levelOf(log.AppMgmt).Inherited)
+}
+
 func TestFindYKConfigMap(t *testing.T) {
 	goodYKConfigmap := v1.ConfigMap{
 		ObjectMeta: apis.ObjectMeta{
@@ -899,3 +1324,161 @@ func TestSaveConfigmap(t *testing.T) {
 	assert.Equal(t, false, resp.Success, "Failure is expected")
 	assert.Assert(t, strings.Contains(resp.Reason, "hot-refresh is enabled"), "Unexpected reason")
 }
+
+func TestEventHandlerDeadLetters(t *testing.T) {
+	context := initContextForTest()
+	context.ApplicationEventHandler()(NewRunApplicationEvent("app-dead-letter"))
+	context.TaskEventHandler()(NewBindTaskEvent("app-dead-letter", "task-dead-letter"))
+	// the app is new, it cannot run before it is submitted
+	context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: "app-dead-letter-2",
+			QueueName:     "root.a",
+			User:          "test-user",
+		},
+	})
+	context.ApplicationEventHandler()(NewRunApplicationEvent("app-dead-letter-2"))
+
+	letters := make(map[string]*dispatcher.DeadLetter)
+	for _, letter := range dispatcher.GetDeadLetters() {
+		letters[letter.EventType+"/"+letter.ApplicationID] = letter
+	}
+	assert.Equal(t, letters["app/app-dead-letter"].Error, "application not exist")
+	assert.Equal(t, letters["task/app-dead-letter"].TaskID, "task-dead-letter")
+	assert.Equal(t, letters["app/app-dead-letter-2"].State, events.States().Application.New)
+	assert.Equal(t, letters["app/app-dead-letter-2"].Error, "event not allowed in the application state")
+}
+
+func TestEventPanicHandler(t *testing.T) {
+	context := initContextForTest()
+	lock := sync.Mutex{}
+	failed := make([]string, 0)
+	dispatcher.RegisterEventHandler(dispatcher.EventTypeApp, func(obj interface{}) {
+		if event, ok := obj.(events.ApplicationEvent); ok {
+			lock.Lock()
+			defer lock.Unlock()
+			failed = append(failed, fmt.Sprintf("%s/%s/%v", event.GetApplicationID(), event.GetEvent(), event.GetArgs()))
+		}
+	})
+	dispatcher.Start()
+	defer dispatcher.Stop()
+
+	handler := context.EventPanicHandler()
+	handler(NewRunApplicationEvent("app-panic"), "boom")
+	// the failure and the status events do not fail the application again
+	handler(NewFailApplicationEvent("app-panic", "failed"), "boom")
+	handler(NewApplicationStatusChangeEvent("app-panic", events.AppStateChange, "Running"), "boom")
+	err := utils.WaitForCondition(func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(failed) > 0
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	lock.Lock()
+	defer lock.Unlock()
+	assert.DeepEqual(t, failed, []string{"app-panic/FailApplication/[event handler panicked: boom]"})
+}
+
+func TestSaveConfigmapDryRun(t *testing.T) {
+	context := initContextForTest()
+	configMaps, err := context.apiProvider.GetAPIs().ConfigMapInformer.Lister().List(nil)
+	assert.NilError(t, err, "No error expected")
+	clientSet := context.apiProvider.GetAPIs().KubeClient.GetClientSet()
+	_, err = clientSet.CoreV1().ConfigMaps(configMaps[0].Namespace).Create(ctx.Background(), configMaps[0], apis.CreateOptions{})
+	assert.NilError(t, err, "No error expected")
+
+	conf.GetSchedulerConf().DryRun = true
+	defer func() { conf.GetSchedulerConf().DryRun = false }()
+	resp := context.SaveConfigmap(&si.UpdateConfigurationRequest{Configs: "newConfig"})
+	assert.Equal(t, true, resp.Success, "Successful update expected")
+	assert.Equal(t, resp.OldConfig, "OldData")
+	// the configmap is not changed in the cluster
+	saved, err := clientSet.CoreV1().ConfigMaps(configMaps[0].Namespace).Get(ctx.Background(), configMaps[0].Name, apis.GetOptions{})
+	assert.NilError(t, err, "No error expected")
+	assert.Equal(t, saved.Data["queues.yaml"], "OldData")
+}
+
+func TestSaveConfigmapProtectedQueues(t *testing.T) {
+	context := initContextForTest()
+	context.apiProvider.GetAPIs().Conf.ProtectQueuesWithApps = true
+	defer func() { context.apiProvider.GetAPIs().Conf.ProtectQueuesWithApps = false }()
+	configMaps, err := context.apiProvider.GetAPIs().ConfigMapInformer.Lister().List(nil)
+	assert.NilError(t, err, "No error expected")
+	configMaps[0].Data["queues.yaml"] = "partitions:\n  - name: default\n    queues:\n      - name: root\n        queues:\n          - name: a\n          - name: b\n          - name: c\n"
+	defer func() { configMaps[0].Data["queues.yaml"] = "OldData" }()
+	_, err = context.apiProvider.GetAPIs().KubeClient.GetClientSet().CoreV1().ConfigMaps(configMaps[0].Namespace).
+		Create(ctx.Background(), configMaps[0], apis.CreateOptions{})
+	assert.NilError(t, err, "No error expected")
+
+	// running app in root.a, a short queue name in root.b and a finished app in root.c
+	for appID, queue := range map[string]string{"app-a": "root.a", "app-b": "b", "app-c": "root.c"} {
+		context.AddApplication(&interfaces.AddApplicationRequest{
+			Metadata: interfaces.ApplicationMetadata{
+				ApplicationID: appID,
+				QueueName:     queue,
+				User:          "test-user",
+			},
+		})
+	}
+	context.applications["app-c"].sm.SetState(events.States().Application.Completed)
+
+	newConf := si.UpdateConfigurationRequest{
+		Configs: "partitions:\n  - name: default\n    queues:\n      - name: root\n",
+	}
+	resp := context.SaveConfigmap(&newConf)
+	assert.Equal(t, false, resp.Success, "Failure is expected")
+	assert.Equal(t, resp.Reason, "queues with running applications cannot be removed: [default]root.a, [default]root.b")
+
+	// removing only the queue of the finished app is allowed
+	newConf.Configs = "partitions:\n  - name: default\n    queues:\n      - name: root\n        queues:\n          - name: a\n          - name: b\n"
+	resp = context.SaveConfigmap(&newConf)
+	assert.Equal(t, true, resp.Success, "Successful update expected")
+
+	// guardrail disabled
+	context.apiProvider.GetAPIs().Conf.ProtectQueuesWithApps = false
+	newConf.Configs = "partitions:\n  - name: default\n    queues:\n      - name: root\n"
+	resp = context.SaveConfigmap(&newConf)
+	assert.Equal(t, true, resp.Success, "Successful update expected")
+}
+
+func TestLoadCache(t *testing.T) {
+	context := initContextForTest()
+	node1 := utils.NodeForTest("host0001", "10G", "10")
+	node1.ResourceVersion = "1"
+	node2 := utils.NodeForTest("host0002", "10G", "10")
+	node2.ResourceVersion = "1"
+	pod1 := newPodHelper("pod1", "default", "UID-POD-00001", "host0001", v1.PodRunning)
+	pod1.ResourceVersion = "1"
+	pod2 := newPodHelper("pod2", "default", "UID-POD-00002", "", v1.PodPending)
+	// terminated pods and pods of other schedulers are not loaded
+	pod3 := newPodHelper("pod3", "default", "UID-POD-00003", "host0001", v1.PodSucceeded)
+	pod4 := newPodHelper("pod4", "default", "UID-POD-00004", "host0002", v1.PodRunning)
+	pod4.Spec.SchedulerName = "default-scheduler"
+
+	context.loadCache([]*v1.Node{node1, node2}, []*v1.Pod{pod1, pod2, pod3, pod4})
+	assert.Assert(t, context.nodes.getNode("host0001") != nil)
+	assert.Assert(t, context.nodes.getNode("host0002") != nil)
+	assert.Equal(t, len(context.schedulerCache.GetNodesInfoSnapshot()), 2)
+	assert.Equal(t, len(context.schedulerCache.GetNode("host0001").Pods), 1)
+	assert.Equal(t, len(context.schedulerCache.GetNode("host0002").Pods), 0)
+	_, ok := context.schedulerCache.GetPod("UID-POD-00002")
+	assert.Assert(t, ok)
+	_, ok = context.schedulerCache.GetPod("UID-POD-00003")
+	assert.Assert(t, !ok)
+
+	// the replayed add events find the objects cached
+	assert.Assert(t, context.isNodeCached(node1))
+	cachedNode := context.schedulerCache.GetNode("host0001")
+	context.addNode(node1)
+	assert.Assert(t, context.schedulerCache.GetNode("host0001") == cachedNode)
+	context.addPodToCache(pod1)
+	assert.Equal(t, len(context.schedulerCache.GetNode("host0001").Pods), 1)
+
+	// a newer version is added as before
+	updated := node1.DeepCopy()
+	updated.ResourceVersion = "2"
+	assert.Assert(t, !context.isNodeCached(updated))
+	context.addNode(updated)
+	assert.Equal(t, context.schedulerCache.GetNode("host0001").Node().ResourceVersion, "2")
+}
@@ -0,0 +1,83 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"sort"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+)
+
+// GetPendingDemand returns the resources requested by the pods that wait for an allocation, by queue
+// and namespace ordered by queue and namespace. A gang member that replaces an allocated placeholder
+// of its task group is not counted, the resources of the placeholder are already allocated.
+func (ctx *Context) GetPendingDemand() []*metrics.PendingDemand {
+	apps := ctx.listApplications()
+	states := events.States().Task
+	byKey := make(map[string]*metrics.PendingDemand)
+	demands := make([]*metrics.PendingDemand, 0)
+	for _, app := range apps {
+		app.lock.RLock()
+		tasks := app.getAllTasks()
+		// the task groups with placeholders reserving resources for the gang members
+		reserved := make(map[string]bool)
+		for _, task := range tasks {
+			switch task.GetTaskState() {
+			case states.Allocated, states.Bound:
+				if task.placeholder {
+					reserved[task.taskGroupName] = true
+				}
+			}
+		}
+		for _, task := range tasks {
+			// the state of the task is read without the task lock, the resource does not change
+			switch task.GetTaskState() {
+			case states.New, states.Pending, states.Scheduling:
+			default:
+				continue
+			}
+			if !task.placeholder && task.taskGroupName != "" && reserved[task.taskGroupName] {
+				continue
+			}
+			key := app.queue + "/" + task.pod.Namespace
+			demand, ok := byKey[key]
+			if !ok {
+				demand = &metrics.PendingDemand{
+					Queue:     app.queue,
+					Namespace: task.pod.Namespace,
+					Resources: make(map[string]int64),
+				}
+				byKey[key] = demand
+				demands = append(demands, demand)
+			}
+			demand.Pods++
+			addResource(demand.Resources, task.resource)
+		}
+		app.lock.RUnlock()
+	}
+
+	sort.Slice(demands, func(i, j int) bool {
+		if demands[i].Queue != demands[j].Queue {
+			return demands[i].Queue < demands[j].Queue
+		}
+		return demands[i].Namespace < demands[j].Namespace
+	})
+	return demands
+}
@@ -0,0 +1,91 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+)
+
+func TestGetPendingDemand(t *testing.T) {
+	context := initContextForTest()
+	states := events.States().Task
+	for appID, queue := range map[string]string{"app01": "root.b", "app02": "root.a"} {
+		context.AddApplication(&interfaces.AddApplicationRequest{
+			Metadata: interfaces.ApplicationMetadata{
+				ApplicationID: appID,
+				QueueName:     queue,
+				User:          "test-user",
+			},
+		})
+	}
+	addTask := func(appID, taskID, namespace, state, taskGroup string, placeholder bool, vcore int64) {
+		task := context.AddTask(&interfaces.AddTaskRequest{
+			Metadata: interfaces.TaskMetadata{
+				ApplicationID: appID,
+				TaskID:        taskID,
+				Pod: &v1.Pod{
+					ObjectMeta: apis.ObjectMeta{
+						Name:      "pod-" + taskID,
+						Namespace: namespace,
+						UID:       types.UID(taskID),
+					},
+				},
+				Placeholder:   placeholder,
+				TaskGroupName: taskGroup,
+			},
+		}).(*Task)
+		task.resource = common.NewResourceBuilder().AddResource(constants.CPU, vcore).Build()
+		task.sm.SetState(state)
+	}
+	addTask("app01", "task01", "ns1", states.Pending, "", false, 100)
+	addTask("app01", "task02", "ns1", states.Scheduling, "", false, 200)
+	addTask("app01", "task03", "ns2", states.New, "", false, 300)
+	// allocated and ended tasks are not demand
+	addTask("app01", "task04", "ns1", states.Bound, "", false, 400)
+	addTask("app01", "task05", "ns1", states.Failed, "", false, 500)
+	// a pending placeholder is demand, the gang member of an allocated placeholder is not
+	addTask("app02", "task06", "ns1", states.Pending, "group-a", true, 10)
+	addTask("app02", "task07", "ns1", states.Bound, "group-b", true, 20)
+	addTask("app02", "task08", "ns1", states.Pending, "group-b", false, 20)
+
+	demand := context.GetPendingDemand()
+	assert.Equal(t, len(demand), 3)
+	assert.Equal(t, demand[0].Queue, "root.a")
+	assert.Equal(t, demand[0].Namespace, "ns1")
+	assert.Equal(t, demand[0].Pods, 1)
+	assert.DeepEqual(t, demand[0].Resources, map[string]int64{constants.CPU: 10})
+	assert.Equal(t, demand[1].Queue, "root.b")
+	assert.Equal(t, demand[1].Namespace, "ns1")
+	assert.Equal(t, demand[1].Pods, 2)
+	assert.DeepEqual(t, demand[1].Resources, map[string]int64{constants.CPU: 300})
+	assert.Equal(t, demand[2].Queue, "root.b")
+	assert.Equal(t, demand[2].Namespace, "ns2")
+	assert.Equal(t, demand[2].Pods, 1)
+	assert.DeepEqual(t, demand[2].Resources, map[string]int64{constants.CPU: 300})
+}
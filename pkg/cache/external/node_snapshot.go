@@ -0,0 +1,186 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package external
+
+import (
+	"sync/atomic"
+
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// nodeSnapshot is the read-only view of the nodes used by the predicates.
+// A snapshot is never modified once stored: a change to the cache creates a new snapshot
+// that shares everything not touched by the change with the previous one.
+type nodeSnapshot struct {
+	// generation of the cache the snapshot was taken at
+	generation  int64
+	nodeInfoMap map[string]*framework.NodeInfo
+	// position of each node in the nodeInfoList
+	index                                map[string]int
+	nodeInfoList                         []*framework.NodeInfo
+	havePodsWithAffinityList             []*framework.NodeInfo
+	havePodsWithRequiredAntiAffinityList []*framework.NodeInfo
+}
+
+// GetNodesInfoSnapshot returns the node infos at the time of the call without copying them
+// after every call, neither the map nor the node infos must be modified
+func (cache *SchedulerCache) GetNodesInfoSnapshot() map[string]*framework.NodeInfo {
+	return cache.getSnapshot().nodeInfoMap
+}
+
+// GetNodesInfoList returns the node infos of the snapshot as a list, the list must not be modified
+func (cache *SchedulerCache) GetNodesInfoList() []*framework.NodeInfo {
+	return cache.getSnapshot().nodeInfoList
+}
+
+// GetNodesWithAffinityList returns the nodes of the snapshot that have pods with affinity,
+// the list must not be modified
+func (cache *SchedulerCache) GetNodesWithAffinityList() []*framework.NodeInfo {
+	return cache.getSnapshot().havePodsWithAffinityList
+}
+
+// GetNodesWithRequiredAntiAffinityList returns the nodes of the snapshot that have pods with
+// required anti-affinity, the list must not be modified
+func (cache *SchedulerCache) GetNodesWithRequiredAntiAffinityList() []*framework.NodeInfo {
+	return cache.getSnapshot().havePodsWithRequiredAntiAffinityList
+}
+
+// returns the current snapshot, refreshing it first if the cache changed since it was taken
+func (cache *SchedulerCache) getSnapshot() *nodeSnapshot {
+	if snapshot := cache.loadSnapshot(); snapshot != nil && snapshot.generation == atomic.LoadInt64(&cache.generation) {
+		return snapshot
+	}
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	// another reader may have refreshed it while waiting for the lock
+	snapshot := cache.loadSnapshot()
+	if snapshot != nil && snapshot.generation == cache.generation {
+		return snapshot
+	}
+	if snapshot == nil {
+		snapshot = cache.buildSnapshot()
+	} else {
+		snapshot = cache.updateSnapshot(snapshot)
+	}
+	cache.dirtyNodes = make(map[string]bool)
+	cache.nodesSnapshot.Store(snapshot)
+	return snapshot
+}
+
+func (cache *SchedulerCache) loadSnapshot() *nodeSnapshot {
+	snapshot, ok := cache.nodesSnapshot.Load().(*nodeSnapshot)
+	if !ok {
+		return nil
+	}
+	return snapshot
+}
+
+// creates the snapshot from all nodes in the cache, the lock must be held
+func (cache *SchedulerCache) buildSnapshot() *nodeSnapshot {
+	snapshot := &nodeSnapshot{
+		generation:  cache.generation,
+		nodeInfoMap: make(map[string]*framework.NodeInfo, len(cache.nodesMap)),
+	}
+	for name, nodeInfo := range cache.nodesMap {
+		snapshot.nodeInfoMap[name] = nodeInfo
+	}
+	snapshot.rebuildList()
+	snapshot.rebuildAffinityLists()
+	return snapshot
+}
+
+// creates a new snapshot from the previous one, only the nodes that changed since the
+// previous snapshot are looked at, the lock must be held
+func (cache *SchedulerCache) updateSnapshot(prev *nodeSnapshot) *nodeSnapshot {
+	snapshot := &nodeSnapshot{
+		generation:                           cache.generation,
+		nodeInfoMap:                          make(map[string]*framework.NodeInfo, len(cache.nodesMap)),
+		index:                                prev.index,
+		havePodsWithAffinityList:             prev.havePodsWithAffinityList,
+		havePodsWithRequiredAntiAffinityList: prev.havePodsWithRequiredAntiAffinityList,
+	}
+	for name, nodeInfo := range prev.nodeInfoMap {
+		snapshot.nodeInfoMap[name] = nodeInfo
+	}
+	nodesChanged := false
+	affinityChanged := false
+	for name := range cache.dirtyNodes {
+		old, existed := prev.nodeInfoMap[name]
+		if existed && hasPodsWithAffinity(old) {
+			affinityChanged = true
+		}
+		nodeInfo, exists := cache.nodesMap[name]
+		if !exists {
+			if existed {
+				delete(snapshot.nodeInfoMap, name)
+				nodesChanged = true
+			}
+			continue
+		}
+		if !existed {
+			nodesChanged = true
+		}
+		if hasPodsWithAffinity(nodeInfo) {
+			affinityChanged = true
+		}
+		snapshot.nodeInfoMap[name] = nodeInfo
+	}
+	if nodesChanged {
+		snapshot.rebuildList()
+	} else {
+		// same nodes as before: replace the changed entries in place
+		snapshot.nodeInfoList = make([]*framework.NodeInfo, len(prev.nodeInfoList))
+		copy(snapshot.nodeInfoList, prev.nodeInfoList)
+		for name := range cache.dirtyNodes {
+			if nodeInfo, ok := snapshot.nodeInfoMap[name]; ok {
+				snapshot.nodeInfoList[snapshot.index[name]] = nodeInfo
+			}
+		}
+	}
+	if nodesChanged || affinityChanged {
+		snapshot.rebuildAffinityLists()
+	}
+	return snapshot
+}
+
+func (s *nodeSnapshot) rebuildList() {
+	s.index = make(map[string]int, len(s.nodeInfoMap))
+	s.nodeInfoList = make([]*framework.NodeInfo, 0, len(s.nodeInfoMap))
+	for name, nodeInfo := range s.nodeInfoMap {
+		s.index[name] = len(s.nodeInfoList)
+		s.nodeInfoList = append(s.nodeInfoList, nodeInfo)
+	}
+}
+
+func (s *nodeSnapshot) rebuildAffinityLists() {
+	s.havePodsWithAffinityList = make([]*framework.NodeInfo, 0)
+	s.havePodsWithRequiredAntiAffinityList = make([]*framework.NodeInfo, 0)
+	for _, nodeInfo := range s.nodeInfoList {
+		if len(nodeInfo.PodsWithAffinity) > 0 {
+			s.havePodsWithAffinityList = append(s.havePodsWithAffinityList, nodeInfo)
+		}
+		if len(nodeInfo.PodsWithRequiredAntiAffinity) > 0 {
+			s.havePodsWithRequiredAntiAffinityList = append(s.havePodsWithRequiredAntiAffinityList, nodeInfo)
+		}
+	}
+}
+
+func hasPodsWithAffinity(nodeInfo *framework.NodeInfo) bool {
+	return len(nodeInfo.PodsWithAffinity) > 0 || len(nodeInfo.PodsWithRequiredAntiAffinity) > 0
+}
@@ -0,0 +1,144 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package external
+
+import (
+	"sort"
+	"testing"
+
+	"gotest.tools/assert"
+
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+)
+
+func snapshotTestNode(name string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: apis.ObjectMeta{
+			Name: name,
+			UID:  types.UID("UID-" + name),
+		},
+	}
+}
+
+func snapshotTestPod(name, nodeName string, affinity *v1.Affinity) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name: name,
+			UID:  types.UID("UID-" + name),
+		},
+		Spec: v1.PodSpec{
+			NodeName: nodeName,
+			Affinity: affinity,
+		},
+	}
+}
+
+func TestSnapshotGeneration(t *testing.T) {
+	cache := NewSchedulerCache(client.NewMockedAPIProvider().GetAPIs())
+	cache.AddNode(snapshotTestNode("host0001"))
+	cache.AddNode(snapshotTestNode("host0002"))
+	snapshot := cache.getSnapshot()
+	assert.Equal(t, len(snapshot.nodeInfoList), 2)
+	assert.Equal(t, len(cache.dirtyNodes), 0)
+	// no change: the same snapshot is returned
+	assert.Assert(t, cache.getSnapshot() == snapshot)
+
+	// a pod change only replaces the node it is on
+	assert.NilError(t, cache.AssumePod(snapshotTestPod("pod0001", "host0001", nil), true))
+	assert.Equal(t, len(cache.dirtyNodes), 1)
+	updated := cache.getSnapshot()
+	assert.Assert(t, updated != snapshot)
+	assert.Assert(t, updated.generation > snapshot.generation)
+	assert.Equal(t, len(updated.nodeInfoList), 2)
+	assert.Assert(t, updated.nodeInfoMap["host0002"] == snapshot.nodeInfoMap["host0002"])
+	assert.Assert(t, updated.nodeInfoMap["host0001"] == cache.GetNode("host0001"))
+	assert.Assert(t, updated.nodeInfoList[updated.index["host0001"]] == cache.GetNode("host0001"))
+	// the list is not rebuilt when the nodes stay the same
+	assert.Assert(t, updated.index["host0001"] == snapshot.index["host0001"])
+	// the previous snapshot is left untouched
+	assert.Equal(t, len(snapshot.nodeInfoMap["host0001"].Pods), 0)
+	assert.Assert(t, snapshot.nodeInfoList[snapshot.index["host0001"]] == snapshot.nodeInfoMap["host0001"])
+
+	// adding and removing nodes
+	cache.AddNode(snapshotTestNode("host0003"))
+	assert.NilError(t, cache.RemoveNode(snapshotTestNode("host0002")))
+	updated = cache.getSnapshot()
+	assert.Equal(t, len(updated.nodeInfoList), 2)
+	assert.Equal(t, len(updated.nodeInfoMap), 2)
+	_, ok := updated.nodeInfoMap["host0002"]
+	assert.Assert(t, !ok)
+	assert.Assert(t, updated.nodeInfoList[updated.index["host0003"]] == cache.GetNode("host0003"))
+}
+
+func TestSnapshotAffinityLists(t *testing.T) {
+	cache := NewSchedulerCache(client.NewMockedAPIProvider().GetAPIs())
+	cache.AddNode(snapshotTestNode("host0001"))
+	cache.AddNode(snapshotTestNode("host0002"))
+	assert.Equal(t, len(cache.GetNodesWithAffinityList()), 0)
+	assert.Equal(t, len(cache.GetNodesWithRequiredAntiAffinityList()), 0)
+
+	affinity := &v1.Affinity{
+		PodAffinity: &v1.PodAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []v1.WeightedPodAffinityTerm{
+				{Weight: 1, PodAffinityTerm: v1.PodAffinityTerm{TopologyKey: "zone"}},
+			},
+		},
+	}
+	antiAffinity := &v1.Affinity{
+		PodAntiAffinity: &v1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{
+				{TopologyKey: "zone"},
+			},
+		},
+	}
+	withAffinity := snapshotTestPod("pod0001", "host0001", affinity)
+	assert.NilError(t, cache.AssumePod(withAffinity, true))
+	assert.NilError(t, cache.AssumePod(snapshotTestPod("pod0002", "host0002", antiAffinity), true))
+	// the pods with anti-affinity are in the affinity list too
+	nodes := cache.GetNodesWithAffinityList()
+	assert.Equal(t, len(nodes), 2)
+	names := []string{nodes[0].Node().Name, nodes[1].Node().Name}
+	sort.Strings(names)
+	assert.DeepEqual(t, names, []string{"host0001", "host0002"})
+	nodes = cache.GetNodesWithRequiredAntiAffinityList()
+	assert.Equal(t, len(nodes), 1)
+	assert.Equal(t, nodes[0].Node().Name, "host0002")
+
+	// a node without affinity pods does not change the lists
+	cache.AddNode(snapshotTestNode("host0003"))
+	cache.getSnapshot()
+	before := cache.GetNodesWithAffinityList()
+	assert.NilError(t, cache.AssumePod(snapshotTestPod("pod0003", "host0003", nil), true))
+	after := cache.GetNodesWithAffinityList()
+	assert.Equal(t, len(after), 2)
+	assert.Assert(t, &after[0] == &before[0])
+
+	// removing the last affinity pod drops the node from the list
+	assert.NilError(t, cache.ForgetPod(withAffinity))
+	nodes = cache.GetNodesWithAffinityList()
+	assert.Equal(t, len(nodes), 1)
+	assert.Equal(t, nodes[0].Node().Name, "host0002")
+	assert.Equal(t, len(cache.GetNodesWithRequiredAntiAffinityList()), 1)
+	// the earlier list is left untouched
+	assert.Equal(t, len(before), 2)
+}
@@ -21,6 +21,7 @@ package external
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
@@ -29,16 +30,30 @@ import (
 	"k8s.io/kubernetes/pkg/scheduler/framework"
 
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
 )
 
+// a decoded kubernetes object takes a few times the size of its protobuf encoding in memory
+const decodedObjectFactor = 3
+
 // scheduler cache maintains some critical information about nodes and pods used for scheduling
 // nodes are cached in the form of de-scheduler nodeInfo, instead of re-creating all nodes info from scratch,
 // we replicate nodes info from de-scheduler, in order to re-use predicates functions.
+// A cached nodeInfo is never modified: a change replaces it with an updated clone, the predicates
+// can use a nodeInfo without holding the lock.
 type SchedulerCache struct {
 	// node name to NodeInfo map
 	nodesMap map[string]*framework.NodeInfo
-	podsMap  map[string]*v1.Pod
+	// read-only view of the nodes for the predicates, refreshed on the first read after a change
+	nodesSnapshot atomic.Value
+	// incremented on every change of the nodes, read without the lock
+	generation int64
+	// the nodes changed since the last snapshot
+	dirtyNodes map[string]bool
+	podsMap    map[string]*v1.Pod
+	// the cached pods by the node they are placed on, then by pod key
+	podsByNode map[string]map[string]*v1.Pod
 	// this is a map of assumed pods,
 	// the value indicates if a pod volumes are all bound
 	assumedPods map[string]bool
@@ -50,27 +65,97 @@ type SchedulerCache struct {
 func NewSchedulerCache(clients *client.Clients) *SchedulerCache {
 	cache := &SchedulerCache{
 		nodesMap:    make(map[string]*framework.NodeInfo),
+		dirtyNodes:  make(map[string]bool),
 		podsMap:     make(map[string]*v1.Pod),
+		podsByNode:  make(map[string]map[string]*v1.Pod),
 		assumedPods: make(map[string]bool),
 		clients:     clients,
 	}
 	return cache
 }
 
+// GetObjectCounts returns the number of nodes, pods and assumed pods in the cache
+func (cache *SchedulerCache) GetObjectCounts() map[string]int {
+	cache.lock.RLock()
+	defer cache.lock.RUnlock()
+	return map[string]int{
+		"nodes":        len(cache.nodesMap),
+		"pods":         len(cache.podsMap),
+		"assumed_pods": len(cache.assumedPods),
+	}
+}
+
+// GetMemoryUsage returns the approximate number of bytes held by the cached nodes and pods
+func (cache *SchedulerCache) GetMemoryUsage() int64 {
+	cache.lock.RLock()
+	defer cache.lock.RUnlock()
+	var bytes int64
+	for _, info := range cache.nodesMap {
+		if node := info.Node(); node != nil {
+			bytes += int64(node.Size()) * decodedObjectFactor
+		}
+	}
+	for _, pod := range cache.podsMap {
+		bytes += int64(pod.Size()) * decodedObjectFactor
+	}
+	return bytes
+}
+
+// RemoveTerminatedPods drops the succeeded and the failed pods from the cache, they do not use
+// the resources of their node anymore. Returns the number of pods removed.
+func (cache *SchedulerCache) RemoveTerminatedPods() int {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	removed := 0
+	for key, pod := range cache.podsMap {
+		if !utils.IsPodTerminated(pod) || cache.isAssumedPod(key) {
+			continue
+		}
+		if err := cache.removePod(pod); err != nil {
+			log.Log(log.Cache).Debug("failed to remove terminated pod from its node",
+				zap.String("pod", key),
+				zap.Error(err))
+		}
+		cache.deletePod(key)
+		removed++
+	}
+	return removed
+}
+
 func (cache *SchedulerCache) GetNodesInfoMap() map[string]*framework.NodeInfo {
 	return cache.nodesMap
 }
 
+// GetNodesInfoMapCopy returns a copy of the map, the node infos are shared and must not be modified
 func (cache *SchedulerCache) GetNodesInfoMapCopy() map[string]*framework.NodeInfo {
-	cache.lock.RLock()
-	defer cache.lock.RUnlock()
-	copyOfMap := make(map[string]*framework.NodeInfo, len(cache.nodesMap))
-	for k, v := range cache.nodesMap {
-		copyOfMap[k] = v.Clone()
+	snapshot := cache.GetNodesInfoSnapshot()
+	copyOfMap := make(map[string]*framework.NodeInfo, len(snapshot))
+	for k, v := range snapshot {
+		copyOfMap[k] = v
 	}
 	return copyOfMap
 }
 
+// returns a clone of the node info to be modified and stored with setNode, the lock must be held
+func (cache *SchedulerCache) cloneNode(name string) *framework.NodeInfo {
+	if n, ok := cache.nodesMap[name]; ok {
+		return n.Clone()
+	}
+	return framework.NewNodeInfo()
+}
+
+// replaces the node info, the lock must be held
+func (cache *SchedulerCache) setNode(name string, n *framework.NodeInfo) {
+	cache.nodesMap[name] = n
+	cache.markDirty(name)
+}
+
+// the node was added, changed or removed, the lock must be held
+func (cache *SchedulerCache) markDirty(name string) {
+	cache.dirtyNodes[name] = true
+	atomic.AddInt64(&cache.generation, 1)
+}
+
 func (cache *SchedulerCache) GetNode(name string) *framework.NodeInfo {
 	cache.lock.RLock()
 	defer cache.lock.RUnlock()
@@ -85,34 +170,76 @@ func (cache *SchedulerCache) AddNode(node *v1.Node) {
 	cache.lock.Lock()
 	defer cache.lock.Unlock()
 
-	_, ok := cache.nodesMap[node.Name]
-	if !ok {
-		n := framework.NewNodeInfo()
-		cache.nodesMap[node.Name] = n
-	}
-
+	n := cache.cloneNode(node.Name)
 	// make sure the node is always linked to the cached node object
 	// Currently, SetNode API call always returns nil, never an error
-	if err := cache.nodesMap[node.Name].SetNode(node); err != nil {
+	if err := n.SetNode(node); err != nil {
 		// currently, this may never reached because SetNode always return nil
 		// keep the check around to prevent the API changes to provide an error in some cases
-		log.Logger().Error("failed to store v1.Node in cache", zap.Error(err))
+		log.Log(log.Cache).Error("failed to store v1.Node in cache", zap.Error(err))
+	}
+	cache.setNode(node.Name, n)
+}
+
+// LoadNodesAndPods adds the listed nodes and pods to the cache under a single lock, every node is cloned
+// at most once however many pods it runs. Used at startup instead of adding the objects one at a time,
+// the pods already in the cache are left alone. Returns the number of pods added.
+func (cache *SchedulerCache) LoadNodesAndPods(nodes []*v1.Node, pods []*v1.Pod) int {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	changed := make(map[string]*framework.NodeInfo)
+	getNode := func(name string) *framework.NodeInfo {
+		n, ok := changed[name]
+		if !ok {
+			n = cache.cloneNode(name)
+			changed[name] = n
+		}
+		return n
+	}
+	for _, node := range nodes {
+		if err := getNode(node.Name).SetNode(node); err != nil {
+			log.Log(log.Cache).Error("failed to store v1.Node in cache", zap.Error(err))
+		}
+	}
+	added := 0
+	for _, pod := range pods {
+		key, err := framework.GetPodKey(pod)
+		if err != nil {
+			log.Log(log.Cache).Warn("listed pod is not added to the cache",
+				zap.String("podName", pod.Name),
+				zap.Error(err))
+			continue
+		}
+		if _, ok := cache.podsMap[key]; ok {
+			continue
+		}
+		if pod.Spec.NodeName != "" {
+			getNode(pod.Spec.NodeName).AddPod(pod)
+		}
+		cache.setPod(key, pod)
+		added++
+	}
+	for name, n := range changed {
+		cache.setNode(name, n)
 	}
+	return added
 }
 
 func (cache *SchedulerCache) UpdateNode(oldNode, newNode *v1.Node) error {
 	cache.lock.Lock()
 	defer cache.lock.Unlock()
 
-	n, ok := cache.nodesMap[newNode.Name]
-	if !ok {
-		log.Logger().Warn("updated node info not found, adding it to the cache",
+	if _, ok := cache.nodesMap[newNode.Name]; !ok {
+		log.Log(log.Cache).Warn("updated node info not found, adding it to the cache",
 			zap.String("nodeName", newNode.Name))
-		n = framework.NewNodeInfo()
-		cache.nodesMap[newNode.Name] = n
 	}
-
-	return n.SetNode(newNode)
+	n := cache.cloneNode(newNode.Name)
+	if err := n.SetNode(newNode); err != nil {
+		return err
+	}
+	cache.setNode(newNode.Name, n)
+	return nil
 }
 
 func (cache *SchedulerCache) RemoveNode(node *v1.Node) error {
@@ -129,6 +256,13 @@ func (cache *SchedulerCache) removeNode(node *v1.Node) error {
 	}
 
 	delete(cache.nodesMap, node.Name)
+	cache.markDirty(node.Name)
+	// the pods placed on the node go with it, an assumed pod is left for its binding to fail
+	for key := range cache.podsByNode[node.Name] {
+		if !cache.isAssumedPod(key) {
+			cache.deletePod(key)
+		}
+	}
 	return nil
 }
 
@@ -161,26 +295,26 @@ func (cache *SchedulerCache) AddPod(pod *v1.Pod) error {
 	case ok && cache.isAssumedPod(key):
 		if currState.Spec.NodeName != pod.Spec.NodeName {
 			// The pod was added to a different node than it was assumed to.
-			log.Logger().Warn("inconsistent pod location",
+			log.Log(log.Cache).Warn("inconsistent pod location",
 				zap.String("assumedLocation", pod.Spec.NodeName),
 				zap.String("actualLocation", currState.Spec.NodeName))
 
 			// Clean this up.
 			err = cache.removePod(currState)
 			if err != nil {
-				log.Logger().Debug("node not in cache",
+				log.Log(log.Cache).Debug("node not in cache",
 					zap.Error(err))
 			}
 			cache.addPod(pod)
 		}
 		delete(cache.assumedPods, key)
-		cache.podsMap[key] = pod
+		cache.setPod(key, pod)
 	case !ok:
 		// Pod was expired. We should add it back.
 		cache.addPod(pod)
-		cache.podsMap[key] = pod
+		cache.setPod(key, pod)
 	default:
-		log.Logger().Debug("pod was already in added state", zap.String("pod", key))
+		log.Sampled(log.Cache).Debug("pod was already in added state", zap.String("pod", key))
 	}
 	return nil
 }
@@ -200,13 +334,13 @@ func (cache *SchedulerCache) UpdatePod(oldPod, newPod *v1.Pod) error {
 	// before Update event, in which case the state would change from Assumed to Added.
 	case ok && !cache.isAssumedPod(key):
 		if currState.Spec.NodeName != newPod.Spec.NodeName {
-			log.Logger().Error("pod updated on a different node than previously added to", zap.String("pod", key))
-			log.Logger().Error("scheduler cache is corrupted and can badly affect scheduling decisions")
+			log.Log(log.Cache).Error("pod updated on a different node than previously added to", zap.String("pod", key))
+			log.Log(log.Cache).Error("scheduler cache is corrupted and can badly affect scheduling decisions")
 		}
 		if err = cache.updatePod(oldPod, newPod); err != nil {
 			return err
 		}
-		cache.podsMap[key] = newPod
+		cache.setPod(key, newPod)
 	default:
 		return fmt.Errorf("pod %v is not added to scheduler cache, so cannot be updated", key)
 	}
@@ -216,12 +350,9 @@ func (cache *SchedulerCache) UpdatePod(oldPod, newPod *v1.Pod) error {
 // Assumes that lock is already acquired.
 func (cache *SchedulerCache) addPod(pod *v1.Pod) {
 	if pod.Spec.NodeName != "" {
-		n, ok := cache.nodesMap[pod.Spec.NodeName]
-		if !ok {
-			n = framework.NewNodeInfo()
-			cache.nodesMap[pod.Spec.NodeName] = n
-		}
+		n := cache.cloneNode(pod.Spec.NodeName)
 		n.AddPod(pod)
+		cache.setNode(pod.Spec.NodeName, n)
 	}
 }
 
@@ -233,25 +364,75 @@ func (cache *SchedulerCache) updatePod(oldPod, newPod *v1.Pod) error {
 	return nil
 }
 
+// RemovePod removes the pod from its node and drops it from the cache
 func (cache *SchedulerCache) RemovePod(pod *v1.Pod) error {
 	cache.lock.Lock()
 	defer cache.lock.Unlock()
-	return cache.removePod(pod)
+	err := cache.removePod(pod)
+	if key, keyErr := framework.GetPodKey(pod); keyErr == nil {
+		delete(cache.assumedPods, key)
+		cache.deletePod(key)
+	}
+	return err
 }
 
 func (cache *SchedulerCache) removePod(pod *v1.Pod) error {
 	if pod.Spec.NodeName != "" {
-		n, ok := cache.nodesMap[pod.Spec.NodeName]
-		if !ok {
+		if _, ok := cache.nodesMap[pod.Spec.NodeName]; !ok {
 			return fmt.Errorf("node %v is not found", pod.Spec.NodeName)
 		}
+		n := cache.cloneNode(pod.Spec.NodeName)
 		if err := n.RemovePod(pod); err != nil {
 			return err
 		}
+		cache.setNode(pod.Spec.NodeName, n)
 	}
 	return nil
 }
 
+// GetPodsOnNode returns the cached pods placed on the node, assumed or bound
+func (cache *SchedulerCache) GetPodsOnNode(nodeName string) []*v1.Pod {
+	cache.lock.RLock()
+	defer cache.lock.RUnlock()
+	pods := make([]*v1.Pod, 0, len(cache.podsByNode[nodeName]))
+	for _, pod := range cache.podsByNode[nodeName] {
+		pods = append(pods, pod)
+	}
+	return pods
+}
+
+// stores the pod and indexes it by the node it is placed on, the lock must be held
+func (cache *SchedulerCache) setPod(key string, pod *v1.Pod) {
+	cache.unindexPod(key)
+	cache.podsMap[key] = pod
+	if pod.Spec.NodeName != "" {
+		pods, ok := cache.podsByNode[pod.Spec.NodeName]
+		if !ok {
+			pods = make(map[string]*v1.Pod)
+			cache.podsByNode[pod.Spec.NodeName] = pods
+		}
+		pods[key] = pod
+	}
+}
+
+// the lock must be held
+func (cache *SchedulerCache) deletePod(key string) {
+	cache.unindexPod(key)
+	delete(cache.podsMap, key)
+}
+
+// the lock must be held
+func (cache *SchedulerCache) unindexPod(key string) {
+	if pod, ok := cache.podsMap[key]; ok && pod.Spec.NodeName != "" {
+		if pods, ok := cache.podsByNode[pod.Spec.NodeName]; ok {
+			delete(pods, key)
+			if len(pods) == 0 {
+				delete(cache.podsByNode, pod.Spec.NodeName)
+			}
+		}
+	}
+}
+
 func (cache *SchedulerCache) GetPod(uid string) (*v1.Pod, bool) {
 	cache.lock.RLock()
 	defer cache.lock.RUnlock()
@@ -274,12 +455,21 @@ func (cache *SchedulerCache) AssumePod(pod *v1.Pod, allBound bool) error {
 	//}
 
 	cache.addPod(pod)
-	cache.podsMap[key] = pod
+	cache.setPod(key, pod)
 	cache.assumedPods[key] = allBound
 
 	return nil
 }
 
+// AssumePodOnNode assumes a copy of the pod assigned to the node. Only the pod struct is copied, the
+// metadata, spec and status fields are shared with the original: pods come from the informers and are
+// never modified, a deep copy for every assumed pod is not needed.
+func (cache *SchedulerCache) AssumePodOnNode(pod *v1.Pod, nodeName string, allBound bool) error {
+	assumedPod := *pod
+	assumedPod.Spec.NodeName = nodeName
+	return cache.AssumePod(&assumedPod, allBound)
+}
+
 func (cache *SchedulerCache) ForgetPod(pod *v1.Pod) error {
 	key, err := framework.GetPodKey(pod)
 	if err != nil {
@@ -303,13 +493,65 @@ func (cache *SchedulerCache) ForgetPod(pod *v1.Pod) error {
 			return err
 		}
 		delete(cache.assumedPods, key)
-		delete(cache.podsMap, key)
+		cache.deletePod(key)
 	default:
 		return fmt.Errorf("pod %v wasn't assumed so cannot be forgotten", key)
 	}
 	return nil
 }
 
+// ReplacePods rebuilds the pods of the cache from the listed pods, the pods that are not listed are
+// dropped. An assumed pod that is still listed keeps its assumed node, it may not be bound yet.
+// Returns the number of pods dropped from the cache.
+func (cache *SchedulerCache) ReplacePods(pods []*v1.Pod) int {
+	listed := make(map[string]*v1.Pod, len(pods))
+	for _, pod := range pods {
+		key, err := framework.GetPodKey(pod)
+		if err != nil {
+			log.Log(log.Cache).Warn("listed pod is not added to the cache",
+				zap.String("podName", pod.Name),
+				zap.Error(err))
+			continue
+		}
+		listed[key] = pod
+	}
+
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	dropped := 0
+	for key := range cache.podsMap {
+		if _, ok := listed[key]; !ok {
+			delete(cache.assumedPods, key)
+			dropped++
+		}
+	}
+	// the node infos are rebuilt without pods, the node infos only created for pods are dropped
+	for name, nodeInfo := range cache.nodesMap {
+		cache.markDirty(name)
+		node := nodeInfo.Node()
+		if node == nil {
+			delete(cache.nodesMap, name)
+			continue
+		}
+		nodeInfo = framework.NewNodeInfo()
+		if err := nodeInfo.SetNode(node); err != nil {
+			log.Log(log.Cache).Error("failed to store v1.Node in cache", zap.Error(err))
+		}
+		cache.nodesMap[name] = nodeInfo
+	}
+	previous := cache.podsMap
+	cache.podsMap = make(map[string]*v1.Pod, len(listed))
+	cache.podsByNode = make(map[string]map[string]*v1.Pod)
+	for key, pod := range listed {
+		if cache.isAssumedPod(key) {
+			pod = previous[key]
+		}
+		cache.addPod(pod)
+		cache.setPod(key, pod)
+	}
+	return dropped
+}
+
 // Implement k8s.io/client-go/listers/core/v1#PodLister interface
 func (cache *SchedulerCache) List(selector labels.Selector) ([]*v1.Pod, error) {
 	cache.lock.RLock()
@@ -27,6 +27,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
 )
@@ -235,6 +236,9 @@ func TestUpdateNode(t *testing.T) {
 
 	// make sure the node in cache also gets updated
 	// unschedulable -> schedulable
+	// the node info read before the update is replaced, not modified
+	assert.Equal(t, nodeInCache.Node().Spec.Unschedulable, true)
+	nodeInCache = cache.GetNode("host0001")
 	assert.Assert(t, nodeInCache.Node() != nil)
 	assert.Equal(t, nodeInCache.Node().Name, "host0001")
 	assert.Equal(t, nodeInCache.Node().Spec.Unschedulable, false)
@@ -334,3 +338,286 @@ func TestGetNodesInfoMapCopy(t *testing.T) {
 		assert.Equal(t, len(v.Node().Annotations), 3)
 	}
 }
+
+func TestGetObjectCounts(t *testing.T) {
+	cache := NewSchedulerCache(client.NewMockedAPIProvider().GetAPIs())
+	assert.DeepEqual(t, cache.GetObjectCounts(), map[string]int{"nodes": 0, "pods": 0, "assumed_pods": 0})
+
+	cache.AddNode(&v1.Node{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "host0001",
+			UID:  "Node-UID-00001",
+		},
+	})
+	for i := 0; i < 3; i++ {
+		pod := &v1.Pod{
+			ObjectMeta: apis.ObjectMeta{
+				Name:      fmt.Sprintf("pod-%d", i),
+				Namespace: "default",
+				UID:       types.UID(fmt.Sprintf("Pod-UID-%d", i)),
+			},
+			Spec: v1.PodSpec{
+				NodeName: "host0001",
+			},
+		}
+		if i == 0 {
+			assert.NilError(t, cache.AssumePod(pod, true))
+		} else {
+			assert.NilError(t, cache.AddPod(pod))
+		}
+	}
+	assert.DeepEqual(t, cache.GetObjectCounts(), map[string]int{"nodes": 1, "pods": 3, "assumed_pods": 1})
+}
+
+func TestReplacePods(t *testing.T) {
+	cache := NewSchedulerCache(client.NewMockedAPIProvider().GetAPIs())
+	cache.AddNode(&v1.Node{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "host0001",
+			UID:  "Node-UID-00001",
+		},
+	})
+	pods := make([]*v1.Pod, 0)
+	for i := 0; i < 3; i++ {
+		pod := &v1.Pod{
+			ObjectMeta: apis.ObjectMeta{
+				Name:      fmt.Sprintf("pod-%d", i),
+				Namespace: "default",
+				UID:       types.UID(fmt.Sprintf("Pod-UID-%d", i)),
+			},
+			Spec: v1.PodSpec{
+				NodeName: "host0001",
+			},
+		}
+		pods = append(pods, pod)
+	}
+	assert.NilError(t, cache.AssumePod(pods[0], false))
+	assert.NilError(t, cache.AddPod(pods[1]))
+	assert.NilError(t, cache.AddPod(pods[2]))
+	// pod on a node that is not in the cache
+	assert.NilError(t, cache.AddPod(&v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "pod-3",
+			UID:  "Pod-UID-3",
+		},
+		Spec: v1.PodSpec{
+			NodeName: "host0002",
+		},
+	}))
+
+	// the assumed pod is listed without its node, it is not bound yet
+	unbound := pods[0].DeepCopy()
+	unbound.Spec.NodeName = ""
+	assert.Equal(t, cache.ReplacePods([]*v1.Pod{unbound, pods[1]}), 2)
+	assert.DeepEqual(t, cache.GetObjectCounts(), map[string]int{"nodes": 1, "pods": 2, "assumed_pods": 1})
+	assert.Assert(t, cache.GetNode("host0002") == nil)
+	// nolint:staticcheck
+	assert.Equal(t, len(cache.GetNode("host0001").Pods), 2)
+	assumed, ok := cache.GetPod("Pod-UID-0")
+	assert.Assert(t, ok)
+	assert.Equal(t, assumed.Spec.NodeName, "host0001")
+	_, ok = cache.GetPod("Pod-UID-2")
+	assert.Assert(t, !ok)
+}
+
+func TestGetNodesInfoSnapshot(t *testing.T) {
+	cache := NewSchedulerCache(client.NewMockedAPIProvider().GetAPIs())
+	node := &v1.Node{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "host0001",
+			UID:  "Node-UID-00001",
+		},
+	}
+	cache.AddNode(node)
+	snapshot := cache.GetNodesInfoSnapshot()
+	assert.Equal(t, len(snapshot), 1)
+	// the snapshot is reused until the cache changes
+	before := snapshot["host0001"]
+	assert.Assert(t, cache.GetNodesInfoSnapshot()["host0001"] == before)
+
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "pod0001",
+			UID:  "Pod-UID-00001",
+		},
+		Spec: v1.PodSpec{NodeName: "host0001"},
+	}
+	assert.NilError(t, cache.AssumePod(pod, true))
+	// the node infos in the earlier snapshot are not modified
+	assert.Equal(t, len(before.Pods), 0)
+	after := cache.GetNodesInfoSnapshot()["host0001"]
+	assert.Assert(t, after != before)
+	assert.Equal(t, len(after.Pods), 1)
+	assert.Assert(t, cache.GetNode("host0001") == after)
+
+	assert.NilError(t, cache.RemoveNode(node))
+	assert.Equal(t, len(cache.GetNodesInfoSnapshot()), 0)
+	assert.Equal(t, len(snapshot), 1)
+}
+
+func TestLoadNodesAndPods(t *testing.T) {
+	cache := NewSchedulerCache(client.NewMockedAPIProvider().GetAPIs())
+	nodes := []*v1.Node{
+		{ObjectMeta: apis.ObjectMeta{Name: "host0001", UID: "Node-UID-00001"}},
+		{ObjectMeta: apis.ObjectMeta{Name: "host0002", UID: "Node-UID-00002"}},
+	}
+	pods := make([]*v1.Pod, 0)
+	for i := 0; i < 3; i++ {
+		pods = append(pods, &v1.Pod{
+			ObjectMeta: apis.ObjectMeta{
+				Name: fmt.Sprintf("pod%04d", i),
+				UID:  types.UID(fmt.Sprintf("Pod-UID-%05d", i)),
+			},
+			Spec: v1.PodSpec{NodeName: "host0001"},
+		})
+	}
+	// not assigned yet
+	pods = append(pods, &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{Name: "pod0003", UID: "Pod-UID-00003"},
+	})
+	// already in the cache
+	assert.NilError(t, cache.AssumePod(pods[0], true))
+
+	assert.Equal(t, cache.LoadNodesAndPods(nodes, pods), 3)
+	assert.Equal(t, len(cache.GetNodesInfoSnapshot()), 2)
+	assert.Equal(t, len(cache.GetNode("host0001").Pods), 3)
+	assert.Equal(t, len(cache.GetNode("host0002").Pods), 0)
+	assert.Equal(t, cache.GetNode("host0002").Node().Name, "host0002")
+	_, ok := cache.GetPod("Pod-UID-00003")
+	assert.Assert(t, ok)
+	// the assumed pod is not replaced
+	assert.Assert(t, cache.isAssumedPod("Pod-UID-00000"))
+
+	// loading again does not add anything
+	assert.Equal(t, cache.LoadNodesAndPods(nodes, pods), 0)
+	assert.Equal(t, len(cache.GetNode("host0001").Pods), 3)
+}
+
+func TestAssumePodOnNode(t *testing.T) {
+	cache := NewSchedulerCache(client.NewMockedAPIProvider().GetAPIs())
+	cache.AddNode(&v1.Node{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "host0001",
+			UID:  "Node-UID-00001",
+		},
+	})
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name:   "pod0001",
+			UID:    "Pod-UID-00001",
+			Labels: map[string]string{"applicationId": "app0001"},
+		},
+	}
+	assert.NilError(t, cache.AddPod(pod))
+	assert.NilError(t, cache.AssumePodOnNode(pod, "host0001", true))
+	// the original pod is not modified
+	assert.Equal(t, pod.Spec.NodeName, "")
+	assumed, ok := cache.GetPod("Pod-UID-00001")
+	assert.Assert(t, ok)
+	assert.Assert(t, assumed != pod)
+	assert.Equal(t, assumed.Spec.NodeName, "host0001")
+	assert.Equal(t, assumed.Labels["applicationId"], "app0001")
+	assert.Assert(t, cache.isAssumedPod("Pod-UID-00001"))
+	assert.Equal(t, len(cache.GetNode("host0001").Pods), 1)
+}
+
+func TestGetPodsOnNode(t *testing.T) {
+	cache := NewSchedulerCache(client.NewMockedAPIProvider().GetAPIs())
+	node := &v1.Node{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "host0001",
+			UID:  "Node-UID-00001",
+		},
+	}
+	cache.AddNode(node)
+	newPod := func(i int, nodeName string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: apis.ObjectMeta{
+				Name: fmt.Sprintf("pod%04d", i),
+				UID:  types.UID(fmt.Sprintf("Pod-UID-%05d", i)),
+			},
+			Spec: v1.PodSpec{NodeName: nodeName},
+		}
+	}
+	assert.NilError(t, cache.AddPod(newPod(1, "host0001")))
+	assert.NilError(t, cache.AddPod(newPod(2, "host0002")))
+	assert.NilError(t, cache.AddPod(newPod(3, "")))
+	assert.Equal(t, len(cache.GetPodsOnNode("host0001")), 1)
+	assert.Equal(t, len(cache.GetPodsOnNode("host0002")), 1)
+	assert.Equal(t, len(cache.GetPodsOnNode("")), 0)
+
+	// assumed on a node
+	assert.NilError(t, cache.AssumePodOnNode(newPod(3, ""), "host0001", true))
+	assert.Equal(t, len(cache.GetPodsOnNode("host0001")), 2)
+	// forgotten
+	assumed, ok := cache.GetPod("Pod-UID-00003")
+	assert.Assert(t, ok)
+	assert.NilError(t, cache.ForgetPod(assumed))
+	assert.Equal(t, len(cache.GetPodsOnNode("host0001")), 1)
+	_, ok = cache.GetPod("Pod-UID-00003")
+	assert.Assert(t, !ok)
+
+	// removed pods are dropped from the cache
+	assert.NilError(t, cache.RemovePod(newPod(2, "host0002")))
+	assert.Equal(t, len(cache.GetPodsOnNode("host0002")), 0)
+	_, ok = cache.GetPod("Pod-UID-00002")
+	assert.Assert(t, !ok)
+
+	// the pods go with the removed node, the assumed pods stay
+	assert.NilError(t, cache.AssumePod(newPod(4, "host0001"), false))
+	assert.NilError(t, cache.RemoveNode(node))
+	pods := cache.GetPodsOnNode("host0001")
+	assert.Equal(t, len(pods), 1)
+	assert.Equal(t, pods[0].Name, "pod0004")
+	_, ok = cache.GetPod("Pod-UID-00001")
+	assert.Assert(t, !ok)
+
+	// rebuilt from the listed pods
+	cache.AddNode(node)
+	assert.Equal(t, cache.ReplacePods([]*v1.Pod{newPod(4, ""), newPod(5, "host0001")}), 0)
+	assert.Equal(t, len(cache.GetPodsOnNode("host0001")), 2)
+}
+
+func TestRemoveTerminatedPods(t *testing.T) {
+	cache := NewSchedulerCache(client.NewMockedAPIProvider().GetAPIs())
+	assert.Equal(t, cache.GetMemoryUsage(), int64(0))
+
+	cache.AddNode(&v1.Node{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "host0001",
+			UID:  "Node-UID-00001",
+		},
+	})
+	phases := []v1.PodPhase{v1.PodRunning, v1.PodSucceeded, v1.PodFailed, v1.PodSucceeded}
+	for i, phase := range phases {
+		pod := &v1.Pod{
+			ObjectMeta: apis.ObjectMeta{
+				Name:      fmt.Sprintf("pod-%d", i),
+				Namespace: "default",
+				UID:       types.UID(fmt.Sprintf("Pod-UID-%d", i)),
+			},
+			Spec: v1.PodSpec{
+				NodeName: "host0001",
+			},
+			Status: v1.PodStatus{
+				Phase: phase,
+			},
+		}
+		// an assumed pod is kept until the scheduler forgets it
+		if i == 3 {
+			assert.NilError(t, cache.AssumePod(pod, true))
+		} else {
+			assert.NilError(t, cache.AddPod(pod))
+		}
+	}
+	before := cache.GetMemoryUsage()
+	assert.Assert(t, before > 0)
+
+	assert.Equal(t, cache.RemoveTerminatedPods(), 2)
+	assert.Equal(t, cache.GetObjectCounts()["pods"], 2)
+	assert.Equal(t, len(cache.GetPodsOnNode("host0001")), 2)
+	_, ok := cache.GetPod("Pod-UID-1")
+	assert.Assert(t, !ok)
+	assert.Assert(t, cache.GetMemoryUsage() < before)
+	assert.Equal(t, cache.RemoveTerminatedPods(), 0)
+}
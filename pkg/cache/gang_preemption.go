@@ -0,0 +1,83 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/dispatcher"
+)
+
+// the style of the gang applications that can only run with all their members
+const hardGangStyle = "Hard"
+
+// the evicted victim was a member of a gang, the gang is coordinated with the policy of the preemption
+// without holding the locks of the victim
+func (task *Task) coordinateGang(p *preemption, result string) {
+	if result == evictionFailed || task.IsPlaceholder() ||
+		(p.gangPolicy != conf.GangPolicyRestart && p.gangPolicy != conf.GangPolicyFail) {
+		return
+	}
+	go task.context.coordinateGangPreemption(task.applicationID, task, p.gangPolicy)
+}
+
+// a hard gang application does not make progress once one of its members is preempted, the other
+// members would keep their resources without doing useful work. They are evicted and the controllers
+// that own the pods create the gang again, with the fail policy the application fails as well.
+func (ctx *Context) coordinateGangPreemption(appID string, victim *Task, policy string) {
+	app, ok := ctx.getApplication(appID)
+	if !ok || app.getSchedulingStyle() != hardGangStyle || len(app.getTaskGroups()) == 0 {
+		return
+	}
+	reason := fmt.Sprintf("task %s of the gang is preempted", victim.alias)
+	app.logger().Info("coordinating the gang of a preempted task",
+		zap.String("taskID", victim.taskID),
+		zap.String("policy", policy))
+	if policy == conf.GangPolicyFail {
+		dispatcher.Dispatch(NewFailApplicationEvent(appID, constants.ApplicationGangPreemptedFailure+": "+reason))
+	}
+	states := events.States().Task
+	for _, task := range app.getAllTasks() {
+		if task == victim || task.IsPlaceholder() {
+			continue
+		}
+		if state := task.GetTaskState(); state != states.Allocated && state != states.Bound {
+			continue
+		}
+		task.lock.RLock()
+		preempted := task.preemption != nil
+		pod := task.pod
+		task.lock.RUnlock()
+		if preempted {
+			continue
+		}
+		events.GetRecorder().Eventf(pod, v1.EventTypeWarning, "GangPreempted",
+			"Task %s is evicted as the gang of application %s can not run: %s", task.alias, appID, reason)
+		result := task.evictPod(pod)
+		task.logger().Info("evicted member of a preempted gang",
+			zap.String("podName", pod.Name),
+			zap.String("result", result))
+	}
+}
@@ -0,0 +1,124 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/dispatcher"
+)
+
+// adds a gang application with the given style and its bound members, the last member is a placeholder
+func addGangApplication(context *Context, appID, style string, members int) (*Application, []*Task) {
+	context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: appID,
+			QueueName:     "root.a",
+			User:          "test-user",
+		},
+	})
+	app, _ := context.getApplication(appID)
+	app.setTaskGroups([]v1alpha1.TaskGroup{{Name: "workers", MinMember: int32(members)}})
+	app.setSchedulingStyle(style)
+	app.SetState(events.States().Application.Running)
+	tasks := make([]*Task, 0, members)
+	for i := 0; i < members; i++ {
+		taskID := appID + "-member-" + string(rune('a'+i))
+		task := context.AddTask(&interfaces.AddTaskRequest{
+			Metadata: interfaces.TaskMetadata{
+				ApplicationID: appID,
+				TaskID:        taskID,
+				Pod:           newPodHelper("pod-"+taskID, "default", taskID, "node-0001", v1.PodRunning),
+				Placeholder:   i == members-1,
+				TaskGroupName: "workers",
+			},
+		}).(*Task)
+		task.sm.SetState(events.States().Task.Bound)
+		tasks = append(tasks, task)
+	}
+	return app, tasks
+}
+
+func TestCoordinateGangPreemption(t *testing.T) {
+	events.SetRecorderForTest(record.NewFakeRecorder(1024))
+	context := initContextForTest()
+	var lock sync.Mutex
+	evicted := make([]string, 0)
+	apiProvider := context.apiProvider.(*client.MockedAPIProvider)
+	apiProvider.MockEvictFn(func(pod *v1.Pod, gracePeriodSeconds *int64) error {
+		lock.Lock()
+		defer lock.Unlock()
+		evicted = append(evicted, pod.Name)
+		return nil
+	})
+	getEvicted := func() []string {
+		lock.Lock()
+		defer lock.Unlock()
+		result := append([]string{}, evicted...)
+		sort.Strings(result)
+		return result
+	}
+
+	// the members of a hard gang keep running without the gang policy
+	_, tasks := addGangApplication(context, "app-0001", hardGangStyle, 3)
+	tasks[0].preempt("root.a", false, "allocation preempted")
+	assert.DeepEqual(t, getEvicted(), []string{"pod-app-0001-member-a"})
+
+	apiProvider.GetAPIs().Conf.PreemptionGangPolicy = conf.GangPolicyRestart
+	_, tasks = addGangApplication(context, "app-0002", "Soft", 3)
+	tasks[0].preempt("root.a", false, "allocation preempted")
+	assert.DeepEqual(t, getEvicted(), []string{"pod-app-0001-member-a", "pod-app-0002-member-a"})
+
+	// the other members of a hard gang are evicted, the placeholder is left to the core
+	_, tasks = addGangApplication(context, "app-0003", hardGangStyle, 4)
+	tasks[1].preempt("root.a", false, "allocation preempted")
+	err := utils.WaitForCondition(func() bool {
+		return len(getEvicted()) == 5
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, getEvicted(), []string{"pod-app-0001-member-a", "pod-app-0002-member-a",
+		"pod-app-0003-member-a", "pod-app-0003-member-b", "pod-app-0003-member-c"})
+}
+
+func TestCoordinateGangPreemptionFail(t *testing.T) {
+	events.SetRecorderForTest(record.NewFakeRecorder(1024))
+	context := initContextForTest()
+	dispatcher.RegisterEventHandler(dispatcher.EventTypeApp, context.ApplicationEventHandler())
+	dispatcher.Start()
+	defer dispatcher.Stop()
+	NewPlaceholderManager(client.NewMockedAPIProvider().GetAPIs())
+	context.apiProvider.GetAPIs().Conf.PreemptionGangPolicy = conf.GangPolicyFail
+
+	app, tasks := addGangApplication(context, "app-0001", hardGangStyle, 2)
+	tasks[0].preempt("root.a", false, "allocation preempted")
+	assertAppState(t, app, events.States().Application.Failing, 3*time.Second)
+}
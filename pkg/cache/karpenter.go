@@ -0,0 +1,61 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// tells Karpenter about the allocation of the pod before it is bound. Karpenter provisions a node for every
+// pending pod that failed to schedule and is not nominated to a node: the allocated pod is nominated to its
+// node, which can be an incoming node that is not ready yet, and the binding can take a while.
+// Karpenter does not disrupt the nodes of the pods with the do-not-disrupt annotation: a gang cannot run once
+// one of its members, or one of its placeholders, is evicted by a consolidation.
+// The pod is passed by the binding that holds the lock of the task.
+func (ctx *Context) signalKarpenter(pod *v1.Pod, app *Application, nodeID string) {
+	if !ctx.apiProvider.GetAPIs().Conf.KarpenterSignals {
+		return
+	}
+	clientSet := ctx.apiProvider.GetAPIs().KubeClient.GetClientSet()
+	if app != nil && len(app.getTaskGroups()) > 0 && pod.Annotations[constants.AnnotationDoNotDisrupt] != "true" &&
+		!client.SkipMutation("patch", "pods", pod.Namespace, pod.Name) {
+		annotations := map[string]string{constants.AnnotationDoNotDisrupt: "true"}
+		if _, err := client.ApplyPodAnnotations(clientSet, pod, annotations); err != nil {
+			log.Log(log.Cache).Warn("failed to protect the gang member from disruption",
+				zap.String("namespace", pod.Namespace),
+				zap.String("podName", pod.Name),
+				zap.Error(err))
+		}
+	}
+	if pod.Status.NominatedNodeName == nodeID || client.SkipMutation("updateStatus", "pods", pod.Namespace, pod.Name) {
+		return
+	}
+	if _, err := client.ApplyPodNominatedNode(clientSet, pod, nodeID); err != nil {
+		log.Log(log.Cache).Warn("failed to nominate the pod to its node",
+			zap.String("namespace", pod.Namespace),
+			zap.String("podName", pod.Name),
+			zap.String("nodeID", nodeID),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,65 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+)
+
+func TestSignalKarpenter(t *testing.T) {
+	context := initContextForTest()
+	apis := context.apiProvider.GetAPIs()
+	tracker := apis.KubeClient.GetClientSet().(*fake.Clientset).Tracker()
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	getPod := func(name string) *v1.Pod {
+		obj, err := tracker.Get(pods, "default", name)
+		assert.NilError(t, err)
+		return obj.(*v1.Pod)
+	}
+	gang := NewApplication("app-0001", "root.a", "test-user", map[string]string{}, apis.SchedulerAPI)
+	gang.setTaskGroups([]v1alpha1.TaskGroup{{Name: "workers", MinMember: 2}})
+	single := NewApplication("app-0002", "root.a", "test-user", map[string]string{}, apis.SchedulerAPI)
+	for _, name := range []string{"pod-1", "pod-2", "pod-3"} {
+		assert.NilError(t, tracker.Add(newPodHelper(name, "default", name, "", v1.PodPending)))
+	}
+
+	// nothing is written without the signals
+	context.signalKarpenter(getPod("pod-1"), gang, "node-1")
+	assert.Equal(t, getPod("pod-1").Status.NominatedNodeName, "")
+	assert.Equal(t, getPod("pod-1").Annotations[constants.AnnotationDoNotDisrupt], "")
+
+	// the members of a gang are protected from disruption
+	apis.Conf.KarpenterSignals = true
+	context.signalKarpenter(getPod("pod-2"), gang, "node-1")
+	assert.Equal(t, getPod("pod-2").Status.NominatedNodeName, "node-1")
+	assert.Equal(t, getPod("pod-2").Annotations[constants.AnnotationDoNotDisrupt], "true")
+
+	// the other pods are only nominated
+	context.signalKarpenter(getPod("pod-3"), single, "node-2")
+	assert.Equal(t, getPod("pod-3").Status.NominatedNodeName, "node-2")
+	assert.Equal(t, getPod("pod-3").Annotations[constants.AnnotationDoNotDisrupt], "")
+}
@@ -0,0 +1,149 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/audit"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// MaintenanceState tells whether the scheduling is paused, while paused no new requests are sent
+// to the core and the pods allocated by the core are not bound. The running pods are not affected.
+type MaintenanceState struct {
+	Enabled bool       `json:"enabled"`
+	Since   *time.Time `json:"since,omitempty"`
+	Reason  string     `json:"reason,omitempty"`
+	By      string     `json:"by,omitempty"`
+}
+
+// the maintenance mode is kept in memory, a restarted shim schedules again
+type maintenance struct {
+	enabled bool
+	since   time.Time
+	reason  string
+	by      string        // the caller that paused the scheduling
+	resumed chan struct{} // closed when the maintenance mode ends
+}
+
+func newMaintenance() *maintenance {
+	return &maintenance{}
+}
+
+// InMaintenance returns true while the scheduling is paused
+func (ctx *Context) InMaintenance() bool {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	return ctx.maintenance.enabled
+}
+
+// GetMaintenance returns the state of the maintenance mode
+func (ctx *Context) GetMaintenance() *MaintenanceState {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	return ctx.getMaintenanceState()
+}
+
+func (ctx *Context) getMaintenanceState() *MaintenanceState {
+	state := &MaintenanceState{Enabled: ctx.maintenance.enabled}
+	if state.Enabled {
+		since := ctx.maintenance.since
+		state.Since = &since
+		state.Reason = ctx.maintenance.reason
+		state.By = ctx.maintenance.by
+	}
+	return state
+}
+
+// SetMaintenance pauses or resumes the scheduling. When the scheduling is paused an event is
+// published on the pods that are waiting to be scheduled, the binds that are held back are
+// released when the scheduling resumes. The change is written to the audit log with the caller that
+// requested it.
+func (ctx *Context) SetMaintenance(enabled bool, reason, caller string) *MaintenanceState {
+	ctx.lock.Lock()
+	if ctx.maintenance.enabled == enabled {
+		defer ctx.lock.Unlock()
+		return ctx.getMaintenanceState()
+	}
+	action := audit.ActionMaintenanceEnd
+	if enabled {
+		action = audit.ActionMaintenanceStart
+		ctx.maintenance.enabled = true
+		ctx.maintenance.since = time.Now()
+		ctx.maintenance.reason = reason
+		ctx.maintenance.by = caller
+		ctx.maintenance.resumed = make(chan struct{})
+	} else {
+		ctx.maintenance.enabled = false
+		close(ctx.maintenance.resumed)
+	}
+	state := ctx.getMaintenanceState()
+	var waiting []*Task
+	if enabled {
+		waiting = ctx.getWaitingTasks()
+	}
+	ctx.lock.Unlock()
+
+	log.Log(log.Cache).Warn("maintenance mode changed",
+		zap.Bool("enabled", enabled),
+		zap.String("reason", reason),
+		zap.String("caller", caller))
+	audit.Log(&audit.Record{Action: action, Reason: reason, Caller: caller})
+	for _, task := range waiting {
+		events.GetRecorder().Eventf(task.GetTaskPod(), v1.EventTypeNormal, "SchedulingPaused",
+			"scheduling is paused, the scheduler is in maintenance mode: %s", reason)
+	}
+	return state
+}
+
+// returns the tasks that are waiting to be scheduled, the context lock must be held
+func (ctx *Context) getWaitingTasks() []*Task {
+	taskStates := events.States().Task
+	var waiting []*Task
+	for _, app := range ctx.applications {
+		for _, task := range app.getAllTasks() {
+			switch task.GetTaskState() {
+			case taskStates.New, taskStates.Pending, taskStates.Scheduling:
+				waiting = append(waiting, task)
+			}
+		}
+	}
+	return waiting
+}
+
+// blocks while the scheduling is paused, returns true when it had to wait
+func (ctx *Context) waitForMaintenanceEnd(task *Task) bool {
+	ctx.lock.RLock()
+	enabled := ctx.maintenance.enabled
+	resumed := ctx.maintenance.resumed
+	ctx.lock.RUnlock()
+	if !enabled {
+		return false
+	}
+	task.setBindingStep(BindingStepMaintenance, "", "the scheduler is in maintenance mode")
+	events.GetRecorder().Eventf(task.GetTaskPod(), v1.EventTypeNormal, "BindingPaused",
+		"binding of task %s is held back, the scheduler is in maintenance mode", task.alias)
+	<-resumed
+	return true
+}
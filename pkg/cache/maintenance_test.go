@@ -0,0 +1,90 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+)
+
+func TestMaintenance(t *testing.T) {
+	context := initContextForTest()
+	assert.Assert(t, !context.InMaintenance())
+	assert.DeepEqual(t, context.GetMaintenance(), &MaintenanceState{})
+
+	context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: "app01",
+			QueueName:     "root.a",
+			User:          "test-user",
+		},
+	})
+	task := context.AddTask(&interfaces.AddTaskRequest{
+		Metadata: interfaces.TaskMetadata{
+			ApplicationID: "app01",
+			TaskID:        "task01",
+			Pod: &v1.Pod{
+				ObjectMeta: apis.ObjectMeta{
+					Name:      "pod-01",
+					Namespace: "default",
+					UID:       "task01",
+					Labels:    map[string]string{constants.LabelApplicationID: "app01"},
+				},
+			},
+		},
+	}).(*Task)
+	// not paused: no wait
+	assert.Assert(t, !context.waitForMaintenanceEnd(task))
+
+	state := context.SetMaintenance(true, "core upgrade", "admin")
+	assert.Assert(t, state.Enabled)
+	assert.Assert(t, state.Since != nil)
+	assert.Equal(t, state.Reason, "core upgrade")
+	assert.Equal(t, state.By, "admin")
+	assert.Assert(t, context.InMaintenance())
+	// enabling again keeps the original reason
+	assert.Equal(t, context.SetMaintenance(true, "again", "other").Reason, "core upgrade")
+
+	waited := make(chan bool)
+	go func() {
+		waited <- context.waitForMaintenanceEnd(task)
+	}()
+	select {
+	case <-waited:
+		t.Fatal("binding is not held back while in maintenance mode")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	state = context.SetMaintenance(false, "", "admin")
+	assert.Assert(t, !state.Enabled)
+	select {
+	case result := <-waited:
+		assert.Assert(t, result)
+	case <-time.After(time.Second):
+		t.Fatal("binding is still held back after the maintenance mode ended")
+	}
+	assert.Assert(t, !context.InMaintenance())
+}
@@ -0,0 +1,98 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"runtime/debug"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+)
+
+// MemoryCheckInterval is the interval the memory held by the caches is accounted at
+const MemoryCheckInterval = 30 * time.Second
+
+// the approximate memory held by an application and a task of the context, the pods of the
+// tasks are shared with the scheduler cache and are accounted there
+const (
+	applicationBytes = 2048
+	taskBytes        = 1024
+)
+
+// returns the approximate number of bytes held by the applications and the tasks
+func (ctx *Context) getMemoryUsage() int64 {
+	counts := ctx.getObjectCounts()
+	return int64(counts["applications"])*applicationBytes + int64(counts["tasks"])*taskBytes
+}
+
+// CheckMemoryBudget accounts the approximate memory held by the scheduler cache and the context.
+// When the caches hold more than the configured limit the terminal objects are removed from them,
+// and the memory that is freed is returned to the operating system.
+func (ctx *Context) CheckMemoryBudget() {
+	limit := int64(ctx.apiProvider.GetAPIs().Conf.CacheMemoryLimitMB) << 20
+	schedulerBytes := ctx.schedulerCache.GetMemoryUsage()
+	contextBytes := ctx.getMemoryUsage()
+	cacheMetrics := metrics.GetCacheMetrics()
+	cacheMetrics.SetMemory(metrics.CacheScheduler, schedulerBytes)
+	cacheMetrics.SetMemory(metrics.CacheContext, contextBytes)
+	cacheMetrics.SetMemoryLimit(limit)
+	if limit == 0 || schedulerBytes+contextBytes <= limit {
+		return
+	}
+
+	pods, tasks, apps := ctx.removeTerminalObjects()
+	cacheMetrics.AddEvictions("pods", pods)
+	cacheMetrics.AddEvictions("tasks", tasks)
+	cacheMetrics.AddEvictions("applications", apps)
+	log.Log(log.Cache).Warn("caches exceed the memory limit, terminal objects removed",
+		zap.Int64("limitBytes", limit),
+		zap.Int64("schedulerCacheBytes", schedulerBytes),
+		zap.Int64("contextBytes", contextBytes),
+		zap.Int("removedPods", pods),
+		zap.Int("removedTasks", tasks),
+		zap.Int("removedApplications", apps))
+	if pods+tasks+apps > 0 {
+		debug.FreeOSMemory()
+	}
+}
+
+// removes the terminated pods from the scheduler cache, the terminated tasks from their application,
+// and the applications in a terminal state that have no tasks left
+func (ctx *Context) removeTerminalObjects() (pods, tasks, apps int) {
+	pods = ctx.schedulerCache.RemoveTerminatedPods()
+	states := events.States().Application
+	for _, app := range ctx.listApplications() {
+		for _, task := range app.getAllTasks() {
+			if task.isTerminated() && app.removeTask(task.taskID) == nil {
+				tasks++
+			}
+		}
+		switch app.GetApplicationState() {
+		case states.Completed, states.Failed, states.Killed, states.Rejected:
+			if app.taskCount() == 0 && ctx.RemoveApplication(app.applicationID) == nil {
+				apps++
+			}
+		}
+	}
+	return pods, tasks, apps
+}
@@ -0,0 +1,85 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+)
+
+func TestRemoveTerminalObjects(t *testing.T) {
+	context := initContextForTest()
+	for _, appID := range []string{"app-running", "app-completed", "app-failed"} {
+		context.AddApplication(&interfaces.AddApplicationRequest{
+			Metadata: interfaces.ApplicationMetadata{
+				ApplicationID: appID,
+				QueueName:     "root.a",
+				User:          "test-user",
+			},
+		})
+	}
+	addTask := func(appID, taskID, state string) {
+		task := context.AddTask(&interfaces.AddTaskRequest{
+			Metadata: interfaces.TaskMetadata{
+				ApplicationID: appID,
+				TaskID:        taskID,
+				Pod:           newPodHelper(taskID, "default", taskID, "", v1.PodPending),
+			},
+		})
+		task.(*Task).sm.SetState(state)
+	}
+	taskStates := events.States().Task
+	addTask("app-running", "task-1", taskStates.Bound)
+	addTask("app-running", "task-2", taskStates.Completed)
+	addTask("app-completed", "task-3", taskStates.Completed)
+	addTask("app-failed", "task-4", taskStates.Failed)
+	addTask("app-failed", "task-5", taskStates.Bound)
+	context.GetApplication("app-completed").SetState(events.States().Application.Completed)
+	context.GetApplication("app-failed").SetState(events.States().Application.Failed)
+	before := context.getMemoryUsage()
+	assert.Equal(t, before, int64(3*applicationBytes+5*taskBytes))
+
+	// the failed app still has a task that is not terminated
+	pods, tasks, apps := context.removeTerminalObjects()
+	assert.Equal(t, pods, 0)
+	assert.Equal(t, tasks, 3)
+	assert.Equal(t, apps, 1)
+	assert.Assert(t, context.GetApplication("app-completed") == nil)
+	assert.Assert(t, context.GetApplication("app-failed") != nil)
+	assert.Equal(t, context.GetApplication("app-running").(*Application).taskCount(), 1)
+	assert.Equal(t, context.getMemoryUsage(), int64(2*applicationBytes+2*taskBytes))
+
+	// the caches do not exceed the limit, nothing is removed
+	task, err := context.getTask("app-failed", "task-5")
+	assert.NilError(t, err)
+	task.sm.SetState(taskStates.Completed)
+	context.apiProvider.GetAPIs().Conf.CacheMemoryLimitMB = 1
+	context.CheckMemoryBudget()
+	assert.Assert(t, context.GetApplication("app-failed") != nil)
+
+	_, tasks, apps = context.removeTerminalObjects()
+	assert.Equal(t, tasks, 1)
+	assert.Equal(t, apps, 1)
+	assert.Assert(t, context.GetApplication("app-failed") == nil)
+}
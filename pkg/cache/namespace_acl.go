@@ -0,0 +1,147 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/dispatcher"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// how long the submission of an application waits for its namespace to be known, it is denied after that
+var namespaceACLWaitTimeout = time.Minute
+
+// checks the queue ACL of the namespace allows the pods of the application to submit to its queue. This is
+// enforced by the shim as the admission controller can be bypassed, so the ACL is only checked against the
+// service accounts of the pods: the user resolved from the labels or the annotations of a pod is chosen by the
+// submitter when the admission controller does not replace it. The check fails closed, the submission is
+// deferred while the namespace or the pods of the application are not known and is denied once the namespace
+// is not known within the wait timeout. When the namespace annotations are disabled the namespaces are not
+// watched, the ACL cannot be checked and the submission is denied. The applications that are recovered are
+// already running and are not checked. Returns why the submission is denied, and false while the submission
+// is deferred.
+func (ctx *Context) checkNamespaceQueueACL(app *Application, namespace string) (string, bool) {
+	informer := ctx.apiProvider.GetAPIs().NamespaceInformer
+	if informer == nil {
+		return fmt.Sprintf("namespace %s is not watched, its queue ACL cannot be checked", namespace), true
+	}
+	namespaceObj, err := informer.Lister().Get(namespace)
+	if err != nil {
+		if time.Since(app.stateSince) < namespaceACLWaitTimeout {
+			log.Log(log.Cache).Debug("deferring the application submission, the namespace is not known",
+				zap.String("appID", app.applicationID),
+				zap.String("namespace", namespace),
+				zap.Error(err))
+			return "", false
+		}
+		return fmt.Sprintf("namespace %s is not known", namespace), true
+	}
+	tasks := app.getAllTasks()
+	if len(tasks) == 0 {
+		// the pods that join later are checked when they are added
+		_, ok := namespaceObj.Annotations[constants.AnnotationNamespaceQueueACL]
+		return "", !ok
+	}
+	for _, task := range tasks {
+		if err = ctx.checkPodNamespaceQueueACL(app, task.GetTaskPod()); err != nil {
+			return err.Error(), true
+		}
+	}
+	return "", true
+}
+
+// checks the queue ACL of the namespace of the pod allows its service account to submit to the queue of the
+// application. The pods are checked one by one as a pod can join an application that is already submitted,
+// from another namespace or with another service account. Fails closed when the namespace is not known.
+func (ctx *Context) checkPodNamespaceQueueACL(app *Application, pod *v1.Pod) error {
+	informer := ctx.apiProvider.GetAPIs().NamespaceInformer
+	if informer == nil {
+		return fmt.Errorf("namespace %s is not watched, its queue ACL cannot be checked", pod.Namespace)
+	}
+	namespaceObj, err := informer.Lister().Get(pod.Namespace)
+	if err != nil {
+		return fmt.Errorf("namespace %s is not known", pod.Namespace)
+	}
+	if _, ok := namespaceObj.Annotations[constants.AnnotationNamespaceQueueACL]; !ok {
+		return nil
+	}
+	identity := utils.GetServiceAccountUserGroup(pod)
+	if err = utils.CheckNamespaceQueueACL(namespaceObj, app.queue, identity.User, identity.Groups); err != nil {
+		log.Log(log.Cache).Warn("submission denied by the namespace queue ACL",
+			zap.String("appID", app.applicationID),
+			zap.String("namespace", pod.Namespace),
+			zap.String("pod", pod.Name),
+			zap.String("queue", app.queue),
+			zap.String("serviceAccount", identity.User),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// checks the pod that joins an application after its submission, the pods of an application that is not
+// submitted yet are checked with the application. The pods that are already assigned to a node are running
+// and are not checked. The task of a denied pod is rejected and the pod is failed.
+func (ctx *Context) checkTaskSubmission(app *Application, task *Task) {
+	if app.submissionCheck == nil || app.GetApplicationState() == events.States().Application.New ||
+		task.GetTaskPod().Spec.NodeName != "" {
+		return
+	}
+	if err := ctx.checkPodNamespaceQueueACL(app, task.GetTaskPod()); err != nil {
+		events.GetRecorder().Eventf(task.GetTaskPod(), v1.EventTypeWarning, "SubmissionDenied",
+			"Task %s is rejected, %s", task.alias, err.Error())
+		if err = task.handle(NewRejectTaskEvent(app.applicationID, task.taskID, err.Error())); err != nil {
+			log.Log(log.Cache).Warn("failed to reject the task",
+				zap.String("appID", app.applicationID),
+				zap.String("taskID", task.taskID),
+				zap.Error(err))
+		}
+	}
+}
+
+// runs the submission check of the application before it is submitted, returns false while it is deferred
+func (app *Application) checkSubmission() bool {
+	if app.submissionCheck == nil {
+		return true
+	}
+	denied, ready := app.submissionCheck()
+	app.submissionDenied = denied
+	return ready
+}
+
+// rejects the application that the namespace does not allow, the pods of the application are failed
+// like the pods of an application the core rejects. Called from the submit state transition.
+func (app *Application) rejectDeniedSubmission() {
+	app.logger().Info("app submission denied by the namespace queue ACL",
+		zap.String("appID", app.applicationID),
+		zap.String("reason", app.submissionDenied))
+	for _, task := range app.getAllTasks() {
+		events.GetRecorder().Eventf(task.GetTaskPod(), v1.EventTypeWarning, "SubmissionDenied",
+			"Application %s is rejected, %s", app.applicationID, app.submissionDenied)
+	}
+	dispatcher.Dispatch(NewApplicationEvent(app.applicationID, events.RejectApplication, app.submissionDenied))
+}
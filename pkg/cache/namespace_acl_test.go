@@ -0,0 +1,194 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/test"
+	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
+)
+
+func TestNamespaceQueueACL(t *testing.T) {
+	context := initContextForTest()
+	lister, ok := context.apiProvider.GetAPIs().NamespaceInformer.Lister().(*test.MockNamespaceLister)
+	assert.Assert(t, ok)
+	var submitted []string
+	context.apiProvider.(*client.MockedAPIProvider).MockSchedulerAPIUpdateApplicationFn(func(request *si.ApplicationRequest) error {
+		for _, app := range request.New {
+			submitted = append(submitted, app.ApplicationID)
+		}
+		return nil
+	})
+	lister.Add(&v1.Namespace{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "tenant",
+			Annotations: map[string]string{
+				constants.AnnotationNamespaceQueueACL: `{"root.tenant":{"users":["system:serviceaccount:tenant:builder"]}}`,
+			},
+		},
+	})
+	addApp := func(appID, queue, namespace, serviceAccount string) *Application {
+		app, ok := context.AddApplication(&interfaces.AddApplicationRequest{
+			Metadata: interfaces.ApplicationMetadata{
+				ApplicationID: appID,
+				QueueName:     queue,
+				// the user resolved from the labels of the pod is not trusted
+				User: "system:serviceaccount:tenant:builder",
+				Tags: map[string]string{constants.AppTagNamespace: namespace},
+			},
+		}).(*Application)
+		assert.Assert(t, ok)
+		pod := newPodHelper("pod-"+appID, namespace, "uid-"+appID, "", v1.PodPending)
+		pod.Spec.ServiceAccountName = serviceAccount
+		context.AddTask(&interfaces.AddTaskRequest{
+			Metadata: interfaces.TaskMetadata{
+				ApplicationID: appID,
+				TaskID:        "task-" + appID,
+				Pod:           pod,
+			},
+		})
+		return app
+	}
+
+	allowed := addApp("app01", "root.tenant", "tenant", "builder")
+	otherQueue := addApp("app02", "root.shared", "tenant", "builder")
+	spoofed := addApp("app03", "root.tenant", "tenant", "default")
+	for _, app := range []*Application{allowed, otherQueue, spoofed} {
+		assert.Assert(t, app.Schedule())
+		assert.Equal(t, app.GetApplicationState(), events.States().Application.Submitted)
+	}
+	assert.Equal(t, allowed.submissionDenied, "")
+	assert.Equal(t, otherQueue.submissionDenied, "namespace tenant does not allow submitting to queue root.shared")
+	assert.Equal(t, spoofed.submissionDenied,
+		"namespace tenant does not allow user system:serviceaccount:tenant:default to submit to queue root.tenant")
+	// the denied applications never reach the core
+	assert.DeepEqual(t, submitted, []string{"app01"})
+}
+
+func TestNamespaceQueueACLUnknownNamespace(t *testing.T) {
+	context := initContextForTest()
+	var submitted []string
+	context.apiProvider.(*client.MockedAPIProvider).MockSchedulerAPIUpdateApplicationFn(func(request *si.ApplicationRequest) error {
+		for _, app := range request.New {
+			submitted = append(submitted, app.ApplicationID)
+		}
+		return nil
+	})
+	app, ok := context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: "app01",
+			QueueName:     "root.tenant",
+			User:          "test-user",
+			Tags:          map[string]string{constants.AppTagNamespace: "unknown"},
+		},
+	}).(*Application)
+	assert.Assert(t, ok)
+
+	// the submission waits for the namespace to be known
+	assert.Assert(t, app.Schedule())
+	assert.Equal(t, app.GetApplicationState(), events.States().Application.New)
+	assert.Equal(t, len(submitted), 0)
+
+	// and is denied once the namespace is not known within the wait timeout
+	app.stateSince = time.Now().Add(-namespaceACLWaitTimeout)
+	assert.Assert(t, app.Schedule())
+	assert.Equal(t, app.GetApplicationState(), events.States().Application.Submitted)
+	assert.Equal(t, app.submissionDenied, "namespace unknown is not known")
+	assert.Equal(t, len(submitted), 0)
+}
+
+func TestNamespaceQueueACLLaterPods(t *testing.T) {
+	context := initContextForTest()
+	lister, ok := context.apiProvider.GetAPIs().NamespaceInformer.Lister().(*test.MockNamespaceLister)
+	assert.Assert(t, ok)
+	lister.Add(&v1.Namespace{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "tenant",
+			Annotations: map[string]string{
+				constants.AnnotationNamespaceQueueACL: `{"root.tenant":{"users":["system:serviceaccount:tenant:builder"]}}`,
+			},
+		},
+	})
+	app, ok := context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: "app01",
+			QueueName:     "root.tenant",
+			User:          "system:serviceaccount:tenant:builder",
+			Tags:          map[string]string{constants.AppTagNamespace: "tenant"},
+		},
+	}).(*Application)
+	assert.Assert(t, ok)
+	addTask := func(taskID, namespace, serviceAccount string) *Task {
+		pod := newPodHelper("pod-"+taskID, namespace, "uid-"+taskID, "", v1.PodPending)
+		pod.Spec.ServiceAccountName = serviceAccount
+		task, ok := context.AddTask(&interfaces.AddTaskRequest{
+			Metadata: interfaces.TaskMetadata{
+				ApplicationID: "app01",
+				TaskID:        taskID,
+				Pod:           pod,
+			},
+		}).(*Task)
+		assert.Assert(t, ok)
+		return task
+	}
+	// the pods of the application that is not submitted are checked with the application
+	first := addTask("task01", "tenant", "builder")
+	assert.Assert(t, app.Schedule())
+	assert.Equal(t, app.GetApplicationState(), events.States().Application.Submitted)
+	assert.Equal(t, app.submissionDenied, "")
+	assert.Equal(t, first.GetTaskState(), events.States().Task.New)
+
+	// the pods that join the submitted application are checked one by one
+	allowed := addTask("task02", "tenant", "builder")
+	spoofed := addTask("task03", "tenant", "default")
+	unknown := addTask("task04", "other", "builder")
+	assert.Equal(t, allowed.GetTaskState(), events.States().Task.New)
+	assert.Equal(t, spoofed.GetTaskState(), events.States().Task.Rejected)
+	assert.Equal(t, unknown.GetTaskState(), events.States().Task.Rejected)
+}
+
+func TestNamespaceQueueACLNotWatched(t *testing.T) {
+	context := initContextForTest()
+	// the namespaces are not watched with the namespace annotations disabled
+	context.apiProvider.GetAPIs().NamespaceInformer = nil
+	app, ok := context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: "app01",
+			QueueName:     "root.tenant",
+			User:          "test-user",
+			Tags:          map[string]string{constants.AppTagNamespace: "tenant"},
+		},
+	}).(*Application)
+	assert.Assert(t, ok)
+
+	// the ACL cannot be checked, the submission fails closed
+	assert.Assert(t, app.Schedule())
+	assert.Equal(t, app.GetApplicationState(), events.States().Application.Submitted)
+	assert.Equal(t, app.submissionDenied, "namespace tenant is not watched, its queue ACL cannot be checked")
+}
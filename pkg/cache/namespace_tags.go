@@ -0,0 +1,163 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"reflect"
+	"sort"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/audit"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
+)
+
+// the namespace annotations were changed, the namespace tags of its applications are refreshed
+func (ctx *Context) updateNamespace(oldObj, newObj interface{}) {
+	oldNamespace, ok := oldObj.(*v1.Namespace)
+	if !ok {
+		return
+	}
+	newNamespace, ok := newObj.(*v1.Namespace)
+	if !ok {
+		log.Log(log.Cache).Error("cannot convert to *v1.Namespace", zap.Any("object", newObj))
+		return
+	}
+	if reflect.DeepEqual(getNamespaceTags(oldNamespace), getNamespaceTags(newNamespace)) {
+		return
+	}
+	ctx.refreshNamespaceTags(newNamespace.Name)
+}
+
+// RefreshNamespaceTags reads the namespace annotations again and updates the namespace tags of the
+// applications in the namespace, all the namespaces are refreshed when the namespace is empty.
+// The updated tags are sent to the core for the applications the core has accepted. Returns the
+// IDs of the updated applications. The operation is written to the audit log with the caller that
+// requested it.
+func (ctx *Context) RefreshNamespaceTags(namespace, caller string) []string {
+	updated := ctx.refreshNamespaceTags(namespace)
+	audit.Log(&audit.Record{
+		Action:    audit.ActionNamespaceRefresh,
+		Namespace: namespace,
+		Caller:    caller,
+	})
+	return updated
+}
+
+func (ctx *Context) refreshNamespaceTags(namespace string) []string {
+	ctx.lock.RLock()
+	apps := make([]*Application, 0)
+	for _, app := range ctx.applications {
+		if appNamespace := app.getNamespace(); appNamespace != "" && (namespace == "" || appNamespace == namespace) {
+			apps = append(apps, app)
+		}
+	}
+	ctx.lock.RUnlock()
+
+	updated := make([]string, 0)
+	namespaceTags := make(map[string]map[string]string)
+	for _, app := range apps {
+		appNamespace := app.getNamespace()
+		tags, ok := namespaceTags[appNamespace]
+		if !ok {
+			namespaceObj := ctx.getNamespaceObject(appNamespace)
+			if namespaceObj == nil {
+				continue
+			}
+			tags = getNamespaceTags(namespaceObj)
+			namespaceTags[appNamespace] = tags
+		}
+		if app.updateNamespaceTags(tags) {
+			updated = append(updated, app.applicationID)
+		}
+	}
+	sort.Strings(updated)
+	log.Log(log.Cache).Info("namespace tags refreshed",
+		zap.String("namespace", namespace),
+		zap.Strings("updatedApps", updated))
+	return updated
+}
+
+func (app *Application) getNamespace() string {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	return app.tags[constants.AppTagNamespace]
+}
+
+// replaces the namespace tags of the application, the tags are sent to the core when the core has
+// accepted the application. A new application sends its tags when it is submitted. Returns true
+// when the tags were changed.
+func (app *Application) updateNamespaceTags(namespaceTags map[string]string) bool {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	// the tags are shared with the readers, they are copied and never changed in place
+	tags := make(map[string]string, len(app.tags))
+	for tag, value := range app.tags {
+		tags[tag] = value
+	}
+	delete(tags, constants.AppTagNamespaceResourceQuota)
+	delete(tags, constants.AppTagNamespaceParentQueue)
+	delete(tags, constants.AppTagNamespacePriorityOffset)
+	delete(tags, constants.AppTagNamespacePriorityFence)
+	delete(tags, constants.AppTagNamespaceUserQuota)
+	delete(tags, constants.AppTagNamespaceGroupQuota)
+	for tag, value := range namespaceTags {
+		tags[tag] = value
+	}
+	if reflect.DeepEqual(tags, app.tags) {
+		return false
+	}
+	app.tags = tags
+
+	appStates := events.States().Application
+	switch app.sm.Current() {
+	case appStates.Accepted, appStates.Reserving, appStates.Running, appStates.Resuming:
+	default:
+		return true
+	}
+	// the application is sent again with the updated tags, the response of the core does not change
+	// the state of the application: it is only accepted or rejected in the Submitted state
+	err := app.schedulerAPI.UpdateApplication(
+		&si.ApplicationRequest{
+			New: []*si.AddApplicationRequest{
+				{
+					ApplicationID: app.applicationID,
+					QueueName:     app.queue,
+					PartitionName: app.partition,
+					Ugi: &si.UserGroupInformation{
+						User:   app.user,
+						Groups: app.groups,
+					},
+					Tags:                         app.tags,
+					ExecutionTimeoutMilliSeconds: app.placeholderTimeoutInSec * 1000,
+					GangSchedulingStyle:          app.schedulingStyle,
+				},
+			},
+			RmID: conf.GetSchedulerConf().ClusterID,
+		})
+	if err != nil {
+		app.logger().Warn("failed to send the updated namespace tags to the core", zap.Error(err))
+	}
+	return true
+}
@@ -0,0 +1,141 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/test"
+	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
+)
+
+func TestRefreshNamespaceTags(t *testing.T) {
+	context := initContextForTest()
+	lister, ok := context.apiProvider.GetAPIs().NamespaceInformer.Lister().(*test.MockNamespaceLister)
+	assert.Assert(t, ok)
+	var requests []*si.AddApplicationRequest
+	context.apiProvider.(*client.MockedAPIProvider).MockSchedulerAPIUpdateApplicationFn(func(request *si.ApplicationRequest) error {
+		requests = append(requests, request.New...)
+		return nil
+	})
+
+	getTags := func(appID string) map[string]string {
+		app, ok := context.GetApplication(appID).(*Application)
+		assert.Assert(t, ok, appID)
+		return app.GetTags()
+	}
+
+	unannotated := &v1.Namespace{ObjectMeta: apis.ObjectMeta{Name: "test1"}}
+	lister.Add(unannotated)
+	lister.Add(&v1.Namespace{ObjectMeta: apis.ObjectMeta{Name: "test2"}})
+	for appID, namespace := range map[string]string{"app01": "test1", "app02": "test1", "app03": "test2"} {
+		context.AddApplication(&interfaces.AddApplicationRequest{
+			Metadata: interfaces.ApplicationMetadata{
+				ApplicationID: appID,
+				QueueName:     "root.a",
+				User:          "test-user",
+				Tags:          map[string]string{constants.AppTagNamespace: namespace},
+			},
+		})
+	}
+	running, ok := context.GetApplication("app02").(*Application)
+	assert.Assert(t, ok)
+	running.sm.SetState(events.States().Application.Running)
+
+	annotated := &v1.Namespace{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "test1",
+			Annotations: map[string]string{
+				"yunikorn.apache.org/namespace.max.memory": "256M",
+				"yunikorn.apache.org/parentqueue":          "root.test",
+				constants.AnnotationNamespacePriorityFence: "100",
+			},
+		},
+	}
+	lister.Add(annotated)
+	context.updateNamespace(unannotated, annotated)
+	for _, appID := range []string{"app01", "app02"} {
+		tags := getTags(appID)
+		assert.Equal(t, tags[constants.AppTagNamespaceParentQueue], "root.test", appID)
+		assert.Assert(t, tags[constants.AppTagNamespaceResourceQuota] != "", appID)
+		assert.Equal(t, tags[constants.AppTagNamespace], "test1", appID)
+		assert.Equal(t, tags[constants.AppTagNamespacePriorityFence], "100", appID)
+	}
+	_, ok = getTags("app03")[constants.AppTagNamespaceParentQueue]
+	assert.Assert(t, !ok)
+	// only the application accepted by the core is sent again
+	assert.Equal(t, len(requests), 1)
+	assert.Equal(t, requests[0].ApplicationID, "app02")
+	assert.Equal(t, requests[0].Tags[constants.AppTagNamespaceParentQueue], "root.test")
+
+	// annotations that do not change the tags
+	context.updateNamespace(annotated, annotated)
+	assert.Equal(t, len(requests), 1)
+
+	// annotations removed and refreshed through the admin trigger
+	lister.Add(unannotated)
+	assert.DeepEqual(t, context.RefreshNamespaceTags("", "admin"), []string{"app01", "app02"})
+	_, ok = getTags("app01")[constants.AppTagNamespaceResourceQuota]
+	assert.Assert(t, !ok)
+	_, ok = getTags("app01")[constants.AppTagNamespacePriorityFence]
+	assert.Assert(t, !ok)
+	assert.Equal(t, len(requests), 2)
+	assert.DeepEqual(t, context.RefreshNamespaceTags("test1", "admin"), []string{})
+}
+
+func TestTaskPriorityFence(t *testing.T) {
+	context := initContextForTest()
+	context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: "app01",
+			QueueName:     "root.a",
+			User:          "test-user",
+			Tags:          map[string]string{constants.AppTagNamespace: "tenant"},
+		},
+	})
+	priority := int32(5000)
+	pod := newPodHelper("pod01", "tenant", "task01", "", v1.PodPending)
+	pod.Spec.Priority = &priority
+	task := context.AddTask(&interfaces.AddTaskRequest{
+		Metadata: interfaces.TaskMetadata{
+			ApplicationID: "app01",
+			TaskID:        "task01",
+			Pod:           pod,
+		},
+	}).(*Task)
+	assert.Equal(t, task.getPriority(), int32(5000))
+
+	app, ok := context.GetApplication("app01").(*Application)
+	assert.Assert(t, ok)
+	app.updateNamespaceTags(map[string]string{
+		constants.AppTagNamespacePriorityOffset: "-1000",
+		constants.AppTagNamespacePriorityFence:  "2000",
+	})
+	assert.Equal(t, task.getPriority(), int32(2000))
+	app.updateNamespaceTags(map[string]string{constants.AppTagNamespacePriorityOffset: "-1000"})
+	assert.Equal(t, task.getPriority(), int32(4000))
+}
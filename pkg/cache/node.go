@@ -29,6 +29,7 @@ import (
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/dispatcher"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/eventsink"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
 	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/api"
 	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
@@ -106,7 +107,7 @@ func (n *SchedulerNode) initFSM() {
 func (n *SchedulerNode) addExistingAllocation(allocation *si.Allocation) {
 	n.lock.Lock()
 	defer n.lock.Unlock()
-	log.Logger().Info("add existing allocation",
+	log.Log(log.Cache).Info("add existing allocation",
 		zap.String("nodeID", n.name),
 		zap.Any("allocation", allocation))
 	n.existingAllocations = append(n.existingAllocations, allocation)
@@ -115,7 +116,7 @@ func (n *SchedulerNode) addExistingAllocation(allocation *si.Allocation) {
 func (n *SchedulerNode) setOccupiedResource(resource *si.Resource) {
 	n.lock.Lock()
 	defer n.lock.Unlock()
-	log.Logger().Info("set node occupied resource",
+	log.Log(log.Cache).Info("set node occupied resource",
 		zap.String("nodeID", n.name),
 		zap.String("occupied", resource.String()))
 	n.occupied = resource
@@ -145,7 +146,7 @@ func (n *SchedulerNode) postNodeAccepted(event *fsm.Event) {
 }
 
 func (n *SchedulerNode) handleNodeRecovery(event *fsm.Event) {
-	log.Logger().Info("node recovering",
+	log.Log(log.Cache).Info("node recovering",
 		zap.String("nodeID", n.name),
 		zap.Bool("schedulable", n.schedulable))
 
@@ -172,18 +173,18 @@ func (n *SchedulerNode) handleNodeRecovery(event *fsm.Event) {
 
 	// send alloc request to scheduler-core
 	if err := n.schedulerAPI.UpdateAllocation(allocRequest); err != nil {
-		log.Logger().Error("failed to send UpdateAllocation request",
+		log.Log(log.Cache).Error("failed to send UpdateAllocation request",
 			zap.Any("request", allocRequest))
 	}
 	// send node request to scheduler-core
 	if err := n.schedulerAPI.UpdateNode(nodeRequest); err != nil {
-		log.Logger().Error("failed to send UpdateNode request",
+		log.Log(log.Cache).Error("failed to send UpdateNode request",
 			zap.Any("request", nodeRequest))
 	}
 }
 
 func (n *SchedulerNode) handleDrainNode(event *fsm.Event) {
-	log.Logger().Info("node enters draining mode",
+	log.Log(log.Cache).Info("node enters draining mode",
 		zap.String("nodeID", n.name))
 
 	allocRequest := &si.AllocationRequest{
@@ -206,19 +207,19 @@ func (n *SchedulerNode) handleDrainNode(event *fsm.Event) {
 
 	// send request to scheduler-core
 	if err := n.schedulerAPI.UpdateAllocation(allocRequest); err != nil {
-		log.Logger().Error("failed to send UpdateAllocation request",
+		log.Log(log.Cache).Error("failed to send UpdateAllocation request",
 			zap.Any("request", allocRequest))
 	}
 
 	// send request to scheduler-core
 	if err := n.schedulerAPI.UpdateNode(nodeRequest); err != nil {
-		log.Logger().Error("failed to send UpdateNode request",
+		log.Log(log.Cache).Error("failed to send UpdateNode request",
 			zap.Any("request", nodeRequest))
 	}
 }
 
 func (n *SchedulerNode) handleRestoreNode(event *fsm.Event) {
-	log.Logger().Info("restore node from draining mode",
+	log.Log(log.Cache).Info("restore node from draining mode",
 		zap.String("nodeID", n.name))
 
 	allocRequest := &si.AllocationRequest{
@@ -241,12 +242,12 @@ func (n *SchedulerNode) handleRestoreNode(event *fsm.Event) {
 
 	// send request to scheduler-core
 	if err := n.schedulerAPI.UpdateAllocation(allocRequest); err != nil {
-		log.Logger().Error("failed to send UpdateAllocation request",
+		log.Log(log.Cache).Error("failed to send UpdateAllocation request",
 			zap.Any("request", allocRequest))
 	}
 	// send request to scheduler-core
 	if err := n.schedulerAPI.UpdateNode(nodeRequest); err != nil {
-		log.Logger().Error("failed to send UpdateNode request",
+		log.Log(log.Cache).Error("failed to send UpdateNode request",
 			zap.Any("request", nodeRequest))
 	}
 }
@@ -269,9 +270,16 @@ func (n *SchedulerNode) canHandle(ev events.SchedulerNodeEvent) bool {
 }
 
 func (n *SchedulerNode) enterState(event *fsm.Event) {
-	log.Logger().Debug("shim node state transition",
+	log.Log(log.Cache).Debug("shim node state transition",
 		zap.String("nodeID", n.name),
 		zap.String("source", event.Src),
 		zap.String("destination", event.Dst),
 		zap.String("event", event.Event))
+	eventsink.Publish(&eventsink.LifecycleEvent{
+		Kind:   eventsink.KindNode,
+		NodeID: n.name,
+		Event:  event.Event,
+		From:   event.Src,
+		To:     event.Dst,
+	})
 }
@@ -70,13 +70,24 @@ func TestUpdatePod(t *testing.T) {
 	}
 	coordinator.updatePod(pod1, pod2)
 
-	// pod state remains in Pending, pod is assigned to a node
-	// this happens when the pod just gets allocated and started, but not in running state yet
-	// trigger an update
+	// a resync of the same pod version is skipped even if the pod looks assigned
 	pod1.Status.Phase = v1.PodPending
 	pod2.Status.Phase = v1.PodPending
 	pod1.Spec.NodeName = HostEmpty
 	pod2.Spec.NodeName = Host1
+	pod1.ResourceVersion = "1"
+	pod2.ResourceVersion = "1"
+	mockedSchedulerApi.UpdateNodeFn = func(request *si.NodeRequest) error {
+		t.Fatalf("update should not run for a resync")
+		return nil
+	}
+	coordinator.updatePod(pod1, pod2)
+	pod1.ResourceVersion = ""
+	pod2.ResourceVersion = ""
+
+	// pod state remains in Pending, pod is assigned to a node
+	// this happens when the pod just gets allocated and started, but not in running state yet
+	// trigger an update
 	executed := false
 	mockedSchedulerApi.UpdateNodeFn = func(request *si.NodeRequest) error {
 		executed = true
@@ -0,0 +1,205 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
+)
+
+// NodeStats are the scheduling statistics of a node as seen by the shim, a node that keeps
+// failing the bindings of the pods allocated to it shows up with a high number of bind failures.
+type NodeStats struct {
+	Name               string     `json:"name"`
+	AllocationAttempts uint64     `json:"allocationAttempts"`
+	PredicateRuns      uint64     `json:"predicateRuns"`
+	PredicateFailures  uint64     `json:"predicateFailures"`
+	PredicatePassRate  float64    `json:"predicatePassRate"`
+	Binds              uint64     `json:"binds"`
+	BindFailures       uint64     `json:"bindFailures"`
+	LastBindFailure    *time.Time `json:"lastBindFailure,omitempty"`
+	LastBindError      string     `json:"lastBindError,omitempty"`
+	// fraction of the allocatable resources requested by the pods on the node, by resource
+	Utilization map[string]float64 `json:"utilization,omitempty"`
+}
+
+type nodeStats struct {
+	allocations       uint64
+	predicateRuns     uint64
+	predicateFailures uint64
+	binds             uint64
+	bindFailures      uint64
+	lastBindFailure   time.Time
+	lastBindError     string
+}
+
+// nodeStatsTracker counts the scheduling attempts per node, the stats of a node
+// are dropped when the node is removed from the cluster
+type nodeStatsTracker struct {
+	nodes map[string]*nodeStats
+	sync.Mutex
+}
+
+func newNodeStatsTracker() *nodeStatsTracker {
+	return &nodeStatsTracker{
+		nodes: make(map[string]*nodeStats),
+	}
+}
+
+// must be called with the lock held
+func (t *nodeStatsTracker) get(node string) *nodeStats {
+	stats, ok := t.nodes[node]
+	if !ok {
+		stats = &nodeStats{}
+		t.nodes[node] = stats
+	}
+	return stats
+}
+
+// counts an allocation the core made on the node
+func (t *nodeStatsTracker) recordAllocation(node string) {
+	t.Lock()
+	defer t.Unlock()
+	t.get(node).allocations++
+}
+
+func (t *nodeStatsTracker) recordPredicates(node string, err error) {
+	t.Lock()
+	defer t.Unlock()
+	stats := t.get(node)
+	stats.predicateRuns++
+	if err != nil {
+		stats.predicateFailures++
+	}
+}
+
+// counts the binding of a pod to the node, the binding fails when either the volumes or the pod cannot be bound
+func (t *nodeStatsTracker) recordBind(node string, err error) {
+	t.Lock()
+	defer t.Unlock()
+	stats := t.get(node)
+	if err == nil {
+		stats.binds++
+		return
+	}
+	stats.bindFailures++
+	stats.lastBindFailure = time.Now()
+	stats.lastBindError = err.Error()
+}
+
+func (t *nodeStatsTracker) remove(node string) {
+	t.Lock()
+	defer t.Unlock()
+	delete(t.nodes, node)
+}
+
+func (t *nodeStatsTracker) getStats(node string) *NodeStats {
+	result := &NodeStats{Name: node, PredicatePassRate: 1}
+	stats, ok := t.nodes[node]
+	if !ok {
+		return result
+	}
+	result.AllocationAttempts = stats.allocations
+	result.PredicateRuns = stats.predicateRuns
+	result.PredicateFailures = stats.predicateFailures
+	if stats.predicateRuns > 0 {
+		result.PredicatePassRate = float64(stats.predicateRuns-stats.predicateFailures) / float64(stats.predicateRuns)
+	}
+	result.Binds = stats.binds
+	result.BindFailures = stats.bindFailures
+	if stats.bindFailures > 0 {
+		last := stats.lastBindFailure
+		result.LastBindFailure = &last
+		result.LastBindError = stats.lastBindError
+	}
+	return result
+}
+
+// GetNodeStats returns the scheduling statistics of the nodes known to the shim,
+// the nodes with the most bind failures first. The utilization is computed on each call.
+func (ctx *Context) GetNodeStats() []*NodeStats {
+	nodeInfos := ctx.schedulerCache.GetNodesInfoMapCopy()
+	ctx.nodeStats.Lock()
+	result := make([]*NodeStats, 0, len(nodeInfos))
+	for name, nodeInfo := range nodeInfos {
+		stats := ctx.nodeStats.getStats(name)
+		if nodeInfo.Allocatable != nil && nodeInfo.Requested != nil {
+			stats.Utilization = make(map[string]float64)
+			if nodeInfo.Allocatable.MilliCPU > 0 {
+				stats.Utilization[string(v1.ResourceCPU)] = float64(nodeInfo.Requested.MilliCPU) / float64(nodeInfo.Allocatable.MilliCPU)
+			}
+			if nodeInfo.Allocatable.Memory > 0 {
+				stats.Utilization[string(v1.ResourceMemory)] = float64(nodeInfo.Requested.Memory) / float64(nodeInfo.Allocatable.Memory)
+			}
+		}
+		result = append(result, stats)
+	}
+	ctx.nodeStats.Unlock()
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].BindFailures != result[j].BindFailures {
+			return result[i].BindFailures > result[j].BindFailures
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// NodePod is a pod placed on a node as seen by the scheduler cache
+type NodePod struct {
+	Namespace     string      `json:"namespace"`
+	Name          string      `json:"name"`
+	UID           string      `json:"uid"`
+	Phase         v1.PodPhase `json:"phase"`
+	SchedulerName string      `json:"schedulerName"`
+	ApplicationID string      `json:"applicationId,omitempty"`
+}
+
+// GetNodePods returns the pods placed on the node sorted by namespace and name,
+// nil if the node is not known to the shim
+func (ctx *Context) GetNodePods(nodeName string) []*NodePod {
+	if ctx.schedulerCache.GetNode(nodeName) == nil {
+		return nil
+	}
+	pods := ctx.schedulerCache.GetPodsOnNode(nodeName)
+	result := make([]*NodePod, 0, len(pods))
+	for _, pod := range pods {
+		// pods of other schedulers do not have an application
+		appID, _ := utils.GetApplicationIDFromPod(pod)
+		result = append(result, &NodePod{
+			Namespace:     pod.Namespace,
+			Name:          pod.Name,
+			UID:           string(pod.UID),
+			Phase:         pod.Status.Phase,
+			SchedulerName: pod.Spec.SchedulerName,
+			ApplicationID: appID,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
@@ -0,0 +1,111 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"testing"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetNodeStats(t *testing.T) {
+	context := initContextForTest()
+	for _, name := range []string{"node-1", "node-2"} {
+		context.schedulerCache.AddNode(&v1.Node{
+			ObjectMeta: apis.ObjectMeta{Name: name},
+			Status: v1.NodeStatus{
+				Allocatable: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("4"),
+					v1.ResourceMemory: resource.MustParse("8Gi"),
+				},
+			},
+		})
+	}
+
+	context.nodeStats.recordAllocation("node-1")
+	context.nodeStats.recordPredicates("node-1", nil)
+	context.nodeStats.recordBind("node-1", nil)
+	context.nodeStats.recordAllocation("node-2")
+	context.nodeStats.recordAllocation("node-2")
+	context.nodeStats.recordPredicates("node-2", nil)
+	context.nodeStats.recordPredicates("node-2", fmt.Errorf("node(s) had taints"))
+	context.nodeStats.recordBind("node-2", fmt.Errorf("connection refused"))
+	context.nodeStats.recordBind("node-2", nil)
+
+	stats := context.GetNodeStats()
+	assert.Equal(t, len(stats), 2)
+	// most bind failures first
+	assert.Equal(t, stats[0].Name, "node-2")
+	assert.Equal(t, stats[0].AllocationAttempts, uint64(2))
+	assert.Equal(t, stats[0].PredicateRuns, uint64(2))
+	assert.Equal(t, stats[0].PredicateFailures, uint64(1))
+	assert.Equal(t, stats[0].PredicatePassRate, 0.5)
+	assert.Equal(t, stats[0].Binds, uint64(1))
+	assert.Equal(t, stats[0].BindFailures, uint64(1))
+	assert.Assert(t, stats[0].LastBindFailure != nil)
+	assert.Equal(t, stats[0].LastBindError, "connection refused")
+	assert.Equal(t, stats[0].Utilization[string(v1.ResourceCPU)], 0.0)
+	assert.Equal(t, stats[1].Name, "node-1")
+	assert.Equal(t, stats[1].PredicatePassRate, 1.0)
+	assert.Equal(t, stats[1].BindFailures, uint64(0))
+	assert.Assert(t, stats[1].LastBindFailure == nil)
+
+	// the stats are dropped with the node
+	context.nodeStats.remove("node-2")
+	stats = context.GetNodeStats()
+	assert.Equal(t, stats[0].Name, "node-1")
+	assert.Equal(t, stats[1].Name, "node-2")
+	assert.Equal(t, stats[1].AllocationAttempts, uint64(0))
+}
+
+func TestGetNodePods(t *testing.T) {
+	context := initContextForTest()
+	assert.Assert(t, context.GetNodePods("node-1") == nil)
+
+	context.schedulerCache.AddNode(&v1.Node{
+		ObjectMeta: apis.ObjectMeta{Name: "node-1"},
+	})
+	assert.Equal(t, len(context.GetNodePods("node-1")), 0)
+
+	pod1 := newPodHelper("pod-b", "default", "UID-POD-00001", "node-1", v1.PodRunning)
+	pod1.Labels = map[string]string{"applicationId": "app-1"}
+	pod2 := newPodHelper("pod-a", "default", "UID-POD-00002", "node-1", v1.PodRunning)
+	pod2.Spec.SchedulerName = "default-scheduler"
+	pod3 := newPodHelper("pod-c", "default", "UID-POD-00003", "node-2", v1.PodRunning)
+	for _, pod := range []*v1.Pod{pod1, pod2, pod3} {
+		assert.NilError(t, context.schedulerCache.AddPod(pod))
+	}
+	pods := context.GetNodePods("node-1")
+	assert.Equal(t, len(pods), 2)
+	assert.Equal(t, pods[0].Name, "pod-a")
+	assert.Equal(t, pods[0].SchedulerName, "default-scheduler")
+	assert.Equal(t, pods[0].ApplicationID, "")
+	assert.Equal(t, pods[1].Name, "pod-b")
+	assert.Equal(t, pods[1].ApplicationID, "app-1")
+	assert.Equal(t, pods[1].Phase, v1.PodRunning)
+
+	assert.NilError(t, context.schedulerCache.RemovePod(pod2))
+	pods = context.GetNodePods("node-1")
+	assert.Equal(t, len(pods), 1)
+	assert.Equal(t, pods[0].Name, "pod-b")
+}
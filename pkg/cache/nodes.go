@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
@@ -44,10 +45,13 @@ const (
 
 // scheduler nodes maintain cluster nodes and their status for the scheduler
 type schedulerNodes struct {
-	proxy    api.SchedulerAPI
-	nodesMap map[string]*SchedulerNode
-	cache    *external.SchedulerCache
-	lock     *sync.RWMutex
+	proxy        api.SchedulerAPI
+	nodesMap     map[string]*SchedulerNode
+	cache        *external.SchedulerCache
+	maxStaleness time.Duration   // occupied resource updates are collapsed for at most this long, 0 sends them directly
+	pending      map[string]bool // nodes with occupied resource updates not sent to the core yet
+	flushTimer   *time.Timer     // sends the pending updates, nil when nothing is pending
+	lock         *sync.RWMutex
 }
 
 func newSchedulerNodes(schedulerAPI api.SchedulerAPI, cache *external.SchedulerCache) *schedulerNodes {
@@ -55,6 +59,7 @@ func newSchedulerNodes(schedulerAPI api.SchedulerAPI, cache *external.SchedulerC
 		proxy:    schedulerAPI,
 		nodesMap: make(map[string]*SchedulerNode),
 		cache:    cache,
+		pending:  make(map[string]bool),
 		lock:     &sync.RWMutex{},
 	}
 }
@@ -68,6 +73,13 @@ func (nc *schedulerNodes) getNode(name string) *SchedulerNode {
 	return nil
 }
 
+// returns the number of nodes known to the shim
+func (nc *schedulerNodes) count() int {
+	nc.lock.RLock()
+	defer nc.lock.RUnlock()
+	return len(nc.nodesMap)
+}
+
 func convertToNode(obj interface{}) (*v1.Node, error) {
 	if node, ok := obj.(*v1.Node); ok {
 		return node, nil
@@ -95,21 +107,34 @@ func (nc *schedulerNodes) addNode(node *v1.Node) {
 	nc.addAndReportNode(node, true)
 }
 
+// adds the listed nodes under a single lock, the nodes that still need to be recovered are reported
+func (nc *schedulerNodes) addNodes(nodes []*v1.Node) {
+	nc.lock.Lock()
+	defer nc.lock.Unlock()
+	for _, node := range nodes {
+		nc.addNodeInternal(node, true)
+	}
+}
+
 func (nc *schedulerNodes) addAndReportNode(node *v1.Node, reportNode bool) {
 	nc.lock.Lock()
 	defer nc.lock.Unlock()
+	nc.addNodeInternal(node, reportNode)
+}
 
+// the lock must be held
+func (nc *schedulerNodes) addNodeInternal(node *v1.Node, reportNode bool) {
 	// add node to nodes map
 	if _, ok := nc.nodesMap[node.Name]; !ok {
 
 		var nodeLabels []byte
 		nodeLabels, err := json.Marshal(node.Labels) // A nil pointer encodes as the "null" JSON value.
 		if err != nil {
-			log.Logger().Error("failed to marshall node labels to json", zap.Error(err))
+			log.Log(log.Cache).Error("failed to marshall node labels to json", zap.Error(err))
 			nodeLabels = make([]byte, 0)
 		}
 
-		log.Logger().Info("adding node to context",
+		log.Log(log.Cache).Info("adding node to context",
 			zap.String("nodeName", node.Name),
 			zap.String("nodeLabels", string(nodeLabels)),
 			zap.Bool("schedulable", !node.Spec.Unschedulable))
@@ -135,7 +160,7 @@ func (nc *schedulerNodes) addAndReportNode(node *v1.Node, reportNode bool) {
 }
 
 func (nc *schedulerNodes) drainNode(node *v1.Node) {
-	log.Logger().Info("draining node", zap.String("name", node.Name))
+	log.Log(log.Cache).Info("draining node", zap.String("name", node.Name))
 	if node, ok := nc.nodesMap[node.Name]; ok {
 		if node.getNodeState() == events.States().Node.Healthy {
 			dispatcher.Dispatch(CachedSchedulerNodeEvent{
@@ -147,7 +172,7 @@ func (nc *schedulerNodes) drainNode(node *v1.Node) {
 }
 
 func (nc *schedulerNodes) restoreNode(node *v1.Node) {
-	log.Logger().Info("restoring node", zap.String("name", node.Name))
+	log.Log(log.Cache).Info("restoring node", zap.String("name", node.Name))
 	if node, ok := nc.nodesMap[node.Name]; ok {
 		if node.getNodeState() == events.States().Node.Draining {
 			dispatcher.Dispatch(CachedSchedulerNodeEvent{
@@ -177,22 +202,66 @@ func (nc *schedulerNodes) updateNodeOccupiedResources(name string, resource *si.
 			return
 		}
 
+		// the occupied resources change with every pod not scheduled by us, the updates of all
+		// nodes are collapsed and sent to the core in one request once the staleness is reached
+		if nc.maxStaleness > 0 {
+			nc.pending[schedulerNode.name] = true
+			if nc.flushTimer == nil {
+				nc.flushTimer = time.AfterFunc(nc.maxStaleness, nc.flushOccupiedResources)
+			}
+			return
+		}
+
 		node := common.NewNode(schedulerNode.name, schedulerNode.uid, schedulerNode.capacity, schedulerNode.occupied)
 		request := common.CreateUpdateRequestForUpdatedNode(node)
-		log.Logger().Info("report occupied resources updates",
+		log.Log(log.Cache).Info("report occupied resources updates",
 			zap.String("node", schedulerNode.name),
 			zap.Any("request", request))
 		if err := nc.proxy.UpdateNode(&request); err != nil {
-			log.Logger().Info("hitting error while handling UpdateNode", zap.Error(err))
+			log.Log(log.Cache).Info("hitting error while handling UpdateNode", zap.Error(err))
+		}
+	}
+}
+
+// sends the collapsed occupied resource updates of all the pending nodes to the core
+func (nc *schedulerNodes) flushOccupiedResources() {
+	nc.lock.Lock()
+	defer nc.lock.Unlock()
+
+	nc.flushTimer = nil
+	nodes := make([]common.Node, 0, len(nc.pending))
+	for name := range nc.pending {
+		if schedulerNode, ok := nc.nodesMap[name]; ok {
+			nodes = append(nodes, common.NewNode(schedulerNode.name, schedulerNode.uid,
+				schedulerNode.capacity, schedulerNode.occupied))
 		}
 	}
+	nc.pending = make(map[string]bool)
+	if len(nodes) == 0 {
+		return
+	}
+
+	request := common.CreateUpdateRequestForUpdatedNodes(nodes)
+	log.Log(log.Cache).Info("report collapsed occupied resources updates",
+		zap.Int("nodes", len(nodes)))
+	if err := nc.proxy.UpdateNode(&request); err != nil {
+		log.Log(log.Cache).Info("hitting error while handling UpdateNode", zap.Error(err))
+	}
+}
+
+// returns the number of nodes with occupied resource updates waiting to be sent to the core
+func (nc *schedulerNodes) pendingUpdates() int {
+	nc.lock.RLock()
+	defer nc.lock.RUnlock()
+	return len(nc.pending)
 }
 
 func (nc *schedulerNodes) updateNode(oldNode, newNode *v1.Node) {
 	// before updating a node, check if it exists in the cache or not
 	// if we receive a update node event but the node doesn't exist,
 	// we need to add it instead of updating it.
-	if cachedNode := nc.getNode(newNode.Name); cachedNode == nil {
+	cachedNode := nc.getNode(newNode.Name)
+	if cachedNode == nil {
 		nc.addNode(newNode)
 		return
 	}
@@ -212,11 +281,14 @@ func (nc *schedulerNodes) updateNode(oldNode, newNode *v1.Node) {
 		return
 	}
 
-	node := common.CreateFrom(newNode)
+	// capacity changes are not collapsed, the pending occupied resources of the node go along
+	cachedNode.capacity = common.GetNodeResource(&newNode.Status)
+	delete(nc.pending, cachedNode.name)
+	node := common.NewNode(cachedNode.name, cachedNode.uid, cachedNode.capacity, cachedNode.occupied)
 	request := common.CreateUpdateRequestForUpdatedNode(node)
-	log.Logger().Info("report updated nodes to scheduler", zap.Any("request", request))
+	log.Log(log.Cache).Info("report updated nodes to scheduler", zap.Any("request", request))
 	if err := nc.proxy.UpdateNode(&request); err != nil {
-		log.Logger().Info("hitting error while handling UpdateNode", zap.Error(err))
+		log.Log(log.Cache).Info("hitting error while handling UpdateNode", zap.Error(err))
 	}
 }
 
@@ -225,12 +297,13 @@ func (nc *schedulerNodes) deleteNode(node *v1.Node) {
 	defer nc.lock.Unlock()
 
 	delete(nc.nodesMap, node.Name)
+	delete(nc.pending, node.Name)
 
 	n := common.CreateFrom(node)
 	request := common.CreateUpdateRequestForDeleteNode(n)
-	log.Logger().Info("report updated nodes to scheduler", zap.Any("request", request.String()))
+	log.Log(log.Cache).Info("report updated nodes to scheduler", zap.Any("request", request.String()))
 	if err := nc.proxy.UpdateNode(&request); err != nil {
-		log.Logger().Error("hitting error while handling UpdateNode", zap.Error(err))
+		log.Log(log.Cache).Error("hitting error while handling UpdateNode", zap.Error(err))
 	}
 }
 
@@ -240,7 +313,7 @@ func (nc *schedulerNodes) schedulerNodeEventHandler() func(obj interface{}) {
 			if node := nc.getNode(event.GetNodeID()); node != nil {
 				if node.canHandle(event) {
 					if err := node.handle(event); err != nil {
-						log.Logger().Error("failed to handle scheduler node event",
+						log.Log(log.Cache).Error("failed to handle scheduler node event",
 							zap.String("event", string(event.GetEvent())),
 							zap.Error(err))
 					}
@@ -30,6 +30,7 @@ import (
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
 
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/cache/external"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/test"
@@ -554,3 +555,68 @@ func TestCordonNode(t *testing.T) {
 	}, 1*time.Second, 5*time.Second)
 	assert.NilError(t, err)
 }
+
+func TestCollapseOccupiedResourceUpdates(t *testing.T) {
+	api := test.NewSchedulerAPIMock()
+	requests := make(chan *si.NodeRequest, 10)
+	api.UpdateNodeFunction(func(request *si.NodeRequest) error {
+		requests <- request
+		return nil
+	})
+
+	nodes := newSchedulerNodes(api, NewTestSchedulerCache())
+	nodes.maxStaleness = 100 * time.Millisecond
+	host1 := utils.NodeForTest("host0001", "10G", "10")
+	host2 := utils.NodeForTest("host0002", "10G", "10")
+	nodes.addAndReportNode(host1, false)
+	nodes.addAndReportNode(host2, false)
+
+	// the updates are held back and collapsed into one request
+	occupied := common.NewResourceBuilder().
+		AddResource(constants.Memory, 100).
+		AddResource(constants.CPU, 100).
+		Build()
+	nodes.updateNodeOccupiedResources("host0001", occupied, AddOccupiedResource)
+	nodes.updateNodeOccupiedResources("host0001", occupied, AddOccupiedResource)
+	nodes.updateNodeOccupiedResources("host0001", occupied, AddOccupiedResource)
+	nodes.updateNodeOccupiedResources("host0002", occupied, AddOccupiedResource)
+	assert.Equal(t, api.GetUpdateNodeCount(), int32(0))
+	assert.Equal(t, nodes.pendingUpdates(), 2)
+
+	select {
+	case request := <-requests:
+		assert.Equal(t, len(request.Nodes), 2)
+		for _, info := range request.Nodes {
+			assert.Equal(t, info.Action, si.NodeInfo_UPDATE)
+			switch info.NodeID {
+			case "host0001":
+				assert.Equal(t, info.OccupiedResource.Resources[constants.Memory].Value, int64(300))
+			case "host0002":
+				assert.Equal(t, info.OccupiedResource.Resources[constants.Memory].Value, int64(100))
+			default:
+				t.Fatalf("unexpected node %s", info.NodeID)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("collapsed update was not sent")
+	}
+	assert.Equal(t, api.GetUpdateNodeCount(), int32(1))
+	assert.Equal(t, nodes.pendingUpdates(), 0)
+
+	// a capacity change is sent immediately, the pending occupied resources go along
+	nodes.updateNodeOccupiedResources("host0001", occupied, SubOccupiedResource)
+	assert.Equal(t, nodes.pendingUpdates(), 1)
+	updated := utils.NodeForTest("host0001", "20G", "10")
+	nodes.updateNode(host1, updated)
+	assert.Equal(t, api.GetUpdateNodeCount(), int32(2))
+	assert.Equal(t, nodes.pendingUpdates(), 0)
+	request := <-requests
+	assert.Equal(t, len(request.Nodes), 1)
+	assert.Equal(t, request.Nodes[0].NodeID, "host0001")
+	assert.Equal(t, request.Nodes[0].SchedulableResource.Resources[constants.Memory].Value, int64(20000))
+	assert.Equal(t, request.Nodes[0].OccupiedResource.Resources[constants.Memory].Value, int64(200))
+
+	// the cleared node is not reported again when the timer fires
+	time.Sleep(2 * nodes.maxStaleness)
+	assert.Equal(t, api.GetUpdateNodeCount(), int32(2))
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
 )
 
 // MUST: run the placeholder pod as non-root user
@@ -39,6 +40,29 @@ import (
 var runAsUser int64 = 1000
 var runAsGroup int64 = 3000
 
+// the placeholders follow the Restricted Pod Security Standard, so that gang scheduling works in the
+// namespaces that enforce it: the placeholder runs as non-root with the default seccomp profile, cannot
+// escalate its privileges, has no capabilities and a read-only root filesystem
+func restrictedPodSecurityContext() *v1.PodSecurityContext {
+	runAsNonRoot := true
+	return &v1.PodSecurityContext{
+		RunAsUser:      &runAsUser,
+		RunAsGroup:     &runAsGroup,
+		RunAsNonRoot:   &runAsNonRoot,
+		SeccompProfile: &v1.SeccompProfile{Type: v1.SeccompProfileTypeRuntimeDefault},
+	}
+}
+
+func restrictedContainerSecurityContext() *v1.SecurityContext {
+	allowPrivilegeEscalation := false
+	readOnlyRootFilesystem := true
+	return &v1.SecurityContext{
+		AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+		ReadOnlyRootFilesystem:   &readOnlyRootFilesystem,
+		Capabilities:             &v1.Capabilities{Drop: []v1.Capability{"ALL"}},
+	}
+}
+
 type Placeholder struct {
 	appID         string
 	taskGroupName string
@@ -72,17 +96,15 @@ func newPlaceholder(placeholderName string, app *Application, taskGroup v1alpha1
 			OwnerReferences: ownerRefs,
 		},
 		Spec: v1.PodSpec{
-			SecurityContext: &v1.PodSecurityContext{
-				RunAsUser:  &runAsUser,
-				RunAsGroup: &runAsGroup,
-			},
+			SecurityContext: restrictedPodSecurityContext(),
 			Containers: []v1.Container{
 				{
 					Name:  constants.PlaceholderContainerName,
-					Image: constants.PlaceholderContainerImage,
+					Image: app.getPlaceholderImage(),
 					Resources: v1.ResourceRequirements{
 						Requests: utils.GetPlaceholderResourceRequest(taskGroup.MinResource),
 					},
+					SecurityContext: restrictedContainerSecurityContext(),
 				},
 			},
 			RestartPolicy: constants.PlaceholderPodRestartPolicy,
@@ -100,6 +122,17 @@ func newPlaceholder(placeholderName string, app *Application, taskGroup v1alpha1
 	}
 }
 
+// replaces the restricted security context of the placeholder pod or of its container with the
+// security context of the cluster, e.g. when the cluster requires a user ID range or an fsGroup
+func (p *Placeholder) overrideSecurityContext(override *conf.PlaceholderSecurityContext) {
+	if override.Pod != nil {
+		p.pod.Spec.SecurityContext = override.Pod.DeepCopy()
+	}
+	if override.Container != nil {
+		p.pod.Spec.Containers[0].SecurityContext = override.Container.DeepCopy()
+	}
+}
+
 func (p *Placeholder) String() string {
 	return fmt.Sprintf("appID: %s, taskGroup: %s, podName: %s/%s",
 		p.appID, p.taskGroupName, p.pod.Namespace, p.pod.Name)
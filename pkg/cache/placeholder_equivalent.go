@@ -0,0 +1,95 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+)
+
+// returns the oldest pod of the application that is a member of the task group, nil when no member is known yet
+func (app *Application) getTaskGroupMember(taskGroupName string) *v1.Pod {
+	var member *Task
+	for _, task := range app.getAllTasks() {
+		if task.placeholder || task.taskGroupName != taskGroupName {
+			continue
+		}
+		if member == nil || task.createTime.Before(member.createTime) {
+			member = task
+		}
+	}
+	if member == nil {
+		return nil
+	}
+	return member.pod
+}
+
+// makes the placeholder an equivalent of the member pod for the scheduler simulation of the cluster autoscaler:
+// the autoscaler scales up for the placeholders, the node shapes must fit the real pods that replace them.
+// The placeholder requests the resources of the member when the minimum resources of the task group are lower,
+// and takes the scheduling constraints of the member the task group does not define.
+func (p *Placeholder) mirrorMember(member *v1.Pod) {
+	if member == nil {
+		return
+	}
+	spec := &p.pod.Spec
+	requests := spec.Containers[0].Resources.Requests
+	for name, quantity := range getPodRequests(member) {
+		if current, ok := requests[name]; !ok || current.Cmp(quantity) < 0 {
+			requests[name] = quantity
+		}
+	}
+	if len(spec.NodeSelector) == 0 {
+		spec.NodeSelector = member.Spec.NodeSelector
+	}
+	if len(spec.Tolerations) == 0 {
+		spec.Tolerations = member.Spec.Tolerations
+	}
+	if spec.Affinity == nil {
+		spec.Affinity = member.Spec.Affinity
+	}
+	spec.TopologySpreadConstraints = member.Spec.TopologySpreadConstraints
+	spec.PriorityClassName = member.Spec.PriorityClassName
+	spec.Priority = member.Spec.Priority
+	spec.RuntimeClassName = member.Spec.RuntimeClassName
+	spec.Overhead = member.Spec.Overhead
+	p.pod.Annotations[constants.AnnotationEquivalentPod] = member.Namespace + "/" + member.Name
+}
+
+// returns the resources the pod requests from a node without the overhead: the sum of the containers,
+// or the largest init container when it requests more
+func getPodRequests(pod *v1.Pod) v1.ResourceList {
+	requests := v1.ResourceList{}
+	for _, container := range pod.Spec.Containers {
+		for name, quantity := range container.Resources.Requests {
+			total := requests[name]
+			total.Add(quantity)
+			requests[name] = total
+		}
+	}
+	for _, container := range pod.Spec.InitContainers {
+		for name, quantity := range container.Resources.Requests {
+			if current, ok := requests[name]; !ok || current.Cmp(quantity) < 0 {
+				requests[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	return requests
+}
@@ -0,0 +1,138 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+)
+
+func TestGetPodRequests(t *testing.T) {
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{
+				{Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("2"),
+					v1.ResourceMemory: resource.MustParse("1Gi"),
+				}}},
+			},
+			Containers: []v1.Container{
+				{Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("500m"),
+					v1.ResourceMemory: resource.MustParse("2Gi"),
+				}}},
+				{Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("1"),
+					v1.ResourceMemory: resource.MustParse("1Gi"),
+				}}},
+			},
+		},
+	}
+	requests := getPodRequests(pod)
+	// the init container requests more cpu than all the containers, but less memory
+	assert.Equal(t, requests.Cpu().MilliValue(), int64(2000))
+	memory := resource.MustParse("3Gi")
+	assert.Equal(t, requests.Memory().Value(), memory.Value())
+}
+
+func TestEquivalentPlaceholders(t *testing.T) {
+	app := createAppWIthTaskGroupForTest()
+	priority := int32(100)
+	member := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name:      "member-01",
+			Namespace: namespace,
+			UID:       "UID-01",
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("2"),
+					v1.ResourceMemory: resource.MustParse("512M"),
+				}}},
+			},
+			NodeSelector:      map[string]string{"node.kubernetes.io/instance-type": "large"},
+			Tolerations:       []v1.Toleration{{Key: "gpu", Operator: v1.TolerationOpExists}},
+			PriorityClassName: "high",
+			Priority:          &priority,
+		},
+	}
+	app.addTask(NewFromTaskMeta("task-01", app, nil, interfaces.TaskMetadata{
+		ApplicationID: appID,
+		TaskID:        "task-01",
+		Pod:           member,
+		TaskGroupName: "test-group-1",
+	}))
+	// a younger member is not used
+	younger := member.DeepCopy()
+	younger.Name = "member-02"
+	younger.CreationTimestamp = apis.NewTime(time.Now())
+	app.addTask(NewFromTaskMeta("task-02", app, nil, interfaces.TaskMetadata{
+		ApplicationID: appID,
+		TaskID:        "task-02",
+		Pod:           younger,
+		TaskGroupName: "test-group-1",
+	}))
+
+	mockedAPIProvider := client.NewMockedAPIProvider()
+	var lock sync.Mutex
+	created := make(map[string]*v1.Pod)
+	mockedAPIProvider.MockCreateFn(func(pod *v1.Pod) (*v1.Pod, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		created[pod.Annotations[constants.AnnotationTaskGroupName]] = pod
+		return pod, nil
+	})
+	mgr := NewPlaceholderManager(mockedAPIProvider.GetAPIs())
+	assert.NilError(t, mgr.createAppPlaceholders(app))
+	// disabled by default
+	assert.Equal(t, created["test-group-1"].Spec.Containers[0].Resources.Requests.Cpu().MilliValue(), int64(500))
+	assert.Equal(t, len(created["test-group-1"].Spec.NodeSelector), 0)
+
+	mockedAPIProvider.GetAPIs().Conf.EquivalentPlaceholders = true
+	assert.NilError(t, mgr.createAppPlaceholders(app))
+	placeholder := created["test-group-1"]
+	assert.Equal(t, placeholder.Annotations[constants.AnnotationEquivalentPod], namespace+"/member-01")
+	// the larger of the member and the task group resources is requested
+	requests := placeholder.Spec.Containers[0].Resources.Requests
+	assert.Equal(t, requests.Cpu().MilliValue(), int64(2000))
+	memory := resource.MustParse("1024M")
+	assert.Equal(t, requests.Memory().Value(), memory.Value())
+	assert.DeepEqual(t, placeholder.Spec.NodeSelector, member.Spec.NodeSelector)
+	assert.DeepEqual(t, placeholder.Spec.Tolerations, member.Spec.Tolerations)
+	assert.Equal(t, placeholder.Spec.PriorityClassName, "high")
+	assert.Equal(t, *placeholder.Spec.Priority, priority)
+
+	// the task group without a member is created from the task group only
+	placeholder = created["test-group-2"]
+	_, ok := placeholder.Annotations[constants.AnnotationEquivalentPod]
+	assert.Assert(t, !ok)
+	assert.Equal(t, placeholder.Spec.Containers[0].Resources.Requests.Cpu().MilliValue(), int64(1000))
+	assert.Equal(t, placeholder.Spec.PriorityClassName, "")
+}
@@ -19,6 +19,7 @@
 package cache
 
 import (
+	"fmt"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -26,7 +27,9 @@ import (
 
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
@@ -78,45 +81,120 @@ func (mgr *PlaceholderManager) createAppPlaceholders(app *Application) error {
 	defer mgr.Unlock()
 
 	// iterate all task groups, create placeholders for all the min members
+	placeholders := make([]*Placeholder, 0)
 	for _, tg := range app.getTaskGroups() {
 		for i := int32(0); i < tg.MinMember; i++ {
 			placeholderName := utils.GeneratePlaceholderName(tg.Name, app.GetApplicationID(), i)
-			placeholder := newPlaceholder(placeholderName, app, tg)
+			placeholders = append(placeholders, mgr.newPlaceholder(placeholderName, app, tg))
+		}
+	}
+
+	// the placeholders are created in parallel, after a failure the placeholders not started yet are skipped
+	size := mgr.getWorkers()
+	workers := newWorkerPool(func() int { return size })
+	var wg sync.WaitGroup
+	var errLock sync.Mutex
+	var createErr error
+	for _, placeholder := range placeholders {
+		workers.acquire()
+		errLock.Lock()
+		failed := createErr != nil
+		errLock.Unlock()
+		if failed {
+			workers.release()
+			break
+		}
+		wg.Add(1)
+		go func(placeholder *Placeholder) {
+			defer wg.Done()
+			defer workers.release()
 			// create the placeholder on K8s
-			_, err := mgr.clients.KubeClient.Create(placeholder.pod)
-			if err != nil {
-				log.Logger().Error("failed to create placeholder pod",
+			if _, err := mgr.clients.KubeClient.Create(placeholder.pod); err != nil {
+				log.Log(log.Cache).Error("failed to create placeholder pod",
 					zap.Error(err))
-				return err
+				errLock.Lock()
+				if createErr == nil {
+					createErr = err
+				}
+				errLock.Unlock()
+				return
 			}
-			log.Logger().Info("placeholder created",
+			log.Log(log.Cache).Info("placeholder created",
 				zap.String("placeholder", placeholder.String()))
+		}(placeholder)
+	}
+	wg.Wait()
+	return createErr
+}
+
+// creates a placeholder of the task group again after a preemption released it, the gang asks the core
+// for the reservation it lost
+func (mgr *PlaceholderManager) recreatePlaceholder(app *Application, name, taskGroupName string) error {
+	mgr.Lock()
+	defer mgr.Unlock()
+	for _, tg := range app.getTaskGroups() {
+		if tg.Name != taskGroupName {
+			continue
+		}
+		placeholder := mgr.newPlaceholder(name, app, tg)
+		if _, err := mgr.clients.KubeClient.Create(placeholder.pod); err != nil {
+			return err
 		}
+		log.Log(log.Cache).Info("placeholder re-created",
+			zap.String("placeholder", placeholder.String()))
+		return nil
+	}
+	return fmt.Errorf("task group %s is not found in application %s", taskGroupName, app.GetApplicationID())
+}
+
+// the placeholders are the equivalent of a member of their task group when the autoscaler simulation is enabled,
+// the security context of the cluster replaces the restricted defaults when it is set
+func (mgr *PlaceholderManager) newPlaceholder(name string, app *Application, tg v1alpha1.TaskGroup) *Placeholder {
+	placeholder := newPlaceholder(name, app, tg)
+	if mgr.clients.Conf.EquivalentPlaceholders {
+		placeholder.mirrorMember(app.getTaskGroupMember(tg.Name))
+	}
+	override, err := mgr.clients.Conf.GetPlaceholderSecurityContext()
+	if err != nil {
+		log.Log(log.Cache).Warn("ignoring the placeholder security context", zap.Error(err))
+	} else if override != nil {
+		placeholder.overrideSecurityContext(override)
 	}
+	return placeholder
+}
 
-	return nil
+// returns the number of placeholders created at the same time
+func (mgr *PlaceholderManager) getWorkers() int {
+	nodes := 0
+	if lister := mgr.clients.NodeInformer.Lister(); lister != nil {
+		if list, err := lister.List(labels.Everything()); err == nil {
+			nodes = len(list)
+		}
+	}
+	return scaledWorkers(mgr.clients.Conf.PlaceholderWorkers, nodes,
+		nodesPerPlaceholderWorker, minPlaceholderWorkers, maxPlaceholderWorkers)
 }
 
 // clean up all the placeholders for an application
 func (mgr *PlaceholderManager) cleanUp(app *Application) {
 	mgr.Lock()
 	defer mgr.Unlock()
-	log.Logger().Info("start to clean up app placeholders",
+	log.Log(log.Cache).Info("start to clean up app placeholders",
 		zap.String("appID", app.GetApplicationID()))
-	for taskID, task := range app.taskMap {
+	for _, task := range app.getAllTasks() {
 		if task.IsPlaceholder() {
 			// remove pod
 			err := mgr.clients.KubeClient.Delete(task.pod)
 			if err != nil {
-				log.Logger().Warn("failed to clean up placeholder pod",
+				log.Log(log.Cache).Warn("failed to clean up placeholder pod",
 					zap.Error(err))
 				if !strings.Contains(err.Error(), "not found") {
-					mgr.orphanPods[taskID] = task.pod
+					mgr.orphanPods[task.taskID] = task.pod
 				}
 			}
 		}
 	}
-	log.Logger().Info("finished cleaning up app placeholders",
+	log.Log(log.Cache).Info("finished cleaning up app placeholders",
 		zap.String("appID", app.GetApplicationID()))
 }
 
@@ -124,12 +202,12 @@ func (mgr *PlaceholderManager) cleanOrphanPlaceholders() {
 	mgr.Lock()
 	defer mgr.Unlock()
 	for taskID, pod := range mgr.orphanPods {
-		log.Logger().Debug("start to clean up orphan pod",
+		log.Log(log.Cache).Debug("start to clean up orphan pod",
 			zap.String("taskID", taskID),
 			zap.String("podName", pod.Name))
 		err := mgr.clients.KubeClient.Delete(pod)
 		if err != nil {
-			log.Logger().Warn("failed to clean up orphan pod", zap.Error(err))
+			log.Log(log.Cache).Warn("failed to clean up orphan pod", zap.Error(err))
 		} else {
 			delete(mgr.orphanPods, taskID)
 		}
@@ -138,10 +216,10 @@ func (mgr *PlaceholderManager) cleanOrphanPlaceholders() {
 
 func (mgr *PlaceholderManager) Start() {
 	if mgr.isRunning() {
-		log.Logger().Info("PlaceholderManager is already started")
+		log.Log(log.Cache).Info("PlaceholderManager is already started")
 		return
 	}
-	log.Logger().Info("starting the PlaceholderManager")
+	log.Log(log.Cache).Info("starting the PlaceholderManager")
 	mgr.setRunning(true)
 	go func() {
 		// clean orphan placeholders approximately every 5 seconds
@@ -149,7 +227,7 @@ func (mgr *PlaceholderManager) Start() {
 			select {
 			case <-mgr.stopChan:
 				mgr.setRunning(false)
-				log.Logger().Info("PlaceholderManager has been stopped")
+				log.Log(log.Cache).Info("PlaceholderManager has been stopped")
 				return
 			case <-time.After(mgr.cleanupTime):
 				mgr.cleanOrphanPlaceholders()
@@ -160,10 +238,10 @@ func (mgr *PlaceholderManager) Start() {
 
 func (mgr *PlaceholderManager) Stop() {
 	if !mgr.isRunning() {
-		log.Logger().Info("PlaceholderManager already stopped")
+		log.Log(log.Cache).Info("PlaceholderManager already stopped")
 		return
 	}
-	log.Logger().Info("stopping the PlaceholderManager")
+	log.Log(log.Cache).Info("stopping the PlaceholderManager")
 	mgr.stopChan <- struct{}{}
 }
 
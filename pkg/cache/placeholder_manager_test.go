@@ -20,6 +20,7 @@ package cache
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -73,18 +74,72 @@ func TestCreateAppPlaceholders(t *testing.T) {
 	assert.Error(t, err, "failed to create pod tg-test-group-2-app01-15")
 }
 
+func TestCreateAppPlaceholdersWorkers(t *testing.T) {
+	app := createAppWIthTaskGroupForTest()
+	mockedAPIProvider := client.NewMockedAPIProvider()
+	mockedAPIProvider.GetAPIs().Conf.PlaceholderWorkers = 2
+	var lock sync.Mutex
+	running := 0
+	maxRunning := 0
+	created := 0
+	mockedAPIProvider.MockCreateFn(func(pod *v1.Pod) (*v1.Pod, error) {
+		lock.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		lock.Unlock()
+		time.Sleep(time.Millisecond)
+		lock.Lock()
+		running--
+		created++
+		lock.Unlock()
+		return pod, nil
+	})
+	mgr := NewPlaceholderManager(mockedAPIProvider.GetAPIs())
+	assert.Equal(t, mgr.getWorkers(), 2)
+	assert.NilError(t, mgr.createAppPlaceholders(app))
+	assert.Equal(t, created, 30)
+	assert.Assert(t, maxRunning <= 2, "more placeholders created at the same time than configured: %d", maxRunning)
+
+	// scaled with the cluster size when not configured
+	mockedAPIProvider.GetAPIs().Conf.PlaceholderWorkers = 0
+	assert.Equal(t, mgr.getWorkers(), minPlaceholderWorkers)
+}
+
+func TestRecreatePlaceholder(t *testing.T) {
+	app := createAppWIthTaskGroupForTest()
+	mockedAPIProvider := client.NewMockedAPIProvider()
+	createdPods := newThreadSafePodsMap()
+	mockedAPIProvider.MockCreateFn(func(pod *v1.Pod) (*v1.Pod, error) {
+		createdPods.add(pod)
+		return pod, nil
+	})
+	mgr := NewPlaceholderManager(mockedAPIProvider.GetAPIs())
+	assert.NilError(t, mgr.recreatePlaceholder(app, "tg-test-group-2-app01-3", "test-group-2"))
+	assert.Equal(t, createdPods.count(), 1)
+	pod := createdPods.pods["tg-test-group-2-app01-3"]
+	assert.Assert(t, pod != nil)
+	assert.Equal(t, pod.Annotations[constants.AnnotationTaskGroupName], "test-group-2")
+	assert.Equal(t, pod.Labels[constants.LabelQueueName], queue)
+
+	err := mgr.recreatePlaceholder(app, "tg-test-group-3-app01-0", "test-group-3")
+	assert.Error(t, err, "task group test-group-3 is not found in application app01")
+	assert.Equal(t, createdPods.count(), 1)
+}
+
 func createAndCheckPlaceholderCreate(mockedAPIProvider *client.MockedAPIProvider, app *Application, t *testing.T) map[string]*v1.Pod {
-	createdPods := make(map[string]*v1.Pod)
+	createdPods := newThreadSafePodsMap()
 	mockedAPIProvider.MockCreateFn(func(pod *v1.Pod) (*v1.Pod, error) {
-		createdPods[pod.Name] = pod
+		createdPods.add(pod)
 		return pod, nil
 	})
 	placeholderMgr := NewPlaceholderManager(mockedAPIProvider.GetAPIs())
 
 	err := placeholderMgr.createAppPlaceholders(app)
 	assert.NilError(t, err, "create app placeholders should be successful")
-	assert.Equal(t, len(createdPods), 30)
-	return createdPods
+	assert.Equal(t, createdPods.count(), 30)
+	return createdPods.pods
 }
 
 func TestCreateAppPlaceholdersWithOwnReference(t *testing.T) {
@@ -29,6 +29,7 @@ import (
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
 )
 
 func TestNewPlaceholder(t *testing.T) {
@@ -69,6 +70,33 @@ func TestNewPlaceholder(t *testing.T) {
 	assert.Equal(t, holder.String(), "appID: app01, taskGroup: test-group-1, podName: test/ph-name")
 	assert.Equal(t, holder.pod.Spec.SecurityContext.RunAsUser, &runAsUser)
 	assert.Equal(t, holder.pod.Spec.SecurityContext.RunAsGroup, &runAsGroup)
+	assert.Equal(t, *holder.pod.Spec.SecurityContext.RunAsNonRoot, true)
+	assert.Equal(t, holder.pod.Spec.SecurityContext.SeccompProfile.Type, v1.SeccompProfileTypeRuntimeDefault)
+	containerSecurity := holder.pod.Spec.Containers[0].SecurityContext
+	assert.Equal(t, *containerSecurity.AllowPrivilegeEscalation, false)
+	assert.Equal(t, *containerSecurity.ReadOnlyRootFilesystem, true)
+	assert.DeepEqual(t, containerSecurity.Capabilities.Drop, []v1.Capability{"ALL"})
+}
+
+func TestOverrideSecurityContext(t *testing.T) {
+	app := NewApplication("app01", "root.default",
+		"bob", map[string]string{constants.AppTagNamespace: "test"}, newMockSchedulerAPI())
+	app.setTaskGroups([]v1alpha1.TaskGroup{{Name: "test-group-1", MinMember: 1}})
+
+	holder := newPlaceholder("ph-name", app, app.taskGroups[0])
+	fsGroup := int64(2000)
+	holder.overrideSecurityContext(&conf.PlaceholderSecurityContext{
+		Pod: &v1.PodSecurityContext{FSGroup: &fsGroup},
+	})
+	assert.DeepEqual(t, holder.pod.Spec.SecurityContext, &v1.PodSecurityContext{FSGroup: &fsGroup})
+	// the container keeps the restricted defaults
+	assert.DeepEqual(t, holder.pod.Spec.Containers[0].SecurityContext, restrictedContainerSecurityContext())
+
+	privileged := true
+	holder.overrideSecurityContext(&conf.PlaceholderSecurityContext{
+		Container: &v1.SecurityContext{Privileged: &privileged},
+	})
+	assert.DeepEqual(t, holder.pod.Spec.Containers[0].SecurityContext, &v1.SecurityContext{Privileged: &privileged})
 }
 
 func TestNewPlaceholderWithLabelsAndAnnotations(t *testing.T) {
@@ -0,0 +1,201 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// podStatusWrite is a write of one part of the pod status: a condition or the phase.
+type podStatusWrite struct {
+	pod   *v1.Pod
+	part  string // the part of the status written, the condition type or the phase
+	value string // identifies the content of the write, a write identical to the last one is skipped
+	apply func(pod *v1.Pod) error
+}
+
+// the writes of a single pod
+type podStatusWrites struct {
+	lastWrite time.Time
+	written   map[string]string          // value last written per part
+	pending   map[string]*podStatusWrite // writes held back by the minimum interval per part, the latest wins
+	timer     *time.Timer
+}
+
+// podStatusWriter is the single path of the pod status writes to the api-server.
+// Writes identical to the last write of the same part are skipped, and the writes of a pod
+// within the minimum interval are held back and collapsed, only the latest write of a part
+// is sent. This keeps the api-server load low when many pods are pending at the same time.
+type podStatusWriter struct {
+	minInterval time.Duration
+	pods        map[string]*podStatusWrites
+	lock        sync.Mutex
+}
+
+func newPodStatusWriter(minInterval time.Duration) *podStatusWriter {
+	return &podStatusWriter{
+		minInterval: minInterval,
+		pods:        make(map[string]*podStatusWrites),
+	}
+}
+
+// writes or holds back the write, returns false if the write was skipped because it is identical
+// to the last one. An error is only returned for a write that was sent directly.
+func (w *podStatusWriter) write(write *podStatusWrite) (bool, error) {
+	key := podStatusKey(write.pod)
+	w.lock.Lock()
+	writes, ok := w.pods[key]
+	if !ok {
+		writes = &podStatusWrites{
+			written: make(map[string]string),
+			pending: make(map[string]*podStatusWrite),
+		}
+		w.pods[key] = writes
+	}
+	if pending, ok := writes.pending[write.part]; ok {
+		if pending.value == write.value {
+			w.lock.Unlock()
+			return false, nil
+		}
+	} else if writes.written[write.part] == write.value {
+		w.lock.Unlock()
+		return false, nil
+	}
+
+	if wait := w.minInterval - time.Since(writes.lastWrite); !writes.lastWrite.IsZero() && wait > 0 {
+		writes.pending[write.part] = write
+		if writes.timer == nil {
+			writes.timer = time.AfterFunc(wait, func() {
+				w.flush(key)
+			})
+		}
+		w.lock.Unlock()
+		log.Log(log.Cache).Debug("pod status write held back",
+			zap.String("namespace", write.pod.Namespace),
+			zap.String("podName", write.pod.Name),
+			zap.String("part", write.part),
+			zap.Duration("wait", wait))
+		return true, nil
+	}
+	delete(writes.pending, write.part)
+	writes.lastWrite = time.Now()
+	w.lock.Unlock()
+
+	err := write.apply(write.pod)
+	if err == nil {
+		w.recordWritten(key, write)
+	}
+	return err == nil, err
+}
+
+// sends the writes of the pod held back by the minimum interval
+func (w *podStatusWriter) flush(key string) {
+	w.lock.Lock()
+	writes, ok := w.pods[key]
+	if !ok {
+		w.lock.Unlock()
+		return
+	}
+	pending := writes.pending
+	writes.pending = make(map[string]*podStatusWrite)
+	writes.timer = nil
+	writes.lastWrite = time.Now()
+	w.lock.Unlock()
+
+	for _, write := range pending {
+		if err := write.apply(write.pod); err != nil {
+			log.Log(log.Cache).Error("failed to write the held back pod status",
+				zap.String("namespace", write.pod.Namespace),
+				zap.String("podName", write.pod.Name),
+				zap.String("part", write.part),
+				zap.Error(err))
+			continue
+		}
+		w.recordWritten(key, write)
+	}
+}
+
+// records the value written, unless the pod was forgotten in the meantime
+func (w *podStatusWriter) recordWritten(key string, write *podStatusWrite) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if writes, ok := w.pods[key]; ok {
+		writes.written[write.part] = write.value
+	}
+}
+
+// drops the writes of a removed pod, the held back writes are not sent
+func (w *podStatusWriter) forget(pod *v1.Pod) {
+	key := podStatusKey(pod)
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if writes, ok := w.pods[key]; ok {
+		if writes.timer != nil {
+			writes.timer.Stop()
+		}
+		delete(w.pods, key)
+	}
+}
+
+// the writes are tracked per pod UID, the name is only used for a pod without one
+func podStatusKey(pod *v1.Pod) string {
+	if pod.UID != "" {
+		return string(pod.UID)
+	}
+	return pod.Namespace + "/" + pod.Name
+}
+
+// returns the number of pods with writes held back
+func (w *podStatusWriter) pendingPods() int {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	count := 0
+	for _, writes := range w.pods {
+		if len(writes.pending) > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// returns the write of a pod condition
+func newPodConditionWrite(pod *v1.Pod, condition *v1.PodCondition, apply func(pod *v1.Pod) error) *podStatusWrite {
+	return &podStatusWrite{
+		pod:   pod,
+		part:  "condition/" + string(condition.Type),
+		value: string(condition.Status) + "/" + condition.Reason + "/" + condition.Message,
+		apply: apply,
+	}
+}
+
+// returns the write of the pod phase, the reason and the message
+func newPodPhaseWrite(pod *v1.Pod, apply func(pod *v1.Pod) error) *podStatusWrite {
+	return &podStatusWrite{
+		pod:   pod,
+		part:  "phase",
+		value: string(pod.Status.Phase) + "/" + pod.Status.Reason + "/" + pod.Status.Message,
+		apply: apply,
+	}
+}
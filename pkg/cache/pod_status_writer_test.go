@@ -0,0 +1,161 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
+)
+
+// records the writes applied to the api-server
+type appliedWrites struct {
+	values []string
+	err    error
+	lock   sync.Mutex
+}
+
+func (a *appliedWrites) conditionWrite(pod *v1.Pod, reason string) *podStatusWrite {
+	condition := &v1.PodCondition{
+		Type:   v1.PodScheduled,
+		Status: v1.ConditionFalse,
+		Reason: reason,
+	}
+	return newPodConditionWrite(pod, condition, func(pod *v1.Pod) error {
+		a.lock.Lock()
+		defer a.lock.Unlock()
+		if a.err != nil {
+			return a.err
+		}
+		a.values = append(a.values, reason)
+		return nil
+	})
+}
+
+func (a *appliedWrites) get() []string {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return append([]string(nil), a.values...)
+}
+
+func (a *appliedWrites) setErr(err error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.err = err
+}
+
+func TestPodStatusWriterDedup(t *testing.T) {
+	writer := newPodStatusWriter(0)
+	applied := &appliedWrites{}
+	pod := utils.PodForTest("pod1", "1G", "500m")
+	pod.UID = "UID-00001"
+
+	written, err := writer.write(applied.conditionWrite(pod, "Unschedulable"))
+	assert.NilError(t, err)
+	assert.Assert(t, written)
+	// the same write again is skipped
+	written, err = writer.write(applied.conditionWrite(pod, "Unschedulable"))
+	assert.NilError(t, err)
+	assert.Assert(t, !written)
+	assert.DeepEqual(t, applied.get(), []string{"Unschedulable"})
+
+	// a changed condition is written
+	written, err = writer.write(applied.conditionWrite(pod, "SchedulingSkipped"))
+	assert.NilError(t, err)
+	assert.Assert(t, written)
+	assert.DeepEqual(t, applied.get(), []string{"Unschedulable", "SchedulingSkipped"})
+
+	// a failed write is not recorded and is tried again
+	applied.setErr(fmt.Errorf("api-server unavailable"))
+	written, err = writer.write(applied.conditionWrite(pod, "Unschedulable"))
+	assert.ErrorContains(t, err, "api-server unavailable")
+	assert.Assert(t, !written)
+	applied.setErr(nil)
+	written, err = writer.write(applied.conditionWrite(pod, "Unschedulable"))
+	assert.NilError(t, err)
+	assert.Assert(t, written)
+	assert.DeepEqual(t, applied.get(), []string{"Unschedulable", "SchedulingSkipped", "Unschedulable"})
+
+	// a forgotten pod starts over
+	writer.forget(pod)
+	written, err = writer.write(applied.conditionWrite(pod, "Unschedulable"))
+	assert.NilError(t, err)
+	assert.Assert(t, written)
+	assert.Equal(t, len(applied.get()), 4)
+}
+
+func TestPodStatusWriterMinInterval(t *testing.T) {
+	writer := newPodStatusWriter(100 * time.Millisecond)
+	applied := &appliedWrites{}
+	pod := utils.PodForTest("pod1", "1G", "500m")
+	pod.UID = "UID-00001"
+
+	written, err := writer.write(applied.conditionWrite(pod, "first"))
+	assert.NilError(t, err)
+	assert.Assert(t, written)
+
+	// the writes within the interval are held back, only the latest is sent
+	written, err = writer.write(applied.conditionWrite(pod, "second"))
+	assert.NilError(t, err)
+	assert.Assert(t, written)
+	written, err = writer.write(applied.conditionWrite(pod, "third"))
+	assert.NilError(t, err)
+	assert.Assert(t, written)
+	written, err = writer.write(applied.conditionWrite(pod, "third"))
+	assert.NilError(t, err)
+	assert.Assert(t, !written)
+	assert.DeepEqual(t, applied.get(), []string{"first"})
+	assert.Equal(t, writer.pendingPods(), 1)
+
+	// other pods are not limited by the writes of this pod
+	other := utils.PodForTest("pod2", "1G", "500m")
+	other.UID = "UID-00002"
+	written, err = writer.write(applied.conditionWrite(other, "first"))
+	assert.NilError(t, err)
+	assert.Assert(t, written)
+	assert.DeepEqual(t, applied.get(), []string{"first", "first"})
+
+	err = utils.WaitForCondition(func() bool {
+		return len(applied.get()) == 3
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err)
+	assert.Equal(t, writer.pendingPods(), 0)
+	assert.DeepEqual(t, applied.get(), []string{"first", "first", "third"})
+
+	// the held back writes of a forgotten pod are dropped: the interval may have passed since the last
+	// write of the pod, the second write is then sent and the third one held back
+	written, err = writer.write(applied.conditionWrite(other, "second"))
+	assert.NilError(t, err)
+	assert.Assert(t, written)
+	written, err = writer.write(applied.conditionWrite(other, "third"))
+	assert.NilError(t, err)
+	assert.Assert(t, written)
+	assert.Equal(t, writer.pendingPods(), 1)
+	sent := len(applied.get())
+	writer.forget(other)
+	assert.Equal(t, writer.pendingPods(), 0)
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, len(applied.get()), sent)
+}
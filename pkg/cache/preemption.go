@@ -0,0 +1,352 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+)
+
+// the results of the eviction of a preempted pod
+const (
+	evictionEvicted   = "evicted"
+	evictionGone      = "gone"
+	evictionFailed    = "failed"
+	evictionBlocked   = "blocked"
+	evictionProtected = "protected"
+	evictionRefused   = "refused"
+	evictionDisabled  = "disabled"
+	evictionDryRun    = "dryrun"
+	// the eviction API failed with a transient error, the eviction is retried
+	evictionUnavailable = "unavailable"
+)
+
+// the backoff of the evictions refused by a disruption budget, the retries end at the eviction timeout
+var disruptionBudgetBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2.0,
+	Jitter:   0.2,
+	Cap:      30 * time.Second,
+	Steps:    math.MaxInt32,
+}
+
+// the core names the ask it preempts for in the release message of the victims,
+// e.g. "preempted by task-0001 of application app-0001"
+var preemptorPattern = regexp.MustCompile(`preempted by (\S+) of application (\S+)`)
+
+// the preemption of the allocation of a task, kept on the victim until the allocation is released
+type preemption struct {
+	preemptorAppID  string
+	preemptorTaskID string
+	preemptorQueue  string
+	message         string
+	requested       time.Time
+	// the queue of the victim and whether its application allows preempting its own tasks
+	queue           string
+	intraAppAllowed bool
+	// the victim still runs and its resources are reported to the core as occupied
+	occupied bool
+	// the gang policy when the task is preempted, a change of the policy does not affect the running evictions
+	gangPolicy string
+}
+
+func newPreemption(message string, now time.Time) *preemption {
+	p := &preemption{
+		message:   message,
+		requested: now,
+	}
+	if match := preemptorPattern.FindStringSubmatch(message); match != nil {
+		p.preemptorTaskID = match[1]
+		p.preemptorAppID = match[2]
+	}
+	return p
+}
+
+// handles the allocation of the task the core preempted: the pod is evicted, which honours
+// its termination grace period, and the allocation is released back to the core with the
+// preempted termination type once the pod is gone. The queue and the intra-application preemption
+// opt-in come from the application of the task, the application lock is held by the caller.
+func (task *Task) preempt(queue string, intraAppAllowed bool, message string) {
+	p := newPreemption(message, time.Now())
+	p.queue = queue
+	p.intraAppAllowed = intraAppAllowed
+	p.preemptorQueue = task.getPreemptorQueue(p.preemptorAppID, queue)
+	task.preemptAllocation(p)
+}
+
+// evicts the pod of the preempted task, only the allocation the core released is evicted
+func (task *Task) preemptAllocation(p *preemption) {
+	task.lock.Lock()
+	if task.preemption != nil {
+		task.lock.Unlock()
+		task.logger().Debug("task is already being preempted",
+			zap.String("taskID", task.taskID))
+		return
+	}
+	task.terminationType = preemptedTerminationType
+	p.gangPolicy = task.context.apiProvider.GetAPIs().Conf.PreemptionGangPolicy
+	task.preemption = p
+	pod := task.pod
+	task.lock.Unlock()
+
+	task.logger().Info("evicting preempted pod",
+		zap.String("taskID", task.taskID),
+		zap.String("podName", pod.Name),
+		zap.String("preemptorAppID", p.preemptorAppID),
+		zap.String("preemptorQueue", p.preemptorQueue),
+		zap.String("message", p.message))
+	if p.preemptorAppID != "" {
+		events.GetRecorder().Eventf(pod, v1.EventTypeWarning, "Preempted",
+			"Task %s is preempted by task %s of application %s in queue %s",
+			task.alias, p.preemptorTaskID, p.preemptorAppID, p.preemptorQueue)
+	} else {
+		events.GetRecorder().Eventf(pod, v1.EventTypeWarning, "Preempted",
+			"Task %s is preempted by the scheduler: %s", task.alias, p.message)
+	}
+
+	if reason, result := task.skipEviction(p, pod); reason != "" {
+		task.logger().Info("preempted pod is not evicted",
+			zap.String("podName", pod.Name),
+			zap.String("reason", reason))
+		task.reportOccupied(p)
+		events.GetRecorder().Eventf(pod, v1.EventTypeNormal, "PreemptionSkipped",
+			"Task %s is not evicted and keeps running: %s", task.alias, reason)
+		metrics.GetPreemptionMetrics().IncVictims(result)
+		return
+	}
+	task.annotatePreemption(pod, p)
+	result := task.evictPod(pod)
+	switch result {
+	case evictionBlocked, evictionUnavailable:
+		task.reportOccupied(p)
+		go task.retryEviction(pod, p)
+		return
+	case evictionFailed:
+		task.reportOccupied(p)
+	}
+	metrics.GetPreemptionMetrics().IncVictims(result)
+	task.coordinateGang(p, result)
+}
+
+// returns the queue of the preempting application, the queue of the application of the task is passed in
+// as the lock of that application is held
+func (task *Task) getPreemptorQueue(preemptorAppID, queue string) string {
+	if preemptorAppID == "" {
+		return ""
+	}
+	if preemptorAppID == task.applicationID {
+		return queue
+	}
+	if app, ok := task.context.getApplication(preemptorAppID); ok {
+		return app.GetQueue()
+	}
+	return ""
+}
+
+// names the preempting application and queue on the victim, the annotations stay on the pod while it terminates
+func (task *Task) annotatePreemption(pod *v1.Pod, p *preemption) {
+	if p.preemptorAppID == "" {
+		return
+	}
+	annotations := map[string]string{constants.AnnotationPreemptedByApplication: p.preemptorAppID}
+	if p.preemptorQueue != "" {
+		annotations[constants.AnnotationPreemptedByQueue] = p.preemptorQueue
+	}
+	if _, err := client.ApplyPodAnnotations(task.context.apiProvider.GetAPIs().KubeClient.GetClientSet(), pod, annotations); err != nil {
+		task.logger().Warn("failed to annotate preempted pod",
+			zap.String("podName", pod.Name),
+			zap.Error(err))
+	}
+}
+
+// returns why the victim keeps running instead of being evicted and the result recorded for it,
+// the reason is empty when the victim is evicted
+func (task *Task) skipEviction(p *preemption, pod *v1.Pod) (string, string) {
+	schedulerConf := task.context.apiProvider.GetAPIs().Conf
+	switch {
+	case !schedulerConf.IsPreemptionEnabled(p.queue):
+		return fmt.Sprintf("preemption is not enabled for queue %s", p.queue), evictionDisabled
+	case p.preemptorAppID == task.applicationID && !p.intraAppAllowed:
+		return "the application does not allow preempting its own tasks", evictionRefused
+	// the tags ask the core to leave these pods alone, they are not evicted even when it does not
+	case !common.IsPreemptible(pod):
+		return "critical and static pods are never evicted", evictionRefused
+	case task.protectedByDisruptionBudget(pod):
+		return "the pod is protected by a PodDisruptionBudget", evictionProtected
+	// checked last, the preemption simulation evicts the victims that are only skipped by the dry-run
+	case schedulerConf.PreemptionDryRun:
+		return "preemption dry-run is enabled", evictionDryRun
+	}
+	return "", ""
+}
+
+// returns true when the protected victims are skipped and a disruption budget of the pod allows no disruption
+func (task *Task) protectedByDisruptionBudget(pod *v1.Pod) bool {
+	apis := task.context.apiProvider.GetAPIs()
+	if apis.Conf.PreemptionPDBPolicy != conf.PDBPolicySkip || apis.PDBInformer == nil {
+		return false
+	}
+	pdbs, err := apis.PDBInformer.Lister().PodDisruptionBudgets(pod.Namespace).List(labels.Everything())
+	if err != nil {
+		task.logger().Warn("failed to list disruption budgets", zap.Error(err))
+		return false
+	}
+	for _, pdb := range pdbs {
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		// an empty selector matches no pods
+		if err != nil || selector.Empty() {
+			continue
+		}
+		if selector.Matches(labels.Set(pod.Labels)) && pdb.Status.DisruptionsAllowed < 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// the victim keeps running for now, the core has released its allocation. The resources of the pod
+// are reported as occupied on the node until the pod terminates, the core does not hand them out twice.
+func (task *Task) reportOccupied(p *preemption) {
+	task.lock.Lock()
+	if p.occupied {
+		task.lock.Unlock()
+		return
+	}
+	p.occupied = true
+	nodeName := task.nodeName
+	resource := task.resource
+	task.lock.Unlock()
+	task.context.nodes.updateNodeOccupiedResources(nodeName, resource, AddOccupiedResource)
+}
+
+// retries the eviction refused by a disruption budget or failed with a transient error with backoff until
+// the eviction timeout, the victim keeps running when it can not be evicted within the timeout.
+func (task *Task) retryEviction(pod *v1.Pod, p *preemption) {
+	backoff := disruptionBudgetBackoff
+	deadline := p.requested.Add(task.context.apiProvider.GetAPIs().Conf.PreemptionEvictTimeout)
+	result := evictionBlocked
+	for time.Now().Before(deadline) {
+		time.Sleep(backoff.Step())
+		if task.isTerminated() {
+			return
+		}
+		if result = task.evictPod(pod); result != evictionBlocked && result != evictionUnavailable {
+			metrics.GetPreemptionMetrics().IncVictims(result)
+			task.coordinateGang(p, result)
+			return
+		}
+	}
+	if result == evictionUnavailable {
+		task.logger().Warn("preempted pod could not be evicted within the eviction timeout",
+			zap.String("podName", pod.Name),
+			zap.Duration("waited", time.Since(p.requested)))
+		events.GetRecorder().Eventf(pod, v1.EventTypeWarning, "PreemptionFailed",
+			"Task %s could not be evicted within the eviction timeout as the eviction API is not available", task.alias)
+		metrics.GetPreemptionMetrics().IncVictims(evictionFailed)
+		return
+	}
+	task.logger().Warn("disruption budget did not allow the eviction of the preempted pod",
+		zap.String("podName", pod.Name),
+		zap.Duration("waited", time.Since(p.requested)))
+	events.GetRecorder().Eventf(pod, v1.EventTypeWarning, "PreemptionBlocked",
+		"Task %s could not be evicted within the eviction timeout as a PodDisruptionBudget does not allow it", task.alias)
+	metrics.GetPreemptionMetrics().IncVictims(evictionProtected)
+}
+
+// called when the allocation of a preempted task is released, the task lock must be held
+func (task *Task) releasePreemption() {
+	if task.preemption == nil {
+		return
+	}
+	metrics.GetPreemptionMetrics().ObserveRelease(time.Since(task.preemption.requested))
+	if task.preemption.occupied {
+		task.preemption.occupied = false
+		task.context.nodes.updateNodeOccupiedResources(task.nodeName, task.resource, SubOccupiedResource)
+	}
+	task.notifyPreemptor(task.preemption)
+	// the gang lost a placeholder, it is asked for again
+	if task.placeholder && task.taskGroupName != "" {
+		go task.requestPlaceholder(task.pod.Name, task.taskGroupName)
+	}
+}
+
+// evicts the pod of a preempted task. The pod is never deleted in place of the eviction: a delete does not
+// respect the disruption budgets, the grace period nor the UID of the pod. The refusals of a disruption budget
+// and the transient errors are retried by the caller, the victim keeps running when the eviction fails.
+func (task *Task) evictPod(pod *v1.Pod) string {
+	var gracePeriodSeconds *int64
+	if gracePeriod := task.context.apiProvider.GetAPIs().Conf.PreemptionGracePeriod; gracePeriod > 0 {
+		seconds := int64(math.Ceil(gracePeriod.Seconds()))
+		gracePeriodSeconds = &seconds
+	}
+	err := task.context.apiProvider.GetAPIs().KubeClient.Evict(pod, gracePeriodSeconds)
+	if err == nil {
+		return evictionEvicted
+	}
+	if apierrors.IsNotFound(err) {
+		return evictionGone
+	}
+	if client.IsDisruptionBudgetError(err) {
+		return evictionBlocked
+	}
+	if client.IsRetryable(err) {
+		task.logger().Warn("failed to evict preempted pod, retrying",
+			zap.String("podName", pod.Name),
+			zap.Error(err))
+		return evictionUnavailable
+	}
+	task.logger().Error("failed to evict preempted pod",
+		zap.String("podName", pod.Name),
+		zap.Error(err))
+	events.GetRecorder().Eventf(pod, v1.EventTypeWarning, "PreemptionFailed",
+		"Task %s could not be evicted: %s", task.alias, err.Error())
+	return evictionFailed
+}
+
+// tells the pod the allocation was preempted for that the resources of the victim are free
+func (task *Task) notifyPreemptor(p *preemption) {
+	if p.preemptorAppID == "" {
+		return
+	}
+	preemptor, err := task.context.getTask(p.preemptorAppID, p.preemptorTaskID)
+	if err != nil {
+		task.logger().Debug("preemptor is not found",
+			zap.String("preemptorAppID", p.preemptorAppID),
+			zap.String("preemptorTaskID", p.preemptorTaskID))
+		return
+	}
+	events.GetRecorder().Eventf(preemptor.GetTaskPod(), v1.EventTypeNormal, "VictimReleased",
+		"Preempted task %s of application %s released its resources for this pod", task.alias, task.applicationID)
+}
@@ -0,0 +1,282 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"sort"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
+)
+
+// the reasons a simulated pod does not preempt
+const (
+	SimulationNotPending          = "NotPending"
+	SimulationNeverPreempts       = "NeverPreempts"
+	SimulationFitsWithoutPreempt  = "FitsWithoutPreemption"
+	SimulationInsufficientVictims = "InsufficientVictims"
+)
+
+// PreemptionVictim is an allocation the preemption of a pending pod would release
+type PreemptionVictim struct {
+	Namespace     string           `json:"namespace"`
+	Name          string           `json:"name"`
+	ApplicationID string           `json:"applicationID"`
+	Queue         string           `json:"queue"`
+	Priority      int32            `json:"priority"`
+	Resource      map[string]int64 `json:"resource"`
+	// the shim keeps the victim running, its resources are not released
+	SkipReason string `json:"skipReason,omitempty"`
+}
+
+// PreemptionSimulation is the preemption a pending pod would trigger, nothing is evicted. The core picks
+// the victims, the simulation follows the priorities of the pods and the preemption settings of the shim.
+type PreemptionSimulation struct {
+	Namespace     string           `json:"namespace"`
+	Name          string           `json:"name"`
+	ApplicationID string           `json:"applicationID"`
+	Queue         string           `json:"queue"`
+	Priority      int32            `json:"priority"`
+	Request       map[string]int64 `json:"request"`
+	// the node the pod fits on with the fewest victims
+	Node    string              `json:"node,omitempty"`
+	Victims []*PreemptionVictim `json:"victims"`
+	// why the pod does not preempt, empty when it does
+	Reason string `json:"reason,omitempty"`
+}
+
+// simulates the preemptions of pending pods one after the other, the victims of a pod and the resources
+// it is placed on are not available to the pods simulated after it
+type preemptionSimulator struct {
+	ctx       *Context
+	free      map[string]*si.Resource
+	nodeTasks map[string][]*Task
+	taken     map[*Task]bool
+	// the placeholders are released before the real workloads, as the core is asked to, the candidates
+	// all have a lower priority than the preemptor
+	preferPlaceholder bool
+}
+
+func newPreemptionSimulator(ctx *Context) *preemptionSimulator {
+	free, nodeTasks := ctx.getNodeResources()
+	return &preemptionSimulator{
+		ctx:               ctx,
+		free:              free,
+		nodeTasks:         nodeTasks,
+		taken:             make(map[*Task]bool),
+		preferPlaceholder: isPreferPlaceholder(ctx),
+	}
+}
+
+// returns the resources of the schedulable nodes that are not occupied nor allocated to a task,
+// and the allocated tasks of the nodes
+func (ctx *Context) getNodeResources() (map[string]*si.Resource, map[string][]*Task) {
+	free := make(map[string]*si.Resource)
+	nodeTasks := make(map[string][]*Task)
+	ctx.nodes.lock.RLock()
+	for name, node := range ctx.nodes.nodesMap {
+		node.lock.RLock()
+		if node.schedulable {
+			free[name] = common.Sub(node.capacity, node.occupied)
+		}
+		node.lock.RUnlock()
+	}
+	ctx.nodes.lock.RUnlock()
+	states := events.States().Task
+	for _, app := range ctx.listApplications() {
+		for _, task := range app.getAllTasks() {
+			if state := task.GetTaskState(); state != states.Allocated && state != states.Bound {
+				continue
+			}
+			task.lock.RLock()
+			nodeName := task.nodeName
+			task.lock.RUnlock()
+			if resource, ok := free[nodeName]; ok {
+				free[nodeName] = common.Sub(resource, task.getTaskResource())
+				nodeTasks[nodeName] = append(nodeTasks[nodeName], task)
+			}
+		}
+	}
+	return free, nodeTasks
+}
+
+// SimulatePreemption returns the allocations the pending pod would preempt, nil when the pod is not known by the shim
+func (ctx *Context) SimulatePreemption(namespace, name string) *PreemptionSimulation {
+	task := ctx.findPodTask(namespace, name)
+	if task == nil {
+		return nil
+	}
+	app, ok := ctx.getApplication(task.applicationID)
+	if !ok {
+		return nil
+	}
+	return newPreemptionSimulator(ctx).simulate(app, task)
+}
+
+// SimulateApplicationPreemption returns the allocations the pending pods of the application would preempt,
+// the pods are simulated in the order they were created. Nil when the application is not found.
+func (ctx *Context) SimulateApplicationPreemption(appID string) []*PreemptionSimulation {
+	app, ok := ctx.getApplication(appID)
+	if !ok {
+		return nil
+	}
+	tasks := app.getAllTasks()
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].createTime.Before(tasks[j].createTime)
+	})
+	s := newPreemptionSimulator(ctx)
+	simulations := make([]*PreemptionSimulation, 0)
+	for _, task := range tasks {
+		if isPendingTask(task) {
+			simulations = append(simulations, s.simulate(app, task))
+		}
+	}
+	return simulations
+}
+
+func isPendingTask(task *Task) bool {
+	states := events.States().Task
+	state := task.GetTaskState()
+	return state == states.New || state == states.Pending || state == states.Scheduling
+}
+
+func (s *preemptionSimulator) simulate(app *Application, task *Task) *PreemptionSimulation {
+	pod := task.GetTaskPod()
+	request := task.getTaskResource()
+	simulation := &PreemptionSimulation{
+		Namespace:     pod.Namespace,
+		Name:          pod.Name,
+		ApplicationID: task.applicationID,
+		Queue:         app.GetQueue(),
+		Priority:      task.getPriority(),
+		Request:       make(map[string]int64),
+		Victims:       make([]*PreemptionVictim, 0),
+	}
+	addResource(simulation.Request, request)
+	switch {
+	case !isPendingTask(task):
+		simulation.Reason = SimulationNotPending
+		return simulation
+	case !common.CanPreempt(pod):
+		simulation.Reason = SimulationNeverPreempts
+		return simulation
+	}
+
+	nodes := make([]string, 0, len(s.free))
+	for name := range s.free {
+		nodes = append(nodes, name)
+	}
+	sort.Strings(nodes)
+	for _, name := range nodes {
+		if common.FitIn(s.free[name], request) {
+			simulation.Node = name
+			simulation.Reason = SimulationFitsWithoutPreempt
+			s.free[name] = common.Sub(s.free[name], request)
+			return simulation
+		}
+	}
+
+	var best []*Task
+	var bestVictims []*PreemptionVictim
+	var bestFreed *si.Resource
+	for _, name := range nodes {
+		released, victims, freed := s.simulateNode(name, task, simulation.Priority, request)
+		if released != nil && (best == nil || len(released) < len(best)) {
+			simulation.Node = name
+			best, bestVictims, bestFreed = released, victims, freed
+		}
+	}
+	if best == nil {
+		simulation.Reason = SimulationInsufficientVictims
+		return simulation
+	}
+	for _, victim := range best {
+		s.taken[victim] = true
+	}
+	simulation.Victims = bestVictims
+	s.free[simulation.Node] = common.Sub(common.Add(s.free[simulation.Node], bestFreed), request)
+	return simulation
+}
+
+// walks the allocations on the node with a lower priority than the pod, the lowest priority first, until
+// enough resources are released. Returns the released tasks, nil when the node can not free enough resources.
+func (s *preemptionSimulator) simulateNode(name string, preemptor *Task, priority int32,
+	request *si.Resource) ([]*Task, []*PreemptionVictim, *si.Resource) {
+	candidates := make([]*Task, 0)
+	for _, task := range s.nodeTasks[name] {
+		task.lock.RLock()
+		preempted := task.preemption != nil
+		task.lock.RUnlock()
+		if !s.taken[task] && !preempted && task.getPriority() < priority {
+			candidates = append(candidates, task)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if s.preferPlaceholder && candidates[i].IsPlaceholder() != candidates[j].IsPlaceholder() {
+			return candidates[i].IsPlaceholder()
+		}
+		return candidates[i].getPriority() < candidates[j].getPriority()
+	})
+
+	released := make([]*Task, 0)
+	victims := make([]*PreemptionVictim, 0)
+	freed := &si.Resource{}
+	for _, candidate := range candidates {
+		if common.FitIn(common.Add(s.free[name], freed), request) {
+			break
+		}
+		p := &preemption{preemptorAppID: preemptor.applicationID}
+		if app, ok := s.ctx.getApplication(candidate.applicationID); ok {
+			p.queue = app.GetQueue()
+			p.intraAppAllowed = app.GetTags()[constants.AppTagIntraAppPreemption] == "true"
+		}
+		// a dry-run still evicts in the simulation, the simulation shows what enforcing it does
+		if reason, result := candidate.skipEviction(p, candidate.GetTaskPod()); reason != "" && result != evictionDryRun {
+			victim := s.newVictim(candidate)
+			victim.SkipReason = reason
+			victims = append(victims, victim)
+			continue
+		}
+		victims = append(victims, s.newVictim(candidate))
+		released = append(released, candidate)
+		freed = common.Add(freed, candidate.getTaskResource())
+	}
+	if !common.FitIn(common.Add(s.free[name], freed), request) {
+		return nil, nil, nil
+	}
+	return released, victims, freed
+}
+
+func (s *preemptionSimulator) newVictim(task *Task) *PreemptionVictim {
+	pod := task.GetTaskPod()
+	victim := &PreemptionVictim{
+		Namespace:     pod.Namespace,
+		Name:          pod.Name,
+		ApplicationID: task.applicationID,
+		Priority:      task.getPriority(),
+		Resource:      make(map[string]int64),
+	}
+	if app, ok := s.ctx.getApplication(task.applicationID); ok {
+		victim.Queue = app.GetQueue()
+	}
+	addResource(victim.Resource, task.getTaskResource())
+	return victim
+}
@@ -0,0 +1,131 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+)
+
+func addSimulationNode(context *Context, cpu int64) {
+	context.nodes.nodesMap["node-0001"] = newSchedulerNode("node-0001", "uid-0001", "",
+		common.NewResourceBuilder().AddResource(constants.CPU, cpu).Build(), nil, true)
+}
+
+// adds a pending task with the given priority and cpu to the application
+func addPendingTask(context *Context, appID, taskID string, priority int32, cpu int64) *Task {
+	context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: appID,
+			QueueName:     "root.b",
+			User:          "test-user",
+		},
+	})
+	pod := newPodHelper("pod-"+taskID, "default", taskID, "", v1.PodPending)
+	pod.Spec.Priority = &priority
+	task := context.AddTask(&interfaces.AddTaskRequest{
+		Metadata: interfaces.TaskMetadata{
+			ApplicationID: appID,
+			TaskID:        taskID,
+			Pod:           pod,
+		},
+	}).(*Task)
+	task.resource = common.NewResourceBuilder().AddResource(constants.CPU, cpu).Build()
+	task.sm.SetState(events.States().Task.Pending)
+	return task
+}
+
+func TestSimulatePreemption(t *testing.T) {
+	events.SetRecorderForTest(record.NewFakeRecorder(1024))
+	context := initContextForTest()
+	evicted := 0
+	apiProvider := context.apiProvider.(*client.MockedAPIProvider)
+	apiProvider.MockEvictFn(func(pod *v1.Pod, gracePeriodSeconds *int64) error {
+		evicted++
+		return nil
+	})
+	addSimulationNode(context, 4000)
+	low := addPreemptionCandidate(context, "app-0001", false, 10, 1500)
+	lowest := addPreemptionCandidate(context, "app-0002", false, 5, 1500)
+	addPreemptionCandidate(context, "app-0003", false, 500, 1000)
+	addPendingTask(context, "app-0004", "task-0001", 100, 2500)
+
+	assert.Assert(t, context.SimulatePreemption("default", "pod-unknown") == nil)
+	simulation := context.SimulatePreemption("default", "pod-task-0001")
+	assert.Equal(t, simulation.Reason, "")
+	assert.Equal(t, simulation.Node, "node-0001")
+	assert.Equal(t, simulation.Queue, "root.b")
+	assert.Equal(t, simulation.Request[constants.CPU], int64(2500))
+	assert.Equal(t, len(simulation.Victims), 2)
+	assert.Equal(t, simulation.Victims[0].Name, "pod-app-0002")
+	assert.Equal(t, simulation.Victims[1].Name, "pod-app-0001")
+	assert.Equal(t, simulation.Victims[1].Resource[constants.CPU], int64(1500))
+	// nothing is evicted
+	assert.Equal(t, evicted, 0)
+	assert.Assert(t, low.preemption == nil && lowest.preemption == nil)
+
+	// the shim does not evict a critical pod, the lower priority pods do not free enough resources
+	lowest.pod.Spec.PriorityClassName = constants.SystemNodeCritical
+	simulation = context.SimulatePreemption("default", "pod-task-0001")
+	assert.Equal(t, simulation.Reason, SimulationInsufficientVictims)
+	assert.Equal(t, simulation.Node, "")
+	assert.Equal(t, len(simulation.Victims), 0)
+
+	never := v1.PreemptNever
+	preemptor := addPendingTask(context, "app-0004", "task-0002", 100, 100)
+	preemptor.pod.Spec.PreemptionPolicy = &never
+	simulation = context.SimulatePreemption("default", "pod-task-0002")
+	assert.Equal(t, simulation.Reason, SimulationNeverPreempts)
+
+	simulation = context.SimulatePreemption("default", "pod-app-0003")
+	assert.Equal(t, simulation.Reason, SimulationNotPending)
+}
+
+func TestSimulateApplicationPreemption(t *testing.T) {
+	events.SetRecorderForTest(record.NewFakeRecorder(1024))
+	context := initContextForTest()
+	addSimulationNode(context, 3000)
+	addPreemptionCandidate(context, "app-0001", false, 10, 1000)
+	addPreemptionCandidate(context, "app-0002", false, 20, 1000)
+	first := addPendingTask(context, "app-0003", "task-0001", 100, 1000)
+	second := addPendingTask(context, "app-0003", "task-0002", 100, 1000)
+	second.createTime = first.createTime.Add(1)
+	third := addPendingTask(context, "app-0003", "task-0003", 100, 2000)
+	third.createTime = first.createTime.Add(2)
+
+	assert.Assert(t, context.SimulateApplicationPreemption("app-unknown") == nil)
+	simulations := context.SimulateApplicationPreemption("app-0003")
+	assert.Equal(t, len(simulations), 3)
+	assert.Equal(t, simulations[0].Reason, SimulationFitsWithoutPreempt)
+	assert.Equal(t, simulations[0].Node, "node-0001")
+	// the free resources are taken by the first pod
+	assert.Equal(t, len(simulations[1].Victims), 1)
+	assert.Equal(t, simulations[1].Victims[0].Name, "pod-app-0001")
+	// the victim of the second pod is not released twice
+	assert.Equal(t, simulations[2].Reason, SimulationInsufficientVictims)
+}
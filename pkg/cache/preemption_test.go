@@ -0,0 +1,455 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	ctx "context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+)
+
+func TestNewPreemption(t *testing.T) {
+	p := newPreemption("preempted by task-0002 of application app-0002", time.Now())
+	assert.Equal(t, p.preemptorTaskID, "task-0002")
+	assert.Equal(t, p.preemptorAppID, "app-0002")
+
+	p = newPreemption("allocation preempted", time.Now())
+	assert.Equal(t, p.preemptorTaskID, "")
+	assert.Equal(t, p.preemptorAppID, "")
+	assert.Equal(t, p.message, "allocation preempted")
+}
+
+func TestPreemptTask(t *testing.T) {
+	recorder := record.NewFakeRecorder(1024)
+	events.SetRecorderForTest(recorder)
+	context := initContextForTest()
+	evicted := make([]string, 0)
+	deleted := make([]string, 0)
+	apiProvider := context.apiProvider.(*client.MockedAPIProvider)
+	apiProvider.MockEvictFn(func(pod *v1.Pod, gracePeriodSeconds *int64) error {
+		evicted = append(evicted, pod.Name)
+		return nil
+	})
+	apiProvider.MockDeleteFn(func(pod *v1.Pod) error {
+		deleted = append(deleted, pod.Name)
+		return nil
+	})
+	for _, appID := range []string{"app-0001", "app-0002"} {
+		context.AddApplication(&interfaces.AddApplicationRequest{
+			Metadata: interfaces.ApplicationMetadata{
+				ApplicationID: appID,
+				QueueName:     "root.a",
+				User:          "test-user",
+			},
+		})
+		context.AddTask(&interfaces.AddTaskRequest{
+			Metadata: interfaces.TaskMetadata{
+				ApplicationID: appID,
+				TaskID:        "task-" + appID,
+				Pod:           newPodHelper("pod-"+appID, "default", "task-"+appID, "", v1.PodPending),
+			},
+		})
+	}
+	app, ok := context.GetApplication("app-0001").(*Application)
+	assert.Assert(t, ok)
+	app.SetState(events.States().Application.Running)
+	victim, err := context.getTask("app-0001", "task-app-0001")
+	assert.NilError(t, err)
+	victim.allocationUUID = "uuid-0001"
+	victim.sm.SetState(events.States().Task.Bound)
+	pods := context.apiProvider.GetAPIs().KubeClient.GetClientSet().CoreV1().Pods("default")
+	_, err = pods.Create(ctx.Background(), victim.GetTaskPod(), apis.CreateOptions{})
+	assert.NilError(t, err)
+
+	err = app.handle(NewPreemptAppAllocationEvent("app-0001", "uuid-0001",
+		"preempted by task-app-0002 of application app-0002"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, evicted, []string{"pod-app-0001"})
+	assert.Equal(t, len(deleted), 0)
+	assert.Equal(t, victim.terminationType, preemptedTerminationType)
+	assert.Assert(t, victim.preemption != nil)
+	recorded := ""
+	for len(recorder.Events) > 0 {
+		recorded += <-recorder.Events + "\n"
+	}
+	assert.Assert(t, strings.Contains(recorded, "Preempted Task default/pod-app-0001 is preempted by task task-app-0002 "+
+		"of application app-0002 in queue root.a"), recorded)
+	annotated, err := pods.Get(ctx.Background(), "pod-app-0001", apis.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, annotated.Annotations[constants.AnnotationPreemptedByApplication], "app-0002")
+	assert.Equal(t, annotated.Annotations[constants.AnnotationPreemptedByQueue], "root.a")
+
+	// the preemptor is told once the resources of the victim are released
+	victim.lock.Lock()
+	victim.releasePreemption()
+	victim.lock.Unlock()
+	assert.Assert(t, strings.Contains(<-recorder.Events, "VictimReleased Preempted task default/pod-app-0001"))
+
+	// the same release is received again, the pod is not evicted twice
+	victim.preempt("root.a", false, "preempted again")
+	assert.Equal(t, len(evicted), 1)
+}
+
+func TestPreemptTaskEvictionFailure(t *testing.T) {
+	events.SetRecorderForTest(record.NewFakeRecorder(1024))
+	context := initContextForTest()
+	deleted := make([]string, 0)
+	apiProvider := context.apiProvider.(*client.MockedAPIProvider)
+	apiProvider.MockEvictFn(func(pod *v1.Pod, gracePeriodSeconds *int64) error {
+		return fmt.Errorf("eviction is not supported")
+	})
+	apiProvider.MockDeleteFn(func(pod *v1.Pod) error {
+		deleted = append(deleted, pod.Name)
+		return nil
+	})
+	context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: "app-0001",
+			QueueName:     "root.a",
+			User:          "test-user",
+		},
+	})
+	task := context.AddTask(&interfaces.AddTaskRequest{
+		Metadata: interfaces.TaskMetadata{
+			ApplicationID: "app-0001",
+			TaskID:        "task-0001",
+			Pod:           newPodHelper("pod-0001", "default", "task-0001", "", v1.PodRunning),
+		},
+	}).(*Task)
+
+	// the pod is never deleted in place of the eviction
+	assert.Equal(t, task.evictPod(task.GetTaskPod()), evictionFailed)
+	apiProvider.MockEvictFn(func(pod *v1.Pod, gracePeriodSeconds *int64) error {
+		return apierrors.NewServiceUnavailable("api-server is not available")
+	})
+	assert.Equal(t, task.evictPod(task.GetTaskPod()), evictionUnavailable)
+	assert.Equal(t, len(deleted), 0)
+}
+
+func TestPreemptTaskEvictionFailureKeepsVictim(t *testing.T) {
+	events.SetRecorderForTest(record.NewFakeRecorder(1024))
+	context := initContextForTest()
+	deleted := 0
+	apiProvider := context.apiProvider.(*client.MockedAPIProvider)
+	apiProvider.MockEvictFn(func(pod *v1.Pod, gracePeriodSeconds *int64) error {
+		return apierrors.NewForbidden(policy.Resource("evictions"), pod.Name, fmt.Errorf("not allowed"))
+	})
+	apiProvider.MockDeleteFn(func(pod *v1.Pod) error {
+		deleted++
+		return nil
+	})
+	victim := addPreemptionVictim(context, "app-0001")
+	victim.preempt("root.a", false, "allocation preempted")
+	assert.Equal(t, deleted, 0)
+	// the victim keeps running, its resources are reported to the core as occupied
+	assert.Assert(t, victim.preemption.occupied)
+}
+
+// adds an application with one bound task to the context
+func addPreemptionVictim(context *Context, appID string) *Task {
+	context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: appID,
+			QueueName:     "root.a",
+			User:          "test-user",
+		},
+	})
+	pod := newPodHelper("pod-"+appID, "default", "task-"+appID, "node-0001", v1.PodRunning)
+	pod.Labels = map[string]string{"app": "web"}
+	task := context.AddTask(&interfaces.AddTaskRequest{
+		Metadata: interfaces.TaskMetadata{
+			ApplicationID: appID,
+			TaskID:        "task-" + appID,
+			Pod:           pod,
+		},
+	}).(*Task)
+	task.allocationUUID = "uuid-" + appID
+	task.sm.SetState(events.States().Task.Bound)
+	return task
+}
+
+func TestPreemptTaskProtectedByDisruptionBudget(t *testing.T) {
+	events.SetRecorderForTest(record.NewFakeRecorder(1024))
+	context := initContextForTest()
+	evicted := 0
+	apiProvider := context.apiProvider.(*client.MockedAPIProvider)
+	apiProvider.MockEvictFn(func(pod *v1.Pod, gracePeriodSeconds *int64) error {
+		evicted++
+		return nil
+	})
+	pdbInformer := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0).Policy().V1beta1().PodDisruptionBudgets()
+	err := pdbInformer.Informer().GetIndexer().Add(&policy.PodDisruptionBudget{
+		ObjectMeta: apis.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: policy.PodDisruptionBudgetSpec{
+			Selector: &apis.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+		Status: policy.PodDisruptionBudgetStatus{DisruptionsAllowed: 0},
+	})
+	assert.NilError(t, err)
+	context.apiProvider.GetAPIs().PDBInformer = pdbInformer
+	victim := addPreemptionVictim(context, "app-0001")
+
+	// the budget is only checked when the protected victims are skipped
+	context.apiProvider.GetAPIs().Conf.PreemptionPDBPolicy = conf.PDBPolicyEvict
+	assert.Assert(t, !victim.protectedByDisruptionBudget(victim.GetTaskPod()))
+
+	context.apiProvider.GetAPIs().Conf.PreemptionPDBPolicy = conf.PDBPolicySkip
+	victim.preempt("root.a", false, "allocation preempted")
+	assert.Equal(t, evicted, 0)
+	assert.Assert(t, victim.preemption.occupied)
+
+	// the other pods in the namespace are not protected
+	other := addPreemptionVictim(context, "app-0002")
+	other.pod.Labels = map[string]string{"app": "batch"}
+	other.preempt("root.a", false, "allocation preempted")
+	assert.Equal(t, evicted, 1)
+	assert.Assert(t, !other.preemption.occupied)
+}
+
+func TestPreemptTaskDisruptionBudgetEvictionFailure(t *testing.T) {
+	events.SetRecorderForTest(record.NewFakeRecorder(1024))
+	defer func(backoff wait.Backoff) {
+		disruptionBudgetBackoff = backoff
+	}(disruptionBudgetBackoff)
+	disruptionBudgetBackoff = wait.Backoff{Duration: time.Millisecond, Factor: 1.0, Steps: 1000}
+	context := initContextForTest()
+	context.apiProvider.GetAPIs().Conf.PreemptionEvictTimeout = 10 * time.Second
+	var lock sync.Mutex
+	attempts := 0
+	deleted := 0
+	apiProvider := context.apiProvider.(*client.MockedAPIProvider)
+	// the eviction API fails with an error that is not the refusal of the disruption budget
+	apiProvider.MockEvictFn(func(pod *v1.Pod, gracePeriodSeconds *int64) error {
+		lock.Lock()
+		defer lock.Unlock()
+		attempts++
+		return apierrors.NewInternalError(fmt.Errorf("etcd is not available"))
+	})
+	apiProvider.MockDeleteFn(func(pod *v1.Pod) error {
+		lock.Lock()
+		defer lock.Unlock()
+		deleted++
+		return nil
+	})
+	pdbInformer := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0).Policy().V1beta1().PodDisruptionBudgets()
+	err := pdbInformer.Informer().GetIndexer().Add(&policy.PodDisruptionBudget{
+		ObjectMeta: apis.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: policy.PodDisruptionBudgetSpec{
+			Selector: &apis.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+		Status: policy.PodDisruptionBudgetStatus{DisruptionsAllowed: 0},
+	})
+	assert.NilError(t, err)
+	context.apiProvider.GetAPIs().PDBInformer = pdbInformer
+	// the budget is left to the eviction API
+	context.apiProvider.GetAPIs().Conf.PreemptionPDBPolicy = conf.PDBPolicyEvict
+
+	victim := addPreemptionVictim(context, "app-0001")
+	victim.preempt("root.a", false, "allocation preempted")
+	err = utils.WaitForCondition(func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return attempts >= 3
+	}, 5*time.Millisecond, 5*time.Second)
+	assert.NilError(t, err)
+	lock.Lock()
+	assert.Equal(t, deleted, 0, "the pod covered by the disruption budget must not be deleted")
+	lock.Unlock()
+	victim.lock.Lock()
+	assert.Assert(t, victim.preemption.occupied)
+	victim.lock.Unlock()
+	// stop the retries
+	victim.sm.SetState(events.States().Task.Completed)
+}
+
+func TestPreemptTaskEvictionRetry(t *testing.T) {
+	events.SetRecorderForTest(record.NewFakeRecorder(1024))
+	defer func(backoff wait.Backoff) {
+		disruptionBudgetBackoff = backoff
+	}(disruptionBudgetBackoff)
+	disruptionBudgetBackoff = wait.Backoff{Duration: time.Millisecond, Factor: 1.0, Steps: 1000}
+	context := initContextForTest()
+	context.apiProvider.GetAPIs().Conf.PreemptionEvictTimeout = 10 * time.Second
+	var lock sync.Mutex
+	attempts := 0
+	apiProvider := context.apiProvider.(*client.MockedAPIProvider)
+	apiProvider.MockEvictFn(func(pod *v1.Pod, gracePeriodSeconds *int64) error {
+		lock.Lock()
+		defer lock.Unlock()
+		attempts++
+		if attempts < 3 {
+			err := apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+			err.ErrStatus.Details.Causes = append(err.ErrStatus.Details.Causes, apis.StatusCause{Type: "DisruptionBudget"})
+			return err
+		}
+		return nil
+	})
+	victim := addPreemptionVictim(context, "app-0001")
+	victim.preempt("root.a", false, "allocation preempted")
+	err := utils.WaitForCondition(func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return attempts == 3
+	}, 5*time.Millisecond, 5*time.Second)
+	assert.NilError(t, err)
+
+	// the pod keeps its resources on the node until the allocation is released
+	victim.lock.Lock()
+	assert.Assert(t, victim.preemption.occupied)
+	victim.releasePreemption()
+	assert.Assert(t, !victim.preemption.occupied)
+	victim.lock.Unlock()
+}
+
+func TestPreemptCriticalTask(t *testing.T) {
+	events.SetRecorderForTest(record.NewFakeRecorder(1024))
+	context := initContextForTest()
+	evicted := 0
+	apiProvider := context.apiProvider.(*client.MockedAPIProvider)
+	apiProvider.MockEvictFn(func(pod *v1.Pod, gracePeriodSeconds *int64) error {
+		evicted++
+		return nil
+	})
+	victim := addPreemptionVictim(context, "app-0001")
+	victim.pod.Spec.PriorityClassName = "system-node-critical"
+	victim.preempt("root.a", false, "allocation preempted")
+	assert.Equal(t, evicted, 0)
+	assert.Assert(t, victim.preemption.occupied)
+}
+
+func TestPreemptTaskSkipped(t *testing.T) {
+	events.SetRecorderForTest(record.NewFakeRecorder(1024))
+	context := initContextForTest()
+	var gracePeriods []int64
+	apiProvider := context.apiProvider.(*client.MockedAPIProvider)
+	apiProvider.MockEvictFn(func(pod *v1.Pod, gracePeriodSeconds *int64) error {
+		if gracePeriodSeconds != nil {
+			gracePeriods = append(gracePeriods, *gracePeriodSeconds)
+		}
+		return nil
+	})
+	schedulerConf := context.apiProvider.GetAPIs().Conf
+	schedulerConf.PreemptionGracePeriod = 1500 * time.Millisecond
+
+	// the queue of the victim does not allow preemption
+	schedulerConf.PreemptionQueues = "root.batch"
+	victim := addPreemptionVictim(context, "app-0001")
+	victim.preempt("root.a", false, "allocation preempted")
+	assert.Equal(t, len(gracePeriods), 0)
+	assert.Assert(t, victim.preemption.occupied)
+
+	// dry-run only logs the victim
+	schedulerConf.PreemptionDryRun = true
+	victim = addPreemptionVictim(context, "app-0002")
+	victim.preempt("root.batch", false, "allocation preempted")
+	assert.Equal(t, len(gracePeriods), 0)
+	assert.Assert(t, victim.preemption.occupied)
+
+	schedulerConf.PreemptionDryRun = false
+	victim = addPreemptionVictim(context, "app-0003")
+	victim.preempt("root.batch.nightly", false, "allocation preempted")
+	assert.DeepEqual(t, gracePeriods, []int64{2})
+	assert.Assert(t, !victim.preemption.occupied)
+}
+
+func TestPreemptTaskGracePeriodRetry(t *testing.T) {
+	events.SetRecorderForTest(record.NewFakeRecorder(1024))
+	defer func(backoff wait.Backoff) {
+		disruptionBudgetBackoff = backoff
+	}(disruptionBudgetBackoff)
+	disruptionBudgetBackoff = wait.Backoff{Duration: time.Millisecond, Factor: 1.0, Steps: 1000}
+	context := initContextForTest()
+	schedulerConf := context.apiProvider.GetAPIs().Conf
+	schedulerConf.PreemptionGracePeriod = 1500 * time.Millisecond
+	schedulerConf.PreemptionEvictTimeout = 10 * time.Second
+	var lock sync.Mutex
+	var gracePeriods []int64
+	deleted := 0
+	apiProvider := context.apiProvider.(*client.MockedAPIProvider)
+	apiProvider.MockEvictFn(func(pod *v1.Pod, gracePeriodSeconds *int64) error {
+		lock.Lock()
+		defer lock.Unlock()
+		gracePeriods = append(gracePeriods, *gracePeriodSeconds)
+		if len(gracePeriods) == 1 {
+			return apierrors.NewServiceUnavailable("api-server is not available")
+		}
+		return nil
+	})
+	apiProvider.MockDeleteFn(func(pod *v1.Pod) error {
+		lock.Lock()
+		defer lock.Unlock()
+		deleted++
+		return nil
+	})
+
+	// the retried eviction keeps the configured grace period, the pod is never deleted
+	victim := addPreemptionVictim(context, "app-0001")
+	victim.preempt("root.a", false, "allocation preempted")
+	err := utils.WaitForCondition(func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(gracePeriods) == 2
+	}, 5*time.Millisecond, 5*time.Second)
+	assert.NilError(t, err)
+	lock.Lock()
+	defer lock.Unlock()
+	assert.DeepEqual(t, gracePeriods, []int64{2, 2})
+	assert.Equal(t, deleted, 0)
+}
+
+func TestPreemptTaskOfSameApplication(t *testing.T) {
+	events.SetRecorderForTest(record.NewFakeRecorder(1024))
+	context := initContextForTest()
+	evicted := 0
+	apiProvider := context.apiProvider.(*client.MockedAPIProvider)
+	apiProvider.MockEvictFn(func(pod *v1.Pod, gracePeriodSeconds *int64) error {
+		evicted++
+		return nil
+	})
+	victim := addPreemptionVictim(context, "app-0001")
+	victim.preempt("root.a", false, "preempted by task-0002 of application app-0001")
+	assert.Equal(t, evicted, 0)
+	assert.Assert(t, victim.preemption.occupied)
+	assert.Equal(t, victim.preemption.preemptorQueue, "root.a")
+
+	// the application opted in to the intra-application preemption
+	victim = addPreemptionVictim(context, "app-0002")
+	victim.preempt("root.a", true, "preempted by task-0003 of application app-0002")
+	assert.Equal(t, evicted, 1)
+}
@@ -0,0 +1,201 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/dispatcher"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// the interval the ProvisioningRequest of a gang is checked at
+var provisioningPollInterval = 10 * time.Second
+
+// returns the name of the ProvisioningRequest of the application, the placeholders limit the application ID
+// to 28 characters to keep their names valid, the request allows a few more.
+func provisioningRequestName(appID string) string {
+	return fmt.Sprintf("yunikorn-%.40s", appID)
+}
+
+// a placeholder of the application cannot be scheduled. With a provisioning class set, the capacity for all
+// the placeholders of a hard gang that are still pending is requested from the cluster-autoscaler at once.
+// A gang gets at most one request: it asks for the whole gang and is kept until the gang is reserved.
+func (ctx *Context) requestProvisioning(task *Task) {
+	apis := ctx.apiProvider.GetAPIs()
+	class := apis.Conf.ProvisioningClass
+	if class == "" || apis.DynamicClient == nil || !task.IsPlaceholder() {
+		return
+	}
+	app, ok := ctx.getApplication(task.applicationID)
+	if !ok || app.getSchedulingStyle() != hardGangStyle || !apis.Conf.IsScaleUpEnabled(app.GetQueue()) ||
+		app.GetApplicationState() != events.States().Application.Reserving {
+		return
+	}
+	app.lock.Lock()
+	if app.provisioningRequest != "" {
+		app.lock.Unlock()
+		return
+	}
+	name := provisioningRequestName(app.applicationID)
+	app.provisioningRequest = name
+	app.lock.Unlock()
+	go ctx.provisionGang(app, name, class)
+}
+
+// returns the pending placeholders of the application by task group
+func getPendingPlaceholders(app *Application) map[string][]*Task {
+	states := events.States().Task
+	pending := make(map[string][]*Task)
+	for _, task := range app.getAllTasks() {
+		if !task.IsPlaceholder() {
+			continue
+		}
+		switch task.GetTaskState() {
+		case states.New, states.Pending, states.Scheduling:
+			group := task.getTaskGroupName()
+			pending[group] = append(pending[group], task)
+		}
+	}
+	return pending
+}
+
+// creates the ProvisioningRequest for the pending placeholders of the gang, the placeholders reference it and
+// are held until the capacity arrives: the autoscaler adds the nodes for the request only, not for each pod.
+// The placeholder timeout of the gang must leave the autoscaler the time to add the nodes.
+func (ctx *Context) provisionGang(app *Application, name, class string) {
+	apis := ctx.apiProvider.GetAPIs()
+	namespace := app.GetTags()[constants.AppTagNamespace]
+	app.lock.RLock()
+	owners := app.placeholderOwnerReferences
+	app.lock.RUnlock()
+
+	pending := getPendingPlaceholders(app)
+	podSets := make([]*client.ProvisioningPodSet, 0, len(pending))
+	templates := make([]string, 0, len(pending))
+	placeholders := make([]*Task, 0)
+	for _, taskGroup := range app.getTaskGroups() {
+		tasks := pending[taskGroup.Name]
+		if len(tasks) == 0 {
+			continue
+		}
+		pod := tasks[0].GetTaskPod()
+		template := &v1.PodTemplate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            fmt.Sprintf("%s-%.20s", name, taskGroup.Name),
+				Namespace:       namespace,
+				Labels:          map[string]string{constants.LabelApplicationID: app.applicationID},
+				OwnerReferences: owners,
+			},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      pod.Labels,
+					Annotations: pod.Annotations,
+				},
+				Spec: pod.Spec,
+			},
+		}
+		podSets = append(podSets, &client.ProvisioningPodSet{Template: template, Count: int32(len(tasks))})
+		templates = append(templates, template.Name)
+		placeholders = append(placeholders, tasks...)
+	}
+	if len(podSets) == 0 {
+		return
+	}
+
+	err := client.CreateProvisioningRequest(apis.KubeClient.GetClientSet(), apis.DynamicClient,
+		namespace, name, class, podSets, owners)
+	if err != nil {
+		app.logger().Warn("failed to request the capacity of the gang", zap.Error(err))
+		return
+	}
+	app.logger().Info("requested the capacity of the gang",
+		zap.String("provisioningRequest", name),
+		zap.String("class", class),
+		zap.Int("placeholders", len(placeholders)))
+	annotations := map[string]string{
+		constants.AnnotationConsumeProvisioningRequest: name,
+		constants.AnnotationProvisioningClassName:      class,
+	}
+	for _, task := range placeholders {
+		pod := task.GetTaskPod()
+		if _, err = client.ApplyPodAnnotations(apis.KubeClient.GetClientSet(), pod, annotations); err != nil {
+			task.logger().Warn("failed to reference the provisioning request from the placeholder",
+				zap.String("podName", pod.Name),
+				zap.Error(err))
+		}
+		events.GetRecorder().Eventf(pod, v1.EventTypeNormal, "ProvisioningRequested",
+			"Capacity for %d placeholders of application %s is requested with ProvisioningRequest %s",
+			len(placeholders), app.applicationID, name)
+	}
+
+	ctx.waitForProvisioning(app, namespace, name)
+	if err = client.DeleteProvisioningRequest(apis.KubeClient.GetClientSet(), apis.DynamicClient,
+		namespace, name, templates); err != nil {
+		app.logger().Warn("failed to delete the provisioning request of the gang", zap.Error(err))
+	}
+}
+
+// waits until the gang no longer reserves its resources. A hard gang cannot run without the capacity:
+// when the autoscaler gives up the application fails, it does not wait for the placeholder timeout.
+func (ctx *Context) waitForProvisioning(app *Application, namespace, name string) {
+	apis := ctx.apiProvider.GetAPIs()
+	ticker := time.NewTicker(provisioningPollInterval)
+	defer ticker.Stop()
+	provisioned := false
+	for range ticker.C {
+		if current, ok := ctx.getApplication(app.applicationID); !ok || current != app ||
+			app.GetApplicationState() != events.States().Application.Reserving {
+			return
+		}
+		if provisioned {
+			continue
+		}
+		condition, message, err := client.GetProvisioningRequestCondition(apis.DynamicClient, namespace, name)
+		if err != nil {
+			log.Log(log.Cache).Debug("failed to get the provisioning request",
+				zap.String("appID", app.applicationID),
+				zap.String("provisioningRequest", name),
+				zap.Error(err))
+			continue
+		}
+		switch condition {
+		case client.ProvisioningProvisioned:
+			provisioned = true
+			app.logger().Info("the capacity of the gang is provisioned",
+				zap.String("provisioningRequest", name),
+				zap.String("message", message))
+		case client.ProvisioningFailed:
+			app.logger().Info("the capacity of the gang cannot be provisioned",
+				zap.String("provisioningRequest", name),
+				zap.String("message", message))
+			dispatcher.Dispatch(NewFailApplicationEvent(app.applicationID, fmt.Sprintf("%s: ProvisioningRequest %s: %s",
+				constants.ApplicationProvisioningFailure, name, message)))
+			return
+		}
+	}
+}
@@ -0,0 +1,188 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/dispatcher"
+	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
+)
+
+// adds a reserving gang application with the given style and its pending placeholders
+func addReservingGang(t *testing.T, context *Context, appID, style string, placeholders int) *Application {
+	context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: appID,
+			QueueName:     "root.a",
+			User:          "test-user",
+			Tags:          map[string]string{constants.AppTagNamespace: "default"},
+		},
+	})
+	app, _ := context.getApplication(appID)
+	app.setTaskGroups([]v1alpha1.TaskGroup{{Name: "workers", MinMember: int32(placeholders)}})
+	app.setSchedulingStyle(style)
+	app.SetState(events.States().Application.Reserving)
+	// the placeholders are stored for the apply patches of their annotations
+	tracker := context.apiProvider.GetAPIs().KubeClient.GetClientSet().(*fake.Clientset).Tracker()
+	for i := 0; i < placeholders; i++ {
+		taskID := appID + "-placeholder-" + string(rune('a'+i))
+		pod := newPodHelper("pod-"+taskID, "default", taskID, "", v1.PodPending)
+		assert.NilError(t, tracker.Add(pod))
+		task := context.AddTask(&interfaces.AddTaskRequest{
+			Metadata: interfaces.TaskMetadata{
+				ApplicationID: appID,
+				TaskID:        taskID,
+				Pod:           pod,
+				Placeholder:   true,
+				TaskGroupName: "workers",
+			},
+		}).(*Task)
+		task.sm.SetState(events.States().Task.Scheduling)
+	}
+	return app
+}
+
+func TestRequestProvisioning(t *testing.T) {
+	events.SetRecorderForTest(record.NewFakeRecorder(1024))
+	context := initContextForTest()
+	dispatcher.RegisterEventHandler(dispatcher.EventTypeApp, context.ApplicationEventHandler())
+	dispatcher.Start()
+	defer dispatcher.Stop()
+	NewPlaceholderManager(client.NewMockedAPIProvider().GetAPIs())
+	interval := provisioningPollInterval
+	provisioningPollInterval = 10 * time.Millisecond
+	defer func() { provisioningPollInterval = interval }()
+	apis := context.apiProvider.GetAPIs()
+	apis.Conf.ProvisioningClass = conf.ProvisioningClassAtomicScaleUp
+
+	// the autoscaler has not decided until the condition is set
+	var lock sync.Mutex
+	created := make([]*unstructured.Unstructured, 0)
+	deleted := make([]string, 0)
+	condition := map[string]interface{}{}
+	dynamicClient := apis.DynamicClient.(*dynamicfake.FakeDynamicClient)
+	dynamicClient.PrependReactor("create", "provisioningrequests", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		created = append(created, action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured))
+		return false, nil, nil
+	})
+	dynamicClient.PrependReactor("get", "provisioningrequests", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		request := &unstructured.Unstructured{Object: map[string]interface{}{
+			"status": map[string]interface{}{"conditions": []interface{}{condition}},
+		}}
+		return true, request, nil
+	})
+	dynamicClient.PrependReactor("delete", "provisioningrequests", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		deleted = append(deleted, action.(k8stesting.DeleteAction).GetName())
+		return false, nil, nil
+	})
+	getCounts := func() (int, int) {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(created), len(deleted)
+	}
+	failScheduling := func(appID, taskID string) {
+		context.HandleContainerStateUpdate(&si.UpdateContainerSchedulingStateRequest{
+			ApplicartionID: appID,
+			AllocationKey:  taskID,
+			State:          si.UpdateContainerSchedulingStateRequest_FAILED,
+			Reason:         "no node has enough resources",
+		})
+	}
+
+	// the capacity of a soft gang is not requested
+	soft := addReservingGang(t, context, "app-0001", "Soft", 2)
+	failScheduling("app-0001", "app-0001-placeholder-a")
+	soft.lock.RLock()
+	assert.Equal(t, soft.provisioningRequest, "")
+	soft.lock.RUnlock()
+
+	// one request is created for all the pending placeholders of a hard gang
+	app := addReservingGang(t, context, "app-0002", hardGangStyle, 2)
+	failScheduling("app-0002", "app-0002-placeholder-a")
+	failScheduling("app-0002", "app-0002-placeholder-b")
+	err := utils.WaitForCondition(func() bool {
+		requests, _ := getCounts()
+		return requests == 1
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err)
+	lock.Lock()
+	request := created[0]
+	lock.Unlock()
+	assert.Equal(t, request.GetName(), "yunikorn-app-0002")
+	assert.Equal(t, request.GetNamespace(), "default")
+	sets, _, err := unstructured.NestedSlice(request.Object, "spec", "podSets")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, sets, []interface{}{map[string]interface{}{
+		"podTemplateRef": map[string]interface{}{"name": "yunikorn-app-0002-workers"},
+		"count":          int64(2),
+	}})
+
+	// the placeholders consume the requested capacity
+	tracker := apis.KubeClient.GetClientSet().(*fake.Clientset).Tracker()
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	err = utils.WaitForCondition(func() bool {
+		for _, name := range []string{"pod-app-0002-placeholder-a", "pod-app-0002-placeholder-b"} {
+			obj, getErr := tracker.Get(pods, "default", name)
+			if getErr != nil || obj.(*v1.Pod).Annotations[constants.AnnotationConsumeProvisioningRequest] != "yunikorn-app-0002" {
+				return false
+			}
+		}
+		return true
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err)
+
+	// the gang fails once the autoscaler gives up, the request is deleted
+	lock.Lock()
+	condition = map[string]interface{}{"type": client.ProvisioningFailed, "status": "True", "message": "out of quota"}
+	lock.Unlock()
+	assertAppState(t, app, events.States().Application.Failing, 3*time.Second)
+	err = utils.WaitForCondition(func() bool {
+		_, deletes := getCounts()
+		return deletes == 1
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err)
+	requests, _ := getCounts()
+	assert.Equal(t, requests, 1)
+}
@@ -0,0 +1,65 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+)
+
+// returns the state of a task in the queue metrics, the tasks that are allocated but not yet
+// bound are still scheduling. The tasks that ended or are being killed are not counted.
+func getQueuePodState(taskState string) (string, bool) {
+	states := events.States().Task
+	switch taskState {
+	case states.New, states.Pending:
+		return metrics.QueuePodsPending, true
+	case states.Scheduling, states.Allocated:
+		return metrics.QueuePodsScheduling, true
+	case states.Bound:
+		return metrics.QueuePodsBound, true
+	case states.Rejected, states.Failed:
+		return metrics.QueuePodsFailed, true
+	default:
+		return "", false
+	}
+}
+
+// returns the number of pods by state for each queue of the applications in the context
+func (ctx *Context) getQueuePodCounts() map[string]map[string]int {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	counts := make(map[string]map[string]int)
+	for _, app := range ctx.applications {
+		app.lock.RLock()
+		queueCounts, ok := counts[app.queue]
+		if !ok {
+			queueCounts = make(map[string]int)
+			counts[app.queue] = queueCounts
+		}
+		for _, task := range app.getAllTasks() {
+			// the state of the task is read without the task lock
+			if state, ok := getQueuePodState(task.GetTaskState()); ok {
+				queueCounts[state]++
+			}
+		}
+		app.lock.RUnlock()
+	}
+	return counts
+}
@@ -0,0 +1,71 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"testing"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+)
+
+func TestGetQueuePodCounts(t *testing.T) {
+	context := initContextForTest()
+	states := events.States().Task
+	addTasks := func(appID, queue string, taskStates ...string) {
+		context.AddApplication(&interfaces.AddApplicationRequest{
+			Metadata: interfaces.ApplicationMetadata{
+				ApplicationID: appID,
+				QueueName:     queue,
+				User:          "test-user",
+			},
+		})
+		for i, state := range taskStates {
+			task := context.AddTask(&interfaces.AddTaskRequest{
+				Metadata: interfaces.TaskMetadata{
+					ApplicationID: appID,
+					TaskID:        fmt.Sprintf("%s-task-%d", appID, i),
+					Pod:           &v1.Pod{},
+				},
+			})
+			assert.Assert(t, task != nil)
+			task.(*Task).sm.SetState(state)
+		}
+	}
+	addTasks("app01", "root.a", states.New, states.Pending, states.Scheduling, states.Allocated, states.Bound)
+	addTasks("app02", "root.a", states.Bound, states.Failed, states.Completed, states.Killing)
+	addTasks("app03", "root.b", states.Rejected)
+	addTasks("app04", "root.c")
+
+	assert.DeepEqual(t, context.getQueuePodCounts(), map[string]map[string]int{
+		"root.a": {
+			metrics.QueuePodsPending:    2,
+			metrics.QueuePodsScheduling: 2,
+			metrics.QueuePodsBound:      2,
+			metrics.QueuePodsFailed:     1,
+		},
+		"root.b": {metrics.QueuePodsFailed: 1},
+		"root.c": {},
+	})
+}
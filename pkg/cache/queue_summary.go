@@ -0,0 +1,104 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"sort"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
+)
+
+// QueueSummary is a queue as seen by the shim: the applications the shim submitted to it
+// and the resources of their pods. The queues configured in the core without applications are not listed.
+type QueueSummary struct {
+	Queue     string `json:"queue"`
+	Partition string `json:"partition"`
+	// the applications ordered by ID
+	Applications      []string       `json:"applications"`
+	ApplicationStates map[string]int `json:"applicationStates"`
+	// the number of pods in each state of the queue metrics
+	Pods map[string]int `json:"pods"`
+	// the resources of the pods that wait for an allocation
+	Requested map[string]int64 `json:"requested"`
+	// the resources of the pods allocated by the core, bound or not
+	Allocated map[string]int64 `json:"allocated"`
+}
+
+// GetQueueSummaries returns the queues of the applications in the shim ordered by partition and name
+func (ctx *Context) GetQueueSummaries() []*QueueSummary {
+	apps := ctx.listApplications()
+	states := events.States().Task
+	byQueue := make(map[string]*QueueSummary)
+	summaries := make([]*QueueSummary, 0)
+	for _, app := range apps {
+		app.lock.RLock()
+		key := app.partition + "/" + app.queue
+		summary, ok := byQueue[key]
+		if !ok {
+			summary = &QueueSummary{
+				Queue:             app.queue,
+				Partition:         app.partition,
+				Applications:      make([]string, 0),
+				ApplicationStates: make(map[string]int),
+				Pods:              make(map[string]int),
+				Requested:         make(map[string]int64),
+				Allocated:         make(map[string]int64),
+			}
+			byQueue[key] = summary
+			summaries = append(summaries, summary)
+		}
+		summary.Applications = append(summary.Applications, app.applicationID)
+		summary.ApplicationStates[app.sm.Current()]++
+		for _, task := range app.getAllTasks() {
+			// the state of the task is read without the task lock, the resource does not change
+			taskState := task.GetTaskState()
+			if state, ok := getQueuePodState(taskState); ok {
+				summary.Pods[state]++
+			}
+			switch taskState {
+			case states.New, states.Pending, states.Scheduling:
+				addResource(summary.Requested, task.resource)
+			case states.Allocated, states.Bound:
+				addResource(summary.Allocated, task.resource)
+			}
+		}
+		app.lock.RUnlock()
+	}
+
+	for _, summary := range summaries {
+		sort.Strings(summary.Applications)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Partition != summaries[j].Partition {
+			return summaries[i].Partition < summaries[j].Partition
+		}
+		return summaries[i].Queue < summaries[j].Queue
+	})
+	return summaries
+}
+
+func addResource(total map[string]int64, resource *si.Resource) {
+	if resource == nil {
+		return
+	}
+	for name, quantity := range resource.Resources {
+		total[name] += quantity.GetValue()
+	}
+}
@@ -0,0 +1,90 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+)
+
+func TestGetQueueSummaries(t *testing.T) {
+	context := initContextForTest()
+	states := events.States().Task
+	for appID, queue := range map[string]string{"app01": "root.b", "app02": "root.a", "app03": "root.b"} {
+		context.AddApplication(&interfaces.AddApplicationRequest{
+			Metadata: interfaces.ApplicationMetadata{
+				ApplicationID: appID,
+				QueueName:     queue,
+				User:          "test-user",
+			},
+		})
+	}
+	addTask := func(appID, taskID, state string, vcore int64) {
+		task := context.AddTask(&interfaces.AddTaskRequest{
+			Metadata: interfaces.TaskMetadata{
+				ApplicationID: appID,
+				TaskID:        taskID,
+				Pod: &v1.Pod{
+					ObjectMeta: apis.ObjectMeta{
+						Name:      "pod-" + taskID,
+						Namespace: "default",
+						UID:       types.UID(taskID),
+					},
+				},
+			},
+		}).(*Task)
+		task.resource = common.NewResourceBuilder().AddResource(constants.CPU, vcore).Build()
+		task.sm.SetState(state)
+	}
+	addTask("app01", "task01", states.Pending, 100)
+	addTask("app01", "task02", states.Bound, 200)
+	addTask("app03", "task03", states.Allocated, 300)
+	addTask("app03", "task04", states.Scheduling, 400)
+	// ended tasks are not counted
+	addTask("app03", "task05", states.Completed, 500)
+
+	summaries := context.GetQueueSummaries()
+	assert.Equal(t, len(summaries), 2)
+	assert.Equal(t, summaries[0].Queue, "root.a")
+	assert.Equal(t, summaries[0].Partition, constants.DefaultPartition)
+	assert.DeepEqual(t, summaries[0].Applications, []string{"app02"})
+	assert.Equal(t, len(summaries[0].Requested), 0)
+
+	queue := summaries[1]
+	assert.Equal(t, queue.Queue, "root.b")
+	assert.DeepEqual(t, queue.Applications, []string{"app01", "app03"})
+	assert.DeepEqual(t, queue.ApplicationStates, map[string]int{events.States().Application.New: 2})
+	assert.DeepEqual(t, queue.Pods, map[string]int{
+		metrics.QueuePodsPending:    1,
+		metrics.QueuePodsScheduling: 2,
+		metrics.QueuePodsBound:      1,
+	})
+	assert.DeepEqual(t, queue.Requested, map[string]int64{constants.CPU: 500})
+	assert.DeepEqual(t, queue.Allocated, map[string]int64{constants.CPU: 500})
+}
@@ -0,0 +1,134 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/audit"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// ResyncResult describes what a resync of the caches changed
+type ResyncResult struct {
+	Time           time.Time `json:"time"`
+	Relisted       []string  `json:"relisted"`
+	NodesAdded     int       `json:"nodesAdded"`
+	NodesRemoved   int       `json:"nodesRemoved"`
+	PodsDropped    int       `json:"podsDropped"`
+	TasksCompleted int       `json:"tasksCompleted"`
+}
+
+// Resync rebuilds the scheduler cache and the context from the api-server without restarting
+// the shim, to recover from a suspected cache corruption. The informers list all the objects
+// again, which delivers them to the event handlers again. The caches are reconciled with the
+// informer stores: the missing nodes are added, the nodes that no longer exist are removed,
+// the pods of the scheduler cache are rebuilt and the tasks of the pods that no longer exist
+// are completed. The operation is written to the audit log with the caller that requested it.
+func (ctx *Context) Resync(reason, caller string) (*ResyncResult, error) {
+	result := &ResyncResult{
+		Time:     time.Now(),
+		Relisted: client.RelistInformers(),
+	}
+	apis := ctx.apiProvider.GetAPIs()
+	nodes, err := apis.NodeInformer.Lister().List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the nodes: %v", err)
+	}
+	pods, err := apis.PodInformer.Lister().List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the pods: %v", err)
+	}
+
+	listedPods := make(map[string]bool, len(pods))
+	cachedPods := make([]*v1.Pod, 0, len(pods))
+	cachedUIDs := make(map[string]bool, len(pods))
+	for _, pod := range pods {
+		listedPods[string(pod.UID)] = true
+		if ctx.filterPods(pod) {
+			cachedPods = append(cachedPods, pod)
+			cachedUIDs[string(pod.UID)] = true
+		}
+	}
+
+	listedNodes := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		listedNodes[node.Name] = true
+		cached := ctx.schedulerCache.GetNode(node.Name)
+		if cached == nil || cached.Node() == nil || ctx.nodes.getNode(node.Name) == nil {
+			ctx.addNode(node)
+			result.NodesAdded++
+		}
+	}
+	for name, nodeInfo := range ctx.schedulerCache.GetNodesInfoMapCopy() {
+		if node := nodeInfo.Node(); node != nil && !listedNodes[name] {
+			// the pods are removed from the cache with the node, the ones that are not listed are dropped
+			for _, podInfo := range nodeInfo.Pods {
+				if !cachedUIDs[string(podInfo.Pod.UID)] {
+					result.PodsDropped++
+				}
+			}
+			ctx.deleteNode(node)
+			result.NodesRemoved++
+		}
+	}
+	result.PodsDropped += ctx.schedulerCache.ReplacePods(cachedPods)
+
+	for _, task := range ctx.getTasksWithoutPod(listedPods) {
+		ctx.NotifyTaskComplete(task.applicationID, task.taskID)
+		result.TasksCompleted++
+	}
+
+	log.Log(log.Cache).Warn("caches resynced from the api-server",
+		zap.Strings("relisted", result.Relisted),
+		zap.Int("nodesAdded", result.NodesAdded),
+		zap.Int("nodesRemoved", result.NodesRemoved),
+		zap.Int("podsDropped", result.PodsDropped),
+		zap.Int("tasksCompleted", result.TasksCompleted),
+		zap.String("reason", reason),
+		zap.String("caller", caller))
+	audit.Log(&audit.Record{
+		Time:   result.Time,
+		Action: audit.ActionResync,
+		Reason: reason,
+		Caller: caller,
+	})
+	return result, nil
+}
+
+// returns the tasks that are not terminated while their pod no longer exists
+func (ctx *Context) getTasksWithoutPod(listedPods map[string]bool) []*Task {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	var tasks []*Task
+	for _, app := range ctx.applications {
+		for _, task := range app.getAllTasks() {
+			if !task.isTerminated() && !listedPods[task.taskID] {
+				tasks = append(tasks, task)
+			}
+		}
+	}
+	return tasks
+}
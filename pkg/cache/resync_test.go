@@ -0,0 +1,101 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/test"
+)
+
+func TestResync(t *testing.T) {
+	context := initContextForTest()
+	apiProvider, ok := context.apiProvider.(*client.MockedAPIProvider)
+	assert.Assert(t, ok)
+
+	newNode := func(name string) *v1.Node {
+		return &v1.Node{
+			ObjectMeta: apis.ObjectMeta{
+				Name: name,
+				UID:  types.UID("uid_" + name),
+			},
+		}
+	}
+	// host0002 was deleted, the deletion of host0003 was missed
+	context.addNode(newNode("host0001"))
+	context.addNode(newNode("host0003"))
+	nodeLister := test.NewNodeListerMock()
+	nodeLister.AddNode(newNode("host0001"))
+	nodeLister.AddNode(newNode("host0002"))
+	apiProvider.SetNodeLister(nodeLister)
+
+	// the pod of task02 was deleted but the deletion was missed
+	context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: "app01",
+			QueueName:     "root.a",
+			User:          "test-user",
+		},
+	})
+	pod1 := newPodHelper("pod-01", "default", "task01", "host0001", v1.PodRunning)
+	pod2 := newPodHelper("pod-02", "default", "task02", "host0003", v1.PodRunning)
+	context.addPodToCache(pod1)
+	context.addPodToCache(pod2)
+	for _, pod := range []*v1.Pod{pod1, pod2} {
+		context.AddTask(&interfaces.AddTaskRequest{
+			Metadata: interfaces.TaskMetadata{
+				ApplicationID: "app01",
+				TaskID:        string(pod.UID),
+				Pod:           pod,
+			},
+		})
+	}
+	podLister := test.NewPodListerMock()
+	podLister.AddPod(pod1)
+	apiProvider.SetPodLister(podLister)
+
+	result, err := context.Resync("suspected corruption", "admin")
+	assert.NilError(t, err)
+	assert.Equal(t, result.NodesAdded, 1)
+	assert.Equal(t, result.NodesRemoved, 1)
+	assert.Equal(t, result.PodsDropped, 1)
+	assert.Equal(t, result.TasksCompleted, 1)
+
+	assert.Assert(t, context.nodes.getNode("host0002") != nil)
+	assert.Assert(t, context.nodes.getNode("host0003") == nil)
+	assert.Assert(t, context.schedulerCache.GetNode("host0003") == nil)
+	_, ok = context.schedulerCache.GetPod("task01")
+	assert.Assert(t, ok)
+	_, ok = context.schedulerCache.GetPod("task02")
+	assert.Assert(t, !ok)
+
+	// nothing left to reconcile
+	result, err = context.Resync("", "")
+	assert.NilError(t, err)
+	assert.Equal(t, result.NodesAdded, 0)
+	assert.Equal(t, result.NodesRemoved, 0)
+	assert.Equal(t, result.PodsDropped, 0)
+}
@@ -0,0 +1,111 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// ScaleDownProtectionInterval is the interval the scale down protection of the nodes is updated at
+const ScaleDownProtectionInterval = 10 * time.Second
+
+// the nodes the shim annotated to keep the cluster autoscaler from removing them
+type scaleDownProtection struct {
+	nodes map[string]bool
+	lock  sync.Mutex
+}
+
+func newScaleDownProtection() *scaleDownProtection {
+	return &scaleDownProtection{
+		nodes: make(map[string]bool),
+	}
+}
+
+// returns the nodes the scheduler is about to use: the nodes with allocated placeholders, which hold the
+// resources reserved for a gang, and the nodes with allocated pods that are not bound yet
+func (ctx *Context) getPendingPlacementNodes() map[string]bool {
+	states := events.States().Task
+	nodes := make(map[string]bool)
+	for _, app := range ctx.listApplications() {
+		for _, task := range app.getAllTasks() {
+			state := task.GetTaskState()
+			if state != states.Allocated && (state != states.Bound || !task.IsPlaceholder()) {
+				continue
+			}
+			task.lock.RLock()
+			nodeName := task.nodeName
+			task.lock.RUnlock()
+			if nodeName != "" {
+				nodes[nodeName] = true
+			}
+		}
+	}
+	return nodes
+}
+
+// ProtectScaleDown annotates the nodes with pending placements for the cluster autoscaler to keep them,
+// the annotation is removed once the placements are done: the placeholders are replaced and the pods bound.
+// A node that failed to be annotated, or whose annotation failed to be removed, is tried again next time.
+func (ctx *Context) ProtectScaleDown() {
+	schedulerConf := ctx.apiProvider.GetAPIs().Conf
+	if !schedulerConf.ScaleDownProtection {
+		return
+	}
+	clientSet := ctx.apiProvider.GetAPIs().KubeClient.GetClientSet()
+	wanted := ctx.getPendingPlacementNodes()
+	ctx.scaleDown.lock.Lock()
+	defer ctx.scaleDown.lock.Unlock()
+	for nodeName := range wanted {
+		if ctx.scaleDown.nodes[nodeName] || client.SkipMutation("patch", "nodes", "", nodeName) {
+			continue
+		}
+		annotations := map[string]string{schedulerConf.ScaleDownAnnotation: "true"}
+		if _, err := client.ApplyNodeAnnotations(clientSet, nodeName, annotations); err != nil {
+			log.Log(log.Cache).Warn("failed to protect the node from the scale down",
+				zap.String("nodeID", nodeName),
+				zap.Error(err))
+			continue
+		}
+		log.Log(log.Cache).Info("node is protected from the scale down", zap.String("nodeID", nodeName))
+		ctx.scaleDown.nodes[nodeName] = true
+	}
+	for nodeName := range ctx.scaleDown.nodes {
+		if wanted[nodeName] {
+			continue
+		}
+		// the annotations left out of the apply are removed, a node that is gone needs no update
+		if _, err := client.ApplyNodeAnnotations(clientSet, nodeName, map[string]string{}); err != nil &&
+			!apierrors.IsNotFound(err) {
+			log.Log(log.Cache).Warn("failed to remove the scale down protection of the node",
+				zap.String("nodeID", nodeName),
+				zap.Error(err))
+			continue
+		}
+		log.Log(log.Cache).Info("scale down protection of the node is removed", zap.String("nodeID", nodeName))
+		delete(ctx.scaleDown.nodes, nodeName)
+	}
+}
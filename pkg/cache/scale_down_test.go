@@ -0,0 +1,126 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+)
+
+func TestProtectScaleDown(t *testing.T) {
+	context := initContextForTest()
+	schedulerConf := context.apiProvider.GetAPIs().Conf
+	schedulerConf.ScaleDownAnnotation = conf.DefaultScaleDownAnnotation
+	clientSet := context.apiProvider.GetAPIs().KubeClient.GetClientSet().(*fake.Clientset)
+	for _, name := range []string{"node-1", "node-2", "node-3"} {
+		assert.NilError(t, clientSet.Tracker().Add(&v1.Node{ObjectMeta: apis.ObjectMeta{Name: name}}))
+	}
+	// the annotations of every node patch, keyed by the node name
+	patches := make(map[string]map[string]string)
+	clientSet.PrependReactor("patch", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patchAction := action.(k8stesting.PatchAction)
+		var patch struct {
+			Metadata struct {
+				Annotations map[string]string `json:"annotations"`
+			} `json:"metadata"`
+		}
+		assert.NilError(t, json.Unmarshal(patchAction.GetPatch(), &patch))
+		patches[patchAction.GetName()] = patch.Metadata.Annotations
+		return false, nil, nil
+	})
+	getProtected := func() []string {
+		context.scaleDown.lock.Lock()
+		defer context.scaleDown.lock.Unlock()
+		nodes := make([]string, 0)
+		for name := range context.scaleDown.nodes {
+			nodes = append(nodes, name)
+		}
+		sort.Strings(nodes)
+		return nodes
+	}
+
+	context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: "app-0001",
+			QueueName:     "root.a",
+			User:          "test-user",
+		},
+	})
+	addTask := func(taskID, nodeName string, placeholder bool, state string) *Task {
+		task := context.AddTask(&interfaces.AddTaskRequest{
+			Metadata: interfaces.TaskMetadata{
+				ApplicationID: "app-0001",
+				TaskID:        taskID,
+				Pod:           newPodHelper("pod-"+taskID, "default", taskID, "", v1.PodPending),
+				Placeholder:   placeholder,
+			},
+		}).(*Task)
+		task.setAllocated(nodeName, "uuid-"+taskID)
+		task.sm.SetState(state)
+		return task
+	}
+	states := events.States().Task
+	placeholder := addTask("placeholder", "node-1", true, states.Bound)
+	binding := addTask("binding", "node-2", false, states.Allocated)
+	addTask("running", "node-3", false, states.Bound)
+
+	// the nodes are not annotated without the protection
+	context.ProtectScaleDown()
+	assert.Equal(t, len(patches), 0)
+
+	// the nodes with the placeholder and the pod that is not bound yet are protected
+	schedulerConf.ScaleDownProtection = true
+	context.ProtectScaleDown()
+	assert.DeepEqual(t, getProtected(), []string{"node-1", "node-2"})
+	assert.DeepEqual(t, patches, map[string]map[string]string{
+		"node-1": {conf.DefaultScaleDownAnnotation: "true"},
+		"node-2": {conf.DefaultScaleDownAnnotation: "true"},
+	})
+	obj, err := clientSet.Tracker().Get(schema.GroupVersionResource{Version: "v1", Resource: "nodes"}, "", "node-1")
+	assert.NilError(t, err)
+	assert.Equal(t, obj.(*v1.Node).Annotations[conf.DefaultScaleDownAnnotation], "true")
+
+	// the protected nodes are not annotated again
+	patches = make(map[string]map[string]string)
+	context.ProtectScaleDown()
+	assert.Equal(t, len(patches), 0)
+
+	// the protection is removed once the placeholder is replaced and the pod is bound
+	placeholder.sm.SetState(states.Completed)
+	binding.sm.SetState(states.Bound)
+	context.ProtectScaleDown()
+	assert.DeepEqual(t, getProtected(), []string{})
+	assert.DeepEqual(t, patches, map[string]map[string]string{
+		"node-1": {},
+		"node-2": {},
+	})
+}
@@ -0,0 +1,69 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"strings"
+	"time"
+
+	"github.com/looplab/fsm"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+)
+
+// the reason label of a failure when the message matches none of the known failures
+const otherFailure = "Other"
+
+// the failure messages are free text, the known failures are matched on a part of the
+// message to keep the number of reasons in the metrics small
+var failureReasons = []struct {
+	pattern string
+	reason  string
+}{
+	{constants.ApplicationRejectedFailure, constants.ApplicationRejectedFailure},
+	{constants.ApplicationInsufficientResourcesFailure, constants.ApplicationInsufficientResourcesFailure},
+	{"rejected by scheduler", "TaskRejected"},
+	{"bind pod", "BindFailure"},
+	{"event handler panicked", "EventHandlerPanic"},
+}
+
+// records the transition of the state machine of an object and the time spent in the source state,
+// since is the time the object entered the source state, it is moved to now.
+func observeTransition(object string, since *time.Time, event *fsm.Event, failedState string) {
+	now := time.Now()
+	metrics.GetStateMetrics().ObserveTransition(object, event.Src, event.Dst, now.Sub(*since))
+	*since = now
+	if event.Dst == failedState && event.Src != failedState {
+		message := ""
+		if len(event.Args) > 0 {
+			message, _ = event.Args[0].(string)
+		}
+		metrics.GetStateMetrics().IncFailures(object, getFailureReason(message))
+	}
+}
+
+func getFailureReason(message string) string {
+	for _, known := range failureReasons {
+		if strings.Contains(message, known.pattern) {
+			return known.reason
+		}
+	}
+	return otherFailure
+}
@@ -0,0 +1,57 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/looplab/fsm"
+	"gotest.tools/assert"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+)
+
+func TestGetFailureReason(t *testing.T) {
+	assert.Equal(t, getFailureReason(fmt.Sprintf("%s: %s", constants.ApplicationRejectedFailure, "queue not found")),
+		constants.ApplicationRejectedFailure)
+	assert.Equal(t, getFailureReason(constants.ApplicationInsufficientResourcesFailure),
+		constants.ApplicationInsufficientResourcesFailure)
+	assert.Equal(t, getFailureReason("task ns/pod failed because it is rejected by scheduler"), "TaskRejected")
+	assert.Equal(t, getFailureReason("bind pod volumes failed, name: ns/pod, timeout"), "BindFailure")
+	assert.Equal(t, getFailureReason("event handler panicked: nil map"), "EventHandlerPanic")
+	assert.Equal(t, getFailureReason("unexpected"), otherFailure)
+	assert.Equal(t, getFailureReason(""), otherFailure)
+}
+
+func TestObserveTransition(t *testing.T) {
+	since := time.Now().Add(-time.Minute)
+	before := since
+	observeTransition(metrics.ObjectTask, &since, &fsm.Event{
+		Event: string(events.TaskFail),
+		Src:   events.States().Task.Allocated,
+		Dst:   events.States().Task.Failed,
+		Args:  []interface{}{"bind pod failed"},
+	}, events.States().Task.Failed)
+	// the time the object entered the destination state is kept for the next transition
+	assert.Assert(t, since.After(before))
+}
@@ -0,0 +1,149 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+)
+
+// the steps an allocated task passes until its pod runs
+const (
+	// the binding has not started yet
+	BindingStepQueued = "Queued"
+	// the binding is held back while the scheduler is in maintenance mode
+	BindingStepMaintenance   = "Maintenance"
+	BindingStepVolumeBinding = "VolumeBinding"
+	BindingStepAPIBind       = "APIBind"
+	// the api-server did not accept the binding, or the volumes could not be bound
+	BindingStepAPIError = "APIError"
+	// the pod is bound, the kubelet has not started it
+	BindingStepKubelet = "Kubelet"
+)
+
+// DefaultStuckBindingThreshold is the time after the allocation a pod is reported as stuck
+const DefaultStuckBindingThreshold = 5 * time.Minute
+
+// the progress of the binding of an allocated task, read without the task lock:
+// the lock is held for the whole binding
+type bindingProgress struct {
+	step      string
+	message   string
+	nodeName  string
+	allocated time.Time
+	since     time.Time
+}
+
+// StuckBinding is a pod allocated by the core that is not running after the threshold
+type StuckBinding struct {
+	Namespace     string    `json:"namespace"`
+	Name          string    `json:"name"`
+	ApplicationID string    `json:"applicationID"`
+	TaskID        string    `json:"taskID"`
+	NodeName      string    `json:"nodeName,omitempty"`
+	State         string    `json:"state"`
+	Step          string    `json:"step"`
+	Message       string    `json:"message,omitempty"`
+	AllocatedAt   time.Time `json:"allocatedAt"`
+	StepSince     time.Time `json:"stepSince"`
+}
+
+// starts tracking the binding of the task that was allocated
+func (task *Task) startBinding(now time.Time) {
+	task.bindingProgress.Store(&bindingProgress{step: BindingStepQueued, allocated: now, since: now})
+}
+
+// moves the binding to the next step, an empty node name keeps the node of the previous step
+func (task *Task) setBindingStep(step, nodeName, message string) {
+	current := task.getBindingProgress()
+	if current == nil {
+		return
+	}
+	next := &bindingProgress{
+		step:      step,
+		message:   message,
+		nodeName:  current.nodeName,
+		allocated: current.allocated,
+		since:     time.Now(),
+	}
+	if nodeName != "" {
+		next.nodeName = nodeName
+	}
+	task.bindingProgress.Store(next)
+}
+
+// the pod is running, the binding is no longer tracked
+func (task *Task) endBinding() {
+	task.bindingProgress.Store((*bindingProgress)(nil))
+}
+
+func (task *Task) getBindingProgress() *bindingProgress {
+	progress, ok := task.bindingProgress.Load().(*bindingProgress)
+	if !ok {
+		return nil
+	}
+	return progress
+}
+
+// GetStuckBindings returns the pods that were allocated longer than the threshold ago and do not run yet,
+// the ones allocated first come first. The pods recovered after a restart of the scheduler are not tracked.
+func (ctx *Context) GetStuckBindings(threshold time.Duration) []*StuckBinding {
+	states := events.States().Task
+	tasks := make([]*Task, 0)
+	for _, app := range ctx.listApplications() {
+		tasks = append(tasks, app.getAllTasks()...)
+	}
+
+	now := time.Now()
+	stuck := make([]*StuckBinding, 0)
+	for _, task := range tasks {
+		state := task.GetTaskState()
+		if state != states.Allocated && state != states.Bound {
+			continue
+		}
+		progress := task.getBindingProgress()
+		if progress == nil || now.Sub(progress.allocated) < threshold {
+			continue
+		}
+		// the update of the pod that started to run may not have been processed yet
+		if pod, ok := ctx.schedulerCache.GetPod(string(task.pod.UID)); ok && pod.Status.Phase == v1.PodRunning {
+			continue
+		}
+		stuck = append(stuck, &StuckBinding{
+			Namespace:     task.pod.Namespace,
+			Name:          task.pod.Name,
+			ApplicationID: task.applicationID,
+			TaskID:        task.taskID,
+			NodeName:      progress.nodeName,
+			State:         state,
+			Step:          progress.step,
+			Message:       progress.message,
+			AllocatedAt:   progress.allocated,
+			StepSince:     progress.since,
+		})
+	}
+	sort.Slice(stuck, func(i, j int) bool {
+		return stuck[i].AllocatedAt.Before(stuck[j].AllocatedAt)
+	})
+	return stuck
+}
@@ -0,0 +1,102 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+)
+
+func TestGetStuckBindings(t *testing.T) {
+	context := initContextForTest()
+	states := events.States().Task
+	context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: "app01",
+			QueueName:     "root.a",
+			User:          "test-user",
+		},
+	})
+	addTask := func(taskID, state string, allocated time.Time) *Task {
+		task := context.AddTask(&interfaces.AddTaskRequest{
+			Metadata: interfaces.TaskMetadata{
+				ApplicationID: "app01",
+				TaskID:        taskID,
+				Pod: &v1.Pod{
+					ObjectMeta: apis.ObjectMeta{
+						Name:      "pod-" + taskID,
+						Namespace: "default",
+						UID:       types.UID(taskID),
+					},
+				},
+			},
+		}).(*Task)
+		task.sm.SetState(state)
+		if !allocated.IsZero() {
+			task.startBinding(allocated)
+		}
+		return task
+	}
+	old := time.Now().Add(-time.Hour)
+
+	// allocated recently
+	addTask("task01", states.Allocated, time.Now())
+	// recovered after a restart, the binding is not tracked
+	addTask("task02", states.Bound, time.Time{})
+	// held back by the volume binding
+	volumes := addTask("task03", states.Allocated, old.Add(time.Minute))
+	volumes.setBindingStep(BindingStepVolumeBinding, "node01", "")
+	// bound first, the kubelet has not started it
+	kubelet := addTask("task04", states.Allocated, old)
+	kubelet.setBindingStep(BindingStepAPIBind, "node02", "")
+	kubelet.sm.SetState(states.Bound)
+	kubelet.setBindingStep(BindingStepKubelet, "", "waiting for the kubelet to start the pod")
+	// running, the update of the pod was not processed
+	running := addTask("task05", states.Bound, old)
+	runningPod := running.pod.DeepCopy()
+	runningPod.Status.Phase = v1.PodRunning
+	assert.NilError(t, context.schedulerCache.AddPod(runningPod))
+	// failed binding, the task is no longer waiting
+	failed := addTask("task06", states.Allocated, old)
+	failed.setBindingStep(BindingStepAPIError, "", "bind failed")
+	failed.sm.SetState(states.Failed)
+
+	stuck := context.GetStuckBindings(DefaultStuckBindingThreshold)
+	assert.Equal(t, len(stuck), 2)
+	assert.Equal(t, stuck[0].TaskID, "task04")
+	assert.Equal(t, stuck[0].State, states.Bound)
+	assert.Equal(t, stuck[0].Step, BindingStepKubelet)
+	assert.Equal(t, stuck[0].NodeName, "node02")
+	assert.Equal(t, stuck[1].Name, "pod-task03")
+	assert.Equal(t, stuck[1].Step, BindingStepVolumeBinding)
+	assert.Equal(t, stuck[1].AllocatedAt, old.Add(time.Minute))
+
+	// the pod started to run
+	kubelet.endBinding()
+	assert.Equal(t, len(context.GetStuckBindings(DefaultStuckBindingThreshold)), 1)
+	assert.Equal(t, len(context.GetStuckBindings(0)), 2)
+}
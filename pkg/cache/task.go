@@ -21,16 +21,21 @@ package cache
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/audit"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/dispatcher"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/eventsink"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/tracing"
 	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
 
 	"github.com/looplab/fsm"
@@ -48,9 +53,16 @@ type Task struct {
 	context         *Context
 	nodeName        string
 	createTime      time.Time
+	schedulingTimes schedulingTimes
+	stateSince      time.Time
+	trace           tracing.SpanContext
+	traceEnded      bool
 	taskGroupName   string
 	placeholder     bool
 	terminationType string
+	preemption      *preemption
+	unschedulable   atomic.Value
+	bindingProgress atomic.Value
 	sm              *fsm.FSM
 	lock            *sync.RWMutex
 }
@@ -88,6 +100,8 @@ func createTaskInternal(tid string, app *Application, resource *si.Resource,
 		pod:           pod,
 		resource:      resource,
 		createTime:    pod.GetCreationTimestamp().Time,
+		stateSince:    time.Now(),
+		trace:         tracing.NewTrace(string(pod.UID)),
 		placeholder:   placeholder,
 		taskGroupName: taskGroupName,
 		context:       ctx,
@@ -174,6 +188,11 @@ func (task *Task) canHandle(te events.TaskEvent) bool {
 	return task.sm.Can(string(te.GetEvent()))
 }
 
+// the logger of the task, the logs carry the correlation ID of the task
+func (task *Task) logger() *zap.Logger {
+	return log.Log(log.Cache).With(log.CorrelationID(task.applicationID, task.taskID))
+}
+
 func (task *Task) GetTaskPod() *v1.Pod {
 	task.lock.RLock()
 	defer task.lock.RUnlock()
@@ -215,6 +234,26 @@ func (task *Task) getTaskGroupName() string {
 	return task.taskGroupName
 }
 
+// returns the priority of the pod capped by the priority fence of its namespace, the tags of the
+// application are read without the application lock as they are replaced and never changed in place
+func (task *Task) getPriority() int32 {
+	return task.getPodPriority(task.GetTaskPod())
+}
+
+// the priority of the given pod of the task, for the callers that hold the task lock
+func (task *Task) getPodPriority(pod *v1.Pod) int32 {
+	if task.application == nil {
+		return common.GetPodPriority(pod)
+	}
+	return common.GetEffectivePriority(pod, task.application.GetTags())
+}
+
+func (task *Task) getTaskResource() *si.Resource {
+	task.lock.RLock()
+	defer task.lock.RUnlock()
+	return task.resource
+}
+
 func (task *Task) getTaskAllocationUUID() string {
 	task.lock.RLock()
 	defer task.lock.RUnlock()
@@ -225,8 +264,14 @@ func (task *Task) DeleteTaskPod(pod *v1.Pod) error {
 	return task.context.apiProvider.GetAPIs().KubeClient.Delete(task.pod)
 }
 
-func (task *Task) UpdateTaskPodStatus(pod *v1.Pod) (*v1.Pod, error) {
-	return task.context.apiProvider.GetAPIs().KubeClient.UpdateStatus(pod)
+// the status write goes through the pod status writer of the context, it can be held back
+// or skipped when the same status was written last
+func (task *Task) UpdateTaskPodStatus(pod *v1.Pod) error {
+	_, err := task.context.statusWriter.write(newPodPhaseWrite(pod, func(pod *v1.Pod) error {
+		_, err := task.context.apiProvider.GetAPIs().KubeClient.UpdateStatus(pod)
+		return err
+	}))
+	return err
 }
 
 func (task *Task) isTerminated() bool {
@@ -254,7 +299,7 @@ func (task *Task) initialize() {
 		task.allocationUUID = string(task.pod.UID)
 		task.nodeName = task.pod.Spec.NodeName
 		task.sm.SetState(events.States().Task.Allocated)
-		log.Logger().Info("set task as Allocated",
+		task.logger().Info("set task as Allocated",
 			zap.String("appID", task.applicationID),
 			zap.String("taskID", task.taskID),
 			zap.String("allocationUUID", task.allocationUUID),
@@ -269,7 +314,7 @@ func (task *Task) initialize() {
 		task.allocationUUID = string(task.pod.UID)
 		task.nodeName = task.pod.Spec.NodeName
 		task.sm.SetState(events.States().Task.Completed)
-		log.Logger().Info("set task as Completed",
+		task.logger().Info("set task as Completed",
 			zap.String("appID", task.applicationID),
 			zap.String("taskID", task.taskID),
 			zap.String("allocationUUID", task.allocationUUID),
@@ -294,14 +339,14 @@ func (task *Task) handleFailEvent(event *fsm.Event) {
 		return
 	}
 
-	log.Logger().Error("task failed",
+	task.logger().Error("task failed",
 		zap.String("appID", task.applicationID),
 		zap.String("taskID", task.taskID),
 		zap.String("reason", eventArgs[0]))
 }
 
 func (task *Task) handleSubmitTaskEvent(event *fsm.Event) {
-	log.Logger().Debug("scheduling pod",
+	task.logger().Debug("scheduling pod",
 		zap.String("podName", task.pod.Name))
 	// convert the request
 	rr := common.CreateAllocationRequestForTask(
@@ -311,11 +356,18 @@ func (task *Task) handleSubmitTaskEvent(event *fsm.Event) {
 		task.placeholder,
 		task.taskGroupName,
 		task.pod)
-	log.Logger().Debug("send update request", zap.String("request", rr.String()))
+	addVictimPolicyTags(rr.Asks[0].Tags, task.placeholder, task.getPodPriority(task.pod),
+		task.context.apiProvider.GetAPIs().Conf.PreemptionVictimPolicy)
+	if task.trace.IsValid() {
+		// the core can link its own spans to the trace of the pod
+		rr.Asks[0].Tags[tracing.TraceparentTag] = task.trace.Traceparent()
+	}
+	task.logger().Debug("send update request", zap.String("request", rr.String()))
 	if err := task.context.apiProvider.GetAPIs().SchedulerAPI.UpdateAllocation(&rr); err != nil {
-		log.Logger().Debug("failed to send scheduling request to scheduler", zap.Error(err))
+		task.logger().Debug("failed to send scheduling request to scheduler", zap.Error(err))
 		return
 	}
+	task.markAskSent(time.Now())
 
 	events.GetRecorder().Eventf(task.pod, v1.EventTypeNormal, "Scheduling",
 		"%s is queued and waiting for allocation", task.alias)
@@ -339,21 +391,37 @@ func (task *Task) postTaskPending(event *fsm.Event) {
 // if successful, we move task to next state BOUND,
 // otherwise we fail the task
 func (task *Task) postTaskAllocated(event *fsm.Event) {
+	task.markAllocated(time.Now())
+	task.startBinding(time.Now())
 	// delay binding task
 	// this calls K8s api to bind a pod to the assigned node, this may need some time,
 	// so we do a delay binding to avoid blocking main process. we tracks the result
 	// of the binding and properly handle failures.
 	go func(event *fsm.Event) {
+		// the binding is held back while the scheduler is in maintenance mode
+		waited := task.context.waitForMaintenanceEnd(task)
+
+		// bounds the number of bindings in flight
+		task.context.bindWorkers.acquire()
+		defer task.context.bindWorkers.release()
+
 		// we need to obtain task's lock first,
 		// this ensures no other threads modifying task state at the time being
 		task.lock.Lock()
 		defer task.lock.Unlock()
 
+		// the allocation was released while the binding was held back
+		if waited && task.GetTaskState() != events.States().Task.Allocated {
+			task.logger().Info("task is no longer allocated, skip binding",
+				zap.String("state", task.GetTaskState()))
+			return
+		}
+
 		var errorMessage string
 		eventArgs := make([]string, 2)
 		if err := events.GetEventArgsAsStrings(eventArgs, event.Args); err != nil {
 			errorMessage = err.Error()
-			log.Logger().Error("error", zap.Error(err))
+			task.logger().Error("error", zap.Error(err))
 			dispatcher.Dispatch(NewFailTaskEvent(task.applicationID, task.taskID, errorMessage))
 			return
 		}
@@ -368,14 +436,21 @@ func (task *Task) postTaskAllocated(event *fsm.Event) {
 
 		// task allocation UID is assigned once we get allocation decision from scheduler core
 		task.allocationUUID = allocUUID
+		task.nodeName = nodeID
+		task.audit(audit.ActionAllocate, time.Now())
+		task.context.nodeStats.recordAllocation(nodeID)
+		task.context.signalKarpenter(task.pod, task.application, nodeID)
 
 		// before binding pod to node, first bind volumes to pod
-		log.Logger().Debug("bind pod volumes",
+		task.logger().Debug("bind pod volumes",
 			zap.String("podName", task.pod.Name),
 			zap.String("podUID", string(task.pod.UID)))
 		if task.context.apiProvider.GetAPIs().VolumeBinder != nil {
+			task.setBindingStep(BindingStepVolumeBinding, nodeID, "")
 			if err := task.context.bindPodVolumes(task.pod); err != nil {
+				task.context.nodeStats.recordBind(nodeID, err)
 				errorMessage = fmt.Sprintf("bind pod volumes failed, name: %s, %s", task.alias, err.Error())
+				task.setBindingStep(BindingStepAPIError, "", errorMessage)
 				dispatcher.Dispatch(NewFailTaskEvent(task.applicationID, task.taskID, errorMessage))
 				events.GetRecorder().Eventf(task.pod,
 					v1.EventTypeWarning, "PodVolumesBindFailure", errorMessage)
@@ -383,20 +458,25 @@ func (task *Task) postTaskAllocated(event *fsm.Event) {
 			}
 		}
 
-		log.Logger().Debug("bind pod",
+		task.logger().Debug("bind pod",
 			zap.String("podName", task.pod.Name),
 			zap.String("podUID", string(task.pod.UID)))
 
+		task.setBindingStep(BindingStepAPIBind, nodeID, "")
 		if err := task.context.apiProvider.GetAPIs().KubeClient.Bind(task.pod, nodeID); err != nil {
+			task.context.nodeStats.recordBind(nodeID, err)
 			errorMessage = fmt.Sprintf("bind pod volumes failed, name: %s, %s", task.alias, err.Error())
-			log.Logger().Error(errorMessage)
+			task.setBindingStep(BindingStepAPIError, "", errorMessage)
+			task.logger().Error(errorMessage)
 			dispatcher.Dispatch(NewFailTaskEvent(task.applicationID, task.taskID, errorMessage))
 			events.GetRecorder().Eventf(task.pod,
 				v1.EventTypeWarning, "PodBindFailure", errorMessage)
 			return
 		}
 
-		log.Logger().Info("successfully bound pod", zap.String("podName", task.pod.Name))
+		task.logger().Info("successfully bound pod", zap.String("podName", task.pod.Name))
+		task.audit(audit.ActionBind, time.Now())
+		task.context.nodeStats.recordBind(nodeID, nil)
 		dispatcher.Dispatch(NewBindTaskEvent(task.applicationID, task.taskID))
 		events.GetRecorder().Eventf(task.pod,
 			v1.EventTypeNormal, "PodBindSuccessful",
@@ -421,7 +501,7 @@ func (task *Task) beforeTaskAllocated(event *fsm.Event) {
 		eventArgs := make([]string, 2)
 		if err := events.GetEventArgsAsStrings(eventArgs, event.Args); err != nil {
 			errorMessage = err.Error()
-			log.Logger().Error("error", zap.Error(err))
+			task.logger().Error("error", zap.Error(err))
 			dispatcher.Dispatch(NewFailTaskEvent(task.applicationID, task.taskID, errorMessage))
 			return
 		}
@@ -429,7 +509,7 @@ func (task *Task) beforeTaskAllocated(event *fsm.Event) {
 		allocUUID := eventArgs[0]
 		nodeID := eventArgs[1]
 
-		log.Logger().Info("task is already completed, invalidate the allocation",
+		task.logger().Info("task is already completed, invalidate the allocation",
 			zap.String("currentTaskState", event.Src),
 			zap.String("allocUUID", allocUUID),
 			zap.String("allocatedNode", nodeID))
@@ -440,8 +520,10 @@ func (task *Task) beforeTaskAllocated(event *fsm.Event) {
 }
 
 func (task *Task) postTaskBound(event *fsm.Event) {
+	task.markBound(time.Now())
+	task.setBindingStep(BindingStepKubelet, "", "waiting for the kubelet to start the pod")
 	if task.placeholder {
-		log.Logger().Info("placeholder is bound",
+		task.logger().Info("placeholder is bound",
 			zap.String("appID", task.applicationID),
 			zap.String("taskName", task.alias),
 			zap.String("taskGroupName", task.taskGroupName))
@@ -465,6 +547,7 @@ func (task *Task) postTaskFailed(event *fsm.Event) {
 	// when task is failed, we need to do the cleanup,
 	// we need to release the allocation from scheduler core
 	task.releaseAllocation()
+	task.endTrace(time.Now(), fmt.Errorf("task failed"))
 
 	events.GetRecorder().Eventf(task.pod,
 		v1.EventTypeNormal, "TaskFailed",
@@ -476,6 +559,7 @@ func (task *Task) beforeTaskCompleted(event *fsm.Event) {
 	// this is done as a before hook because the releaseAllocation() call needs to
 	// send different requests to scheduler-core, depending on current task state
 	task.releaseAllocation()
+	task.endTrace(time.Now(), nil)
 
 	events.GetRecorder().Eventf(task.pod,
 		v1.EventTypeNormal, "TaskCompleted",
@@ -485,7 +569,7 @@ func (task *Task) beforeTaskCompleted(event *fsm.Event) {
 func (task *Task) releaseAllocation() {
 	// scheduler api might be nil in some tests
 	if task.context.apiProvider.GetAPIs().SchedulerAPI != nil {
-		log.Logger().Debug("prepare to send release request",
+		task.logger().Debug("prepare to send release request",
 			zap.String("applicationID", task.applicationID),
 			zap.String("taskID", task.taskID),
 			zap.String("taskAlias", task.alias),
@@ -508,7 +592,7 @@ func (task *Task) releaseAllocation() {
 			// log a warning and skip the release request. this may leak some resource
 			// in the scheduler, collect logs and check why this happens.
 			if task.allocationUUID == "" {
-				log.Logger().Warn("task allocation UUID is empty, sending this release request "+
+				task.logger().Warn("task allocation UUID is empty, sending this release request "+
 					"to yunikorn-core could cause all allocations of this app get released. skip this "+
 					"request, this may cause some resource leak. check the logs for more info!",
 					zap.String("applicationID", task.applicationID),
@@ -520,15 +604,17 @@ func (task *Task) releaseAllocation() {
 			}
 			releaseRequest = common.CreateReleaseAllocationRequestForTask(
 				task.applicationID, task.allocationUUID, task.application.partition, task.terminationType)
+			task.audit(task.releaseAction(), time.Now())
+			task.releasePreemption()
 		}
 
 		if releaseRequest.Releases != nil {
-			log.Logger().Info("releasing allocations",
+			task.logger().Info("releasing allocations",
 				zap.Int("numOfAsksToRelease", len(releaseRequest.Releases.AllocationAsksToRelease)),
 				zap.Int("numOfAllocationsToRelease", len(releaseRequest.Releases.AllocationsToRelease)))
 		}
 		if err := task.context.apiProvider.GetAPIs().SchedulerAPI.UpdateAllocation(&releaseRequest); err != nil {
-			log.Logger().Debug("failed to send scheduling request to scheduler", zap.Error(err))
+			task.logger().Debug("failed to send scheduling request to scheduler", zap.Error(err))
 		}
 	}
 }
@@ -547,7 +633,7 @@ func (task *Task) sanityCheckBeforeScheduling() error {
 			continue
 		}
 		pvcName := volume.PersistentVolumeClaim.ClaimName
-		log.Logger().Debug("checking PVC", zap.String("name", pvcName))
+		task.logger().Debug("checking PVC", zap.String("name", pvcName))
 		pvc, err := task.context.apiProvider.GetAPIs().PVCInformer.Lister().PersistentVolumeClaims(namespace).Get(pvcName)
 		if err != nil {
 			return err
@@ -560,11 +646,20 @@ func (task *Task) sanityCheckBeforeScheduling() error {
 }
 
 func (task *Task) enterState(event *fsm.Event) {
-	log.Logger().Debug("shim task state transition",
+	task.logger().Debug("shim task state transition",
 		zap.String("app", task.applicationID),
 		zap.String("task", task.taskID),
 		zap.String("taskAlias", task.alias),
 		zap.String("source", event.Src),
 		zap.String("destination", event.Dst),
 		zap.String("event", event.Event))
+	observeTransition(metrics.ObjectTask, &task.stateSince, event, events.States().Task.Failed)
+	eventsink.Publish(&eventsink.LifecycleEvent{
+		Kind:          eventsink.KindTask,
+		ApplicationID: task.applicationID,
+		TaskID:        task.taskID,
+		Event:         event.Event,
+		From:          event.Src,
+		To:            event.Dst,
+	})
 }
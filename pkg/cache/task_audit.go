@@ -0,0 +1,76 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"time"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/audit"
+	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
+)
+
+// the termination type of the allocations the core releases to make room for other pods
+var preemptedTerminationType = si.TerminationType_name[int32(si.TerminationType_PREEMPTED_BY_SCHEDULER)]
+
+// writes an action on the allocation of the task to the audit log, the task lock must be held
+func (task *Task) audit(action string, now time.Time) {
+	if !audit.Enabled() {
+		return
+	}
+	record := &audit.Record{
+		Time:            now,
+		Action:          action,
+		ApplicationID:   task.applicationID,
+		TaskID:          task.taskID,
+		Namespace:       task.pod.Namespace,
+		PodName:         task.pod.Name,
+		PodUID:          string(task.pod.UID),
+		Queue:           task.application.GetQueue(),
+		Partition:       task.application.getPartition(),
+		NodeID:          task.nodeName,
+		AllocationUUID:  task.allocationUUID,
+		Placeholder:     task.placeholder,
+		TerminationType: task.terminationType,
+	}
+	if task.resource != nil {
+		record.Resource = make(map[string]int64, len(task.resource.Resources))
+		for name, quantity := range task.resource.Resources {
+			record.Resource[name] = quantity.GetValue()
+		}
+	}
+	if action == audit.ActionRelease || action == audit.ActionPreempt {
+		if !task.createTime.IsZero() {
+			created := task.createTime
+			record.PodCreationTime = &created
+		}
+		if allocated := task.schedulingTimes.allocated; !allocated.IsZero() {
+			record.AllocatedTime = &allocated
+			record.DurationSeconds = now.Sub(allocated).Seconds()
+		}
+	}
+	audit.Log(record)
+}
+
+// returns the audit action of the release of the allocation of the task
+func (task *Task) releaseAction() string {
+	if task.terminationType == preemptedTerminationType {
+		return audit.ActionPreempt
+	}
+	return audit.ActionRelease
+}
@@ -0,0 +1,102 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/audit"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common"
+)
+
+func TestTaskAudit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+	assert.NilError(t, audit.Start(path, 1024*1024, 1))
+	defer audit.Stop()
+
+	mockedContext := initContextForTest()
+	created := time.Now().Add(-time.Hour)
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name:              "pod-audit-test-00001",
+			Namespace:         "default",
+			UID:               "UID-00001",
+			CreationTimestamp: apis.NewTime(created),
+		},
+	}
+	app := NewApplication("app01", "root.default",
+		"bob", map[string]string{}, newMockSchedulerAPI())
+	task := NewTask("task01", app, mockedContext, pod)
+	task.resource = common.NewResourceBuilder().
+		AddResource("memory", 100).
+		AddResource("vcore", 10).
+		Build()
+	task.nodeName = "node-1"
+	task.allocationUUID = "alloc-1"
+
+	now := time.Now()
+	task.markAskSent(now)
+	task.markAllocated(now)
+	task.audit(audit.ActionAllocate, now)
+	assert.Equal(t, task.releaseAction(), audit.ActionRelease)
+	task.setTaskTerminationType(preemptedTerminationType)
+	assert.Equal(t, task.releaseAction(), audit.ActionPreempt)
+	task.audit(task.releaseAction(), now.Add(time.Minute))
+
+	content, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Equal(t, len(lines), 2)
+	records := make([]*audit.Record, len(lines))
+	for i, line := range lines {
+		records[i] = &audit.Record{}
+		assert.NilError(t, json.Unmarshal([]byte(line), records[i]))
+	}
+
+	assert.Equal(t, records[0].Action, audit.ActionAllocate)
+	assert.Equal(t, records[0].ApplicationID, "app01")
+	assert.Equal(t, records[0].TaskID, "task01")
+	assert.Equal(t, records[0].Namespace, "default")
+	assert.Equal(t, records[0].PodName, "pod-audit-test-00001")
+	assert.Equal(t, records[0].PodUID, "UID-00001")
+	assert.Equal(t, records[0].Queue, "root.default")
+	assert.Equal(t, records[0].NodeID, "node-1")
+	assert.Equal(t, records[0].AllocationUUID, "alloc-1")
+	assert.DeepEqual(t, records[0].Resource, map[string]int64{"memory": 100, "vcore": 10})
+	assert.Assert(t, records[0].AllocatedTime == nil, "the allocated time is only set on releases")
+
+	assert.Equal(t, records[1].Action, audit.ActionPreempt)
+	assert.Equal(t, records[1].TerminationType, preemptedTerminationType)
+	assert.Assert(t, records[1].PodCreationTime.Equal(created))
+	assert.Assert(t, records[1].AllocatedTime.Equal(now))
+	assert.Equal(t, records[1].DurationSeconds, float64(60))
+}
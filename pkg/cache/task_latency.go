@@ -0,0 +1,88 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"time"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+)
+
+// the points in time a task passes on its way from the creation of the pod to running.
+// The pods do not have scheduling gates in this version of Kubernetes: the latency is
+// measured from the creation of the pod.
+type schedulingTimes struct {
+	askSent   time.Time
+	allocated time.Time
+	bound     time.Time
+	running   time.Time
+}
+
+// the ask of the task was sent to the core, the task lock must be held
+func (task *Task) markAskSent(now time.Time) {
+	if !task.schedulingTimes.askSent.IsZero() {
+		return
+	}
+	task.schedulingTimes.askSent = now
+	if !task.createTime.IsZero() {
+		task.observePhase(metrics.PhaseQueued, task.createTime, now)
+		task.recordSpan(spanQueued, task.createTime, now, nil)
+	}
+}
+
+// the core allocated the task, the task lock must be held
+func (task *Task) markAllocated(now time.Time) {
+	if task.schedulingTimes.askSent.IsZero() || !task.schedulingTimes.allocated.IsZero() {
+		return
+	}
+	task.schedulingTimes.allocated = now
+	task.observePhase(metrics.PhaseAllocation, task.schedulingTimes.askSent, now)
+	task.recordSpan(spanAllocation, task.schedulingTimes.askSent, now, nil)
+}
+
+// the pod of the task was bound to the node, the task lock must be held
+func (task *Task) markBound(now time.Time) {
+	if task.schedulingTimes.allocated.IsZero() || !task.schedulingTimes.bound.IsZero() {
+		return
+	}
+	task.schedulingTimes.bound = now
+	task.observePhase(metrics.PhaseBinding, task.schedulingTimes.allocated, now)
+	task.recordSpan(spanBind, task.schedulingTimes.allocated, now, nil)
+}
+
+// the pod of the task is running. Only the tasks scheduled by this instance of the
+// scheduler are measured: the tasks recovered after a restart have no ask sent.
+func (task *Task) markRunning(now time.Time) {
+	task.lock.Lock()
+	defer task.lock.Unlock()
+	if task.schedulingTimes.bound.IsZero() || !task.schedulingTimes.running.IsZero() {
+		return
+	}
+	task.schedulingTimes.running = now
+	task.observePhase(metrics.PhaseStartup, task.schedulingTimes.bound, now)
+	task.recordSpan(spanStartup, task.schedulingTimes.bound, now, nil)
+	if !task.createTime.IsZero() {
+		metrics.GetSchedulingMetrics().ObservePodLatency(task.application.GetQueue(), now.Sub(task.createTime))
+	}
+	task.endTrace(now, nil)
+}
+
+func (task *Task) observePhase(phase string, start, end time.Time) {
+	metrics.GetSchedulingMetrics().ObservePhaseLatency(task.application.GetQueue(), phase, end.Sub(start))
+}
@@ -0,0 +1,65 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTaskSchedulingTimes(t *testing.T) {
+	mockedContext := initContextForTest()
+	created := time.Now().Add(-time.Minute)
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name:              "pod-latency-test-00001",
+			UID:               "UID-00001",
+			CreationTimestamp: apis.NewTime(created),
+		},
+	}
+	app := NewApplication("app01", "root.default",
+		"bob", map[string]string{}, newMockSchedulerAPI())
+	task := NewTask("task01", app, mockedContext, pod)
+
+	// the phases are only measured in order
+	now := time.Now()
+	task.markAllocated(now)
+	task.markBound(now)
+	task.markRunning(now)
+	assert.Equal(t, task.schedulingTimes, schedulingTimes{})
+
+	task.markAskSent(now)
+	task.markAllocated(now.Add(time.Second))
+	task.markBound(now.Add(2 * time.Second))
+	task.markRunning(now.Add(3 * time.Second))
+	assert.Equal(t, task.schedulingTimes.askSent, now)
+	assert.Equal(t, task.schedulingTimes.allocated, now.Add(time.Second))
+	assert.Equal(t, task.schedulingTimes.bound, now.Add(2*time.Second))
+	assert.Equal(t, task.schedulingTimes.running, now.Add(3*time.Second))
+
+	// a resubmitted ask does not reset the times
+	task.markAskSent(now.Add(time.Hour))
+	task.markRunning(now.Add(time.Hour))
+	assert.Equal(t, task.schedulingTimes.askSent, now)
+	assert.Equal(t, task.schedulingTimes.running, now.Add(3*time.Second))
+}
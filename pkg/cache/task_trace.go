@@ -0,0 +1,94 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/dispatcher"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/tracing"
+)
+
+// the names of the spans of the trace of a pod, the root span covers the pod from its creation
+// until it runs, fails or completes. The children are the scheduling phases and the handling of
+// the dispatcher events of the task.
+const (
+	spanSchedulePod = "pod.schedule"
+	spanQueued      = "pod.queued"
+	spanAllocation  = "pod.allocation"
+	spanBind        = "pod.bind"
+	spanStartup     = "pod.startup"
+	spanHandleEvent = "dispatcher.handle"
+)
+
+func (task *Task) traceAttributes() map[string]string {
+	return map[string]string{
+		"k8s.pod.name":              task.alias,
+		"yunikorn.application.id":   task.applicationID,
+		"yunikorn.task.id":          task.taskID,
+		"yunikorn.queue":            task.application.GetQueue(),
+		"yunikorn.task.placeholder": fmt.Sprint(task.placeholder),
+	}
+}
+
+// records a span in the trace of the task, nothing is recorded when the task is not traced
+func (task *Task) recordSpan(name string, start, end time.Time, err error) {
+	if !task.trace.IsValid() {
+		return
+	}
+	tracing.RecordSpan(name, task.trace, start, end, task.traceAttributes(), err)
+}
+
+// records the root span of the trace of the task once, the task lock must be held
+func (task *Task) endTrace(end time.Time, err error) {
+	if !task.trace.IsValid() || task.traceEnded {
+		return
+	}
+	task.traceEnded = true
+	attributes := task.traceAttributes()
+	if task.nodeName != "" {
+		attributes["k8s.node.name"] = task.nodeName
+	}
+	tracing.RecordRootSpan(spanSchedulePod, task.trace, task.createTime, end, attributes, err)
+}
+
+// EventTracer records the handling of the dispatcher events of the traced tasks as spans
+// in the trace of the pod.
+func (ctx *Context) EventTracer() dispatcher.EventTracer {
+	return func(eventType string, event events.SchedulingEvent) func(outcome string) {
+		taskEvent, ok := event.(events.TaskEvent)
+		if !ok || !tracing.Enabled() {
+			return nil
+		}
+		task, err := ctx.getTask(taskEvent.GetApplicationID(), taskEvent.GetTaskID())
+		if err != nil || !task.trace.IsValid() {
+			return nil
+		}
+		start := time.Now()
+		return func(outcome string) {
+			var err error
+			if outcome != dispatcher.OutcomeSuccess {
+				err = fmt.Errorf("handling of event %s ended with %s", taskEvent.GetEvent(), outcome)
+			}
+			task.recordSpan(spanHandleEvent+" "+string(taskEvent.GetEvent()), start, time.Now(), err)
+		}
+	}
+}
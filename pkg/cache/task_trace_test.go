@@ -0,0 +1,85 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/tracing"
+)
+
+type recordingExporter struct {
+	spans []*tracing.Span
+	sync.Mutex
+}
+
+func (e *recordingExporter) Export(spans []*tracing.Span) error {
+	e.Lock()
+	defer e.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func TestTaskTrace(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracing.StartWithExporter(exporter, 1)
+	defer tracing.Stop()
+
+	mockedContext := initContextForTest()
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name:              "pod-trace-test-00001",
+			Namespace:         "default",
+			UID:               "UID-00001",
+			CreationTimestamp: apis.NewTime(time.Now().Add(-time.Minute)),
+		},
+	}
+	app := NewApplication("app01", "root.default",
+		"bob", map[string]string{}, newMockSchedulerAPI())
+	task := NewTask("task01", app, mockedContext, pod)
+	assert.Assert(t, task.trace.IsValid())
+
+	now := time.Now()
+	task.markAskSent(now)
+	task.markAllocated(now.Add(time.Second))
+	task.markBound(now.Add(2 * time.Second))
+	task.markRunning(now.Add(3 * time.Second))
+	// the root span is only recorded once
+	task.lock.Lock()
+	task.endTrace(now.Add(time.Hour), nil)
+	task.lock.Unlock()
+
+	tracing.Stop()
+	names := make([]string, 0)
+	for _, span := range exporter.spans {
+		assert.Equal(t, span.TraceID, task.trace.TraceID)
+		assert.Equal(t, span.Attributes["yunikorn.queue"], "root.default")
+		names = append(names, span.Name)
+	}
+	assert.DeepEqual(t, names, []string{spanQueued, spanAllocation, spanBind, spanStartup, spanSchedulePod})
+	root := exporter.spans[4]
+	assert.Equal(t, root.SpanContext, task.trace)
+	assert.Equal(t, root.End, now.Add(3*time.Second))
+}
@@ -0,0 +1,296 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/helper"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
+)
+
+// the aggregated reasons the pods cannot be scheduled
+const (
+	UnschedulableQuotaExceeded         = "QuotaExceeded"
+	UnschedulableInsufficientResources = "InsufficientResources"
+	UnschedulableGangWaiting           = "GangWaiting"
+	// followed by the name of the predicate plugin that did not accept the pod
+	UnschedulablePredicatePrefix = "Predicate/"
+)
+
+// maximum number of pods listed for each reason in the summary, all pods are counted
+const maxUnschedulablePods = 100
+
+// the last reason the core or the predicates gave for not scheduling a task
+type unschedulableReason struct {
+	reason  string
+	message string
+	since   time.Time
+}
+
+// UnschedulablePod is a pod that cannot be scheduled
+type UnschedulablePod struct {
+	Namespace     string    `json:"namespace"`
+	Name          string    `json:"name"`
+	ApplicationID string    `json:"applicationID"`
+	Queue         string    `json:"queue"`
+	Message       string    `json:"message,omitempty"`
+	Since         time.Time `json:"since"`
+}
+
+// UnschedulableReasonSummary lists the pods that cannot be scheduled for the same reason
+type UnschedulableReasonSummary struct {
+	Reason string `json:"reason"`
+	Count  int    `json:"count"`
+	// at most maxUnschedulablePods pods, the ones waiting longest first
+	Pods []*UnschedulablePod `json:"pods"`
+}
+
+// UnschedulableSummary groups the pods that cannot be scheduled by reason
+type UnschedulableSummary struct {
+	Total   int                           `json:"total"`
+	Reasons []*UnschedulableReasonSummary `json:"reasons"`
+}
+
+// remembers why the task cannot be scheduled, the reason is replaced when the task fails again
+// for another reason. It is ignored once the task is no longer waiting to be scheduled.
+func (task *Task) setUnschedulable(reason, message string) {
+	if current, ok := task.unschedulable.Load().(*unschedulableReason); ok && current.reason == reason {
+		// keep the time the task started to fail for this reason
+		task.unschedulable.Store(&unschedulableReason{reason: reason, message: message, since: current.since})
+		return
+	}
+	task.unschedulable.Store(&unschedulableReason{reason: reason, message: message, since: time.Now()})
+}
+
+func (task *Task) getUnschedulable() *unschedulableReason {
+	reason, ok := task.unschedulable.Load().(*unschedulableReason)
+	if !ok {
+		return nil
+	}
+	return reason
+}
+
+// returns the PodScheduled=False condition of the task, the details of the message are only collected
+// when the condition of the pod changes
+func (ctx *Context) unschedulableCondition(task *Task, reason, message string) *v1.PodCondition {
+	condition := &v1.PodCondition{
+		Type:    v1.PodScheduled,
+		Status:  v1.ConditionFalse,
+		Reason:  reason,
+		Message: message,
+	}
+	if task.GetTaskState() == events.States().Task.Scheduling && !utils.PodUnderCondition(task.GetTaskPod(), condition) {
+		condition.Message = ctx.describeUnschedulable(task, message)
+	}
+	return condition
+}
+
+// sets the PodScheduled=False condition with the Unschedulable reason the autoscaler scales up for. With a delay
+// configured the pod must have failed to schedule for lack of resources during the delay first: the pods that
+// only wait a moment in the queue do not trigger a scale up. The pod is checked again once the delay passed,
+// a pod that still fails gets the condition then, even when the core does not report the failure again.
+// The pods of the queues the scale up is disabled for get the ScaleUpDisabled reason the autoscaler ignores.
+func (ctx *Context) signalUnschedulable(task *Task, message string) {
+	schedulerConf := ctx.apiProvider.GetAPIs().Conf
+	if queue := task.application.GetQueue(); !schedulerConf.IsScaleUpEnabled(queue) {
+		if ctx.updatePodCondition(task, ctx.unschedulableCondition(task, "ScaleUpDisabled", message)) {
+			events.GetRecorder().Eventf(task.pod,
+				v1.EventTypeNormal, "PodUnschedulable",
+				"Task %s is pending for the requested resources become available, queue %s does not scale up the cluster",
+				task.alias, queue)
+		}
+		return
+	}
+	if delay := schedulerConf.UnschedulableDelay; delay > 0 {
+		recorded := task.getUnschedulable()
+		if recorded == nil || recorded.reason != UnschedulableInsufficientResources {
+			return
+		}
+		if waited := time.Since(recorded.since); waited < delay {
+			if _, scheduled := ctx.delayedSignals.LoadOrStore(task, true); !scheduled {
+				time.AfterFunc(delay-waited, func() {
+					defer ctx.delayedSignals.Delete(task)
+					if current := task.getUnschedulable(); current != nil && current.since == recorded.since {
+						ctx.signalUnschedulable(task, current.message)
+					}
+				})
+			}
+			return
+		}
+	}
+	if ctx.updatePodCondition(task, ctx.unschedulableCondition(task, v1.PodReasonUnschedulable, message)) {
+		events.GetRecorder().Eventf(task.pod,
+			v1.EventTypeNormal, "PodUnschedulable",
+			"Task %s is pending for the requested resources become available", task.alias)
+	}
+}
+
+// returns the reason of the core with the details the shim knows about the pod: the queue, the nodes the
+// node selector and affinity of the pod do not match, and the resources missing on the nodes that do match.
+// The cluster autoscaler and the operators read it from the condition message of the pod.
+func (ctx *Context) describeUnschedulable(task *Task, reason string) string {
+	details := make([]string, 0, 3)
+	if task.application != nil {
+		details = append(details, "queue: "+task.application.GetQueue())
+	}
+	pod := task.GetTaskPod()
+	free, _ := ctx.getNodeResources()
+	matched := make([]*si.Resource, 0, len(free))
+	for name, resource := range free {
+		node, err := ctx.schedulerCache.GetNodeInfo(name)
+		if err != nil || node == nil || helper.PodMatchesNodeSelectorAndAffinityTerms(pod, node) {
+			matched = append(matched, resource)
+		}
+	}
+	if unmatched := len(free) - len(matched); unmatched > 0 {
+		details = append(details, fmt.Sprintf("node selector and affinity: %d of %d nodes do not match", unmatched, len(free)))
+	}
+	if shortfall := getResourceShortfall(task.getTaskResource(), matched); shortfall != "" {
+		details = append(details, "shortfall: "+shortfall)
+	}
+	if len(details) == 0 {
+		return reason
+	}
+	return reason + " (" + strings.Join(details, "; ") + ")"
+}
+
+// returns the resources the request misses on the node that comes closest to each of them, e.g. "memory=512, vcore=250".
+// Empty when the request fits on one of the nodes, or when there are no nodes.
+func getResourceShortfall(request *si.Resource, nodes []*si.Resource) string {
+	if len(nodes) == 0 {
+		return ""
+	}
+	shortfall := make(map[string]int64)
+	for _, free := range nodes {
+		if common.FitIn(free, request) {
+			return ""
+		}
+		for name, quantity := range request.GetResources() {
+			missing := quantity.GetValue() - free.GetResources()[name].GetValue()
+			if current, ok := shortfall[name]; !ok || missing < current {
+				shortfall[name] = missing
+			}
+		}
+	}
+	names := make([]string, 0, len(shortfall))
+	for name, missing := range shortfall {
+		if missing > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, shortfall[name]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// records the predicate that did not accept the pod
+func (ctx *Context) setPredicateUnschedulable(pod *v1.Pod, plugin string, err error) {
+	appID, appErr := utils.GetApplicationIDFromPod(pod)
+	if appErr != nil {
+		return
+	}
+	app, ok := ctx.getApplication(appID)
+	if !ok {
+		return
+	}
+	task, ok := app.getTask(string(pod.UID))
+	if !ok {
+		return
+	}
+	if plugin == "" {
+		plugin = "Unknown"
+	}
+	task.setUnschedulable(UnschedulablePredicatePrefix+plugin, err.Error())
+}
+
+// GetUnschedulableSummary returns the pods that are waiting to be scheduled and cannot be scheduled,
+// grouped by reason. It is computed from the current state of the tasks on each call.
+func (ctx *Context) GetUnschedulableSummary() *UnschedulableSummary {
+	states := events.States()
+	byReason := make(map[string]*UnschedulableReasonSummary)
+	summary := &UnschedulableSummary{Reasons: make([]*UnschedulableReasonSummary, 0)}
+	ctx.lock.RLock()
+	for _, app := range ctx.applications {
+		app.lock.RLock()
+		gangWaiting := app.sm.Current() == states.Application.Reserving
+		for _, task := range app.getAllTasks() {
+			taskState := task.GetTaskState()
+			if taskState != states.Task.Pending && taskState != states.Task.Scheduling {
+				continue
+			}
+			pod := &UnschedulablePod{
+				Namespace:     task.pod.Namespace,
+				Name:          task.pod.Name,
+				ApplicationID: app.applicationID,
+				Queue:         app.queue,
+			}
+			var reason string
+			switch recorded := task.getUnschedulable(); {
+			case gangWaiting && !task.placeholder:
+				reason = UnschedulableGangWaiting
+				pod.Since = task.createTime
+			case recorded != nil:
+				reason = recorded.reason
+				pod.Message = recorded.message
+				pod.Since = recorded.since
+			default:
+				continue
+			}
+			group, ok := byReason[reason]
+			if !ok {
+				group = &UnschedulableReasonSummary{Reason: reason}
+				byReason[reason] = group
+				summary.Reasons = append(summary.Reasons, group)
+			}
+			group.Count++
+			group.Pods = append(group.Pods, pod)
+			summary.Total++
+		}
+		app.lock.RUnlock()
+	}
+	ctx.lock.RUnlock()
+
+	sort.Slice(summary.Reasons, func(i, j int) bool {
+		if summary.Reasons[i].Count != summary.Reasons[j].Count {
+			return summary.Reasons[i].Count > summary.Reasons[j].Count
+		}
+		return summary.Reasons[i].Reason < summary.Reasons[j].Reason
+	})
+	for _, group := range summary.Reasons {
+		sort.Slice(group.Pods, func(i, j int) bool {
+			return group.Pods[i].Since.Before(group.Pods[j].Since)
+		})
+		if len(group.Pods) > maxUnschedulablePods {
+			group.Pods = group.Pods[:maxUnschedulablePods]
+		}
+	}
+	return summary
+}
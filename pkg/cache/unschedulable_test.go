@@ -0,0 +1,294 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
+)
+
+func TestGetUnschedulableSummary(t *testing.T) {
+	context := initContextForTest()
+	states := events.States().Task
+	addTask := func(appID, taskID string, placeholder bool, state string) *Task {
+		if context.GetApplication(appID) == nil {
+			context.AddApplication(&interfaces.AddApplicationRequest{
+				Metadata: interfaces.ApplicationMetadata{
+					ApplicationID: appID,
+					QueueName:     "root.a",
+					User:          "test-user",
+				},
+			})
+		}
+		task := context.AddTask(&interfaces.AddTaskRequest{
+			Metadata: interfaces.TaskMetadata{
+				ApplicationID: appID,
+				TaskID:        taskID,
+				Placeholder:   placeholder,
+				Pod: &v1.Pod{
+					ObjectMeta: apis.ObjectMeta{
+						Name:      "pod-" + taskID,
+						Namespace: "default",
+						UID:       types.UID(taskID),
+						Labels:    map[string]string{constants.LabelApplicationID: appID},
+					},
+				},
+			},
+		}).(*Task)
+		task.sm.SetState(state)
+		return task
+	}
+
+	// nothing is known about pods that just started scheduling
+	addTask("app01", "task01", false, states.Scheduling)
+	assert.Equal(t, context.GetUnschedulableSummary().Total, 0)
+
+	for i := 2; i <= 4; i++ {
+		addTask("app01", fmt.Sprintf("task0%d", i), false, states.Scheduling)
+	}
+	context.HandleContainerStateUpdate(&si.UpdateContainerSchedulingStateRequest{
+		ApplicartionID: "app01",
+		AllocationKey:  "task01",
+		State:          si.UpdateContainerSchedulingStateRequest_SKIPPED,
+		Reason:         "queue root.a has exceeded its quota",
+	})
+	context.HandleContainerStateUpdate(&si.UpdateContainerSchedulingStateRequest{
+		ApplicartionID: "app01",
+		AllocationKey:  "task02",
+		State:          si.UpdateContainerSchedulingStateRequest_SKIPPED,
+		Reason:         "queue root.a has exceeded its quota",
+	})
+	context.HandleContainerStateUpdate(&si.UpdateContainerSchedulingStateRequest{
+		ApplicartionID: "app01",
+		AllocationKey:  "task03",
+		State:          si.UpdateContainerSchedulingStateRequest_FAILED,
+		Reason:         "no node has enough resources",
+	})
+	task4, err := context.getTask("app01", "task04")
+	assert.NilError(t, err)
+	context.lock.RLock()
+	context.setPredicateUnschedulable(task4.pod, "NodeAffinity", fmt.Errorf("node(s) didn't match node selector"))
+	context.lock.RUnlock()
+
+	// the members of a gang wait for the placeholders, the placeholders are not listed
+	addTask("app02", "task05", false, states.Pending)
+	addTask("app02", "task06", true, states.Scheduling)
+	context.GetApplication("app02").(*Application).sm.SetState(events.States().Application.Reserving)
+
+	// the tasks that are no longer waiting are not listed
+	done := addTask("app03", "task07", false, states.Scheduling)
+	done.setUnschedulable(UnschedulableInsufficientResources, "no node has enough resources")
+	done.sm.SetState(states.Bound)
+
+	summary := context.GetUnschedulableSummary()
+	assert.Equal(t, summary.Total, 5)
+	assert.Equal(t, len(summary.Reasons), 4)
+	assert.Equal(t, summary.Reasons[0].Reason, UnschedulableQuotaExceeded)
+	assert.Equal(t, summary.Reasons[0].Count, 2)
+	assert.Equal(t, summary.Reasons[0].Pods[0].Message, "queue root.a has exceeded its quota")
+	assert.Equal(t, summary.Reasons[0].Pods[0].Queue, "root.a")
+	assert.Equal(t, summary.Reasons[1].Reason, UnschedulableGangWaiting)
+	assert.Equal(t, summary.Reasons[1].Pods[0].Name, "pod-task05")
+	assert.Equal(t, summary.Reasons[2].Reason, UnschedulableInsufficientResources)
+	assert.Equal(t, summary.Reasons[2].Pods[0].Name, "pod-task03")
+	assert.Equal(t, summary.Reasons[3].Reason, UnschedulablePredicatePrefix+"NodeAffinity")
+	assert.Equal(t, summary.Reasons[3].Pods[0].Message, "node(s) didn't match node selector")
+}
+
+func TestSetUnschedulable(t *testing.T) {
+	mockedContext := initContextForTest()
+	app := NewApplication("app01", "root.default",
+		"bob", map[string]string{}, newMockSchedulerAPI())
+	task := NewTask("task01", app, mockedContext, &v1.Pod{})
+	assert.Assert(t, task.getUnschedulable() == nil)
+
+	task.setUnschedulable(UnschedulableQuotaExceeded, "first")
+	first := task.getUnschedulable()
+	assert.Equal(t, first.message, "first")
+
+	// the same reason keeps the time the task started to fail for it
+	task.setUnschedulable(UnschedulableQuotaExceeded, "second")
+	assert.Equal(t, task.getUnschedulable().message, "second")
+	assert.Equal(t, task.getUnschedulable().since, first.since)
+
+	task.setUnschedulable(UnschedulableInsufficientResources, "third")
+	assert.Equal(t, task.getUnschedulable().reason, UnschedulableInsufficientResources)
+}
+
+func TestDescribeUnschedulable(t *testing.T) {
+	context := initContextForTest()
+	for name, cpu := range map[string]int64{"node-a": 1000, "node-b": 8000} {
+		context.schedulerCache.AddNode(&v1.Node{
+			ObjectMeta: apis.ObjectMeta{Name: name, Labels: map[string]string{"zone": name}},
+		})
+		context.nodes.nodesMap[name] = newSchedulerNode(name, name, "",
+			common.NewResourceBuilder().AddResource(constants.CPU, cpu).AddResource(constants.Memory, 1000).Build(), nil, true)
+	}
+	context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: "app01",
+			QueueName:     "root.a",
+			User:          "test-user",
+		},
+	})
+	pod := newPodHelper("pod01", "default", "task01", "", v1.PodPending)
+	pod.Spec.NodeSelector = map[string]string{"zone": "node-a"}
+	task := context.AddTask(&interfaces.AddTaskRequest{
+		Metadata: interfaces.TaskMetadata{
+			ApplicationID: "app01",
+			TaskID:        "task01",
+			Pod:           pod,
+		},
+	}).(*Task)
+	task.resource = common.NewResourceBuilder().AddResource(constants.CPU, 1500).AddResource(constants.Memory, 500).Build()
+	assert.Equal(t, context.describeUnschedulable(task, "no node has enough resources"),
+		"no node has enough resources (queue: root.a; node selector and affinity: 1 of 2 nodes do not match; "+
+			"shortfall: vcore=500)")
+
+	// the request fits on a matching node, the pod is not placed for other reasons
+	pod.Spec.NodeSelector = nil
+	assert.Equal(t, context.describeUnschedulable(task, "queue root.a has exceeded its quota"),
+		"queue root.a has exceeded its quota (queue: root.a)")
+
+	// the condition carries the details when it changes
+	task.sm.SetState(events.States().Task.Scheduling)
+	pod.Spec.NodeSelector = map[string]string{"zone": "node-c"}
+	condition := context.unschedulableCondition(task, v1.PodReasonUnschedulable, "no node has enough resources")
+	assert.Equal(t, condition.Message, "no node has enough resources (queue: root.a; "+
+		"node selector and affinity: 2 of 2 nodes do not match)")
+}
+
+func TestGetResourceShortfall(t *testing.T) {
+	request := common.NewResourceBuilder().AddResource(constants.CPU, 1000).AddResource(constants.Memory, 1000).Build()
+	assert.Equal(t, getResourceShortfall(request, nil), "")
+	nodes := []*si.Resource{
+		common.NewResourceBuilder().AddResource(constants.CPU, 200).AddResource(constants.Memory, 900).Build(),
+		common.NewResourceBuilder().AddResource(constants.CPU, 800).AddResource(constants.Memory, 100).Build(),
+	}
+	assert.Equal(t, getResourceShortfall(request, nodes), "memory=100, vcore=200")
+	nodes = append(nodes, request)
+	assert.Equal(t, getResourceShortfall(request, nodes), "")
+}
+
+func TestSignalUnschedulableDelay(t *testing.T) {
+	context := initContextForTest()
+	context.apiProvider.GetAPIs().Conf.UnschedulableDelay = 100 * time.Millisecond
+	context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: "app01",
+			QueueName:     "root.a",
+			User:          "test-user",
+		},
+	})
+	task := context.AddTask(&interfaces.AddTaskRequest{
+		Metadata: interfaces.TaskMetadata{
+			ApplicationID: "app01",
+			TaskID:        "task01",
+			Pod:           newPodHelper("pod-task01", "default", "task01", "", v1.PodPending),
+		},
+	}).(*Task)
+	task.sm.SetState(events.States().Task.Scheduling)
+	failScheduling := func() {
+		context.HandleContainerStateUpdate(&si.UpdateContainerSchedulingStateRequest{
+			ApplicartionID: "app01",
+			AllocationKey:  "task01",
+			State:          si.UpdateContainerSchedulingStateRequest_FAILED,
+			Reason:         "no node has enough resources",
+		})
+	}
+	isDelayed := func() bool {
+		_, delayed := context.delayedSignals.Load(task)
+		return delayed
+	}
+
+	// the pod is not marked unschedulable during the delay, the failures during the delay are checked once
+	failScheduling()
+	failScheduling()
+	assert.Assert(t, isDelayed())
+
+	// the pod still failing is marked once the delay passed, without another failure of the core
+	err := utils.WaitForCondition(func() bool {
+		return !isDelayed()
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err)
+	_, condition := podutil.GetPodCondition(&task.pod.Status, v1.PodScheduled)
+	assert.Assert(t, condition != nil)
+	assert.Equal(t, condition.Reason, v1.PodReasonUnschedulable)
+
+	// a failure after the delay marks the pod right away
+	failScheduling()
+	assert.Assert(t, !isDelayed())
+}
+
+func TestSignalUnschedulableScaleUpDisabled(t *testing.T) {
+	context := initContextForTest()
+	context.apiProvider.GetAPIs().Conf.ScaleUpDisabledQueues = "root.best-effort"
+	for appID, queue := range map[string]string{"app01": "root.best-effort.batch", "app02": "root.a"} {
+		context.AddApplication(&interfaces.AddApplicationRequest{
+			Metadata: interfaces.ApplicationMetadata{
+				ApplicationID: appID,
+				QueueName:     queue,
+				User:          "test-user",
+			},
+		})
+	}
+	failScheduling := func(appID, taskID string) *Task {
+		task := context.AddTask(&interfaces.AddTaskRequest{
+			Metadata: interfaces.TaskMetadata{
+				ApplicationID: appID,
+				TaskID:        taskID,
+				Pod:           newPodHelper("pod-"+taskID, "default", taskID, "", v1.PodPending),
+			},
+		}).(*Task)
+		task.sm.SetState(events.States().Task.Scheduling)
+		context.HandleContainerStateUpdate(&si.UpdateContainerSchedulingStateRequest{
+			ApplicartionID: appID,
+			AllocationKey:  taskID,
+			State:          si.UpdateContainerSchedulingStateRequest_FAILED,
+			Reason:         "no node has enough resources",
+		})
+		return task
+	}
+
+	// the pods of a disabled queue and its children do not trigger the autoscaler
+	task := failScheduling("app01", "task01")
+	_, condition := podutil.GetPodCondition(&task.pod.Status, v1.PodScheduled)
+	assert.Assert(t, condition != nil)
+	assert.Equal(t, condition.Reason, "ScaleUpDisabled")
+	assert.Equal(t, task.getUnschedulable().reason, UnschedulableInsufficientResources)
+
+	task = failScheduling("app02", "task02")
+	_, condition = podutil.GetPodCondition(&task.pod.Status, v1.PodScheduled)
+	assert.Assert(t, condition != nil)
+	assert.Equal(t, condition.Reason, v1.PodReasonUnschedulable)
+}
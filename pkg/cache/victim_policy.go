@@ -0,0 +1,67 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+)
+
+// the victim policy is handed to the core on the asks, the core picks the victims and releases their
+// allocations. The shim evicts exactly the allocations the core released: evicting another pod in place
+// of a victim would free the resources of two allocations while the core accounts for one.
+// The prefer-placeholder policy tags the placeholders with the priority of their gang: a placeholder is
+// preempted before the real workloads only by the preemptors with a higher priority than its gang.
+func addVictimPolicyTags(tags map[string]string, placeholder bool, priority int32, policy string) {
+	if placeholder && policy == conf.VictimPolicyPreferPlaceholder {
+		tags[constants.TagPreemptFirst] = strconv.FormatInt(int64(priority), 10)
+	}
+}
+
+// returns true when the simulated victims follow the prefer-placeholder policy of the core
+func isPreferPlaceholder(ctx *Context) bool {
+	return ctx.apiProvider.GetAPIs().Conf.PreemptionVictimPolicy == conf.VictimPolicyPreferPlaceholder
+}
+
+// asks for the placeholder the preemption released again, the gang keeps its reservation complete while
+// it waits for the real pods
+func (task *Task) requestPlaceholder(name, taskGroupName string) {
+	app, ok := task.context.getApplication(task.applicationID)
+	if !ok {
+		return
+	}
+	if state := app.GetApplicationState(); state != events.States().Application.Reserving &&
+		state != events.States().Application.Running {
+		return
+	}
+	mgr := getPlaceholderManager()
+	if mgr == nil {
+		return
+	}
+	if err := mgr.recreatePlaceholder(app, name, taskGroupName); err != nil {
+		task.logger().Warn("failed to re-create preempted placeholder",
+			zap.String("placeholder", name),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,144 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/apis/yunikorn.apache.org/v1alpha1"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+)
+
+// adds an allocated task with the given priority and cpu to the node of the preemption victims
+func addPreemptionCandidate(context *Context, appID string, placeholder bool, priority int32, cpu int64) *Task {
+	task := addPreemptionVictim(context, appID)
+	task.placeholder = placeholder
+	task.pod.Spec.Priority = &priority
+	task.resource = common.NewResourceBuilder().AddResource(constants.CPU, cpu).Build()
+	return task
+}
+
+func TestVictimPolicyTags(t *testing.T) {
+	tags := make(map[string]string)
+	addVictimPolicyTags(tags, false, 10, conf.VictimPolicyPreferPlaceholder)
+	assert.Equal(t, len(tags), 0)
+	addVictimPolicyTags(tags, true, 10, conf.VictimPolicyDefault)
+	assert.Equal(t, len(tags), 0)
+	// only the preemptors with a higher priority than the gang preempt the placeholder first
+	addVictimPolicyTags(tags, true, 10, conf.VictimPolicyPreferPlaceholder)
+	assert.Equal(t, tags[constants.TagPreemptFirst], "10")
+}
+
+func TestPreemptTaskPreferPlaceholder(t *testing.T) {
+	recorder := record.NewFakeRecorder(1024)
+	events.SetRecorderForTest(recorder)
+	context := initContextForTest()
+	evicted := make([]string, 0)
+	apiProvider := context.apiProvider.(*client.MockedAPIProvider)
+	apiProvider.MockEvictFn(func(pod *v1.Pod, gracePeriodSeconds *int64) error {
+		evicted = append(evicted, pod.Name)
+		return nil
+	})
+	apiProvider.GetAPIs().Conf.PreemptionVictimPolicy = conf.VictimPolicyPreferPlaceholder
+	addSimulationNode(context, 4000)
+	victim := addPreemptionCandidate(context, "app-0001", false, 100, 1000)
+	placeholder := addPreemptionCandidate(context, "app-0002", true, 0, 1000)
+
+	// the core released the allocation of the victim: the victim is evicted and the placeholder keeps its
+	// allocation, nothing is reported as occupied so the queue usage of the core is unchanged
+	victim.preempt("root.a", false, "preempted by task-app-0003 of application app-0003")
+	assert.DeepEqual(t, evicted, []string{"pod-app-0001"})
+	assert.Assert(t, victim.preemption != nil)
+	assert.Assert(t, !victim.preemption.occupied)
+	assert.Assert(t, placeholder.preemption == nil)
+	assert.Equal(t, placeholder.GetTaskState(), events.States().Task.Bound)
+	node := context.nodes.getNode("node-0001")
+	assert.Assert(t, node != nil)
+	assert.Assert(t, common.IsZero(node.occupied))
+}
+
+func TestSimulatePreemptionPreferPlaceholder(t *testing.T) {
+	events.SetRecorderForTest(record.NewFakeRecorder(1024))
+	context := initContextForTest()
+	addSimulationNode(context, 2000)
+	addPreemptionCandidate(context, "app-0001", false, 10, 1000)
+	addPreemptionCandidate(context, "app-0002", true, 50, 1000)
+	addPendingTask(context, "app-0003", "task-0001", 100, 1000)
+
+	simulation := context.SimulatePreemption("default", "pod-task-0001")
+	assert.Equal(t, len(simulation.Victims), 1)
+	assert.Equal(t, simulation.Victims[0].Name, "pod-app-0001")
+
+	// the core is asked to preempt the placeholders first
+	context.apiProvider.GetAPIs().Conf.PreemptionVictimPolicy = conf.VictimPolicyPreferPlaceholder
+	simulation = context.SimulatePreemption("default", "pod-task-0001")
+	assert.Equal(t, len(simulation.Victims), 1)
+	assert.Equal(t, simulation.Victims[0].Name, "pod-app-0002")
+}
+
+func TestRequestPreemptedPlaceholder(t *testing.T) {
+	events.SetRecorderForTest(record.NewFakeRecorder(1024))
+	context := initContextForTest()
+	var lock sync.Mutex
+	created := make([]string, 0)
+	apiProvider := context.apiProvider.(*client.MockedAPIProvider)
+	apiProvider.MockCreateFn(func(pod *v1.Pod) (*v1.Pod, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		created = append(created, pod.Name)
+		return pod, nil
+	})
+	NewPlaceholderManager(apiProvider.GetAPIs())
+	placeholder := addPreemptionCandidate(context, "app-0001", true, 0, 1000)
+	placeholder.taskGroupName = "test-group-1"
+	app, ok := context.GetApplication("app-0001").(*Application)
+	assert.Assert(t, ok)
+	app.setTaskGroups([]v1alpha1.TaskGroup{
+		{
+			Name:        "test-group-1",
+			MinMember:   2,
+			MinResource: map[string]resource.Quantity{"cpu": resource.MustParse("1")},
+		},
+	})
+	app.SetState(events.States().Application.Reserving)
+	placeholder.preempt("root.a", false, "preempted by task-0002 of application app-0002")
+
+	placeholder.lock.Lock()
+	placeholder.releasePreemption()
+	placeholder.lock.Unlock()
+	err := utils.WaitForCondition(func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(created) == 1
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err)
+	assert.Equal(t, created[0], "pod-app-0001")
+}
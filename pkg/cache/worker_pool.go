@@ -0,0 +1,89 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"sync"
+)
+
+// without a configured size the workers are scaled with the number of nodes,
+// one worker for a number of nodes, between a minimum and a maximum
+const (
+	nodesPerBindWorker        = 20
+	minBindWorkers            = 16
+	maxBindWorkers            = 256
+	nodesPerPlaceholderWorker = 50
+	minPlaceholderWorkers     = 4
+	maxPlaceholderWorkers     = 64
+)
+
+// returns the configured number of workers, or the number scaled with the nodes when not configured
+func scaledWorkers(configured, nodes, nodesPerWorker, min, max int) int {
+	if configured > 0 {
+		return configured
+	}
+	workers := nodes / nodesPerWorker
+	if workers < min {
+		return min
+	}
+	if workers > max {
+		return max
+	}
+	return workers
+}
+
+// workerPool bounds the number of goroutines doing the same kind of work at the same time.
+// The size is evaluated on every acquire so that it follows the size of the cluster.
+type workerPool struct {
+	size    func() int
+	running int
+	cond    *sync.Cond
+}
+
+func newWorkerPool(size func() int) *workerPool {
+	return &workerPool{
+		size: size,
+		cond: sync.NewCond(&sync.Mutex{}),
+	}
+}
+
+// waits until a worker is free, every acquire must be followed by a release
+func (p *workerPool) acquire() {
+	p.cond.L.Lock()
+	defer p.cond.L.Unlock()
+	for p.running >= p.size() {
+		p.cond.Wait()
+	}
+	p.running++
+}
+
+func (p *workerPool) release() {
+	p.cond.L.Lock()
+	defer p.cond.L.Unlock()
+	p.running--
+	// the size may have grown, wake up all waiting goroutines
+	p.cond.Broadcast()
+}
+
+// returns the number of goroutines holding a worker
+func (p *workerPool) getRunning() int {
+	p.cond.L.Lock()
+	defer p.cond.L.Unlock()
+	return p.running
+}
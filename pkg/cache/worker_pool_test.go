@@ -0,0 +1,82 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestScaledWorkers(t *testing.T) {
+	// configured
+	assert.Equal(t, scaledWorkers(5, 5000, nodesPerBindWorker, minBindWorkers, maxBindWorkers), 5)
+	// scaled with the nodes
+	assert.Equal(t, scaledWorkers(0, 0, nodesPerBindWorker, minBindWorkers, maxBindWorkers), minBindWorkers)
+	assert.Equal(t, scaledWorkers(0, 1000, nodesPerBindWorker, minBindWorkers, maxBindWorkers), 50)
+	assert.Equal(t, scaledWorkers(0, 100000, nodesPerBindWorker, minBindWorkers, maxBindWorkers), maxBindWorkers)
+	assert.Equal(t, scaledWorkers(0, 10, nodesPerPlaceholderWorker, minPlaceholderWorkers, maxPlaceholderWorkers), minPlaceholderWorkers)
+}
+
+func TestWorkerPool(t *testing.T) {
+	size := 2
+	var lock sync.Mutex
+	pool := newWorkerPool(func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return size
+	})
+	pool.acquire()
+	pool.acquire()
+	assert.Equal(t, pool.getRunning(), 2)
+
+	acquired := make(chan struct{})
+	go func() {
+		pool.acquire()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("acquired a worker above the size of the pool")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// a free worker is taken by the waiting goroutine
+	pool.release()
+	<-acquired
+	assert.Equal(t, pool.getRunning(), 2)
+
+	// the pool follows its size
+	lock.Lock()
+	size = 3
+	lock.Unlock()
+	pool.acquire()
+	assert.Equal(t, pool.getRunning(), 3)
+	lock.Lock()
+	size = 1
+	lock.Unlock()
+	pool.release()
+	pool.release()
+	pool.release()
+	assert.Equal(t, pool.getRunning(), 0)
+	pool.acquire()
+	assert.Equal(t, pool.getRunning(), 1)
+}
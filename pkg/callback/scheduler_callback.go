@@ -75,7 +75,11 @@ func (callback *AsyncRMCallback) UpdateAllocation(response *si.AllocationRespons
 			zap.String("UUID", release.UUID))
 
 		// TerminationType 0 mean STOPPED_BY_RM
-		if release.TerminationType != si.TerminationType_STOPPED_BY_RM {
+		if release.TerminationType == si.TerminationType_PREEMPTED_BY_SCHEDULER {
+			// the victim is evicted, the release is confirmed when the pod is gone
+			ev := cache.NewPreemptAppAllocationEvent(release.ApplicationID, release.UUID, release.Message)
+			dispatcher.Dispatch(ev)
+		} else if release.TerminationType != si.TerminationType_STOPPED_BY_RM {
 			// send release app allocation to application states machine
 			ev := cache.NewReleaseAppAllocationEvent(release.ApplicationID, release.TerminationType, release.UUID)
 			dispatcher.Dispatch(ev)
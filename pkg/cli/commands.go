@@ -0,0 +1,390 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// the responses of the shim REST API, only the fields the commands show are decoded
+
+type taskInfo struct {
+	TaskID        string    `json:"taskID"`
+	ApplicationID string    `json:"applicationID"`
+	Namespace     string    `json:"namespace"`
+	PodName       string    `json:"podName"`
+	State         string    `json:"state"`
+	StateSince    time.Time `json:"stateSince"`
+	NodeName      string    `json:"nodeName"`
+	Placeholder   bool      `json:"placeholder"`
+	TaskGroup     string    `json:"taskGroup"`
+	Reason        string    `json:"reason"`
+	Message       string    `json:"message"`
+}
+
+type appInfo struct {
+	ApplicationID string         `json:"applicationID"`
+	Queue         string         `json:"queue"`
+	Partition     string         `json:"partition"`
+	User          string         `json:"user"`
+	State         string         `json:"state"`
+	StateSince    time.Time      `json:"stateSince"`
+	TaskStates    map[string]int `json:"taskStates"`
+	Tasks         []*taskInfo    `json:"tasks"`
+}
+
+type unschedulableSummary struct {
+	Total   int `json:"total"`
+	Reasons []struct {
+		Reason string `json:"reason"`
+		Count  int    `json:"count"`
+		Pods   []struct {
+			Namespace     string    `json:"namespace"`
+			Name          string    `json:"name"`
+			ApplicationID string    `json:"applicationID"`
+			Message       string    `json:"message"`
+			Since         time.Time `json:"since"`
+		} `json:"pods"`
+	} `json:"reasons"`
+}
+
+type maintenanceState struct {
+	Enabled bool       `json:"enabled"`
+	Since   *time.Time `json:"since"`
+	Reason  string     `json:"reason"`
+}
+
+type healthReport struct {
+	Score  float64 `json:"score"`
+	Status string  `json:"status"`
+	Checks []struct {
+		Name    string `json:"name"`
+		Healthy bool   `json:"healthy"`
+		Message string `json:"message"`
+	} `json:"checks"`
+}
+
+type reasonRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+type maintenanceRequest struct {
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// environment of a command
+type env struct {
+	client *client
+	out    io.Writer
+	// print the responses as JSON instead of tables
+	json bool
+}
+
+type command struct {
+	name    string
+	args    string
+	help    string
+	minArgs int
+	run     func(e *env, args []string) error
+}
+
+var commands = []*command{
+	{"apps", "", "list the applications with the number of tasks in each state", 0, listApps},
+	{"app", "<appID>", "show an application and its tasks", 1, showApp},
+	{"why", "<namespace>/<pod>", "show the state of a pod and why it is pending", 1, whyPending},
+	{"unschedulable", "", "list the pods that cannot be scheduled grouped by reason", 0, listUnschedulable},
+	{"health", "", "show the health of the shim", 0, showHealth},
+	{"version", "", "show the versions and the enabled features of the shim", 0, showVersion},
+	{"maintenance", "", "show whether the scheduling is paused", 0, showMaintenance},
+	{"pause", "[reason]", "pause the scheduling of all the applications (admin)", 0, pauseScheduling},
+	{"resume", "", "resume the scheduling (admin)", 0, resumeScheduling},
+	{"resync", "[reason]", "rebuild the caches of the shim from the api-server (admin)", 0, resync},
+	{"complete", "<appID> [reason]", "force an application to the Completed state (admin)", 1, forceComplete},
+	{"fail", "<appID> [reason]", "force an application to the Failed state (admin)", 1, forceFail},
+}
+
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+func printUsage(out io.Writer) {
+	fmt.Fprintln(out, "Usage: kubectl yunikorn [flags] <command> [args]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Commands:")
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %s %s\t%s\n", cmd.name, cmd.args, cmd.help)
+	}
+	w.Flush()
+}
+
+// the optional reason is the rest of the arguments
+func joinReason(args []string) string {
+	return strings.Join(args, " ")
+}
+
+// prints the response as indented JSON
+func printJSON(e *env, path string) error {
+	var raw json.RawMessage
+	if err := e.client.get(path, &raw); err != nil {
+		return err
+	}
+	return writeIndented(e.out, raw)
+}
+
+func writeIndented(out io.Writer, raw json.RawMessage) error {
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out, string(content))
+	return err
+}
+
+// formats the time since the moment, e.g. 5m3s
+func age(since time.Time) string {
+	if since.IsZero() {
+		return "-"
+	}
+	return time.Since(since).Truncate(time.Second).String()
+}
+
+// formats the task states, e.g. Bound=3,Pending=1
+func formatTaskStates(states map[string]int) string {
+	if len(states) == 0 {
+		return "-"
+	}
+	names := make([]string, 0, len(states))
+	for name := range states {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, states[name]))
+	}
+	return strings.Join(parts, ",")
+}
+
+func listApps(e *env, args []string) error {
+	if e.json {
+		return printJSON(e, "/ws/v1/apps")
+	}
+	var apps []*appInfo
+	if err := e.client.get("/ws/v1/apps", &apps); err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(e.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "APPLICATION\tQUEUE\tUSER\tSTATE\tAGE\tTASKS")
+	for _, app := range apps {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", app.ApplicationID, app.Queue, app.User, app.State,
+			age(app.StateSince), formatTaskStates(app.TaskStates))
+	}
+	return w.Flush()
+}
+
+func showApp(e *env, args []string) error {
+	path := "/ws/v1/apps/" + url.PathEscape(args[0])
+	if e.json {
+		return printJSON(e, path)
+	}
+	var app appInfo
+	if err := e.client.get(path, &app); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.out, "Application: %s\nQueue:       %s\nPartition:   %s\nUser:        %s\nState:       %s (%s)\n\n",
+		app.ApplicationID, app.Queue, app.Partition, app.User, app.State, age(app.StateSince))
+	w := tabwriter.NewWriter(e.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "POD\tSTATE\tAGE\tNODE\tTASK GROUP\tREASON")
+	for _, task := range app.Tasks {
+		name := task.Namespace + "/" + task.PodName
+		if task.Placeholder {
+			name += " (placeholder)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", name, task.State, age(task.StateSince),
+			orDash(task.NodeName), orDash(task.TaskGroup), orDash(task.Reason))
+	}
+	return w.Flush()
+}
+
+func whyPending(e *env, args []string) error {
+	parts := strings.SplitN(args[0], "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("pod must be given as <namespace>/<pod>, got %s", args[0])
+	}
+	path := "/ws/v1/pods/" + url.PathEscape(parts[0]) + "/" + url.PathEscape(parts[1])
+	if e.json {
+		return printJSON(e, path)
+	}
+	var task taskInfo
+	if err := e.client.get(path, &task); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.out, "Pod %s/%s of application %s is %s since %s\n",
+		task.Namespace, task.PodName, task.ApplicationID, task.State, age(task.StateSince))
+	switch {
+	case task.NodeName != "":
+		fmt.Fprintf(e.out, "Allocated on node %s\n", task.NodeName)
+	case task.Reason != "":
+		fmt.Fprintf(e.out, "Reason:  %s\n", task.Reason)
+		if task.Message != "" {
+			fmt.Fprintf(e.out, "Message: %s\n", task.Message)
+		}
+	default:
+		fmt.Fprintln(e.out, "No reason was reported for the pod, check the application and the queue in the core")
+	}
+	// the scheduling of all the pods is held back while paused
+	var state maintenanceState
+	if err := e.client.get("/ws/v1/maintenance", &state); err == nil && state.Enabled {
+		fmt.Fprintf(e.out, "The scheduling is paused: %s\n", state.Reason)
+	}
+	return nil
+}
+
+func listUnschedulable(e *env, args []string) error {
+	if e.json {
+		return printJSON(e, "/ws/v1/pods/unschedulable")
+	}
+	var summary unschedulableSummary
+	if err := e.client.get("/ws/v1/pods/unschedulable", &summary); err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(e.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "POD\tAPPLICATION\tREASON\tAGE\tMESSAGE")
+	for _, reason := range summary.Reasons {
+		for _, pod := range reason.Pods {
+			fmt.Fprintf(w, "%s/%s\t%s\t%s\t%s\t%s\n", pod.Namespace, pod.Name, pod.ApplicationID, reason.Reason,
+				age(pod.Since), orDash(pod.Message))
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.out, "%d pods cannot be scheduled\n", summary.Total)
+	return nil
+}
+
+func showHealth(e *env, args []string) error {
+	if e.json {
+		return printJSON(e, "/ws/v1/health")
+	}
+	var report healthReport
+	if err := e.client.get("/ws/v1/health", &report); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.out, "Status: %s (score %.2f)\n", report.Status, report.Score)
+	w := tabwriter.NewWriter(e.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tHEALTHY\tMESSAGE")
+	for _, check := range report.Checks {
+		fmt.Fprintf(w, "%s\t%t\t%s\n", check.Name, check.Healthy, orDash(check.Message))
+	}
+	return w.Flush()
+}
+
+func showVersion(e *env, args []string) error {
+	return printJSON(e, "/ws/v1/version")
+}
+
+func showMaintenance(e *env, args []string) error {
+	var state maintenanceState
+	if err := e.client.get("/ws/v1/maintenance", &state); err != nil {
+		return err
+	}
+	printMaintenance(e, &state)
+	return nil
+}
+
+func printMaintenance(e *env, state *maintenanceState) {
+	if !state.Enabled {
+		fmt.Fprintln(e.out, "Scheduling is running")
+		return
+	}
+	fmt.Fprintf(e.out, "Scheduling is paused since %s: %s\n", age(*state.Since), state.Reason)
+}
+
+func pauseScheduling(e *env, args []string) error {
+	var state maintenanceState
+	if err := e.client.post("/ws/v1/admin/maintenance", &maintenanceRequest{Enabled: true, Reason: joinReason(args)}, &state); err != nil {
+		return err
+	}
+	printMaintenance(e, &state)
+	return nil
+}
+
+func resumeScheduling(e *env, args []string) error {
+	var state maintenanceState
+	if err := e.client.post("/ws/v1/admin/maintenance", &maintenanceRequest{Enabled: false}, &state); err != nil {
+		return err
+	}
+	printMaintenance(e, &state)
+	return nil
+}
+
+func resync(e *env, args []string) error {
+	var raw json.RawMessage
+	if err := e.client.post("/ws/v1/admin/resync", &reasonRequest{Reason: joinReason(args)}, &raw); err != nil {
+		return err
+	}
+	return writeIndented(e.out, raw)
+}
+
+func forceComplete(e *env, args []string) error {
+	return forceState(e, args, "complete")
+}
+
+func forceFail(e *env, args []string) error {
+	return forceState(e, args, "fail")
+}
+
+func forceState(e *env, args []string, action string) error {
+	var result struct {
+		ApplicationID string `json:"applicationID"`
+		State         string `json:"state"`
+	}
+	path := "/ws/v1/admin/apps/" + url.PathEscape(args[0]) + "/" + action
+	if err := e.client.post(path, &reasonRequest{Reason: joinReason(args[1:])}, &result); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.out, "Application %s forced to %s\n", result.ApplicationID, result.State)
+	return nil
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,144 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+// fake shim web service, records the requests of the admin operations
+type fakeShim struct {
+	authorization string
+	body          map[string]interface{}
+}
+
+func (f *fakeShim) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws/v1/apps", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"applicationID":"app01","queue":"root.a","user":"alice","state":"Running",`+
+			`"taskStates":{"Bound":2,"Pending":1}}]`)
+	})
+	mux.HandleFunc("/ws/v1/apps/app01", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"applicationID":"app01","queue":"root.a","state":"Running","tasks":[`+
+			`{"taskID":"t1","namespace":"default","podName":"pod-1","state":"Bound","nodeName":"node01"},`+
+			`{"taskID":"t2","namespace":"default","podName":"pod-2","state":"Pending","reason":"QueueQuotaExceeded"}]}`)
+	})
+	mux.HandleFunc("/ws/v1/pods/default/pod-2", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"taskID":"t2","applicationID":"app01","namespace":"default","podName":"pod-2",`+
+			`"state":"Pending","reason":"QueueQuotaExceeded","message":"queue root.a is full"}`)
+	})
+	mux.HandleFunc("/ws/v1/maintenance", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"enabled":false}`)
+	})
+	mux.HandleFunc("/ws/v1/admin/maintenance", func(w http.ResponseWriter, r *http.Request) {
+		f.authorization = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&f.body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"enabled":true,"since":"2021-10-01T00:00:00Z","reason":"core upgrade"}`)
+	})
+	mux.HandleFunc("/ws/v1/admin/apps/app01/fail", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid admin token", http.StatusUnauthorized)
+	})
+	mux.HandleFunc("/ws/v1/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `{"score":0.4,"status":"unhealthy","checks":[{"name":"coreRegistered","healthy":false}]}`)
+	})
+	return mux
+}
+
+func runCLI(t *testing.T, server string, args ...string) (int, string, string) {
+	var out, errOut bytes.Buffer
+	getenv := func(name string) string {
+		if name == serverEnv {
+			return server
+		}
+		return ""
+	}
+	code := run(args, &out, &errOut, getenv)
+	return code, out.String(), errOut.String()
+}
+
+func TestCommands(t *testing.T) {
+	shim := &fakeShim{}
+	server := httptest.NewServer(shim.handler())
+	defer server.Close()
+
+	code, out, _ := runCLI(t, server.URL, "apps")
+	assert.Equal(t, code, 0)
+	assert.Assert(t, strings.Contains(out, "APPLICATION"), out)
+	assert.Assert(t, strings.Contains(out, "Bound=2,Pending=1"), out)
+
+	code, out, _ = runCLI(t, server.URL, "app", "app01")
+	assert.Equal(t, code, 0)
+	assert.Assert(t, strings.Contains(out, "default/pod-2"), out)
+	assert.Assert(t, strings.Contains(out, "QueueQuotaExceeded"), out)
+
+	code, out, _ = runCLI(t, server.URL, "why", "default/pod-2")
+	assert.Equal(t, code, 0)
+	assert.Assert(t, strings.Contains(out, "Reason:  QueueQuotaExceeded"), out)
+	assert.Assert(t, strings.Contains(out, "Message: queue root.a is full"), out)
+
+	code, out, _ = runCLI(t, server.URL, "-o", "json", "apps")
+	assert.Equal(t, code, 0)
+	assert.Assert(t, strings.Contains(out, `"applicationID": "app01"`), out)
+
+	// unhealthy is still reported
+	code, out, _ = runCLI(t, server.URL, "health")
+	assert.Equal(t, code, 0)
+	assert.Assert(t, strings.Contains(out, "Status: unhealthy"), out)
+}
+
+func TestAdminCommands(t *testing.T) {
+	shim := &fakeShim{}
+	server := httptest.NewServer(shim.handler())
+	defer server.Close()
+
+	code, out, _ := runCLI(t, server.URL, "-token", "secret", "pause", "core", "upgrade")
+	assert.Equal(t, code, 0)
+	assert.Equal(t, shim.authorization, "Bearer secret")
+	assert.DeepEqual(t, shim.body, map[string]interface{}{"enabled": true, "reason": "core upgrade"})
+	assert.Assert(t, strings.Contains(out, "Scheduling is paused"), out)
+
+	code, _, errOut := runCLI(t, server.URL, "fail", "app01")
+	assert.Equal(t, code, 1)
+	assert.Assert(t, strings.Contains(errOut, "401 Unauthorized"), errOut)
+}
+
+func TestUsage(t *testing.T) {
+	code, _, errOut := runCLI(t, "", "unknown")
+	assert.Equal(t, code, 2)
+	assert.Assert(t, strings.Contains(errOut, "Usage: kubectl yunikorn"), errOut)
+	code, _, _ = runCLI(t, "", "app")
+	assert.Equal(t, code, 2)
+	code, _, _ = runCLI(t, "", "-o", "yaml", "apps")
+	assert.Equal(t, code, 2)
+	code, _, errOut = runCLI(t, "", "why", "pod-2")
+	assert.Equal(t, code, 1)
+	assert.Assert(t, strings.Contains(errOut, "<namespace>/<pod>"), errOut)
+}
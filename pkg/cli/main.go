@@ -0,0 +1,91 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// kubectl-yunikorn is a command line client of the shim REST API. Installed on the PATH it is
+// also a kubectl plugin: kubectl yunikorn <command>. The admin commands need the admin token.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	defaultServer = "http://localhost:9090"
+	serverEnv     = "YUNIKORN_SHIM_URL"
+	tokenEnv      = "YUNIKORN_ADMIN_TOKEN"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr, os.Getenv))
+}
+
+// runs the command of the arguments, returns the exit code
+func run(args []string, out, errOut io.Writer, getenv func(string) string) int {
+	fs := flag.NewFlagSet("kubectl-yunikorn", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	server := fs.String("server", envOrDefault(getenv, serverEnv, defaultServer),
+		"URL of the shim web service, e.g. a port forwarded with kubectl port-forward, also set with "+serverEnv)
+	token := fs.String("token", getenv(tokenEnv),
+		"admin token of the shim, required by the admin commands, also set with "+tokenEnv)
+	timeout := fs.Duration("timeout", 30*time.Second, "timeout of the requests to the shim")
+	output := fs.String("o", "table", "output format of the list commands, table or json")
+	fs.Usage = func() {
+		printUsage(errOut)
+		fmt.Fprintln(errOut)
+		fmt.Fprintln(errOut, "Flags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(errOut, "output must be table or json, got %s\n", *output)
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	cmd := findCommand(fs.Arg(0))
+	cmdArgs := fs.Args()[1:]
+	if cmd == nil || len(cmdArgs) < cmd.minArgs {
+		fs.Usage()
+		return 2
+	}
+	e := &env{
+		client: newClient(*server, *token, *timeout),
+		out:    out,
+		json:   *output == "json",
+	}
+	if err := cmd.run(e, cmdArgs); err != nil {
+		fmt.Fprintf(errOut, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func envOrDefault(getenv func(string) string, name, defaultValue string) string {
+	if value := getenv(name); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -19,12 +19,19 @@
 package client
 
 import (
+	"os"
 	"sync"
 	"time"
 
 	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/informers"
+	coreInformerV1 "k8s.io/client-go/informers/core/v1"
+	policyInformerV1beta1 "k8s.io/client-go/informers/policy/v1beta1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/kubernetes/pkg/controller/volume/scheduling"
 	"k8s.io/kubernetes/pkg/features"
@@ -33,6 +40,7 @@ import (
 	appinformers "github.com/apache/incubator-yunikorn-k8shim/pkg/client/informers/externalversions"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/client/informers/externalversions/yunikorn.apache.org/v1alpha1"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
 	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/api"
@@ -48,6 +56,8 @@ const (
 	PVInformerHandlers
 	PVCInformerHandlers
 	ApplicationInformerHandlers
+	SecretInformerHandlers
+	NamespaceInformerHandlers
 )
 
 type APIProvider interface {
@@ -57,6 +67,9 @@ type APIProvider interface {
 	Stop()
 	WaitForSync() error
 	IsTestingMode() bool
+	// StartInformer starts an informer that is not started with the others, e.g. the informer
+	// of a feature that is enabled later. The call returns once the informer has synced.
+	StartInformer(name string, informer cache.SharedIndexInformer) error
 }
 
 // resource handlers defines add/update/delete operations in response to the corresponding resources updates.
@@ -72,17 +85,32 @@ type ResourceEventHandlers struct {
 // API factory maintains shared clients which can be used to access other external components
 // e.g K8s api-server, or scheduler-core.
 type APIFactory struct {
-	clients  *Clients
-	testMode bool
-	stopChan chan struct{}
-	lock     *sync.RWMutex
+	clients          *Clients
+	testMode         bool
+	stopChan         chan struct{}
+	startedInformers map[string]cache.SharedIndexInformer
+	lock             *sync.RWMutex
 }
 
 func NewAPIFactory(scheduler api.SchedulerAPI, configs *conf.SchedulerConf, testMode bool) *APIFactory {
 	kubeClient := NewKubeClient(configs.KubeConfig)
+	// the optional features the scheduler is not allowed to use are disabled before their informers are created
+	if !testMode {
+		if _, err := DisableDeniedFeatures(kubeClient.GetClientSet(), configs); err != nil {
+			log.Log(log.Client).Fatal("the scheduler is not allowed to use a required feature", zap.Error(err))
+		}
+	}
 
-	// we have disabled re-sync to keep ourselves up-to-date
-	informerFactory := informers.NewSharedInformerFactory(kubeClient.GetClientSet(), 0)
+	// we have disabled re-sync to keep ourselves up-to-date,
+	// unless a resync period is configured for a specific resource
+	informerFactory := informers.NewSharedInformerFactoryWithOptions(kubeClient.GetClientSet(), 0,
+		informers.WithCustomResyncConfig(getCustomResyncConfig(configs)))
+	// the pods and nodes are the bulk of the cached objects, strip them before caching.
+	// These informers can be restarted by the informer watchdog, the scheduling depends on them.
+	// The factory returns these informers for all later requests of the same type.
+	transform := newTransformFunc(configs)
+	informerFactory.InformerFor(&v1.Pod{}, newPodInformerFunc(transform))
+	informerFactory.InformerFor(&v1.Node{}, newNodeInformerFunc(transform))
 
 	// init informers
 	// volume informers are also used to get the Listers for the predicates
@@ -93,7 +121,6 @@ func NewAPIFactory(scheduler api.SchedulerAPI, configs *conf.SchedulerConf, test
 	csiNodeInformer := informerFactory.Storage().V1().CSINodes()
 	pvInformer := informerFactory.Core().V1().PersistentVolumes()
 	pvcInformer := informerFactory.Core().V1().PersistentVolumeClaims()
-	namespaceInformer := informerFactory.Core().V1().Namespaces()
 	var capacityCheck *scheduling.CapacityCheck
 	if utilfeature.DefaultFeatureGate.Enabled(features.CSIStorageCapacity) {
 		capacityCheck = &scheduling.CapacityCheck{
@@ -106,10 +133,43 @@ func NewAPIFactory(scheduler api.SchedulerAPI, configs *conf.SchedulerConf, test
 	var applicationInformer v1alpha1.ApplicationInformer = nil
 
 	if configs.IsOperatorPluginEnabled(constants.AppManagerHandlerName) {
-		appClient = appclient.NewForConfigOrDie(kubeClient.GetConfigs())
+		appClient = appclient.NewForConfigOrDie(GetCRDConfigs(kubeClient.GetConfigs()))
 		applicationInformer = appinformers.NewSharedInformerFactory(appClient, time.Minute*1).Apache().V1alpha1().Applications()
 	}
 
+	// namespaces are only watched when the namespace annotations are used
+	var namespaceInformer coreInformerV1.NamespaceInformer = nil
+	if configs.EnableNamespaceAnnotations {
+		namespaceInformer = informerFactory.Core().V1().Namespaces()
+	}
+
+	// the config Secret lives in the scheduler namespace, only this one Secret is watched
+	var secretInformer coreInformerV1.SecretInformer = nil
+	if configs.ConfigSecret != "" {
+		namespace := os.Getenv(constants.EnvSchedulerPodNamespace)
+		if namespace == "" {
+			namespace = constants.DefaultSchedulerNamespace
+		}
+		secretInformerFactory := informers.NewSharedInformerFactoryWithOptions(kubeClient.GetClientSet(), 0,
+			informers.WithNamespace(namespace),
+			informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.FieldSelector = fields.OneTermEqualSelector("metadata.name", configs.ConfigSecret).String()
+			}))
+		secretInformer = secretInformerFactory.Core().V1().Secrets()
+	}
+
+	// the disruption budgets are only watched when the protected preemption victims are skipped
+	var pdbInformer policyInformerV1beta1.PodDisruptionBudgetInformer = nil
+	if configs.PreemptionPDBPolicy == conf.PDBPolicySkip {
+		pdbInformer = informerFactory.Policy().V1beta1().PodDisruptionBudgets()
+	}
+
+	// the ProvisioningRequests of the cluster-autoscaler are only created when a provisioning class is set
+	var dynamicClient dynamic.Interface = nil
+	if configs.ProvisioningClass != "" {
+		dynamicClient = dynamic.NewForConfigOrDie(GetCRDConfigs(kubeClient.GetConfigs()))
+	}
+
 	// create a volume binder (needs the informers)
 	volumeBinder := scheduling.NewVolumeBinder(
 		kubeClient.GetClientSet(),
@@ -127,7 +187,8 @@ func NewAPIFactory(scheduler api.SchedulerAPI, configs *conf.SchedulerConf, test
 			Conf:              configs,
 			KubeClient:        kubeClient,
 			AppClient:         appClient,
-			SchedulerAPI:      scheduler,
+			DynamicClient:     dynamicClient,
+			SchedulerAPI:      newAskBatcher(newInstrumentedSchedulerAPI(scheduler), configs.AskBatchInterval, configs.AskBatchSize),
 			InformerFactory:   informerFactory,
 			PodInformer:       podInformer,
 			NodeInformer:      nodeInformer,
@@ -138,10 +199,13 @@ func NewAPIFactory(scheduler api.SchedulerAPI, configs *conf.SchedulerConf, test
 			StorageInformer:   storageInformer,
 			VolumeBinder:      volumeBinder,
 			AppInformer:       applicationInformer,
+			SecretInformer:    secretInformer,
+			PDBInformer:       pdbInformer,
 		},
-		testMode: testMode,
-		stopChan: make(chan struct{}),
-		lock:     &sync.RWMutex{},
+		testMode:         testMode,
+		stopChan:         make(chan struct{}),
+		startedInformers: make(map[string]cache.SharedIndexInformer),
+		lock:             &sync.RWMutex{},
 	}
 }
 
@@ -202,6 +266,12 @@ func (s *APIFactory) addEventHandlers(
 	case ApplicationInformerHandlers:
 		s.GetAPIs().AppInformer.Informer().
 			AddEventHandlerWithResyncPeriod(handler, resyncPeriod)
+	case SecretInformerHandlers:
+		s.GetAPIs().SecretInformer.Informer().
+			AddEventHandlerWithResyncPeriod(handler, resyncPeriod)
+	case NamespaceInformerHandlers:
+		s.GetAPIs().NamespaceInformer.Informer().
+			AddEventHandlerWithResyncPeriod(handler, resyncPeriod)
 	}
 }
 
@@ -216,16 +286,46 @@ func (s *APIFactory) WaitForSync() error {
 func (s *APIFactory) Start() {
 	// launch clients
 	if !s.IsTestingMode() {
+		// the watchdog must see the informers before they are started
+		for name, informer := range s.clients.getInformers() {
+			watchdog.watch(name, informer)
+		}
 		s.clients.Run(s.stopChan)
 		if err := s.clients.WaitForSync(time.Second, 30*time.Second); err != nil {
-			log.Logger().Warn("Failed to sync informers",
+			log.Log(log.Client).Warn("Failed to sync informers",
 				zap.Error(err))
 		}
+		if s.clients.Conf.InformerWatchdogInterval > 0 {
+			go watchdog.run(s.stopChan, s.clients.Conf.InformerWatchdogInterval,
+				s.clients.Conf.InformerFailureTimeout, s.clients.Conf.InformerStallTimeout)
+		}
+	}
+}
+
+func (s *APIFactory) StartInformer(name string, informer cache.SharedIndexInformer) error {
+	s.lock.Lock()
+	if _, ok := s.startedInformers[name]; ok {
+		s.lock.Unlock()
+		return nil
+	}
+	s.startedInformers[name] = informer
+	s.lock.Unlock()
+
+	if s.testMode {
+		return nil
 	}
+	log.Log(log.Client).Info("starting informer", zap.String("resource", name))
+	watchdog.watch(name, informer)
+	go informer.Run(s.stopChan)
+	return utils.WaitForCondition(informer.HasSynced, time.Second, 30*time.Second)
 }
 
 func (s *APIFactory) Stop() {
 	if !s.IsTestingMode() {
 		close(s.stopChan)
 	}
+	// the held back asks still reach the core
+	if batcher, ok := s.clients.SchedulerAPI.(*askBatcher); ok {
+		batcher.flush()
+	}
 }
@@ -0,0 +1,205 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// The shim updates the objects it does not own with server-side apply: only the fields the shim sets
+// are owned by its field manager, the fields set by the kubelet and other controllers are never changed.
+
+// returns the apply patch of a core object, the fields are added to the object metadata
+func newApplyPatch(kind, namespace, name string, fields map[string]interface{}) ([]byte, error) {
+	patch := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+		},
+	}
+	for key, value := range fields {
+		patch[key] = value
+	}
+	return json.Marshal(patch)
+}
+
+// applies the patch as the shim field manager. The fields owned by another manager are only taken over
+// after the apply failed with a conflict, the conflict is logged to show the change of ownership.
+func serverSideApply(resource, namespace, name string, apply func(options apis.PatchOptions) error) error {
+	noForce := false
+	err := apply(apis.PatchOptions{FieldManager: constants.FieldManager, Force: &noForce})
+	if err != nil && apierrors.IsConflict(err) {
+		log.Log(log.Client).Info("taking over fields owned by another field manager",
+			zap.String("resource", resource),
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+			zap.Error(err))
+		forced := true
+		err = apply(apis.PatchOptions{FieldManager: constants.FieldManager, Force: &forced})
+	}
+	return err
+}
+
+// returns the apply representation of the pod condition, unset fields are left out
+func podConditionFields(condition *v1.PodCondition) map[string]interface{} {
+	fields := map[string]interface{}{
+		"type":   condition.Type,
+		"status": condition.Status,
+	}
+	if condition.Reason != "" {
+		fields["reason"] = condition.Reason
+	}
+	if condition.Message != "" {
+		fields["message"] = condition.Message
+	}
+	if !condition.LastTransitionTime.IsZero() {
+		fields["lastTransitionTime"] = condition.LastTransitionTime
+	}
+	if !condition.LastProbeTime.IsZero() {
+		fields["lastProbeTime"] = condition.LastProbeTime
+	}
+	return fields
+}
+
+func applyPodStatus(clientSet kubernetes.Interface, pod *v1.Pod, status map[string]interface{}) (*v1.Pod, error) {
+	patch, err := newApplyPatch("Pod", pod.Namespace, pod.Name, map[string]interface{}{"status": status})
+	if err != nil {
+		return nil, err
+	}
+	var applied *v1.Pod
+	err = serverSideApply("pods", pod.Namespace, pod.Name, func(options apis.PatchOptions) error {
+		var applyErr error
+		applied, applyErr = clientSet.CoreV1().Pods(pod.Namespace).Patch(context.Background(), pod.Name,
+			types.ApplyPatchType, patch, options, "status")
+		return applyErr
+	})
+	return applied, err
+}
+
+// ApplyPodCondition sets the condition in the pod status, the other conditions of the pod are not changed.
+func ApplyPodCondition(clientSet kubernetes.Interface, pod *v1.Pod, condition *v1.PodCondition) (*v1.Pod, error) {
+	return applyPodStatus(clientSet, pod, map[string]interface{}{
+		"conditions": []interface{}{podConditionFields(condition)},
+	})
+}
+
+// ApplyPodStatus sets the phase, the reason, the message and the conditions of the pod status.
+// The container statuses and the other fields the kubelet sets are not changed.
+func ApplyPodStatus(clientSet kubernetes.Interface, pod *v1.Pod) (*v1.Pod, error) {
+	status := make(map[string]interface{})
+	if pod.Status.Phase != "" {
+		status["phase"] = pod.Status.Phase
+	}
+	if pod.Status.Reason != "" {
+		status["reason"] = pod.Status.Reason
+	}
+	if pod.Status.Message != "" {
+		status["message"] = pod.Status.Message
+	}
+	if len(pod.Status.Conditions) > 0 {
+		conditions := make([]interface{}, 0, len(pod.Status.Conditions))
+		for i := range pod.Status.Conditions {
+			conditions = append(conditions, podConditionFields(&pod.Status.Conditions[i]))
+		}
+		status["conditions"] = conditions
+	}
+	return applyPodStatus(clientSet, pod, status)
+}
+
+// ApplyPodNominatedNode sets the node the pod is nominated to in the pod status, an empty name clears it.
+func ApplyPodNominatedNode(clientSet kubernetes.Interface, pod *v1.Pod, nodeName string) (*v1.Pod, error) {
+	return applyPodStatus(clientSet, pod, map[string]interface{}{"nominatedNodeName": nodeName})
+}
+
+// ApplyPodAnnotations sets the annotations on the pod, the other annotations of the pod are not changed.
+func ApplyPodAnnotations(clientSet kubernetes.Interface, pod *v1.Pod, annotations map[string]string) (*v1.Pod, error) {
+	patch, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name":        pod.Name,
+			"namespace":   pod.Namespace,
+			"annotations": annotations,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var applied *v1.Pod
+	err = serverSideApply("pods", pod.Namespace, pod.Name, func(options apis.PatchOptions) error {
+		var applyErr error
+		applied, applyErr = clientSet.CoreV1().Pods(pod.Namespace).Patch(context.Background(), pod.Name,
+			types.ApplyPatchType, patch, options)
+		return applyErr
+	})
+	return applied, err
+}
+
+// ApplyNodeAnnotations sets the annotations on the node. The annotations the shim set before that are left
+// out are removed, the annotations set by the others are not changed.
+func ApplyNodeAnnotations(clientSet kubernetes.Interface, name string, annotations map[string]string) (*v1.Node, error) {
+	patch, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Node",
+		"metadata": map[string]interface{}{
+			"name":        name,
+			"annotations": annotations,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var applied *v1.Node
+	err = serverSideApply("nodes", "", name, func(options apis.PatchOptions) error {
+		var applyErr error
+		applied, applyErr = clientSet.CoreV1().Nodes().Patch(context.Background(), name,
+			types.ApplyPatchType, patch, options)
+		return applyErr
+	})
+	return applied, err
+}
+
+// ApplyConfigMapData sets the data keys in the configmap, the keys not in the data are not changed.
+func ApplyConfigMapData(clientSet kubernetes.Interface, namespace, name string, data map[string]string) (*v1.ConfigMap, error) {
+	patch, err := newApplyPatch("ConfigMap", namespace, name, map[string]interface{}{"data": data})
+	if err != nil {
+		return nil, err
+	}
+	var applied *v1.ConfigMap
+	err = serverSideApply("configmaps", namespace, name, func(options apis.PatchOptions) error {
+		var applyErr error
+		applied, applyErr = clientSet.CoreV1().ConfigMaps(namespace).Patch(context.Background(), name,
+			types.ApplyPatchType, patch, options)
+		return applyErr
+	})
+	return applied, err
+}
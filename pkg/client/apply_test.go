@@ -0,0 +1,161 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+)
+
+func TestNewApplyPatch(t *testing.T) {
+	patch, err := newApplyPatch("ConfigMap", "default", "yunikorn-configs",
+		map[string]interface{}{"data": map[string]string{"queues.yaml": "data"}})
+	assert.NilError(t, err)
+	var obj map[string]interface{}
+	assert.NilError(t, json.Unmarshal(patch, &obj))
+	assert.Equal(t, obj["apiVersion"], "v1")
+	assert.Equal(t, obj["kind"], "ConfigMap")
+	assert.DeepEqual(t, obj["metadata"], map[string]interface{}{"name": "yunikorn-configs", "namespace": "default"})
+	assert.DeepEqual(t, obj["data"], map[string]interface{}{"queues.yaml": "data"})
+}
+
+func TestServerSideApply(t *testing.T) {
+	var calls []apis.PatchOptions
+	conflict := apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "yunikorn-configs", nil)
+	err := serverSideApply("configmaps", "default", "yunikorn-configs", func(options apis.PatchOptions) error {
+		calls = append(calls, options)
+		if len(calls) == 1 {
+			return conflict
+		}
+		return nil
+	})
+	assert.NilError(t, err)
+	// forced only after the conflict
+	assert.Equal(t, len(calls), 2)
+	assert.Equal(t, calls[0].FieldManager, constants.FieldManager)
+	assert.Assert(t, !*calls[0].Force)
+	assert.Equal(t, calls[1].FieldManager, constants.FieldManager)
+	assert.Assert(t, *calls[1].Force)
+}
+
+func TestApplyPodCondition(t *testing.T) {
+	clientSet := newFakeClientSet()
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{Name: "pod-1", Namespace: "default"},
+		Status: v1.PodStatus{
+			Phase: v1.PodPending,
+			Conditions: []v1.PodCondition{
+				{Type: v1.PodReady, Status: v1.ConditionFalse},
+			},
+		},
+	}
+	_, err := clientSet.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, apis.CreateOptions{})
+	assert.NilError(t, err)
+
+	applied, err := ApplyPodCondition(clientSet, pod, &v1.PodCondition{
+		Type:   v1.PodScheduled,
+		Status: v1.ConditionFalse,
+		Reason: v1.PodReasonUnschedulable,
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, applied.Status.Phase, v1.PodPending)
+	assert.Equal(t, len(applied.Status.Conditions), 2)
+	for _, condition := range applied.Status.Conditions {
+		if condition.Type == v1.PodScheduled {
+			assert.Equal(t, condition.Reason, v1.PodReasonUnschedulable)
+		}
+	}
+}
+
+func TestApplyPodAnnotations(t *testing.T) {
+	clientSet := newFakeClientSet()
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name:        "pod-1",
+			Namespace:   "default",
+			Annotations: map[string]string{"owner": "team-a"},
+		},
+	}
+	_, err := clientSet.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, apis.CreateOptions{})
+	assert.NilError(t, err)
+
+	applied, err := ApplyPodAnnotations(clientSet, pod, map[string]string{"yunikorn.apache.org/test": "value"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, applied.Annotations, map[string]string{
+		"owner":                    "team-a",
+		"yunikorn.apache.org/test": "value",
+	})
+}
+
+func TestApplyPodNominatedNode(t *testing.T) {
+	clientSet := newFakeClientSet()
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{Name: "pod-1", Namespace: "default"},
+		Status:     v1.PodStatus{Phase: v1.PodPending},
+	}
+	_, err := clientSet.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, apis.CreateOptions{})
+	assert.NilError(t, err)
+
+	applied, err := ApplyPodNominatedNode(clientSet, pod, "node-1")
+	assert.NilError(t, err)
+	assert.Equal(t, applied.Status.NominatedNodeName, "node-1")
+	assert.Equal(t, applied.Status.Phase, v1.PodPending)
+}
+
+func TestApplyNodeAnnotations(t *testing.T) {
+	clientSet := newFakeClientSet()
+	node := &v1.Node{
+		ObjectMeta: apis.ObjectMeta{
+			Name:        "node-1",
+			Annotations: map[string]string{"owner": "team-a"},
+		},
+	}
+	_, err := clientSet.CoreV1().Nodes().Create(context.Background(), node, apis.CreateOptions{})
+	assert.NilError(t, err)
+
+	applied, err := ApplyNodeAnnotations(clientSet, "node-1", map[string]string{"yunikorn.apache.org/test": "true"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, applied.Annotations, map[string]string{
+		"owner":                    "team-a",
+		"yunikorn.apache.org/test": "true",
+	})
+}
+
+func TestApplyConfigMapData(t *testing.T) {
+	clientSet := newFakeClientSet()
+	configMap := &v1.ConfigMap{
+		ObjectMeta: apis.ObjectMeta{Name: "yunikorn-configs", Namespace: "default"},
+		Data:       map[string]string{"queues.yaml": "old", "other": "value"},
+	}
+	_, err := clientSet.CoreV1().ConfigMaps(configMap.Namespace).Create(context.Background(), configMap, apis.CreateOptions{})
+	assert.NilError(t, err)
+
+	applied, err := ApplyConfigMapData(clientSet, "default", "yunikorn-configs", map[string]string{"queues.yaml": "new"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, applied.Data, map[string]string{"queues.yaml": "new", "other": "value"})
+}
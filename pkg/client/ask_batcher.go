@@ -0,0 +1,149 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/api"
+	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
+)
+
+// askBatcher holds back the allocation requests that only carry asks and sends them to the core
+// in one request, after the interval or once the batch is full. An ask replaces the held back ask
+// with the same allocation key. All the other calls send the held back asks first: a release or a
+// removal of an application never overtakes the asks it applies to.
+type askBatcher struct {
+	api.SchedulerAPI
+	interval time.Duration
+	maxAsks  int
+
+	// the held back asks in the order they arrived, and their index by allocation key
+	asks  []*si.AllocationAsk
+	index map[string]int
+	rmID  string
+	timer *time.Timer
+	lock  sync.Mutex
+	// serializes the requests to the core, taken before lock
+	sendLock sync.Mutex
+}
+
+func newAskBatcher(scheduler api.SchedulerAPI, interval time.Duration, maxAsks int) api.SchedulerAPI {
+	if scheduler == nil || interval <= 0 {
+		return scheduler
+	}
+	return &askBatcher{
+		SchedulerAPI: scheduler,
+		interval:     interval,
+		maxAsks:      maxAsks,
+		index:        make(map[string]int),
+	}
+}
+
+// UpdateAllocation holds back a request that only carries asks, the error of the batched request
+// is logged as the caller has already returned.
+func (b *askBatcher) UpdateAllocation(request *si.AllocationRequest) error {
+	if len(request.Asks) == 0 || request.Releases != nil {
+		b.sendLock.Lock()
+		defer b.sendLock.Unlock()
+		b.sendHeldBack()
+		return b.SchedulerAPI.UpdateAllocation(request)
+	}
+
+	b.lock.Lock()
+	if len(b.asks) != 0 && b.rmID != request.RmID {
+		b.lock.Unlock()
+		b.flush()
+		b.lock.Lock()
+	}
+	b.rmID = request.RmID
+	for _, ask := range request.Asks {
+		if i, ok := b.index[ask.AllocationKey]; ok {
+			b.asks[i] = ask
+			metrics.GetCoreAPIMetrics().IncCoalescedAsks()
+			continue
+		}
+		b.index[ask.AllocationKey] = len(b.asks)
+		b.asks = append(b.asks, ask)
+	}
+	full := len(b.asks) >= b.maxAsks
+	if !full && b.timer == nil {
+		b.timer = time.AfterFunc(b.interval, b.flush)
+	}
+	b.lock.Unlock()
+
+	if full {
+		b.flush()
+	}
+	return nil
+}
+
+func (b *askBatcher) UpdateApplication(request *si.ApplicationRequest) error {
+	b.sendLock.Lock()
+	defer b.sendLock.Unlock()
+	b.sendHeldBack()
+	return b.SchedulerAPI.UpdateApplication(request)
+}
+
+// sends the held back asks
+func (b *askBatcher) flush() {
+	b.sendLock.Lock()
+	defer b.sendLock.Unlock()
+	b.sendHeldBack()
+}
+
+// sends the held back asks in one request, the send lock must be held. When the batch fails the asks are
+// sent one by one, as they would have been without the batching, so that one failure does not lose them all.
+func (b *askBatcher) sendHeldBack() {
+	b.lock.Lock()
+	asks := b.asks
+	rmID := b.rmID
+	b.asks = nil
+	b.index = make(map[string]int)
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.lock.Unlock()
+
+	if len(asks) == 0 {
+		return
+	}
+	metrics.GetCoreAPIMetrics().ObserveAskBatch(len(asks))
+	err := b.SchedulerAPI.UpdateAllocation(&si.AllocationRequest{Asks: asks, RmID: rmID})
+	if err == nil {
+		return
+	}
+	log.Log(log.Client).Warn("failed to send the batched allocation asks to the core, sending them one by one",
+		zap.Int("asks", len(asks)),
+		zap.Error(err))
+	for _, ask := range asks {
+		if err = b.SchedulerAPI.UpdateAllocation(&si.AllocationRequest{Asks: []*si.AllocationAsk{ask}, RmID: rmID}); err != nil {
+			log.Log(log.Client).Error("failed to send the allocation ask to the core",
+				zap.String("applicationID", ask.ApplicationID),
+				zap.String("allocationKey", ask.AllocationKey),
+				zap.Error(err))
+		}
+	}
+}
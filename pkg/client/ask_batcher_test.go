@@ -0,0 +1,152 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/test"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
+)
+
+// records the requests the batcher sends to the core
+type sentRequests struct {
+	requests []*si.AllocationRequest
+	sync.Mutex
+}
+
+func (s *sentRequests) record(request *si.AllocationRequest) error {
+	s.Lock()
+	defer s.Unlock()
+	s.requests = append(s.requests, request)
+	return nil
+}
+
+func (s *sentRequests) get() []*si.AllocationRequest {
+	s.Lock()
+	defer s.Unlock()
+	return append([]*si.AllocationRequest(nil), s.requests...)
+}
+
+func askRequest(keys ...string) *si.AllocationRequest {
+	request := &si.AllocationRequest{RmID: "rm-1"}
+	for _, key := range keys {
+		request.Asks = append(request.Asks, &si.AllocationAsk{AllocationKey: key, ApplicationID: "app-1"})
+	}
+	return request
+}
+
+func TestAskBatcherDisabled(t *testing.T) {
+	assert.Assert(t, newAskBatcher(nil, time.Second, 10) == nil)
+	mock := test.NewSchedulerAPIMock()
+	_, ok := newAskBatcher(mock, 0, 10).(*askBatcher)
+	assert.Assert(t, !ok)
+}
+
+func TestAskBatcherBatchSize(t *testing.T) {
+	sent := &sentRequests{}
+	mock := test.NewSchedulerAPIMock().UpdateAllocationFunction(sent.record)
+	batcher := newAskBatcher(mock, time.Hour, 3)
+
+	assert.NilError(t, batcher.UpdateAllocation(askRequest("task-1")))
+	assert.NilError(t, batcher.UpdateAllocation(askRequest("task-2")))
+	// the ask replaces the held back ask with the same key
+	updated := askRequest("task-1")
+	updated.Asks[0].MaxAllocations = 2
+	assert.NilError(t, batcher.UpdateAllocation(updated))
+	assert.Equal(t, len(sent.get()), 0)
+
+	assert.NilError(t, batcher.UpdateAllocation(askRequest("task-3")))
+	requests := sent.get()
+	assert.Equal(t, len(requests), 1)
+	assert.Equal(t, requests[0].RmID, "rm-1")
+	assert.Equal(t, len(requests[0].Asks), 3)
+	assert.Equal(t, requests[0].Asks[0].AllocationKey, "task-1")
+	assert.Equal(t, requests[0].Asks[0].MaxAllocations, int32(2))
+	assert.Equal(t, requests[0].Asks[2].AllocationKey, "task-3")
+}
+
+func TestAskBatcherInterval(t *testing.T) {
+	sent := &sentRequests{}
+	mock := test.NewSchedulerAPIMock().UpdateAllocationFunction(sent.record)
+	batcher := newAskBatcher(mock, 10*time.Millisecond, 100)
+
+	assert.NilError(t, batcher.UpdateAllocation(askRequest("task-1", "task-2")))
+	assert.NilError(t, utils.WaitForCondition(func() bool {
+		return len(sent.get()) == 1
+	}, time.Millisecond, time.Second))
+	assert.Equal(t, len(sent.get()[0].Asks), 2)
+}
+
+func TestAskBatcherOrdering(t *testing.T) {
+	sent := &sentRequests{}
+	mock := test.NewSchedulerAPIMock().UpdateAllocationFunction(sent.record)
+	batcher := newAskBatcher(mock, time.Hour, 100)
+
+	// the release is sent after the ask it releases
+	assert.NilError(t, batcher.UpdateAllocation(askRequest("task-1")))
+	release := &si.AllocationRequest{
+		RmID: "rm-1",
+		Releases: &si.AllocationReleasesRequest{
+			AllocationAsksToRelease: []*si.AllocationAskRelease{{Allocationkey: "task-1"}},
+		},
+	}
+	assert.NilError(t, batcher.UpdateAllocation(release))
+	requests := sent.get()
+	assert.Equal(t, len(requests), 2)
+	assert.Equal(t, requests[0].Asks[0].AllocationKey, "task-1")
+	assert.Assert(t, requests[1] == release)
+
+	// the removal of an application is sent after its asks
+	assert.NilError(t, batcher.UpdateAllocation(askRequest("task-2")))
+	assert.NilError(t, batcher.UpdateApplication(&si.ApplicationRequest{RmID: "rm-1"}))
+	assert.Equal(t, len(sent.get()), 3)
+	assert.Equal(t, mock.GetUpdateApplicationCount(), int32(1))
+
+	// held back asks are sent on stop
+	assert.NilError(t, batcher.UpdateAllocation(askRequest("task-3")))
+	batcher.(*askBatcher).flush()
+	assert.Equal(t, len(sent.get()), 4)
+}
+
+func TestAskBatcherFailedBatch(t *testing.T) {
+	sent := &sentRequests{}
+	// the core rejects the batch, the asks are sent one by one
+	mock := test.NewSchedulerAPIMock().UpdateAllocationFunction(func(request *si.AllocationRequest) error {
+		if len(request.Asks) > 1 {
+			return fmt.Errorf("batch rejected")
+		}
+		return sent.record(request)
+	})
+	batcher := newAskBatcher(mock, time.Hour, 100)
+
+	assert.NilError(t, batcher.UpdateAllocation(askRequest("task-1", "task-2")))
+	batcher.(*askBatcher).flush()
+	requests := sent.get()
+	assert.Equal(t, len(requests), 2)
+	assert.Equal(t, requests[0].Asks[0].AllocationKey, "task-1")
+	assert.Equal(t, requests[1].Asks[0].AllocationKey, "task-2")
+	assert.Equal(t, requests[1].RmID, "rm-1")
+}
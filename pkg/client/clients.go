@@ -21,16 +21,22 @@ package client
 import (
 	"time"
 
+	"go.uber.org/zap"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/client/informers/externalversions/yunikorn.apache.org/v1alpha1"
 
 	"k8s.io/client-go/informers"
 	coreInformerV1 "k8s.io/client-go/informers/core/v1"
+	policyInformerV1beta1 "k8s.io/client-go/informers/policy/v1beta1"
 	storageInformerV1 "k8s.io/client-go/informers/storage/v1"
 	"k8s.io/kubernetes/pkg/controller/volume/scheduling"
 
 	appclient "github.com/apache/incubator-yunikorn-k8shim/pkg/client/clientset/versioned"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
 	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/api"
 )
 
@@ -45,6 +51,8 @@ type Clients struct {
 	KubeClient   KubeClient
 	SchedulerAPI api.SchedulerAPI
 	AppClient    appclient.Interface
+	// the client of the resources without a typed client, nil when no feature needs it
+	DynamicClient dynamic.Interface
 
 	// informer factory
 	InformerFactory informers.SharedInformerFactory
@@ -58,34 +66,55 @@ type Clients struct {
 	StorageInformer   storageInformerV1.StorageClassInformer
 	NamespaceInformer coreInformerV1.NamespaceInformer
 	AppInformer       v1alpha1.ApplicationInformer
+	SecretInformer    coreInformerV1.SecretInformer
+	PDBInformer       policyInformerV1beta1.PodDisruptionBudgetInformer
 
 	// volume binder handles PV/PVC related operations
 	VolumeBinder scheduling.SchedulerVolumeBinder
 }
 
+// returns the informers of the enabled features, keyed by the resource name.
+// The informers of disabled features are nil and are never started.
+func (c *Clients) getInformers() map[string]cache.SharedIndexInformer {
+	informers := map[string]cache.SharedIndexInformer{
+		"nodes":                  c.NodeInformer.Informer(),
+		"pods":                   c.PodInformer.Informer(),
+		"persistentvolumes":      c.PVInformer.Informer(),
+		"persistentvolumeclaims": c.PVCInformer.Informer(),
+		"storageclasses":         c.StorageInformer.Informer(),
+		"configmaps":             c.ConfigMapInformer.Informer(),
+	}
+	if c.NamespaceInformer != nil {
+		informers["namespaces"] = c.NamespaceInformer.Informer()
+	}
+	if c.AppInformer != nil {
+		informers["applications"] = c.AppInformer.Informer()
+	}
+	if c.SecretInformer != nil {
+		informers["secrets"] = c.SecretInformer.Informer()
+	}
+	if c.PDBInformer != nil {
+		informers["poddisruptionbudgets"] = c.PDBInformer.Informer()
+	}
+	return informers
+}
+
 func (c *Clients) WaitForSync(interval time.Duration, timeout time.Duration) error {
+	informers := c.getInformers()
 	return utils.WaitForCondition(func() bool {
 		// cache is re-sync'd when all informers are sync'd
-		return c.NodeInformer.Informer().HasSynced() &&
-			c.PodInformer.Informer().HasSynced() &&
-			c.PVCInformer.Informer().HasSynced() &&
-			c.PVInformer.Informer().HasSynced() &&
-			c.StorageInformer.Informer().HasSynced() &&
-			c.ConfigMapInformer.Informer().HasSynced() &&
-			c.NamespaceInformer.Informer().HasSynced() &&
-			(c.AppInformer == nil || c.AppInformer.Informer().HasSynced())
+		for _, informer := range informers {
+			if !informer.HasSynced() {
+				return false
+			}
+		}
+		return true
 	}, interval, timeout)
 }
 
 func (c *Clients) Run(stopCh <-chan struct{}) {
-	go c.NodeInformer.Informer().Run(stopCh)
-	go c.PodInformer.Informer().Run(stopCh)
-	go c.PVInformer.Informer().Run(stopCh)
-	go c.PVCInformer.Informer().Run(stopCh)
-	go c.StorageInformer.Informer().Run(stopCh)
-	go c.ConfigMapInformer.Informer().Run(stopCh)
-	go c.NamespaceInformer.Informer().Run(stopCh)
-	if c.AppInformer != nil {
-		go c.AppInformer.Informer().Run(stopCh)
+	for name, informer := range c.getInformers() {
+		log.Log(log.Client).Debug("starting informer", zap.String("resource", name))
+		go informer.Run(stopCh)
 	}
 }
@@ -0,0 +1,44 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestGetInformers(t *testing.T) {
+	clients := NewMockedAPIProvider().GetAPIs()
+	informers := clients.getInformers()
+	_, ok := informers["namespaces"]
+	assert.Assert(t, ok, "namespace informer expected")
+	_, ok = informers["secrets"]
+	assert.Assert(t, !ok, "secret informer is not configured")
+
+	// disabled features have no informer
+	clients.NamespaceInformer = nil
+	clients.AppInformer = nil
+	informers = clients.getInformers()
+	_, ok = informers["namespaces"]
+	assert.Assert(t, !ok, "namespace informer is disabled")
+	_, ok = informers["applications"]
+	assert.Assert(t, !ok, "application informer is disabled")
+	assert.Equal(t, len(informers), 6)
+}
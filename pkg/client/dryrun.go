@@ -0,0 +1,50 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+)
+
+// IsDryRun returns true when the shim runs in dry-run mode,
+// the cluster is watched as usual but nothing is changed in it.
+func IsDryRun() bool {
+	return conf.GetSchedulerConf().DryRun
+}
+
+// SkipMutation returns true when the mutation must not be sent to the api-server.
+// In dry-run mode the mutation is logged and counted instead, the caller handles it as a success.
+func SkipMutation(verb, resource, namespace, name string, fields ...zap.Field) bool {
+	if !IsDryRun() {
+		return false
+	}
+	metrics.GetKubeClientMetrics().IncDryRunCalls(verb, resource)
+	log.Log(log.Client).Info("dry-run: skipping api-server call",
+		append([]zap.Field{
+			zap.String("verb", verb),
+			zap.String("resource", resource),
+			zap.String("namespace", namespace),
+			zap.String("name", name),
+		}, fields...)...)
+	return true
+}
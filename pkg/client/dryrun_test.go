@@ -0,0 +1,37 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+)
+
+func TestSkipMutation(t *testing.T) {
+	schedulerConf := conf.GetSchedulerConf()
+	assert.Assert(t, !SkipMutation("bind", "pods", "default", "pod-1"), "mutation skipped without dry-run")
+
+	schedulerConf.DryRun = true
+	defer func() { schedulerConf.DryRun = false }()
+	assert.Assert(t, IsDryRun())
+	assert.Assert(t, SkipMutation("bind", "pods", "default", "pod-1"), "mutation not skipped in dry-run")
+}
@@ -0,0 +1,101 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// the placeholder in an identity that is replaced by the namespace of the pod
+const namespacePlaceholder = "{namespace}"
+
+// the clients that impersonate the identities configured for the tenant namespaces. The pods are deleted
+// and evicted as the identity of their namespace: the api-server audit logs attribute the calls to the
+// identity and the RBAC of the namespace can constrain what the scheduler is allowed to do.
+type podImpersonation struct {
+	// the configs the impersonating clients are copied from
+	config *rest.Config
+	// identities keyed by namespace, the "*" key matches the namespaces that are not listed
+	identities map[string]string
+	// the impersonating clients share one rate limit, however many namespaces there are
+	rateLimiter flowcontrol.RateLimiter
+	clients     map[string]kubernetes.Interface
+	sync.Mutex
+}
+
+func newPodImpersonation(config *rest.Config, identities map[string]string) *podImpersonation {
+	if len(identities) == 0 {
+		return nil
+	}
+	// the identities name the tenants and their service accounts, they are only logged at debug
+	log.Log(log.Client).Info("pod deletes and evictions impersonate the namespace identities",
+		zap.Int("namespaces", len(identities)))
+	log.Log(log.Client).Debug("pod impersonation identities",
+		zap.Any("identities", identities))
+	// unset limits fall back to the client-go defaults like the clients without a shared rate limiter
+	qps, burst := config.QPS, config.Burst
+	if qps == 0 {
+		qps = rest.DefaultQPS
+	}
+	if burst == 0 {
+		burst = rest.DefaultBurst
+	}
+	return &podImpersonation{
+		config:      config,
+		identities:  identities,
+		rateLimiter: flowcontrol.NewTokenBucketRateLimiter(qps, burst),
+		clients:     make(map[string]kubernetes.Interface),
+	}
+}
+
+// returns the identity impersonated for the pods of the namespace, empty if the scheduler acts as itself
+func (p *podImpersonation) getIdentity(namespace string) string {
+	identity, ok := p.identities[namespace]
+	if !ok {
+		identity = p.identities["*"]
+	}
+	return strings.ReplaceAll(identity, namespacePlaceholder, namespace)
+}
+
+// returns the client that impersonates the identity, the clients are created on first use
+func (p *podImpersonation) getClientSet(identity string) (kubernetes.Interface, error) {
+	p.Lock()
+	defer p.Unlock()
+	if clientSet, ok := p.clients[identity]; ok {
+		return clientSet, nil
+	}
+	config := rest.CopyConfig(p.config)
+	// the api-server adds the service account groups when the identity is a service account
+	config.Impersonate = rest.ImpersonationConfig{UserName: identity}
+	config.RateLimiter = p.rateLimiter
+	clientSet, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	p.clients[identity] = clientSet
+	return clientSet, nil
+}
@@ -0,0 +1,76 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestPodImpersonationIdentity(t *testing.T) {
+	assert.Assert(t, newPodImpersonation(&rest.Config{}, map[string]string{}) == nil)
+	impersonation := newPodImpersonation(&rest.Config{}, map[string]string{
+		"tenant": "system:serviceaccount:tenant:evictor",
+		"*":      "system:serviceaccount:{namespace}:yunikorn",
+	})
+	assert.Equal(t, impersonation.getIdentity("tenant"), "system:serviceaccount:tenant:evictor")
+	assert.Equal(t, impersonation.getIdentity("other"), "system:serviceaccount:other:yunikorn")
+
+	impersonation = newPodImpersonation(&rest.Config{}, map[string]string{"tenant": "evictor"})
+	assert.Equal(t, impersonation.getIdentity("other"), "")
+	first, err := impersonation.getClientSet("evictor")
+	assert.NilError(t, err)
+	second, err := impersonation.getClientSet("evictor")
+	assert.NilError(t, err)
+	assert.Equal(t, first, second, "the client of an identity is created once")
+}
+
+func TestDeleteImpersonation(t *testing.T) {
+	var lock sync.Mutex
+	users := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		users[r.URL.Path] = r.Header.Get("Impersonate-User")
+		lock.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Success"}`))
+	}))
+	defer server.Close()
+	config := &rest.Config{Host: server.URL}
+	kubeClient := SchedulerKubeClient{
+		clientSet:     kubernetes.NewForConfigOrDie(config),
+		configs:       config,
+		impersonation: newPodImpersonation(config, map[string]string{"tenant": "system:serviceaccount:tenant:yunikorn"}),
+	}
+
+	assert.NilError(t, kubeClient.Delete(&v1.Pod{ObjectMeta: apis.ObjectMeta{Namespace: "tenant", Name: "pod-1"}}))
+	assert.NilError(t, kubeClient.Delete(&v1.Pod{ObjectMeta: apis.ObjectMeta{Namespace: "other", Name: "pod-2"}}))
+	assert.DeepEqual(t, users, map[string]string{
+		"/api/v1/namespaces/tenant/pods/pod-1": "system:serviceaccount:tenant:yunikorn",
+		"/api/v1/namespaces/other/pods/pod-2":  "",
+	})
+}
@@ -0,0 +1,162 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// yunikorn annotations are used for scheduling, these are never removed from the cached objects
+const yunikornAnnotationPrefix = "yunikorn.apache.org/"
+
+// TransformFunc modifies an object before it is stored in the informer cache,
+// the client-go version in use has no transform support on the informers.
+type TransformFunc func(obj runtime.Object)
+
+// returns the transform that removes the fields the shim does not use from the cached objects,
+// or nil when nothing needs to be removed.
+func newTransformFunc(configs *conf.SchedulerConf) TransformFunc {
+	if !configs.StripManagedFields && configs.MaxAnnotationSize == 0 {
+		return nil
+	}
+	stripManagedFields := configs.StripManagedFields
+	maxAnnotationSize := configs.MaxAnnotationSize
+	return func(obj runtime.Object) {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return
+		}
+		if stripManagedFields {
+			accessor.SetManagedFields(nil)
+		}
+		if maxAnnotationSize > 0 {
+			annotations := accessor.GetAnnotations()
+			for key, value := range annotations {
+				if len(value) > maxAnnotationSize && !strings.HasPrefix(key, yunikornAnnotationPrefix) {
+					delete(annotations, key)
+				}
+			}
+		}
+	}
+}
+
+// returns a list watch that applies the transform to all the listed and watched objects
+func newTransformingListWatch(lw *cache.ListWatch, transform TransformFunc) *cache.ListWatch {
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			list, err := lw.ListFunc(options)
+			if err != nil {
+				return nil, err
+			}
+			if err = meta.EachListItem(list, func(obj runtime.Object) error {
+				transform(obj)
+				return nil
+			}); err != nil {
+				log.Log(log.Client).Warn("failed to transform listed objects", zap.Error(err))
+			}
+			return list, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			w, err := lw.WatchFunc(options)
+			if err != nil {
+				return nil, err
+			}
+			return watch.Filter(w, func(event watch.Event) (watch.Event, bool) {
+				if event.Type != watch.Error && event.Type != watch.Bookmark {
+					transform(event.Object)
+				}
+				return event, true
+			}), nil
+		},
+	}
+}
+
+func newPodInformerFunc(transform TransformFunc) func(kubernetes.Interface, time.Duration) cache.SharedIndexInformer {
+	return func(client kubernetes.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+		lw := &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.CoreV1().Pods(metav1.NamespaceAll).List(context.Background(), options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return client.CoreV1().Pods(metav1.NamespaceAll).Watch(context.Background(), options)
+			},
+		}
+		if transform != nil {
+			lw = newTransformingListWatch(lw, transform)
+		}
+		return newRestartableInformer("pods", lw, &v1.Pod{}, resyncPeriod)
+	}
+}
+
+func newNodeInformerFunc(transform TransformFunc) func(kubernetes.Interface, time.Duration) cache.SharedIndexInformer {
+	return func(client kubernetes.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+		lw := &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return client.CoreV1().Nodes().List(context.Background(), options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return client.CoreV1().Nodes().Watch(context.Background(), options)
+			},
+		}
+		if transform != nil {
+			lw = newTransformingListWatch(lw, transform)
+		}
+		return newRestartableInformer("nodes", lw, &v1.Node{}, resyncPeriod)
+	}
+}
+
+// returns the informer resync periods keyed by the object type, as used by the informer factory
+func getCustomResyncConfig(configs *conf.SchedulerConf) map[metav1.Object]time.Duration {
+	periods, err := configs.GetInformerResyncPeriods()
+	if err != nil {
+		log.Log(log.Client).Warn("ignoring invalid informer resync periods", zap.Error(err))
+		return nil
+	}
+	objects := map[string]metav1.Object{
+		"pods":                   &v1.Pod{},
+		"nodes":                  &v1.Node{},
+		"configmaps":             &v1.ConfigMap{},
+		"persistentvolumes":      &v1.PersistentVolume{},
+		"persistentvolumeclaims": &v1.PersistentVolumeClaim{},
+		"storageclasses":         &storagev1.StorageClass{},
+		"namespaces":             &v1.Namespace{},
+	}
+	resyncConfig := make(map[metav1.Object]time.Duration)
+	for resource, period := range periods {
+		if obj, ok := objects[resource]; ok {
+			resyncConfig[obj] = period
+		}
+	}
+	return resyncConfig
+}
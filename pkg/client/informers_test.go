@@ -0,0 +1,122 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+)
+
+func newPodWithMetadata(name string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			ManagedFields: []metav1.ManagedFieldsEntry{
+				{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationApply},
+			},
+			Annotations: map[string]string{
+				"kubectl.kubernetes.io/last-applied-configuration": strings.Repeat("x", 100),
+				"yunikorn.apache.org/task-groups":                  strings.Repeat("y", 100),
+				"small":                                            "value",
+			},
+		},
+	}
+}
+
+func TestTransformFunc(t *testing.T) {
+	assert.Assert(t, newTransformFunc(&conf.SchedulerConf{}) == nil)
+
+	transform := newTransformFunc(&conf.SchedulerConf{StripManagedFields: true, MaxAnnotationSize: 10})
+	pod := newPodWithMetadata("pod-1")
+	transform(pod)
+	assert.Equal(t, len(pod.ManagedFields), 0)
+	assert.Equal(t, len(pod.Annotations), 2)
+	assert.Equal(t, pod.Annotations["small"], "value")
+	assert.Equal(t, len(pod.Annotations["yunikorn.apache.org/task-groups"]), 100)
+
+	// only the managed fields are removed
+	transform = newTransformFunc(&conf.SchedulerConf{StripManagedFields: true})
+	pod = newPodWithMetadata("pod-2")
+	transform(pod)
+	assert.Equal(t, len(pod.ManagedFields), 0)
+	assert.Equal(t, len(pod.Annotations), 3)
+}
+
+func TestTransformingPodInformer(t *testing.T) {
+	client := fake.NewSimpleClientset(newPodWithMetadata("pod-1"))
+	transform := newTransformFunc(&conf.SchedulerConf{StripManagedFields: true, MaxAnnotationSize: 10})
+	informer := newPodInformerFunc(transform)(client, 0)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go informer.Run(stopCh)
+
+	// listed object
+	assert.NilError(t, waitForPod(informer.GetStore().GetByKey, "default/pod-1"))
+	obj, _, err := informer.GetStore().GetByKey("default/pod-1")
+	assert.NilError(t, err)
+	pod, ok := obj.(*v1.Pod)
+	assert.Assert(t, ok)
+	assert.Equal(t, len(pod.ManagedFields), 0)
+	assert.Equal(t, len(pod.Annotations), 2)
+
+	// watched object
+	_, err = client.CoreV1().Pods("default").Create(context.Background(), newPodWithMetadata("pod-2"), metav1.CreateOptions{})
+	assert.NilError(t, err)
+	assert.NilError(t, waitForPod(informer.GetStore().GetByKey, "default/pod-2"))
+	obj, _, err = informer.GetStore().GetByKey("default/pod-2")
+	assert.NilError(t, err)
+	pod, ok = obj.(*v1.Pod)
+	assert.Assert(t, ok)
+	assert.Equal(t, len(pod.ManagedFields), 0)
+	assert.Equal(t, len(pod.Annotations), 2)
+}
+
+func waitForPod(get func(string) (interface{}, bool, error), key string) error {
+	return utils.WaitForCondition(func() bool {
+		_, exists, err := get(key)
+		return err == nil && exists
+	}, 10*time.Millisecond, 5*time.Second)
+}
+
+func TestGetCustomResyncConfig(t *testing.T) {
+	resyncConfig := getCustomResyncConfig(&conf.SchedulerConf{InformerResyncPeriods: "pods=1m,nodes=0s"})
+	assert.Equal(t, len(resyncConfig), 2)
+	for obj, period := range resyncConfig {
+		switch obj.(type) {
+		case *v1.Pod:
+			assert.Equal(t, period, time.Minute)
+		case *v1.Node:
+			assert.Equal(t, period, time.Duration(0))
+		default:
+			t.Errorf("unexpected object type %T", obj)
+		}
+	}
+	assert.Assert(t, getCustomResyncConfig(&conf.SchedulerConf{InformerResyncPeriods: "invalid"}) == nil)
+}
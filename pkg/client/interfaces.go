@@ -34,6 +34,9 @@ type KubeClient interface {
 	// Delete a pod from a host
 	Delete(pod *v1.Pod) error
 
+	// Evict a pod through the eviction API, a nil grace period uses the grace period of the pod
+	Evict(pod *v1.Pod, gracePeriodSeconds *int64) error
+
 	// Update the status of a pod
 	UpdateStatus(pod *v1.Pod) (*v1.Pod, error)
 
@@ -20,55 +20,192 @@ package client
 
 import (
 	"context"
+	"net"
+	"time"
 
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	// OIDC auth provider for kubeconfig files, exec credential plugins are built in
+	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/client-go/util/retry"
 
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
 )
 
+// the status cause the eviction API sets when a PodDisruptionBudget refuses the eviction
+const disruptionBudgetCause apis.CauseType = "DisruptionBudget"
+
 type SchedulerKubeClient struct {
 	clientSet *kubernetes.Clientset
 	configs   *rest.Config
+	// dedicated client for the latency-critical calls, nil when these use the default client
+	bindClientSet *kubernetes.Clientset
+	// impersonates the namespace identities to delete and evict pods, nil when the scheduler acts as itself
+	impersonation *podImpersonation
 }
 
 func newSchedulerKubeClient(kc string) SchedulerKubeClient {
 	schedulerConf := conf.GetSchedulerConf()
-	// using kube config
+	var config *rest.Config
+	var err error
 	if kc != "" {
-		config, err := clientcmd.BuildConfigFromFlags("", kc)
+		// using kube config
+		config, err = loadKubeConfig(kc, schedulerConf.KubeContext)
+		if err != nil {
+			log.Log(log.Client).Fatal("failed to create kubeClient configs", zap.Error(err))
+		}
+	} else {
+		// using in cluster config
+		config, err = rest.InClusterConfig()
 		if err != nil {
-			log.Logger().Fatal("failed to create kubeClient configs", zap.Error(err))
+			log.Log(log.Client).Fatal("failed to get InClusterConfig", zap.Error(err))
 		}
-		config.QPS = float32(schedulerConf.KubeQPS)
-		config.Burst = schedulerConf.KubeBurst
-		configuredClient := kubernetes.NewForConfigOrDie(config)
-		return SchedulerKubeClient{
-			clientSet: configuredClient,
-			configs:   config,
+	}
+	applyClientConfigs(config, schedulerConf)
+	configuredClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		log.Log(log.Client).Fatal("failed to get Clientset", zap.Error(err))
+	}
+	kubeClient := SchedulerKubeClient{
+		clientSet: configuredClient,
+		configs:   config,
+	}
+	podConfig := config
+	if bindConfig := getBindClientConfigs(config, schedulerConf); bindConfig != nil {
+		kubeClient.bindClientSet, err = kubernetes.NewForConfig(bindConfig)
+		if err != nil {
+			log.Log(log.Client).Fatal("failed to get bind Clientset", zap.Error(err))
 		}
+		podConfig = bindConfig
 	}
+	identities, err := schedulerConf.GetPodImpersonation()
+	if err != nil {
+		log.Log(log.Client).Fatal("failed to parse the pod impersonation", zap.Error(err))
+	}
+	kubeClient.impersonation = newPodImpersonation(podConfig, identities)
+	return kubeClient
+}
 
-	// using in cluster config
-	config, err := rest.InClusterConfig()
+// load the client configs from a kubeconfig file, used for out-of-cluster runs.
+// Exec credential plugins and the OIDC auth provider are supported: the credentials are
+// refreshed by the client transport when they expire or are rejected, all the clients and
+// informers share the transport so none of them needs to be restarted. Refreshed OIDC tokens
+// are written back to the kubeconfig file.
+func loadKubeConfig(kc, kubeContext string) (*rest.Config, error) {
+	loadingRules := &clientcmd.ClientConfigLoadingRules{ExplicitPath: kc}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: kubeContext}
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides).ClientConfig()
 	if err != nil {
-		log.Logger().Fatal("failed to get InClusterConfig", zap.Error(err))
+		return nil, err
+	}
+	authMethod := "static"
+	switch {
+	case config.ExecProvider != nil:
+		authMethod = "exec:" + config.ExecProvider.Command
+	case config.AuthProvider != nil:
+		authMethod = "authProvider:" + config.AuthProvider.Name
+	case config.BearerTokenFile != "":
+		authMethod = "tokenFile"
 	}
+	log.Log(log.Client).Info("loaded kubeconfig",
+		zap.String("path", kc),
+		zap.String("context", kubeContext),
+		zap.String("host", config.Host),
+		zap.String("auth", authMethod))
+	return config, nil
+}
+
+// apply the rate limits, the request timeout and the content type from the scheduler configuration
+func applyClientConfigs(config *rest.Config, schedulerConf *conf.SchedulerConf) {
 	config.QPS = float32(schedulerConf.KubeQPS)
 	config.Burst = schedulerConf.KubeBurst
-	configuredClient, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		log.Logger().Fatal("failed to get Clientset", zap.Error(err))
+	config.Timeout = schedulerConf.KubeTimeout
+	if schedulerConf.KubeContentType != "" {
+		config.ContentType = schedulerConf.KubeContentType
 	}
-	return SchedulerKubeClient{
-		clientSet: configuredClient,
-		configs:   config,
+	config.Wrap(newThrottleDetector)
+	// built-in resources support protobuf, anything else falls back to JSON
+	if config.ContentType == conf.ContentTypeProtobuf {
+		config.AcceptContentTypes = conf.ContentTypeProtobuf + "," + conf.ContentTypeJSON
+	}
+	log.Log(log.Client).Info("kube client configs",
+		zap.Float32("qps", config.QPS),
+		zap.Int("burst", config.Burst),
+		zap.Duration("timeout", config.Timeout),
+		zap.String("contentType", config.ContentType))
+}
+
+// returns the configs of the dedicated client for binding and deleting pods, nil if the split is disabled.
+// The dedicated client has its own rate limiter and its own connections to the api-server: a flood of
+// status updates and events on the default client cannot delay the bindings.
+func getBindClientConfigs(config *rest.Config, schedulerConf *conf.SchedulerConf) *rest.Config {
+	if schedulerConf.KubeBindQPS <= 0 {
+		return nil
+	}
+	bindConfig := rest.CopyConfig(config)
+	bindConfig.QPS = float32(schedulerConf.KubeBindQPS)
+	bindConfig.Burst = schedulerConf.KubeBindBurst
+	bindConfig.RateLimiter = nil
+	// client-go shares the transport of configs with the same TLS settings,
+	// a custom dialer makes sure the dedicated client gets its own transport.
+	bindConfig.Dial = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	log.Log(log.Client).Info("dedicated kube client for bind and delete calls",
+		zap.Float32("qps", bindConfig.QPS),
+		zap.Int("burst", bindConfig.Burst))
+	return bindConfig
+}
+
+// returns the client used for the latency-critical calls
+func (nc SchedulerKubeClient) getBindClientSet() kubernetes.Interface {
+	if nc.bindClientSet != nil {
+		return nc.bindClientSet
+	}
+	return nc.clientSet
+}
+
+// returns the client that deletes and evicts the pods of the namespace and the identity it impersonates.
+// A client that cannot impersonate the identity is an error: the scheduler does not fall back to its own
+// identity as that would bypass the RBAC of the namespace.
+func (nc SchedulerKubeClient) getPodClientSet(namespace string) (kubernetes.Interface, string, error) {
+	if nc.impersonation == nil {
+		return nc.getBindClientSet(), "", nil
+	}
+	identity := nc.impersonation.getIdentity(namespace)
+	if identity == "" {
+		return nc.getBindClientSet(), "", nil
+	}
+	clientSet, err := nc.impersonation.getClientSet(identity)
+	return clientSet, identity, err
+}
+
+// GetCRDConfigs returns a copy of the client configs that uses JSON,
+// custom resources cannot be encoded with protobuf.
+func GetCRDConfigs(config *rest.Config) *rest.Config {
+	crdConfig := rest.CopyConfig(config)
+	crdConfig.ContentType = conf.ContentTypeJSON
+	crdConfig.AcceptContentTypes = conf.ContentTypeJSON
+	return crdConfig
+}
+
+// tracks a call to the api-server, the returned function must be called with the result.
+// A call includes all its retries, the latency shows the delay the caller sees.
+func startCall(verb, resource string) func(err error) {
+	m := metrics.GetKubeClientMetrics()
+	m.IncInFlight(verb, resource)
+	start := time.Now()
+	return func(err error) {
+		m.DecInFlight(verb, resource)
+		m.ObserveCall(verb, resource, time.Since(start), err)
 	}
 }
 
@@ -81,22 +218,30 @@ func (nc SchedulerKubeClient) GetConfigs() *rest.Config {
 }
 
 func (nc SchedulerKubeClient) Bind(pod *v1.Pod, hostID string) error {
-	log.Logger().Info("bind pod to node",
+	log.Log(log.Client).Info("bind pod to node",
 		zap.String("podName", pod.Name),
 		zap.String("podUID", string(pod.UID)),
 		zap.String("nodeID", hostID))
 
-	if err := nc.clientSet.CoreV1().Pods(pod.Namespace).Bind(
-		context.Background(),
-		&v1.Binding{ObjectMeta: apis.ObjectMeta{
-			Namespace: pod.Namespace, Name: pod.Name, UID: pod.UID},
-			Target: v1.ObjectReference{
-				Kind: "Node",
-				Name: hostID,
+	if SkipMutation("bind", "pods", pod.Namespace, pod.Name, zap.String("nodeID", hostID)) {
+		return nil
+	}
+	done := startCall("bind", "pods")
+	err := RetryOnTransientError("BindPod", func() error {
+		return nc.getBindClientSet().CoreV1().Pods(pod.Namespace).Bind(
+			context.Background(),
+			&v1.Binding{ObjectMeta: apis.ObjectMeta{
+				Namespace: pod.Namespace, Name: pod.Name, UID: pod.UID},
+				Target: v1.ObjectReference{
+					Kind: "Node",
+					Name: hostID,
+				},
 			},
-		},
-		apis.CreateOptions{}); err != nil {
-		log.Logger().Error("failed to bind pod",
+			apis.CreateOptions{})
+	})
+	done(err)
+	if err != nil {
+		log.Log(log.Client).Error("failed to bind pod",
 			zap.String("namespace", pod.Namespace),
 			zap.String("podName", pod.Name),
 			zap.Error(err))
@@ -106,30 +251,98 @@ func (nc SchedulerKubeClient) Bind(pod *v1.Pod, hostID string) error {
 }
 
 func (nc SchedulerKubeClient) Create(pod *v1.Pod) (*v1.Pod, error) {
-	return nc.clientSet.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, apis.CreateOptions{})
+	if SkipMutation("create", "pods", pod.Namespace, pod.Name) {
+		return pod, nil
+	}
+	done := startCall("create", "pods")
+	created, err := nc.clientSet.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, apis.CreateOptions{})
+	done(err)
+	return created, err
 }
 
 func (nc SchedulerKubeClient) Delete(pod *v1.Pod) error {
 	// TODO make this configurable for pods
 	gracefulSeconds := int64(3)
-	if err := nc.clientSet.CoreV1().Pods(pod.Namespace).Delete(context.Background(), pod.Name, apis.DeleteOptions{
-		GracePeriodSeconds: &gracefulSeconds,
-	}); err != nil {
-		log.Logger().Warn("failed to delete pod",
+	if SkipMutation("delete", "pods", pod.Namespace, pod.Name) {
+		return nil
+	}
+	clientSet, identity, err := nc.getPodClientSet(pod.Namespace)
+	if err == nil {
+		done := startCall("delete", "pods")
+		err = RetryOnTransientError("DeletePod", func() error {
+			return clientSet.CoreV1().Pods(pod.Namespace).Delete(context.Background(), pod.Name, apis.DeleteOptions{
+				GracePeriodSeconds: &gracefulSeconds,
+			})
+		})
+		done(err)
+	}
+	if err != nil {
+		log.Log(log.Client).Warn("failed to delete pod",
 			zap.String("namespace", pod.Namespace),
 			zap.String("podName", pod.Name),
+			zap.String("impersonate", identity),
 			zap.Error(err))
 		return err
 	}
 	return nil
 }
 
-func (nc SchedulerKubeClient) Get(podNamespace string, podName string) (*v1.Pod, error) {
-	pod, err := nc.clientSet.CoreV1().Pods(podNamespace).Get(context.Background(), podName, apis.GetOptions{})
+func (nc SchedulerKubeClient) Evict(pod *v1.Pod, gracePeriodSeconds *int64) error {
+	if SkipMutation("evict", "pods", pod.Namespace, pod.Name) {
+		return nil
+	}
+	clientSet, identity, err := nc.getPodClientSet(pod.Namespace)
+	if err == nil {
+		done := startCall("evict", "pods")
+		// an eviction refused by a disruption budget is left to the caller, the budget can take long to allow it
+		err = retryWithBackoff("EvictPod", mutationBackoff, func(err error) bool {
+			return IsRetryable(err) && !IsDisruptionBudgetError(err)
+		}, func() error {
+			return clientSet.PolicyV1beta1().Evictions(pod.Namespace).Evict(context.Background(), &policy.Eviction{
+				ObjectMeta: apis.ObjectMeta{Namespace: pod.Namespace, Name: pod.Name},
+				// the UID precondition prevents evicting a new pod with the same name
+				DeleteOptions: &apis.DeleteOptions{
+					GracePeriodSeconds: gracePeriodSeconds,
+					Preconditions:      apis.NewUIDPreconditions(string(pod.UID)),
+				},
+			})
+		})
+		done(err)
+	}
 	if err != nil {
-		log.Logger().Warn("failed to get pod",
+		log.Log(log.Client).Warn("failed to evict pod",
 			zap.String("namespace", pod.Namespace),
 			zap.String("podName", pod.Name),
+			zap.String("impersonate", identity),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// IsDisruptionBudgetError returns true when an eviction is refused as it would violate a PodDisruptionBudget
+func IsDisruptionBudgetError(err error) bool {
+	if !apierrors.IsTooManyRequests(err) {
+		return false
+	}
+	if status, ok := err.(apierrors.APIStatus); ok && status.Status().Details != nil {
+		for _, cause := range status.Status().Details.Causes {
+			if cause.Type == disruptionBudgetCause {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (nc SchedulerKubeClient) Get(podNamespace string, podName string) (*v1.Pod, error) {
+	done := startCall("get", "pods")
+	pod, err := nc.clientSet.CoreV1().Pods(podNamespace).Get(context.Background(), podName, apis.GetOptions{})
+	done(err)
+	if err != nil {
+		log.Log(log.Client).Warn("failed to get pod",
+			zap.String("namespace", podNamespace),
+			zap.String("podName", podName),
 			zap.Error(err))
 		return nil, err
 	}
@@ -137,38 +350,33 @@ func (nc SchedulerKubeClient) Get(podNamespace string, podName string) (*v1.Pod,
 }
 
 func (nc SchedulerKubeClient) UpdateStatus(pod *v1.Pod) (*v1.Pod, error) {
+	if SkipMutation("updateStatus", "pods", pod.Namespace, pod.Name) {
+		return pod, nil
+	}
 	var updatedPod *v1.Pod
-	var updateErr error
-	newPodStatus := pod.Status
-	// In case of conflicts, retry using the logic in
-	// https://github.com/kubernetes/client-go/blob/v0.21.1/examples/create-update-delete-deployment/main.go#L118-L121
-	retryErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		// Retrieve the latest version of Pod before attempting status update
-		// RetryOnConflict uses exponential backoff to avoid exhausting the API server
-		latestPod, getErr := nc.clientSet.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, apis.GetOptions{})
-		if getErr != nil {
-			log.Logger().Warn("failed to get latest version of Pod",
-				zap.Error(getErr))
-		}
-		latestPod.Status = newPodStatus
-
-		if updatedPod, updateErr = nc.clientSet.CoreV1().Pods(pod.Namespace).UpdateStatus(context.Background(), latestPod, apis.UpdateOptions{}); updateErr != nil {
-			log.Logger().Warn("failed to update pod status",
+	// server-side apply only changes the status fields the shim sets, there are no
+	// conflicts with the updates of the kubelet. Transient api-server errors are retried.
+	done := startCall("updateStatus", "pods")
+	retryErr := RetryOnTransientError("UpdatePodStatus", func() error {
+		var applyErr error
+		if updatedPod, applyErr = ApplyPodStatus(nc.clientSet, pod); applyErr != nil {
+			log.Log(log.Client).Warn("failed to update pod status",
 				zap.String("namespace", pod.Namespace),
 				zap.String("podName", pod.Name),
-				zap.Error(updateErr))
-			return updateErr
+				zap.Error(applyErr))
+			return applyErr
 		}
 		return nil
 	})
+	done(retryErr)
 	if retryErr != nil {
-		log.Logger().Error("Update pod status failed",
+		log.Log(log.Client).Error("Update pod status failed",
 			zap.String("namespace", pod.Namespace),
 			zap.String("podName", pod.Name),
 			zap.Error(retryErr))
 		return pod, retryErr
 	}
-	log.Logger().Info("Successfully updated pod status",
+	log.Log(log.Client).Info("Successfully updated pod status",
 		zap.String("namespace", pod.Namespace),
 		zap.String("podName", pod.Name),
 		zap.String("newStatus", pod.Status.String()))
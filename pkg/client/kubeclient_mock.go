@@ -19,13 +19,19 @@
 package client
 
 import (
+	"encoding/json"
 	"fmt"
+	"reflect"
 	"sync"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
 
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
 
@@ -36,6 +42,7 @@ import (
 type KubeClientMock struct {
 	bindFn         func(pod *v1.Pod, hostID string) error
 	deleteFn       func(pod *v1.Pod) error
+	evictFn        func(pod *v1.Pod, gracePeriodSeconds *int64) error
 	createFn       func(pod *v1.Pod) (*v1.Pod, error)
 	updateStatusFn func(pod *v1.Pod) (*v1.Pod, error)
 	getFn          func(podName string) (*v1.Pod, error)
@@ -47,31 +54,36 @@ type KubeClientMock struct {
 func NewKubeClientMock() *KubeClientMock {
 	return &KubeClientMock{
 		bindFn: func(pod *v1.Pod, hostID string) error {
-			log.Logger().Info("pod bound",
+			log.Log(log.Client).Info("pod bound",
 				zap.String("PodName", pod.Name))
 			return nil
 		},
 		deleteFn: func(pod *v1.Pod) error {
-			log.Logger().Info("pod deleted",
+			log.Log(log.Client).Info("pod deleted",
+				zap.String("PodName", pod.Name))
+			return nil
+		},
+		evictFn: func(pod *v1.Pod, gracePeriodSeconds *int64) error {
+			log.Log(log.Client).Info("pod evicted",
 				zap.String("PodName", pod.Name))
 			return nil
 		},
 		createFn: func(pod *v1.Pod) (*v1.Pod, error) {
-			log.Logger().Info("pod created",
+			log.Log(log.Client).Info("pod created",
 				zap.String("PodName", pod.Name))
 			return pod, nil
 		},
 		updateStatusFn: func(pod *v1.Pod) (*v1.Pod, error) {
-			log.Logger().Info("pod status updated",
+			log.Log(log.Client).Info("pod status updated",
 				zap.String("PodName", pod.Name))
 			return pod, nil
 		},
 		getFn: func(podName string) (*v1.Pod, error) {
-			log.Logger().Info("Getting pod",
+			log.Log(log.Client).Info("Getting pod",
 				zap.String("PodName", podName))
 			return nil, nil
 		},
-		clientSet: fake.NewSimpleClientset(),
+		clientSet: newFakeClientSet(),
 		pods:      make(map[string]*v1.Pod),
 		lock:      sync.RWMutex{},
 	}
@@ -85,6 +97,10 @@ func (c *KubeClientMock) MockDeleteFn(dfn func(pod *v1.Pod) error) {
 	c.deleteFn = dfn
 }
 
+func (c *KubeClientMock) MockEvictFn(efn func(pod *v1.Pod, gracePeriodSeconds *int64) error) {
+	c.evictFn = efn
+}
+
 func (c *KubeClientMock) MockCreateFn(cfn func(pod *v1.Pod) (*v1.Pod, error)) {
 	c.createFn = cfn
 }
@@ -127,6 +143,13 @@ func (c *KubeClientMock) Delete(pod *v1.Pod) error {
 	return c.deleteFn(pod)
 }
 
+func (c *KubeClientMock) Evict(pod *v1.Pod, gracePeriodSeconds *int64) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.pods, getPodKey(pod))
+	return c.evictFn(pod, gracePeriodSeconds)
+}
+
 func (c *KubeClientMock) GetClientSet() kubernetes.Interface {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
@@ -142,3 +165,38 @@ func (c *KubeClientMock) GetConfigs() *rest.Config {
 func getPodKey(pod *v1.Pod) string {
 	return pod.Namespace + "/" + pod.Name
 }
+
+// returns a fake clientset that handles apply patches, the fake clientset has no server-side apply support.
+// The apply patch is merged into the stored object like a strategic merge patch: the field ownership is not
+// tracked and the fields left out of the patch are never removed.
+func newFakeClientSet() *fake.Clientset {
+	clientSet := fake.NewSimpleClientset()
+	tracker := clientSet.Tracker()
+	clientSet.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patchAction, ok := action.(k8stesting.PatchAction)
+		if !ok || patchAction.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+		obj, err := tracker.Get(action.GetResource(), action.GetNamespace(), patchAction.GetName())
+		if err != nil {
+			return true, nil, err
+		}
+		original, err := json.Marshal(obj)
+		if err != nil {
+			return true, nil, err
+		}
+		merged, err := strategicpatch.StrategicMergePatch(original, patchAction.GetPatch(), obj)
+		if err != nil {
+			return true, nil, err
+		}
+		applied := reflect.New(reflect.TypeOf(obj).Elem()).Interface().(runtime.Object)
+		if err = json.Unmarshal(merged, applied); err != nil {
+			return true, nil, err
+		}
+		if err = tracker.Update(action.GetResource(), applied, action.GetNamespace()); err != nil {
+			return true, nil, err
+		}
+		return true, applied, nil
+	})
+	return clientSet
+}
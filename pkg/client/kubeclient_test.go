@@ -0,0 +1,166 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+)
+
+func TestApplyClientConfigs(t *testing.T) {
+	config := &rest.Config{}
+	applyClientConfigs(config, &conf.SchedulerConf{
+		KubeQPS:         50,
+		KubeBurst:       100,
+		KubeTimeout:     30 * time.Second,
+		KubeContentType: conf.ContentTypeProtobuf,
+	})
+	assert.Equal(t, config.QPS, float32(50))
+	assert.Equal(t, config.Burst, 100)
+	assert.Equal(t, config.Timeout, 30*time.Second)
+	assert.Equal(t, config.ContentType, conf.ContentTypeProtobuf)
+	assert.Equal(t, config.AcceptContentTypes, "application/vnd.kubernetes.protobuf,application/json")
+
+	// the client-go default content type is kept when none is configured
+	config = &rest.Config{}
+	applyClientConfigs(config, &conf.SchedulerConf{})
+	assert.Equal(t, config.ContentType, "")
+}
+
+func TestGetCRDConfigs(t *testing.T) {
+	config := &rest.Config{
+		Host: "https://localhost:6443",
+		ContentConfig: rest.ContentConfig{
+			ContentType:        conf.ContentTypeProtobuf,
+			AcceptContentTypes: conf.ContentTypeProtobuf,
+		},
+		QPS: 50,
+	}
+	crdConfig := GetCRDConfigs(config)
+	assert.Equal(t, crdConfig.ContentType, conf.ContentTypeJSON)
+	assert.Equal(t, crdConfig.AcceptContentTypes, conf.ContentTypeJSON)
+	assert.Equal(t, crdConfig.Host, config.Host)
+	assert.Equal(t, crdConfig.QPS, config.QPS)
+	// the original configs are not changed
+	assert.Equal(t, config.ContentType, conf.ContentTypeProtobuf)
+}
+
+const testKubeConfig = `apiVersion: v1
+kind: Config
+current-context: exec
+clusters:
+- name: test
+  cluster:
+    server: https://localhost:6443
+contexts:
+- name: exec
+  context:
+    cluster: test
+    user: exec-user
+- name: oidc
+  context:
+    cluster: test
+    user: oidc-user
+users:
+- name: exec-user
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: get-token
+      args:
+      - --cluster=test
+- name: oidc-user
+  user:
+    auth-provider:
+      name: oidc
+      config:
+        idp-issuer-url: https://issuer.example.com
+        client-id: yunikorn
+        id-token: token
+`
+
+func TestLoadKubeConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeconfig")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	kc := filepath.Join(dir, "config")
+	assert.NilError(t, ioutil.WriteFile(kc, []byte(testKubeConfig), 0600))
+
+	// current context uses an exec credential plugin
+	config, err := loadKubeConfig(kc, "")
+	assert.NilError(t, err)
+	assert.Equal(t, config.Host, "https://localhost:6443")
+	assert.Assert(t, config.ExecProvider != nil)
+	assert.Equal(t, config.ExecProvider.Command, "get-token")
+	assert.DeepEqual(t, config.ExecProvider.Args, []string{"--cluster=test"})
+	_, err = kubernetes.NewForConfig(config)
+	assert.NilError(t, err)
+
+	// explicit context using the OIDC auth provider, the provider must be registered
+	config, err = loadKubeConfig(kc, "oidc")
+	assert.NilError(t, err)
+	assert.Assert(t, config.AuthProvider != nil)
+	assert.Equal(t, config.AuthProvider.Name, "oidc")
+	_, err = kubernetes.NewForConfig(config)
+	assert.NilError(t, err)
+
+	// unknown context
+	_, err = loadKubeConfig(kc, "unknown")
+	assert.ErrorContains(t, err, "unknown")
+}
+
+func TestGetBindClientConfigs(t *testing.T) {
+	config := &rest.Config{Host: "https://localhost:6443", QPS: 50, Burst: 100}
+	// split disabled
+	assert.Assert(t, getBindClientConfigs(config, &conf.SchedulerConf{}) == nil)
+
+	bindConfig := getBindClientConfigs(config, &conf.SchedulerConf{KubeBindQPS: 20, KubeBindBurst: 40})
+	assert.Assert(t, bindConfig != nil)
+	assert.Equal(t, bindConfig.Host, config.Host)
+	assert.Equal(t, bindConfig.QPS, float32(20))
+	assert.Equal(t, bindConfig.Burst, 40)
+	assert.Assert(t, bindConfig.Dial != nil, "dedicated client must not share the transport")
+	// the default client is not changed
+	assert.Equal(t, config.QPS, float32(50))
+	assert.Assert(t, config.Dial == nil)
+	_, err := kubernetes.NewForConfig(bindConfig)
+	assert.NilError(t, err)
+}
+
+func TestIsDisruptionBudgetError(t *testing.T) {
+	err := apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+	assert.Assert(t, !IsDisruptionBudgetError(err))
+	err.ErrStatus.Details.Causes = append(err.ErrStatus.Details.Causes, apis.StatusCause{
+		Type: disruptionBudgetCause,
+	})
+	assert.Assert(t, IsDisruptionBudgetError(err))
+	assert.Assert(t, !IsDisruptionBudgetError(apierrors.NewInternalError(err)))
+	assert.Assert(t, !IsDisruptionBudgetError(nil))
+}
@@ -0,0 +1,329 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// the optional features that depend on permissions a locked down cluster may not grant
+const (
+	FeatureNamespaceAnnotations = "namespaceAnnotations"
+	FeatureApplicationCRD       = "applicationCRD"
+	FeatureProvisioningRequests = "provisioningRequests"
+	FeatureConfigSecret         = "configSecret"
+	FeatureEvents               = "events"
+	FeatureDisruptionBudgets    = "disruptionBudgets"
+	FeaturePodImpersonation     = "podImpersonation"
+)
+
+// DisabledFeature is an optional feature that was disabled at startup, as the shim is not
+// allowed the access it needs or the custom resource it uses is not installed
+type DisabledFeature struct {
+	Feature string `json:"feature"`
+	Reason  string `json:"reason"`
+}
+
+// an optional feature, the access it needs and how it is disabled in the configuration
+type feature struct {
+	name string
+	// the group version of the custom resource of the feature, empty for the built-in resources
+	groupVersion string
+	access       []authorizationv1.ResourceAttributes
+	// these are called with the lock of the configuration held
+	enabled func(configs *conf.SchedulerConf) bool
+	disable func(configs *conf.SchedulerConf)
+	// the access that depends on the configuration, added to the access of the feature
+	configuredAccess func(configs *conf.SchedulerConf) []authorizationv1.ResourceAttributes
+	// a required feature is not disabled, the startup fails when it is denied
+	required     bool
+	disabledNote string
+}
+
+var disabledFeatures struct {
+	features []*DisabledFeature
+	sync.RWMutex
+}
+
+// GetDisabledFeatures returns the optional features that were disabled at startup
+func GetDisabledFeatures() []*DisabledFeature {
+	disabledFeatures.RLock()
+	defer disabledFeatures.RUnlock()
+	features := make([]*DisabledFeature, len(disabledFeatures.features))
+	copy(features, disabledFeatures.features)
+	return features
+}
+
+func getOptionalFeatures() []*feature {
+	namespace := os.Getenv(constants.EnvSchedulerPodNamespace)
+	if namespace == "" {
+		namespace = constants.DefaultSchedulerNamespace
+	}
+	return []*feature{
+		{
+			name:   FeatureNamespaceAnnotations,
+			access: resourceAccess("", "namespaces", "", "list", "watch"),
+			enabled: func(configs *conf.SchedulerConf) bool {
+				return configs.EnableNamespaceAnnotations
+			},
+			disable: func(configs *conf.SchedulerConf) {
+				configs.EnableNamespaceAnnotations = false
+			},
+			disabledNote: "the namespace annotations are ignored",
+		},
+		{
+			name:         FeatureApplicationCRD,
+			groupVersion: "yunikorn.apache.org/v1alpha1",
+			access:       resourceAccess("yunikorn.apache.org", "applications", "", "list", "watch", "update"),
+			enabled: func(configs *conf.SchedulerConf) bool {
+				for _, plugin := range strings.Split(configs.OperatorPlugins, ",") {
+					if plugin == constants.AppManagerHandlerName {
+						return true
+					}
+				}
+				return false
+			},
+			disable: func(configs *conf.SchedulerConf) {
+				plugins := make([]string, 0)
+				for _, plugin := range strings.Split(configs.OperatorPlugins, ",") {
+					if plugin != constants.AppManagerHandlerName {
+						plugins = append(plugins, plugin)
+					}
+				}
+				configs.OperatorPlugins = strings.Join(plugins, ",")
+			},
+			disabledNote: "the Application custom resources are not managed",
+		},
+		{
+			name:         FeatureProvisioningRequests,
+			groupVersion: ProvisioningRequestResource.GroupVersion().String(),
+			access: resourceAccess(ProvisioningRequestResource.Group, ProvisioningRequestResource.Resource, "",
+				"create", "get", "delete"),
+			enabled: func(configs *conf.SchedulerConf) bool {
+				return configs.ProvisioningClass != ""
+			},
+			disable: func(configs *conf.SchedulerConf) {
+				configs.ProvisioningClass = ""
+			},
+			disabledNote: "no ProvisioningRequests are created for the gangs that cannot fit",
+		},
+		{
+			name:   FeatureConfigSecret,
+			access: resourceAccess("", "secrets", namespace, "list", "watch"),
+			enabled: func(configs *conf.SchedulerConf) bool {
+				return configs.ConfigSecret != ""
+			},
+			disable: func(configs *conf.SchedulerConf) {
+				configs.ConfigSecret = ""
+			},
+			disabledNote: "the config Secret is not read, the features that need a secret value are disabled",
+		},
+		{
+			name:   FeatureEvents,
+			access: resourceAccess("", "events", "", "create", "patch"),
+			enabled: func(configs *conf.SchedulerConf) bool {
+				return !configs.DryRun && configs.EventRecorderSink == conf.RecorderSinkKubernetes
+			},
+			disable: func(configs *conf.SchedulerConf) {
+				configs.EventRecorderSink = conf.RecorderSinkLog
+			},
+			disabledNote: "the events are written to the log instead",
+		},
+		{
+			name:   FeatureDisruptionBudgets,
+			access: resourceAccess("policy", "poddisruptionbudgets", "", "list", "watch"),
+			enabled: func(configs *conf.SchedulerConf) bool {
+				return configs.PreemptionPDBPolicy == conf.PDBPolicySkip
+			},
+			disable: func(configs *conf.SchedulerConf) {
+				configs.PreemptionPDBPolicy = conf.PDBPolicyEvict
+			},
+			disabledNote: "the preemption victims covered by a disruption budget are evicted, the eviction API enforces the budget",
+		},
+		{
+			name: FeaturePodImpersonation,
+			enabled: func(configs *conf.SchedulerConf) bool {
+				return configs.PodImpersonation != ""
+			},
+			configuredAccess: impersonationAccess,
+			// the scheduler would delete and evict the pods as itself, outside of the RBAC of the namespaces
+			required:     true,
+			disabledNote: "grant the impersonate access or remove the podImpersonation option",
+		},
+	}
+}
+
+// returns the access needed to impersonate the identities of the pod impersonation, an identity that
+// depends on the namespace is checked for all namespaces
+func impersonationAccess(configs *conf.SchedulerConf) []authorizationv1.ResourceAttributes {
+	identities, err := configs.GetPodImpersonation()
+	if err != nil {
+		// the option is validated with the configuration
+		return nil
+	}
+	namespaces := make([]string, 0, len(identities))
+	for namespace := range identities {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	access := make([]authorizationv1.ResourceAttributes, 0, len(identities))
+	for _, namespace := range namespaces {
+		identity := identities[namespace]
+		if namespace != "*" {
+			identity = strings.ReplaceAll(identity, namespacePlaceholder, namespace)
+		}
+		attributes := authorizationv1.ResourceAttributes{Resource: "users", Name: identity, Verb: "impersonate"}
+		// the service accounts are impersonated as system:serviceaccount:<namespace>:<name>
+		if parts := strings.Split(identity, ":"); len(parts) == 4 && parts[0] == "system" && parts[1] == "serviceaccount" {
+			attributes.Resource = "serviceaccounts"
+			attributes.Namespace = parts[2]
+			attributes.Name = parts[3]
+		}
+		if strings.Contains(attributes.Namespace, namespacePlaceholder) {
+			attributes.Namespace = ""
+		}
+		if strings.Contains(attributes.Name, namespacePlaceholder) {
+			attributes.Name = ""
+		}
+		access = append(access, attributes)
+	}
+	return access
+}
+
+func resourceAccess(group, resource, namespace string, verbs ...string) []authorizationv1.ResourceAttributes {
+	access := make([]authorizationv1.ResourceAttributes, len(verbs))
+	for i, verb := range verbs {
+		access[i] = authorizationv1.ResourceAttributes{
+			Group:     group,
+			Resource:  resource,
+			Namespace: namespace,
+			Verb:      verb,
+		}
+	}
+	return access
+}
+
+// DisableDeniedFeatures checks the access of the shim for the optional features that are enabled, and
+// disables the features the shim is not allowed to use or whose custom resource is not installed. This
+// runs before the informers are created so that a denied feature does not fail the startup or log an
+// error on every list and watch. The access is assumed to be granted when it cannot be checked.
+func DisableDeniedFeatures(clientSet kubernetes.Interface, configs *conf.SchedulerConf) ([]*DisabledFeature, error) {
+	disabled := make([]*DisabledFeature, 0)
+	for _, optional := range getOptionalFeatures() {
+		configs.RLock()
+		enabled := optional.enabled(configs)
+		if enabled && optional.configuredAccess != nil {
+			optional.access = append(optional.access, optional.configuredAccess(configs)...)
+		}
+		configs.RUnlock()
+		if !enabled {
+			continue
+		}
+		reason := checkFeature(clientSet, optional)
+		if reason == "" {
+			continue
+		}
+		if optional.required {
+			return nil, fmt.Errorf("feature %s is denied, %s: %s", optional.name, optional.disabledNote, reason)
+		}
+		configs.Lock()
+		optional.disable(configs)
+		configs.Unlock()
+		log.Log(log.Client).Warn("optional feature disabled, "+optional.disabledNote,
+			zap.String("feature", optional.name),
+			zap.String("reason", reason))
+		disabled = append(disabled, &DisabledFeature{Feature: optional.name, Reason: reason})
+	}
+	disabledFeatures.Lock()
+	disabledFeatures.features = disabled
+	disabledFeatures.Unlock()
+	return disabled, nil
+}
+
+// returns why the feature cannot be used, empty when it can be used
+func checkFeature(clientSet kubernetes.Interface, optional *feature) string {
+	if optional.groupVersion != "" {
+		resources, err := clientSet.Discovery().ServerResourcesForGroupVersion(optional.groupVersion)
+		switch {
+		case apierrors.IsNotFound(err) || err == nil && !hasResource(resources, optional.access[0].Resource):
+			return fmt.Sprintf("resource %s of %s is not installed", optional.access[0].Resource, optional.groupVersion)
+		case err != nil:
+			log.Log(log.Client).Warn("failed to check the resources of the scheduler, assuming they are installed",
+				zap.String("feature", optional.name),
+				zap.String("groupVersion", optional.groupVersion),
+				zap.Error(err))
+		}
+	}
+	for _, access := range optional.access {
+		attributes := access
+		review, err := clientSet.AuthorizationV1().SelfSubjectAccessReviews().Create(context.Background(),
+			&authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &attributes},
+			}, metav1.CreateOptions{})
+		if err != nil {
+			log.Log(log.Client).Warn("failed to check the access of the scheduler, assuming it is allowed",
+				zap.String("feature", optional.name),
+				zap.String("resource", access.Resource),
+				zap.String("verb", access.Verb),
+				zap.Error(err))
+			continue
+		}
+		if !review.Status.Allowed {
+			resource := access.Resource
+			if access.Group != "" {
+				resource += "." + access.Group
+			}
+			if access.Name != "" {
+				resource += " " + access.Name
+			}
+			if access.Namespace != "" {
+				return fmt.Sprintf("not allowed to %s %s in namespace %s", access.Verb, resource, access.Namespace)
+			}
+			return fmt.Sprintf("not allowed to %s %s", access.Verb, resource)
+		}
+	}
+	return ""
+}
+
+func hasResource(resources *metav1.APIResourceList, name string) bool {
+	if resources == nil {
+		return false
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Name == name {
+			return true
+		}
+	}
+	return false
+}
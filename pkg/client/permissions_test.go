@@ -0,0 +1,121 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+)
+
+func TestDisableDeniedFeatures(t *testing.T) {
+	clientSet := fake.NewSimpleClientset()
+	discovery, ok := clientSet.Discovery().(*fakediscovery.FakeDiscovery)
+	assert.Assert(t, ok)
+	// the Application CRD is installed, the ProvisioningRequest CRD is not
+	discovery.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "yunikorn.apache.org/v1alpha1",
+			APIResources: []metav1.APIResource{{Name: "applications"}},
+		},
+		{
+			GroupVersion: ProvisioningRequestResource.GroupVersion().String(),
+		},
+	}
+	// the namespaces and the disruption budgets cannot be watched and the events cannot be created
+	clientSet.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review, ok := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		assert.Assert(t, ok)
+		attributes := review.Spec.ResourceAttributes
+		review.Status.Allowed = !(attributes.Resource == "namespaces" && attributes.Verb == "watch") &&
+			attributes.Resource != "events" && attributes.Resource != "poddisruptionbudgets"
+		return true, review, nil
+	})
+
+	configs := &conf.SchedulerConf{
+		EnableNamespaceAnnotations: true,
+		OperatorPlugins:            "general," + constants.AppManagerHandlerName,
+		ProvisioningClass:          conf.ProvisioningClassCheckCapacity,
+		EventRecorderSink:          conf.RecorderSinkKubernetes,
+		PreemptionPDBPolicy:        conf.PDBPolicySkip,
+	}
+	disabled, err := DisableDeniedFeatures(clientSet, configs)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, disabled, []*DisabledFeature{
+		{Feature: FeatureNamespaceAnnotations, Reason: "not allowed to watch namespaces"},
+		{Feature: FeatureProvisioningRequests, Reason: "resource provisioningrequests of autoscaling.x-k8s.io/v1beta1 is not installed"},
+		{Feature: FeatureEvents, Reason: "not allowed to create events"},
+		{Feature: FeatureDisruptionBudgets, Reason: "not allowed to list poddisruptionbudgets.policy"},
+	})
+	assert.DeepEqual(t, GetDisabledFeatures(), disabled)
+	assert.Equal(t, configs.EnableNamespaceAnnotations, false)
+	assert.Equal(t, configs.OperatorPlugins, "general,"+constants.AppManagerHandlerName)
+	assert.Equal(t, configs.ProvisioningClass, "")
+	assert.Equal(t, configs.EventRecorderSink, conf.RecorderSinkLog)
+	assert.Equal(t, configs.PreemptionPDBPolicy, conf.PDBPolicyEvict)
+
+	// the features that are not enabled are not checked
+	configs = &conf.SchedulerConf{OperatorPlugins: "general", EventRecorderSink: conf.RecorderSinkNone}
+	disabled, err = DisableDeniedFeatures(clientSet, configs)
+	assert.NilError(t, err)
+	assert.Equal(t, len(disabled), 0)
+	assert.Equal(t, len(GetDisabledFeatures()), 0)
+}
+
+func TestDisableDeniedFeaturesImpersonation(t *testing.T) {
+	clientSet := fake.NewSimpleClientset()
+	checked := make([]authorizationv1.ResourceAttributes, 0)
+	// the service account of the team1 namespace cannot be impersonated
+	clientSet.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review, ok := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		assert.Assert(t, ok)
+		attributes := review.Spec.ResourceAttributes
+		checked = append(checked, *attributes)
+		review.Status.Allowed = attributes.Namespace != "team1"
+		return true, review, nil
+	})
+
+	configs := &conf.SchedulerConf{
+		OperatorPlugins:  "general",
+		PodImpersonation: "*=system:serviceaccount:{namespace}:yunikorn,team2=ops-user",
+	}
+	disabled, err := DisableDeniedFeatures(clientSet, configs)
+	assert.NilError(t, err)
+	assert.Equal(t, len(disabled), 0)
+	assert.DeepEqual(t, checked, []authorizationv1.ResourceAttributes{
+		{Resource: "serviceaccounts", Name: "yunikorn", Verb: "impersonate"},
+		{Resource: "users", Name: "ops-user", Verb: "impersonate"},
+	})
+
+	// the impersonation is not disabled, the scheduler would act as itself in the namespaces
+	configs.PodImpersonation = "team1=system:serviceaccount:{namespace}:yunikorn"
+	_, err = DisableDeniedFeatures(clientSet, configs)
+	assert.ErrorContains(t, err, "feature podImpersonation is denied")
+	assert.ErrorContains(t, err, "not allowed to impersonate serviceaccounts yunikorn in namespace team1")
+	assert.Equal(t, configs.PodImpersonation, "team1=system:serviceaccount:{namespace}:yunikorn")
+}
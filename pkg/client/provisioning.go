@@ -0,0 +1,180 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ProvisioningRequestResource is the cluster-autoscaler resource that asks for the capacity of a set of pods.
+// The shim has no typed client for it, the requests are handled as unstructured objects.
+var ProvisioningRequestResource = schema.GroupVersionResource{
+	Group:    "autoscaling.x-k8s.io",
+	Version:  "v1beta1",
+	Resource: "provisioningrequests",
+}
+
+// the conditions of a ProvisioningRequest that end the wait for the capacity
+const (
+	ProvisioningProvisioned = "Provisioned"
+	ProvisioningFailed      = "Failed"
+)
+
+// ProvisioningPodSet is a group of identical pods, the capacity for count pods like the template is requested
+type ProvisioningPodSet struct {
+	Template *v1.PodTemplate
+	Count    int32
+}
+
+// returns the ProvisioningRequest referencing the templates of the pod sets
+func newProvisioningRequest(namespace, name, class string, podSets []*ProvisioningPodSet,
+	owners []apis.OwnerReference) *unstructured.Unstructured {
+	sets := make([]interface{}, 0, len(podSets))
+	for _, podSet := range podSets {
+		sets = append(sets, map[string]interface{}{
+			"podTemplateRef": map[string]interface{}{"name": podSet.Template.Name},
+			"count":          int64(podSet.Count),
+		})
+	}
+	request := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"provisioningClassName": class,
+			"podSets":               sets,
+		},
+	}}
+	request.SetAPIVersion(ProvisioningRequestResource.GroupVersion().String())
+	request.SetKind("ProvisioningRequest")
+	request.SetNamespace(namespace)
+	request.SetName(name)
+	request.SetOwnerReferences(owners)
+	return request
+}
+
+// CreateProvisioningRequest creates the PodTemplates of the pod sets and the ProvisioningRequest of the class
+// referencing them. The templates must exist before the request: the autoscaler reads them once it sees the request.
+// Objects left behind by an earlier attempt are reused.
+func CreateProvisioningRequest(clientSet kubernetes.Interface, dynamicClient dynamic.Interface,
+	namespace, name, class string, podSets []*ProvisioningPodSet, owners []apis.OwnerReference) error {
+	if SkipMutation("create", "provisioningrequests", namespace, name) {
+		return nil
+	}
+	done := startCall("create", "podtemplates")
+	err := RetryOnTransientError("CreatePodTemplates", func() error {
+		for _, podSet := range podSets {
+			_, createErr := clientSet.CoreV1().PodTemplates(namespace).Create(context.Background(),
+				podSet.Template, apis.CreateOptions{})
+			if createErr != nil && !apierrors.IsAlreadyExists(createErr) {
+				return createErr
+			}
+		}
+		return nil
+	})
+	done(err)
+	if err != nil {
+		return fmt.Errorf("failed to create the pod templates of provisioning request %s/%s: %w", namespace, name, err)
+	}
+
+	request := newProvisioningRequest(namespace, name, class, podSets, owners)
+	done = startCall("create", "provisioningrequests")
+	err = RetryOnTransientError("CreateProvisioningRequest", func() error {
+		_, createErr := dynamicClient.Resource(ProvisioningRequestResource).Namespace(namespace).Create(
+			context.Background(), request, apis.CreateOptions{})
+		if apierrors.IsAlreadyExists(createErr) {
+			return nil
+		}
+		return createErr
+	})
+	done(err)
+	if err != nil {
+		return fmt.Errorf("failed to create provisioning request %s/%s: %w", namespace, name, err)
+	}
+	return nil
+}
+
+// GetProvisioningRequestCondition returns the condition that ended the wait for the capacity with its message,
+// the condition is empty while the autoscaler has not decided yet.
+func GetProvisioningRequestCondition(dynamicClient dynamic.Interface, namespace, name string) (string, string, error) {
+	request, err := dynamicClient.Resource(ProvisioningRequestResource).Namespace(namespace).Get(
+		context.Background(), name, apis.GetOptions{})
+	if err != nil {
+		return "", "", err
+	}
+	conditions, _, err := unstructured.NestedSlice(request.Object, "status", "conditions")
+	if err != nil {
+		return "", "", err
+	}
+	for _, item := range conditions {
+		condition, ok := item.(map[string]interface{})
+		if !ok || condition["status"] != string(apis.ConditionTrue) {
+			continue
+		}
+		conditionType, _ := condition["type"].(string)
+		if conditionType == ProvisioningProvisioned || conditionType == ProvisioningFailed {
+			message, _ := condition["message"].(string)
+			return conditionType, message, nil
+		}
+	}
+	return "", "", nil
+}
+
+// DeleteProvisioningRequest deletes the ProvisioningRequest and its PodTemplates, the objects already gone are ignored.
+func DeleteProvisioningRequest(clientSet kubernetes.Interface, dynamicClient dynamic.Interface,
+	namespace, name string, templates []string) error {
+	if SkipMutation("delete", "provisioningrequests", namespace, name) {
+		return nil
+	}
+	done := startCall("delete", "provisioningrequests")
+	err := RetryOnTransientError("DeleteProvisioningRequest", func() error {
+		deleteErr := dynamicClient.Resource(ProvisioningRequestResource).Namespace(namespace).Delete(
+			context.Background(), name, apis.DeleteOptions{})
+		if apierrors.IsNotFound(deleteErr) {
+			return nil
+		}
+		return deleteErr
+	})
+	done(err)
+	if err != nil {
+		return fmt.Errorf("failed to delete provisioning request %s/%s: %w", namespace, name, err)
+	}
+	done = startCall("delete", "podtemplates")
+	err = RetryOnTransientError("DeletePodTemplates", func() error {
+		for _, template := range templates {
+			deleteErr := clientSet.CoreV1().PodTemplates(namespace).Delete(context.Background(), template,
+				apis.DeleteOptions{})
+			if deleteErr != nil && !apierrors.IsNotFound(deleteErr) {
+				return deleteErr
+			}
+		}
+		return nil
+	})
+	done(err)
+	if err != nil {
+		return fmt.Errorf("failed to delete the pod templates of provisioning request %s/%s: %w", namespace, name, err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+)
+
+func TestProvisioningRequestLifecycle(t *testing.T) {
+	clientSet := fake.NewSimpleClientset()
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	podSets := []*ProvisioningPodSet{
+		{Template: &v1.PodTemplate{ObjectMeta: apis.ObjectMeta{Name: "gang-workers", Namespace: "default"}}, Count: 3},
+		{Template: &v1.PodTemplate{ObjectMeta: apis.ObjectMeta{Name: "gang-driver", Namespace: "default"}}, Count: 1},
+	}
+	err := CreateProvisioningRequest(clientSet, dynamicClient, "default", "gang", conf.ProvisioningClassAtomicScaleUp,
+		podSets, nil)
+	assert.NilError(t, err)
+	// a second attempt reuses the objects
+	err = CreateProvisioningRequest(clientSet, dynamicClient, "default", "gang", conf.ProvisioningClassAtomicScaleUp,
+		podSets, nil)
+	assert.NilError(t, err)
+
+	_, err = clientSet.CoreV1().PodTemplates("default").Get(context.Background(), "gang-workers", apis.GetOptions{})
+	assert.NilError(t, err)
+	requests := dynamicClient.Resource(ProvisioningRequestResource).Namespace("default")
+	request, err := requests.Get(context.Background(), "gang", apis.GetOptions{})
+	assert.NilError(t, err)
+	class, _, err := unstructured.NestedString(request.Object, "spec", "provisioningClassName")
+	assert.NilError(t, err)
+	assert.Equal(t, class, conf.ProvisioningClassAtomicScaleUp)
+	sets, _, err := unstructured.NestedSlice(request.Object, "spec", "podSets")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, sets, []interface{}{
+		map[string]interface{}{"podTemplateRef": map[string]interface{}{"name": "gang-workers"}, "count": int64(3)},
+		map[string]interface{}{"podTemplateRef": map[string]interface{}{"name": "gang-driver"}, "count": int64(1)},
+	})
+
+	// the request is pending until the autoscaler decides
+	condition, _, err := GetProvisioningRequestCondition(dynamicClient, "default", "gang")
+	assert.NilError(t, err)
+	assert.Equal(t, condition, "")
+	err = unstructured.SetNestedSlice(request.Object, []interface{}{
+		map[string]interface{}{"type": "Accepted", "status": "True"},
+		map[string]interface{}{"type": ProvisioningFailed, "status": "False"},
+		map[string]interface{}{"type": ProvisioningProvisioned, "status": "True", "message": "nodes added"},
+	}, "status", "conditions")
+	assert.NilError(t, err)
+	_, err = requests.Update(context.Background(), request, apis.UpdateOptions{})
+	assert.NilError(t, err)
+	condition, message, err := GetProvisioningRequestCondition(dynamicClient, "default", "gang")
+	assert.NilError(t, err)
+	assert.Equal(t, condition, ProvisioningProvisioned)
+	assert.Equal(t, message, "nodes added")
+
+	err = DeleteProvisioningRequest(clientSet, dynamicClient, "default", "gang", []string{"gang-workers", "gang-driver"})
+	assert.NilError(t, err)
+	_, err = requests.Get(context.Background(), "gang", apis.GetOptions{})
+	assert.Assert(t, apierrors.IsNotFound(err))
+	_, err = clientSet.CoreV1().PodTemplates("default").Get(context.Background(), "gang-driver", apis.GetOptions{})
+	assert.Assert(t, apierrors.IsNotFound(err))
+	// deleting again ignores the objects already gone
+	err = DeleteProvisioningRequest(clientSet, dynamicClient, "default", "gang", []string{"gang-workers", "gang-driver"})
+	assert.NilError(t, err)
+}
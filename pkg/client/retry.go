@@ -0,0 +1,98 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+)
+
+// backoff used for the api-server mutations, the jitter spreads the retries of
+// the calls that failed at the same time, e.g. when the api-server is overloaded.
+var mutationBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 100 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.2,
+	Cap:      5 * time.Second,
+}
+
+// IsRetryable returns true for the errors that are caused by a transient api-server
+// or network condition, the same call is expected to succeed later.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch {
+	case apierrors.IsTooManyRequests(err),
+		apierrors.IsServerTimeout(err),
+		apierrors.IsTimeout(err),
+		apierrors.IsInternalError(err),
+		apierrors.IsServiceUnavailable(err),
+		apierrors.IsUnexpectedServerError(err):
+		return true
+	case utilnet.IsConnectionReset(err),
+		utilnet.IsConnectionRefused(err),
+		utilnet.IsProbableEOF(err):
+		return true
+	}
+	return false
+}
+
+// RetryOnTransientError runs the api-server call and retries it with exponential backoff
+// as long as it fails with a retryable error. The error of the last attempt is returned.
+func RetryOnTransientError(operation string, fn func() error) error {
+	return retryWithBackoff(operation, mutationBackoff, IsRetryable, fn)
+}
+
+func retryWithBackoff(operation string, backoff wait.Backoff, retryable func(error) bool, fn func() error) error {
+	attempt := 0
+	err := retry.OnError(backoff, retryable, func() error {
+		if attempt > 0 {
+			metrics.GetKubeClientMetrics().IncRetries(operation)
+		}
+		attempt++
+		err := fn()
+		// the api-server asks to come back later, wait at least that long
+		if seconds, ok := apierrors.SuggestsClientDelay(err); ok && retryable(err) {
+			delay := time.Duration(seconds) * time.Second
+			if backoff.Cap > 0 && delay > backoff.Cap {
+				delay = backoff.Cap
+			}
+			time.Sleep(delay)
+		}
+		return err
+	})
+	if err != nil {
+		log.Log(log.Client).Warn("api-server call failed",
+			zap.String("operation", operation),
+			zap.Int("attempts", attempt),
+			zap.Error(err))
+		metrics.GetKubeClientMetrics().IncFailures(operation, retryable(err))
+	}
+	return err
+}
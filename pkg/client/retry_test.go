@@ -0,0 +1,107 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestIsRetryable(t *testing.T) {
+	resource := schema.GroupResource{Resource: "pods"}
+	testCases := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{"nil", nil, false},
+		{"too many requests", apierrors.NewTooManyRequests("throttled", 1), true},
+		{"internal error", apierrors.NewInternalError(fmt.Errorf("etcd")), true},
+		{"service unavailable", apierrors.NewServiceUnavailable("unavailable"), true},
+		{"server timeout", apierrors.NewServerTimeout(resource, "create", 1), true},
+		{"conflict", apierrors.NewConflict(resource, "pod", fmt.Errorf("changed")), false},
+		{"not found", apierrors.NewNotFound(resource, "pod"), false},
+		{"plain error", fmt.Errorf("failed"), false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, IsRetryable(tc.err), tc.retryable)
+		})
+	}
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	backoff := wait.Backoff{Steps: 3, Duration: time.Millisecond, Factor: 1.0}
+	transient := apierrors.NewTooManyRequests("throttled", 0)
+
+	// succeeds after a transient failure
+	calls := 0
+	err := retryWithBackoff("test", backoff, IsRetryable, func() error {
+		calls++
+		if calls == 1 {
+			return transient
+		}
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, calls, 2)
+
+	// gives up after all the steps
+	calls = 0
+	err = retryWithBackoff("test", backoff, IsRetryable, func() error {
+		calls++
+		return transient
+	})
+	assert.Assert(t, apierrors.IsTooManyRequests(err))
+	assert.Equal(t, calls, 3)
+
+	// errors that are not retryable fail at once
+	calls = 0
+	err = retryWithBackoff("test", backoff, IsRetryable, func() error {
+		calls++
+		return apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, "pod")
+	})
+	assert.Assert(t, apierrors.IsNotFound(err))
+	assert.Equal(t, calls, 1)
+}
+
+func TestRetryAfter(t *testing.T) {
+	// the suggested delay is capped by the backoff
+	backoff := wait.Backoff{Steps: 2, Duration: time.Millisecond, Factor: 1.0, Cap: 50 * time.Millisecond}
+	calls := 0
+	start := time.Now()
+	err := retryWithBackoff("test", backoff, IsRetryable, func() error {
+		calls++
+		if calls == 1 {
+			return apierrors.NewTooManyRequests("throttled", 10)
+		}
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, calls, 2)
+	elapsed := time.Since(start)
+	assert.Assert(t, elapsed >= 50*time.Millisecond, "retry-after not respected: %v", elapsed)
+	assert.Assert(t, elapsed < 5*time.Second, "retry-after not capped: %v", elapsed)
+}
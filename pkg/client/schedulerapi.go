@@ -0,0 +1,132 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/api"
+	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
+)
+
+// the methods of the scheduler interface in the metrics
+const (
+	coreRegisterResourceManager = "RegisterResourceManager"
+	coreUpdateAllocation        = "UpdateAllocation"
+	coreUpdateApplication       = "UpdateApplication"
+	coreUpdateNode              = "UpdateNode"
+	coreUpdateConfiguration     = "UpdateConfiguration"
+)
+
+// the classes of the errors returned by the core
+const (
+	coreErrorNotRegistered     = "not_registered"
+	coreErrorAlreadyRegistered = "already_registered"
+	coreErrorConfiguration     = "invalid_configuration"
+	coreErrorTimeout           = "timeout"
+	coreErrorOther             = "other"
+)
+
+// instrumentedSchedulerAPI measures the calls to the core, so that problems in the communication
+// with the core can be told apart from problems with the api-server
+type instrumentedSchedulerAPI struct {
+	api.SchedulerAPI
+}
+
+func newInstrumentedSchedulerAPI(scheduler api.SchedulerAPI) api.SchedulerAPI {
+	if scheduler == nil {
+		return nil
+	}
+	return &instrumentedSchedulerAPI{SchedulerAPI: scheduler}
+}
+
+func (s *instrumentedSchedulerAPI) RegisterResourceManager(request *si.RegisterResourceManagerRequest,
+	callback api.ResourceManagerCallback) (*si.RegisterResourceManagerResponse, error) {
+	start := time.Now()
+	response, err := s.SchedulerAPI.RegisterResourceManager(request, callback)
+	observeCoreCall(coreRegisterResourceManager, request, start, err)
+	return response, err
+}
+
+func (s *instrumentedSchedulerAPI) UpdateAllocation(request *si.AllocationRequest) error {
+	start := time.Now()
+	err := s.SchedulerAPI.UpdateAllocation(request)
+	observeCoreCall(coreUpdateAllocation, request, start, err)
+	return err
+}
+
+func (s *instrumentedSchedulerAPI) UpdateApplication(request *si.ApplicationRequest) error {
+	start := time.Now()
+	err := s.SchedulerAPI.UpdateApplication(request)
+	observeCoreCall(coreUpdateApplication, request, start, err)
+	return err
+}
+
+func (s *instrumentedSchedulerAPI) UpdateNode(request *si.NodeRequest) error {
+	start := time.Now()
+	err := s.SchedulerAPI.UpdateNode(request)
+	observeCoreCall(coreUpdateNode, request, start, err)
+	return err
+}
+
+func (s *instrumentedSchedulerAPI) UpdateConfiguration(clusterID string) error {
+	start := time.Now()
+	err := s.SchedulerAPI.UpdateConfiguration(clusterID)
+	observeCoreCall(coreUpdateConfiguration, nil, start, err)
+	return err
+}
+
+// records the latency, the size of the request and the class of the error of a call to the core
+func observeCoreCall(method string, request proto.Message, start time.Time, err error) {
+	result := metrics.CoreCallSuccess
+	if err != nil {
+		result = classifyCoreError(err)
+	}
+	metrics.GetCoreAPIMetrics().ObserveCall(method, result, time.Since(start))
+	if request != nil {
+		metrics.GetCoreAPIMetrics().ObservePayloadSize(method, proto.Size(request))
+	}
+}
+
+// returns the class of an error returned by the core. The core returns plain errors:
+// the class is derived from the message.
+func classifyCoreError(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return coreErrorTimeout
+	}
+	message := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(message, "not registered"):
+		return coreErrorNotRegistered
+	case strings.Contains(message, "already registered"):
+		return coreErrorAlreadyRegistered
+	case strings.Contains(message, "timeout"), strings.Contains(message, "timed out"):
+		return coreErrorTimeout
+	case strings.Contains(message, "config"):
+		return coreErrorConfiguration
+	default:
+		return coreErrorOther
+	}
+}
@@ -0,0 +1,66 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/test"
+	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
+)
+
+func TestInstrumentedSchedulerAPI(t *testing.T) {
+	assert.Assert(t, newInstrumentedSchedulerAPI(nil) == nil)
+
+	mock := test.NewSchedulerAPIMock().UpdateAllocationFunction(func(request *si.AllocationRequest) error {
+		return fmt.Errorf("received AllocationRequest, but RmID=\"%s\" not registered", request.RmID)
+	})
+	scheduler := newInstrumentedSchedulerAPI(mock)
+	err := scheduler.UpdateAllocation(&si.AllocationRequest{RmID: "rm-1"})
+	assert.ErrorContains(t, err, "not registered")
+	assert.NilError(t, scheduler.UpdateNode(&si.NodeRequest{RmID: "rm-1"}))
+	assert.NilError(t, scheduler.UpdateApplication(&si.ApplicationRequest{RmID: "rm-1"}))
+	assert.NilError(t, scheduler.UpdateConfiguration("rm-1"))
+	assert.Equal(t, mock.GetUpdateAllocationCount(), int32(1))
+	assert.Equal(t, mock.GetUpdateNodeCount(), int32(1))
+	assert.Equal(t, mock.GetUpdateApplicationCount(), int32(1))
+}
+
+func TestClassifyCoreError(t *testing.T) {
+	tests := map[string]struct {
+		err   error
+		class string
+	}{
+		"not registered":     {fmt.Errorf("received NodeRequest, but RmID=\"rm-1\" not registered"), coreErrorNotRegistered},
+		"already registered": {fmt.Errorf("RM rm-1 is already registered"), coreErrorAlreadyRegistered},
+		"deadline":           {fmt.Errorf("call failed: %w", context.DeadlineExceeded), coreErrorTimeout},
+		"timed out":          {fmt.Errorf("request timed out"), coreErrorTimeout},
+		"configuration":      {fmt.Errorf("failed to load the scheduler Config"), coreErrorConfiguration},
+		"other":              {fmt.Errorf("something went wrong"), coreErrorOther},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, classifyCoreError(tc.err), tc.class)
+		})
+	}
+}
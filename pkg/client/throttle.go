@@ -0,0 +1,118 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+)
+
+// response headers set by the api-server when API Priority and Fairness handled the request
+const (
+	headerFlowSchemaUID    = "X-Kubernetes-PF-FlowSchema-UID"
+	headerPriorityLevelUID = "X-Kubernetes-PF-PriorityLevel-UID"
+)
+
+// the shim is reported as throttled for this long after the last throttled response
+const throttleWindow = time.Minute
+
+// ThrottleState describes the api-server backpressure seen by the shim
+type ThrottleState struct {
+	Throttled         bool       `json:"throttled"`
+	ThrottledCount    uint64     `json:"throttledCount"`
+	LastThrottled     *time.Time `json:"lastThrottled,omitempty"`
+	LastFlowSchema    string     `json:"lastFlowSchema,omitempty"`
+	LastPriorityLevel string     `json:"lastPriorityLevel,omitempty"`
+	LastRetryAfter    string     `json:"lastRetryAfter,omitempty"`
+	ThrottleWindowMs  int64      `json:"throttleWindowMs"`
+}
+
+type throttleTracker struct {
+	count         uint64
+	lastThrottled time.Time
+	flowSchema    string
+	priorityLevel string
+	retryAfter    string
+	sync.RWMutex
+}
+
+var throttle = &throttleTracker{}
+
+func (t *throttleTracker) record(resp *http.Response) {
+	t.Lock()
+	defer t.Unlock()
+	t.count++
+	t.lastThrottled = time.Now()
+	t.flowSchema = resp.Header.Get(headerFlowSchemaUID)
+	t.priorityLevel = resp.Header.Get(headerPriorityLevelUID)
+	t.retryAfter = resp.Header.Get("Retry-After")
+}
+
+// GetThrottleState returns whether the api-server throttled the shim recently,
+// this tells api-server backpressure apart from slowness of the scheduler itself.
+func GetThrottleState() *ThrottleState {
+	throttle.RLock()
+	defer throttle.RUnlock()
+	state := &ThrottleState{
+		ThrottledCount:    throttle.count,
+		LastFlowSchema:    throttle.flowSchema,
+		LastPriorityLevel: throttle.priorityLevel,
+		LastRetryAfter:    throttle.retryAfter,
+		ThrottleWindowMs:  throttleWindow.Milliseconds(),
+	}
+	if throttle.count > 0 {
+		last := throttle.lastThrottled
+		state.LastThrottled = &last
+		state.Throttled = time.Since(last) < throttleWindow
+	}
+	return state
+}
+
+// throttleDetector inspects the api-server responses for throttling
+type throttleDetector struct {
+	next http.RoundTripper
+}
+
+func newThrottleDetector(rt http.RoundTripper) http.RoundTripper {
+	return &throttleDetector{next: rt}
+}
+
+func (d *throttleDetector) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := d.next.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		throttle.record(resp)
+		source := "server"
+		if resp.Header.Get(headerPriorityLevelUID) != "" {
+			source = "apf"
+		}
+		metrics.GetKubeClientMetrics().IncThrottled(source)
+		log.Log(log.Client).Debug("api-server throttled the request",
+			zap.String("method", req.Method),
+			zap.String("path", req.URL.Path),
+			zap.String("source", source),
+			zap.String("retryAfter", resp.Header.Get("Retry-After")))
+	}
+	return resp, err
+}
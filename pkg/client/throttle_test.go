@@ -0,0 +1,61 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestThrottleDetector(t *testing.T) {
+	throttled := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if throttled {
+			w.Header().Set(headerFlowSchemaUID, "flow-schema-uid")
+			w.Header().Set(headerPriorityLevelUID, "priority-level-uid")
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: newThrottleDetector(http.DefaultTransport)}
+
+	before := GetThrottleState().ThrottledCount
+	resp, err := client.Get(server.URL)
+	assert.NilError(t, err)
+	assert.NilError(t, resp.Body.Close())
+	state := GetThrottleState()
+	assert.Assert(t, state.Throttled)
+	assert.Equal(t, state.ThrottledCount, before+1)
+	assert.Equal(t, state.LastFlowSchema, "flow-schema-uid")
+	assert.Equal(t, state.LastPriorityLevel, "priority-level-uid")
+	assert.Equal(t, state.LastRetryAfter, "1")
+
+	// successful responses are not counted
+	throttled = false
+	resp, err = client.Get(server.URL)
+	assert.NilError(t, err)
+	assert.NilError(t, resp.Body.Close())
+	assert.Equal(t, GetThrottleState().ThrottledCount, before+1)
+}
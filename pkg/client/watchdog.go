@@ -0,0 +1,356 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+)
+
+// returned by the watch of a restarted informer, the reflector then lists all the objects again
+var errRelistRequested = errors.New("re-list requested by the informer watchdog")
+
+// InformerHealth describes the state of an informer as seen by the watchdog
+type InformerHealth struct {
+	Resource     string     `json:"resource"`
+	Healthy      bool       `json:"healthy"`
+	Synced       bool       `json:"synced"`
+	Restartable  bool       `json:"restartable"`
+	Restarts     int        `json:"restarts"`
+	LastEvent    *time.Time `json:"lastEvent,omitempty"`
+	FailingSince *time.Time `json:"failingSince,omitempty"`
+	LastError    string     `json:"lastError,omitempty"`
+}
+
+// InformersHealth is the health of all the watched informers,
+// the shim is only healthy when all the informers are healthy.
+type InformersHealth struct {
+	Healthy   bool              `json:"healthy"`
+	Informers []*InformerHealth `json:"informers"`
+}
+
+type informerState struct {
+	informer     cache.SharedIndexInformer
+	restarts     int
+	lastEvent    time.Time
+	lastRestart  time.Time
+	failingSince time.Time
+	failingRV    string
+	lastError    string
+	recovering   bool
+}
+
+// informerWatchdog detects informers that fail to list or watch, or stop delivering events.
+// A failing informer is restarted, when possible, and reported unhealthy until it has listed
+// all objects again. The re-list delivers all objects to the event handlers again: the caches
+// that depend on the informer are rebuilt from these events.
+type informerWatchdog struct {
+	informers      map[string]*informerState
+	restartFuncs   map[string]func()
+	failureTimeout time.Duration
+	stallTimeout   time.Duration
+	sync.RWMutex
+}
+
+var watchdog = newInformerWatchdog()
+
+func newInformerWatchdog() *informerWatchdog {
+	return &informerWatchdog{
+		informers:      make(map[string]*informerState),
+		restartFuncs:   make(map[string]func()),
+		failureTimeout: conf.DefaultInformerFailure,
+	}
+}
+
+// registers the function that restarts the informer of the resource
+func (w *informerWatchdog) setRestartFunc(resource string, restart func()) {
+	w.Lock()
+	defer w.Unlock()
+	w.restartFuncs[resource] = restart
+}
+
+// starts watching the informer, this must be called before the informer is started
+func (w *informerWatchdog) watch(resource string, informer cache.SharedIndexInformer) {
+	w.Lock()
+	defer w.Unlock()
+	if _, ok := w.informers[resource]; ok {
+		return
+	}
+	w.informers[resource] = &informerState{
+		informer:  informer,
+		lastEvent: time.Now(),
+	}
+	if err := informer.SetWatchErrorHandler(func(r *cache.Reflector, err error) {
+		w.recordError(resource, err)
+		cache.DefaultWatchErrorHandler(r, err)
+	}); err != nil {
+		log.Log(log.Client).Warn("informer errors are not tracked by the watchdog",
+			zap.String("resource", resource),
+			zap.Error(err))
+	}
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			w.recordEvent(resource)
+			// the objects listed at startup or after a restart were not created just now
+			if informer.HasSynced() {
+				observeEventDelay(resource, obj)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) { w.recordEvent(resource) },
+		DeleteFunc: func(obj interface{}) { w.recordEvent(resource) },
+	})
+}
+
+func (w *informerWatchdog) recordEvent(resource string) {
+	w.Lock()
+	defer w.Unlock()
+	if state, ok := w.informers[resource]; ok {
+		state.lastEvent = time.Now()
+		metrics.GetCacheMetrics().SetInformerLastEvent(resource, state.lastEvent)
+	}
+}
+
+// observes the time between the creation of the object and the delivery of its add event
+func observeEventDelay(resource string, obj interface{}) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	created := accessor.GetCreationTimestamp()
+	if created.IsZero() {
+		return
+	}
+	metrics.GetCacheMetrics().ObserveInformerEventDelay(resource, time.Since(created.Time))
+}
+
+func (w *informerWatchdog) recordError(resource string, err error) {
+	if errors.Is(err, errRelistRequested) {
+		return
+	}
+	w.Lock()
+	defer w.Unlock()
+	state, ok := w.informers[resource]
+	if !ok {
+		return
+	}
+	if state.failingSince.IsZero() {
+		state.failingSince = time.Now()
+		state.failingRV = state.informer.LastSyncResourceVersion()
+	}
+	state.lastError = err.Error()
+}
+
+func (w *informerWatchdog) run(stopCh <-chan struct{}, interval, failureTimeout, stallTimeout time.Duration) {
+	w.Lock()
+	w.failureTimeout = failureTimeout
+	w.stallTimeout = stallTimeout
+	w.Unlock()
+	log.Log(log.Client).Info("starting informer watchdog",
+		zap.Duration("interval", interval),
+		zap.Duration("failureTimeout", failureTimeout),
+		zap.Duration("stallTimeout", stallTimeout))
+	wait.Until(func() {
+		w.check(time.Now())
+	}, interval, stopCh)
+}
+
+// checks all the informers, the informers that failed for too long or stalled are restarted
+func (w *informerWatchdog) check(now time.Time) {
+	w.Lock()
+	defer w.Unlock()
+	for resource, state := range w.informers {
+		// any new resource version means the informer has listed or watched successfully since the failure
+		if !state.failingSince.IsZero() && state.informer.LastSyncResourceVersion() != state.failingRV {
+			log.Log(log.Client).Info("informer recovered",
+				zap.String("resource", resource),
+				zap.Duration("failedFor", now.Sub(state.failingSince)))
+			state.failingSince = time.Time{}
+			state.failingRV = ""
+			state.lastError = ""
+			state.recovering = false
+		}
+		failed := !state.failingSince.IsZero() && now.Sub(state.failingSince) > w.failureTimeout
+		stalled := w.stallTimeout > 0 && state.informer.HasSynced() && now.Sub(state.lastEvent) > w.stallTimeout
+		if !failed && !stalled {
+			continue
+		}
+		// give a restarted informer the time to re-list before it is restarted again
+		if state.recovering && now.Sub(state.lastRestart) <= w.failureTimeout {
+			continue
+		}
+		restart, ok := w.restartFuncs[resource]
+		if !ok {
+			if failed {
+				log.Log(log.Client).Warn("informer is failing and cannot be restarted",
+					zap.String("resource", resource),
+					zap.String("lastError", state.lastError))
+			}
+			continue
+		}
+		log.Log(log.Client).Warn("restarting informer",
+			zap.String("resource", resource),
+			zap.Bool("failed", failed),
+			zap.Bool("stalled", stalled),
+			zap.String("lastError", state.lastError))
+		if state.failingSince.IsZero() {
+			state.failingSince = now
+			state.failingRV = state.informer.LastSyncResourceVersion()
+		}
+		state.restarts++
+		state.lastRestart = now
+		state.lastEvent = now
+		state.recovering = true
+		restart()
+	}
+}
+
+// restarts all the informers that can be restarted, returns the restarted resources
+func (w *informerWatchdog) relist(now time.Time) []string {
+	w.Lock()
+	defer w.Unlock()
+	resources := make([]string, 0, len(w.restartFuncs))
+	for resource, restart := range w.restartFuncs {
+		// the forced re-list is not a failure, the informer stays healthy
+		if state, ok := w.informers[resource]; ok {
+			state.restarts++
+			state.lastRestart = now
+			state.lastEvent = now
+		}
+		restart()
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+	return resources
+}
+
+func (w *informerWatchdog) getHealth(now time.Time) *InformersHealth {
+	w.RLock()
+	defer w.RUnlock()
+	health := &InformersHealth{
+		Healthy:   true,
+		Informers: make([]*InformerHealth, 0, len(w.informers)),
+	}
+	for resource, state := range w.informers {
+		_, restartable := w.restartFuncs[resource]
+		informerHealth := &InformerHealth{
+			Resource:    resource,
+			Synced:      state.informer.HasSynced(),
+			Restartable: restartable,
+			Restarts:    state.restarts,
+			LastError:   state.lastError,
+		}
+		lastEvent := state.lastEvent
+		informerHealth.LastEvent = &lastEvent
+		failed := false
+		if !state.failingSince.IsZero() {
+			failingSince := state.failingSince
+			informerHealth.FailingSince = &failingSince
+			failed = state.recovering || now.Sub(failingSince) > w.failureTimeout
+		}
+		informerHealth.Healthy = informerHealth.Synced && !failed
+		health.Healthy = health.Healthy && informerHealth.Healthy
+		health.Informers = append(health.Informers, informerHealth)
+	}
+	return health
+}
+
+// GetInformerHealth returns the health of the informers watched by the watchdog
+func GetInformerHealth() *InformersHealth {
+	return watchdog.getHealth(time.Now())
+}
+
+// RelistInformers forces all the restartable informers to list all the objects again,
+// the objects are delivered to the event handlers again. Returns the re-listed resources.
+func RelistInformers() []string {
+	resources := watchdog.relist(time.Now())
+	log.Log(log.Client).Info("re-listing informers",
+		zap.Strings("resources", resources))
+	return resources
+}
+
+// restartableListWatch allows the watchdog to restart an informer: the informers cannot be stopped and
+// started again, instead the current watch is stopped and the reflector is forced to list all objects.
+type restartableListWatch struct {
+	lw      cache.ListerWatcher
+	current watch.Interface
+	relist  bool
+	sync.Mutex
+}
+
+func (r *restartableListWatch) List(options metav1.ListOptions) (runtime.Object, error) {
+	r.Lock()
+	r.relist = false
+	r.Unlock()
+	return r.lw.List(options)
+}
+
+func (r *restartableListWatch) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	r.Lock()
+	relist := r.relist
+	r.Unlock()
+	if relist {
+		return nil, errRelistRequested
+	}
+	w, err := r.lw.Watch(options)
+	if err != nil {
+		return nil, err
+	}
+	r.Lock()
+	defer r.Unlock()
+	// restarted while the watch was started
+	if r.relist {
+		w.Stop()
+		return nil, errRelistRequested
+	}
+	r.current = w
+	return w, nil
+}
+
+func (r *restartableListWatch) restart() {
+	r.Lock()
+	defer r.Unlock()
+	r.relist = true
+	if r.current != nil {
+		r.current.Stop()
+		r.current = nil
+	}
+}
+
+// returns an informer the watchdog can restart
+func newRestartableInformer(resource string, lw cache.ListerWatcher, objType runtime.Object,
+	resyncPeriod time.Duration) cache.SharedIndexInformer {
+	rlw := &restartableListWatch{lw: lw}
+	watchdog.setRestartFunc(resource, rlw.restart)
+	return cache.NewSharedIndexInformer(rlw, objType, resyncPeriod,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+}
@@ -0,0 +1,172 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// informer with a controllable sync state and resource version
+type stubInformer struct {
+	cache.SharedIndexInformer
+	synced bool
+	rv     string
+}
+
+func (i *stubInformer) AddEventHandler(handler cache.ResourceEventHandler) {}
+
+func (i *stubInformer) SetWatchErrorHandler(handler cache.WatchErrorHandler) error {
+	return nil
+}
+
+func (i *stubInformer) HasSynced() bool {
+	return i.synced
+}
+
+func (i *stubInformer) LastSyncResourceVersion() string {
+	return i.rv
+}
+
+func TestWatchdogRestartFailingInformer(t *testing.T) {
+	wd := newInformerWatchdog()
+	wd.failureTimeout = 2 * time.Minute
+	informer := &stubInformer{synced: true, rv: "1"}
+	wd.watch("pods", informer)
+	restarts := 0
+	wd.setRestartFunc("pods", func() { restarts++ })
+
+	start := time.Now()
+	wd.check(start)
+	assert.Assert(t, wd.getHealth(start).Healthy)
+
+	// failing within the timeout
+	wd.recordError("pods", fmt.Errorf("connection refused"))
+	wd.check(start.Add(time.Minute))
+	assert.Equal(t, restarts, 0)
+	assert.Assert(t, wd.getHealth(start.Add(time.Minute)).Healthy)
+
+	// failing for too long, restarted and unhealthy until it re-listed
+	wd.check(start.Add(3 * time.Minute))
+	assert.Equal(t, restarts, 1)
+	health := wd.getHealth(start.Add(3 * time.Minute))
+	assert.Assert(t, !health.Healthy)
+	assert.Equal(t, health.Informers[0].Restarts, 1)
+	assert.Equal(t, health.Informers[0].LastError, "connection refused")
+	// not restarted again while recovering
+	wd.check(start.Add(4 * time.Minute))
+	assert.Equal(t, restarts, 1)
+
+	informer.rv = "2"
+	wd.check(start.Add(5 * time.Minute))
+	health = wd.getHealth(start.Add(5 * time.Minute))
+	assert.Assert(t, health.Healthy)
+	assert.Assert(t, health.Informers[0].FailingSince == nil)
+	assert.Equal(t, health.Informers[0].LastError, "")
+
+	// the forced re-list is not a failure
+	wd.recordError("pods", errRelistRequested)
+	assert.Assert(t, wd.getHealth(start.Add(10*time.Minute)).Healthy)
+}
+
+func TestWatchdogStalledInformer(t *testing.T) {
+	wd := newInformerWatchdog()
+	wd.stallTimeout = 10 * time.Minute
+	informer := &stubInformer{synced: true, rv: "1"}
+	wd.watch("nodes", informer)
+	restarts := 0
+	wd.setRestartFunc("nodes", func() { restarts++ })
+	// cannot be restarted, only reported
+	wd.watch("configmaps", &stubInformer{synced: true, rv: "1"})
+
+	now := time.Now()
+	wd.check(now.Add(5 * time.Minute))
+	assert.Equal(t, restarts, 0)
+	wd.check(now.Add(11 * time.Minute))
+	assert.Equal(t, restarts, 1)
+	assert.Assert(t, !wd.getHealth(now.Add(11*time.Minute)).Healthy)
+
+	// unsynced informers are unhealthy
+	informer.rv = "2"
+	informer.synced = false
+	wd.check(now.Add(12 * time.Minute))
+	assert.Assert(t, !wd.getHealth(now.Add(12*time.Minute)).Healthy)
+	informer.synced = true
+	assert.Assert(t, wd.getHealth(now.Add(12*time.Minute)).Healthy)
+}
+
+func TestWatchdogRelist(t *testing.T) {
+	wd := newInformerWatchdog()
+	wd.watch("pods", &stubInformer{synced: true, rv: "1"})
+	wd.watch("configmaps", &stubInformer{synced: true, rv: "1"})
+	restarts := 0
+	wd.setRestartFunc("pods", func() { restarts++ })
+	wd.setRestartFunc("nodes", func() { restarts++ })
+
+	now := time.Now()
+	assert.DeepEqual(t, wd.relist(now), []string{"nodes", "pods"})
+	assert.Equal(t, restarts, 2)
+	health := wd.getHealth(now)
+	assert.Assert(t, health.Healthy)
+	for _, informer := range health.Informers {
+		if informer.Resource == "pods" {
+			assert.Equal(t, informer.Restarts, 1)
+			assert.Assert(t, informer.FailingSince == nil)
+		}
+	}
+	// not restarted by the next check
+	wd.check(now.Add(time.Minute))
+	assert.Equal(t, restarts, 2)
+}
+
+func TestRestartableListWatch(t *testing.T) {
+	watcher := watch.NewFake()
+	lists := 0
+	rlw := &restartableListWatch{lw: &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			lists++
+			return nil, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return watcher, nil
+		},
+	}}
+	w, err := rlw.Watch(metav1.ListOptions{})
+	assert.NilError(t, err)
+
+	// the current watch is stopped and watches fail until the next list
+	rlw.restart()
+	_, ok := <-w.ResultChan()
+	assert.Assert(t, !ok, "watch not stopped")
+	_, err = rlw.Watch(metav1.ListOptions{})
+	assert.Equal(t, err, errRelistRequested)
+	_, err = rlw.List(metav1.ListOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, lists, 1)
+	watcher = watch.NewFake()
+	_, err = rlw.Watch(metav1.ListOptions{})
+	assert.NilError(t, err)
+}
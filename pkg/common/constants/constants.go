@@ -36,9 +36,13 @@ const AppTagNamespace = "namespace"
 const AppTagNamespaceResourceQuota = "namespace.resourcequota"
 const AppTagNamespaceParentQueue = "namespace.parentqueue"
 const AppTagStateAwareDisable = "application.stateaware.disable"
+const AppTagIntraAppPreemption = "application.preemption.intra"
+const AnnotationAllowIntraAppPreemption = "yunikorn.apache.org/allow-intra-application-preemption"
 const DefaultAppNamespace = "default"
 const DefaultUserLabel = "yunikorn.apache.org/username"
 const DefaultUser = "nobody"
+const AnnotationUserInfo = "yunikorn.apache.org/user.info"
+const AnnotationCorrelationID = "yunikorn.apache.org/correlation-id"
 
 // Resource
 const Memory = "memory"
@@ -52,6 +56,11 @@ const SparkLabelRoleDriver = "driver"
 // Configuration
 const DefaultConfigMapName = "yunikorn-configs"
 const SchedulerName = "yunikorn"
+const EnvSchedulerPodName = "POD_NAME"
+const EnvSchedulerPodNamespace = "POD_NAMESPACE"
+const DefaultSchedulerNamespace = "default"
+const LabelQueuesFragment = "yunikorn.apache.org/queues-fragment"
+const FieldManager = "yunikorn-scheduler"
 
 // OwnerReferences
 const DaemonSetType = "DaemonSet"
@@ -75,5 +84,50 @@ const SchedulingPolicyStyleParamDefault = "Soft"
 
 var SchedulingPolicyStyleParamValues = map[string]string{"Hard": "Hard", "Soft": "Soft"}
 
+// Namespace scheduling policy overrides
+const AnnotationNamespaceGangSchedulingStyle = "yunikorn.apache.org/namespace.gangSchedulingStyle"
+const AnnotationNamespacePlaceholderTimeout = "yunikorn.apache.org/namespace.placeholderTimeoutInSeconds"
+const AnnotationNamespacePlaceholderImage = "yunikorn.apache.org/namespace.placeholderImage"
+
+// Namespace priority fencing: the offset is added to the priority of the pods, the result is capped at the fence
+const AnnotationNamespacePriorityOffset = "yunikorn.apache.org/namespace.priority.offset"
+const AnnotationNamespacePriorityFence = "yunikorn.apache.org/namespace.priority.fence"
+const AppTagNamespacePriorityOffset = "namespace.priority.offset"
+const AppTagNamespacePriorityFence = "namespace.priority.fence"
+
+// Namespace user and group quotas: a JSON object of the resources of each user or group, the tags hold the
+// same object with the resources converted to the scheduler resources
+const AnnotationNamespaceUserQuota = "yunikorn.apache.org/namespace.user.quota"
+const AnnotationNamespaceGroupQuota = "yunikorn.apache.org/namespace.group.quota"
+const AppTagNamespaceUserQuota = "namespace.userquota"
+const AppTagNamespaceGroupQuota = "namespace.groupquota"
+
+// Namespace submission ACL: a JSON object of the users and groups allowed to submit to each queue,
+// the ACL is checked against the service accounts of the pods
+const AnnotationNamespaceQueueACL = "yunikorn.apache.org/namespace.queue.acl"
+
+// Preemption
+const TagAllowPreemptSelf = "yunikorn.apache.org/allow-preempt-self"
+const TagAllowPreemptOther = "yunikorn.apache.org/allow-preempt-other"
+// the priority of the gang of a placeholder, the preemptors with a higher priority preempt the placeholder first
+const TagPreemptFirst = "yunikorn.apache.org/preempt-first"
+const AnnotationPreemptedByApplication = "yunikorn.apache.org/preempted-by-application"
+const AnnotationPreemptedByQueue = "yunikorn.apache.org/preempted-by-queue"
+
+// the system critical priority and priority classes of kubernetes, the pods that have them are never preempted
+const SystemCriticalPriority = 2 * 1000000000
+const SystemClusterCritical = "system-cluster-critical"
+const SystemNodeCritical = "system-node-critical"
+
 const ApplicationInsufficientResourcesFailure = "ResourceReservationTimeout"
 const ApplicationRejectedFailure = "ApplicationRejected"
+const ApplicationGangPreemptedFailure = "GangMemberPreempted"
+const ApplicationProvisioningFailure = "ProvisioningFailed"
+
+// Cluster autoscaler
+const AnnotationConsumeProvisioningRequest = "autoscaling.x-k8s.io/consume-provisioning-request"
+const AnnotationProvisioningClassName = "autoscaling.x-k8s.io/provisioning-class-name"
+const AnnotationEquivalentPod = "yunikorn.apache.org/equivalent-pod"
+
+// Karpenter
+const AnnotationDoNotDisrupt = "karpenter.sh/do-not-disrupt"
@@ -0,0 +1,88 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package events
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// correlatingRecorder adds the correlation ID of the task to the events of the pods, the same ID
+// is logged by the task: the events and the logs of a scheduling attempt can be traced together.
+type correlatingRecorder struct {
+	recorder record.EventRecorder
+}
+
+func newCorrelatingRecorder(recorder record.EventRecorder) *correlatingRecorder {
+	return &correlatingRecorder{recorder: recorder}
+}
+
+func (r *correlatingRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	if annotations := getCorrelationAnnotations(object, nil); annotations != nil {
+		r.recorder.AnnotatedEventf(object, annotations, eventtype, reason, "%s", message)
+		return
+	}
+	r.recorder.Event(object, eventtype, reason, message)
+}
+
+func (r *correlatingRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	if annotations := getCorrelationAnnotations(object, nil); annotations != nil {
+		r.recorder.AnnotatedEventf(object, annotations, eventtype, reason, messageFmt, args...)
+		return
+	}
+	r.recorder.Eventf(object, eventtype, reason, messageFmt, args...)
+}
+
+func (r *correlatingRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	if correlated := getCorrelationAnnotations(object, annotations); correlated != nil {
+		annotations = correlated
+	}
+	r.recorder.AnnotatedEventf(object, annotations, eventtype, reason, messageFmt, args...)
+}
+
+// the recorder of client-go has no past events, the time the event happened at is kept in an annotation
+func (r *correlatingRecorder) PastEventf(object runtime.Object, timestamp metav1.Time, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.AnnotatedEventf(object, getPastEventAnnotations(timestamp), eventtype, reason, messageFmt, args...)
+}
+
+// returns a copy of the annotations with the correlation ID of the task added, nil when the object
+// is not the pod of a task
+func getCorrelationAnnotations(object runtime.Object, annotations map[string]string) map[string]string {
+	pod, ok := object.(*v1.Pod)
+	if !ok {
+		return nil
+	}
+	appID, err := utils.GetApplicationIDFromPod(pod)
+	if err != nil {
+		return nil
+	}
+	correlated := make(map[string]string, len(annotations)+1)
+	for k, v := range annotations {
+		correlated[k] = v
+	}
+	// the task ID is the UID of the pod
+	correlated[constants.AnnotationCorrelationID] = log.GetCorrelationID(appID, string(pod.UID))
+	return correlated
+}
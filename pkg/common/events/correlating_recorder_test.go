@@ -0,0 +1,78 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package events
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+)
+
+// records the annotations of the events
+type annotationsRecorder struct {
+	MockedRecorder
+	annotations []map[string]string
+}
+
+func (r *annotationsRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	r.annotations = append(r.annotations, nil)
+}
+
+func (r *annotationsRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.annotations = append(r.annotations, nil)
+}
+
+func (r *annotationsRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.annotations = append(r.annotations, annotations)
+}
+
+func TestCorrelatingRecorder(t *testing.T) {
+	mock := &annotationsRecorder{}
+	recorder := newCorrelatingRecorder(mock)
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "pod-1",
+			UID:    "uid-1",
+			Labels: map[string]string{constants.LabelApplicationID: "app-1"},
+		},
+	}
+	recorder.Eventf(pod, v1.EventTypeNormal, "Scheduled", "scheduled %s", "pod-1")
+	recorder.Event(pod, v1.EventTypeNormal, "Scheduled", "scheduled")
+	recorder.AnnotatedEventf(pod, map[string]string{"key": "value"}, v1.EventTypeNormal, "Scheduled", "scheduled")
+	past := metav1.Unix(1600000000, 0)
+	recorder.PastEventf(pod, past, v1.EventTypeNormal, "Scheduled", "scheduled")
+	// the objects that are not pods of an application are not correlated
+	recorder.Eventf(&v1.Pod{}, v1.EventTypeNormal, "Scheduled", "scheduled")
+	recorder.Eventf(&v1.Node{}, v1.EventTypeNormal, "Ready", "ready")
+
+	correlated := map[string]string{constants.AnnotationCorrelationID: "app-1/uid-1"}
+	assert.DeepEqual(t, mock.annotations, []map[string]string{
+		correlated,
+		correlated,
+		{constants.AnnotationCorrelationID: "app-1/uid-1", "key": "value"},
+		{constants.AnnotationCorrelationID: "app-1/uid-1", pastEventTimeAnnotation: "2020-09-13T12:26:40Z"},
+		nil,
+		nil,
+	})
+}
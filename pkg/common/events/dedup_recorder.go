@@ -0,0 +1,177 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package events
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// the annotation of a past event that keeps the time the event happened at
+const pastEventTimeAnnotation = "yunikorn.apache.org/event-time"
+
+func getPastEventAnnotations(timestamp metav1.Time) map[string]string {
+	return map[string]string{pastEventTimeAnnotation: timestamp.UTC().Format(time.RFC3339)}
+}
+
+// the events of an object with the same type and reason within a window
+type eventGroup struct {
+	object      runtime.Object
+	annotations map[string]string
+	eventType   string
+	reason      string
+	message     string
+	start       time.Time
+	repeats     int
+}
+
+// dedupRecorder sends the first event of an object with a type and reason to the wrapped
+// recorder, the repeats within the window are collapsed into one event with a count sent when
+// the window ends. The events sent to the wrapped recorder are rate limited, the events above
+// the limit are dropped.
+type dedupRecorder struct {
+	recorder record.EventRecorder
+	window   time.Duration
+	limiter  flowcontrol.RateLimiter
+	groups   map[string]*eventGroup
+	sync.Mutex
+}
+
+func newDedupRecorder(recorder record.EventRecorder, window time.Duration, qps, burst int) *dedupRecorder {
+	r := &dedupRecorder{
+		recorder: recorder,
+		window:   window,
+		groups:   make(map[string]*eventGroup),
+	}
+	if qps > 0 {
+		r.limiter = flowcontrol.NewTokenBucketRateLimiter(float32(qps), burst)
+	}
+	if window > 0 {
+		go func() {
+			ticker := time.NewTicker(window / 2)
+			defer ticker.Stop()
+			for now := range ticker.C {
+				r.flush(now)
+			}
+		}()
+	}
+	return r
+}
+
+func (r *dedupRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	r.record(object, nil, eventtype, reason, message, time.Now())
+}
+
+func (r *dedupRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.record(object, nil, eventtype, reason, fmt.Sprintf(messageFmt, args...), time.Now())
+}
+
+func (r *dedupRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.record(object, annotations, eventtype, reason, fmt.Sprintf(messageFmt, args...), time.Now())
+}
+
+// past events are not deduplicated, they are only rate limited. The recorder of client-go has no past
+// events: the event is recorded now and the time it happened at is kept in an annotation.
+func (r *dedupRecorder) PastEventf(object runtime.Object, timestamp metav1.Time, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.send(object, getPastEventAnnotations(timestamp), eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r *dedupRecorder) record(object runtime.Object, annotations map[string]string, eventtype, reason, message string, now time.Time) {
+	if r.window > 0 {
+		key := getEventKey(object, eventtype, reason)
+		r.Lock()
+		if group, ok := r.groups[key]; ok && now.Sub(group.start) < r.window {
+			group.repeats++
+			group.message = message
+			r.Unlock()
+			return
+		}
+		r.groups[key] = &eventGroup{
+			object:      object,
+			annotations: annotations,
+			eventType:   eventtype,
+			reason:      reason,
+			start:       now,
+		}
+		r.Unlock()
+	}
+	r.send(object, annotations, eventtype, reason, message)
+}
+
+// sends the collapsed events of the groups with an ended window and removes the groups
+func (r *dedupRecorder) flush(now time.Time) {
+	r.Lock()
+	ended := make([]*eventGroup, 0)
+	for key, group := range r.groups {
+		if now.Sub(group.start) >= r.window {
+			delete(r.groups, key)
+			if group.repeats > 0 {
+				ended = append(ended, group)
+			}
+		}
+	}
+	r.Unlock()
+	for _, group := range ended {
+		r.send(group.object, group.annotations, group.eventType, group.reason,
+			fmt.Sprintf("%s (repeated %d times in %v)", group.message, group.repeats, r.window))
+	}
+}
+
+func (r *dedupRecorder) send(object runtime.Object, annotations map[string]string, eventtype, reason, message string) {
+	if !r.allow(reason) {
+		return
+	}
+	if annotations != nil {
+		r.recorder.AnnotatedEventf(object, annotations, eventtype, reason, "%s", message)
+	} else {
+		r.recorder.Event(object, eventtype, reason, message)
+	}
+}
+
+func (r *dedupRecorder) allow(reason string) bool {
+	if r.limiter == nil || r.limiter.TryAccept() {
+		return true
+	}
+	log.Logger().Debug("event rate limit reached, dropping event",
+		zap.String("reason", reason))
+	return false
+}
+
+// the events of the same object with the same type and reason share a key
+func getEventKey(object runtime.Object, eventtype, reason string) string {
+	id := fmt.Sprintf("%T", object)
+	if accessor, err := meta.Accessor(object); err == nil {
+		if accessor.GetUID() != "" {
+			id += "/" + string(accessor.GetUID())
+		} else {
+			id += "/" + accessor.GetNamespace() + "/" + accessor.GetName()
+		}
+	}
+	return id + "/" + eventtype + "/" + reason
+}
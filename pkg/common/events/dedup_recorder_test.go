@@ -0,0 +1,116 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package events
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+)
+
+func newTestPod(name string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			UID:       types.UID("uid-" + name),
+		},
+	}
+}
+
+func drainEvents(recorder *record.FakeRecorder) []string {
+	events := make([]string, 0)
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestDedupRecorder(t *testing.T) {
+	fake := record.NewFakeRecorder(100)
+	recorder := newDedupRecorder(fake, 0, 0, 0)
+	// the window is set after the creation to control the flush
+	recorder.window = time.Minute
+	pod1 := newTestPod("pod-1")
+	pod2 := newTestPod("pod-2")
+	now := time.Now()
+
+	recorder.record(pod1, nil, v1.EventTypeWarning, "FailedScheduling", "no fit 1", now)
+	recorder.record(pod1, nil, v1.EventTypeWarning, "FailedScheduling", "no fit 2", now.Add(time.Second))
+	recorder.record(pod1, nil, v1.EventTypeWarning, "FailedScheduling", "no fit 3", now.Add(2*time.Second))
+	recorder.record(pod1, nil, v1.EventTypeNormal, "Scheduled", "scheduled", now.Add(3*time.Second))
+	recorder.record(pod2, nil, v1.EventTypeWarning, "FailedScheduling", "no fit", now.Add(4*time.Second))
+	assert.DeepEqual(t, drainEvents(fake), []string{
+		"Warning FailedScheduling no fit 1",
+		"Normal Scheduled scheduled",
+		"Warning FailedScheduling no fit",
+	})
+
+	// the repeats are sent as one event when the window ends
+	recorder.flush(now.Add(30 * time.Second))
+	assert.Equal(t, len(drainEvents(fake)), 0)
+	recorder.flush(now.Add(time.Minute + 5*time.Second))
+	assert.DeepEqual(t, drainEvents(fake), []string{
+		"Warning FailedScheduling no fit 3 (repeated 2 times in 1m0s)",
+	})
+	assert.Equal(t, len(recorder.groups), 0)
+
+	// a new window starts with the next event
+	recorder.record(pod1, nil, v1.EventTypeWarning, "FailedScheduling", "no fit 4", now.Add(2*time.Minute))
+	assert.DeepEqual(t, drainEvents(fake), []string{"Warning FailedScheduling no fit 4"})
+}
+
+func TestDedupRecorderRateLimit(t *testing.T) {
+	fake := record.NewFakeRecorder(100)
+	recorder := newDedupRecorder(fake, 0, 1, 2)
+	for i := 0; i < 5; i++ {
+		recorder.Eventf(newTestPod("pod"), v1.EventTypeNormal, "Scheduled", "scheduled %d", i)
+	}
+	// only the burst is sent
+	assert.DeepEqual(t, drainEvents(fake), []string{
+		"Normal Scheduled scheduled 0",
+		"Normal Scheduled scheduled 1",
+	})
+}
+
+func TestDedupRecorderPastEvent(t *testing.T) {
+	fake := record.NewFakeRecorder(100)
+	recorder := newDedupRecorder(fake, 0, 0, 0)
+	recorder.window = time.Minute
+	// the past events are recorded now and are not collapsed
+	past := metav1.NewTime(time.Now().Add(-time.Hour))
+	recorder.PastEventf(newTestPod("pod"), past, v1.EventTypeNormal, "Scheduled", "scheduled %d", 1)
+	recorder.PastEventf(newTestPod("pod"), past, v1.EventTypeNormal, "Scheduled", "scheduled %d", 2)
+	assert.DeepEqual(t, drainEvents(fake), []string{
+		"Normal Scheduled scheduled 1",
+		"Normal Scheduled scheduled 2",
+	})
+	assert.DeepEqual(t, getPastEventAnnotations(past), map[string]string{
+		pastEventTimeAnnotation: past.UTC().Format(time.RFC3339),
+	})
+}
@@ -27,8 +27,8 @@ import (
 	"k8s.io/client-go/tools/record"
 
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
-	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
 )
 
 var eventRecorder record.EventRecorder = record.NewFakeRecorder(1024)
@@ -43,12 +43,24 @@ func GetRecorder() record.EventRecorder {
 		// in test mode we should skip this and just use a fake recorder instead.
 		configs := conf.GetSchedulerConf()
 		if !configs.IsTestMode() {
-			k8sClient := client.NewKubeClient(configs.KubeConfig)
 			eventBroadcaster := record.NewBroadcaster()
-			eventBroadcaster.StartRecordingToSink(&v1.EventSinkImpl{
-				Interface: k8sClient.GetClientSet().CoreV1().Events("")})
-			eventRecorder = eventBroadcaster.NewRecorder(scheme.Scheme,
-				corev1.EventSource{Component: constants.SchedulerName})
+			switch {
+			case configs.EventRecorderSink == conf.RecorderSinkNone:
+				// the events are dropped by the broadcaster without a sink
+			case configs.DryRun || configs.EventRecorderSink == conf.RecorderSinkLog:
+				// in dry-run mode the events are only logged, never written to the cluster
+				eventBroadcaster.StartLogging(log.Logger().Sugar().Debugf)
+			default:
+				k8sClient := client.NewKubeClient(configs.KubeConfig)
+				eventBroadcaster.StartRecordingToSink(&v1.EventSinkImpl{
+					Interface: k8sClient.GetClientSet().CoreV1().Events("")})
+			}
+			eventRecorder = newCorrelatingRecorder(eventBroadcaster.NewRecorder(scheme.Scheme,
+				corev1.EventSource{Component: configs.EventComponent}))
+			// mass pending pods must not flood the api-server with near-identical events
+			if configs.EventDedupWindow > 0 || configs.EventQPS > 0 {
+				eventRecorder = newDedupRecorder(eventRecorder, configs.EventDedupWindow, configs.EventQPS, configs.EventBurst)
+			}
 		}
 	})
 
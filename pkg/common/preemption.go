@@ -0,0 +1,87 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package common
+
+import (
+	"math"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+)
+
+// the annotation the kubelet sets on the pods it reads from a file or an URL instead of the api-server
+const configSourceAnnotation = "kubernetes.io/config.source"
+
+// IsCriticalPod returns true for the pods with a system critical priority or priority class
+func IsCriticalPod(pod *v1.Pod) bool {
+	if pod.Spec.Priority != nil && *pod.Spec.Priority >= constants.SystemCriticalPriority {
+		return true
+	}
+	return pod.Spec.PriorityClassName == constants.SystemClusterCritical ||
+		pod.Spec.PriorityClassName == constants.SystemNodeCritical
+}
+
+// IsStaticPod returns true for the pods the kubelet runs from its manifests, only their mirror is in the api-server
+func IsStaticPod(pod *v1.Pod) bool {
+	if _, ok := pod.Annotations[v1.MirrorPodAnnotationKey]; ok {
+		return true
+	}
+	source, ok := pod.Annotations[configSourceAnnotation]
+	return ok && source != "api"
+}
+
+// IsPreemptible returns false for the pods that must never be preemption victims: evicting a static pod
+// does not stop it, and the critical pods, including the critical DaemonSet pods, keep the node working.
+func IsPreemptible(pod *v1.Pod) bool {
+	return !IsCriticalPod(pod) && !IsStaticPod(pod)
+}
+
+// CanPreempt returns false for the pods that opted out of preempting other pods
+func CanPreempt(pod *v1.Pod) bool {
+	return pod.Spec.PreemptionPolicy == nil || *pod.Spec.PreemptionPolicy != v1.PreemptNever
+}
+
+// GetPodPriority returns the priority of the pod, the pods without a priority have the priority 0
+func GetPodPriority(pod *v1.Pod) int32 {
+	if pod.Spec.Priority == nil {
+		return 0
+	}
+	return *pod.Spec.Priority
+}
+
+// GetEffectivePriority returns the priority of the pod adjusted by the namespace tags of its application:
+// the offset is added to the priority the pod requests and the result is capped at the fence
+func GetEffectivePriority(pod *v1.Pod, appTags map[string]string) int32 {
+	priority := int64(GetPodPriority(pod))
+	if offset, err := strconv.ParseInt(appTags[constants.AppTagNamespacePriorityOffset], 10, 32); err == nil {
+		priority += offset
+	}
+	if fence, err := strconv.ParseInt(appTags[constants.AppTagNamespacePriorityFence], 10, 32); err == nil && priority > fence {
+		priority = fence
+	}
+	switch {
+	case priority > math.MaxInt32:
+		return math.MaxInt32
+	case priority < math.MinInt32:
+		return math.MinInt32
+	}
+	return int32(priority)
+}
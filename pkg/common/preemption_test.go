@@ -0,0 +1,106 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package common
+
+import (
+	"math"
+	"testing"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+)
+
+func TestIsPreemptible(t *testing.T) {
+	criticalPriority := int32(2000000000)
+	userPriority := int32(1000)
+	tests := []struct {
+		name        string
+		pod         *v1.Pod
+		preemptible bool
+	}{
+		{"plain pod", &v1.Pod{}, true},
+		{"user priority", &v1.Pod{Spec: v1.PodSpec{Priority: &userPriority}}, true},
+		{"critical priority", &v1.Pod{Spec: v1.PodSpec{Priority: &criticalPriority}}, false},
+		{"critical priority class", &v1.Pod{Spec: v1.PodSpec{PriorityClassName: "system-node-critical"}}, false},
+		{"mirror pod", &v1.Pod{ObjectMeta: apis.ObjectMeta{
+			Annotations: map[string]string{v1.MirrorPodAnnotationKey: "hash"}}}, false},
+		{"file source", &v1.Pod{ObjectMeta: apis.ObjectMeta{
+			Annotations: map[string]string{"kubernetes.io/config.source": "file"}}}, false},
+		{"api source", &v1.Pod{ObjectMeta: apis.ObjectMeta{
+			Annotations: map[string]string{"kubernetes.io/config.source": "api"}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, IsPreemptible(tt.pod), tt.preemptible)
+		})
+	}
+}
+
+func TestCreateTagsForTaskPreemption(t *testing.T) {
+	never := v1.PreemptNever
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: v1.PodSpec{
+			PriorityClassName: "system-cluster-critical",
+			PreemptionPolicy:  &never,
+		},
+	}
+	tags := CreateTagsForTask(pod)
+	assert.Equal(t, tags[constants.TagAllowPreemptSelf], "false")
+	assert.Equal(t, tags[constants.TagAllowPreemptOther], "false")
+
+	lower := v1.PreemptLowerPriority
+	pod.Spec.PriorityClassName = ""
+	pod.Spec.PreemptionPolicy = &lower
+	tags = CreateTagsForTask(pod)
+	_, ok := tags[constants.TagAllowPreemptSelf]
+	assert.Assert(t, !ok)
+	_, ok = tags[constants.TagAllowPreemptOther]
+	assert.Assert(t, !ok)
+}
+
+func TestGetPodPriority(t *testing.T) {
+	priority := int32(-10)
+	assert.Equal(t, GetPodPriority(&v1.Pod{}), int32(0))
+	assert.Equal(t, GetPodPriority(&v1.Pod{Spec: v1.PodSpec{Priority: &priority}}), int32(-10))
+}
+
+func TestGetEffectivePriority(t *testing.T) {
+	priority := int32(2000)
+	pod := &v1.Pod{Spec: v1.PodSpec{Priority: &priority}}
+	assert.Equal(t, GetEffectivePriority(pod, nil), int32(2000))
+	assert.Equal(t, GetEffectivePriority(pod, map[string]string{
+		constants.AppTagNamespacePriorityOffset: "-500",
+	}), int32(1500))
+	assert.Equal(t, GetEffectivePriority(pod, map[string]string{
+		constants.AppTagNamespacePriorityOffset: "-500",
+		constants.AppTagNamespacePriorityFence:  "1000",
+	}), int32(1000))
+	// the fence caps the priority, it does not raise it
+	assert.Equal(t, GetEffectivePriority(&v1.Pod{}, map[string]string{
+		constants.AppTagNamespacePriorityFence: "1000",
+	}), int32(0))
+	priority = math.MaxInt32
+	assert.Equal(t, GetEffectivePriority(pod, map[string]string{
+		constants.AppTagNamespacePriorityOffset: "10",
+	}), int32(math.MaxInt32))
+}
@@ -19,6 +19,9 @@
 package common
 
 import (
+	"fmt"
+	"sync"
+
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -49,6 +52,39 @@ func (w *ResourceBuilder) Build() *si.Resource {
 	return &si.Resource{Resources: w.resourceMap}
 }
 
+// the pod resource is calculated for every pod that is added or updated, the containers are summed up
+// in a pooled scratch map and the resource is built once to keep the garbage low under a high pod churn.
+// the si objects themselves are not pooled: they are handed over to the core which keeps them.
+var scratchPool = sync.Pool{
+	New: func() interface{} {
+		return make(map[string]int64)
+	},
+}
+
+func getScratch() map[string]int64 {
+	return scratchPool.Get().(map[string]int64)
+}
+
+func putScratch(scratch map[string]int64) {
+	for name := range scratch {
+		delete(scratch, name)
+	}
+	scratchPool.Put(scratch)
+}
+
+// build a resource from the summed up values, the quantities are allocated in one go
+func buildResource(values map[string]int64) *si.Resource {
+	quantities := make([]si.Quantity, len(values))
+	resources := make(map[string]*si.Quantity, len(values))
+	i := 0
+	for name, value := range values {
+		quantities[i].Value = value
+		resources[name] = &quantities[i]
+		i++
+	}
+	return &si.Resource{Resources: resources}
+}
+
 // Get the resources from a pod's containers and convert that into a internal resource.
 // A pod has two resource parts: Requests and Limits.
 // Based on the values a pod gets a QOS assigned, as per
@@ -57,8 +93,6 @@ func (w *ResourceBuilder) Build() *si.Resource {
 // values, limits are ignored in the current setup.
 // BestEffort pods are scheduled using a minimum resource of 1MB only.
 func GetPodResource(pod *v1.Pod) (resource *si.Resource) {
-	// var memory, vcore = int64(0), int64(0)
-	var podResource *si.Resource
 	// A QosBestEffort pod does not request any resources and thus cannot be
 	// scheduled. Handle a QosBestEffort pod by setting a tiny memory value.
 	if qos.GetPodQOS(pod) == v1.PodQOSBestEffort {
@@ -67,37 +101,28 @@ func GetPodResource(pod *v1.Pod) (resource *si.Resource) {
 		return resources.Build()
 	}
 
+	podResource := getScratch()
+	defer putScratch(podResource)
 	for _, c := range pod.Spec.Containers {
-		resourceList := c.Resources.Requests
-		containerResource := getResource(resourceList)
-		podResource = Add(podResource, containerResource)
+		for name, value := range c.Resources.Requests {
+			resourceName, resourceValue := getQuantity(name, value)
+			podResource[resourceName] += resourceValue
+		}
 	}
 
 	// each resource compare between initcontainer and sum of containers
 	// max(sum(Containers requirement), InitContainers requirement)
-	if pod.Spec.InitContainers != nil {
-		checkInitContainerRequest(pod, podResource)
-	}
-
-	return podResource
-}
-
-func checkInitContainerRequest(pod *v1.Pod, containersResources *si.Resource) {
 	for _, c := range pod.Spec.InitContainers {
-		resourceList := c.Resources.Requests
-		ICResource := getResource(resourceList)
-		for resourceName, ICRequest := range ICResource.Resources {
-			containersRequests, exist := containersResources.Resources[resourceName]
+		for name, value := range c.Resources.Requests {
+			resourceName, resourceValue := getQuantity(name, value)
 			// addtional resource request from init cont, add it to request.
-			if !exist {
-				containersResources.Resources[resourceName] = ICRequest
-				continue
-			}
-			if ICRequest.GetValue() > containersRequests.GetValue() {
-				containersResources.Resources[resourceName] = ICRequest
+			if current, exist := podResource[resourceName]; !exist || resourceValue > current {
+				podResource[resourceName] = resourceValue
 			}
 		}
 	}
+
+	return buildResource(podResource)
 }
 
 func GetNodeResource(nodeStatus *v1.NodeStatus) *si.Resource {
@@ -157,23 +182,40 @@ func GetTGResource(resMap map[string]resource.Quantity, members int64) *si.Resou
 	return result.Build()
 }
 
+// ParseResourceList converts the quantities of the resources by name into a resource
+func ParseResourceList(quantities map[string]string) (*si.Resource, error) {
+	resourceList := v1.ResourceList{}
+	for name, value := range quantities {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity %s of resource %s: %v", value, name, err)
+		}
+		resourceList[v1.ResourceName(name)] = quantity
+	}
+	return getResource(resourceList), nil
+}
+
 func getResource(resourceList v1.ResourceList) *si.Resource {
 	resources := NewResourceBuilder()
 	for name, value := range resourceList {
-		switch name {
-		case v1.ResourceMemory:
-			memory := value.ScaledValue(resource.Mega)
-			resources.AddResource(constants.Memory, memory)
-		case v1.ResourceCPU:
-			vcore := value.MilliValue()
-			resources.AddResource(constants.CPU, vcore)
-		default:
-			resources.AddResource(string(name), value.Value())
-		}
+		resourceName, resourceValue := getQuantity(name, value)
+		resources.AddResource(resourceName, resourceValue)
 	}
 	return resources.Build()
 }
 
+// convert a kubernetes quantity into the name and value used in the si resource
+func getQuantity(name v1.ResourceName, value resource.Quantity) (string, int64) {
+	switch name {
+	case v1.ResourceMemory:
+		return constants.Memory, value.ScaledValue(resource.Mega)
+	case v1.ResourceCPU:
+		return constants.CPU, value.MilliValue()
+	default:
+		return string(name), value.Value()
+	}
+}
+
 func Equals(left *si.Resource, right *si.Resource) bool {
 	if left == right {
 		return true
@@ -203,7 +245,7 @@ func Equals(left *si.Resource, right *si.Resource) bool {
 }
 
 func Add(left *si.Resource, right *si.Resource) *si.Resource {
-	result := &si.Resource{Resources: make(map[string]*si.Quantity)}
+	result := &si.Resource{Resources: make(map[string]*si.Quantity, len(left.GetResources())+len(right.GetResources()))}
 	if left == nil && right == nil {
 		return result
 	}
@@ -264,3 +306,14 @@ func IsZero(r *si.Resource) bool {
 	}
 	return true
 }
+
+// FitIn returns true when the smaller resource fits in the larger one, the resources missing from
+// the larger resource count as zero
+func FitIn(larger *si.Resource, smaller *si.Resource) bool {
+	for k, v := range smaller.GetResources() {
+		if v.GetValue() > larger.GetResources()[k].GetValue() {
+			return false
+		}
+	}
+	return true
+}
@@ -25,6 +25,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
@@ -398,6 +399,17 @@ func TestSub(t *testing.T) {
 	}
 }
 
+func TestFitIn(t *testing.T) {
+	larger := NewResourceBuilder().AddResource(constants.Memory, 100).AddResource(constants.CPU, 10).Build()
+	assert.Assert(t, FitIn(larger, nil))
+	assert.Assert(t, FitIn(larger, larger))
+	assert.Assert(t, FitIn(larger, NewResourceBuilder().AddResource(constants.CPU, 5).Build()))
+	assert.Assert(t, !FitIn(larger, NewResourceBuilder().AddResource(constants.CPU, 11).Build()))
+	assert.Assert(t, !FitIn(larger, NewResourceBuilder().AddResource("nvidia.com/gpu", 1).Build()))
+	assert.Assert(t, FitIn(larger, NewResourceBuilder().AddResource("nvidia.com/gpu", 0).Build()))
+	assert.Assert(t, !FitIn(nil, larger))
+}
+
 func TestParseResourceString(t *testing.T) {
 	testCases := []struct {
 		cpu          string
@@ -448,3 +460,76 @@ func TestParseResourceString(t *testing.T) {
 		})
 	}
 }
+
+func TestParseResourceList(t *testing.T) {
+	res, err := ParseResourceList(map[string]string{"cpu": "500m", "memory": "1G", "nvidia.com/gpu": "2"})
+	assert.NilError(t, err)
+	assert.Assert(t, Equals(res, NewResourceBuilder().
+		AddResource(constants.CPU, 500).
+		AddResource(constants.Memory, 1000).
+		AddResource("nvidia.com/gpu", 2).
+		Build()))
+	_, err = ParseResourceList(map[string]string{"cpu": "half"})
+	assert.ErrorContains(t, err, "invalid quantity half of resource cpu")
+}
+
+func newResourcePod(name string, requests ...v1.ResourceList) *v1.Pod {
+	containers := make([]v1.Container, 0, len(requests))
+	for i, request := range requests {
+		containers = append(containers, v1.Container{
+			Name: fmt.Sprintf("container-%02d", i),
+			Resources: v1.ResourceRequirements{
+				Requests: request,
+			},
+		})
+	}
+	return &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name: name,
+			UID:  types.UID(name),
+		},
+		Spec: v1.PodSpec{
+			Containers: containers,
+		},
+	}
+}
+
+func TestGetPodResourceReusesScratch(t *testing.T) {
+	gpuPod := newResourcePod("gpu-pod", v1.ResourceList{
+		v1.ResourceCPU:                    resource.MustParse("1"),
+		v1.ResourceName("nvidia.com/gpu"): resource.MustParse("2"),
+	})
+	cpuPod := newResourcePod("cpu-pod", v1.ResourceList{
+		v1.ResourceCPU: resource.MustParse("2"),
+	}, v1.ResourceList{
+		v1.ResourceCPU: resource.MustParse("500m"),
+	})
+
+	res := GetPodResource(gpuPod)
+	assert.Equal(t, len(res.Resources), 2)
+	assert.Equal(t, res.Resources["nvidia.com/gpu"].GetValue(), int64(2))
+
+	// nothing of the previous pod may leak into the next calculation
+	res = GetPodResource(cpuPod)
+	assert.Equal(t, len(res.Resources), 1)
+	assert.Equal(t, res.Resources[constants.CPU].GetValue(), int64(2500))
+
+	// the returned resource is not shared with the next calculation
+	res.Resources[constants.CPU].Value = 1
+	res = GetPodResource(cpuPod)
+	assert.Equal(t, res.Resources[constants.CPU].GetValue(), int64(2500))
+}
+
+func BenchmarkGetPodResource(b *testing.B) {
+	request := v1.ResourceList{
+		v1.ResourceCPU:                    resource.MustParse("500m"),
+		v1.ResourceMemory:                 resource.MustParse("512M"),
+		v1.ResourceName("nvidia.com/gpu"): resource.MustParse("1"),
+	}
+	pod := newResourcePod("bench-pod", request, request, request)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		GetPodResource(pod)
+	}
+}
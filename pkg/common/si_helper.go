@@ -30,10 +30,10 @@ import (
 
 func CreateTagsForTask(pod *v1.Pod) map[string]string {
 	metaPrefix := common.DomainK8s + common.GroupMeta
-	tags := map[string]string{
-		metaPrefix + common.KeyNamespace: pod.Namespace,
-		metaPrefix + common.KeyPodName:   pod.Name,
-	}
+	// size the tags up front: the labels are copied in and this runs for every task
+	tags := make(map[string]string, len(pod.Labels)+2)
+	tags[metaPrefix+common.KeyNamespace] = pod.Namespace
+	tags[metaPrefix+common.KeyPodName] = pod.Name
 	owners := pod.GetOwnerReferences()
 	if len(owners) > 0 {
 		for _, value := range owners {
@@ -54,6 +54,13 @@ func CreateTagsForTask(pod *v1.Pod) map[string]string {
 			}
 		}
 	}
+	// the core must not pick the critical and static pods as victims, nor preempt for the pods that opted out
+	if !IsPreemptible(pod) {
+		tags[constants.TagAllowPreemptSelf] = "false"
+	}
+	if !CanPreempt(pod) {
+		tags[constants.TagAllowPreemptOther] = "false"
+	}
 	// add Pod labels to Task tags
 	labelPrefix := common.DomainK8s + common.GroupLabel
 	for k, v := range pod.Labels {
@@ -83,16 +90,15 @@ func CreateAllocationRequestForTask(appID, taskID string, resource *si.Resource,
 }
 
 func CreateReleaseAskRequestForTask(appID, taskID, partition string) si.AllocationRequest {
-	toReleases := make([]*si.AllocationAskRelease, 0)
-	toReleases = append(toReleases, &si.AllocationAskRelease{
-		ApplicationID: appID,
-		Allocationkey: taskID,
-		PartitionName: partition,
-		Message:       "task request is canceled",
-	})
-
 	releaseRequest := si.AllocationReleasesRequest{
-		AllocationAsksToRelease: toReleases,
+		AllocationAsksToRelease: []*si.AllocationAskRelease{
+			{
+				ApplicationID: appID,
+				Allocationkey: taskID,
+				PartitionName: partition,
+				Message:       "task request is canceled",
+			},
+		},
 	}
 
 	result := si.AllocationRequest{
@@ -111,17 +117,16 @@ func GetTerminationTypeFromString(terminationTypeStr string) si.TerminationType
 }
 
 func CreateReleaseAllocationRequestForTask(appID, allocUUID, partition, terminationType string) si.AllocationRequest {
-	toReleases := make([]*si.AllocationRelease, 0)
-	toReleases = append(toReleases, &si.AllocationRelease{
-		ApplicationID:   appID,
-		UUID:            allocUUID,
-		PartitionName:   partition,
-		TerminationType: GetTerminationTypeFromString(terminationType),
-		Message:         "task completed",
-	})
-
 	releaseRequest := si.AllocationReleasesRequest{
-		AllocationsToRelease: toReleases,
+		AllocationsToRelease: []*si.AllocationRelease{
+			{
+				ApplicationID:   appID,
+				UUID:            allocUUID,
+				PartitionName:   partition,
+				TerminationType: GetTerminationTypeFromString(terminationType),
+				Message:         "task completed",
+			},
+		},
 	}
 
 	result := si.AllocationRequest{
@@ -156,17 +161,23 @@ func CreateUpdateRequestForNewNode(node Node) si.NodeRequest {
 }
 
 func CreateUpdateRequestForUpdatedNode(node Node) si.NodeRequest {
-	// Currently only includes resource in the update request
-	nodeInfo := &si.NodeInfo{
-		NodeID:              node.name,
-		Attributes:          make(map[string]string),
-		SchedulableResource: node.capacity,
-		OccupiedResource:    node.occupied,
-		Action:              si.NodeInfo_UPDATE,
+	return CreateUpdateRequestForUpdatedNodes([]Node{node})
+}
+
+// updates of multiple nodes sent to the core in one request
+func CreateUpdateRequestForUpdatedNodes(updated []Node) si.NodeRequest {
+	nodes := make([]*si.NodeInfo, 0, len(updated))
+	for _, node := range updated {
+		// Currently only includes resource in the update request
+		nodes = append(nodes, &si.NodeInfo{
+			NodeID:              node.name,
+			Attributes:          make(map[string]string),
+			SchedulableResource: node.capacity,
+			OccupiedResource:    node.occupied,
+			Action:              si.NodeInfo_UPDATE,
+		})
 	}
 
-	nodes := make([]*si.NodeInfo, 1)
-	nodes[0] = nodeInfo
 	request := si.NodeRequest{
 		Nodes: nodes,
 		RmID:  conf.GetSchedulerConf().ClusterID,
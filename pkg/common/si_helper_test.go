@@ -197,3 +197,27 @@ func TestCreateTagsForTask(t *testing.T) {
 	result4 := CreateTagsForTask(pod)
 	assert.Equal(t, len(result4), 4)
 }
+
+func BenchmarkCreateAllocationRequestForTask(b *testing.B) {
+	res := NewResourceBuilder().
+		AddResource("memory", 512).
+		AddResource("vcore", 500).
+		Build()
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name:      "bench-pod",
+			UID:       "UID-00001",
+			Namespace: "default",
+			Labels: map[string]string{
+				"applicationId": "app01",
+				"queue":         "root.default",
+				"label1":        "val1",
+			},
+		},
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		CreateAllocationRequestForTask("app01", "task01", res, false, "", pod)
+	}
+}
@@ -44,8 +44,20 @@ func NewConfigMapListerMock() *ConfigMapListerMock {
 	}
 }
 
+func (c *ConfigMapListerMock) Add(configMap *v1.ConfigMap) {
+	c.configMaps = append(c.configMaps, configMap)
+}
+
 func (c ConfigMapListerMock) List(selector labels.Selector) (ret []*v1.ConfigMap, err error) {
-	return c.configMaps, nil
+	if selector == nil {
+		return c.configMaps, nil
+	}
+	for _, configMap := range c.configMaps {
+		if selector.Matches(labels.Set(configMap.Labels)) {
+			ret = append(ret, configMap)
+		}
+	}
+	return ret, nil
 }
 
 func (c ConfigMapListerMock) ConfigMaps(namespace string) listers.ConfigMapNamespaceLister {
@@ -0,0 +1,117 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// UserGroup is the user an application is submitted to the core as, with the groups of the user.
+// The core applies the user and group limits of the queues to it.
+type UserGroup struct {
+	User   string   `json:"user"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// GetUserGroupFromPod resolves the user of the pod from the sources of the userResolution in order,
+// the first source that knows the user wins. The default user without groups is returned otherwise.
+func GetUserGroupFromPod(pod *v1.Pod) UserGroup {
+	schedulerConf := conf.GetSchedulerConf()
+	for _, source := range schedulerConf.GetUserResolution() {
+		var userGroup *UserGroup
+		switch source {
+		case conf.UserSourceAnnotation:
+			userGroup = getUserGroupFromAnnotation(pod)
+		case conf.UserSourceLabel:
+			userGroup = getUserGroupFromLabel(pod, schedulerConf.UserLabelKey)
+		case conf.UserSourceServiceAccount:
+			userGroup = getUserGroupFromServiceAccount(pod)
+		}
+		if userGroup != nil {
+			log.Logger().Debug("found user of pod",
+				zap.String("namespace", pod.Namespace),
+				zap.String("name", pod.Name),
+				zap.String("source", source),
+				zap.String("user", userGroup.User),
+				zap.Strings("groups", userGroup.Groups))
+			return *userGroup
+		}
+	}
+	log.Logger().Debug("unable to resolve the user of pod, using the default user",
+		zap.String("namespace", pod.Namespace),
+		zap.String("name", pod.Name))
+	return UserGroup{User: constants.DefaultUser}
+}
+
+// the user and the groups the admission controller set from the request that created the pod
+func getUserGroupFromAnnotation(pod *v1.Pod) *UserGroup {
+	value, ok := pod.Annotations[constants.AnnotationUserInfo]
+	if !ok {
+		return nil
+	}
+	userGroup := &UserGroup{}
+	if err := json.Unmarshal([]byte(value), userGroup); err != nil || userGroup.User == "" {
+		log.Logger().Warn("ignoring invalid user info annotation",
+			zap.String("namespace", pod.Namespace),
+			zap.String("name", pod.Name),
+			zap.String("annotation", value),
+			zap.Error(err))
+		return nil
+	}
+	return userGroup
+}
+
+// the user of the label, a label cannot list the groups
+func getUserGroupFromLabel(pod *v1.Pod, labelKey string) *UserGroup {
+	if value, ok := pod.Labels[labelKey]; ok && value != "" {
+		return &UserGroup{User: value}
+	}
+	return nil
+}
+
+// the user and the groups the api-server authenticates the service account of the pod as
+func getUserGroupFromServiceAccount(pod *v1.Pod) *UserGroup {
+	if pod.Spec.ServiceAccountName == "" {
+		return nil
+	}
+	userGroup := GetServiceAccountUserGroup(pod)
+	return &userGroup
+}
+
+// GetServiceAccountUserGroup returns the user and the groups the api-server authenticates the service account of
+// the pod as. Unlike the labels and the annotations of the pod the submitter can not pick this identity freely,
+// the service account must exist in the namespace of the pod. A pod without a service account runs as the default.
+func GetServiceAccountUserGroup(pod *v1.Pod) UserGroup {
+	serviceAccount := pod.Spec.ServiceAccountName
+	if serviceAccount == "" {
+		serviceAccount = "default"
+	}
+	return UserGroup{
+		User:   fmt.Sprintf("system:serviceaccount:%s:%s", pod.Namespace, serviceAccount),
+		Groups: []string{"system:serviceaccounts", "system:serviceaccounts:" + pod.Namespace},
+	}
+}
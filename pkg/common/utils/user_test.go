@@ -0,0 +1,98 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package utils
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+)
+
+func TestGetUserGroupFromPod(t *testing.T) {
+	schedulerConf := conf.GetSchedulerConf()
+	defer func(resolution string) {
+		schedulerConf.UserResolution = resolution
+	}(schedulerConf.UserResolution)
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod-01",
+			Namespace: "ns1",
+			Labels:    map[string]string{constants.DefaultUserLabel: "label-user"},
+			Annotations: map[string]string{
+				constants.AnnotationUserInfo: `{"user":"annotation-user","groups":["dev","ops"]}`,
+			},
+		},
+		Spec: v1.PodSpec{
+			ServiceAccountName: "runner",
+		},
+	}
+	saUser := UserGroup{
+		User:   "system:serviceaccount:ns1:runner",
+		Groups: []string{"system:serviceaccounts", "system:serviceaccounts:ns1"},
+	}
+	testCases := []struct {
+		name       string
+		resolution string
+		pod        *v1.Pod
+		expected   UserGroup
+	}{
+		{"annotation first", "annotation,label,serviceaccount", pod,
+			UserGroup{User: "annotation-user", Groups: []string{"dev", "ops"}}},
+		{"label first", "label,annotation", pod, UserGroup{User: "label-user"}},
+		{"service account", "serviceaccount", pod, saUser},
+		{"no source", "", pod, UserGroup{User: constants.DefaultUser}},
+		{"fall through", "annotation,label,serviceaccount", &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "ns1",
+				Annotations: map[string]string{constants.AnnotationUserInfo: "not json"},
+			},
+			Spec: v1.PodSpec{ServiceAccountName: "runner"},
+		}, saUser},
+		{"default user", "annotation,label,serviceaccount", &v1.Pod{}, UserGroup{User: constants.DefaultUser}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			schedulerConf.UserResolution = tc.resolution
+			assert.DeepEqual(t, GetUserGroupFromPod(tc.pod), tc.expected)
+		})
+	}
+}
+
+func TestGetServiceAccountUserGroup(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod-01",
+			Namespace: "tenant",
+			Labels:    map[string]string{constants.DefaultUserLabel: "admin"},
+		},
+	}
+	// the labels are ignored, a pod without a service account runs as the default one
+	assert.DeepEqual(t, GetServiceAccountUserGroup(pod), UserGroup{
+		User:   "system:serviceaccount:tenant:default",
+		Groups: []string{"system:serviceaccounts", "system:serviceaccounts:tenant"},
+	})
+	pod.Spec.ServiceAccountName = "builder"
+	assert.Equal(t, GetServiceAccountUserGroup(pod).User, "system:serviceaccount:tenant:builder")
+}
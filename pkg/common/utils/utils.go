@@ -19,7 +19,9 @@
 package utils
 
 import (
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -32,7 +34,6 @@ import (
 
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
-	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
 	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
 )
@@ -70,6 +71,35 @@ func IsAssignedPod(pod *v1.Pod) bool {
 	return len(pod.Spec.NodeName) != 0
 }
 
+// IsPodUpdateRelevant returns false for an update that does not change anything the shim looks at,
+// e.g. an informer resync or a change of the container statuses only. Only cheap field comparisons
+// are done, the check runs for every pod update before any cache or dispatch work.
+func IsPodUpdateRelevant(oldPod, newPod *v1.Pod) bool {
+	// a resync delivers the same version of the object
+	if oldPod.ResourceVersion != "" && oldPod.ResourceVersion == newPod.ResourceVersion {
+		return false
+	}
+	return oldPod.UID != newPod.UID ||
+		oldPod.Spec.NodeName != newPod.Spec.NodeName ||
+		oldPod.Spec.SchedulerName != newPod.Spec.SchedulerName ||
+		oldPod.Status.Phase != newPod.Status.Phase ||
+		(oldPod.DeletionTimestamp == nil) != (newPod.DeletionTimestamp == nil) ||
+		!stringMapEqual(oldPod.Labels, newPod.Labels) ||
+		!stringMapEqual(oldPod.Annotations, newPod.Annotations)
+}
+
+func stringMapEqual(first, second map[string]string) bool {
+	if len(first) != len(second) {
+		return false
+	}
+	for k, v := range first {
+		if other, ok := second[k]; !ok || other != v {
+			return false
+		}
+	}
+	return true
+}
+
 func GeneralPodFilter(pod *v1.Pod) bool {
 	return strings.Compare(pod.Spec.SchedulerName, constants.SchedulerName) == 0
 }
@@ -122,6 +152,176 @@ func GetNamespaceQuotaFromAnnotation(namespaceObj *v1.Namespace) *si.Resource {
 	return common.ParseResource(cpuQuota, memQuota)
 }
 
+// GetNamespacePriorityTags returns the priority offset and fence of the namespace annotations as app tags,
+// the annotations that are not a valid priority are ignored
+func GetNamespacePriorityTags(namespaceObj *v1.Namespace) map[string]string {
+	tags := make(map[string]string)
+	for annotation, tag := range map[string]string{
+		constants.AnnotationNamespacePriorityOffset: constants.AppTagNamespacePriorityOffset,
+		constants.AnnotationNamespacePriorityFence:  constants.AppTagNamespacePriorityFence,
+	} {
+		value, ok := namespaceObj.Annotations[annotation]
+		if !ok {
+			continue
+		}
+		priority, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			log.Logger().Warn("Failed to parse priority from namespace annotation, ignoring it",
+				zap.String("namespace", namespaceObj.Name),
+				zap.String("annotation", annotation),
+				zap.String("value", value))
+			continue
+		}
+		tags[tag] = strconv.FormatInt(priority, 10)
+	}
+	return tags
+}
+
+// GetNamespaceUserQuotaTags returns the user and group quotas of the namespace annotations as app tags,
+// the quotas of the annotation are keyed by the user or group name. An annotation that is not valid is ignored.
+func GetNamespaceUserQuotaTags(namespaceObj *v1.Namespace) map[string]string {
+	tags := make(map[string]string)
+	for annotation, tag := range map[string]string{
+		constants.AnnotationNamespaceUserQuota:  constants.AppTagNamespaceUserQuota,
+		constants.AnnotationNamespaceGroupQuota: constants.AppTagNamespaceGroupQuota,
+	} {
+		value, ok := namespaceObj.Annotations[annotation]
+		if !ok {
+			continue
+		}
+		quotas, err := parseUserQuotas(value)
+		if err != nil {
+			log.Logger().Warn("Failed to parse quotas from namespace annotation, ignoring it",
+				zap.String("namespace", namespaceObj.Name),
+				zap.String("annotation", annotation),
+				zap.Error(err))
+			continue
+		}
+		if len(quotas) == 0 {
+			continue
+		}
+		if quotaStr, err := json.Marshal(quotas); err == nil {
+			tags[tag] = string(quotaStr)
+		}
+	}
+	return tags
+}
+
+// parses the quotas by user or group name, e.g. {"alice":{"cpu":"2","memory":"4Gi"}}
+func parseUserQuotas(value string) (map[string]*si.Resource, error) {
+	var quantities map[string]map[string]string
+	if err := json.Unmarshal([]byte(value), &quantities); err != nil {
+		return nil, err
+	}
+	quotas := make(map[string]*si.Resource, len(quantities))
+	for name, resources := range quantities {
+		if name == "" {
+			return nil, fmt.Errorf("empty user or group name")
+		}
+		quota, err := common.ParseResourceList(resources)
+		if err != nil {
+			return nil, fmt.Errorf("quota of %s: %v", name, err)
+		}
+		if !common.IsZero(quota) {
+			quotas[name] = quota
+		}
+	}
+	return quotas, nil
+}
+
+// QueueACL lists the users and the groups that may submit applications to a queue, a * allows everyone
+type QueueACL struct {
+	Users  []string `json:"users,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// CheckNamespaceQueueACL returns an error when the queue ACL of the namespace annotations does not allow the
+// user or one of the groups to submit to the queue, e.g. {"root.batch":{"users":["system:serviceaccount:ns:sa"]}}.
+// The ACL of a queue applies to its child queues, the most specific ACL is used. Without the annotation all
+// the submissions are allowed, with the annotation the queues without an ACL are denied. An annotation that
+// is not valid denies all the submissions.
+func CheckNamespaceQueueACL(namespaceObj *v1.Namespace, queue, user string, groups []string) error {
+	value, ok := namespaceObj.Annotations[constants.AnnotationNamespaceQueueACL]
+	if !ok {
+		return nil
+	}
+	var acls map[string]*QueueACL
+	if err := json.Unmarshal([]byte(value), &acls); err != nil {
+		log.Logger().Warn("Failed to parse the queue ACL from namespace annotation, denying all submissions",
+			zap.String("namespace", namespaceObj.Name),
+			zap.Error(err))
+		return fmt.Errorf("the queue ACL of namespace %s is not valid", namespaceObj.Name)
+	}
+	queue = NormalizeQueueName(queue)
+	var acl *QueueACL
+	matched := ""
+	for name, queueACL := range acls {
+		name = NormalizeQueueName(name)
+		if (queue == name || strings.HasPrefix(queue, name+".")) && len(name) > len(matched) {
+			acl = queueACL
+			matched = name
+		}
+	}
+	if acl == nil {
+		return fmt.Errorf("namespace %s does not allow submitting to queue %s", namespaceObj.Name, queue)
+	}
+	for _, allowed := range acl.Users {
+		if allowed == "*" || allowed == user {
+			return nil
+		}
+	}
+	for _, allowed := range acl.Groups {
+		for _, group := range groups {
+			if allowed == "*" || allowed == group {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("namespace %s does not allow user %s to submit to queue %s", namespaceObj.Name, user, queue)
+}
+
+// NormalizeQueueName returns the fully qualified queue name in lower case, the queue names are not case
+// sensitive and the core places an application relative to the root queue when the name is not qualified
+func NormalizeQueueName(queue string) string {
+	queue = strings.ToLower(strings.TrimSpace(queue))
+	if queue != "root" && !strings.HasPrefix(queue, "root.") {
+		queue = "root." + queue
+	}
+	return queue
+}
+
+// scheduling policy overrides set through namespace annotations,
+// an empty or zero value means the namespace does not override the setting.
+type NamespaceSchedulingPolicy struct {
+	GangSchedulingStyle string
+	PlaceholderTimeout  int64
+	PlaceholderImage    string
+}
+
+func GetNamespaceSchedulingPolicy(namespaceObj *v1.Namespace) NamespaceSchedulingPolicy {
+	policy := NamespaceSchedulingPolicy{
+		PlaceholderImage: namespaceObj.Annotations[constants.AnnotationNamespacePlaceholderImage],
+	}
+	if style, ok := namespaceObj.Annotations[constants.AnnotationNamespaceGangSchedulingStyle]; ok {
+		if policy.GangSchedulingStyle, ok = constants.SchedulingPolicyStyleParamValues[style]; !ok {
+			log.Logger().Warn("Unknown gang scheduling style in namespace annotation, ignoring it",
+				zap.String("namespace", namespaceObj.Name),
+				zap.String("style", style))
+		}
+	}
+	if timeout, ok := namespaceObj.Annotations[constants.AnnotationNamespacePlaceholderTimeout]; ok {
+		value, err := strconv.ParseInt(timeout, 10, 64)
+		if err != nil || value < 0 {
+			log.Logger().Warn("Failed to parse placeholder timeout from namespace annotation, ignoring it",
+				zap.String("namespace", namespaceObj.Name),
+				zap.String("timeout", timeout))
+		} else {
+			policy.PlaceholderTimeout = value
+		}
+	}
+	return policy
+}
+
 type K8sResource struct {
 	ResourceName v1.ResourceName
 	Value        int64
@@ -214,21 +414,7 @@ func MergeMaps(first, second map[string]string) map[string]string {
 	return result
 }
 
-// find user name from pod label
+// find user name from pod, the user of GetUserGroupFromPod
 func GetUserFromPod(pod *v1.Pod) string {
-	userLabelKey := conf.GetSchedulerConf().UserLabelKey
-	// User name to be defined in labels
-	for name, value := range pod.Labels {
-		if name == userLabelKey {
-			log.Logger().Info("Found user name from pod labels.",
-				zap.String("userLabel", userLabelKey), zap.String("user", value))
-			return value
-		}
-	}
-	value := constants.DefaultUser
-
-	log.Logger().Debug("Unable to retrieve user name from pod labels. Empty user label",
-		zap.String("userLabel", constants.DefaultUserLabel))
-
-	return value
+	return GetUserGroupFromPod(pod).User
 }
@@ -59,6 +59,61 @@ func TestIsAssignedPod(t *testing.T) {
 	assert.Equal(t, assigned, false)
 }
 
+func TestIsPodUpdateRelevant(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "pod-01",
+			UID:             "UID-01",
+			ResourceVersion: "1",
+			Labels:          map[string]string{"applicationId": "app-01"},
+		},
+		Spec: v1.PodSpec{SchedulerName: constants.SchedulerName},
+		Status: v1.PodStatus{
+			Phase: v1.PodPending,
+		},
+	}
+	// resync of the same version
+	assert.Assert(t, !IsPodUpdateRelevant(pod, pod.DeepCopy()))
+
+	// only the container statuses changed
+	newPod := pod.DeepCopy()
+	newPod.ResourceVersion = "2"
+	newPod.Status.ContainerStatuses = []v1.ContainerStatus{{Name: "container-01"}}
+	assert.Assert(t, !IsPodUpdateRelevant(pod, newPod))
+
+	newPod = pod.DeepCopy()
+	newPod.ResourceVersion = "2"
+	newPod.Spec.NodeName = "node-01"
+	assert.Assert(t, IsPodUpdateRelevant(pod, newPod))
+
+	newPod = pod.DeepCopy()
+	newPod.ResourceVersion = "2"
+	newPod.Status.Phase = v1.PodRunning
+	assert.Assert(t, IsPodUpdateRelevant(pod, newPod))
+
+	newPod = pod.DeepCopy()
+	newPod.ResourceVersion = "2"
+	now := metav1.Now()
+	newPod.DeletionTimestamp = &now
+	assert.Assert(t, IsPodUpdateRelevant(pod, newPod))
+
+	newPod = pod.DeepCopy()
+	newPod.ResourceVersion = "2"
+	newPod.Labels["queue"] = "root.a"
+	assert.Assert(t, IsPodUpdateRelevant(pod, newPod))
+
+	newPod = pod.DeepCopy()
+	newPod.ResourceVersion = "2"
+	newPod.Annotations = map[string]string{"yunikorn.apache.org/user": "test"}
+	assert.Assert(t, IsPodUpdateRelevant(pod, newPod))
+
+	// objects without a version are always compared field by field
+	pod.ResourceVersion = ""
+	newPod = pod.DeepCopy()
+	newPod.Status.Phase = v1.PodSucceeded
+	assert.Assert(t, IsPodUpdateRelevant(pod, newPod))
+}
+
 func TestGetNamespaceQuotaFromAnnotation(t *testing.T) {
 	testCases := []struct {
 		namespace        *v1.Namespace
@@ -145,6 +200,112 @@ func TestGetNamespaceQuotaFromAnnotation(t *testing.T) {
 	}
 }
 
+func TestGetNamespaceSchedulingPolicy(t *testing.T) {
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    NamespaceSchedulingPolicy
+	}{
+		{"no annotations", nil, NamespaceSchedulingPolicy{}},
+		{"all overrides", map[string]string{
+			constants.AnnotationNamespaceGangSchedulingStyle: "Hard",
+			constants.AnnotationNamespacePlaceholderTimeout:  "60",
+			constants.AnnotationNamespacePlaceholderImage:    "registry.local/pause",
+		}, NamespaceSchedulingPolicy{
+			GangSchedulingStyle: "Hard",
+			PlaceholderTimeout:  60,
+			PlaceholderImage:    "registry.local/pause",
+		}},
+		{"invalid values are ignored", map[string]string{
+			constants.AnnotationNamespaceGangSchedulingStyle: "Strict",
+			constants.AnnotationNamespacePlaceholderTimeout:  "-1",
+		}, NamespaceSchedulingPolicy{}},
+		{"unparsable timeout", map[string]string{
+			constants.AnnotationNamespacePlaceholderTimeout: "1m",
+		}, NamespaceSchedulingPolicy{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy := GetNamespaceSchedulingPolicy(&v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Annotations: tc.annotations,
+				},
+			})
+			assert.DeepEqual(t, policy, tc.expected)
+		})
+	}
+}
+
+func TestGetNamespacePriorityTags(t *testing.T) {
+	namespace := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+	assert.Equal(t, len(GetNamespacePriorityTags(namespace)), 0)
+	namespace.Annotations = map[string]string{
+		constants.AnnotationNamespacePriorityOffset: "-100",
+		constants.AnnotationNamespacePriorityFence:  "+1000",
+	}
+	assert.DeepEqual(t, GetNamespacePriorityTags(namespace), map[string]string{
+		constants.AppTagNamespacePriorityOffset: "-100",
+		constants.AppTagNamespacePriorityFence:  "1000",
+	})
+	namespace.Annotations = map[string]string{
+		constants.AnnotationNamespacePriorityOffset: "high",
+		constants.AnnotationNamespacePriorityFence:  "3000000000",
+	}
+	assert.Equal(t, len(GetNamespacePriorityTags(namespace)), 0)
+}
+
+func TestGetNamespaceUserQuotaTags(t *testing.T) {
+	namespace := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+	assert.Equal(t, len(GetNamespaceUserQuotaTags(namespace)), 0)
+	namespace.Annotations = map[string]string{
+		constants.AnnotationNamespaceUserQuota:  `{"alice":{"cpu":"2","memory":"512M"},"bob":{"cpu":"0"}}`,
+		constants.AnnotationNamespaceGroupQuota: `{"dev":{"nvidia.com/gpu":"4"}}`,
+	}
+	// the zero quotas are dropped
+	assert.DeepEqual(t, GetNamespaceUserQuotaTags(namespace), map[string]string{
+		constants.AppTagNamespaceUserQuota:  `{"alice":{"resources":{"memory":{"value":512},"vcore":{"value":2000}}}}`,
+		constants.AppTagNamespaceGroupQuota: `{"dev":{"resources":{"nvidia.com/gpu":{"value":4}}}}`,
+	})
+	namespace.Annotations = map[string]string{
+		constants.AnnotationNamespaceUserQuota:  `{"alice":{"cpu":"two"}}`,
+		constants.AnnotationNamespaceGroupQuota: `["dev"]`,
+	}
+	assert.Equal(t, len(GetNamespaceUserQuotaTags(namespace)), 0)
+}
+
+func TestCheckNamespaceQueueACL(t *testing.T) {
+	namespace := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+	// no ACL: all the submissions are allowed
+	assert.NilError(t, CheckNamespaceQueueACL(namespace, "root.batch", "alice", nil))
+
+	namespace.Annotations = map[string]string{
+		constants.AnnotationNamespaceQueueACL: `{"root.batch":{"users":["alice"],"groups":["dev"]},` +
+			`"root.batch.urgent":{"users":["bob"]},"sandbox":{"users":["*"]}}`,
+	}
+	assert.NilError(t, CheckNamespaceQueueACL(namespace, "root.batch", "alice", nil))
+	assert.NilError(t, CheckNamespaceQueueACL(namespace, "ROOT.Batch", "carol", []string{"ops", "dev"}))
+	// the ACL of the parent applies to the child queues without an ACL
+	assert.NilError(t, CheckNamespaceQueueACL(namespace, "root.batch.nightly", "alice", nil))
+	// the most specific ACL is used
+	assert.NilError(t, CheckNamespaceQueueACL(namespace, "root.batch.urgent", "bob", nil))
+	assert.ErrorContains(t, CheckNamespaceQueueACL(namespace, "root.batch.urgent", "alice", []string{"dev"}),
+		"namespace test does not allow user alice to submit to queue root.batch.urgent")
+	// the queue names are qualified, * allows everyone
+	assert.NilError(t, CheckNamespaceQueueACL(namespace, "root.sandbox", "anyone", nil))
+	assert.NilError(t, CheckNamespaceQueueACL(namespace, "sandbox", "anyone", nil))
+	assert.ErrorContains(t, CheckNamespaceQueueACL(namespace, "root.batches", "alice", nil),
+		"namespace test does not allow submitting to queue root.batches")
+	assert.ErrorContains(t, CheckNamespaceQueueACL(namespace, "root", "alice", nil),
+		"namespace test does not allow submitting to queue root")
+
+	// an ACL that is not valid denies all the submissions
+	namespace.Annotations[constants.AnnotationNamespaceQueueACL] = `["root.batch"]`
+	assert.ErrorContains(t, CheckNamespaceQueueACL(namespace, "root.batch", "alice", nil),
+		"the queue ACL of namespace test is not valid")
+}
+
 // nolint: funlen
 func TestPodUnderCondition(t *testing.T) {
 	// pod has no condition set
@@ -467,3 +628,10 @@ func TestNeedRecovery(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizeQueueName(t *testing.T) {
+	assert.Equal(t, NormalizeQueueName("root"), "root")
+	assert.Equal(t, NormalizeQueueName("root.a"), "root.a")
+	assert.Equal(t, NormalizeQueueName("a.b"), "root.a.b")
+	assert.Equal(t, NormalizeQueueName(" Root.A "), "root.a")
+}
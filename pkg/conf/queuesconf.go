@@ -0,0 +1,230 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package conf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
+)
+
+// QueuesConfig is the subset of the scheduler core queue configuration the shim needs to
+// inspect. Unknown fields are preserved when the config is written back, see Extra.
+type QueuesConfig struct {
+	Partitions []PartitionConfig      `yaml:"partitions"`
+	Extra      map[string]interface{} `yaml:",inline"`
+}
+
+type PartitionConfig struct {
+	Name   string                 `yaml:"name"`
+	Queues []QueueConfig          `yaml:"queues"`
+	Extra  map[string]interface{} `yaml:",inline"`
+}
+
+type QueueConfig struct {
+	Name            string                 `yaml:"name"`
+	Resources       QueueResources         `yaml:"resources,omitempty"`
+	MaxApplications uint64                 `yaml:"maxapplications,omitempty"`
+	Queues          []QueueConfig          `yaml:"queues,omitempty"`
+	Extra           map[string]interface{} `yaml:",inline"`
+}
+
+type QueueResources struct {
+	Guaranteed map[string]string `yaml:"guaranteed,omitempty"`
+	Max        map[string]string `yaml:"max,omitempty"`
+}
+
+// QueuesConfigDiff lists the fully qualified names of the queues that differ between two configs,
+// the names are prefixed with the partition, e.g. "[default]root.a".
+type QueuesConfigDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+func (d *QueuesConfigDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+func ParseQueuesConfig(content string) (*QueuesConfig, error) {
+	config := &QueuesConfig{}
+	if err := yaml.Unmarshal([]byte(content), config); err != nil {
+		return nil, fmt.Errorf("failed to parse queues configuration: %v", err)
+	}
+	return config, nil
+}
+
+// returns all the queues in the config keyed by their fully qualified name
+func (c *QueuesConfig) flatten() map[string]QueueConfig {
+	queues := make(map[string]QueueConfig)
+	for _, partition := range c.Partitions {
+		for _, queue := range partition.Queues {
+			flattenQueue(queues, fmt.Sprintf("[%s]", partition.Name), queue)
+		}
+	}
+	return queues
+}
+
+func flattenQueue(queues map[string]QueueConfig, parent string, queue QueueConfig) {
+	path := parent + queue.Name
+	queues[path] = queue
+	for _, child := range queue.Queues {
+		flattenQueue(queues, path+".", child)
+	}
+}
+
+// DiffQueuesConfig compares the queues of the two configs, a queue is reported as
+// changed when its resources or the max number of applications differ.
+func DiffQueuesConfig(oldConfig, newConfig *QueuesConfig) *QueuesConfigDiff {
+	oldQueues := oldConfig.flatten()
+	newQueues := newConfig.flatten()
+	diff := &QueuesConfigDiff{}
+	for path, newQueue := range newQueues {
+		oldQueue, ok := oldQueues[path]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, path)
+		case !equalResources(oldQueue.Resources.Guaranteed, newQueue.Resources.Guaranteed) ||
+			!equalResources(oldQueue.Resources.Max, newQueue.Resources.Max) ||
+			oldQueue.MaxApplications != newQueue.MaxApplications:
+			diff.Changed = append(diff.Changed, path)
+		}
+	}
+	for path := range oldQueues {
+		if _, ok := newQueues[path]; !ok {
+			diff.Removed = append(diff.Removed, path)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff
+}
+
+// a nil and an empty resource map are considered equal
+func equalResources(left, right map[string]string) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for name, value := range left {
+		if other, ok := right[name]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
+
+// QueuesFragment is a queue subtree contributed by a separate ConfigMap,
+// the queues are added as children of the parent queue in the given partition.
+type QueuesFragment struct {
+	Partition string        `yaml:"partition"`
+	Parent    string        `yaml:"parent"`
+	Queues    []QueueConfig `yaml:"queues"`
+}
+
+func ParseQueuesFragment(content string) (*QueuesFragment, error) {
+	fragment := &QueuesFragment{}
+	if err := yaml.UnmarshalStrict([]byte(content), fragment); err != nil {
+		return nil, fmt.Errorf("failed to parse queues fragment: %v", err)
+	}
+	if fragment.Partition == "" {
+		fragment.Partition = constants.DefaultPartition
+	}
+	if fragment.Parent == "" {
+		return nil, fmt.Errorf("queues fragment must define the parent queue")
+	}
+	if len(fragment.Queues) == 0 {
+		return nil, fmt.Errorf("queues fragment must define at least one queue")
+	}
+	return fragment, nil
+}
+
+func (c *QueuesConfig) Marshal() (string, error) {
+	content, err := yaml.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// returns the queue with the given path in the given partition, or nil if not found
+func (c *QueuesConfig) findQueue(partitionName, path string) *QueueConfig {
+	for i := range c.Partitions {
+		if c.Partitions[i].Name != partitionName {
+			continue
+		}
+		queues := c.Partitions[i].Queues
+		var found *QueueConfig
+		for _, name := range strings.Split(path, ".") {
+			found = nil
+			for j := range queues {
+				if strings.EqualFold(queues[j].Name, name) {
+					found = &queues[j]
+					break
+				}
+			}
+			if found == nil {
+				return nil
+			}
+			queues = found.Queues
+		}
+		return found
+	}
+	return nil
+}
+
+// MergeQueuesFragments adds the fragments to the config in the order of their names,
+// a fragment is rejected as a whole if its parent does not exist or any of its queues
+// clashes with a queue that is already defined. The rejected fragments are returned
+// with the reason, the config contains all the fragments that could be merged.
+func (c *QueuesConfig) MergeQueuesFragments(fragments map[string]*QueuesFragment) map[string]error {
+	names := make([]string, 0, len(fragments))
+	for name := range fragments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rejected := make(map[string]error)
+	for _, name := range names {
+		fragment := fragments[name]
+		parent := c.findQueue(fragment.Partition, fragment.Parent)
+		if parent == nil {
+			rejected[name] = fmt.Errorf("parent queue %s not found in partition %s", fragment.Parent, fragment.Partition)
+			continue
+		}
+		var clash []string
+		for _, queue := range fragment.Queues {
+			for _, existing := range parent.Queues {
+				if strings.EqualFold(existing.Name, queue.Name) {
+					clash = append(clash, fragment.Parent+"."+queue.Name)
+				}
+			}
+		}
+		if len(clash) > 0 {
+			rejected[name] = fmt.Errorf("queues already defined: %s", strings.Join(clash, ", "))
+			continue
+		}
+		parent.Queues = append(parent.Queues, fragment.Queues...)
+	}
+	return rejected
+}
@@ -19,12 +19,21 @@
 package conf
 
 import (
+	"encoding/json"
 	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
 	"strings"
 	"sync"
 	"time"
 
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v2"
+	v1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/klog"
 
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
@@ -37,40 +46,388 @@ const (
 	DefaultPolicyGroup          = "queues"
 	DefaultLoggingLevel         = 0
 	DefaultLogEncoding          = "console"
+	DefaultLogSampleRate        = 1
 	DefaultVolumeBindTimeout    = 10 * time.Second
 	DefaultSchedulingInterval   = time.Second
 	DefaultEventChannelCapacity = 1024 * 1024
 	DefaultDispatchTimeout      = 300 * time.Second
+	DefaultBackpressure         = BackpressureAsync
+	DefaultDispatcherDrain      = 30 * time.Second
+	DefaultEventHistorySize     = 1000
 	DefaultKubeQPS              = 1000
 	DefaultKubeBurst            = 1000
+	DefaultKubeBindQPS          = 0
+	DefaultKubeBindBurst        = 100
+	DefaultKubeTimeout          = 0 * time.Second
+	DefaultKubeContentType      = ContentTypeProtobuf
+	DefaultWebServicePort       = 9090
+	DefaultShimConfigFile       = "/etc/yunikorn/k8shim.yaml"
+	DefaultConfigDelivery       = ConfigDeliveryFile
+	DefaultWatchdogInterval     = 30 * time.Second
+	DefaultEventDedupWindow     = time.Minute
+	DefaultEventQPS             = 20
+	DefaultEventBurst           = 200
+	DefaultTracingSampleRatio   = 0.1
+	DefaultAuditLogMaxSize      = 100
+	DefaultAuditLogMaxBackups   = 10
+	DefaultHealthQueueThreshold = 0.8
+	DefaultInformerFailure      = 2 * time.Minute
+	DefaultAskBatchSize         = 100
+	DefaultNodeUpdateStaleness  = 5 * time.Second
+	DefaultPodStatusInterval    = 5 * time.Second
+	DefaultRecoveryWorkers      = 16
+	DefaultRecoveryTimeout      = time.Minute
+	DefaultPreemptionPDBPolicy  = PDBPolicyEvict
+	DefaultPreemptEvictTimeout  = 2 * time.Minute
+	DefaultPreemptVictimPolicy  = VictimPolicyDefault
+	DefaultPreemptGangPolicy    = GangPolicyNone
+	DefaultScaleDownAnnotation  = "cluster-autoscaler.kubernetes.io/scale-down-disabled"
 )
 
+// content types the Kubernetes client can use to talk to the api-server
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/vnd.kubernetes.protobuf"
+)
+
+// the ways the queue configuration reaches the core: the core reads the file mounted from the
+// scheduler ConfigMap, or the shim reads the ConfigMap and pushes the content to the core.
+const (
+	ConfigDeliveryFile   = "file"
+	ConfigDeliveryDirect = "direct"
+)
+
+// the behaviour of the dispatcher when the event channel is full: the event waits in a goroutine,
+// the producer is blocked, or the low priority events are dropped and the others wait in a goroutine.
+const (
+	BackpressureAsync = "async"
+	BackpressureBlock = "block"
+	BackpressureShed  = "shed"
+)
+
+// where the Kubernetes events of the scheduler go: the api-server, the scheduler log, or nowhere.
+const (
+	RecorderSinkKubernetes = "kubernetes"
+	RecorderSinkLog        = "log"
+	RecorderSinkNone       = "none"
+)
+
+// how the preemption treats the victims protected by a PodDisruptionBudget: the eviction is retried
+// until the budget allows it, or the protected victims are skipped and keep running.
+const (
+	PDBPolicyEvict = "evict"
+	PDBPolicySkip  = "skip"
+)
+
+// how the core picks the victims: by the priorities of the pods, or the allocated placeholders of the gangs
+// with a lower priority than the preemptor are preempted before the real workloads.
+const (
+	VictimPolicyDefault           = "default"
+	VictimPolicyPreferPlaceholder = "prefer-placeholder"
+)
+
+// what happens to the other members of a hard gang application when one of its members is preempted:
+// nothing, the members are evicted for their controllers to create the gang again, or the application fails.
+const (
+	GangPolicyNone    = "none"
+	GangPolicyRestart = "restart"
+	GangPolicyFail    = "fail"
+)
+
+// the cluster-autoscaler provisioning classes of the ProvisioningRequests created for the hard gangs that
+// cannot fit: the autoscaler checks the capacity is there, or scales up all the nodes of the gang at once.
+const (
+	ProvisioningClassCheckCapacity = "check-capacity.autoscaling.x-k8s.io"
+	ProvisioningClassAtomicScaleUp = "atomic-scale-up.autoscaling.x-k8s.io"
+)
+
+// the sources the user and the groups of an application are resolved from: the annotation the admission
+// controller sets from the user creating the pod, the user label, or the service account of the pod.
+const (
+	UserSourceAnnotation     = "annotation"
+	UserSourceLabel          = "label"
+	UserSourceServiceAccount = "serviceaccount"
+)
+
+// how the callers of the admin and debug endpoints of the web service are authenticated: with the admin
+// token of the config Secret, or with a TokenReview and authorized with a SubjectAccessReview of the api-server.
+const (
+	WebServiceAuthToken      = "token"
+	WebServiceAuthKubernetes = "kubernetes"
+)
+
+const shimConfigFileFlag = "shimConfigFile"
+
+// environment variables that override the shim configuration file, keyed by the flag name.
+// command line flags always take precedence over the environment variables.
+var envVars = map[string]string{
+	"kubeConfig":                 "KUBECONFIG",
+	"kubeContext":                "KUBE_CONTEXT",
+	"interval":                   "SCHEDULING_INTERVAL",
+	"clusterId":                  "CLUSTER_ID",
+	"clusterVersion":             "CLUSTER_VERSION",
+	"policyGroup":                "POLICY_GROUP",
+	"volumeBindTimeout":          "VOLUME_BINDING_TIMEOUT",
+	"eventChannelCapacity":       "EVENT_CHANNEL_CAPACITY",
+	"dispatchTimeout":            "DISPATCHER_TIMEOUT",
+	"asyncDispatchLimit":         "DISPATCHER_ASYNC_LIMIT",
+	"dispatcherBackpressure":     "DISPATCHER_BACKPRESSURE",
+	"dispatcherWorkers":          "DISPATCHER_WORKERS",
+	"dispatcherDrainTimeout":     "DISPATCHER_DRAIN_TIMEOUT",
+	"eventHistorySize":           "EVENT_HISTORY_SIZE",
+	"syncRecovery":               "SYNC_RECOVERY",
+	"recoveryWorkers":            "RECOVERY_WORKERS",
+	"recoveryTimeout":            "RECOVERY_TIMEOUT",
+	"askBatchInterval":           "ASK_BATCH_INTERVAL",
+	"askBatchSize":               "ASK_BATCH_SIZE",
+	"bindWorkers":                "BIND_WORKERS",
+	"placeholderWorkers":         "PLACEHOLDER_WORKERS",
+	"nodeUpdateMaxStaleness":     "NODE_UPDATE_MAX_STALENESS",
+	"podStatusWriteInterval":     "POD_STATUS_WRITE_INTERVAL",
+	"kubeQPS":                    "KUBE_CLIENT_QPS",
+	"kubeBurst":                  "KUBE_CLIENT_BURST",
+	"kubeBindQPS":                "KUBE_CLIENT_BIND_QPS",
+	"kubeBindBurst":              "KUBE_CLIENT_BIND_BURST",
+	"kubeTimeout":                "KUBE_CLIENT_TIMEOUT",
+	"kubeContentType":            "KUBE_CLIENT_CONTENT_TYPE",
+	"podImpersonation":           "POD_IMPERSONATION",
+	"informerResyncPeriods":      "INFORMER_RESYNC_PERIODS",
+	"informerWatchdogInterval":   "INFORMER_WATCHDOG_INTERVAL",
+	"informerFailureTimeout":     "INFORMER_FAILURE_TIMEOUT",
+	"informerStallTimeout":       "INFORMER_STALL_TIMEOUT",
+	"stripManagedFields":         "STRIP_MANAGED_FIELDS",
+	"maxAnnotationSize":          "MAX_ANNOTATION_SIZE",
+	"operatorPlugins":            "OPERATOR_PLUGINS",
+	"webServicePort":             "WEB_SERVICE_PORT",
+	"webServiceTLS":              "WEB_SERVICE_TLS",
+	"webServiceAuth":             "WEB_SERVICE_AUTH",
+	"webServiceAuthDebug":        "WEB_SERVICE_AUTH_DEBUG",
+	"enableProfiling":            "ENABLE_PROFILING",
+	"coreServiceURL":             "CORE_SERVICE_URL",
+	shimConfigFileFlag:           "SHIM_CONFIG_FILE",
+	"logLevel":                   "LOG_LEVEL",
+	"logEncoding":                "LOG_ENCODING",
+	"logFile":                    "LOG_FILE",
+	"logSampleRate":              "LOG_SAMPLE_RATE",
+	"enableConfigHotRefresh":     "ENABLE_CONFIG_HOT_REFRESH",
+	"configDelivery":             "CONFIG_DELIVERY",
+	"configSecret":               "CONFIG_SECRET",
+	"queuesFragmentNamespaces":   "QUEUES_FRAGMENT_NAMESPACES",
+	"queuesFragmentParents":      "QUEUES_FRAGMENT_PARENTS",
+	"protectQueuesWithApps":      "PROTECT_QUEUES_WITH_APPS",
+	"requireConfigSignature":     "REQUIRE_CONFIG_SIGNATURE",
+	"disableGangScheduling":      "DISABLE_GANG_SCHEDULING",
+	"enableNamespaceAnnotations": "ENABLE_NAMESPACE_ANNOTATIONS",
+	"dryRun":                     "DRY_RUN",
+	"userLabelKey":               "USER_LABEL_KEY",
+	"userResolution":             "USER_RESOLUTION",
+	"eventSinks":                 "EVENT_SINKS",
+	"eventDedupWindow":           "EVENT_DEDUP_WINDOW",
+	"eventQPS":                   "EVENT_QPS",
+	"eventBurst":                 "EVENT_BURST",
+	"eventComponent":             "EVENT_COMPONENT",
+	"eventRecorderSink":          "EVENT_RECORDER_SINK",
+	"tracingEndpoint":            "TRACING_ENDPOINT",
+	"tracingSampleRatio":         "TRACING_SAMPLE_RATIO",
+	"auditLogPath":               "AUDIT_LOG_PATH",
+	"auditLogMaxSize":            "AUDIT_LOG_MAX_SIZE",
+	"auditLogMaxBackups":         "AUDIT_LOG_MAX_BACKUPS",
+	"healthQueueThreshold":       "HEALTH_QUEUE_THRESHOLD",
+	"enableMemoryAccounting":     "ENABLE_MEMORY_ACCOUNTING",
+	"cacheMemoryLimitMB":         "CACHE_MEMORY_LIMIT_MB",
+	"preemptionPDBPolicy":        "PREEMPTION_PDB_POLICY",
+	"preemptionEvictTimeout":     "PREEMPTION_EVICT_TIMEOUT",
+	"preemptionGracePeriod":      "PREEMPTION_GRACE_PERIOD",
+	"preemptionDryRun":           "PREEMPTION_DRY_RUN",
+	"preemptionQueues":           "PREEMPTION_QUEUES",
+	"preemptionVictimPolicy":     "PREEMPTION_VICTIM_POLICY",
+	"preemptionGangPolicy":       "PREEMPTION_GANG_POLICY",
+	"provisioningClass":          "PROVISIONING_CLASS",
+	"karpenterSignals":           "KARPENTER_SIGNALS",
+	"scaleDownProtection":        "SCALE_DOWN_PROTECTION",
+	"scaleDownAnnotation":        "SCALE_DOWN_ANNOTATION",
+	"unschedulableDelay":         "UNSCHEDULABLE_DELAY",
+	"equivalentPlaceholders":     "EQUIVALENT_PLACEHOLDERS",
+	"scaleUpDisabledQueues":      "SCALE_UP_DISABLED_QUEUES",
+	"placeholderSecurityContext": "PLACEHOLDER_SECURITY_CONTEXT",
+}
+
 var once sync.Once
 var configuration *SchedulerConf
 
 type SchedulerConf struct {
-	ClusterID              string        `json:"clusterId"`
-	ClusterVersion         string        `json:"clusterVersion"`
-	PolicyGroup            string        `json:"policyGroup"`
-	Interval               time.Duration `json:"schedulingIntervalSecond"`
-	KubeConfig             string        `json:"absoluteKubeConfigFilePath"`
-	LoggingLevel           int           `json:"loggingLevel"`
-	LogEncoding            string        `json:"logEncoding"`
-	LogFile                string        `json:"logFilePath"`
-	VolumeBindTimeout      time.Duration `json:"volumeBindTimeout"`
-	TestMode               bool          `json:"testMode"`
-	EventChannelCapacity   int           `json:"eventChannelCapacity"`
-	DispatchTimeout        time.Duration `json:"dispatchTimeout"`
-	KubeQPS                int           `json:"kubeQPS"`
-	KubeBurst              int           `json:"kubeBurst"`
-	Predicates             string        `json:"predicates"`
-	OperatorPlugins        string        `json:"operatorPlugins"`
-	EnableConfigHotRefresh bool          `json:"enableConfigHotRefresh"`
-	DisableGangScheduling  bool          `json:"disableGangScheduling"`
-	UserLabelKey           string        `json:"userLabelKey"`
+	ClusterID                  string        `json:"clusterId"`
+	ClusterVersion             string        `json:"clusterVersion"`
+	PolicyGroup                string        `json:"policyGroup"`
+	Interval                   time.Duration `json:"schedulingIntervalSecond"`
+	KubeConfig                 string        `json:"absoluteKubeConfigFilePath"`
+	KubeContext                string        `json:"kubeContext"`
+	LoggingLevel               int           `json:"loggingLevel"`
+	LogEncoding                string        `json:"logEncoding"`
+	LogFile                    string        `json:"logFilePath"`
+	LogSampleRate              int           `json:"logSampleRate"`
+	VolumeBindTimeout          time.Duration `json:"volumeBindTimeout"`
+	TestMode                   bool          `json:"testMode"`
+	EventChannelCapacity       int           `json:"eventChannelCapacity"`
+	DispatchTimeout            time.Duration `json:"dispatchTimeout"`
+	AsyncDispatchLimit         int           `json:"asyncDispatchLimit"`
+	DispatcherBackpressure     string        `json:"dispatcherBackpressure"`
+	DispatcherWorkers          int           `json:"dispatcherWorkers"`
+	DispatcherDrainTimeout     time.Duration `json:"dispatcherDrainTimeout"`
+	EventHistorySize           int           `json:"eventHistorySize"`
+	SyncRecovery               bool          `json:"syncRecovery"`
+	RecoveryWorkers            int           `json:"recoveryWorkers"`
+	RecoveryTimeout            time.Duration `json:"recoveryTimeout"`
+	AskBatchInterval           time.Duration `json:"askBatchInterval"`
+	AskBatchSize               int           `json:"askBatchSize"`
+	BindWorkers                int           `json:"bindWorkers"`
+	PlaceholderWorkers         int           `json:"placeholderWorkers"`
+	NodeUpdateMaxStaleness     time.Duration `json:"nodeUpdateMaxStaleness"`
+	PodStatusWriteInterval     time.Duration `json:"podStatusWriteInterval"`
+	KubeQPS                    int           `json:"kubeQPS"`
+	KubeBurst                  int           `json:"kubeBurst"`
+	KubeBindQPS                int           `json:"kubeBindQPS"`
+	KubeBindBurst              int           `json:"kubeBindBurst"`
+	KubeTimeout                time.Duration `json:"kubeTimeout"`
+	KubeContentType            string        `json:"kubeContentType"`
+	PodImpersonation           string        `json:"podImpersonation"`
+	InformerResyncPeriods      string        `json:"informerResyncPeriods"`
+	InformerWatchdogInterval   time.Duration `json:"informerWatchdogInterval"`
+	InformerFailureTimeout     time.Duration `json:"informerFailureTimeout"`
+	InformerStallTimeout       time.Duration `json:"informerStallTimeout"`
+	StripManagedFields         bool          `json:"stripManagedFields"`
+	MaxAnnotationSize          int           `json:"maxAnnotationSize"`
+	Predicates                 string        `json:"predicates"`
+	OperatorPlugins            string        `json:"operatorPlugins"`
+	EnableConfigHotRefresh     bool          `json:"enableConfigHotRefresh"`
+	DisableGangScheduling      bool          `json:"disableGangScheduling"`
+	EnableNamespaceAnnotations bool          `json:"enableNamespaceAnnotations"`
+	DryRun                     bool          `json:"dryRun"`
+	UserLabelKey               string        `json:"userLabelKey"`
+	UserResolution             string        `json:"userResolution"`
+	WebServicePort             int           `json:"webServicePort"`
+	WebServiceTLS              bool          `json:"webServiceTLS"`
+	WebServiceAuth             string        `json:"webServiceAuth"`
+	WebServiceAuthDebug        bool          `json:"webServiceAuthDebug"`
+	EnableProfiling            bool          `json:"enableProfiling"`
+	CoreServiceURL             string        `json:"coreServiceURL"`
+	ShimConfigFile             string        `json:"shimConfigFile"`
+	ConfigDelivery             string        `json:"configDelivery"`
+	ConfigSecret               string        `json:"configSecret"`
+	QueuesFragmentNamespaces   string        `json:"queuesFragmentNamespaces"`
+	QueuesFragmentParents      string        `json:"queuesFragmentParents"`
+	ProtectQueuesWithApps      bool          `json:"protectQueuesWithApps"`
+	RequireConfigSignature     bool          `json:"requireConfigSignature"`
+	EventSinks                 string        `json:"eventSinks"`
+	EventDedupWindow           time.Duration `json:"eventDedupWindow"`
+	EventQPS                   int           `json:"eventQPS"`
+	EventBurst                 int           `json:"eventBurst"`
+	EventComponent             string        `json:"eventComponent"`
+	EventRecorderSink          string        `json:"eventRecorderSink"`
+	TracingEndpoint            string        `json:"tracingEndpoint"`
+	TracingSampleRatio         float64       `json:"tracingSampleRatio"`
+	AuditLogPath               string        `json:"auditLogPath"`
+	AuditLogMaxSize            int           `json:"auditLogMaxSize"`
+	AuditLogMaxBackups         int           `json:"auditLogMaxBackups"`
+	HealthQueueThreshold       float64       `json:"healthQueueThreshold"`
+	EnableMemoryAccounting     bool          `json:"enableMemoryAccounting"`
+	CacheMemoryLimitMB         int           `json:"cacheMemoryLimitMB"`
+	PreemptionPDBPolicy        string        `json:"preemptionPDBPolicy"`
+	PreemptionEvictTimeout     time.Duration `json:"preemptionEvictTimeout"`
+	PreemptionGracePeriod      time.Duration `json:"preemptionGracePeriod"`
+	PreemptionDryRun           bool          `json:"preemptionDryRun"`
+	PreemptionQueues           string        `json:"preemptionQueues"`
+	PreemptionVictimPolicy     string        `json:"preemptionVictimPolicy"`
+	PreemptionGangPolicy       string        `json:"preemptionGangPolicy"`
+	ProvisioningClass          string        `json:"provisioningClass"`
+	KarpenterSignals           bool          `json:"karpenterSignals"`
+	ScaleDownProtection        bool          `json:"scaleDownProtection"`
+	ScaleDownAnnotation        string        `json:"scaleDownAnnotation"`
+	UnschedulableDelay         time.Duration `json:"unschedulableDelay"`
+	EquivalentPlaceholders     bool          `json:"equivalentPlaceholders"`
+	ScaleUpDisabledQueues      string        `json:"scaleUpDisabledQueues"`
+	PlaceholderSecurityContext string        `json:"placeholderSecurityContext"`
+	loadErrors                 []string
+	secrets                    map[string][]byte
+	secretListeners            []func(changed []string)
 	sync.RWMutex
 }
 
+// resources that support a custom informer resync period
+var informerResources = map[string]bool{
+	"pods":                   true,
+	"nodes":                  true,
+	"configmaps":             true,
+	"persistentvolumes":      true,
+	"persistentvolumeclaims": true,
+	"storageclasses":         true,
+	"namespaces":             true,
+}
+
+// PlaceholderSecurityContext overrides the security context of the placeholder pods, a security context
+// that is set replaces the restricted default of the pod or of the container
+type PlaceholderSecurityContext struct {
+	Pod       *v1.PodSecurityContext `json:"pod,omitempty"`
+	Container *v1.SecurityContext    `json:"container,omitempty"`
+}
+
+// GetPlaceholderSecurityContext parses the placeholderSecurityContext option, nil when it is not set
+func (conf *SchedulerConf) GetPlaceholderSecurityContext() (*PlaceholderSecurityContext, error) {
+	if conf.PlaceholderSecurityContext == "" {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(strings.NewReader(conf.PlaceholderSecurityContext))
+	decoder.DisallowUnknownFields()
+	override := &PlaceholderSecurityContext{}
+	if err := decoder.Decode(override); err != nil {
+		return nil, fmt.Errorf("placeholderSecurityContext: %v", err)
+	}
+	return override, nil
+}
+
+// GetPodImpersonation parses the podImpersonation option into identities keyed by namespace,
+// the "*" key holds the identity of the namespaces that are not listed
+func (conf *SchedulerConf) GetPodImpersonation() (map[string]string, error) {
+	identities := make(map[string]string)
+	if conf.PodImpersonation == "" {
+		return identities, nil
+	}
+	for _, entry := range strings.Split(conf.PodImpersonation, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("podImpersonation: invalid entry %q", entry)
+		}
+		if parts[0] != "*" && len(validation.IsDNS1123Label(parts[0])) > 0 {
+			return nil, fmt.Errorf("podImpersonation: invalid namespace %q", parts[0])
+		}
+		if _, ok := identities[parts[0]]; ok {
+			return nil, fmt.Errorf("podImpersonation: duplicate namespace %q", parts[0])
+		}
+		identities[parts[0]] = strings.TrimSpace(parts[1])
+	}
+	return identities, nil
+}
+
+// GetInformerResyncPeriods parses the informerResyncPeriods option into resync periods keyed by resource
+func (conf *SchedulerConf) GetInformerResyncPeriods() (map[string]time.Duration, error) {
+	periods := make(map[string]time.Duration)
+	if conf.InformerResyncPeriods == "" {
+		return periods, nil
+	}
+	for _, entry := range strings.Split(conf.InformerResyncPeriods, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		if len(parts) != 2 || !informerResources[parts[0]] {
+			return nil, fmt.Errorf("informerResyncPeriods: invalid entry %q", entry)
+		}
+		period, err := time.ParseDuration(parts[1])
+		if err != nil || period < 0 {
+			return nil, fmt.Errorf("informerResyncPeriods: invalid period for %s: %s", parts[0], parts[1])
+		}
+		periods[parts[0]] = period
+	}
+	return periods, nil
+}
+
 func GetSchedulerConf() *SchedulerConf {
 	once.Do(initConfigs)
 	return configuration
@@ -94,6 +451,87 @@ func (conf *SchedulerConf) GetSchedulingInterval() time.Duration {
 	return conf.Interval
 }
 
+// GetRecoveryWorkers returns the number of recovery workers, the default when the configuration is not loaded
+func (conf *SchedulerConf) GetRecoveryWorkers() int {
+	conf.RLock()
+	defer conf.RUnlock()
+	if conf.RecoveryWorkers <= 0 {
+		return DefaultRecoveryWorkers
+	}
+	return conf.RecoveryWorkers
+}
+
+// IsPreemptionEnabled returns true when the pods of the queue, or of one of its parents, can be evicted
+// for a preemption. All queues are enabled when no queues are configured.
+func (conf *SchedulerConf) IsPreemptionEnabled(queue string) bool {
+	conf.RLock()
+	defer conf.RUnlock()
+	if conf.PreemptionQueues == "" {
+		return true
+	}
+	return isQueueListed(conf.PreemptionQueues, queue)
+}
+
+// IsScaleUpEnabled returns true when the unschedulable pods of the queue can trigger the autoscaler to scale up
+// the cluster: the queue and its parents are not disabled.
+func (conf *SchedulerConf) IsScaleUpEnabled(queue string) bool {
+	conf.RLock()
+	defer conf.RUnlock()
+	return !isQueueListed(conf.ScaleUpDisabledQueues, queue)
+}
+
+// IsQueuesFragmentNamespace returns true when the queues fragments are read from the namespace: the
+// scheduler namespace and the namespaces of the queuesFragmentNamespaces option
+func (conf *SchedulerConf) IsQueuesFragmentNamespace(namespace, schedulerNamespace string) bool {
+	conf.RLock()
+	defer conf.RUnlock()
+	if namespace == schedulerNamespace {
+		return true
+	}
+	for _, listed := range strings.Split(conf.QueuesFragmentNamespaces, ",") {
+		if strings.TrimSpace(listed) == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// IsQueuesFragmentParent returns true when a queues fragment can add queues to the parent queue: the parent
+// is, or is below, one of the queues of the queuesFragmentParents option. Any parent is allowed when none is set.
+func (conf *SchedulerConf) IsQueuesFragmentParent(parent string) bool {
+	conf.RLock()
+	defer conf.RUnlock()
+	if conf.QueuesFragmentParents == "" {
+		return true
+	}
+	// the core matches the queue names case-insensitively
+	return isQueueListed(strings.ToLower(conf.QueuesFragmentParents), strings.ToLower(parent))
+}
+
+// returns true when the queue, or one of its parents, is in the comma separated list of queues
+func isQueueListed(queues, queue string) bool {
+	for _, listed := range strings.Split(queues, ",") {
+		listed = strings.TrimSpace(listed)
+		if listed != "" && (queue == listed || strings.HasPrefix(queue, listed+".")) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetUserResolution returns the sources the user of an application is resolved from, in order
+func (conf *SchedulerConf) GetUserResolution() []string {
+	conf.RLock()
+	defer conf.RUnlock()
+	sources := make([]string, 0)
+	for _, source := range strings.Split(conf.UserResolution, ",") {
+		if source = strings.TrimSpace(source); source != "" {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
 func (conf *SchedulerConf) GetKubeConfigPath() string {
 	conf.RLock()
 	defer conf.RUnlock()
@@ -117,76 +555,681 @@ func (conf *SchedulerConf) IsOperatorPluginEnabled(name string) bool {
 	return false
 }
 
+// Validate checks the loaded configuration, the returned error lists all
+// values that could not be loaded or are outside of the allowed range.
+func (conf *SchedulerConf) Validate() error {
+	conf.RLock()
+	defer conf.RUnlock()
+	var errs []error
+	for _, msg := range conf.loadErrors {
+		errs = append(errs, fmt.Errorf("%s", msg))
+	}
+	if conf.ClusterID == "" {
+		errs = append(errs, fmt.Errorf("clusterId must not be empty"))
+	}
+	if conf.PolicyGroup == "" {
+		errs = append(errs, fmt.Errorf("policyGroup must not be empty"))
+	}
+	if conf.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("interval must be positive, got %v", conf.Interval))
+	}
+	if conf.LoggingLevel < -1 || conf.LoggingLevel > 5 {
+		errs = append(errs, fmt.Errorf("logLevel must be in range [-1, 5], got %d", conf.LoggingLevel))
+	}
+	if conf.LogEncoding != "json" && conf.LogEncoding != "console" {
+		errs = append(errs, fmt.Errorf("logEncoding must be json or console, got %s", conf.LogEncoding))
+	}
+	if conf.LogSampleRate < 1 {
+		errs = append(errs, fmt.Errorf("logSampleRate must be at least 1, got %d", conf.LogSampleRate))
+	}
+	if conf.VolumeBindTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("volumeBindTimeout must be positive, got %v", conf.VolumeBindTimeout))
+	}
+	if conf.EventChannelCapacity <= 0 {
+		errs = append(errs, fmt.Errorf("eventChannelCapacity must be positive, got %d", conf.EventChannelCapacity))
+	}
+	if conf.DispatchTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("dispatchTimeout must be positive, got %v", conf.DispatchTimeout))
+	}
+	if conf.AsyncDispatchLimit < 0 {
+		errs = append(errs, fmt.Errorf("asyncDispatchLimit must not be negative, got %d", conf.AsyncDispatchLimit))
+	}
+	if conf.DispatcherWorkers <= 0 {
+		errs = append(errs, fmt.Errorf("dispatcherWorkers must be positive, got %d", conf.DispatcherWorkers))
+	}
+	if conf.DispatcherDrainTimeout < 0 {
+		errs = append(errs, fmt.Errorf("dispatcherDrainTimeout must not be negative, got %v", conf.DispatcherDrainTimeout))
+	}
+	if conf.EventHistorySize < 0 {
+		errs = append(errs, fmt.Errorf("eventHistorySize must not be negative, got %d", conf.EventHistorySize))
+	}
+	switch conf.DispatcherBackpressure {
+	case BackpressureAsync, BackpressureBlock, BackpressureShed:
+	default:
+		errs = append(errs, fmt.Errorf("dispatcherBackpressure must be %s, %s or %s, got %s",
+			BackpressureAsync, BackpressureBlock, BackpressureShed, conf.DispatcherBackpressure))
+	}
+	if conf.KubeQPS <= 0 {
+		errs = append(errs, fmt.Errorf("kubeQPS must be positive, got %d", conf.KubeQPS))
+	}
+	if conf.KubeBurst <= 0 {
+		errs = append(errs, fmt.Errorf("kubeBurst must be positive, got %d", conf.KubeBurst))
+	}
+	if conf.KubeBindQPS < 0 {
+		errs = append(errs, fmt.Errorf("kubeBindQPS must not be negative, got %d", conf.KubeBindQPS))
+	}
+	if conf.KubeBindQPS > 0 && conf.KubeBindBurst <= 0 {
+		errs = append(errs, fmt.Errorf("kubeBindBurst must be positive, got %d", conf.KubeBindBurst))
+	}
+	if conf.KubeTimeout < 0 {
+		errs = append(errs, fmt.Errorf("kubeTimeout must not be negative, got %v", conf.KubeTimeout))
+	}
+	if conf.KubeContentType != ContentTypeJSON && conf.KubeContentType != ContentTypeProtobuf {
+		errs = append(errs, fmt.Errorf("kubeContentType must be %s or %s, got %s",
+			ContentTypeJSON, ContentTypeProtobuf, conf.KubeContentType))
+	}
+	if _, err := conf.GetPodImpersonation(); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := conf.GetInformerResyncPeriods(); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := conf.GetPlaceholderSecurityContext(); err != nil {
+		errs = append(errs, err)
+	}
+	if conf.InformerWatchdogInterval < 0 {
+		errs = append(errs, fmt.Errorf("informerWatchdogInterval must not be negative, got %v", conf.InformerWatchdogInterval))
+	}
+	if conf.InformerFailureTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("informerFailureTimeout must be positive, got %v", conf.InformerFailureTimeout))
+	}
+	if conf.InformerStallTimeout < 0 {
+		errs = append(errs, fmt.Errorf("informerStallTimeout must not be negative, got %v", conf.InformerStallTimeout))
+	}
+	if conf.RecoveryWorkers <= 0 {
+		errs = append(errs, fmt.Errorf("recoveryWorkers must be positive, got %d", conf.RecoveryWorkers))
+	}
+	if conf.RecoveryTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("recoveryTimeout must be positive, got %v", conf.RecoveryTimeout))
+	}
+	if conf.AskBatchInterval < 0 {
+		errs = append(errs, fmt.Errorf("askBatchInterval must not be negative, got %v", conf.AskBatchInterval))
+	}
+	if conf.AskBatchSize <= 0 {
+		errs = append(errs, fmt.Errorf("askBatchSize must be positive, got %d", conf.AskBatchSize))
+	}
+	if conf.BindWorkers < 0 {
+		errs = append(errs, fmt.Errorf("bindWorkers must not be negative, got %d", conf.BindWorkers))
+	}
+	if conf.PlaceholderWorkers < 0 {
+		errs = append(errs, fmt.Errorf("placeholderWorkers must not be negative, got %d", conf.PlaceholderWorkers))
+	}
+	if conf.NodeUpdateMaxStaleness < 0 {
+		errs = append(errs, fmt.Errorf("nodeUpdateMaxStaleness must not be negative, got %v", conf.NodeUpdateMaxStaleness))
+	}
+	if conf.PodStatusWriteInterval < 0 {
+		errs = append(errs, fmt.Errorf("podStatusWriteInterval must not be negative, got %v", conf.PodStatusWriteInterval))
+	}
+	if conf.MaxAnnotationSize < 0 {
+		errs = append(errs, fmt.Errorf("maxAnnotationSize must not be negative, got %d", conf.MaxAnnotationSize))
+	}
+	if conf.WebServicePort < 0 || conf.WebServicePort > 65535 {
+		errs = append(errs, fmt.Errorf("webServicePort must be in range [0, 65535], got %d", conf.WebServicePort))
+	}
+	if conf.ConfigDelivery != ConfigDeliveryFile && conf.ConfigDelivery != ConfigDeliveryDirect {
+		errs = append(errs, fmt.Errorf("configDelivery must be %s or %s, got %s",
+			ConfigDeliveryFile, ConfigDeliveryDirect, conf.ConfigDelivery))
+	}
+	if conf.EventDedupWindow < 0 {
+		errs = append(errs, fmt.Errorf("eventDedupWindow must not be negative, got %v", conf.EventDedupWindow))
+	}
+	if conf.EventQPS < 0 {
+		errs = append(errs, fmt.Errorf("eventQPS must not be negative, got %d", conf.EventQPS))
+	}
+	if conf.EventQPS > 0 && conf.EventBurst <= 0 {
+		errs = append(errs, fmt.Errorf("eventBurst must be positive, got %d", conf.EventBurst))
+	}
+	if conf.EventComponent == "" {
+		errs = append(errs, fmt.Errorf("eventComponent must not be empty"))
+	}
+	switch conf.EventRecorderSink {
+	case RecorderSinkKubernetes, RecorderSinkLog, RecorderSinkNone:
+	default:
+		errs = append(errs, fmt.Errorf("eventRecorderSink must be %s, %s or %s, got %s",
+			RecorderSinkKubernetes, RecorderSinkLog, RecorderSinkNone, conf.EventRecorderSink))
+	}
+	for _, sink := range strings.Split(conf.EventSinks, ",") {
+		sink = strings.TrimSpace(sink)
+		if sink == "" {
+			continue
+		}
+		if u, err := url.Parse(sink); err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "file") {
+			errs = append(errs, fmt.Errorf("eventSinks must be http, https or file URLs, got %s", sink))
+		}
+	}
+	if conf.CoreServiceURL != "" {
+		if u, err := url.Parse(conf.CoreServiceURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			errs = append(errs, fmt.Errorf("coreServiceURL must be a http or https URL, got %s", conf.CoreServiceURL))
+		}
+	}
+	if conf.TracingEndpoint != "" {
+		if u, err := url.Parse(conf.TracingEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			errs = append(errs, fmt.Errorf("tracingEndpoint must be a http or https URL, got %s", conf.TracingEndpoint))
+		}
+	}
+	if conf.TracingSampleRatio < 0 || conf.TracingSampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("tracingSampleRatio must be between 0 and 1, got %v", conf.TracingSampleRatio))
+	}
+	if conf.AuditLogMaxSize <= 0 {
+		errs = append(errs, fmt.Errorf("auditLogMaxSize must be positive, got %d", conf.AuditLogMaxSize))
+	}
+	if conf.AuditLogMaxBackups < 0 {
+		errs = append(errs, fmt.Errorf("auditLogMaxBackups must not be negative, got %d", conf.AuditLogMaxBackups))
+	}
+	if conf.HealthQueueThreshold <= 0 || conf.HealthQueueThreshold > 1 {
+		errs = append(errs, fmt.Errorf("healthQueueThreshold must be above 0 and at most 1, got %v", conf.HealthQueueThreshold))
+	}
+	if conf.CacheMemoryLimitMB < 0 {
+		errs = append(errs, fmt.Errorf("cacheMemoryLimitMB must not be negative, got %d", conf.CacheMemoryLimitMB))
+	}
+	if conf.CacheMemoryLimitMB > 0 && !conf.EnableMemoryAccounting {
+		errs = append(errs, fmt.Errorf("cacheMemoryLimitMB requires enableMemoryAccounting"))
+	}
+	if conf.PreemptionPDBPolicy != PDBPolicyEvict && conf.PreemptionPDBPolicy != PDBPolicySkip {
+		errs = append(errs, fmt.Errorf("preemptionPDBPolicy must be %s or %s, got %s",
+			PDBPolicyEvict, PDBPolicySkip, conf.PreemptionPDBPolicy))
+	}
+	if conf.PreemptionEvictTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("preemptionEvictTimeout must be positive, got %v", conf.PreemptionEvictTimeout))
+	}
+	if conf.PreemptionGracePeriod < 0 {
+		errs = append(errs, fmt.Errorf("preemptionGracePeriod must not be negative, got %v", conf.PreemptionGracePeriod))
+	}
+	errs = append(errs, checkQueueNames("preemptionQueues", conf.PreemptionQueues)...)
+	errs = append(errs, checkQueueNames("queuesFragmentParents", conf.QueuesFragmentParents)...)
+	for _, namespace := range strings.Split(conf.QueuesFragmentNamespaces, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" && len(validation.IsDNS1123Label(namespace)) > 0 {
+			errs = append(errs, fmt.Errorf("queuesFragmentNamespaces must be a list of namespaces, got %s", namespace))
+		}
+	}
+	if conf.PreemptionVictimPolicy != VictimPolicyDefault && conf.PreemptionVictimPolicy != VictimPolicyPreferPlaceholder {
+		errs = append(errs, fmt.Errorf("preemptionVictimPolicy must be %s or %s, got %s",
+			VictimPolicyDefault, VictimPolicyPreferPlaceholder, conf.PreemptionVictimPolicy))
+	}
+	switch conf.PreemptionGangPolicy {
+	case GangPolicyNone, GangPolicyRestart, GangPolicyFail:
+	default:
+		errs = append(errs, fmt.Errorf("preemptionGangPolicy must be %s, %s or %s, got %s",
+			GangPolicyNone, GangPolicyRestart, GangPolicyFail, conf.PreemptionGangPolicy))
+	}
+	switch conf.ProvisioningClass {
+	case "", ProvisioningClassCheckCapacity, ProvisioningClassAtomicScaleUp:
+	default:
+		errs = append(errs, fmt.Errorf("provisioningClass must be empty, %s or %s, got %s",
+			ProvisioningClassCheckCapacity, ProvisioningClassAtomicScaleUp, conf.ProvisioningClass))
+	}
+	if msgs := validation.IsQualifiedName(conf.ScaleDownAnnotation); len(msgs) > 0 {
+		errs = append(errs, fmt.Errorf("scaleDownAnnotation must be a qualified name, got %s: %s",
+			conf.ScaleDownAnnotation, strings.Join(msgs, ", ")))
+	}
+	if conf.UnschedulableDelay < 0 {
+		errs = append(errs, fmt.Errorf("unschedulableDelay must not be negative, got %v", conf.UnschedulableDelay))
+	}
+	errs = append(errs, checkQueueNames("scaleUpDisabledQueues", conf.ScaleUpDisabledQueues)...)
+	for _, source := range strings.Split(conf.UserResolution, ",") {
+		switch source = strings.TrimSpace(source); source {
+		case "", UserSourceAnnotation, UserSourceLabel, UserSourceServiceAccount:
+		default:
+			errs = append(errs, fmt.Errorf("userResolution must be a list of %s, %s or %s, got %s",
+				UserSourceAnnotation, UserSourceLabel, UserSourceServiceAccount, source))
+		}
+	}
+	if conf.WebServiceAuth != WebServiceAuthToken && conf.WebServiceAuth != WebServiceAuthKubernetes {
+		errs = append(errs, fmt.Errorf("webServiceAuth must be %s or %s, got %s",
+			WebServiceAuthToken, WebServiceAuthKubernetes, conf.WebServiceAuth))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// checks the comma separated list of queues of the option contains fully qualified queue names only
+func checkQueueNames(option, queues string) []error {
+	var errs []error
+	for _, queue := range strings.Split(queues, ",") {
+		queue = strings.TrimSpace(queue)
+		if queue != "" && queue != "root" && !strings.HasPrefix(queue, "root.") {
+			errs = append(errs, fmt.Errorf("%s must be fully qualified queue names, got %s", option, queue))
+		}
+	}
+	return errs
+}
+
 func initConfigs() {
+	configuration = loadConfigs(flag.CommandLine, os.Args[1:], os.LookupEnv)
+
+	// if log level is debug, enable klog and set its log level verbosity to 4 (represents debug level),
+	// For details refer to the Logging Conventions of klog at
+	// https://github.com/kubernetes/community/blob/master/contributors/devel/sig-instrumentation/logging.md
+	if zapcore.Level(configuration.LoggingLevel).Enabled(zapcore.DebugLevel) {
+		klog.InitFlags(nil)
+		// cannot really handle the error here ignore it
+		//nolint:errcheck
+		_ = flag.Set("v", "4")
+	}
+}
+
+// load the scheduler configuration, a value set by a later source overrides the value
+// set by an earlier one: defaults < shim configuration file (mounted from the scheduler
+// ConfigMap) < environment variables listed in envVars < command line flags.
+// All the values are set through the registered flags, this makes sure every source
+// is parsed and type checked the same way. Values that could not be applied are recorded
+// in the returned configuration and reported by Validate.
+func loadConfigs(fs *flag.FlagSet, args []string, lookupEnv func(string) (string, bool)) *SchedulerConf {
 	// scheduler options
-	kubeConfig := flag.String("kubeConfig", "",
+	kubeConfig := fs.String("kubeConfig", "",
 		"absolute path to the kubeconfig file")
-	schedulingInterval := flag.Duration("interval", DefaultSchedulingInterval,
+	kubeContext := fs.String("kubeContext", "",
+		"name of the kubeconfig context to use, defaults to the current context")
+	schedulingInterval := fs.Duration("interval", DefaultSchedulingInterval,
 		"scheduling interval in seconds")
-	clusterID := flag.String("clusterId", DefaultClusterID,
+	clusterID := fs.String("clusterId", DefaultClusterID,
 		"cluster id")
-	clusterVersion := flag.String("clusterVersion", DefaultClusterVersion,
+	clusterVersion := fs.String("clusterVersion", DefaultClusterVersion,
 		"cluster version")
-	policyGroup := flag.String("policyGroup", DefaultPolicyGroup,
+	policyGroup := fs.String("policyGroup", DefaultPolicyGroup,
 		"policy group")
-	volumeBindTimeout := flag.Duration("volumeBindTimeout", DefaultVolumeBindTimeout,
+	volumeBindTimeout := fs.Duration("volumeBindTimeout", DefaultVolumeBindTimeout,
 		"timeout in seconds when binding a volume")
-	eventChannelCapacity := flag.Int("eventChannelCapacity", DefaultEventChannelCapacity,
+	eventChannelCapacity := fs.Int("eventChannelCapacity", DefaultEventChannelCapacity,
 		"event channel capacity of dispatcher")
-	dispatchTimeout := flag.Duration("dispatchTimeout", DefaultDispatchTimeout,
+	dispatchTimeout := fs.Duration("dispatchTimeout", DefaultDispatchTimeout,
 		"timeout in seconds when dispatching an event")
-	kubeQPS := flag.Int("kubeQPS", DefaultKubeQPS,
+	asyncDispatchLimit := fs.Int("asyncDispatchLimit", 0,
+		"maximum number of events waiting for space in the full event channel of the dispatcher, "+
+			"0 uses a tenth of the event channel capacity with a minimum of 10000")
+	dispatcherBackpressure := fs.String("dispatcherBackpressure", DefaultBackpressure,
+		"behaviour of the dispatcher when the event channel is full: "+BackpressureAsync+" waits for space in the "+
+			"background up to the async dispatch limit, "+BackpressureBlock+" blocks the producer until there is space, "+
+			BackpressureShed+" drops the low priority events and waits in the background for the others")
+	dispatcherWorkers := fs.Int("dispatcherWorkers", 1,
+		"number of goroutines handling the events of the dispatcher, the events of an application and its tasks, "+
+			"or of a node, are always handled in order by the same goroutine")
+	dispatcherDrainTimeout := fs.Duration("dispatcherDrainTimeout", DefaultDispatcherDrain,
+		"maximum time the dispatcher handles the queued events on shutdown, the events left after this time "+
+			"are logged and not handled, 0 stops the dispatcher without draining")
+	eventHistorySize := fs.Int("eventHistorySize", DefaultEventHistorySize,
+		"number of events most recently accepted by the dispatcher that are kept for debugging, "+
+			"0 disables the event history")
+	syncRecovery := fs.Bool("syncRecovery", true,
+		"Flag for handling the node recovery events in order on the recovery goroutine, the task events are "+
+			"held back until the recovery is done so that no asks reach the core before the nodes are registered")
+	recoveryWorkers := fs.Int("recoveryWorkers", DefaultRecoveryWorkers,
+		"maximum number of applications, pods and nodes recovered at the same time on startup")
+	recoveryTimeout := fs.Duration("recoveryTimeout", DefaultRecoveryTimeout,
+		"maximum time the recovery of the applications and the nodes takes on startup, the scheduler starts "+
+			"with the objects recovered when the time is up, the other nodes are not used until the core accepts them")
+	askBatchInterval := fs.Duration("askBatchInterval", 0,
+		"maximum time the allocation asks are held back to be sent to the core in one request, "+
+			"0 sends every ask on its own")
+	askBatchSize := fs.Int("askBatchSize", DefaultAskBatchSize,
+		"number of held back allocation asks that are sent to the core without waiting for the batch interval")
+	bindWorkers := fs.Int("bindWorkers", 0,
+		"maximum number of pods bound to their nodes at the same time, "+
+			"0 scales the number with the size of the cluster")
+	placeholderWorkers := fs.Int("placeholderWorkers", 0,
+		"maximum number of placeholder pods of an application created at the same time, "+
+			"0 scales the number with the size of the cluster")
+	nodeUpdateMaxStaleness := fs.Duration("nodeUpdateMaxStaleness", DefaultNodeUpdateStaleness,
+		"maximum time the occupied resource updates of a node are collapsed before they are sent to the core, "+
+			"capacity and schedulable changes are sent immediately, 0 sends every update on its own")
+	podStatusWriteInterval := fs.Duration("podStatusWriteInterval", DefaultPodStatusInterval,
+		"minimum time between two pod status writes of the same pod, the writes in between are collapsed "+
+			"and only the latest one is sent, 0 disables the limit")
+	kubeQPS := fs.Int("kubeQPS", DefaultKubeQPS,
 		"the maximum QPS to kubernetes master from this client")
-	kubeBurst := flag.Int("kubeBurst", DefaultKubeBurst,
+	kubeBurst := fs.Int("kubeBurst", DefaultKubeBurst,
 		"the maximum burst for throttle to kubernetes master from this client")
-	operatorPluginList := flag.String("operatorPlugins", "general,"+constants.AppManagerHandlerName,
+	kubeBindQPS := fs.Int("kubeBindQPS", DefaultKubeBindQPS,
+		"the maximum QPS of the dedicated client for binding and deleting pods, "+
+			"0 means these calls share the rate limit and the connections of the default client")
+	kubeBindBurst := fs.Int("kubeBindBurst", DefaultKubeBindBurst,
+		"the maximum burst of the dedicated client for binding and deleting pods")
+	kubeTimeout := fs.Duration("kubeTimeout", DefaultKubeTimeout,
+		"timeout of a single request to kubernetes master from this client, 0 means no timeout")
+	kubeContentType := fs.String("kubeContentType", DefaultKubeContentType,
+		"content type used by this client to talk to kubernetes master, "+ContentTypeJSON+" or "+ContentTypeProtobuf)
+	podImpersonation := fs.String("podImpersonation", "",
+		"comma-separated list of namespace=identity, the scheduler impersonates the identity to delete and evict "+
+			"the pods of the namespace, \"*\" matches the namespaces that are not listed and {namespace} is replaced "+
+			"by the namespace, e.g. \"*=system:serviceaccount:{namespace}:yunikorn\"")
+	informerResyncPeriods := fs.String("informerResyncPeriods", "",
+		"comma-separated list of resource=duration resync periods for the informers, e.g. \"pods=0s,nodes=10m\", "+
+			"resync is disabled for the resources that are not listed")
+	informerWatchdogInterval := fs.Duration("informerWatchdogInterval", DefaultWatchdogInterval,
+		"interval of the informer health checks, 0 disables the informer watchdog")
+	informerFailureTimeout := fs.Duration("informerFailureTimeout", DefaultInformerFailure,
+		"an informer that fails to list or watch for longer than this is restarted and reported unhealthy")
+	informerStallTimeout := fs.Duration("informerStallTimeout", 0,
+		"an informer that delivers no events for longer than this is restarted, 0 disables the check")
+	stripManagedFields := fs.Bool("stripManagedFields", true,
+		"remove the managed fields from the pods and nodes before they are cached")
+	maxAnnotationSize := fs.Int("maxAnnotationSize", 0,
+		"remove the annotations larger than this size in bytes from the pods and nodes before they are cached, "+
+			"the yunikorn annotations are always kept, 0 keeps all the annotations")
+	operatorPluginList := fs.String("operatorPlugins", "general,"+constants.AppManagerHandlerName,
 		"comma-separated list of operator plugin names, currently, only \"spark-k8s-operator\""+
 			"and"+constants.AppManagerHandlerName+"is supported.")
+	webServicePort := fs.Int("webServicePort", DefaultWebServicePort,
+		"port of the shim REST web service, set to 0 to disable the web service")
+	webServiceTLS := fs.Bool("webServiceTLS", false, "Flag for serving the shim REST web service over TLS "+
+		"with the tlsCert and tlsKey of the config Secret, the certificate is reloaded when the Secret changes. "+
+		"With a tlsClientCA the admin operations also accept the client certificates signed by the CA. "+
+		"The metrics can be scraped over TLS from /ws/v1/metrics of the shim web service.")
+	webServiceAuth := fs.String("webServiceAuth", WebServiceAuthToken, "how the callers of the admin "+
+		"operations are authenticated: token accepts the adminToken of the config Secret, kubernetes also "+
+		"reviews the bearer tokens with the api-server and authorizes the callers with a SubjectAccessReview "+
+		"of the request path and method")
+	webServiceAuthDebug := fs.Bool("webServiceAuthDebug", false, "Flag for authenticating and authorizing "+
+		"the callers of the debug endpoints of the shim web service like the admin operations.")
+	enableProfiling := fs.Bool("enableProfiling", false, "Flag for serving the pprof and execution trace "+
+		"endpoints of the shim web service, these can also be enabled at runtime.")
+	coreServiceURL := fs.String("coreServiceURL", "",
+		"the URL of the web service of a core that runs separately, e.g. http://yunikorn-core:9080, the "+
+			"configurations are validated by that core, empty validates them with the embedded core")
+	shimConfigFile := fs.String("shimConfigFile", DefaultShimConfigFile,
+		"absolute path to the shim configuration file, usually mounted from the scheduler ConfigMap")
 
 	// logging options
-	logLevel := flag.Int("logLevel", DefaultLoggingLevel,
+	logLevel := fs.Int("logLevel", DefaultLoggingLevel,
 		"logging level, available range [-1, 5], from DEBUG to FATAL.")
-	encode := flag.String("logEncoding", DefaultLogEncoding,
+	encode := fs.String("logEncoding", DefaultLogEncoding,
 		"log encoding, json or console.")
-	logFile := flag.String("logFile", "",
+	logFile := fs.String("logFile", "",
 		"absolute log file path")
-	enableConfigHotRefresh := flag.Bool("enableConfigHotRefresh", false, "Flag for enabling "+
+	logSampleRate := fs.Int("logSampleRate", DefaultLogSampleRate,
+		"only 1 in logSampleRate of the per-pod debug messages of the hot paths is logged, 1 logs all of them.")
+	enableConfigHotRefresh := fs.Bool("enableConfigHotRefresh", false, "Flag for enabling "+
 		"configuration hot-refresh. If this value is set to true, the configuration updates in the configmap will be "+
 		"automatically reloaded without restarting the scheduler.")
-	disableGangScheduling := flag.Bool("disableGangScheduling", false, "Flag for disabling "+
+	configDelivery := fs.String("configDelivery", DefaultConfigDelivery,
+		"how the queue configuration is delivered to the core, \"file\" reads the file mounted from the "+
+			"ConfigMap, \"direct\" pushes the ConfigMap content to the core without waiting for the volume update")
+	configSecret := fs.String("configSecret", "",
+		"name of the Secret in the scheduler namespace that holds the sensitive configuration values")
+	queuesFragmentNamespaces := fs.String("queuesFragmentNamespaces", "",
+		"comma-separated list of the namespaces the queues fragments are read from besides the scheduler namespace, "+
+			"the fragments in any other namespace are ignored")
+	queuesFragmentParents := fs.String("queuesFragmentParents", "",
+		"comma-separated list of the queues the queues fragments can add queues to, a fragment with a parent that is "+
+			"not one of these queues or below them is rejected, empty allows any parent")
+	protectQueuesWithApps := fs.Bool("protectQueuesWithApps", true, "Flag for rejecting "+
+		"configuration updates through the scheduler API that remove queues with running applications.")
+	requireConfigSignature := fs.Bool("requireConfigSignature", false, "Flag for rejecting the "+
+		"configuration updates through the scheduler API that are not signed with the configSigningKey of the "+
+		"config Secret: the configuration must end with a \"# signature: <hex HMAC-SHA256>\" comment line, the HMAC "+
+		"is computed over all the bytes before that line.")
+	disableGangScheduling := fs.Bool("disableGangScheduling", false, "Flag for disabling "+
 		"gang scheduling. If this value is set to true, task-group metadata will be ignored by the scheduler.")
-	userLabelKey := flag.String("userLabelKey", constants.DefaultUserLabel,
+	enableNamespaceAnnotations := fs.Bool("enableNamespaceAnnotations", true, "Flag for enabling "+
+		"the namespace annotations, e.g. the namespace resource quota and scheduling policy. If this value is set "+
+		"to false, the namespaces are not watched by the scheduler and the applications are rejected as the namespace "+
+		"queue ACL cannot be checked.")
+	dryRun := fs.Bool("dryRun", false, "Flag for running the scheduler in dry-run mode. If this value is set "+
+		"to true, the binds, pod deletions, status updates and configmap writes are logged but not executed.")
+	userLabelKey := fs.String("userLabelKey", constants.DefaultUserLabel,
 		"provide pod label key to be used to identify an user")
+	userResolution := fs.String("userResolution", UserSourceLabel,
+		"comma separated list of the sources the user and the groups of an application are resolved from, "+
+			"in order: annotation, label or serviceaccount. The annotation is only trusted with the admission "+
+			"controller setting it, the default user is used when no source resolves the user")
+	eventSinks := fs.String("eventSinks", "",
+		"comma-separated list of URLs the application, task and node lifecycle events are streamed to, "+
+			"http and https URLs are webhooks that receive the events as JSON arrays, "+
+			"file URLs are files the events are appended to as JSON lines")
+	eventDedupWindow := fs.Duration("eventDedupWindow", DefaultEventDedupWindow,
+		"the Kubernetes events with the same type and reason for the same object within this window are "+
+			"collapsed into one event with a count, 0 disables the deduplication")
+	eventQPS := fs.Int("eventQPS", DefaultEventQPS,
+		"the maximum number of Kubernetes events the scheduler creates per second, the events above the limit "+
+			"are dropped, 0 means no limit")
+	eventBurst := fs.Int("eventBurst", DefaultEventBurst,
+		"the maximum burst of Kubernetes events the scheduler creates")
+	eventComponent := fs.String("eventComponent", constants.SchedulerName,
+		"the source component of the Kubernetes events the scheduler creates")
+	eventRecorderSink := fs.String("eventRecorderSink", RecorderSinkKubernetes,
+		"where the Kubernetes events of the scheduler go: \""+RecorderSinkKubernetes+"\" creates them in the cluster, "+
+			"\""+RecorderSinkLog+"\" only logs them, \""+RecorderSinkNone+"\" drops them")
+	tracingEndpoint := fs.String("tracingEndpoint", "",
+		"the OTLP/HTTP endpoint the traces of the pod scheduling are exported to, "+
+			"e.g. http://otel-collector:4318/v1/traces, empty disables the tracing")
+	tracingSampleRatio := fs.Float64("tracingSampleRatio", DefaultTracingSampleRatio,
+		"the ratio of the pods that are traced when the tracing is enabled, between 0 and 1")
+	auditLogPath := fs.String("auditLogPath", "",
+		"the file the audit log of the allocations is written to as JSON lines, empty disables the audit log")
+	auditLogMaxSize := fs.Int("auditLogMaxSize", DefaultAuditLogMaxSize,
+		"the size in megabytes the audit log is rotated at")
+	auditLogMaxBackups := fs.Int("auditLogMaxBackups", DefaultAuditLogMaxBackups,
+		"the number of rotated audit log files that are kept")
+	healthQueueThreshold := fs.Float64("healthQueueThreshold", DefaultHealthQueueThreshold,
+		"the fraction of the event channel capacity the dispatcher queues can fill before the scheduler is reported degraded")
+	enableMemoryAccounting := fs.Bool("enableMemoryAccounting", false,
+		"Flag for accounting the approximate memory held by the scheduler cache and the context, exposed as metrics")
+	cacheMemoryLimitMB := fs.Int("cacheMemoryLimitMB", 0,
+		"the approximate memory in megabytes the caches can hold before the terminated pods, tasks and applications "+
+			"are removed from them, 0 disables the limit")
+	preemptionPDBPolicy := fs.String("preemptionPDBPolicy", DefaultPreemptionPDBPolicy,
+		"how preemption victims protected by a PodDisruptionBudget are handled: evict retries the eviction "+
+			"until the budget allows it, skip leaves the protected victims running")
+	preemptionEvictTimeout := fs.Duration("preemptionEvictTimeout", DefaultPreemptEvictTimeout,
+		"the time the eviction of a preemption victim blocked by a PodDisruptionBudget is retried for")
+	preemptionGracePeriod := fs.Duration("preemptionGracePeriod", 0,
+		"the termination grace period of the evicted preemption victims, 0 uses the grace period of the pod")
+	preemptionDryRun := fs.Bool("preemptionDryRun", false,
+		"Flag for logging the preemption victims without evicting them")
+	preemptionQueues := fs.String("preemptionQueues", "",
+		"comma separated list of the queues, including their children, whose pods can be evicted for a preemption, "+
+			"empty enables all queues")
+	preemptionVictimPolicy := fs.String("preemptionVictimPolicy", DefaultPreemptVictimPolicy,
+		"how the core chooses the preemption victims: default follows the priorities of the pods, prefer-placeholder "+
+			"asks the core to preempt the placeholders of the gangs with a lower priority than the preemptor "+
+			"before the real workloads")
+	preemptionGangPolicy := fs.String("preemptionGangPolicy", DefaultPreemptGangPolicy,
+		"what happens to a hard gang application when one of its pods is preempted: none leaves the other pods "+
+			"running, restart evicts them for their controllers to create the gang again, fail fails the application")
+	provisioningClass := fs.String("provisioningClass", "",
+		"the class of the cluster-autoscaler ProvisioningRequests created for the hard gangs that cannot fit, "+
+			"check-capacity.autoscaling.x-k8s.io or atomic-scale-up.autoscaling.x-k8s.io, empty creates none")
+	karpenterSignals := fs.Bool("karpenterSignals", false,
+		"Flag for nominating the allocated pods to their node until they are bound, and for protecting the pods "+
+			"of gang applications from the disruption by Karpenter")
+	scaleDownProtection := fs.Bool("scaleDownProtection", false,
+		"Flag for annotating the nodes with allocated placeholders or pods that are not bound yet, "+
+			"for the cluster autoscaler to keep the nodes")
+	scaleDownAnnotation := fs.String("scaleDownAnnotation", DefaultScaleDownAnnotation,
+		"the annotation that keeps the cluster autoscaler from removing the nodes protected from the scale down")
+	unschedulableDelay := fs.Duration("unschedulableDelay", 0,
+		"the time a pod must fail to schedule for lack of resources before it is marked unschedulable, "+
+			"which triggers the autoscaler to scale up, 0 marks the pod at the first failure")
+	equivalentPlaceholders := fs.Bool("equivalentPlaceholders", false,
+		"Flag for creating the placeholders with the resources and the scheduling constraints of a member of "+
+			"their task group, for the scheduler simulation of the cluster autoscaler to provision nodes that fit the real pods")
+	scaleUpDisabledQueues := fs.String("scaleUpDisabledQueues", "",
+		"comma separated list of the queues, including their children, whose unschedulable pods do not trigger "+
+			"the autoscaler to scale up the cluster")
+	placeholderSecurityContext := fs.String("placeholderSecurityContext", "",
+		"a JSON object that overrides the restricted security context of the placeholder pods: the \"pod\" "+
+			"security context and the \"container\" security context replace the defaults of the pod and of its "+
+			"container, e.g. {\"pod\":{\"runAsUser\":2000,\"runAsNonRoot\":true}}")
 
-	flag.Parse()
+	var errs []error
+	if err := fs.Parse(args); err != nil {
+		errs = append(errs, err)
+	}
+	errs = append(errs, applyConfigSources(fs, lookupEnv)...)
+	loadErrors := make([]string, 0, len(errs))
+	for _, err := range errs {
+		loadErrors = append(loadErrors, err.Error())
+	}
 
-	// if log level is debug, enable klog and set its log level verbosity to 4 (represents debug level),
-	// For details refer to the Logging Conventions of klog at
-	// https://github.com/kubernetes/community/blob/master/contributors/devel/sig-instrumentation/logging.md
-	if zapcore.Level(*logLevel).Enabled(zapcore.DebugLevel) {
-		klog.InitFlags(nil)
-		// cannot really handle the error here ignore it
-		//nolint:errcheck
-		_ = flag.Set("v", "4")
+	conf := &SchedulerConf{
+		ClusterID:                  *clusterID,
+		ClusterVersion:             *clusterVersion,
+		PolicyGroup:                *policyGroup,
+		Interval:                   *schedulingInterval,
+		KubeConfig:                 *kubeConfig,
+		KubeContext:                *kubeContext,
+		LoggingLevel:               *logLevel,
+		LogEncoding:                *encode,
+		LogFile:                    *logFile,
+		LogSampleRate:              *logSampleRate,
+		VolumeBindTimeout:          *volumeBindTimeout,
+		EventChannelCapacity:       *eventChannelCapacity,
+		DispatchTimeout:            *dispatchTimeout,
+		AsyncDispatchLimit:         *asyncDispatchLimit,
+		DispatcherBackpressure:     *dispatcherBackpressure,
+		DispatcherWorkers:          *dispatcherWorkers,
+		DispatcherDrainTimeout:     *dispatcherDrainTimeout,
+		EventHistorySize:           *eventHistorySize,
+		SyncRecovery:               *syncRecovery,
+		RecoveryWorkers:            *recoveryWorkers,
+		RecoveryTimeout:            *recoveryTimeout,
+		AskBatchInterval:           *askBatchInterval,
+		AskBatchSize:               *askBatchSize,
+		BindWorkers:                *bindWorkers,
+		PlaceholderWorkers:         *placeholderWorkers,
+		NodeUpdateMaxStaleness:     *nodeUpdateMaxStaleness,
+		PodStatusWriteInterval:     *podStatusWriteInterval,
+		KubeQPS:                    *kubeQPS,
+		KubeBurst:                  *kubeBurst,
+		KubeBindQPS:                *kubeBindQPS,
+		KubeBindBurst:              *kubeBindBurst,
+		KubeTimeout:                *kubeTimeout,
+		KubeContentType:            *kubeContentType,
+		PodImpersonation:           *podImpersonation,
+		InformerResyncPeriods:      *informerResyncPeriods,
+		InformerWatchdogInterval:   *informerWatchdogInterval,
+		InformerFailureTimeout:     *informerFailureTimeout,
+		InformerStallTimeout:       *informerStallTimeout,
+		StripManagedFields:         *stripManagedFields,
+		MaxAnnotationSize:          *maxAnnotationSize,
+		OperatorPlugins:            *operatorPluginList,
+		EnableConfigHotRefresh:     *enableConfigHotRefresh,
+		DisableGangScheduling:      *disableGangScheduling,
+		EnableNamespaceAnnotations: *enableNamespaceAnnotations,
+		DryRun:                     *dryRun,
+		UserLabelKey:               *userLabelKey,
+		UserResolution:             *userResolution,
+		WebServicePort:             *webServicePort,
+		WebServiceTLS:              *webServiceTLS,
+		WebServiceAuth:             *webServiceAuth,
+		WebServiceAuthDebug:        *webServiceAuthDebug,
+		EnableProfiling:            *enableProfiling,
+		CoreServiceURL:             *coreServiceURL,
+		ShimConfigFile:             *shimConfigFile,
+		ConfigDelivery:             *configDelivery,
+		ConfigSecret:               *configSecret,
+		QueuesFragmentNamespaces:   *queuesFragmentNamespaces,
+		QueuesFragmentParents:      *queuesFragmentParents,
+		ProtectQueuesWithApps:      *protectQueuesWithApps,
+		RequireConfigSignature:     *requireConfigSignature,
+		EventSinks:                 *eventSinks,
+		EventDedupWindow:           *eventDedupWindow,
+		EventQPS:                   *eventQPS,
+		EventBurst:                 *eventBurst,
+		EventComponent:             *eventComponent,
+		EventRecorderSink:          *eventRecorderSink,
+		TracingEndpoint:            *tracingEndpoint,
+		TracingSampleRatio:         *tracingSampleRatio,
+		AuditLogPath:               *auditLogPath,
+		AuditLogMaxSize:            *auditLogMaxSize,
+		AuditLogMaxBackups:         *auditLogMaxBackups,
+		HealthQueueThreshold:       *healthQueueThreshold,
+		EnableMemoryAccounting:     *enableMemoryAccounting,
+		CacheMemoryLimitMB:         *cacheMemoryLimitMB,
+		PreemptionPDBPolicy:        *preemptionPDBPolicy,
+		PreemptionEvictTimeout:     *preemptionEvictTimeout,
+		PreemptionGracePeriod:      *preemptionGracePeriod,
+		PreemptionDryRun:           *preemptionDryRun,
+		PreemptionQueues:           *preemptionQueues,
+		PreemptionVictimPolicy:     *preemptionVictimPolicy,
+		PreemptionGangPolicy:       *preemptionGangPolicy,
+		ProvisioningClass:          *provisioningClass,
+		KarpenterSignals:           *karpenterSignals,
+		ScaleDownProtection:        *scaleDownProtection,
+		ScaleDownAnnotation:        *scaleDownAnnotation,
+		UnschedulableDelay:         *unschedulableDelay,
+		EquivalentPlaceholders:     *equivalentPlaceholders,
+		ScaleUpDisabledQueues:      *scaleUpDisabledQueues,
+		PlaceholderSecurityContext: *placeholderSecurityContext,
+		loadErrors:                 loadErrors,
 	}
+	return conf
+}
 
-	configuration = &SchedulerConf{
-		ClusterID:              *clusterID,
-		ClusterVersion:         *clusterVersion,
-		PolicyGroup:            *policyGroup,
-		Interval:               *schedulingInterval,
-		KubeConfig:             *kubeConfig,
-		LoggingLevel:           *logLevel,
-		LogEncoding:            *encode,
-		LogFile:                *logFile,
-		VolumeBindTimeout:      *volumeBindTimeout,
-		EventChannelCapacity:   *eventChannelCapacity,
-		DispatchTimeout:        *dispatchTimeout,
-		KubeQPS:                *kubeQPS,
-		KubeBurst:              *kubeBurst,
-		OperatorPlugins:        *operatorPluginList,
-		EnableConfigHotRefresh: *enableConfigHotRefresh,
-		DisableGangScheduling:  *disableGangScheduling,
-		UserLabelKey:           *userLabelKey,
+// apply the values from the shim configuration file and the environment variables
+// to all the flags that were not explicitly set on the command line.
+func applyConfigSources(fs *flag.FlagSet, lookupEnv func(string) (string, bool)) []error {
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	// the location of the file itself can only be set by a flag or an environment variable
+	var errs []error
+	if !explicit[shimConfigFileFlag] {
+		if value, ok := lookupEnv(envVars[shimConfigFileFlag]); ok {
+			if err := fs.Set(shimConfigFileFlag, value); err != nil {
+				errs = append(errs, fmt.Errorf("environment variable %s: %v", envVars[shimConfigFileFlag], err))
+			}
+			explicit[shimConfigFileFlag] = true
+		}
+	}
+	fileValues, err := readShimConfigFile(fs.Lookup(shimConfigFileFlag).Value.String(), explicit[shimConfigFileFlag])
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for name := range fileValues {
+		if fs.Lookup(name) == nil || name == shimConfigFileFlag {
+			errs = append(errs, fmt.Errorf("shim configuration file: unknown option %s", name))
+		}
+	}
+
+	fs.VisitAll(func(f *flag.Flag) {
+		if explicit[f.Name] {
+			return
+		}
+		if env, ok := envVars[f.Name]; ok {
+			if value, ok := lookupEnv(env); ok {
+				if err := fs.Set(f.Name, value); err != nil {
+					errs = append(errs, fmt.Errorf("environment variable %s: %v", env, err))
+				}
+				return
+			}
+		}
+		if value, ok := fileValues[f.Name]; ok && f.Name != shimConfigFileFlag {
+			if err := fs.Set(f.Name, value); err != nil {
+				errs = append(errs, fmt.Errorf("shim configuration file option %s: %v", f.Name, err))
+			}
+		}
+	})
+	return errs
+}
+
+// read the shim configuration file, this is a flat yaml map using the flag names as keys.
+// a missing file is only reported when the location was explicitly configured.
+func readShimConfigFile(path string, required bool) (map[string]string, error) {
+	values := make(map[string]string)
+	if path == "" {
+		return values, nil
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && !required {
+			return values, nil
+		}
+		return values, fmt.Errorf("failed to read shim configuration file %s: %v", path, err)
+	}
+	if err = yaml.Unmarshal(content, &values); err != nil {
+		return values, fmt.Errorf("failed to parse shim configuration file %s: %v", path, err)
 	}
+	return values, nil
 }
@@ -18,7 +18,12 @@
 package conf
 
 import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"gotest.tools/assert"
 
@@ -36,5 +41,253 @@ func TestDefaultValues(t *testing.T) {
 	assert.Equal(t, conf.DispatchTimeout, DefaultDispatchTimeout)
 	assert.Equal(t, conf.KubeQPS, DefaultKubeQPS)
 	assert.Equal(t, conf.KubeBurst, DefaultKubeBurst)
+	assert.Equal(t, conf.KubeTimeout, DefaultKubeTimeout)
+	assert.Equal(t, conf.KubeContentType, DefaultKubeContentType)
 	assert.Equal(t, conf.UserLabelKey, constants.DefaultUserLabel)
+	assert.DeepEqual(t, conf.GetUserResolution(), []string{UserSourceLabel})
+	assert.Equal(t, conf.WebServicePort, DefaultWebServicePort)
+	assert.Equal(t, conf.EventDedupWindow, DefaultEventDedupWindow)
+	assert.Equal(t, conf.EventQPS, DefaultEventQPS)
+	assert.Equal(t, conf.EventBurst, DefaultEventBurst)
+	assert.Equal(t, conf.SyncRecovery, true)
+}
+
+func newEnv(values map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := values[key]
+		return value, ok
+	}
+}
+
+func writeShimConfigFile(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "shim-conf")
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	path := filepath.Join(dir, "k8shim.yaml")
+	assert.NilError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoadConfigsPrecedence(t *testing.T) {
+	path := writeShimConfigFile(t, "clusterId: file-cluster\nclusterVersion: file-version\nkubeQPS: 50\nkubeBurst: 60\n")
+	env := newEnv(map[string]string{
+		"SHIM_CONFIG_FILE":  path,
+		"CLUSTER_VERSION":   "env-version",
+		"KUBE_CLIENT_QPS":   "70",
+		"KUBE_CLIENT_BURST": "80",
+	})
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	conf := loadConfigs(fs, []string{"-kubeBurst=90"}, env)
+	assert.NilError(t, conf.Validate())
+	// default, not set anywhere else
+	assert.Equal(t, conf.PolicyGroup, DefaultPolicyGroup)
+	// file overrides default
+	assert.Equal(t, conf.ClusterID, "file-cluster")
+	// env overrides file
+	assert.Equal(t, conf.ClusterVersion, "env-version")
+	assert.Equal(t, conf.KubeQPS, 70)
+	// flag overrides env
+	assert.Equal(t, conf.KubeBurst, 90)
+	assert.Equal(t, conf.ShimConfigFile, path)
+}
+
+func TestLoadConfigsMissingFile(t *testing.T) {
+	// the default file location is optional
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	conf := loadConfigs(fs, []string{"-shimConfigFile="}, newEnv(nil))
+	assert.NilError(t, conf.Validate())
+
+	// an explicitly configured file must exist
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	conf = loadConfigs(fs, []string{"-shimConfigFile=/non/existing/k8shim.yaml"}, newEnv(nil))
+	assert.ErrorContains(t, conf.Validate(), "failed to read shim configuration file")
+}
+
+func TestLoadConfigsErrors(t *testing.T) {
+	path := writeShimConfigFile(t, "unknownOption: true\ninterval: not-a-duration\n")
+	env := newEnv(map[string]string{
+		"LOG_ENCODING":              "xml",
+		"KUBE_CLIENT_QPS":           "many",
+		"CONFIG_DELIVERY":           "inline",
+		"KUBE_CLIENT_CONTENT_TYPE":  "application/xml",
+		"EVENT_SINKS":               "http://sink:8080/events,kafka://broker:9092",
+		"EVENT_RECORDER_SINK":       "etcd",
+		"TRACING_ENDPOINT":          "grpc://collector:4317",
+		"TRACING_SAMPLE_RATIO":      "2",
+		"AUDIT_LOG_MAX_SIZE":        "0",
+		"AUDIT_LOG_MAX_BACKUPS":     "-1",
+		"HEALTH_QUEUE_THRESHOLD":    "1.5",
+		"LOG_SAMPLE_RATE":           "0",
+		"CORE_SERVICE_URL":          "yunikorn-core:9080",
+		"ASK_BATCH_INTERVAL":        "-10ms",
+		"ASK_BATCH_SIZE":            "0",
+		"BIND_WORKERS":              "-1",
+		"PLACEHOLDER_WORKERS":       "-2",
+		"NODE_UPDATE_MAX_STALENESS": "-1s",
+		"POD_STATUS_WRITE_INTERVAL": "-2s",
+		"RECOVERY_WORKERS":          "0",
+		"RECOVERY_TIMEOUT":          "0s",
+		"CACHE_MEMORY_LIMIT_MB":     "-1",
+		"PREEMPTION_PDB_POLICY":     "drain",
+		"PREEMPTION_EVICT_TIMEOUT":  "0s",
+		"PREEMPTION_GRACE_PERIOD":   "-1s",
+		"PREEMPTION_QUEUES":         "root.a,batch",
+		"PREEMPTION_VICTIM_POLICY":  "youngest",
+		"PREEMPTION_GANG_POLICY":    "suspend",
+		"PROVISIONING_CLASS":        "best-effort",
+		"SCALE_DOWN_ANNOTATION":     "scale/down/disabled",
+		"UNSCHEDULABLE_DELAY":       "-1m",
+		"SCALE_UP_DISABLED_QUEUES":  "root.best-effort,spot",
+		"USER_RESOLUTION":           "annotation,token",
+		"WEB_SERVICE_AUTH":          "oidc",
+	})
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	conf := loadConfigs(fs, []string{"-shimConfigFile=" + path, "-kubeBurst=0", "-kubeBindQPS=10", "-kubeBindBurst=0"}, env)
+	err := conf.Validate()
+	assert.ErrorContains(t, err, "unknown option unknownOption")
+	assert.ErrorContains(t, err, "shim configuration file option interval")
+	assert.ErrorContains(t, err, "environment variable KUBE_CLIENT_QPS")
+	assert.ErrorContains(t, err, "logEncoding must be json or console")
+	assert.ErrorContains(t, err, "kubeBurst must be positive")
+	assert.ErrorContains(t, err, "kubeBindBurst must be positive")
+	assert.ErrorContains(t, err, "configDelivery must be file or direct, got inline")
+	assert.ErrorContains(t, err, "kubeContentType must be application/json or application/vnd.kubernetes.protobuf")
+	assert.ErrorContains(t, err, "eventSinks must be http, https or file URLs, got kafka://broker:9092")
+	assert.ErrorContains(t, err, "eventRecorderSink must be kubernetes, log or none, got etcd")
+	assert.ErrorContains(t, err, "tracingEndpoint must be a http or https URL, got grpc://collector:4317")
+	assert.ErrorContains(t, err, "tracingSampleRatio must be between 0 and 1, got 2")
+	assert.ErrorContains(t, err, "auditLogMaxSize must be positive, got 0")
+	assert.ErrorContains(t, err, "auditLogMaxBackups must not be negative, got -1")
+	assert.ErrorContains(t, err, "healthQueueThreshold must be above 0 and at most 1, got 1.5")
+	assert.ErrorContains(t, err, "logSampleRate must be at least 1, got 0")
+	assert.ErrorContains(t, err, "coreServiceURL must be a http or https URL, got yunikorn-core:9080")
+	assert.ErrorContains(t, err, "askBatchInterval must not be negative, got -10ms")
+	assert.ErrorContains(t, err, "askBatchSize must be positive, got 0")
+	assert.ErrorContains(t, err, "bindWorkers must not be negative, got -1")
+	assert.ErrorContains(t, err, "placeholderWorkers must not be negative, got -2")
+	assert.ErrorContains(t, err, "nodeUpdateMaxStaleness must not be negative, got -1s")
+	assert.ErrorContains(t, err, "podStatusWriteInterval must not be negative, got -2s")
+	assert.ErrorContains(t, err, "recoveryWorkers must be positive, got 0")
+	assert.ErrorContains(t, err, "recoveryTimeout must be positive, got 0s")
+	assert.ErrorContains(t, err, "cacheMemoryLimitMB must not be negative, got -1")
+	assert.ErrorContains(t, err, "preemptionPDBPolicy must be evict or skip, got drain")
+	assert.ErrorContains(t, err, "preemptionEvictTimeout must be positive, got 0s")
+	assert.ErrorContains(t, err, "preemptionGracePeriod must not be negative, got -1s")
+	assert.ErrorContains(t, err, "preemptionQueues must be fully qualified queue names, got batch")
+	assert.ErrorContains(t, err, "preemptionVictimPolicy must be default or prefer-placeholder, got youngest")
+	assert.ErrorContains(t, err, "preemptionGangPolicy must be none, restart or fail, got suspend")
+	assert.ErrorContains(t, err, "provisioningClass must be empty, check-capacity.autoscaling.x-k8s.io or "+
+		"atomic-scale-up.autoscaling.x-k8s.io, got best-effort")
+	assert.ErrorContains(t, err, "scaleDownAnnotation must be a qualified name, got scale/down/disabled")
+	assert.ErrorContains(t, err, "unschedulableDelay must not be negative, got -1m0s")
+	assert.ErrorContains(t, err, "scaleUpDisabledQueues must be fully qualified queue names, got spot")
+	assert.ErrorContains(t, err, "userResolution must be a list of annotation, label or serviceaccount, got token")
+	assert.ErrorContains(t, err, "webServiceAuth must be token or kubernetes, got oidc")
+}
+
+func TestGetPlaceholderSecurityContext(t *testing.T) {
+	conf := &SchedulerConf{}
+	override, err := conf.GetPlaceholderSecurityContext()
+	assert.NilError(t, err)
+	assert.Assert(t, override == nil)
+
+	conf.PlaceholderSecurityContext = `{"pod":{"runAsUser":2000,"fsGroup":2000},"container":{"readOnlyRootFilesystem":false}}`
+	override, err = conf.GetPlaceholderSecurityContext()
+	assert.NilError(t, err)
+	assert.Equal(t, *override.Pod.RunAsUser, int64(2000))
+	assert.Equal(t, *override.Pod.FSGroup, int64(2000))
+	assert.Equal(t, *override.Container.ReadOnlyRootFilesystem, false)
+
+	conf.PlaceholderSecurityContext = `{"pod":{"runAsUser":"nobody"}}`
+	_, err = conf.GetPlaceholderSecurityContext()
+	assert.ErrorContains(t, err, "placeholderSecurityContext: json: cannot unmarshal string")
+	conf.PlaceholderSecurityContext = `{"pods":{}}`
+	_, err = conf.GetPlaceholderSecurityContext()
+	assert.ErrorContains(t, err, `placeholderSecurityContext: json: unknown field "pods"`)
+	assert.ErrorContains(t, conf.Validate(), `placeholderSecurityContext: json: unknown field "pods"`)
+}
+
+func TestGetInformerResyncPeriods(t *testing.T) {
+	conf := &SchedulerConf{}
+	periods, err := conf.GetInformerResyncPeriods()
+	assert.NilError(t, err)
+	assert.Equal(t, len(periods), 0)
+
+	conf.InformerResyncPeriods = "pods=0s, nodes=10m"
+	periods, err = conf.GetInformerResyncPeriods()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, periods, map[string]time.Duration{"pods": 0, "nodes": 10 * time.Minute})
+
+	conf.InformerResyncPeriods = "secrets=1m"
+	_, err = conf.GetInformerResyncPeriods()
+	assert.ErrorContains(t, err, "invalid entry")
+	conf.InformerResyncPeriods = "pods=-1m"
+	_, err = conf.GetInformerResyncPeriods()
+	assert.ErrorContains(t, err, "invalid period for pods")
+}
+
+func TestGetPodImpersonation(t *testing.T) {
+	conf := &SchedulerConf{}
+	identities, err := conf.GetPodImpersonation()
+	assert.NilError(t, err)
+	assert.Equal(t, len(identities), 0)
+
+	conf.PodImpersonation = "tenant=system:serviceaccount:tenant:evictor, *=system:serviceaccount:{namespace}:yunikorn"
+	identities, err = conf.GetPodImpersonation()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, identities, map[string]string{
+		"tenant": "system:serviceaccount:tenant:evictor",
+		"*":      "system:serviceaccount:{namespace}:yunikorn",
+	})
+
+	conf.PodImpersonation = "tenant="
+	_, err = conf.GetPodImpersonation()
+	assert.ErrorContains(t, err, "invalid entry")
+	conf.PodImpersonation = "Tenant_1=evictor"
+	_, err = conf.GetPodImpersonation()
+	assert.ErrorContains(t, err, "invalid namespace")
+	conf.PodImpersonation = "tenant=a,tenant=b"
+	_, err = conf.GetPodImpersonation()
+	assert.ErrorContains(t, err, "duplicate namespace")
+}
+
+func TestQueuesFragmentScope(t *testing.T) {
+	conf := &SchedulerConf{}
+	assert.Assert(t, conf.IsQueuesFragmentNamespace("yunikorn", "yunikorn"))
+	assert.Assert(t, !conf.IsQueuesFragmentNamespace("tenant", "yunikorn"))
+	assert.Assert(t, conf.IsQueuesFragmentParent("root"))
+	conf.QueuesFragmentNamespaces = "team1, team2"
+	assert.Assert(t, conf.IsQueuesFragmentNamespace("team2", "yunikorn"))
+	assert.Assert(t, !conf.IsQueuesFragmentNamespace("tenant", "yunikorn"))
+	conf.QueuesFragmentParents = "root.tenants"
+	assert.Assert(t, conf.IsQueuesFragmentParent("root.tenants"))
+	assert.Assert(t, conf.IsQueuesFragmentParent("root.Tenants.team1"))
+	assert.Assert(t, !conf.IsQueuesFragmentParent("root"))
+	assert.Assert(t, !conf.IsQueuesFragmentParent("root.tenants2"))
+}
+
+func TestIsPreemptionEnabled(t *testing.T) {
+	conf := &SchedulerConf{}
+	assert.Assert(t, conf.IsPreemptionEnabled("root.a"))
+	conf.PreemptionQueues = "root.batch, root.dev.team1"
+	assert.Assert(t, conf.IsPreemptionEnabled("root.batch"))
+	assert.Assert(t, conf.IsPreemptionEnabled("root.batch.nightly"))
+	assert.Assert(t, conf.IsPreemptionEnabled("root.dev.team1"))
+	assert.Assert(t, !conf.IsPreemptionEnabled("root.batches"))
+	assert.Assert(t, !conf.IsPreemptionEnabled("root.dev"))
+	assert.Assert(t, !conf.IsPreemptionEnabled("root"))
+}
+
+func TestIsScaleUpEnabled(t *testing.T) {
+	conf := &SchedulerConf{}
+	assert.Assert(t, conf.IsScaleUpEnabled("root.a"))
+	conf.ScaleUpDisabledQueues = "root.best-effort, root.dev.team1"
+	assert.Assert(t, !conf.IsScaleUpEnabled("root.best-effort"))
+	assert.Assert(t, !conf.IsScaleUpEnabled("root.best-effort.nightly"))
+	assert.Assert(t, !conf.IsScaleUpEnabled("root.dev.team1"))
+	assert.Assert(t, conf.IsScaleUpEnabled("root.best-efforts"))
+	assert.Assert(t, conf.IsScaleUpEnabled("root.dev"))
+	assert.Assert(t, conf.IsScaleUpEnabled("root"))
 }
@@ -0,0 +1,100 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package conf
+
+import (
+	"bytes"
+	"sort"
+)
+
+// configuration keys that hold sensitive values, these are never read from the ConfigMap,
+// the command line or the environment, only from the Secret named by the configSecret option.
+const (
+	SecretEventSinkToken = "eventSinkToken"
+	SecretTLSCert        = "tlsCert"
+	SecretTLSKey         = "tlsKey"
+	SecretTLSClientCA    = "tlsClientCA"
+	SecretAdminToken     = "adminToken"
+	SecretConfigSignKey  = "configSigningKey"
+)
+
+var secretKeys = map[string]bool{
+	SecretEventSinkToken: true,
+	SecretTLSCert:        true,
+	SecretTLSKey:         true,
+	SecretTLSClientCA:    true,
+	SecretAdminToken:     true,
+	SecretConfigSignKey:  true,
+}
+
+// GetSecretValue returns the value of a sensitive configuration key,
+// or false if the key is not set in the config Secret.
+func (conf *SchedulerConf) GetSecretValue(key string) ([]byte, bool) {
+	conf.RLock()
+	defer conf.RUnlock()
+	value, ok := conf.secrets[key]
+	return value, ok
+}
+
+// AddSecretListener registers a function that is called with the changed keys
+// each time the values in the config Secret are updated.
+func (conf *SchedulerConf) AddSecretListener(listener func(changed []string)) {
+	conf.Lock()
+	defer conf.Unlock()
+	conf.secretListeners = append(conf.secretListeners, listener)
+}
+
+// UpdateSecretValues replaces the sensitive values with the data of the config Secret,
+// a nil data removes all the values. Keys that are not sensitive configuration keys are ignored.
+// The listeners are notified when at least one value changed. Returns the changed and the ignored keys.
+func (conf *SchedulerConf) UpdateSecretValues(data map[string][]byte) (changed []string, ignored []string) {
+	secrets := make(map[string][]byte)
+	for key, value := range data {
+		if !secretKeys[key] {
+			ignored = append(ignored, key)
+			continue
+		}
+		secrets[key] = value
+	}
+
+	conf.Lock()
+	for key, value := range secrets {
+		if old, ok := conf.secrets[key]; !ok || !bytes.Equal(old, value) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range conf.secrets {
+		if _, ok := secrets[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	conf.secrets = secrets
+	listeners := make([]func([]string), len(conf.secretListeners))
+	copy(listeners, conf.secretListeners)
+	conf.Unlock()
+
+	sort.Strings(changed)
+	sort.Strings(ignored)
+	if len(changed) > 0 {
+		for _, listener := range listeners {
+			listener(changed)
+		}
+	}
+	return changed, ignored
+}
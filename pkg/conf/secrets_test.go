@@ -0,0 +1,66 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package conf
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestUpdateSecretValues(t *testing.T) {
+	conf := &SchedulerConf{}
+	var notified [][]string
+	conf.AddSecretListener(func(changed []string) {
+		notified = append(notified, changed)
+	})
+
+	changed, ignored := conf.UpdateSecretValues(map[string][]byte{
+		SecretTLSKey:  []byte("key"),
+		SecretTLSCert: []byte("cert"),
+		"password":    []byte("secret"),
+	})
+	assert.DeepEqual(t, changed, []string{SecretTLSCert, SecretTLSKey})
+	assert.DeepEqual(t, ignored, []string{"password"})
+	value, ok := conf.GetSecretValue(SecretTLSKey)
+	assert.Assert(t, ok)
+	assert.Equal(t, string(value), "key")
+	_, ok = conf.GetSecretValue("password")
+	assert.Assert(t, !ok)
+
+	// same values, no listener call
+	conf.UpdateSecretValues(map[string][]byte{
+		SecretTLSKey:  []byte("key"),
+		SecretTLSCert: []byte("cert"),
+	})
+	assert.Equal(t, len(notified), 1)
+
+	// changed and removed values
+	changed, _ = conf.UpdateSecretValues(map[string][]byte{
+		SecretTLSKey: []byte("rotated"),
+	})
+	assert.DeepEqual(t, changed, []string{SecretTLSCert, SecretTLSKey})
+	assert.Equal(t, len(notified), 2)
+
+	// deleted Secret
+	changed, _ = conf.UpdateSecretValues(nil)
+	assert.DeepEqual(t, changed, []string{SecretTLSKey})
+	_, ok = conf.GetSecretValue(SecretTLSKey)
+	assert.Assert(t, !ok)
+}
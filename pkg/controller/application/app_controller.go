@@ -171,6 +171,9 @@ func (appMgr *AppManager) updateAppCRDStatus(appCRD *appv1.Application, status a
 		Message:    "app CRD status change",
 		LastUpdate: v1.NewTime(time.Now()),
 	}
+	if client.SkipMutation("updateStatus", "applications", appCRD.Namespace, appCRD.Name) {
+		return
+	}
 	_, err := appMgr.apiProvider.GetAPIs().AppClient.ApacheV1alpha1().Applications(appCRD.Namespace).UpdateStatus(context.Background(), appCopy, v1.UpdateOptions{})
 	if err != nil {
 		log.Logger().Error("Failed to update application CRD",
@@ -0,0 +1,125 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dispatcher
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+)
+
+// maximum number of entries kept in the dead-letter store, the oldest entry is removed first
+const deadLetterCapacity = 1000
+
+// DeadLetter is an event the handler failed to handle, with the context of the failure.
+// Repeated failures of the same event share an entry.
+type DeadLetter struct {
+	EventType     string    `json:"eventType"`
+	Event         string    `json:"event"`
+	ApplicationID string    `json:"applicationID,omitempty"`
+	TaskID        string    `json:"taskID,omitempty"`
+	State         string    `json:"state,omitempty"`
+	Error         string    `json:"error"`
+	Failures      int       `json:"failures"`
+	FirstFailure  time.Time `json:"firstFailure"`
+	LastFailure   time.Time `json:"lastFailure"`
+}
+
+func (d *DeadLetter) key() string {
+	return d.EventType + "/" + d.Event + "/" + d.ApplicationID + "/" + d.TaskID + "/" + d.State + "/" + d.Error
+}
+
+type deadLetterStore struct {
+	entries map[string]*DeadLetter
+	order   []string
+	sync.RWMutex
+}
+
+var deadLetters = newDeadLetterStore()
+
+func newDeadLetterStore() *deadLetterStore {
+	return &deadLetterStore{
+		entries: make(map[string]*DeadLetter),
+	}
+}
+
+// adds the failure to the store, returns true for the first failure of the event
+func (s *deadLetterStore) add(letter *DeadLetter) bool {
+	s.Lock()
+	defer s.Unlock()
+	key := letter.key()
+	now := time.Now()
+	if existing, ok := s.entries[key]; ok {
+		existing.Failures++
+		existing.LastFailure = now
+		return false
+	}
+	if len(s.order) >= deadLetterCapacity {
+		delete(s.entries, s.order[0])
+		s.order = s.order[1:]
+	}
+	letter.Failures = 1
+	letter.FirstFailure = now
+	letter.LastFailure = now
+	s.entries[key] = letter
+	s.order = append(s.order, key)
+	return true
+}
+
+func (s *deadLetterStore) list() []*DeadLetter {
+	s.RLock()
+	defer s.RUnlock()
+	letters := make([]*DeadLetter, 0, len(s.order))
+	for _, key := range s.order {
+		letter := *s.entries[key]
+		letters = append(letters, &letter)
+	}
+	return letters
+}
+
+// AddDeadLetter records an event the handler failed to handle, the failure is logged and counted.
+// Only the first failure of an event is logged as a warning, the repeated failures are counted.
+func AddDeadLetter(letter *DeadLetter) {
+	metrics.GetDispatcherMetrics().IncDeadLetters(letter.EventType)
+	if letter.ApplicationID != "" {
+		markFailed(letter.ApplicationID)
+	}
+	fields := []zap.Field{
+		zap.String("eventType", letter.EventType),
+		zap.String("event", letter.Event),
+		zap.String("applicationID", letter.ApplicationID),
+		zap.String("taskID", letter.TaskID),
+		zap.String("state", letter.State),
+		zap.String("error", letter.Error),
+	}
+	if deadLetters.add(letter) {
+		log.Log(log.Dispatcher).Warn("event could not be handled", fields...)
+	} else {
+		log.Log(log.Dispatcher).Debug("event could not be handled again", fields...)
+	}
+}
+
+// GetDeadLetters returns the events that could not be handled, oldest first
+func GetDeadLetters() []*DeadLetter {
+	return deadLetters.list()
+}
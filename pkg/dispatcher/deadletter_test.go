@@ -0,0 +1,50 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dispatcher
+
+import (
+	"fmt"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestDeadLetterStore(t *testing.T) {
+	store := newDeadLetterStore()
+	assert.Assert(t, store.add(&DeadLetter{EventType: "task", Event: "Bind", ApplicationID: "app-1", TaskID: "task-1", Error: "failed"}))
+	// repeated failure of the same event
+	assert.Assert(t, !store.add(&DeadLetter{EventType: "task", Event: "Bind", ApplicationID: "app-1", TaskID: "task-1", Error: "failed"}))
+	assert.Assert(t, store.add(&DeadLetter{EventType: "app", Event: "RunApplication", ApplicationID: "app-1", Error: "failed"}))
+
+	letters := store.list()
+	assert.Equal(t, len(letters), 2)
+	assert.Equal(t, letters[0].TaskID, "task-1")
+	assert.Equal(t, letters[0].Failures, 2)
+	assert.Assert(t, !letters[0].LastFailure.Before(letters[0].FirstFailure))
+	assert.Equal(t, letters[1].EventType, "app")
+	assert.Equal(t, letters[1].Failures, 1)
+
+	// the oldest entries are removed when the store is full
+	for i := 0; i < deadLetterCapacity; i++ {
+		store.add(&DeadLetter{EventType: "app", Event: "RunApplication", ApplicationID: fmt.Sprintf("app-%d", i+2)})
+	}
+	letters = store.list()
+	assert.Equal(t, len(letters), deadLetterCapacity)
+	assert.Equal(t, letters[0].ApplicationID, "app-2")
+}
@@ -20,6 +20,7 @@ package dispatcher
 
 import (
 	"fmt"
+	"hash/fnv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -29,11 +30,15 @@ import (
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
 )
 
 var dispatcher *Dispatcher
 var once sync.Once
 
+// capacity of the queue of a worker, the dispatcher waits when the queue is full
+const workerQueueSize = 1024
+
 type EventType int8
 
 const (
@@ -48,41 +53,71 @@ var (
 	AsyncDispatchLimit         int32
 	AsyncDispatchCheckInterval = 3 * time.Second
 	DispatchTimeout            time.Duration
+	Workers                    int
+	Backpressure               string
 	asyncDispatchCount         int32 = 0
 )
 
+// the lanes of the dispatcher, the events in the low priority lane are
+// only handled when there are no events in the high priority lane.
+const (
+	laneHigh = "high"
+	laneLow  = "low"
+)
+
 // central dispatcher that dispatches scheduling events.
 type Dispatcher struct {
-	eventChan chan events.SchedulingEvent
-	stopChan  chan struct{}
-	handlers  map[EventType]func(interface{})
-	running   atomic.Value
-	lock      sync.RWMutex
+	eventChan       chan events.SchedulingEvent
+	lowPriorityChan chan events.SchedulingEvent
+	stopChan        chan struct{}
+	handlers        map[EventType]func(interface{})
+	workers         []chan events.SchedulingEvent
+	running         atomic.Value
+	lock            sync.RWMutex
+	// closed by the event loop when it exits, replaced by each start
+	stopped chan struct{}
 }
 
 func initDispatcher() {
 	eventChannelCapacity := conf.GetSchedulerConf().EventChannelCapacity
 	if dispatcher == nil {
 		dispatcher = &Dispatcher{
-			eventChan: make(chan events.SchedulingEvent, eventChannelCapacity),
-			handlers:  make(map[EventType]func(interface{})),
-			stopChan:  make(chan struct{}),
-			running:   atomic.Value{},
-			lock:      sync.RWMutex{},
+			eventChan:       make(chan events.SchedulingEvent, eventChannelCapacity),
+			lowPriorityChan: make(chan events.SchedulingEvent, getLowPriorityCapacity(eventChannelCapacity)),
+			handlers:        make(map[EventType]func(interface{})),
+			stopChan:        make(chan struct{}),
+			running:         atomic.Value{},
+			lock:            sync.RWMutex{},
 		}
 		dispatcher.setRunning(false)
 	}
 	DispatchTimeout = conf.GetSchedulerConf().DispatchTimeout
-	AsyncDispatchLimit = int32(eventChannelCapacity / 10)
-	if AsyncDispatchLimit < 10000 {
-		AsyncDispatchLimit = 10000
+	AsyncDispatchLimit = int32(conf.GetSchedulerConf().AsyncDispatchLimit)
+	if AsyncDispatchLimit == 0 {
+		AsyncDispatchLimit = int32(eventChannelCapacity / 10)
+		if AsyncDispatchLimit < 10000 {
+			AsyncDispatchLimit = 10000
+		}
 	}
-	log.Logger().Info("Init dispatcher",
+	Backpressure = conf.GetSchedulerConf().DispatcherBackpressure
+	Workers = conf.GetSchedulerConf().DispatcherWorkers
+	setHistorySize(conf.GetSchedulerConf().EventHistorySize)
+	log.Log(log.Dispatcher).Info("Init dispatcher",
 		zap.Int("EventChannelCapacity", eventChannelCapacity),
 		zap.Int32("AsyncDispatchLimit", AsyncDispatchLimit),
+		zap.String("Backpressure", Backpressure),
 		zap.Float64("DispatchTimeoutInSeconds", DispatchTimeout.Seconds()))
 }
 
+// the low priority events are few compared to the others, their lane is smaller
+func getLowPriorityCapacity(eventChannelCapacity int) int {
+	capacity := eventChannelCapacity / 10
+	if capacity < 1024 {
+		capacity = 1024
+	}
+	return capacity
+}
+
 func RegisterEventHandler(eventType EventType, handlerFn func(interface{})) {
 	eventDispatcher := getDispatcher()
 	eventDispatcher.lock.Lock()
@@ -108,16 +143,34 @@ func getDispatcher() *Dispatcher {
 // dispatches scheduler events to actual app/task handler,
 // each app/task has its own state machine and maintain their own states.
 // currently all events share same channel, so they are dispatched
-// one by one in order.
+// one by one in order. With multiple workers only the events of the same
+// application, or of the same node, are handled in order.
 func Dispatch(event events.SchedulingEvent) {
 	// currently if dispatch fails, we simply log the error
 	// we may revisit this later, e.g add retry here
 	if err := getDispatcher().dispatch(event); err != nil {
-		log.Logger().Warn("failed to dispatch SchedulingEvent",
+		log.Log(log.Dispatcher).Warn("failed to dispatch SchedulingEvent",
 			zap.Error(err))
 	}
 }
 
+func (p *Dispatcher) setWorkers(workers []chan events.SchedulingEvent) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.workers = workers
+}
+
+// returns the number of events waiting in the event channel and in the worker queues
+func (p *Dispatcher) pending() int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	count := len(p.eventChan) + len(p.lowPriorityChan)
+	for _, worker := range p.workers {
+		count += len(worker)
+	}
+	return count
+}
+
 func (p *Dispatcher) isRunning() bool {
 	return p.running.Load().(bool)
 }
@@ -126,83 +179,198 @@ func (p *Dispatcher) setRunning(flag bool) {
 	p.running.Store(flag)
 }
 
+// returns the channel the event loop that is started closes when it exits
+func (p *Dispatcher) newStopped() chan struct{} {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.stopped = make(chan struct{})
+	return p.stopped
+}
+
+// waits until the event loop exited, returns false when the timeout is reached first
+func (p *Dispatcher) waitStopped(timeout time.Duration) bool {
+	p.lock.RLock()
+	stopped := p.stopped
+	p.lock.RUnlock()
+	if stopped == nil {
+		return true
+	}
+	select {
+	case <-stopped:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
 func (p *Dispatcher) dispatch(event events.SchedulingEvent) error {
 	if !p.isRunning() {
 		return fmt.Errorf("dispatcher is not running")
 	}
+	history.add(event)
+	if recovery.hold(event) {
+		return nil
+	}
+	return p.enqueue(event)
+}
+
+// puts the event in its lane, applies the backpressure when the lane is full
+func (p *Dispatcher) enqueue(event events.SchedulingEvent) error {
+	queue, lane := p.eventChan, laneHigh
+	if isLowPriority(event) {
+		queue, lane = p.lowPriorityChan, laneLow
+	}
 	select {
-	case p.eventChan <- event:
+	case queue <- event:
+		metrics.GetDispatcherMetrics().SetQueueLength(lane, len(queue))
 		return nil
 	default:
-		p.asyncDispatch(event)
+	}
+	// the event channel is full
+	switch Backpressure {
+	case conf.BackpressureBlock:
+		return p.blockingDispatch(queue, event)
+	case conf.BackpressureShed:
+		if lane == laneLow {
+			eventType := getEventTypeName(event)
+			metrics.GetDispatcherMetrics().IncShedEvents(eventType)
+			log.Log(log.Dispatcher).Debug("event channel is full, dropping low priority event",
+				zap.String("eventType", eventType))
+			return nil
+		}
+		p.asyncDispatch(queue, event)
+	default:
+		p.asyncDispatch(queue, event)
+	}
+	return nil
+}
+
+// low priority events only keep external state in sync, the next event of the same kind replaces them.
+// These are dispatched in the low priority lane: the allocation and binding of pods is never delayed
+// by a backlog of low priority events.
+func isLowPriority(event events.SchedulingEvent) bool {
+	_, ok := event.(events.ApplicationStatusEvent)
+	return ok
+}
+
+func getEventTypeName(event events.SchedulingEvent) string {
+	switch event.(type) {
+	case events.ApplicationStatusEvent:
+		return "appStatus"
+	case events.ApplicationEvent:
+		return "app"
+	case events.TaskEvent:
+		return "task"
+	case events.SchedulerEvent:
+		return "scheduler"
+	case events.SchedulerNodeEvent:
+		return "node"
+	default:
+		return "unknown"
+	}
+}
+
+// blocks the producer until the event is queued or the dispatch times out
+func (p *Dispatcher) blockingDispatch(queue chan events.SchedulingEvent, event events.SchedulingEvent) error {
+	beginTime := time.Now()
+	defer func() {
+		metrics.GetDispatcherMetrics().ObserveBlockedTime(conf.BackpressureBlock, time.Since(beginTime))
+	}()
+	select {
+	case queue <- event:
 		return nil
+	case <-time.After(DispatchTimeout):
+		metrics.GetDispatcherMetrics().IncTimeouts()
+		return fmt.Errorf("dispatch timeout after %v, event channel is full", DispatchTimeout)
 	}
 }
 
 // async-dispatch try to enqueue the event in every 3 seconds util timeout,
 // it's only called when event channel is full.
-func (p *Dispatcher) asyncDispatch(event events.SchedulingEvent) {
+func (p *Dispatcher) asyncDispatch(queue chan events.SchedulingEvent, event events.SchedulingEvent) {
 	count := atomic.AddInt32(&asyncDispatchCount, 1)
-	log.Logger().Warn("event channel is full, transition to async-dispatch mode",
+	log.Log(log.Dispatcher).Warn("event channel is full, transition to async-dispatch mode",
 		zap.Int32("asyncDispatchCount", count))
 	if count > AsyncDispatchLimit {
 		panic(fmt.Errorf("dispatcher exceeds async-dispatch limit"))
 	}
+	metrics.GetDispatcherMetrics().IncAsyncDispatches()
 	go func(beginTime time.Time, stop chan struct{}) {
 		defer atomic.AddInt32(&asyncDispatchCount, -1)
+		defer metrics.GetDispatcherMetrics().DecAsyncDispatches()
 		for p.isRunning() {
 			select {
 			case <-stop:
 				return
-			case p.eventChan <- event:
+			case queue <- event:
+				metrics.GetDispatcherMetrics().ObserveBlockedTime(conf.BackpressureAsync, time.Since(beginTime))
 				return
 			case <-time.After(AsyncDispatchCheckInterval):
 				elapseTime := time.Since(beginTime)
 				if elapseTime >= DispatchTimeout {
-					log.Logger().Error("dispatch timeout",
+					metrics.GetDispatcherMetrics().IncTimeouts()
+					log.Log(log.Dispatcher).Error("dispatch timeout",
 						zap.Float64("elapseSeconds", elapseTime.Seconds()))
 					return
 				}
-				log.Logger().Warn("event channel is full, keep waiting...",
+				log.Log(log.Dispatcher).Warn("event channel is full, keep waiting...",
 					zap.Float64("elapseSeconds", elapseTime.Seconds()))
 			}
 		}
+		logUnhandled(event)
 	}(time.Now(), p.stopChan)
 }
 
 func (p *Dispatcher) drain() {
-	for len(p.eventChan) > 0 {
-		log.Logger().Info("wait dispatcher to drain",
-			zap.Int("remaining events", len(p.eventChan)))
+	for p.pending() > 0 {
+		log.Log(log.Dispatcher).Info("wait dispatcher to drain",
+			zap.Int("remaining events", p.pending()))
 		time.Sleep(1 * time.Second)
 	}
-	log.Logger().Info("dispatcher is draining out")
+	log.Log(log.Dispatcher).Info("dispatcher is draining out")
 }
 
 func Start() {
-	log.Logger().Info("starting the dispatcher")
+	log.Log(log.Dispatcher).Info("starting the dispatcher",
+		zap.Int("workers", Workers))
+	workers := startWorkers(Workers)
+	getDispatcher().setWorkers(workers)
+	stopped := getDispatcher().newStopped()
 	go func() {
+		handle := func(event events.SchedulingEvent) {
+			if workers == nil {
+				handleEvent(event)
+			} else {
+				index := getWorkerIndex(event, len(workers))
+				workers[index] <- event
+				metrics.GetDispatcherMetrics().SetWorkerQueueLength(index, len(workers[index]))
+			}
+		}
 		for {
+			// the high priority events are always handled first
 			select {
 			case event := <-getDispatcher().eventChan:
-				switch v := event.(type) {
-				case events.ApplicationStatusEvent:
-					getEventHandler(EventTypeAppStatus)(v)
-				case events.ApplicationEvent:
-					getEventHandler(EventTypeApp)(v)
-				case events.TaskEvent:
-					getEventHandler(EventTypeTask)(v)
-				case events.SchedulerEvent:
-					getEventHandler(EventTypeScheduler)(v)
-				case events.SchedulerNodeEvent:
-					getEventHandler(EventTypeNode)(v)
-				default:
-					log.Logger().Fatal("unsupported event",
-						zap.Any("event", v))
-				}
+				metrics.GetDispatcherMetrics().SetQueueLength(laneHigh, len(getDispatcher().eventChan))
+				handle(event)
+				continue
+			default:
+			}
+			select {
+			case event := <-getDispatcher().eventChan:
+				metrics.GetDispatcherMetrics().SetQueueLength(laneHigh, len(getDispatcher().eventChan))
+				handle(event)
+			case event := <-getDispatcher().lowPriorityChan:
+				metrics.GetDispatcherMetrics().SetQueueLength(laneLow, len(getDispatcher().lowPriorityChan))
+				handle(event)
 			case <-getDispatcher().stopChan:
-				log.Logger().Info("shutting down event channel")
-				getDispatcher().setRunning(false)
+				log.Log(log.Dispatcher).Info("shutting down event channel")
+				// the workers handle the events already passed to them before they stop
+				for _, worker := range workers {
+					close(worker)
+				}
+				// the running flag is cleared by the caller of the stop, a loop that exits after
+				// the dispatcher was started again must not clear the flag of the new loop
+				close(stopped)
 				return
 			}
 		}
@@ -210,22 +378,90 @@ func Start() {
 	getDispatcher().setRunning(true)
 }
 
+func handleEvent(event events.SchedulingEvent) {
+	atomic.AddInt32(&handlingCount, 1)
+	defer atomic.AddInt32(&handlingCount, -1)
+	h := startHandling(event)
+	defer func() {
+		if r := recover(); r != nil {
+			h.finish(true)
+			recoverPanic(event, r)
+			return
+		}
+		h.finish(false)
+	}()
+	switch v := event.(type) {
+	case events.ApplicationStatusEvent:
+		getEventHandler(EventTypeAppStatus)(v)
+	case events.ApplicationEvent:
+		getEventHandler(EventTypeApp)(v)
+	case events.TaskEvent:
+		getEventHandler(EventTypeTask)(v)
+	case events.SchedulerEvent:
+		getEventHandler(EventTypeScheduler)(v)
+	case events.SchedulerNodeEvent:
+		getEventHandler(EventTypeNode)(v)
+	default:
+		log.Log(log.Dispatcher).Fatal("unsupported event",
+			zap.Any("event", v))
+	}
+}
+
+// starts the workers that handle the events in parallel, nil is returned when the
+// events are handled by the dispatcher itself.
+func startWorkers(count int) []chan events.SchedulingEvent {
+	if count <= 1 {
+		return nil
+	}
+	workers := make([]chan events.SchedulingEvent, count)
+	for i := range workers {
+		workers[i] = make(chan events.SchedulingEvent, workerQueueSize)
+		go func(index int, queue chan events.SchedulingEvent) {
+			for event := range queue {
+				metrics.GetDispatcherMetrics().SetWorkerQueueLength(index, len(queue))
+				handleEvent(event)
+			}
+		}(i, workers[i])
+	}
+	return workers
+}
+
+// returns the worker that handles the event. The events of an application and its tasks always go to the
+// same worker, as do the events of a node: these are handled in the order they were dispatched.
+// The events without an application or node go to the first worker.
+func getWorkerIndex(event events.SchedulingEvent, count int) int {
+	var key string
+	switch v := event.(type) {
+	case interface{ GetApplicationID() string }:
+		key = v.GetApplicationID()
+	case events.SchedulerNodeEvent:
+		key = v.GetNodeID()
+	default:
+		return 0
+	}
+	hash := fnv.New32a()
+	//nolint:errcheck
+	_, _ = hash.Write([]byte(key))
+	return int(hash.Sum32() % uint32(count))
+}
+
 // stop the dispatcher and wait at most 5 seconds gracefully
 func Stop() {
-	log.Logger().Info("stopping the dispatcher")
+	log.Log(log.Dispatcher).Info("stopping the dispatcher")
+	if !getDispatcher().isRunning() {
+		log.Log(log.Dispatcher).Info("dispatcher is already stopped")
+		return
+	}
+	// the event loop of a dispatcher that was just started may not be waiting for the stop yet
 	select {
 	case getDispatcher().stopChan <- struct{}{}:
-		maxTimeout := 5
-		for getDispatcher().isRunning() && maxTimeout > 0 {
-			log.Logger().Info("waiting for dispatcher to be stopped",
-				zap.Int("remainingSeconds", maxTimeout))
-			time.Sleep(1 * time.Second)
-			maxTimeout--
+		getDispatcher().setRunning(false)
+		if getDispatcher().waitStopped(stopTimeout) {
+			log.Log(log.Dispatcher).Info("dispatcher stopped")
+		} else {
+			log.Log(log.Dispatcher).Warn("dispatcher did not stop, an event handler is blocked")
 		}
-		if !getDispatcher().isRunning() {
-			log.Logger().Info("dispatcher stopped")
-		}
-	default:
-		log.Logger().Info("dispatcher is already stopped")
+	case <-time.After(stopTimeout):
+		log.Log(log.Dispatcher).Warn("dispatcher did not stop, an event handler is blocked")
 	}
 }
@@ -31,6 +31,7 @@ import (
 
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
 )
 
 // app event for testing
@@ -218,11 +219,14 @@ func TestDispatchTimeout(t *testing.T) {
 	Start()
 
 	// dispatch 3 events, the third event will be dispatched asynchronously
+	flags := make([]chan bool, 0)
 	for i := 0; i < 3; i++ {
+		flag := make(chan bool)
+		flags = append(flags, flag)
 		Dispatch(TestAppEvent{
 			appID:     fmt.Sprintf("test-%d", i),
 			eventType: events.RunApplication,
-			flag:      make(chan bool),
+			flag:      flag,
 		})
 	}
 
@@ -249,6 +253,12 @@ func TestDispatchTimeout(t *testing.T) {
 	runtime.Stack(buf, true)
 	assert.Assert(t, !strings.Contains(string(buf), "asyncDispatch"))
 
+	// release the handler, the dispatcher is stopped with nothing left so that the later tests do not share its state
+	for _, flag := range flags {
+		close(flag)
+	}
+	assert.Assert(t, dispatcher.waitDrained(time.Second))
+
 	// stop the dispatcher
 	Stop()
 }
@@ -256,28 +266,35 @@ func TestDispatchTimeout(t *testing.T) {
 // Test exceeding the async-dispatch limit, should panic immediately.
 func TestExceedAsyncDispatchLimit(t *testing.T) {
 	// reset event channel with small capacity for testing
+	backupCapacity := cap(dispatcher.eventChan)
 	dispatcher.eventChan = make(chan events.SchedulingEvent, 1)
 	AsyncDispatchLimit = 1
 	// pretend to be an time-consuming event-handler
-	handledChan := make(chan bool)
 	RegisterEventHandler(EventTypeApp, func(obj interface{}) {
 		if _, ok := obj.(events.ApplicationEvent); ok {
-			time.Sleep(2 * time.Second)
-			handledChan <- true
+			time.Sleep(100 * time.Millisecond)
 		}
 	})
 	// Handle errors in defer func with recover.
 	defer func() {
-		// stop the dispatcher
-		Stop()
-		// recovery variables
-		AsyncDispatchLimit = 10000
 		// check error
 		if err := recover(); err != nil {
 			assert.Assert(t, strings.Contains(err.(error).Error(), "dispatcher exceeds async-dispatch limit"))
 		} else {
 			t.Error("Panic should be caught here")
 		}
+		// the events dispatched before the limit was exceeded are handled, the rejected event is still
+		// counted: the dispatcher is stopped with nothing left so that the later tests do not share its state
+		err := utils.WaitForCondition(func() bool {
+			return atomic.LoadInt32(&asyncDispatchCount) == 1 && dispatcher.pending() == 0 &&
+				atomic.LoadInt32(&handlingCount) == 0
+		}, 10*time.Millisecond, 5*time.Second)
+		atomic.StoreInt32(&asyncDispatchCount, 0)
+		Stop()
+		// recovery variables
+		AsyncDispatchLimit = 10000
+		dispatcher.eventChan = make(chan events.SchedulingEvent, backupCapacity)
+		assert.NilError(t, err)
 	}()
 	// start the dispatcher
 	Start()
@@ -289,3 +306,232 @@ func TestExceedAsyncDispatchLimit(t *testing.T) {
 		})
 	}
 }
+
+// app status event for testing
+type TestAppStatusEvent struct {
+	state string
+}
+
+func (t TestAppStatusEvent) GetState() string {
+	return t.state
+}
+
+func (t TestAppStatusEvent) GetArgs() []interface{} {
+	return nil
+}
+
+// fills the event channel of capacity 1: the first event is stuck in the handler, the second one is queued.
+// The events are handled by the dispatcher itself, it only takes the queued event once the handler is released.
+// The returned function releases the handler.
+func fillEventChannel(t *testing.T) func() {
+	flag := make(chan bool)
+	handling := make(chan struct{})
+	RegisterEventHandler(EventTypeApp, func(obj interface{}) {
+		if appEvent, ok := obj.(TestAppEvent); ok {
+			if appEvent.appID == "test-0" {
+				close(handling)
+			}
+			<-appEvent.flag
+		}
+	})
+	Dispatch(TestAppEvent{appID: "test-0", eventType: events.RunApplication, flag: flag})
+	select {
+	case <-handling:
+	case <-time.After(time.Second):
+		t.Fatal("the first event is not handled")
+	}
+	Dispatch(TestAppEvent{appID: "test-1", eventType: events.RunApplication, flag: flag})
+	assert.Equal(t, len(dispatcher.eventChan), 1)
+	return func() { close(flag) }
+}
+
+func TestShedLowPriorityEvents(t *testing.T) {
+	getDispatcher()
+	backupCapacity := cap(dispatcher.eventChan)
+	backupLowCapacity := cap(dispatcher.lowPriorityChan)
+	backupBackpressure := Backpressure
+	backupWorkers := Workers
+	dispatcher.eventChan = make(chan events.SchedulingEvent, 1)
+	// the low priority lane is full as long as the handler is stuck
+	dispatcher.lowPriorityChan = make(chan events.SchedulingEvent)
+	Backpressure = conf.BackpressureShed
+	Workers = 1
+	defer func() {
+		dispatcher.eventChan = make(chan events.SchedulingEvent, backupCapacity)
+		dispatcher.lowPriorityChan = make(chan events.SchedulingEvent, backupLowCapacity)
+		Backpressure = backupBackpressure
+		Workers = backupWorkers
+	}()
+	statusEvents := int32(0)
+	RegisterEventHandler(EventTypeAppStatus, func(obj interface{}) {
+		atomic.AddInt32(&statusEvents, 1)
+	})
+
+	Start()
+	release := fillEventChannel(t)
+
+	// the low priority event is dropped, the others wait in the background
+	Dispatch(TestAppStatusEvent{state: "Running"})
+	assert.Equal(t, atomic.LoadInt32(&asyncDispatchCount), int32(0))
+	handled := make(chan bool)
+	close(handled)
+	Dispatch(TestAppEvent{appID: "test-2", eventType: events.RunApplication, flag: handled})
+	assert.Equal(t, atomic.LoadInt32(&asyncDispatchCount), int32(1))
+
+	release()
+	err := utils.WaitForCondition(func() bool {
+		return atomic.LoadInt32(&asyncDispatchCount) == 0
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err)
+	dispatcher.drain()
+	assert.Equal(t, atomic.LoadInt32(&statusEvents), int32(0))
+	Stop()
+}
+
+func TestHighPriorityEventsHandledFirst(t *testing.T) {
+	backupCapacity := cap(dispatcher.eventChan)
+	dispatcher.eventChan = make(chan events.SchedulingEvent, 10)
+	defer func() {
+		dispatcher.eventChan = make(chan events.SchedulingEvent, backupCapacity)
+	}()
+
+	lock := sync.Mutex{}
+	handled := make([]string, 0)
+	record := func(name string) {
+		lock.Lock()
+		defer lock.Unlock()
+		handled = append(handled, name)
+	}
+	RegisterEventHandler(EventTypeApp, func(obj interface{}) {
+		if appEvent, ok := obj.(TestAppEvent); ok {
+			<-appEvent.flag
+			record(appEvent.appID)
+		}
+	})
+	RegisterEventHandler(EventTypeAppStatus, func(obj interface{}) {
+		record("status")
+	})
+
+	Start()
+	flag := make(chan bool)
+	Dispatch(TestAppEvent{appID: "test-0", eventType: events.RunApplication, flag: flag})
+	err := utils.WaitForCondition(func() bool {
+		return len(dispatcher.eventChan) == 0
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err)
+
+	// the low priority event is queued first but handled last
+	Dispatch(TestAppStatusEvent{state: "Running"})
+	Dispatch(TestAppEvent{appID: "test-1", eventType: events.RunApplication, flag: flag})
+	assert.Equal(t, len(dispatcher.lowPriorityChan), 1)
+	assert.Equal(t, len(dispatcher.eventChan), 1)
+	close(flag)
+	err = utils.WaitForCondition(func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(handled) == 3
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err)
+	Stop()
+	assert.DeepEqual(t, handled, []string{"test-0", "test-1", "status"})
+}
+
+func TestBlockingDispatch(t *testing.T) {
+	getDispatcher()
+	backupCapacity := cap(dispatcher.eventChan)
+	backupBackpressure := Backpressure
+	backupDispatchTimeout := DispatchTimeout
+	backupWorkers := Workers
+	dispatcher.eventChan = make(chan events.SchedulingEvent, 1)
+	Backpressure = conf.BackpressureBlock
+	DispatchTimeout = 200 * time.Millisecond
+	Workers = 1
+	defer func() {
+		dispatcher.eventChan = make(chan events.SchedulingEvent, backupCapacity)
+		Backpressure = backupBackpressure
+		DispatchTimeout = backupDispatchTimeout
+		Workers = backupWorkers
+	}()
+
+	Start()
+	release := fillEventChannel(t)
+
+	// the producer is blocked until the dispatch times out, nothing waits in the background
+	handled := make(chan bool)
+	close(handled)
+	start := time.Now()
+	err := dispatcher.dispatch(TestAppEvent{appID: "test-2", eventType: events.RunApplication, flag: handled})
+	assert.ErrorContains(t, err, "dispatch timeout")
+	assert.Assert(t, time.Since(start) >= DispatchTimeout)
+	assert.Equal(t, atomic.LoadInt32(&asyncDispatchCount), int32(0))
+
+	// the producer is unblocked as soon as there is space
+	release()
+	err = dispatcher.dispatch(TestAppEvent{appID: "test-3", eventType: events.RunApplication, flag: handled})
+	assert.NilError(t, err)
+	dispatcher.drain()
+	Stop()
+}
+
+func TestGetWorkerIndex(t *testing.T) {
+	appEvent := TestAppEvent{appID: "app-1", eventType: events.RunApplication}
+	index := getWorkerIndex(appEvent, 4)
+	assert.Assert(t, index >= 0 && index < 4)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, getWorkerIndex(TestAppEvent{appID: "app-1", eventType: events.SubmitApplication}, 4), index)
+	}
+	// no application or node
+	assert.Equal(t, getWorkerIndex(TestAppStatusEvent{state: "Running"}, 4), 0)
+}
+
+func TestParallelWorkersKeepOrder(t *testing.T) {
+	// events are only handled in order when none of them is dispatched asynchronously
+	backupCapacity := cap(dispatcher.eventChan)
+	backupWorkers := Workers
+	dispatcher.eventChan = make(chan events.SchedulingEvent, 1024)
+	Workers = 4
+	defer func() {
+		dispatcher.eventChan = make(chan events.SchedulingEvent, backupCapacity)
+		Workers = backupWorkers
+	}()
+
+	lock := sync.Mutex{}
+	// the sequence number of the event is carried in the event type
+	handled := make(map[string][]string)
+	RegisterEventHandler(EventTypeApp, func(obj interface{}) {
+		if event, ok := obj.(TestAppEvent); ok {
+			lock.Lock()
+			defer lock.Unlock()
+			handled[event.appID] = append(handled[event.appID], string(event.eventType))
+		}
+	})
+
+	Start()
+	numApps := 8
+	numEvents := 50
+	for i := 0; i < numEvents; i++ {
+		for j := 0; j < numApps; j++ {
+			Dispatch(TestAppEvent{
+				appID:     fmt.Sprintf("app-%d", j),
+				eventType: events.ApplicationEventType(fmt.Sprintf("%d", i)),
+			})
+		}
+	}
+	err := utils.WaitForCondition(func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		total := 0
+		for _, seq := range handled {
+			total += len(seq)
+		}
+		return total == numApps*numEvents
+	}, 10*time.Millisecond, 5*time.Second)
+	assert.NilError(t, err)
+	Stop()
+	assert.Equal(t, len(handled), numApps)
+	for appID, seq := range handled {
+		for i, eventType := range seq {
+			assert.Equal(t, eventType, fmt.Sprintf("%d", i), "events of %s handled out of order", appID)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dispatcher
+
+import (
+	"sync/atomic"
+)
+
+// Health is the state of the dispatcher
+type Health struct {
+	Running         bool  `json:"running"`
+	PendingEvents   int   `json:"pendingEvents"`
+	AsyncDispatches int32 `json:"asyncDispatches"`
+	RecoveredPanics int64 `json:"recoveredPanics"`
+	DeadLetters     int   `json:"deadLetters"`
+}
+
+// GetHealth returns the state of the dispatcher, including the number of
+// panics recovered in the event handlers
+func GetHealth() *Health {
+	deadLetters.RLock()
+	letters := len(deadLetters.entries)
+	deadLetters.RUnlock()
+	return &Health{
+		Running:         getDispatcher().isRunning(),
+		PendingEvents:   getDispatcher().pending(),
+		AsyncDispatches: atomic.LoadInt32(&asyncDispatchCount),
+		RecoveredPanics: GetRecoveredPanics(),
+		DeadLetters:     letters,
+	}
+}
@@ -0,0 +1,88 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dispatcher
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+)
+
+// PanicHandler is called with the event a handler panicked on and the recovered value, it
+// fails the object the event belongs to so that the other objects are not affected.
+type PanicHandler func(event events.SchedulingEvent, recovered interface{})
+
+var panicHandler atomic.Value
+
+// number of panics recovered in the event handlers since the start
+var recoveredPanics int64
+
+// RegisterPanicHandler sets the handler called after a panic in an event handler is recovered
+func RegisterPanicHandler(handler PanicHandler) {
+	panicHandler.Store(handler)
+}
+
+func getPanicHandler() PanicHandler {
+	if handler, ok := panicHandler.Load().(PanicHandler); ok {
+		return handler
+	}
+	return nil
+}
+
+// recovers from a panic in the handler of the event: the event is added to the dead letters and
+// the panic handler fails the object the event belongs to, the dispatcher keeps running.
+func recoverPanic(event events.SchedulingEvent, recovered interface{}) {
+	atomic.AddInt64(&recoveredPanics, 1)
+	recorded := newRecordedEvent(historyEntry{event: event})
+	metrics.GetDispatcherMetrics().IncHandlerPanics(recorded.EventType)
+	log.Log(log.Dispatcher).Error("recovered from a panic in the event handler",
+		zap.String("eventType", recorded.EventType),
+		zap.String("event", recorded.Event),
+		zap.String("applicationID", recorded.ApplicationID),
+		zap.String("taskID", recorded.TaskID),
+		zap.String("nodeID", recorded.NodeID),
+		zap.Any("panic", recovered),
+		zap.Stack("stack"))
+	AddDeadLetter(&DeadLetter{
+		EventType:     recorded.EventType,
+		Event:         recorded.Event,
+		ApplicationID: recorded.ApplicationID,
+		TaskID:        recorded.TaskID,
+		Error:         fmt.Sprintf("panic: %v", recovered),
+	})
+	if handler := getPanicHandler(); handler != nil {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Log(log.Dispatcher).Error("panic handler failed",
+					zap.Any("panic", r))
+			}
+		}()
+		handler(event, recovered)
+	}
+}
+
+// GetRecoveredPanics returns the number of panics recovered in the event handlers since the start
+func GetRecoveredPanics() int64 {
+	return atomic.LoadInt64(&recoveredPanics)
+}
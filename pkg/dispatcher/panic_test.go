@@ -0,0 +1,67 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dispatcher
+
+import (
+	"reflect"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+)
+
+func TestRecoverPanic(t *testing.T) {
+	defer RegisterPanicHandler(nil)
+	var failed events.SchedulingEvent
+	var recovered interface{}
+	RegisterPanicHandler(func(event events.SchedulingEvent, r interface{}) {
+		failed = event
+		recovered = r
+	})
+	RegisterEventHandler(EventTypeTask, func(obj interface{}) {
+		panic("task handler failed")
+	})
+	panics := GetRecoveredPanics()
+
+	// the panic is recovered, the panic handler is called with the event
+	event := replayedTaskEvent{replayedEvent{"TaskBound", nil}, "app-panic", "task-panic"}
+	handleEvent(event)
+	assert.Equal(t, GetRecoveredPanics(), panics+1)
+	// the event holds unexported fields and a slice, it is compared with reflect
+	assert.Assert(t, reflect.DeepEqual(failed, events.SchedulingEvent(event)), "unexpected event %v", failed)
+	assert.Equal(t, recovered, "task handler failed")
+	found := false
+	for _, letter := range GetDeadLetters() {
+		if letter.TaskID == "task-panic" {
+			found = true
+			assert.Equal(t, letter.ApplicationID, "app-panic")
+			assert.Equal(t, letter.Event, "TaskBound")
+			assert.Equal(t, letter.Error, "panic: task handler failed")
+		}
+	}
+	assert.Assert(t, found, "dead letter not recorded")
+
+	// a panic in the panic handler is recovered too
+	RegisterPanicHandler(func(event events.SchedulingEvent, r interface{}) {
+		panic("panic handler failed")
+	})
+	handleEvent(event)
+	assert.Equal(t, GetRecoveredPanics(), panics+2)
+}
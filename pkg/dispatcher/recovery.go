@@ -0,0 +1,131 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// the state of the recovery phase: while the scheduler recovers, the task events are held
+// back so that no asks reach the core before the nodes and the existing allocations are known.
+type recoveryMode struct {
+	recovering bool
+	held       []events.SchedulingEvent
+	// serializes the synchronous dispatches
+	syncLock sync.Mutex
+	lock     sync.Mutex
+}
+
+var recovery = &recoveryMode{}
+
+// EnterRecoveryMode holds back the task events dispatched from now on, until ExitRecoveryMode is called.
+func EnterRecoveryMode() {
+	recovery.lock.Lock()
+	defer recovery.lock.Unlock()
+	log.Log(log.Dispatcher).Info("dispatcher enters recovery mode")
+	recovery.recovering = true
+}
+
+// ExitRecoveryMode dispatches the task events held back during the recovery, in the order
+// they were dispatched, and stops holding back the task events. Returns the number of
+// events released.
+func ExitRecoveryMode() int {
+	recovery.lock.Lock()
+	defer recovery.lock.Unlock()
+	if !recovery.recovering {
+		return 0
+	}
+	// the events dispatched while the held events are released wait for the lock:
+	// they are queued after the held events
+	released := len(recovery.held)
+	for _, event := range recovery.held {
+		if err := getDispatcher().enqueue(event); err != nil {
+			log.Log(log.Dispatcher).Warn("failed to dispatch event held during recovery",
+				zap.Error(err))
+		}
+	}
+	recovery.held = nil
+	recovery.recovering = false
+	log.Log(log.Dispatcher).Info("dispatcher exits recovery mode",
+		zap.Int("releasedEvents", released))
+	return released
+}
+
+// IsRecoveryMode returns true when the task events are held back.
+func IsRecoveryMode() bool {
+	recovery.lock.Lock()
+	defer recovery.lock.Unlock()
+	return recovery.recovering
+}
+
+// returns true when the event is held back until the recovery is done
+func (r *recoveryMode) hold(event events.SchedulingEvent) bool {
+	if _, ok := event.(events.TaskEvent); !ok {
+		return false
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if !r.recovering {
+		return false
+	}
+	r.held = append(r.held, event)
+	return true
+}
+
+// DispatchSync handles the event on the calling goroutine and returns when the event is handled.
+// The synchronous dispatches are handled one by one in the order of the calls, the events
+// dispatched by the handler go through the event channel as usual. This is used during the
+// recovery, where the order of the node and application events must not depend on the workers.
+func DispatchSync(event events.SchedulingEvent) error {
+	if !getDispatcher().isRunning() {
+		return fmt.Errorf("dispatcher is not running")
+	}
+	history.add(event)
+	recovery.syncLock.Lock()
+	defer recovery.syncLock.Unlock()
+	handleEvent(event)
+	return nil
+}
+
+// DispatchSyncAll handles the events on at most the given number of goroutines and returns when
+// all the events are handled. The events must not depend on each other, like the events of
+// different nodes: they are started in the order of the slice but can finish in any order.
+// The other synchronous dispatches wait until all the events are handled.
+func DispatchSyncAll(evs []events.SchedulingEvent, workers int) error {
+	if !getDispatcher().isRunning() {
+		return fmt.Errorf("dispatcher is not running")
+	}
+	for _, event := range evs {
+		history.add(event)
+	}
+	recovery.syncLock.Lock()
+	defer recovery.syncLock.Unlock()
+	workqueue.ParallelizeUntil(context.Background(), workers, len(evs), func(i int) {
+		handleEvent(evs[i])
+	})
+	return nil
+}
@@ -0,0 +1,136 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dispatcher
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
+)
+
+type TestTaskEvent struct {
+	appID  string
+	taskID string
+}
+
+func (t TestTaskEvent) GetApplicationID() string {
+	return t.appID
+}
+
+func (t TestTaskEvent) GetTaskID() string {
+	return t.taskID
+}
+
+func (t TestTaskEvent) GetEvent() events.TaskEventType {
+	return events.SubmitTask
+}
+
+func (t TestTaskEvent) GetArgs() []interface{} {
+	return nil
+}
+
+func TestTaskEventsHeldDuringRecovery(t *testing.T) {
+	var lock sync.Mutex
+	handled := make([]string, 0)
+	RegisterEventHandler(EventTypeTask, func(obj interface{}) {
+		lock.Lock()
+		defer lock.Unlock()
+		handled = append(handled, obj.(TestTaskEvent).taskID)
+	})
+	RegisterEventHandler(EventTypeApp, func(obj interface{}) {
+		lock.Lock()
+		defer lock.Unlock()
+		handled = append(handled, obj.(TestAppEvent).appID)
+	})
+	getHandled := func() []string {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]string(nil), handled...)
+	}
+
+	Start()
+	defer Stop()
+	EnterRecoveryMode()
+	assert.Assert(t, IsRecoveryMode())
+	Dispatch(TestTaskEvent{appID: "app-1", taskID: "task-1"})
+	Dispatch(TestTaskEvent{appID: "app-1", taskID: "task-2"})
+	// the other events are handled during the recovery
+	Dispatch(TestAppEvent{appID: "app-1", eventType: events.RunApplication})
+	err := utils.WaitForCondition(func() bool {
+		return len(getHandled()) == 1
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, getHandled(), []string{"app-1"})
+
+	// the held events are handled in the order they were dispatched
+	assert.Equal(t, ExitRecoveryMode(), 2)
+	assert.Assert(t, !IsRecoveryMode())
+	Dispatch(TestTaskEvent{appID: "app-1", taskID: "task-3"})
+	err = utils.WaitForCondition(func() bool {
+		return len(getHandled()) == 4
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, getHandled(), []string{"app-1", "task-1", "task-2", "task-3"})
+	assert.Equal(t, ExitRecoveryMode(), 0)
+}
+
+func TestDispatchSync(t *testing.T) {
+	handled := make([]string, 0)
+	RegisterEventHandler(EventTypeApp, func(obj interface{}) {
+		handled = append(handled, obj.(TestAppEvent).appID)
+	})
+
+	Start()
+	defer Stop()
+	// the events are handled before the call returns
+	assert.NilError(t, DispatchSync(TestAppEvent{appID: "app-1", eventType: events.RunApplication}))
+	assert.DeepEqual(t, handled, []string{"app-1"})
+	assert.NilError(t, DispatchSync(TestAppEvent{appID: "app-2", eventType: events.RunApplication}))
+	assert.DeepEqual(t, handled, []string{"app-1", "app-2"})
+}
+
+func TestDispatchSyncAll(t *testing.T) {
+	var lock sync.Mutex
+	handled := make(map[string]bool)
+	RegisterEventHandler(EventTypeApp, func(obj interface{}) {
+		lock.Lock()
+		defer lock.Unlock()
+		handled[obj.(TestAppEvent).appID] = true
+	})
+
+	evs := make([]events.SchedulingEvent, 0)
+	for i := 0; i < 10; i++ {
+		evs = append(evs, TestAppEvent{appID: fmt.Sprintf("app-%d", i), eventType: events.RunApplication})
+	}
+	assert.ErrorContains(t, DispatchSyncAll(evs, 4), "dispatcher is not running")
+
+	Start()
+	defer Stop()
+	// all the events are handled before the call returns
+	assert.NilError(t, DispatchSyncAll(evs, 4))
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, len(handled), 10)
+}
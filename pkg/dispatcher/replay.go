@@ -0,0 +1,283 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dispatcher
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// RecordedEvent is an event accepted by the dispatcher. The event can be replayed when all its
+// arguments are strings: the handlers read them back with events.GetEventArgsAsStrings.
+type RecordedEvent struct {
+	Seq           uint64    `json:"seq"`
+	Time          time.Time `json:"time"`
+	EventType     string    `json:"eventType"`
+	Event         string    `json:"event,omitempty"`
+	ApplicationID string    `json:"applicationID,omitempty"`
+	TaskID        string    `json:"taskID,omitempty"`
+	NodeID        string    `json:"nodeID,omitempty"`
+	State         string    `json:"state,omitempty"`
+	Args          []string  `json:"args,omitempty"`
+	Replayable    bool      `json:"replayable"`
+}
+
+type historyEntry struct {
+	seq   uint64
+	time  time.Time
+	event events.SchedulingEvent
+}
+
+// ring buffer of the events most recently accepted by the dispatcher, the events are only
+// converted when the history is read
+type eventHistory struct {
+	entries []historyEntry
+	next    int
+	seq     uint64
+	sync.Mutex
+}
+
+var history = newEventHistory(0)
+
+func newEventHistory(size int) *eventHistory {
+	return &eventHistory{
+		entries: make([]historyEntry, 0, size),
+	}
+}
+
+// sets the number of events kept in the history, 0 disables the history
+func setHistorySize(size int) {
+	history.Lock()
+	defer history.Unlock()
+	history.entries = make([]historyEntry, 0, size)
+	history.next = 0
+}
+
+func (h *eventHistory) add(event events.SchedulingEvent) {
+	h.Lock()
+	defer h.Unlock()
+	size := cap(h.entries)
+	if size == 0 {
+		return
+	}
+	h.seq++
+	entry := historyEntry{seq: h.seq, time: time.Now(), event: event}
+	if len(h.entries) < size {
+		h.entries = append(h.entries, entry)
+	} else {
+		h.entries[h.next] = entry
+	}
+	h.next = (h.next + 1) % size
+}
+
+// returns the recorded events, oldest first
+func (h *eventHistory) list() []*RecordedEvent {
+	h.Lock()
+	entries := make([]historyEntry, 0, len(h.entries))
+	if len(h.entries) == cap(h.entries) {
+		entries = append(entries, h.entries[h.next:]...)
+		entries = append(entries, h.entries[:h.next]...)
+	} else {
+		entries = append(entries, h.entries...)
+	}
+	h.Unlock()
+	recorded := make([]*RecordedEvent, 0, len(entries))
+	for _, entry := range entries {
+		recorded = append(recorded, newRecordedEvent(entry))
+	}
+	return recorded
+}
+
+func newRecordedEvent(entry historyEntry) *RecordedEvent {
+	recorded := &RecordedEvent{
+		Seq:        entry.seq,
+		Time:       entry.time,
+		EventType:  getEventTypeName(entry.event),
+		Replayable: true,
+	}
+	switch v := entry.event.(type) {
+	case events.ApplicationStatusEvent:
+		recorded.State = v.GetState()
+		if app, ok := v.(events.ApplicationEvent); ok {
+			recorded.Event = string(app.GetEvent())
+			recorded.ApplicationID = app.GetApplicationID()
+		}
+	case events.ApplicationEvent:
+		recorded.Event = string(v.GetEvent())
+		recorded.ApplicationID = v.GetApplicationID()
+	case events.TaskEvent:
+		recorded.Event = string(v.GetEvent())
+		recorded.ApplicationID = v.GetApplicationID()
+		recorded.TaskID = v.GetTaskID()
+	case events.SchedulerEvent:
+		recorded.Event = string(v.GetEvent())
+	case events.SchedulerNodeEvent:
+		recorded.Event = string(v.GetEvent())
+		recorded.NodeID = v.GetNodeID()
+	default:
+		recorded.Replayable = false
+	}
+	if _, ok := entry.event.(events.ApplicationStatusEvent); !ok {
+		for _, arg := range entry.event.GetArgs() {
+			if s, ok := arg.(string); ok {
+				recorded.Args = append(recorded.Args, s)
+			} else {
+				recorded.Args = append(recorded.Args, fmt.Sprintf("%v", arg))
+				recorded.Replayable = false
+			}
+		}
+	}
+	return recorded
+}
+
+// GetRecentEvents returns the events most recently accepted by the dispatcher, oldest first
+func GetRecentEvents() []*RecordedEvent {
+	return history.list()
+}
+
+// Replay dispatches the recorded events in order, the events that cannot be replayed are skipped.
+// It is only allowed in test mode: the replayed events change the state of the scheduler.
+// Returns the number of events dispatched.
+func Replay(recorded []*RecordedEvent) (int, error) {
+	if !conf.GetSchedulerConf().IsTestMode() {
+		return 0, fmt.Errorf("events can only be replayed in test mode")
+	}
+	var errs []error
+	count := 0
+	for _, r := range recorded {
+		event, err := r.toEvent()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("event %d: %v", r.Seq, err))
+			continue
+		}
+		if err = getDispatcher().dispatch(event); err != nil {
+			errs = append(errs, fmt.Errorf("event %d: %v", r.Seq, err))
+			continue
+		}
+		count++
+	}
+	log.Log(log.Dispatcher).Info("replayed recorded events",
+		zap.Int("replayed", count),
+		zap.Int("skipped", len(errs)))
+	return count, utilerrors.NewAggregate(errs)
+}
+
+func (r *RecordedEvent) toEvent() (events.SchedulingEvent, error) {
+	if !r.Replayable {
+		return nil, fmt.Errorf("%s event %s has arguments that cannot be replayed", r.EventType, r.Event)
+	}
+	args := make([]interface{}, len(r.Args))
+	for i, arg := range r.Args {
+		args[i] = arg
+	}
+	e := replayedEvent{event: r.Event, args: args}
+	switch r.EventType {
+	case "appStatus":
+		return replayedAppStatusEvent{replayedAppEvent{e, r.ApplicationID}, r.State}, nil
+	case "app":
+		return replayedAppEvent{e, r.ApplicationID}, nil
+	case "task":
+		return replayedTaskEvent{e, r.ApplicationID, r.TaskID}, nil
+	case "scheduler":
+		return replayedSchedulerEvent{e}, nil
+	case "node":
+		return replayedNodeEvent{e, r.NodeID}, nil
+	default:
+		return nil, fmt.Errorf("unknown event type %s", r.EventType)
+	}
+}
+
+// the events rebuilt from the recorded events
+type replayedEvent struct {
+	event string
+	args  []interface{}
+}
+
+func (e replayedEvent) GetArgs() []interface{} {
+	return e.args
+}
+
+type replayedAppEvent struct {
+	replayedEvent
+	applicationID string
+}
+
+func (e replayedAppEvent) GetEvent() events.ApplicationEventType {
+	return events.ApplicationEventType(e.event)
+}
+
+func (e replayedAppEvent) GetApplicationID() string {
+	return e.applicationID
+}
+
+type replayedAppStatusEvent struct {
+	replayedAppEvent
+	state string
+}
+
+func (e replayedAppStatusEvent) GetState() string {
+	return e.state
+}
+
+type replayedTaskEvent struct {
+	replayedEvent
+	applicationID string
+	taskID        string
+}
+
+func (e replayedTaskEvent) GetEvent() events.TaskEventType {
+	return events.TaskEventType(e.event)
+}
+
+func (e replayedTaskEvent) GetApplicationID() string {
+	return e.applicationID
+}
+
+func (e replayedTaskEvent) GetTaskID() string {
+	return e.taskID
+}
+
+type replayedSchedulerEvent struct {
+	replayedEvent
+}
+
+func (e replayedSchedulerEvent) GetEvent() events.SchedulerEventType {
+	return events.SchedulerEventType(e.event)
+}
+
+type replayedNodeEvent struct {
+	replayedEvent
+	nodeID string
+}
+
+func (e replayedNodeEvent) GetEvent() events.SchedulerNodeEventType {
+	return events.SchedulerNodeEventType(e.event)
+}
+
+func (e replayedNodeEvent) GetNodeID() string {
+	return e.nodeID
+}
@@ -0,0 +1,113 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dispatcher
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+)
+
+func TestEventHistory(t *testing.T) {
+	h := newEventHistory(3)
+	assert.Equal(t, len(h.list()), 0)
+	for _, appID := range []string{"app-1", "app-2", "app-3", "app-4", "app-5"} {
+		h.add(TestAppEvent{appID: appID, eventType: events.RunApplication})
+	}
+	// only the most recent events are kept, oldest first
+	recorded := h.list()
+	assert.Equal(t, len(recorded), 3)
+	for i, r := range recorded {
+		assert.Equal(t, r.Seq, uint64(i+3))
+		assert.Equal(t, r.EventType, "app")
+		assert.Equal(t, r.Event, string(events.RunApplication))
+		assert.Assert(t, r.Replayable)
+	}
+	assert.Equal(t, recorded[0].ApplicationID, "app-3")
+	assert.Equal(t, recorded[2].ApplicationID, "app-5")
+
+	// disabled history
+	h = newEventHistory(0)
+	h.add(TestAppEvent{appID: "app-1", eventType: events.RunApplication})
+	assert.Equal(t, len(h.list()), 0)
+}
+
+func TestRecordedEvent(t *testing.T) {
+	h := newEventHistory(10)
+	h.add(replayedTaskEvent{replayedEvent{"InitTask", []interface{}{"message"}}, "app-1", "task-1"})
+	h.add(replayedNodeEvent{replayedEvent{"NodeAccepted", []interface{}{42}}, "node-1"})
+	h.add(TestAppStatusEvent{state: "Running"})
+	recorded := h.list()
+	assert.DeepEqual(t, *recorded[0], RecordedEvent{Seq: 1, Time: recorded[0].Time, EventType: "task", Event: "InitTask",
+		ApplicationID: "app-1", TaskID: "task-1", Args: []string{"message"}, Replayable: true})
+	// the arguments that are not strings cannot be replayed
+	assert.DeepEqual(t, recorded[1].Args, []string{"42"})
+	assert.Assert(t, !recorded[1].Replayable)
+	_, err := recorded[1].toEvent()
+	assert.ErrorContains(t, err, "cannot be replayed")
+	assert.Equal(t, recorded[2].EventType, "appStatus")
+	assert.Equal(t, recorded[2].State, "Running")
+}
+
+func TestReplay(t *testing.T) {
+	backup := conf.GetSchedulerConf().IsTestMode()
+	defer conf.GetSchedulerConf().SetTestMode(backup)
+	recorded := []*RecordedEvent{
+		{Seq: 1, EventType: "app", Event: "SubmitApplication", ApplicationID: "app-1", Replayable: true},
+		{Seq: 2, EventType: "task", Event: "InitTask", ApplicationID: "app-1", TaskID: "task-1", Args: []string{"msg"}, Replayable: true},
+		{Seq: 3, EventType: "node", Event: "NodeAccepted", NodeID: "node-1", Args: []string{"42"}, Replayable: false},
+	}
+	conf.GetSchedulerConf().SetTestMode(false)
+	_, err := Replay(recorded)
+	assert.ErrorContains(t, err, "only be replayed in test mode")
+
+	conf.GetSchedulerConf().SetTestMode(true)
+	lock := sync.Mutex{}
+	handled := make([]string, 0)
+	RegisterEventHandler(EventTypeApp, func(obj interface{}) {
+		event := obj.(events.ApplicationEvent)
+		lock.Lock()
+		defer lock.Unlock()
+		handled = append(handled, event.GetApplicationID()+"/"+string(event.GetEvent()))
+	})
+	RegisterEventHandler(EventTypeTask, func(obj interface{}) {
+		event := obj.(events.TaskEvent)
+		lock.Lock()
+		defer lock.Unlock()
+		handled = append(handled, event.GetTaskID()+"/"+string(event.GetEvent())+"/"+event.GetArgs()[0].(string))
+	})
+	Start()
+	count, err := Replay(recorded)
+	assert.Equal(t, count, 2)
+	assert.ErrorContains(t, err, "event 3: node event NodeAccepted has arguments that cannot be replayed")
+	err = utils.WaitForCondition(func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(handled) == 2
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err)
+	Stop()
+	assert.DeepEqual(t, handled, []string{"app-1/SubmitApplication", "task-1/InitTask/msg"})
+}
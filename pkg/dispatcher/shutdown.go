@@ -0,0 +1,137 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dispatcher
+
+import (
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+var (
+	// interval of the checks while the dispatcher drains
+	drainCheckInterval = 100 * time.Millisecond
+	// maximum wait time for the event loop to stop after the drain
+	stopTimeout = 5 * time.Second
+)
+
+// number of events being handled
+var handlingCount int32
+
+// Shutdown drains the dispatcher and stops it. The producers of the events must be stopped
+// first: the events dispatched during the drain, e.g. by the handlers of the queued events, are
+// still handled. After the timeout the dispatcher stops accepting events, the events that were
+// not handled are logged so that the state they leave behind can be checked after the restart.
+// Returns the number of events that were not handled.
+func Shutdown(timeout time.Duration) int {
+	if !getDispatcher().isRunning() {
+		log.Log(log.Dispatcher).Info("dispatcher is already stopped")
+		return 0
+	}
+	log.Log(log.Dispatcher).Info("draining the dispatcher",
+		zap.Int("pendingEvents", getDispatcher().pending()),
+		zap.Duration("timeout", timeout))
+	if getDispatcher().waitDrained(timeout) {
+		log.Log(log.Dispatcher).Info("dispatcher is drained")
+	} else {
+		log.Log(log.Dispatcher).Warn("dispatcher drain timed out",
+			zap.Int("pendingEvents", getDispatcher().pending()))
+	}
+	// no new events are accepted from now on, the event loop stops as soon as the
+	// event being handled is done
+	getDispatcher().setRunning(false)
+	select {
+	case getDispatcher().stopChan <- struct{}{}:
+		// the next start must not race with the event loop that is stopping
+		if getDispatcher().waitStopped(stopTimeout) {
+			log.Log(log.Dispatcher).Info("dispatcher stopped")
+		} else {
+			log.Log(log.Dispatcher).Warn("dispatcher did not stop, an event handler is blocked")
+		}
+	case <-time.After(stopTimeout):
+		log.Log(log.Dispatcher).Warn("dispatcher did not stop, an event handler is blocked")
+	}
+	unhandled := getDispatcher().flagUnhandled()
+	if unhandled > 0 {
+		log.Log(log.Dispatcher).Error("events were not handled before the shutdown",
+			zap.Int("unhandledEvents", unhandled))
+	}
+	return unhandled
+}
+
+// waits until no event is queued or being handled, returns false when the timeout is reached first
+func (p *Dispatcher) waitDrained(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if p.pending() == 0 && atomic.LoadInt32(&asyncDispatchCount) == 0 && atomic.LoadInt32(&handlingCount) == 0 {
+			return true
+		}
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		time.Sleep(drainCheckInterval)
+	}
+}
+
+// removes the events left in the event channels of the stopped dispatcher and logs them,
+// returns the number of events removed
+func (p *Dispatcher) flagUnhandled() int {
+	count := 0
+	for _, queue := range []chan events.SchedulingEvent{p.eventChan, p.lowPriorityChan} {
+		for len(queue) > 0 {
+			select {
+			case event := <-queue:
+				logUnhandled(event)
+				count++
+			default:
+			}
+		}
+	}
+	return count
+}
+
+func logUnhandled(event events.SchedulingEvent) {
+	fields := []zap.Field{zap.String("eventType", getEventTypeName(event))}
+	switch v := event.(type) {
+	case events.ApplicationEvent:
+		fields = append(fields,
+			zap.String("event", string(v.GetEvent())),
+			zap.String("applicationID", v.GetApplicationID()))
+	case events.TaskEvent:
+		fields = append(fields,
+			zap.String("event", string(v.GetEvent())),
+			zap.String("applicationID", v.GetApplicationID()),
+			zap.String("taskID", v.GetTaskID()))
+	case events.SchedulerNodeEvent:
+		fields = append(fields,
+			zap.String("event", string(v.GetEvent())),
+			zap.String("nodeID", v.GetNodeID()))
+	case events.SchedulerEvent:
+		fields = append(fields,
+			zap.String("event", string(v.GetEvent())))
+	case events.ApplicationStatusEvent:
+		fields = append(fields,
+			zap.String("state", v.GetState()))
+	}
+	log.Log(log.Dispatcher).Warn("event not handled before the shutdown", fields...)
+}
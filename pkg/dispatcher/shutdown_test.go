@@ -0,0 +1,112 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dispatcher
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
+)
+
+func TestShutdownDrainsEvents(t *testing.T) {
+	handled := int32(0)
+	RegisterEventHandler(EventTypeApp, func(obj interface{}) {
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&handled, 1)
+	})
+
+	Start()
+	for i := 0; i < 20; i++ {
+		Dispatch(TestAppEvent{appID: "app-1", eventType: events.RunApplication})
+	}
+	assert.Equal(t, Shutdown(5*time.Second), 0)
+	assert.Equal(t, atomic.LoadInt32(&handled), int32(20))
+	assert.Assert(t, !getDispatcher().isRunning())
+	// no events are accepted after the shutdown
+	assert.ErrorContains(t, getDispatcher().dispatch(TestAppEvent{appID: "app-1", eventType: events.RunApplication}),
+		"dispatcher is not running")
+	assert.Equal(t, Shutdown(time.Second), 0)
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	backupCapacity := cap(dispatcher.eventChan)
+	backupStopTimeout := stopTimeout
+	dispatcher.eventChan = make(chan events.SchedulingEvent, 10)
+	stopTimeout = 100 * time.Millisecond
+	defer func() {
+		dispatcher.eventChan = make(chan events.SchedulingEvent, backupCapacity)
+		stopTimeout = backupStopTimeout
+	}()
+	handled := int32(0)
+	RegisterEventHandler(EventTypeApp, func(obj interface{}) {
+		if appEvent, ok := obj.(TestAppEvent); ok && appEvent.flag != nil {
+			<-appEvent.flag
+		}
+		atomic.AddInt32(&handled, 1)
+	})
+
+	Start()
+	flag := make(chan bool)
+	Dispatch(TestAppEvent{appID: "app-0", eventType: events.RunApplication, flag: flag})
+	err := utils.WaitForCondition(func() bool {
+		return len(dispatcher.eventChan) == 0
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err)
+	for i := 0; i < 3; i++ {
+		Dispatch(TestAppEvent{appID: "app-1", eventType: events.RunApplication})
+	}
+
+	// the handler is blocked: the queued events are not handled
+	start := time.Now()
+	assert.Equal(t, Shutdown(200*time.Millisecond), 3)
+	assert.Assert(t, time.Since(start) >= 200*time.Millisecond)
+	assert.Equal(t, len(dispatcher.eventChan), 0)
+
+	// release the handler and stop the event loop
+	close(flag)
+	dispatcher.stopChan <- struct{}{}
+	assert.Equal(t, atomic.LoadInt32(&handled), int32(1))
+}
+
+func TestShutdownRestart(t *testing.T) {
+	handled := int32(0)
+	RegisterEventHandler(EventTypeApp, func(obj interface{}) {
+		atomic.AddInt32(&handled, 1)
+	})
+	// the shutdown returns once the event loop exited, the loop does not stop the next start
+	for i := 0; i < 20; i++ {
+		Start()
+		assert.Equal(t, Shutdown(time.Second), 0)
+		assert.Assert(t, !getDispatcher().isRunning())
+	}
+	Start()
+	defer Stop()
+	time.Sleep(10 * time.Millisecond)
+	assert.Assert(t, getDispatcher().isRunning())
+	assert.NilError(t, getDispatcher().dispatch(TestAppEvent{appID: "app-1", eventType: events.RunApplication}))
+	err := utils.WaitForCondition(func() bool {
+		return atomic.LoadInt32(&handled) == 1
+	}, time.Millisecond, time.Second)
+	assert.NilError(t, err)
+}
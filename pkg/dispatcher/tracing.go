@@ -0,0 +1,118 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dispatcher
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+)
+
+// outcome of the handling of an event
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+	OutcomePanic   = "panic"
+)
+
+// EventTracer is called when the handling of an event starts, the returned function is called with
+// the outcome when the handling ends. It allows the handling to be traced externally, e.g. as
+// OpenTelemetry spans, the durations are always recorded in the metrics.
+type EventTracer func(eventType string, event events.SchedulingEvent) func(outcome string)
+
+var eventTracer atomic.Value
+
+// SetEventTracer sets the tracer of the event handling, nil removes the tracer
+func SetEventTracer(tracer EventTracer) {
+	eventTracer.Store(tracer)
+}
+
+func getEventTracer() EventTracer {
+	if tracer, ok := eventTracer.Load().(EventTracer); ok {
+		return tracer
+	}
+	return nil
+}
+
+// the handling of a single event
+type eventHandling struct {
+	eventType string
+	appID     string
+	start     time.Time
+	failed    bool
+	end       func(outcome string)
+}
+
+// the events being handled by application ID: the events of an application are always handled
+// by the same worker one at a time, the failures reported by the handler belong to the event.
+var inFlight = struct {
+	events map[string]*eventHandling
+	sync.Mutex
+}{
+	events: make(map[string]*eventHandling),
+}
+
+func startHandling(event events.SchedulingEvent) *eventHandling {
+	h := &eventHandling{
+		eventType: getEventTypeName(event),
+		start:     time.Now(),
+	}
+	if tracer := getEventTracer(); tracer != nil {
+		h.end = tracer(h.eventType, event)
+	}
+	if v, ok := event.(interface{ GetApplicationID() string }); ok {
+		h.appID = v.GetApplicationID()
+		inFlight.Lock()
+		inFlight.events[h.appID] = h
+		inFlight.Unlock()
+	}
+	return h
+}
+
+// marks the event of the application being handled as failed
+func markFailed(appID string) {
+	inFlight.Lock()
+	defer inFlight.Unlock()
+	if h, ok := inFlight.events[appID]; ok {
+		h.failed = true
+	}
+}
+
+// ends the handling, the outcome is derived from the reported failures unless the handler panicked
+func (h *eventHandling) finish(panicked bool) {
+	outcome := OutcomeSuccess
+	if h.appID != "" {
+		inFlight.Lock()
+		if h.failed {
+			outcome = OutcomeFailure
+		}
+		delete(inFlight.events, h.appID)
+		inFlight.Unlock()
+	}
+	if panicked {
+		outcome = OutcomePanic
+	}
+	metrics.GetDispatcherMetrics().ObserveEventHandling(h.eventType, outcome, time.Since(h.start))
+	if h.end != nil {
+		h.end(outcome)
+	}
+}
@@ -0,0 +1,61 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dispatcher
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+)
+
+func TestEventHandlingOutcome(t *testing.T) {
+	outcomes := make(map[string]string)
+	SetEventTracer(func(eventType string, event events.SchedulingEvent) func(outcome string) {
+		assert.Equal(t, eventType, "app")
+		appID := event.(TestAppEvent).appID
+		return func(outcome string) {
+			outcomes[appID] = outcome
+		}
+	})
+	defer SetEventTracer(nil)
+	RegisterEventHandler(EventTypeApp, func(obj interface{}) {
+		switch obj.(TestAppEvent).appID {
+		case "app-failure":
+			AddDeadLetter(&DeadLetter{EventType: "app", Event: "RunApplication", ApplicationID: "app-failure", Error: "failed"})
+		case "app-panic":
+			panic("handler failed")
+		}
+	})
+
+	handleEvent(TestAppEvent{appID: "app-success", eventType: events.RunApplication})
+	handleEvent(TestAppEvent{appID: "app-failure", eventType: events.RunApplication})
+	handleEvent(TestAppEvent{appID: "app-panic", eventType: events.RunApplication})
+	assert.DeepEqual(t, outcomes, map[string]string{
+		"app-success": OutcomeSuccess,
+		"app-failure": OutcomeFailure,
+		"app-panic":   OutcomePanic,
+	})
+	// a failure reported outside of the handling does not change the outcome
+	AddDeadLetter(&DeadLetter{EventType: "app", Event: "RunApplication", ApplicationID: "app-success", Error: "failed"})
+	handleEvent(TestAppEvent{appID: "app-success", eventType: events.RunApplication})
+	assert.Equal(t, outcomes["app-success"], OutcomeSuccess)
+	assert.Equal(t, len(inFlight.events), 0)
+}
@@ -0,0 +1,246 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package eventsink
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+)
+
+// the kinds of objects the lifecycle events belong to
+const (
+	KindApplication = "application"
+	KindTask        = "task"
+	KindNode        = "node"
+)
+
+const (
+	// maximum number of events buffered for a sink, new events are dropped when the buffer is full
+	bufferSize = 10000
+	// maximum number of events sent to a sink at once
+	batchSize = 100
+	// a batch is dropped after this number of failed attempts
+	maxAttempts = 5
+)
+
+var (
+	// the buffered events are sent at least this often
+	flushInterval = time.Second
+	// wait time before the first retry of a failed batch, it doubles on each retry
+	retryInterval = 500 * time.Millisecond
+)
+
+// LifecycleEvent is a state transition of an application, task or node
+type LifecycleEvent struct {
+	Kind          string    `json:"kind"`
+	ApplicationID string    `json:"applicationID,omitempty"`
+	TaskID        string    `json:"taskID,omitempty"`
+	NodeID        string    `json:"nodeID,omitempty"`
+	Queue         string    `json:"queue,omitempty"`
+	Event         string    `json:"event"`
+	From          string    `json:"from"`
+	To            string    `json:"to"`
+	Time          time.Time `json:"time"`
+}
+
+// Sink receives the lifecycle events in batches. Send is never called concurrently for the
+// same sink, an error causes the batch to be retried: the events are delivered at least once.
+type Sink interface {
+	// name of the sink in the logs and metrics
+	Name() string
+	Send(events []*LifecycleEvent) error
+}
+
+// streams the events to a sink from a buffer
+type publisher struct {
+	sink   Sink
+	buffer chan *LifecycleEvent
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+var publishers = struct {
+	list []*publisher
+	sync.RWMutex
+}{}
+
+// NewSink creates the sink for the URL: http and https URLs are webhooks, file URLs are files
+// the events are appended to as JSON lines.
+func NewSink(sinkURL string) (Sink, error) {
+	u, err := url.Parse(sinkURL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return newWebhookSink(u.String()), nil
+	case "file":
+		if u.Path == "" {
+			return nil, fmt.Errorf("event sink %s has no file path", sinkURL)
+		}
+		return newFileSink(u.Path), nil
+	default:
+		return nil, fmt.Errorf("event sink %s has an unsupported scheme, expected http, https or file", sinkURL)
+	}
+}
+
+// AddSinks adds the sinks of the comma-separated list of URLs, see NewSink
+func AddSinks(sinkURLs string) error {
+	for _, sinkURL := range strings.Split(sinkURLs, ",") {
+		sinkURL = strings.TrimSpace(sinkURL)
+		if sinkURL == "" {
+			continue
+		}
+		sink, err := NewSink(sinkURL)
+		if err != nil {
+			return err
+		}
+		AddSink(sink)
+	}
+	return nil
+}
+
+// AddSink starts streaming the lifecycle events to the sink, other sinks than the built-in
+// ones, e.g. a message queue, can be added here.
+func AddSink(sink Sink) {
+	p := &publisher{
+		sink:   sink,
+		buffer: make(chan *LifecycleEvent, bufferSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	publishers.Lock()
+	publishers.list = append(publishers.list, p)
+	publishers.Unlock()
+	go p.run()
+	log.Logger().Info("streaming lifecycle events to sink",
+		zap.String("sink", sink.Name()))
+}
+
+// Publish streams the event to all sinks and subscribers, it never blocks: the event is dropped
+// for a sink or a subscriber that has a full buffer.
+func Publish(event *LifecycleEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	notifySubscribers(event)
+	publishers.RLock()
+	defer publishers.RUnlock()
+	for _, p := range publishers.list {
+		select {
+		case p.buffer <- event:
+		default:
+			metrics.GetEventSinkMetrics().AddEvents(p.sink.Name(), metrics.SinkEventDropped, 1)
+			log.Logger().Debug("event sink buffer is full, dropping lifecycle event",
+				zap.String("sink", p.sink.Name()))
+		}
+	}
+}
+
+// Stop sends the buffered events, removes all sinks and closes all subscriptions
+func Stop() {
+	closeSubscriptions()
+	publishers.Lock()
+	list := publishers.list
+	publishers.list = nil
+	publishers.Unlock()
+	for _, p := range list {
+		close(p.stop)
+		<-p.done
+	}
+}
+
+func (p *publisher) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	batch := make([]*LifecycleEvent, 0, batchSize)
+	for {
+		select {
+		case event := <-p.buffer:
+			batch = append(batch, event)
+			if len(batch) >= batchSize {
+				p.send(batch)
+				batch = make([]*LifecycleEvent, 0, batchSize)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				p.send(batch)
+				batch = make([]*LifecycleEvent, 0, batchSize)
+			}
+		case <-p.stop:
+			// the publisher is no longer listed, nothing is added to the buffer
+			for len(p.buffer) > 0 {
+				batch = append(batch, <-p.buffer)
+				if len(batch) >= batchSize {
+					p.send(batch)
+					batch = make([]*LifecycleEvent, 0, batchSize)
+				}
+			}
+			if len(batch) > 0 {
+				p.send(batch)
+			}
+			return
+		}
+	}
+}
+
+// sends the batch, failed attempts are retried with an increasing wait time until the
+// batch is dropped. The retries stop early when the publisher is stopped.
+func (p *publisher) send(batch []*LifecycleEvent) {
+	name := p.sink.Name()
+	wait := retryInterval
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		err := p.sink.Send(batch)
+		metrics.GetEventSinkMetrics().ObserveSendLatency(name, time.Since(start))
+		if err == nil {
+			metrics.GetEventSinkMetrics().AddEvents(name, metrics.SinkEventSent, len(batch))
+			return
+		}
+		if attempt >= maxAttempts {
+			metrics.GetEventSinkMetrics().AddEvents(name, metrics.SinkEventFailed, len(batch))
+			log.Logger().Warn("failed to send lifecycle events to sink, dropping them",
+				zap.String("sink", name),
+				zap.Int("events", len(batch)),
+				zap.Int("attempts", attempt),
+				zap.Error(err))
+			return
+		}
+		log.Logger().Debug("failed to send lifecycle events to sink, retrying",
+			zap.String("sink", name),
+			zap.Int("attempt", attempt),
+			zap.Error(err))
+		select {
+		case <-time.After(wait):
+		case <-p.stop:
+			// one last attempt when stopping
+			attempt = maxAttempts - 1
+		}
+		wait *= 2
+	}
+}
@@ -0,0 +1,196 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package eventsink
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/utils"
+)
+
+// records the batches, the first attempts up to the number of failures fail
+type testSink struct {
+	failures int
+	attempts int
+	events   []*LifecycleEvent
+	sync.Mutex
+}
+
+func (s *testSink) Name() string {
+	return "test"
+}
+
+func (s *testSink) Send(events []*LifecycleEvent) error {
+	s.Lock()
+	defer s.Unlock()
+	s.attempts++
+	if s.attempts <= s.failures {
+		return fmt.Errorf("send failed")
+	}
+	s.events = append(s.events, events...)
+	return nil
+}
+
+func (s *testSink) getEvents() []*LifecycleEvent {
+	s.Lock()
+	defer s.Unlock()
+	return s.events
+}
+
+func TestNewSink(t *testing.T) {
+	sink, err := NewSink("https://example.com/events")
+	assert.NilError(t, err)
+	assert.Equal(t, sink.Name(), "webhook")
+	sink, err = NewSink("file:///var/log/yunikorn/events.json")
+	assert.NilError(t, err)
+	assert.Equal(t, sink.(*fileSink).path, "/var/log/yunikorn/events.json")
+	_, err = NewSink("kafka://broker:9092/events")
+	assert.ErrorContains(t, err, "unsupported scheme")
+	_, err = NewSink("file://")
+	assert.ErrorContains(t, err, "no file path")
+	assert.ErrorContains(t, AddSinks("http://example.com, ftp://example.com"), "unsupported scheme")
+	Stop()
+}
+
+func TestPublishRetry(t *testing.T) {
+	backupRetry := retryInterval
+	backupFlush := flushInterval
+	retryInterval = time.Millisecond
+	flushInterval = time.Hour
+	defer func() {
+		retryInterval = backupRetry
+		flushInterval = backupFlush
+	}()
+
+	// nothing happens without sinks
+	Publish(&LifecycleEvent{Kind: KindNode, NodeID: "node-0"})
+
+	sink := &testSink{failures: 2}
+	AddSink(sink)
+	for i := 0; i < batchSize+1; i++ {
+		Publish(&LifecycleEvent{Kind: KindTask, ApplicationID: "app-1", TaskID: fmt.Sprintf("task-%d", i),
+			Event: "InitTask", From: "New", To: "Pending"})
+	}
+	// the full batch is sent at once, the remaining event is sent on stop
+	err := utils.WaitForCondition(func() bool {
+		return len(sink.getEvents()) == batchSize
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err)
+	Stop()
+	events := sink.getEvents()
+	assert.Equal(t, len(events), batchSize+1)
+	assert.Equal(t, events[0].TaskID, "task-0")
+	assert.Assert(t, !events[0].Time.IsZero())
+	assert.Equal(t, sink.attempts, 4)
+}
+
+func TestPublishDropsFailedBatch(t *testing.T) {
+	backupRetry := retryInterval
+	backupFlush := flushInterval
+	retryInterval = time.Millisecond
+	flushInterval = 10 * time.Millisecond
+	defer func() {
+		retryInterval = backupRetry
+		flushInterval = backupFlush
+	}()
+
+	sink := &testSink{failures: maxAttempts}
+	AddSink(sink)
+	Publish(&LifecycleEvent{Kind: KindApplication, ApplicationID: "app-1"})
+	// the batch is flushed and dropped after all the attempts failed
+	err := utils.WaitForCondition(func() bool {
+		sink.Lock()
+		defer sink.Unlock()
+		return sink.attempts == maxAttempts
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err)
+	Stop()
+	assert.Equal(t, len(sink.getEvents()), 0)
+	assert.Equal(t, sink.attempts, maxAttempts)
+}
+
+func TestPublishStopEndsRetries(t *testing.T) {
+	sink := &testSink{failures: maxAttempts}
+	AddSink(sink)
+	Publish(&LifecycleEvent{Kind: KindApplication, ApplicationID: "app-1"})
+	// the batch is only sent on stop, the retries jump to the last attempt
+	Stop()
+	assert.Equal(t, len(sink.getEvents()), 0)
+	assert.Equal(t, sink.attempts, 2)
+}
+
+func TestWebhookSink(t *testing.T) {
+	var received []*LifecycleEvent
+	var contentType string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := newWebhookSink(server.URL)
+	err := sink.Send([]*LifecycleEvent{{Kind: KindApplication, ApplicationID: "app-1", Queue: "root.a"}})
+	assert.NilError(t, err)
+	assert.Equal(t, contentType, "application/json")
+	assert.Equal(t, len(received), 1)
+	assert.Equal(t, received[0].Queue, "root.a")
+	status = http.StatusServiceUnavailable
+	err = sink.Send([]*LifecycleEvent{{Kind: KindApplication, ApplicationID: "app-1"}})
+	assert.ErrorContains(t, err, "returned status 503")
+}
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eventsink")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.json")
+
+	sink := newFileSink(path)
+	assert.NilError(t, sink.Send([]*LifecycleEvent{{Kind: KindNode, NodeID: "node-1"}}))
+	assert.NilError(t, sink.Send([]*LifecycleEvent{{Kind: KindNode, NodeID: "node-2"}, {Kind: KindNode, NodeID: "node-3"}}))
+
+	f, err := os.Open(path)
+	assert.NilError(t, err)
+	defer f.Close()
+	nodes := make([]string, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event LifecycleEvent
+		assert.NilError(t, json.Unmarshal(scanner.Bytes(), &event))
+		nodes = append(nodes, event.NodeID)
+	}
+	assert.DeepEqual(t, nodes, []string{"node-1", "node-2", "node-3"})
+}
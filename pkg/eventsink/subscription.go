@@ -0,0 +1,89 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package eventsink
+
+import (
+	"sync"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+)
+
+// name of the subscriptions in the event sink metrics
+const subscriptionSinkName = "subscription"
+
+// Subscription receives the lifecycle events as they are published, e.g. for a client of the
+// event stream of the web service. Unlike the sinks there are no retries: an event is dropped
+// for a subscriber that does not keep up.
+type Subscription struct {
+	events chan *LifecycleEvent
+}
+
+var subscriptions = struct {
+	set map[*Subscription]struct{}
+	sync.RWMutex
+}{set: make(map[*Subscription]struct{})}
+
+// Subscribe starts delivering the published lifecycle events, up to size events are buffered
+// for the subscriber. The subscription must be closed when it is no longer used.
+func Subscribe(size int) *Subscription {
+	s := &Subscription{events: make(chan *LifecycleEvent, size)}
+	subscriptions.Lock()
+	subscriptions.set[s] = struct{}{}
+	subscriptions.Unlock()
+	return s
+}
+
+// Events returns the channel the events are delivered on, the channel is closed when the
+// subscription is closed or the event sinks are stopped.
+func (s *Subscription) Events() <-chan *LifecycleEvent {
+	return s.events
+}
+
+// Close stops delivering the events to the subscriber
+func (s *Subscription) Close() {
+	subscriptions.Lock()
+	defer subscriptions.Unlock()
+	if _, ok := subscriptions.set[s]; ok {
+		delete(subscriptions.set, s)
+		close(s.events)
+	}
+}
+
+// delivers the event to all subscribers, it never blocks
+func notifySubscribers(event *LifecycleEvent) {
+	subscriptions.RLock()
+	defer subscriptions.RUnlock()
+	for s := range subscriptions.set {
+		select {
+		case s.events <- event:
+		default:
+			metrics.GetEventSinkMetrics().AddEvents(subscriptionSinkName, metrics.SinkEventDropped, 1)
+		}
+	}
+}
+
+// closes all the subscriptions
+func closeSubscriptions() {
+	subscriptions.Lock()
+	defer subscriptions.Unlock()
+	for s := range subscriptions.set {
+		close(s.events)
+	}
+	subscriptions.set = make(map[*Subscription]struct{})
+}
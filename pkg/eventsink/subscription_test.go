@@ -0,0 +1,53 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package eventsink
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSubscription(t *testing.T) {
+	s1 := Subscribe(2)
+	s2 := Subscribe(1)
+	Publish(&LifecycleEvent{Kind: KindTask, TaskID: "task01", Event: "InitTask"})
+	Publish(&LifecycleEvent{Kind: KindTask, TaskID: "task02", Event: "InitTask"})
+
+	event := <-s1.Events()
+	assert.Equal(t, event.TaskID, "task01")
+	assert.Assert(t, !event.Time.IsZero())
+	assert.Equal(t, (<-s1.Events()).TaskID, "task02")
+	// the second event is dropped for the subscriber with a full buffer
+	assert.Equal(t, (<-s2.Events()).TaskID, "task01")
+	assert.Equal(t, len(s2.Events()), 0)
+
+	s2.Close()
+	s2.Close()
+	_, ok := <-s2.Events()
+	assert.Assert(t, !ok)
+	Publish(&LifecycleEvent{Kind: KindNode, NodeID: "node01"})
+	assert.Equal(t, (<-s1.Events()).NodeID, "node01")
+
+	// stopping the sinks closes the subscriptions
+	Stop()
+	_, ok = <-s1.Events()
+	assert.Assert(t, !ok)
+	s1.Close()
+}
@@ -0,0 +1,216 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package health
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/dispatcher"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+)
+
+// the names of the health checks, the dispatcher and informers checks are only used by the probes
+const (
+	CheckCoreRegistered    = "coreRegistered"
+	CheckInformersSynced   = "informersSynced"
+	CheckDispatcherQueues  = "dispatcherQueues"
+	CheckAPIServerThrottle = "apiServerNotThrottled"
+	CheckMaintenance       = "notInMaintenance"
+	CheckDispatcher        = "dispatcher"
+	CheckInformers         = "informers"
+)
+
+// the status of the scheduler in the health report
+const (
+	StatusHealthy   = "healthy"
+	StatusDegraded  = "degraded"
+	StatusUnhealthy = "unhealthy"
+)
+
+// Check is the result of one of the health checks
+type Check struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	Message string `json:"message,omitempty"`
+}
+
+// Report is the composite health of the scheduler. The score is the fraction of the checks
+// that pass: a scheduler that is alive but degraded has a score between 0 and 1.
+// The scheduler is unhealthy when it is not registered with the core, as it cannot
+// schedule anything, and degraded when any of the other checks fails. The notes list the optional
+// features that were disabled at startup, these do not change the score.
+type Report struct {
+	Score  float64  `json:"score"`
+	Status string   `json:"status"`
+	Checks []*Check `json:"checks"`
+	Notes  []string `json:"notes,omitempty"`
+}
+
+var sources struct {
+	schedulerState func() string
+	maintenance    func() bool
+	sync.RWMutex
+}
+
+// Init sets the sources of the scheduler state and of the maintenance mode,
+// and exposes the health checks as metrics
+func Init(stateSource func() string, maintenanceSource func() bool) {
+	sources.Lock()
+	sources.schedulerState = stateSource
+	sources.maintenance = maintenanceSource
+	sources.Unlock()
+	metrics.GetHealthMetrics().SetSource(func() (float64, map[string]bool) {
+		report := GetReport()
+		checks := make(map[string]bool, len(report.Checks))
+		for _, check := range report.Checks {
+			checks[check.Name] = check.Healthy
+		}
+		return report.Score, checks
+	})
+}
+
+// returns the state of the scheduler, empty when the source is not set
+func getSchedulerState() string {
+	sources.RLock()
+	source := sources.schedulerState
+	sources.RUnlock()
+	if source == nil {
+		return ""
+	}
+	return source()
+}
+
+// returns whether the scheduling is paused, false when the source is not set
+func inMaintenance() bool {
+	sources.RLock()
+	source := sources.maintenance
+	sources.RUnlock()
+	return source != nil && source()
+}
+
+// GetReport runs the health checks, the checks only read the state of the shim
+func GetReport() *Report {
+	state := getSchedulerState()
+	configs := conf.GetSchedulerConf()
+	configs.RLock()
+	capacity := configs.EventChannelCapacity
+	threshold := configs.HealthQueueThreshold
+	configs.RUnlock()
+	report := evaluate(state, client.GetInformerHealth(), dispatcher.GetHealth(),
+		client.GetThrottleState(), int(float64(capacity)*threshold), inMaintenance())
+	report.Notes = getNotes(client.GetDisabledFeatures())
+	return report
+}
+
+func getNotes(disabled []*client.DisabledFeature) []string {
+	var notes []string
+	for _, feature := range disabled {
+		notes = append(notes, fmt.Sprintf("feature %s is disabled: %s", feature.Feature, feature.Reason))
+	}
+	return notes
+}
+
+func evaluate(state string, informers *client.InformersHealth, dispatch *dispatcher.Health,
+	throttle *client.ThrottleState, maxPending int, maintenance bool) *Report {
+	checks := []*Check{
+		checkCoreRegistered(state),
+		checkInformersSynced(informers),
+		checkDispatcherQueues(dispatch, maxPending),
+		checkAPIServerThrottle(throttle),
+		checkMaintenance(maintenance),
+	}
+	report := &Report{
+		Status: StatusHealthy,
+		Checks: checks,
+	}
+	passed := 0
+	for _, check := range checks {
+		if check.Healthy {
+			passed++
+		} else if report.Status == StatusHealthy {
+			report.Status = StatusDegraded
+		}
+	}
+	report.Score = float64(passed) / float64(len(checks))
+	if !checks[0].Healthy {
+		report.Status = StatusUnhealthy
+	}
+	return report
+}
+
+func checkCoreRegistered(state string) *Check {
+	check := &Check{Name: CheckCoreRegistered}
+	states := events.States().Scheduler
+	switch state {
+	case states.Registered, states.Recovering, states.Running, states.Draining:
+		check.Healthy = true
+	default:
+		check.Message = fmt.Sprintf("scheduler is not registered with the core, state %q", state)
+	}
+	return check
+}
+
+func checkInformersSynced(health *client.InformersHealth) *Check {
+	check := &Check{Name: CheckInformersSynced, Healthy: health.Healthy}
+	if !check.Healthy {
+		for _, informer := range health.Informers {
+			if !informer.Healthy {
+				check.Message = fmt.Sprintf("informer %s is not healthy", informer.Resource)
+				break
+			}
+		}
+	}
+	return check
+}
+
+func checkDispatcherQueues(health *dispatcher.Health, maxPending int) *Check {
+	check := &Check{Name: CheckDispatcherQueues}
+	switch {
+	case !health.Running:
+		check.Message = "dispatcher is not running"
+	case health.PendingEvents >= maxPending:
+		check.Message = fmt.Sprintf("%d events pending in the dispatcher queues, threshold is %d", health.PendingEvents, maxPending)
+	default:
+		check.Healthy = true
+	}
+	return check
+}
+
+func checkMaintenance(maintenance bool) *Check {
+	check := &Check{Name: CheckMaintenance, Healthy: !maintenance}
+	if maintenance {
+		check.Message = "scheduling is paused, the scheduler is in maintenance mode"
+	}
+	return check
+}
+
+func checkAPIServerThrottle(state *client.ThrottleState) *Check {
+	check := &Check{Name: CheckAPIServerThrottle, Healthy: !state.Throttled}
+	if state.Throttled {
+		check.Message = "api-server is throttling the requests of the shim"
+		if state.LastPriorityLevel != "" {
+			check.Message += ", priority level UID " + state.LastPriorityLevel
+		}
+	}
+	return check
+}
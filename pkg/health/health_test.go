@@ -0,0 +1,83 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package health
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/dispatcher"
+)
+
+func TestEvaluate(t *testing.T) {
+	running := events.States().Scheduler.Running
+	healthyInformers := &client.InformersHealth{Healthy: true}
+	runningDispatcher := &dispatcher.Health{Running: true, PendingEvents: 10}
+	notThrottled := &client.ThrottleState{}
+
+	report := evaluate(running, healthyInformers, runningDispatcher, notThrottled, 100, false)
+	assert.Equal(t, report.Score, 1.0)
+	assert.Equal(t, report.Status, StatusHealthy)
+	assert.Equal(t, len(report.Checks), 5)
+
+	// queues above the threshold and api-server throttling: degraded
+	busyDispatcher := &dispatcher.Health{Running: true, PendingEvents: 100}
+	throttled := &client.ThrottleState{Throttled: true}
+	report = evaluate(running, healthyInformers, busyDispatcher, throttled, 100, false)
+	assert.Equal(t, report.Score, 0.6)
+	assert.Equal(t, report.Status, StatusDegraded)
+	assert.Equal(t, report.Checks[2].Message, "100 events pending in the dispatcher queues, threshold is 100")
+	assert.Equal(t, report.Checks[3].Healthy, false)
+
+	// an informer that is not synced
+	failingInformers := &client.InformersHealth{
+		Informers: []*client.InformerHealth{{Resource: "pods", Healthy: true}, {Resource: "nodes"}},
+	}
+	report = evaluate(running, failingInformers, runningDispatcher, notThrottled, 100, false)
+	assert.Equal(t, report.Score, 0.8)
+	assert.Equal(t, report.Status, StatusDegraded)
+	assert.Equal(t, report.Checks[1].Message, "informer nodes is not healthy")
+
+	// paused scheduling: degraded
+	report = evaluate(running, healthyInformers, runningDispatcher, notThrottled, 100, true)
+	assert.Equal(t, report.Score, 0.8)
+	assert.Equal(t, report.Status, StatusDegraded)
+	assert.Equal(t, report.Checks[4].Message, "scheduling is paused, the scheduler is in maintenance mode")
+
+	// not registered with the core: unhealthy, also when the other checks pass
+	report = evaluate(events.States().Scheduler.Registering, healthyInformers, runningDispatcher, notThrottled, 100, false)
+	assert.Equal(t, report.Score, 0.8)
+	assert.Equal(t, report.Status, StatusUnhealthy)
+	assert.Equal(t, report.Checks[0].Healthy, false)
+}
+
+func TestGetReportWithoutSource(t *testing.T) {
+	report := GetReport()
+	assert.Equal(t, report.Status, StatusUnhealthy)
+	assert.Equal(t, report.Checks[0].Message, "scheduler is not registered with the core, state \"\"")
+}
+
+func TestGetNotes(t *testing.T) {
+	assert.Equal(t, len(getNotes(nil)), 0)
+	notes := getNotes([]*client.DisabledFeature{{Feature: client.FeatureEvents, Reason: "not allowed to create events"}})
+	assert.DeepEqual(t, notes, []string{"feature events is disabled: not allowed to create events"})
+}
@@ -0,0 +1,127 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package health
+
+import (
+	"fmt"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/dispatcher"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+)
+
+// Probe is the result of the liveness or the readiness probe of the shim, the probe passes
+// when all the checks pass. A shim that is starting is alive but not ready, a shim that is
+// broken is not alive and must be restarted.
+type Probe struct {
+	Healthy bool     `json:"healthy"`
+	Checks  []*Check `json:"checks"`
+}
+
+func newProbe(checks ...*Check) *Probe {
+	probe := &Probe{Healthy: true, Checks: checks}
+	for _, check := range checks {
+		probe.Healthy = probe.Healthy && check.Healthy
+	}
+	return probe
+}
+
+// Liveness fails when the shim cannot recover without a restart: the scheduler stopped,
+// the dispatcher stopped after the shim started, or an informer that cannot be restarted failed.
+func Liveness() *Probe {
+	state := getSchedulerState()
+	return newProbe(
+		liveCore(state),
+		liveDispatcher(state, dispatcher.GetHealth()),
+		liveInformers(client.GetInformerHealth()),
+	)
+}
+
+// Readiness fails until the shim can schedule: the informer caches are synced, the scheduler
+// registered with the core and recovered the existing allocations, and the dispatcher runs.
+// While the scheduler recovers, the check of the core reports the progress of the recovery.
+func Readiness() *Probe {
+	state := getSchedulerState()
+	core := readyCore(state)
+	if state == events.States().Scheduler.Recovering {
+		recovery := metrics.GetRecoveryMetrics()
+		core.Message += ", " + describeRecovery(recovery.GetProgress(metrics.ObjectNode),
+			recovery.GetProgress(metrics.ObjectApplication))
+	}
+	return newProbe(
+		core,
+		readyDispatcher(dispatcher.GetHealth()),
+		checkInformersSynced(client.GetInformerHealth()),
+	)
+}
+
+func liveCore(state string) *Check {
+	check := &Check{Name: CheckCoreRegistered, Healthy: true}
+	if state == events.States().Scheduler.Stopped {
+		check.Healthy = false
+		check.Message = "scheduler is stopped"
+	}
+	return check
+}
+
+func readyCore(state string) *Check {
+	check := &Check{Name: CheckCoreRegistered, Healthy: true}
+	if state != events.States().Scheduler.Running {
+		check.Healthy = false
+		check.Message = fmt.Sprintf("scheduler is not running, state %q", state)
+	}
+	return check
+}
+
+func describeRecovery(nodes, apps metrics.RecoveryProgress) string {
+	return fmt.Sprintf("recovered %d of %d nodes and %d of %d applications",
+		nodes.Recovered, nodes.Total, apps.Recovered, apps.Total)
+}
+
+// the dispatcher is started before the scheduler registers with the core
+func liveDispatcher(state string, health *dispatcher.Health) *Check {
+	check := &Check{Name: CheckDispatcher, Healthy: true}
+	if !health.Running && state != "" && state != events.States().Scheduler.New {
+		check.Healthy = false
+		check.Message = "dispatcher is not running"
+	}
+	return check
+}
+
+func readyDispatcher(health *dispatcher.Health) *Check {
+	check := &Check{Name: CheckDispatcher, Healthy: health.Running}
+	if !health.Running {
+		check.Message = "dispatcher is not running"
+	}
+	return check
+}
+
+// the watchdog restarts the failing informers that can be restarted, the other ones only recover with the shim
+func liveInformers(health *client.InformersHealth) *Check {
+	check := &Check{Name: CheckInformers, Healthy: true}
+	for _, informer := range health.Informers {
+		if !informer.Healthy && informer.FailingSince != nil && !informer.Restartable {
+			check.Healthy = false
+			check.Message = fmt.Sprintf("informer %s failed and cannot be restarted", informer.Resource)
+			break
+		}
+	}
+	return check
+}
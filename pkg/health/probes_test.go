@@ -0,0 +1,78 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package health
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/dispatcher"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+)
+
+func TestLivenessChecks(t *testing.T) {
+	states := events.States().Scheduler
+	assert.Assert(t, liveCore(states.Registering).Healthy)
+	assert.Assert(t, !liveCore(states.Stopped).Healthy)
+
+	// the dispatcher is not started yet
+	stopped := &dispatcher.Health{}
+	assert.Assert(t, liveDispatcher("", stopped).Healthy)
+	assert.Assert(t, liveDispatcher(states.New, stopped).Healthy)
+	assert.Assert(t, !liveDispatcher(states.Running, stopped).Healthy)
+	assert.Assert(t, liveDispatcher(states.Running, &dispatcher.Health{Running: true}).Healthy)
+
+	// informers that are syncing or can be restarted by the watchdog are alive
+	since := time.Now()
+	informers := &client.InformersHealth{
+		Informers: []*client.InformerHealth{
+			{Resource: "pods"},
+			{Resource: "nodes", FailingSince: &since, Restartable: true},
+		},
+	}
+	assert.Assert(t, liveInformers(informers).Healthy)
+	informers.Informers = append(informers.Informers, &client.InformerHealth{Resource: "priorityclasses", FailingSince: &since})
+	check := liveInformers(informers)
+	assert.Assert(t, !check.Healthy)
+	assert.Equal(t, check.Message, "informer priorityclasses failed and cannot be restarted")
+}
+
+func TestReadinessChecks(t *testing.T) {
+	states := events.States().Scheduler
+	for _, state := range []string{"", states.New, states.Registering, states.Registered, states.Recovering, states.Stopped} {
+		assert.Assert(t, !readyCore(state).Healthy, "scheduler is ready in state %s", state)
+	}
+	assert.Assert(t, readyCore(states.Running).Healthy)
+	assert.Assert(t, !readyDispatcher(&dispatcher.Health{}).Healthy)
+	assert.Assert(t, readyDispatcher(&dispatcher.Health{Running: true}).Healthy)
+	assert.Equal(t, describeRecovery(metrics.RecoveryProgress{Recovered: 3, Total: 10}, metrics.RecoveryProgress{Recovered: 5, Total: 5}),
+		"recovered 3 of 10 nodes and 5 of 5 applications")
+}
+
+func TestNewProbe(t *testing.T) {
+	probe := newProbe(&Check{Name: "a", Healthy: true}, &Check{Name: "b", Healthy: true})
+	assert.Assert(t, probe.Healthy)
+	probe = newProbe(&Check{Name: "a", Healthy: true}, &Check{Name: "b"})
+	assert.Assert(t, !probe.Healthy)
+	assert.Equal(t, len(probe.Checks), 2)
+}
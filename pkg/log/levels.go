@@ -0,0 +1,191 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package log
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// the subsystems of the shim that have their own log level
+const (
+	Cache      = "cache"
+	AppMgmt    = "appmgmt"
+	Dispatcher = "dispatcher"
+	Predicates = "predicates"
+	Client     = "client"
+)
+
+// the level of a subsystem is set in the scheduler ConfigMap with the key log.<subsystem>.level
+const (
+	levelKeyPrefix = "log."
+	levelKeySuffix = ".level"
+)
+
+// the level of a subsystem that follows the level of the shim
+const levelUnset = int32(math.MinInt32)
+
+// the log level of a subsystem, the messages below the level are dropped
+type subsystemLevel struct {
+	level int32
+}
+
+func (s *subsystemLevel) Enabled(level zapcore.Level) bool {
+	if l := atomic.LoadInt32(&s.level); l != levelUnset {
+		return level >= zapcore.Level(l)
+	}
+	return zapConfigs.Level.Enabled(level)
+}
+
+var subsystems = map[string]*subsystemLevel{
+	Cache:      {level: levelUnset},
+	AppMgmt:    {level: levelUnset},
+	Dispatcher: {level: levelUnset},
+	Predicates: {level: levelUnset},
+	Client:     {level: levelUnset},
+}
+
+var subsystemLoggers map[string]*zap.Logger
+
+// filters the entries of the wrapped core with a level that can change at runtime,
+// the wrapped core must accept all levels
+type levelCore struct {
+	zapcore.Core
+	enabler zapcore.LevelEnabler
+}
+
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return c.enabler.Enabled(level)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), enabler: c.enabler}
+}
+
+func (c *levelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(entry.Level) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
+
+func withLevel(base *zap.Logger, enabler zapcore.LevelEnabler) *zap.Logger {
+	return base.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &levelCore{Core: core, enabler: enabler}
+	}))
+}
+
+// creates the loggers of the subsystems from the logger that accepts all levels
+func initSubsystemLoggers(base *zap.Logger) {
+	subsystemLoggers = make(map[string]*zap.Logger, len(subsystems))
+	for name, level := range subsystems {
+		subsystemLoggers[name] = withLevel(base.Named(name), level)
+	}
+}
+
+// Log returns the logger of the subsystem, the shim logger is returned for an unknown subsystem
+func Log(subsystem string) *zap.Logger {
+	once.Do(initLogger)
+	if l, ok := subsystemLoggers[subsystem]; ok {
+		return l
+	}
+	return logger
+}
+
+// LevelInfo is the log level of a subsystem
+type LevelInfo struct {
+	Subsystem string `json:"subsystem"`
+	Level     string `json:"level"`
+	// true when the subsystem has no level of its own and follows the level of the shim
+	Inherited bool `json:"inherited"`
+}
+
+// GetLevels returns the log levels of all the subsystems, sorted by subsystem
+func GetLevels() []*LevelInfo {
+	once.Do(initLogger)
+	levels := make([]*LevelInfo, 0, len(subsystems))
+	for name, s := range subsystems {
+		info := &LevelInfo{Subsystem: name}
+		if l := atomic.LoadInt32(&s.level); l != levelUnset {
+			info.Level = zapcore.Level(l).String()
+		} else {
+			info.Level = zapConfigs.Level.Level().String()
+			info.Inherited = true
+		}
+		levels = append(levels, info)
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		return levels[i].Subsystem < levels[j].Subsystem
+	})
+	return levels
+}
+
+// SetLevel changes the log level of the subsystem, e.g. to debug, an empty level makes the
+// subsystem follow the level of the shim again
+func SetLevel(subsystem, level string) error {
+	s, ok := subsystems[subsystem]
+	if !ok {
+		return fmt.Errorf("unknown log subsystem %s", subsystem)
+	}
+	if level == "" {
+		atomic.StoreInt32(&s.level, levelUnset)
+		return nil
+	}
+	var l zapcore.Level
+	if err := l.UnmarshalText([]byte(strings.ToLower(level))); err != nil {
+		return fmt.Errorf("invalid log level %s for subsystem %s", level, subsystem)
+	}
+	atomic.StoreInt32(&s.level, int32(l))
+	return nil
+}
+
+// GetConfigLevels returns the levels of the subsystems set in the scheduler ConfigMap data
+func GetConfigLevels(data map[string]string) map[string]string {
+	levels := make(map[string]string)
+	for key, value := range data {
+		if len(key) > len(levelKeyPrefix)+len(levelKeySuffix) &&
+			strings.HasPrefix(key, levelKeyPrefix) && strings.HasSuffix(key, levelKeySuffix) {
+			levels[key[len(levelKeyPrefix):len(key)-len(levelKeySuffix)]] = value
+		}
+	}
+	return levels
+}
+
+// SetConfigLevels applies the levels from the scheduler ConfigMap, the subsystems without a
+// level in the ConfigMap follow the level of the shim
+func SetConfigLevels(levels map[string]string) {
+	for name := range subsystems {
+		if err := SetLevel(name, levels[name]); err != nil {
+			Logger().Warn("ignoring the log level from the configuration", zap.Error(err))
+		}
+	}
+	for name := range levels {
+		if _, ok := subsystems[name]; !ok {
+			Logger().Warn("ignoring the log level of an unknown subsystem",
+				zap.String("subsystem", name))
+		}
+	}
+	Logger().Info("log levels changed", zap.Any("levels", GetLevels()))
+}
@@ -0,0 +1,88 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package log
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gotest.tools/assert"
+)
+
+func TestSubsystemLevel(t *testing.T) {
+	Logger()
+	defer func() {
+		assert.NilError(t, SetLevel(Cache, ""))
+	}()
+	core, logs := observer.New(zapcore.DebugLevel)
+	cacheLogger := withLevel(zap.New(core), subsystems[Cache])
+
+	// follows the level of the shim
+	shimLevel := zapConfigs.Level.Level()
+	assert.Assert(t, shimLevel > zapcore.DebugLevel, "the tests expect the shim not to log at debug level")
+	cacheLogger.Debug("dropped")
+	cacheLogger.Check(shimLevel, "logged").Write()
+	assert.Equal(t, logs.Len(), 1)
+
+	assert.NilError(t, SetLevel(Cache, "DEBUG"))
+	cacheLogger.With(zap.String("key", "value")).Debug("debug")
+	assert.Equal(t, logs.Len(), 2)
+	assert.Equal(t, logs.All()[1].Message, "debug")
+
+	assert.NilError(t, SetLevel(Cache, "error"))
+	cacheLogger.Warn("dropped")
+	assert.Equal(t, logs.Len(), 2)
+
+	assert.NilError(t, SetLevel(Cache, ""))
+	cacheLogger.Debug("dropped")
+	assert.Equal(t, logs.Len(), 2)
+}
+
+func TestSetLevel(t *testing.T) {
+	defer func() {
+		assert.NilError(t, SetLevel(Client, ""))
+	}()
+	assert.ErrorContains(t, SetLevel("unknown", "debug"), "unknown log subsystem unknown")
+	assert.ErrorContains(t, SetLevel(Client, "verbose"), "invalid log level verbose for subsystem client")
+	assert.NilError(t, SetLevel(Client, "warn"))
+	for _, level := range GetLevels() {
+		if level.Subsystem == Client {
+			assert.Equal(t, level.Level, "warn")
+			assert.Assert(t, !level.Inherited)
+		} else {
+			assert.Assert(t, level.Inherited, "level of %s is not inherited", level.Subsystem)
+		}
+	}
+}
+
+func TestGetConfigLevels(t *testing.T) {
+	levels := GetConfigLevels(map[string]string{
+		"queues.yaml":          "partitions: []",
+		"log.cache.level":      "debug",
+		"log.predicates.level": "info",
+		"log..level":           "debug",
+		"log.level":            "debug",
+	})
+	assert.DeepEqual(t, levels, map[string]string{
+		Cache:      "debug",
+		Predicates: "info",
+	})
+}
@@ -72,13 +72,19 @@ func initLogger() {
 		ErrorOutputPaths: []string{"stderr"},
 	}
 
-	var err error
-	logger, err = zapConfigs.Build()
+	// the logger is built for all levels, the levels of the shim and of each subsystem
+	// are applied on top of it and can be changed at runtime
+	baseConfigs := *zapConfigs
+	baseConfigs.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	base, err := baseConfigs.Build()
 	// this should really not happen so just write to stdout and set a Nop logger
 	if err != nil {
 		fmt.Printf("Logging disabled, logger init failed with error: %v", err)
-		logger = zap.NewNop()
+		base = zap.NewNop()
 	}
+	logger = withLevel(base, zapConfigs.Level)
+	initSubsystemLoggers(base)
+	initSampledLoggers(configs.LogSampleRate)
 
 	// dump configuration
 	var c []byte
@@ -97,3 +103,17 @@ func initLogger() {
 func GetZapConfigs() *zap.Config {
 	return zapConfigs
 }
+
+// GetCorrelationID returns the ID that ties the logs and the events of an application, or of a
+// task when the task ID is set, together.
+func GetCorrelationID(appID, taskID string) string {
+	if taskID == "" {
+		return appID
+	}
+	return appID + "/" + taskID
+}
+
+// CorrelationID returns the log field with the correlation ID of an application or a task
+func CorrelationID(appID, taskID string) zap.Field {
+	return zap.String("correlationID", GetCorrelationID(appID, taskID))
+}
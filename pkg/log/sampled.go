@@ -0,0 +1,81 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package log
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// only 1 in sampleRate of the entries below the info level is written by the sampled loggers
+var sampleRate = uint64(1)
+
+var sampledLoggers map[string]*zap.Logger
+
+// drops the debug entries of the wrapped core except for 1 in sampleRate, the entries
+// at the info level and above are not sampled. The loggers derived with With share the count.
+type sampledCore struct {
+	zapcore.Core
+	count *uint64
+}
+
+func (c *sampledCore) With(fields []zapcore.Field) zapcore.Core {
+	return &sampledCore{Core: c.Core.With(fields), count: c.count}
+}
+
+func (c *sampledCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if entry.Level < zapcore.InfoLevel && c.Core.Enabled(entry.Level) {
+		rate := atomic.LoadUint64(&sampleRate)
+		if rate > 1 && (atomic.AddUint64(c.count, 1)-1)%rate != 0 {
+			return checked
+		}
+	}
+	return c.Core.Check(entry, checked)
+}
+
+func withSampling(l *zap.Logger) *zap.Logger {
+	return l.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &sampledCore{Core: core, count: new(uint64)}
+	}))
+}
+
+// creates the sampled loggers from the loggers of the shim and of the subsystems
+func initSampledLoggers(rate int) {
+	if rate > 1 {
+		atomic.StoreUint64(&sampleRate, uint64(rate))
+	}
+	sampledLoggers = make(map[string]*zap.Logger, len(subsystemLoggers)+1)
+	for name, l := range subsystemLoggers {
+		sampledLoggers[name] = withSampling(l)
+	}
+	// the shim logger, for the unknown subsystems
+	sampledLoggers[""] = withSampling(logger)
+}
+
+// Sampled returns the logger of the subsystem for the per-pod debug messages of the hot paths,
+// only 1 in logSampleRate of these messages is logged. The shim logger is sampled for an unknown subsystem.
+func Sampled(subsystem string) *zap.Logger {
+	once.Do(initLogger)
+	if l, ok := sampledLoggers[subsystem]; ok {
+		return l
+	}
+	return sampledLoggers[""]
+}
@@ -0,0 +1,63 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package log
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gotest.tools/assert"
+)
+
+func TestSampledCore(t *testing.T) {
+	defer atomic.StoreUint64(&sampleRate, atomic.LoadUint64(&sampleRate))
+	core, logs := observer.New(zapcore.DebugLevel)
+	sampled := withSampling(zap.New(core))
+
+	// not sampled by default
+	atomic.StoreUint64(&sampleRate, 1)
+	for i := 0; i < 3; i++ {
+		sampled.Debug("debug")
+	}
+	assert.Equal(t, logs.Len(), 3)
+
+	// 1 in 3 debug messages, also through the derived loggers
+	atomic.StoreUint64(&sampleRate, 3)
+	for i := 0; i < 6; i++ {
+		sampled.Debug("debug")
+		sampled.With(zap.Int("i", i)).Debug("debug")
+	}
+	assert.Equal(t, logs.Len(), 7)
+
+	// info and above are never sampled
+	for i := 0; i < 3; i++ {
+		sampled.Info("info")
+		sampled.Warn("warn")
+	}
+	assert.Equal(t, logs.Len(), 13)
+}
+
+func TestSampled(t *testing.T) {
+	assert.Assert(t, Sampled(Cache) != nil)
+	assert.Assert(t, Sampled(Cache) == Sampled(Cache), "the sampled logger of a subsystem is shared")
+	assert.Assert(t, Sampled("unknown") != nil)
+}
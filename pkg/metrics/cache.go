@@ -0,0 +1,160 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// the caches of the shim that report their size
+const (
+	CacheScheduler = "scheduler"
+	CacheContext   = "context"
+)
+
+// CacheSource returns the number of objects in a cache by kind of object, e.g. pods and nodes
+type CacheSource func() map[string]int
+
+// CacheMetrics tracks the size of the caches of the shim and the events of the informers that fill them.
+// The sizes are read from the caches when the metrics are collected, the memory is set by the memory accounting.
+type CacheMetrics struct {
+	objects            *cacheObjectsCollector
+	informerLastEvent  *prometheus.GaugeVec
+	informerEventDelay *prometheus.HistogramVec
+	memory             *prometheus.GaugeVec
+	memoryLimit        prometheus.Gauge
+	evictions          *prometheus.CounterVec
+}
+
+// collects the size of the caches from their sources
+type cacheObjectsCollector struct {
+	desc    *prometheus.Desc
+	sources map[string]CacheSource
+	sync.RWMutex
+}
+
+func newCacheMetrics() *CacheMetrics {
+	return &CacheMetrics{
+		objects: &cacheObjectsCollector{
+			desc: prometheus.NewDesc(
+				prometheus.BuildFQName(Namespace, Subsystem, "cache_objects"),
+				"Number of objects held in the caches of the shim, by cache and kind of object.",
+				[]string{"cache", "object"}, nil),
+			sources: make(map[string]CacheSource),
+		},
+		informerLastEvent: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "informer_last_event_timestamp_seconds",
+				Help:      "Unix time of the last event delivered by the informers, by resource.",
+			}, []string{"resource"}),
+		informerEventDelay: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "informer_add_event_delay_seconds",
+				Help:      "Time from the creation of an object until the informer delivered it, by resource, with a precision of one second.",
+				Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12),
+			}, []string{"resource"}),
+		memory: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "cache_memory_bytes",
+				Help:      "Approximate memory held by the caches of the shim, by cache.",
+			}, []string{"cache"}),
+		memoryLimit: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "cache_memory_limit_bytes",
+				Help:      "Approximate memory the caches of the shim can hold before the terminal objects are removed, 0 when there is no limit.",
+			}),
+		evictions: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "cache_memory_evictions_total",
+				Help:      "Total number of terminal objects removed from the caches because they exceeded the memory limit, by kind of object.",
+			}, []string{"object"}),
+	}
+}
+
+func (m *CacheMetrics) register(registerer prometheus.Registerer) {
+	for _, collector := range []prometheus.Collector{m.objects, m.informerLastEvent, m.informerEventDelay,
+		m.memory, m.memoryLimit, m.evictions} {
+		if err := registerer.Register(collector); err != nil {
+			log.Logger().Warn("failed to register cache metrics", zap.Error(err))
+		}
+	}
+}
+
+// SetSource sets the source of the size of the cache, it replaces the current source of the cache
+func (m *CacheMetrics) SetSource(cache string, source CacheSource) {
+	m.objects.Lock()
+	defer m.objects.Unlock()
+	m.objects.sources[cache] = source
+}
+
+func (m *CacheMetrics) SetInformerLastEvent(resource string, eventTime time.Time) {
+	m.informerLastEvent.WithLabelValues(resource).Set(float64(eventTime.UnixNano()) / float64(time.Second))
+}
+
+func (m *CacheMetrics) ObserveInformerEventDelay(resource string, delay time.Duration) {
+	m.informerEventDelay.WithLabelValues(resource).Observe(delay.Seconds())
+}
+
+func (m *CacheMetrics) SetMemory(cache string, bytes int64) {
+	m.memory.WithLabelValues(cache).Set(float64(bytes))
+}
+
+func (m *CacheMetrics) SetMemoryLimit(bytes int64) {
+	m.memoryLimit.Set(float64(bytes))
+}
+
+func (m *CacheMetrics) AddEvictions(object string, count int) {
+	m.evictions.WithLabelValues(object).Add(float64(count))
+}
+
+func (c *cacheObjectsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *cacheObjectsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.RLock()
+	defer c.RUnlock()
+	caches := make([]string, 0, len(c.sources))
+	for cache := range c.sources {
+		caches = append(caches, cache)
+	}
+	sort.Strings(caches)
+	for _, cache := range caches {
+		for object, count := range c.sources[cache]() {
+			ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count), cache, object)
+		}
+	}
+}
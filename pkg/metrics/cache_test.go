@@ -0,0 +1,67 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestCacheMetrics(t *testing.T) {
+	m := newCacheMetrics()
+	m.register(prometheus.NewRegistry())
+	m.SetSource(CacheScheduler, func() map[string]int {
+		return map[string]int{"nodes": 2, "pods": 10}
+	})
+	m.SetSource(CacheContext, func() map[string]int {
+		return map[string]int{"applications": 1}
+	})
+	expected := `
+# HELP yunikorn_k8s_shim_cache_objects Number of objects held in the caches of the shim, by cache and kind of object.
+# TYPE yunikorn_k8s_shim_cache_objects gauge
+yunikorn_k8s_shim_cache_objects{cache="context",object="applications"} 1
+yunikorn_k8s_shim_cache_objects{cache="scheduler",object="nodes"} 2
+yunikorn_k8s_shim_cache_objects{cache="scheduler",object="pods"} 10
+`
+	assert.NilError(t, testutil.CollectAndCompare(m.objects, strings.NewReader(expected)))
+
+	// the source of a cache is replaced
+	m.SetSource(CacheContext, func() map[string]int {
+		return map[string]int{"applications": 3}
+	})
+	assert.Equal(t, testutil.CollectAndCount(m.objects), 3)
+
+	m.SetInformerLastEvent("pods", time.Unix(100, 0))
+	m.ObserveInformerEventDelay("pods", time.Second)
+	assert.Equal(t, testutil.ToFloat64(m.informerLastEvent.WithLabelValues("pods")), float64(100))
+	assert.Equal(t, testutil.CollectAndCount(m.informerEventDelay), 1)
+
+	m.SetMemory(CacheScheduler, 4096)
+	m.SetMemoryLimit(1 << 20)
+	m.AddEvictions("pods", 3)
+	m.AddEvictions("pods", 2)
+	assert.Equal(t, testutil.ToFloat64(m.memory.WithLabelValues(CacheScheduler)), float64(4096))
+	assert.Equal(t, testutil.ToFloat64(m.memoryLimit), float64(1<<20))
+	assert.Equal(t, testutil.ToFloat64(m.evictions.WithLabelValues("pods")), float64(5))
+}
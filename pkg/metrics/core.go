@@ -0,0 +1,112 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// the result of a successful call to the core, the failed calls have the class of the error as result
+const CoreCallSuccess = "success"
+
+// CoreAPIMetrics tracks the calls of the shim to the scheduler core, apart from the calls to the api-server
+type CoreAPIMetrics struct {
+	callLatency *prometheus.HistogramVec
+	payloadSize *prometheus.HistogramVec
+	errors      *prometheus.CounterVec
+	askBatch    prometheus.Histogram
+	coalesced   prometheus.Counter
+}
+
+func newCoreAPIMetrics() *CoreAPIMetrics {
+	return &CoreAPIMetrics{
+		callLatency: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "core_call_duration_seconds",
+				Help:      "Time the calls to the scheduler core took, by method and result.",
+				Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 10),
+			}, []string{"method", "result"}),
+		payloadSize: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "core_call_payload_bytes",
+				Help:      "Size of the requests sent to the scheduler core, by method.",
+				Buckets:   prometheus.ExponentialBuckets(64, 4, 10),
+			}, []string{"method"}),
+		errors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "core_call_errors_total",
+				Help:      "Total number of calls to the scheduler core that returned an error, by method and error class.",
+			}, []string{"method", "class"}),
+		askBatch: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "core_ask_batch_size",
+				Help:      "Number of allocation asks sent to the scheduler core in one batched request.",
+				Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+			}),
+		coalesced: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "core_asks_coalesced_total",
+				Help:      "Total number of allocation asks replaced by a later ask for the same allocation key before they were sent.",
+			}),
+	}
+}
+
+func (m *CoreAPIMetrics) register(registerer prometheus.Registerer) {
+	for _, collector := range []prometheus.Collector{m.callLatency, m.payloadSize, m.errors, m.askBatch, m.coalesced} {
+		if err := registerer.Register(collector); err != nil {
+			log.Logger().Warn("failed to register core API metrics", zap.Error(err))
+		}
+	}
+}
+
+// ObserveCall records a call to the core, the result is CoreCallSuccess or the class of the error
+func (m *CoreAPIMetrics) ObserveCall(method, result string, duration time.Duration) {
+	m.callLatency.WithLabelValues(method, result).Observe(duration.Seconds())
+	if result != CoreCallSuccess {
+		m.errors.WithLabelValues(method, result).Inc()
+	}
+}
+
+func (m *CoreAPIMetrics) ObservePayloadSize(method string, size int) {
+	m.payloadSize.WithLabelValues(method).Observe(float64(size))
+}
+
+// ObserveAskBatch records the number of asks sent to the core in one batched request
+func (m *CoreAPIMetrics) ObserveAskBatch(asks int) {
+	m.askBatch.Observe(float64(asks))
+}
+
+func (m *CoreAPIMetrics) IncCoalescedAsks() {
+	m.coalesced.Inc()
+}
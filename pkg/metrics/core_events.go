@@ -0,0 +1,87 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// the reasons the event records of the core are not published as Kubernetes events
+const (
+	EventDropTaskNotFound    = "task_not_found"
+	EventDropNodeNotFound    = "node_not_found"
+	EventDropUnsupportedType = "unsupported_type"
+)
+
+// CoreEventMetrics tracks the event records the core sends to the shim, and whether they were
+// published as Kubernetes events
+type CoreEventMetrics struct {
+	received  *prometheus.CounterVec
+	published *prometheus.CounterVec
+	dropped   *prometheus.CounterVec
+}
+
+func newCoreEventMetrics() *CoreEventMetrics {
+	return &CoreEventMetrics{
+		received: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "core_events_received_total",
+				Help:      "Total number of event records received from the core, by type of record.",
+			}, []string{"type"}),
+		published: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "core_events_published_total",
+				Help:      "Total number of event records of the core passed to the Kubernetes event recorder, by type of record.",
+			}, []string{"type"}),
+		dropped: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "core_events_dropped_total",
+				Help:      "Total number of event records of the core that were not published, by type of record and reason.",
+			}, []string{"type", "reason"}),
+	}
+}
+
+func (m *CoreEventMetrics) register(registerer prometheus.Registerer) {
+	for _, collector := range []prometheus.Collector{m.received, m.published, m.dropped} {
+		if err := registerer.Register(collector); err != nil {
+			log.Logger().Warn("failed to register core event metrics", zap.Error(err))
+		}
+	}
+}
+
+func (m *CoreEventMetrics) IncReceived(recordType string) {
+	m.received.WithLabelValues(recordType).Inc()
+}
+
+func (m *CoreEventMetrics) IncPublished(recordType string) {
+	m.published.WithLabelValues(recordType).Inc()
+}
+
+func (m *CoreEventMetrics) IncDropped(recordType, reason string) {
+	m.dropped.WithLabelValues(recordType, reason).Inc()
+}
@@ -0,0 +1,45 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestCoreAPIMetrics(t *testing.T) {
+	m := newCoreAPIMetrics()
+	m.register(prometheus.NewRegistry())
+	m.ObserveCall("UpdateAllocation", CoreCallSuccess, time.Millisecond)
+	m.ObserveCall("UpdateAllocation", "not_registered", time.Millisecond)
+	m.ObserveCall("UpdateNode", "not_registered", time.Millisecond)
+	m.ObservePayloadSize("UpdateAllocation", 1024)
+	assert.Equal(t, testutil.CollectAndCount(m.callLatency), 3)
+	assert.Equal(t, testutil.CollectAndCount(m.errors), 2)
+	assert.Equal(t, testutil.ToFloat64(m.errors.WithLabelValues("UpdateAllocation", "not_registered")), float64(1))
+	assert.Equal(t, testutil.CollectAndCount(m.payloadSize), 1)
+	m.ObserveAskBatch(10)
+	m.IncCoalescedAsks()
+	assert.Equal(t, testutil.CollectAndCount(m.askBatch), 1)
+	assert.Equal(t, testutil.ToFloat64(m.coalesced), float64(1))
+}
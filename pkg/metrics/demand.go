@@ -0,0 +1,94 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// PendingDemand is the demand of the pods of a queue in a namespace that wait for an allocation
+type PendingDemand struct {
+	Queue     string           `json:"queue"`
+	Namespace string           `json:"namespace"`
+	Pods      int              `json:"pods"`
+	Resources map[string]int64 `json:"resources"`
+}
+
+// DemandSource returns the pending demand of each queue and namespace
+type DemandSource func() []*PendingDemand
+
+// DemandMetrics exports the resources requested by the pods that are not allocated yet, for
+// autoscaler add-ons and capacity planners that do not read the pod conditions. The demand is
+// read from the source when the metrics are collected.
+type DemandMetrics struct {
+	pods      *prometheus.Desc
+	resources *prometheus.Desc
+	source    DemandSource
+	sync.RWMutex
+}
+
+func newDemandMetrics() *DemandMetrics {
+	return &DemandMetrics{
+		pods: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, Subsystem, "pending_demand_pods"),
+			"Number of pods waiting for an allocation, by queue and namespace.",
+			[]string{"queue", "namespace"}, nil),
+		resources: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, Subsystem, "pending_demand"),
+			"Resources requested by the pods waiting for an allocation, by queue, namespace and resource.",
+			[]string{"queue", "namespace", "resource"}, nil),
+	}
+}
+
+func (m *DemandMetrics) register(registerer prometheus.Registerer) {
+	if err := registerer.Register(m); err != nil {
+		log.Logger().Warn("failed to register demand metrics", zap.Error(err))
+	}
+}
+
+// SetSource sets the source of the pending demand, it replaces the current source
+func (m *DemandMetrics) SetSource(source DemandSource) {
+	m.Lock()
+	defer m.Unlock()
+	m.source = source
+}
+
+func (m *DemandMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.pods
+	ch <- m.resources
+}
+
+func (m *DemandMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.RLock()
+	defer m.RUnlock()
+	if m.source == nil {
+		return
+	}
+	for _, demand := range m.source() {
+		ch <- prometheus.MustNewConstMetric(m.pods, prometheus.GaugeValue, float64(demand.Pods), demand.Queue, demand.Namespace)
+		for resource, quantity := range demand.Resources {
+			ch <- prometheus.MustNewConstMetric(m.resources, prometheus.GaugeValue, float64(quantity), demand.Queue, demand.Namespace, resource)
+		}
+	}
+}
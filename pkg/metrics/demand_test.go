@@ -0,0 +1,53 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestDemandMetrics(t *testing.T) {
+	m := newDemandMetrics()
+	m.register(prometheus.NewRegistry())
+	assert.Equal(t, testutil.CollectAndCount(m), 0)
+
+	m.SetSource(func() []*PendingDemand {
+		return []*PendingDemand{
+			{Queue: "root.a", Namespace: "ns1", Pods: 2, Resources: map[string]int64{"memory": 2048, "vcore": 500}},
+			{Queue: "root.b", Namespace: "ns2", Pods: 1, Resources: map[string]int64{"vcore": 100}},
+		}
+	})
+	expected := `
+# HELP yunikorn_k8s_shim_pending_demand Resources requested by the pods waiting for an allocation, by queue, namespace and resource.
+# TYPE yunikorn_k8s_shim_pending_demand gauge
+yunikorn_k8s_shim_pending_demand{namespace="ns1",queue="root.a",resource="memory"} 2048
+yunikorn_k8s_shim_pending_demand{namespace="ns1",queue="root.a",resource="vcore"} 500
+yunikorn_k8s_shim_pending_demand{namespace="ns2",queue="root.b",resource="vcore"} 100
+# HELP yunikorn_k8s_shim_pending_demand_pods Number of pods waiting for an allocation, by queue and namespace.
+# TYPE yunikorn_k8s_shim_pending_demand_pods gauge
+yunikorn_k8s_shim_pending_demand_pods{namespace="ns1",queue="root.a"} 2
+yunikorn_k8s_shim_pending_demand_pods{namespace="ns2",queue="root.b"} 1
+`
+	assert.NilError(t, testutil.CollectAndCompare(m, strings.NewReader(expected)))
+}
@@ -0,0 +1,161 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// DispatcherMetrics tracks the event queue of the dispatcher and the backpressure on the producers
+type DispatcherMetrics struct {
+	queueLength     *prometheus.GaugeVec
+	workerQueue     *prometheus.GaugeVec
+	asyncDispatches prometheus.Gauge
+	blockedTime     *prometheus.HistogramVec
+	shedEvents      *prometheus.CounterVec
+	timeouts        prometheus.Counter
+	deadLetters     *prometheus.CounterVec
+	handlingTime    *prometheus.HistogramVec
+	handlerPanics   *prometheus.CounterVec
+}
+
+func newDispatcherMetrics() *DispatcherMetrics {
+	return &DispatcherMetrics{
+		queueLength: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "dispatcher_queue_length",
+				Help:      "Number of events in the event channels of the dispatcher, by priority lane.",
+			}, []string{"lane"}),
+		workerQueue: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "dispatcher_worker_queue_length",
+				Help:      "Number of events in the queues of the dispatcher workers, by worker.",
+			}, []string{"worker"}),
+		asyncDispatches: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "dispatcher_async_dispatches",
+				Help:      "Number of events waiting in the background for space in the full event channel.",
+			}),
+		blockedTime: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "dispatcher_blocked_duration_seconds",
+				Help:      "Time events waited for space in the full event channel, by backpressure mode.",
+				Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+			}, []string{"mode"}),
+		shedEvents: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "dispatcher_shed_events_total",
+				Help:      "Total number of low priority events dropped because the event channel was full, by event type.",
+			}, []string{"type"}),
+		timeouts: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "dispatcher_timeouts_total",
+				Help:      "Total number of events dropped because the event channel stayed full until the dispatch timeout.",
+			}),
+		deadLetters: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "dispatcher_dead_letter_events_total",
+				Help:      "Total number of events the handlers failed to handle, by event type.",
+			}, []string{"type"}),
+		handlingTime: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "dispatcher_event_handling_duration_seconds",
+				Help:      "Time the handlers took to handle the events, by event type and outcome.",
+				Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 10),
+			}, []string{"type", "outcome"}),
+		handlerPanics: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "dispatcher_handler_panics_total",
+				Help:      "Total number of panics recovered in the event handlers, by event type.",
+			}, []string{"type"}),
+	}
+}
+
+func (m *DispatcherMetrics) register(registerer prometheus.Registerer) {
+	for _, collector := range []prometheus.Collector{m.queueLength, m.workerQueue, m.asyncDispatches, m.blockedTime,
+		m.shedEvents, m.timeouts, m.deadLetters, m.handlingTime, m.handlerPanics} {
+		if err := registerer.Register(collector); err != nil {
+			log.Logger().Warn("failed to register dispatcher metrics", zap.Error(err))
+		}
+	}
+}
+
+func (m *DispatcherMetrics) SetQueueLength(lane string, length int) {
+	m.queueLength.WithLabelValues(lane).Set(float64(length))
+}
+
+func (m *DispatcherMetrics) SetWorkerQueueLength(worker int, length int) {
+	m.workerQueue.WithLabelValues(strconv.Itoa(worker)).Set(float64(length))
+}
+
+func (m *DispatcherMetrics) IncAsyncDispatches() {
+	m.asyncDispatches.Inc()
+}
+
+func (m *DispatcherMetrics) DecAsyncDispatches() {
+	m.asyncDispatches.Dec()
+}
+
+func (m *DispatcherMetrics) ObserveBlockedTime(mode string, duration time.Duration) {
+	m.blockedTime.WithLabelValues(mode).Observe(duration.Seconds())
+}
+
+func (m *DispatcherMetrics) IncShedEvents(eventType string) {
+	m.shedEvents.WithLabelValues(eventType).Inc()
+}
+
+func (m *DispatcherMetrics) IncTimeouts() {
+	m.timeouts.Inc()
+}
+
+func (m *DispatcherMetrics) IncDeadLetters(eventType string) {
+	m.deadLetters.WithLabelValues(eventType).Inc()
+}
+
+func (m *DispatcherMetrics) ObserveEventHandling(eventType, outcome string, duration time.Duration) {
+	m.handlingTime.WithLabelValues(eventType, outcome).Observe(duration.Seconds())
+}
+
+func (m *DispatcherMetrics) IncHandlerPanics(eventType string) {
+	m.handlerPanics.WithLabelValues(eventType).Inc()
+}
@@ -0,0 +1,53 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestDispatcherMetrics(t *testing.T) {
+	m := newDispatcherMetrics()
+	m.register(prometheus.NewRegistry())
+	m.SetQueueLength("high", 5)
+	m.IncAsyncDispatches()
+	m.IncAsyncDispatches()
+	m.DecAsyncDispatches()
+	m.ObserveBlockedTime("async", 10*time.Millisecond)
+	m.ObserveBlockedTime("block", 20*time.Millisecond)
+	m.IncShedEvents("appStatus")
+	m.IncTimeouts()
+	m.IncDeadLetters("task")
+	m.ObserveEventHandling("task", "success", time.Millisecond)
+	m.ObserveEventHandling("task", "failure", time.Second)
+	m.IncHandlerPanics("app")
+	assert.Equal(t, testutil.ToFloat64(m.queueLength.WithLabelValues("high")), float64(5))
+	assert.Equal(t, testutil.ToFloat64(m.asyncDispatches), float64(1))
+	assert.Equal(t, testutil.CollectAndCount(m.blockedTime), 2)
+	assert.Equal(t, testutil.CollectAndCount(m.handlingTime), 2)
+	assert.Equal(t, testutil.ToFloat64(m.shedEvents.WithLabelValues("appStatus")), float64(1))
+	assert.Equal(t, testutil.ToFloat64(m.timeouts), float64(1))
+	assert.Equal(t, testutil.ToFloat64(m.deadLetters.WithLabelValues("task")), float64(1))
+	assert.Equal(t, testutil.ToFloat64(m.handlerPanics.WithLabelValues("app")), float64(1))
+}
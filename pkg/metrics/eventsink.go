@@ -0,0 +1,77 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// the results of the lifecycle events streamed to the external sinks
+const (
+	SinkEventSent    = "sent"
+	SinkEventFailed  = "failed"
+	SinkEventDropped = "dropped"
+)
+
+// EventSinkMetrics tracks the lifecycle events streamed to the external sinks
+type EventSinkMetrics struct {
+	events      *prometheus.CounterVec
+	sendLatency *prometheus.HistogramVec
+}
+
+func newEventSinkMetrics() *EventSinkMetrics {
+	return &EventSinkMetrics{
+		events: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "event_sink_events_total",
+				Help:      "Total number of lifecycle events streamed to the external sinks, by sink and result.",
+			}, []string{"sink", "result"}),
+		sendLatency: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "event_sink_send_duration_seconds",
+				Help:      "Latency of sending a batch of lifecycle events to the external sinks, by sink.",
+				Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+			}, []string{"sink"}),
+	}
+}
+
+func (m *EventSinkMetrics) register(registerer prometheus.Registerer) {
+	for _, collector := range []prometheus.Collector{m.events, m.sendLatency} {
+		if err := registerer.Register(collector); err != nil {
+			log.Logger().Warn("failed to register event sink metrics", zap.Error(err))
+		}
+	}
+}
+
+func (m *EventSinkMetrics) AddEvents(sink, result string, count int) {
+	m.events.WithLabelValues(sink, result).Add(float64(count))
+}
+
+func (m *EventSinkMetrics) ObserveSendLatency(sink string, duration time.Duration) {
+	m.sendLatency.WithLabelValues(sink).Observe(duration.Seconds())
+}
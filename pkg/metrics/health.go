@@ -0,0 +1,89 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// HealthSource returns the composite health score of the scheduler, between 0 and 1,
+// and whether each of the checks the score is computed from passes
+type HealthSource func() (float64, map[string]bool)
+
+// HealthMetrics exposes the health of the scheduler, a score below 1 tells a scheduler
+// that is alive but degraded. The checks are run when the metrics are collected.
+type HealthMetrics struct {
+	score  *prometheus.Desc
+	check  *prometheus.Desc
+	source HealthSource
+	sync.RWMutex
+}
+
+func newHealthMetrics() *HealthMetrics {
+	return &HealthMetrics{
+		score: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, Subsystem, "health_score"),
+			"Fraction of the health checks of the scheduler that pass, 1 when the scheduler is healthy.",
+			nil, nil),
+		check: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, Subsystem, "health_check"),
+			"Whether the health check passes, 1 when it passes and 0 otherwise, by check.",
+			[]string{"check"}, nil),
+	}
+}
+
+func (m *HealthMetrics) register(registerer prometheus.Registerer) {
+	if err := registerer.Register(m); err != nil {
+		log.Logger().Warn("failed to register health metrics", zap.Error(err))
+	}
+}
+
+// SetSource sets the source of the health checks, it replaces the current source
+func (m *HealthMetrics) SetSource(source HealthSource) {
+	m.Lock()
+	defer m.Unlock()
+	m.source = source
+}
+
+func (m *HealthMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.score
+	ch <- m.check
+}
+
+func (m *HealthMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.RLock()
+	defer m.RUnlock()
+	if m.source == nil {
+		return
+	}
+	score, checks := m.source()
+	ch <- prometheus.MustNewConstMetric(m.score, prometheus.GaugeValue, score)
+	for name, passed := range checks {
+		value := 0.0
+		if passed {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(m.check, prometheus.GaugeValue, value, name)
+	}
+}
@@ -0,0 +1,48 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestHealthMetrics(t *testing.T) {
+	m := newHealthMetrics()
+	m.register(prometheus.NewRegistry())
+	assert.Equal(t, testutil.CollectAndCount(m), 0)
+
+	m.SetSource(func() (float64, map[string]bool) {
+		return 0.5, map[string]bool{"coreRegistered": true, "informersSynced": false}
+	})
+	expected := `
+# HELP yunikorn_k8s_shim_health_check Whether the health check passes, 1 when it passes and 0 otherwise, by check.
+# TYPE yunikorn_k8s_shim_health_check gauge
+yunikorn_k8s_shim_health_check{check="coreRegistered"} 1
+yunikorn_k8s_shim_health_check{check="informersSynced"} 0
+# HELP yunikorn_k8s_shim_health_score Fraction of the health checks of the scheduler that pass, 1 when the scheduler is healthy.
+# TYPE yunikorn_k8s_shim_health_score gauge
+yunikorn_k8s_shim_health_score 0.5
+`
+	assert.NilError(t, testutil.CollectAndCompare(m, strings.NewReader(expected)))
+}
@@ -0,0 +1,147 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// KubeClientMetrics tracks the calls the shim makes to the api-server
+type KubeClientMetrics struct {
+	retries       *prometheus.CounterVec
+	failures      *prometheus.CounterVec
+	throttled     *prometheus.CounterVec
+	lastThrottled prometheus.Gauge
+	callLatency   *prometheus.HistogramVec
+	callErrors    *prometheus.CounterVec
+	inFlight      *prometheus.GaugeVec
+	dryRunCalls   *prometheus.CounterVec
+}
+
+func newKubeClientMetrics() *KubeClientMetrics {
+	return &KubeClientMetrics{
+		retries: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "kube_client_retries_total",
+				Help:      "Total number of retried api-server calls, by operation.",
+			}, []string{"operation"}),
+		failures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "kube_client_failures_total",
+				Help:      "Total number of api-server calls that failed after all retries, by operation and whether the last error was retryable.",
+			}, []string{"operation", "retryable"}),
+		throttled: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "kube_client_throttled_total",
+				Help:      "Total number of api-server responses with status 429, by source: apf for API Priority and Fairness, server otherwise.",
+			}, []string{"source"}),
+		lastThrottled: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "kube_client_last_throttled_timestamp_seconds",
+				Help:      "Unix time of the last api-server response with status 429.",
+			}),
+		callLatency: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "kube_client_call_duration_seconds",
+				Help:      "Latency of the api-server calls including the retries, by verb and resource.",
+				Buckets:   prometheus.ExponentialBuckets(0.001, 2, 16),
+			}, []string{"verb", "resource"}),
+		callErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "kube_client_call_errors_total",
+				Help:      "Total number of failed api-server calls, by verb and resource.",
+			}, []string{"verb", "resource"}),
+		inFlight: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "kube_client_calls_in_flight",
+				Help:      "Number of api-server calls in progress, by verb and resource.",
+			}, []string{"verb", "resource"}),
+		dryRunCalls: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "kube_client_dry_run_calls_total",
+				Help:      "Total number of api-server mutations skipped in dry-run mode, by verb and resource.",
+			}, []string{"verb", "resource"}),
+	}
+}
+
+func (m *KubeClientMetrics) register(registerer prometheus.Registerer) {
+	for _, collector := range []prometheus.Collector{m.retries, m.failures, m.throttled, m.lastThrottled,
+		m.callLatency, m.callErrors, m.inFlight, m.dryRunCalls} {
+		if err := registerer.Register(collector); err != nil {
+			log.Logger().Warn("failed to register kube client metrics", zap.Error(err))
+		}
+	}
+}
+
+func (m *KubeClientMetrics) IncRetries(operation string) {
+	m.retries.WithLabelValues(operation).Inc()
+}
+
+func (m *KubeClientMetrics) IncFailures(operation string, retryable bool) {
+	label := "false"
+	if retryable {
+		label = "true"
+	}
+	m.failures.WithLabelValues(operation, label).Inc()
+}
+
+func (m *KubeClientMetrics) IncThrottled(source string) {
+	m.throttled.WithLabelValues(source).Inc()
+	m.lastThrottled.SetToCurrentTime()
+}
+
+func (m *KubeClientMetrics) IncInFlight(verb, resource string) {
+	m.inFlight.WithLabelValues(verb, resource).Inc()
+}
+
+func (m *KubeClientMetrics) DecInFlight(verb, resource string) {
+	m.inFlight.WithLabelValues(verb, resource).Dec()
+}
+
+func (m *KubeClientMetrics) ObserveCall(verb, resource string, duration time.Duration, err error) {
+	m.callLatency.WithLabelValues(verb, resource).Observe(duration.Seconds())
+	if err != nil {
+		m.callErrors.WithLabelValues(verb, resource).Inc()
+	}
+}
+
+func (m *KubeClientMetrics) IncDryRunCalls(verb, resource string) {
+	m.dryRunCalls.WithLabelValues(verb, resource).Inc()
+}
@@ -0,0 +1,65 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestKubeClientMetrics(t *testing.T) {
+	m := newKubeClientMetrics()
+	m.register(prometheus.NewRegistry())
+	m.IncRetries("BindPod")
+	m.IncRetries("BindPod")
+	m.IncFailures("BindPod", true)
+	m.IncFailures("DeletePod", false)
+	assert.Equal(t, testutil.ToFloat64(m.retries.WithLabelValues("BindPod")), float64(2))
+	assert.Equal(t, testutil.ToFloat64(m.failures.WithLabelValues("BindPod", "true")), float64(1))
+	assert.Equal(t, testutil.ToFloat64(m.failures.WithLabelValues("DeletePod", "false")), float64(1))
+}
+
+func TestKubeClientCallMetrics(t *testing.T) {
+	m := newKubeClientMetrics()
+	m.register(prometheus.NewRegistry())
+	m.IncInFlight("bind", "pods")
+	m.IncInFlight("bind", "pods")
+	assert.Equal(t, testutil.ToFloat64(m.inFlight.WithLabelValues("bind", "pods")), float64(2))
+	m.DecInFlight("bind", "pods")
+	m.ObserveCall("bind", "pods", 10*time.Millisecond, nil)
+	m.DecInFlight("bind", "pods")
+	m.ObserveCall("bind", "pods", 20*time.Millisecond, fmt.Errorf("failed"))
+	assert.Equal(t, testutil.ToFloat64(m.inFlight.WithLabelValues("bind", "pods")), float64(0))
+	assert.Equal(t, testutil.ToFloat64(m.callErrors.WithLabelValues("bind", "pods")), float64(1))
+	assert.Equal(t, testutil.CollectAndCount(m.callLatency), 1)
+}
+
+func TestKubeClientDryRunMetrics(t *testing.T) {
+	m := newKubeClientMetrics()
+	m.register(prometheus.NewRegistry())
+	m.IncDryRunCalls("bind", "pods")
+	m.IncDryRunCalls("update", "configmaps")
+	assert.Equal(t, testutil.ToFloat64(m.dryRunCalls.WithLabelValues("bind", "pods")), float64(1))
+	assert.Equal(t, testutil.ToFloat64(m.dryRunCalls.WithLabelValues("update", "configmaps")), float64(1))
+}
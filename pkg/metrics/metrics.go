@@ -0,0 +1,149 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// shim metrics are registered with the default prometheus registry,
+// they are exposed together with the core metrics by the core REST service and the shim web service.
+const (
+	Namespace = "yunikorn"
+	Subsystem = "k8s_shim"
+)
+
+var once sync.Once
+var kubeClientMetrics *KubeClientMetrics
+var dispatcherMetrics *DispatcherMetrics
+var eventSinkMetrics *EventSinkMetrics
+var schedulingMetrics *SchedulingMetrics
+var stateMetrics *StateMetrics
+var predicateMetrics *PredicateMetrics
+var cacheMetrics *CacheMetrics
+var coreAPIMetrics *CoreAPIMetrics
+var queueMetrics *QueueMetrics
+var coreEventMetrics *CoreEventMetrics
+var healthMetrics *HealthMetrics
+var recoveryMetrics *RecoveryMetrics
+var preemptionMetrics *PreemptionMetrics
+var demandMetrics *DemandMetrics
+
+func initMetrics() {
+	kubeClientMetrics = newKubeClientMetrics()
+	kubeClientMetrics.register(prometheus.DefaultRegisterer)
+	dispatcherMetrics = newDispatcherMetrics()
+	dispatcherMetrics.register(prometheus.DefaultRegisterer)
+	eventSinkMetrics = newEventSinkMetrics()
+	eventSinkMetrics.register(prometheus.DefaultRegisterer)
+	schedulingMetrics = newSchedulingMetrics()
+	schedulingMetrics.register(prometheus.DefaultRegisterer)
+	stateMetrics = newStateMetrics()
+	stateMetrics.register(prometheus.DefaultRegisterer)
+	predicateMetrics = newPredicateMetrics()
+	predicateMetrics.register(prometheus.DefaultRegisterer)
+	cacheMetrics = newCacheMetrics()
+	cacheMetrics.register(prometheus.DefaultRegisterer)
+	coreAPIMetrics = newCoreAPIMetrics()
+	coreAPIMetrics.register(prometheus.DefaultRegisterer)
+	queueMetrics = newQueueMetrics()
+	queueMetrics.register(prometheus.DefaultRegisterer)
+	coreEventMetrics = newCoreEventMetrics()
+	coreEventMetrics.register(prometheus.DefaultRegisterer)
+	healthMetrics = newHealthMetrics()
+	healthMetrics.register(prometheus.DefaultRegisterer)
+	recoveryMetrics = newRecoveryMetrics()
+	recoveryMetrics.register(prometheus.DefaultRegisterer)
+	preemptionMetrics = newPreemptionMetrics()
+	preemptionMetrics.register(prometheus.DefaultRegisterer)
+	demandMetrics = newDemandMetrics()
+	demandMetrics.register(prometheus.DefaultRegisterer)
+}
+
+func GetKubeClientMetrics() *KubeClientMetrics {
+	once.Do(initMetrics)
+	return kubeClientMetrics
+}
+
+func GetDispatcherMetrics() *DispatcherMetrics {
+	once.Do(initMetrics)
+	return dispatcherMetrics
+}
+
+func GetEventSinkMetrics() *EventSinkMetrics {
+	once.Do(initMetrics)
+	return eventSinkMetrics
+}
+
+func GetSchedulingMetrics() *SchedulingMetrics {
+	once.Do(initMetrics)
+	return schedulingMetrics
+}
+
+func GetStateMetrics() *StateMetrics {
+	once.Do(initMetrics)
+	return stateMetrics
+}
+
+func GetPredicateMetrics() *PredicateMetrics {
+	once.Do(initMetrics)
+	return predicateMetrics
+}
+
+func GetCacheMetrics() *CacheMetrics {
+	once.Do(initMetrics)
+	return cacheMetrics
+}
+
+func GetCoreAPIMetrics() *CoreAPIMetrics {
+	once.Do(initMetrics)
+	return coreAPIMetrics
+}
+
+func GetQueueMetrics() *QueueMetrics {
+	once.Do(initMetrics)
+	return queueMetrics
+}
+
+func GetCoreEventMetrics() *CoreEventMetrics {
+	once.Do(initMetrics)
+	return coreEventMetrics
+}
+
+func GetHealthMetrics() *HealthMetrics {
+	once.Do(initMetrics)
+	return healthMetrics
+}
+
+func GetRecoveryMetrics() *RecoveryMetrics {
+	once.Do(initMetrics)
+	return recoveryMetrics
+}
+
+func GetPreemptionMetrics() *PreemptionMetrics {
+	once.Do(initMetrics)
+	return preemptionMetrics
+}
+
+func GetDemandMetrics() *DemandMetrics {
+	once.Do(initMetrics)
+	return demandMetrics
+}
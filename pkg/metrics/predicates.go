@@ -0,0 +1,70 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// PredicateMetrics tracks the evaluation of the scheduler framework plugins by the predicate manager
+type PredicateMetrics struct {
+	evaluationTime *prometheus.HistogramVec
+	failures       *prometheus.CounterVec
+}
+
+func newPredicateMetrics() *PredicateMetrics {
+	return &PredicateMetrics{
+		evaluationTime: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "predicate_evaluation_duration_seconds",
+				Help:      "Time the predicate plugins took to evaluate a pod, by plugin and extension point.",
+				Buckets:   prometheus.ExponentialBuckets(0.00001, 4, 10),
+			}, []string{"plugin", "extension_point"}),
+		failures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "predicate_failures_total",
+				Help:      "Total number of pods the predicate plugins did not accept, by plugin, extension point and status code.",
+			}, []string{"plugin", "extension_point", "code"}),
+	}
+}
+
+func (m *PredicateMetrics) register(registerer prometheus.Registerer) {
+	for _, collector := range []prometheus.Collector{m.evaluationTime, m.failures} {
+		if err := registerer.Register(collector); err != nil {
+			log.Logger().Warn("failed to register predicate metrics", zap.Error(err))
+		}
+	}
+}
+
+func (m *PredicateMetrics) ObserveEvaluation(plugin, extensionPoint string, duration time.Duration) {
+	m.evaluationTime.WithLabelValues(plugin, extensionPoint).Observe(duration.Seconds())
+}
+
+func (m *PredicateMetrics) IncFailures(plugin, extensionPoint, code string) {
+	m.failures.WithLabelValues(plugin, extensionPoint, code).Inc()
+}
@@ -0,0 +1,70 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// PreemptionMetrics tracks the eviction of the pods whose allocations the core preempted
+type PreemptionMetrics struct {
+	victims        *prometheus.CounterVec
+	releaseLatency prometheus.Histogram
+}
+
+func newPreemptionMetrics() *PreemptionMetrics {
+	return &PreemptionMetrics{
+		victims: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "preemption_victims_total",
+				Help:      "Total number of pods preempted by the core, by the result of the eviction.",
+			}, []string{"result"}),
+		releaseLatency: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "preemption_release_duration_seconds",
+				Help:      "Time from the preemption of an allocation by the core until the shim released it after the pod terminated.",
+				Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12),
+			}),
+	}
+}
+
+func (m *PreemptionMetrics) register(registerer prometheus.Registerer) {
+	for _, collector := range []prometheus.Collector{m.victims, m.releaseLatency} {
+		if err := registerer.Register(collector); err != nil {
+			log.Logger().Warn("failed to register preemption metrics", zap.Error(err))
+		}
+	}
+}
+
+func (m *PreemptionMetrics) IncVictims(result string) {
+	m.victims.WithLabelValues(result).Inc()
+}
+
+func (m *PreemptionMetrics) ObserveRelease(duration time.Duration) {
+	m.releaseLatency.Observe(duration.Seconds())
+}
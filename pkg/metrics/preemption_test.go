@@ -0,0 +1,40 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestPreemptionMetrics(t *testing.T) {
+	m := newPreemptionMetrics()
+	m.register(prometheus.NewRegistry())
+	m.IncVictims("evicted")
+	m.IncVictims("evicted")
+	m.IncVictims("failed")
+	m.ObserveRelease(time.Second)
+	assert.Equal(t, testutil.ToFloat64(m.victims.WithLabelValues("evicted")), float64(2))
+	assert.Equal(t, testutil.ToFloat64(m.victims.WithLabelValues("failed")), float64(1))
+	assert.Equal(t, testutil.CollectAndCount(m.releaseLatency), 1)
+}
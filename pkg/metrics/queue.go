@@ -0,0 +1,97 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// the states of the pods in the queue metrics, as seen by the shim
+const (
+	QueuePodsPending    = "pending"
+	QueuePodsScheduling = "scheduling"
+	QueuePodsBound      = "bound"
+	QueuePodsFailed     = "failed"
+)
+
+var queuePodStates = []string{QueuePodsPending, QueuePodsScheduling, QueuePodsBound, QueuePodsFailed}
+
+// QueueSource returns the number of pods by state for each queue
+type QueueSource func() map[string]map[string]int
+
+// QueueMetrics tracks the pods of each queue from the point of view of the shim, these are
+// available even when the core cannot be reached. The counts are read from the source when
+// the metrics are collected.
+type QueueMetrics struct {
+	desc   *prometheus.Desc
+	source QueueSource
+	sync.RWMutex
+}
+
+func newQueueMetrics() *QueueMetrics {
+	return &QueueMetrics{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, Subsystem, "queue_pods"),
+			"Number of pods in the queue as seen by the shim, by queue and state.",
+			[]string{"queue", "state"}, nil),
+	}
+}
+
+func (m *QueueMetrics) register(registerer prometheus.Registerer) {
+	if err := registerer.Register(m); err != nil {
+		log.Logger().Warn("failed to register queue metrics", zap.Error(err))
+	}
+}
+
+// SetSource sets the source of the pod counts, it replaces the current source
+func (m *QueueMetrics) SetSource(source QueueSource) {
+	m.Lock()
+	defer m.Unlock()
+	m.source = source
+}
+
+func (m *QueueMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.desc
+}
+
+// every state is reported for each queue, also when there are no pods in the state
+func (m *QueueMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.RLock()
+	defer m.RUnlock()
+	if m.source == nil {
+		return
+	}
+	counts := m.source()
+	queues := make([]string, 0, len(counts))
+	for queue := range counts {
+		queues = append(queues, queue)
+	}
+	sort.Strings(queues)
+	for _, queue := range queues {
+		for _, state := range queuePodStates {
+			ch <- prometheus.MustNewConstMetric(m.desc, prometheus.GaugeValue, float64(counts[queue][state]), queue, state)
+		}
+	}
+}
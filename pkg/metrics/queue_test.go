@@ -0,0 +1,54 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestQueueMetrics(t *testing.T) {
+	m := newQueueMetrics()
+	m.register(prometheus.NewRegistry())
+	assert.Equal(t, testutil.CollectAndCount(m), 0)
+
+	m.SetSource(func() map[string]map[string]int {
+		return map[string]map[string]int{
+			"root.a": {QueuePodsPending: 2, QueuePodsBound: 1},
+			"root.b": {QueuePodsFailed: 1},
+		}
+	})
+	expected := `
+# HELP yunikorn_k8s_shim_queue_pods Number of pods in the queue as seen by the shim, by queue and state.
+# TYPE yunikorn_k8s_shim_queue_pods gauge
+yunikorn_k8s_shim_queue_pods{queue="root.a",state="bound"} 1
+yunikorn_k8s_shim_queue_pods{queue="root.a",state="failed"} 0
+yunikorn_k8s_shim_queue_pods{queue="root.a",state="pending"} 2
+yunikorn_k8s_shim_queue_pods{queue="root.a",state="scheduling"} 0
+yunikorn_k8s_shim_queue_pods{queue="root.b",state="bound"} 0
+yunikorn_k8s_shim_queue_pods{queue="root.b",state="failed"} 1
+yunikorn_k8s_shim_queue_pods{queue="root.b",state="pending"} 0
+yunikorn_k8s_shim_queue_pods{queue="root.b",state="scheduling"} 0
+`
+	assert.NilError(t, testutil.CollectAndCompare(m, strings.NewReader(expected)))
+}
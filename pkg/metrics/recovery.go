@@ -0,0 +1,104 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// RecoveryProgress is the number of objects of a kind recovered on startup, out of the objects to recover
+type RecoveryProgress struct {
+	Recovered int `json:"recovered"`
+	Total     int `json:"total"`
+}
+
+// RecoveryMetrics tracks the progress of the recovery of the applications and the nodes on startup.
+// The last progress is kept so that it can be reported by the health checks.
+type RecoveryMetrics struct {
+	objects  *prometheus.GaugeVec
+	duration prometheus.Gauge
+	timedOut prometheus.Gauge
+	progress map[string]RecoveryProgress
+	sync.RWMutex
+}
+
+func newRecoveryMetrics() *RecoveryMetrics {
+	return &RecoveryMetrics{
+		objects: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "recovery_objects",
+				Help:      "Number of objects to recover on startup and number of objects recovered, by object and state.",
+			}, []string{"object", "state"}),
+		duration: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "recovery_duration_seconds",
+				Help:      "Time taken by the recovery of the applications and the nodes on startup.",
+			}),
+		timedOut: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "recovery_timed_out",
+				Help:      "1 when the recovery on startup did not finish before the deadline and the scheduler started with the objects recovered, 0 otherwise.",
+			}),
+		progress: make(map[string]RecoveryProgress),
+	}
+}
+
+func (m *RecoveryMetrics) register(registerer prometheus.Registerer) {
+	for _, collector := range []prometheus.Collector{m.objects, m.duration, m.timedOut} {
+		if err := registerer.Register(collector); err != nil {
+			log.Logger().Warn("failed to register recovery metrics", zap.Error(err))
+		}
+	}
+}
+
+func (m *RecoveryMetrics) SetProgress(object string, recovered, total int) {
+	m.Lock()
+	defer m.Unlock()
+	m.progress[object] = RecoveryProgress{Recovered: recovered, Total: total}
+	m.objects.WithLabelValues(object, "recovered").Set(float64(recovered))
+	m.objects.WithLabelValues(object, "total").Set(float64(total))
+}
+
+// GetProgress returns the last progress of the recovery of the objects, zero when the recovery did not start
+func (m *RecoveryMetrics) GetProgress(object string) RecoveryProgress {
+	m.RLock()
+	defer m.RUnlock()
+	return m.progress[object]
+}
+
+func (m *RecoveryMetrics) ObserveRecovery(duration time.Duration, timedOut bool) {
+	m.duration.Set(duration.Seconds())
+	if timedOut {
+		m.timedOut.Set(1)
+	} else {
+		m.timedOut.Set(0)
+	}
+}
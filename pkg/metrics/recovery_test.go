@@ -0,0 +1,47 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestRecoveryMetrics(t *testing.T) {
+	m := newRecoveryMetrics()
+	m.register(prometheus.NewRegistry())
+	assert.Equal(t, m.GetProgress(ObjectNode), RecoveryProgress{})
+
+	m.SetProgress(ObjectNode, 3, 10)
+	m.SetProgress(ObjectApplication, 5, 5)
+	assert.Equal(t, m.GetProgress(ObjectNode), RecoveryProgress{Recovered: 3, Total: 10})
+	assert.Equal(t, testutil.ToFloat64(m.objects.WithLabelValues(ObjectNode, "recovered")), float64(3))
+	assert.Equal(t, testutil.ToFloat64(m.objects.WithLabelValues(ObjectNode, "total")), float64(10))
+	assert.Equal(t, testutil.CollectAndCount(m.objects), 4)
+
+	m.ObserveRecovery(2*time.Second, true)
+	assert.Equal(t, testutil.ToFloat64(m.duration), float64(2))
+	assert.Equal(t, testutil.ToFloat64(m.timedOut), float64(1))
+	m.ObserveRecovery(time.Second, false)
+	assert.Equal(t, testutil.ToFloat64(m.timedOut), float64(0))
+}
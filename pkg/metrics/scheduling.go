@@ -0,0 +1,81 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// the phases a pod goes through on its way from the creation to running:
+// waiting in the shim until the ask is sent to the core, waiting for the allocation
+// from the core, binding to the node, and starting on the node.
+const (
+	PhaseQueued     = "queued"
+	PhaseAllocation = "allocation"
+	PhaseBinding    = "binding"
+	PhaseStartup    = "startup"
+)
+
+// SchedulingMetrics tracks the time the pods spend in the shim and in the core until they run
+type SchedulingMetrics struct {
+	phaseLatency *prometheus.HistogramVec
+	podLatency   *prometheus.HistogramVec
+}
+
+func newSchedulingMetrics() *SchedulingMetrics {
+	return &SchedulingMetrics{
+		phaseLatency: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "pod_scheduling_phase_duration_seconds",
+				Help:      "Time the pods spent in each scheduling phase, by queue and phase.",
+				Buckets:   prometheus.ExponentialBuckets(0.001, 4, 12),
+			}, []string{"queue", "phase"}),
+		podLatency: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "pod_scheduling_latency_seconds",
+				Help:      "Time from the creation of the pods until they are running, by queue.",
+				Buckets:   prometheus.ExponentialBuckets(0.001, 4, 12),
+			}, []string{"queue"}),
+	}
+}
+
+func (m *SchedulingMetrics) register(registerer prometheus.Registerer) {
+	for _, collector := range []prometheus.Collector{m.phaseLatency, m.podLatency} {
+		if err := registerer.Register(collector); err != nil {
+			log.Logger().Warn("failed to register scheduling metrics", zap.Error(err))
+		}
+	}
+}
+
+func (m *SchedulingMetrics) ObservePhaseLatency(queue, phase string, duration time.Duration) {
+	m.phaseLatency.WithLabelValues(queue, phase).Observe(duration.Seconds())
+}
+
+func (m *SchedulingMetrics) ObservePodLatency(queue string, duration time.Duration) {
+	m.podLatency.WithLabelValues(queue).Observe(duration.Seconds())
+}
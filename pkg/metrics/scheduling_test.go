@@ -0,0 +1,39 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestSchedulingMetrics(t *testing.T) {
+	m := newSchedulingMetrics()
+	m.register(prometheus.NewRegistry())
+	m.ObservePhaseLatency("root.a", PhaseQueued, 10*time.Millisecond)
+	m.ObservePhaseLatency("root.a", PhaseAllocation, 100*time.Millisecond)
+	m.ObservePhaseLatency("root.b", PhaseQueued, 10*time.Millisecond)
+	m.ObservePodLatency("root.a", time.Second)
+	assert.Equal(t, testutil.CollectAndCount(m.phaseLatency), 3)
+	assert.Equal(t, testutil.CollectAndCount(m.podLatency), 1)
+}
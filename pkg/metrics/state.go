@@ -0,0 +1,86 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// the objects of the shim that have a state machine
+const (
+	ObjectApplication = "application"
+	ObjectTask        = "task"
+	ObjectNode        = "node"
+)
+
+// StateMetrics tracks the state transitions of the applications and the tasks
+type StateMetrics struct {
+	transitions   *prometheus.CounterVec
+	stateDuration *prometheus.HistogramVec
+	failures      *prometheus.CounterVec
+}
+
+func newStateMetrics() *StateMetrics {
+	return &StateMetrics{
+		transitions: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "state_transitions_total",
+				Help:      "Total number of state transitions, by object, source state and destination state.",
+			}, []string{"object", "from", "to"}),
+		stateDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "state_duration_seconds",
+				Help:      "Time spent in the source state before the transition, by object, source state and destination state.",
+				Buckets:   prometheus.ExponentialBuckets(0.001, 4, 12),
+			}, []string{"object", "from", "to"}),
+		failures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "state_failures_total",
+				Help:      "Total number of transitions into the failed state, by object and reason.",
+			}, []string{"object", "reason"}),
+	}
+}
+
+func (m *StateMetrics) register(registerer prometheus.Registerer) {
+	for _, collector := range []prometheus.Collector{m.transitions, m.stateDuration, m.failures} {
+		if err := registerer.Register(collector); err != nil {
+			log.Logger().Warn("failed to register state metrics", zap.Error(err))
+		}
+	}
+}
+
+func (m *StateMetrics) ObserveTransition(object, from, to string, duration time.Duration) {
+	m.transitions.WithLabelValues(object, from, to).Inc()
+	m.stateDuration.WithLabelValues(object, from, to).Observe(duration.Seconds())
+}
+
+func (m *StateMetrics) IncFailures(object, reason string) {
+	m.failures.WithLabelValues(object, reason).Inc()
+}
@@ -30,6 +30,7 @@ import (
 
 	"go.uber.org/zap"
 	"k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -123,6 +124,9 @@ func (c *admissionController) mutate(req *v1beta1.AdmissionRequest) *v1beta1.Adm
 
 	patch = updateSchedulerName(patch)
 	patch = updateLabels(namespace, &pod, patch)
+	if req.Operation == v1beta1.Create {
+		patch = updateUserInfo(&pod, req.UserInfo, patch)
+	}
 	log.Logger().Info("generated patch", zap.String("podName", pod.Name),
 		zap.Any("patch", patch))
 
@@ -196,6 +200,34 @@ func updateLabels(namespace string, pod *v1.Pod, patch []patchOperation) []patch
 	return patch
 }
 
+// sets the user info annotation to the user that created the pod, the shim resolves the user of the application
+// from it. The annotation the pod was submitted with is replaced: the user cannot pick the identity its quota is
+// charged to.
+func updateUserInfo(pod *v1.Pod, userInfo authenticationv1.UserInfo, patch []patchOperation) []patchOperation {
+	value, err := json.Marshal(map[string]interface{}{
+		"user":   userInfo.Username,
+		"groups": userInfo.Groups,
+	})
+	if err != nil {
+		log.Logger().Error("failed to marshal user info", zap.Error(err))
+		return patch
+	}
+	result := make(map[string]string)
+	for k, v := range pod.Annotations {
+		result[k] = v
+	}
+	result[constants.AnnotationUserInfo] = string(value)
+	log.Logger().Info("updating pod user info",
+		zap.String("podName", pod.Name),
+		zap.String("user", userInfo.Username),
+		zap.Strings("groups", userInfo.Groups))
+	return append(patch, patchOperation{
+		Op:    "add",
+		Path:  "/metadata/annotations",
+		Value: result,
+	})
+}
+
 func isConfigMapUpdateAllowed(userInfo string) bool {
 	hotRefreshEnabled := os.Getenv(enableConfigHotRefreshEnvVar)
 	allowed, err := strconv.ParseBool(hotRefreshEnabled)
@@ -28,6 +28,7 @@ import (
 	"testing"
 
 	"gotest.tools/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -249,6 +250,37 @@ func TestUpdateSchedulerName(t *testing.T) {
 	}
 }
 
+func TestUpdateUserInfo(t *testing.T) {
+	var patch []patchOperation
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "a-test-pod",
+			Namespace: "default",
+			Annotations: map[string]string{
+				"random":                     "random",
+				constants.AnnotationUserInfo: `{"user":"admin","groups":["system:masters"]}`,
+			},
+		},
+	}
+	userInfo := authenticationv1.UserInfo{
+		Username: "test-user",
+		Groups:   []string{"dev", "system:authenticated"},
+	}
+	patch = updateUserInfo(pod, userInfo, patch)
+	assert.Equal(t, len(patch), 1)
+	assert.Equal(t, patch[0].Op, "add")
+	assert.Equal(t, patch[0].Path, "/metadata/annotations")
+	if updatedMap, ok := patch[0].Value.(map[string]string); ok {
+		assert.Equal(t, len(updatedMap), 2)
+		assert.Equal(t, updatedMap["random"], "random")
+		// the user info the pod was submitted with is replaced
+		assert.Equal(t, updatedMap[constants.AnnotationUserInfo],
+			`{"groups":["dev","system:authenticated"],"user":"test-user"}`)
+	} else {
+		t.Fatal("patch info content is not as expected")
+	}
+}
+
 func TestValidateConfigMap(t *testing.T) {
 	configName := fmt.Sprintf("%s.yaml", conf.DefaultPolicyGroup)
 	controller := &admissionController{
@@ -0,0 +1,116 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// how often the files of the mounted Secret are checked for a rotated certificate
+const certReloadInterval = time.Minute
+
+// servingCertificate holds the certificate of the webhook and of the health probes, it is reloaded from
+// the files of the mounted Secret when the Secret is rotated. The connections already open keep the
+// certificate they were established with.
+type servingCertificate struct {
+	certPath string
+	keyPath  string
+	cert     *tls.Certificate
+	notAfter time.Time
+	sync.RWMutex
+}
+
+func newServingCertificate(certPath, keyPath string) (*servingCertificate, error) {
+	certs := &servingCertificate{
+		certPath: certPath,
+		keyPath:  keyPath,
+	}
+	if _, err := certs.load(); err != nil {
+		return nil, err
+	}
+	return certs, nil
+}
+
+// loads the key pair from the files, returns true when the certificate changed
+func (c *servingCertificate) load() (bool, error) {
+	pair, err := tls.LoadX509KeyPair(c.certPath, c.keyPath)
+	if err != nil {
+		return false, err
+	}
+	return c.set(pair)
+}
+
+// replaces the certificate, returns true when the certificate changed
+func (c *servingCertificate) set(pair tls.Certificate) (bool, error) {
+	if len(pair.Certificate) == 0 {
+		return false, fmt.Errorf("no certificate in the key pair")
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return false, err
+	}
+	c.Lock()
+	defer c.Unlock()
+	changed := c.cert == nil || !bytes.Equal(c.cert.Certificate[0], pair.Certificate[0])
+	c.cert = &pair
+	c.notAfter = cert.NotAfter
+	return changed, nil
+}
+
+// reloads the certificate each interval until stopped, a certificate that fails to load keeps the previous one
+func (c *servingCertificate) watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			changed, err := c.load()
+			if err != nil {
+				log.Logger().Error("failed to reload the admission controller certificate, the previous certificate is kept",
+					zap.Error(err))
+			} else if changed {
+				log.Logger().Info("admission controller certificate reloaded",
+					zap.Time("notAfter", c.getNotAfter()))
+			}
+		}
+	}
+}
+
+func (c *servingCertificate) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.RLock()
+	defer c.RUnlock()
+	return c.cert, nil
+}
+
+func (c *servingCertificate) getNotAfter() time.Time {
+	c.RLock()
+	defer c.RUnlock()
+	return c.notAfter
+}
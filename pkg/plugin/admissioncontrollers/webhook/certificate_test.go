@@ -0,0 +1,109 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+// writes the key pair as the PEM files of a mounted Secret
+func writeTestKeyPair(t *testing.T, dir string, pair tls.Certificate) (string, string) {
+	certPath := filepath.Join(dir, tlsCertFile)
+	keyPath := filepath.Join(dir, tlsKeyFile)
+	der, err := x509.MarshalECPrivateKey(pair.PrivateKey.(*ecdsa.PrivateKey))
+	assert.NilError(t, err)
+	assert.NilError(t, ioutil.WriteFile(certPath,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: pair.Certificate[0]}), 0600))
+	assert.NilError(t, ioutil.WriteFile(keyPath,
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600))
+	return certPath, keyPath
+}
+
+func TestServingCertificate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "webhook-tls")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = newServingCertificate(filepath.Join(dir, tlsCertFile), filepath.Join(dir, tlsKeyFile))
+	assert.Assert(t, err != nil, "the certificate files do not exist")
+
+	first := time.Now().Add(time.Hour).Truncate(time.Second)
+	certPath, keyPath := writeTestKeyPair(t, dir, newTestKeyPair(t, first))
+	certs, err := newServingCertificate(certPath, keyPath)
+	assert.NilError(t, err)
+	assert.Assert(t, certs.getNotAfter().Equal(first))
+	cert, err := certs.getCertificate(nil)
+	assert.NilError(t, err)
+	assert.Assert(t, cert != nil)
+
+	// unchanged files do not replace the certificate
+	changed, err := certs.load()
+	assert.NilError(t, err)
+	assert.Assert(t, !changed)
+
+	// the rotated certificate is served by the new connections
+	second := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+	writeTestKeyPair(t, dir, newTestKeyPair(t, second))
+	changed, err = certs.load()
+	assert.NilError(t, err)
+	assert.Assert(t, changed)
+	assert.Assert(t, certs.getNotAfter().Equal(second))
+	rotated, err := certs.getCertificate(nil)
+	assert.NilError(t, err)
+	assert.Assert(t, rotated != cert)
+
+	// a certificate that fails to load keeps the previous one
+	assert.NilError(t, ioutil.WriteFile(certPath, []byte("not a certificate"), 0600))
+	_, err = certs.load()
+	assert.Assert(t, err != nil)
+	assert.Assert(t, certs.getNotAfter().Equal(second))
+
+	_, err = certs.set(tls.Certificate{})
+	assert.ErrorContains(t, err, "no certificate in the key pair")
+}
+
+func TestServingCertificateWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "webhook-tls")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	certPath, keyPath := writeTestKeyPair(t, dir, newTestKeyPair(t, time.Now().Add(-time.Minute)))
+	certs, err := newServingCertificate(certPath, keyPath)
+	assert.NilError(t, err)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go certs.watch(10*time.Millisecond, stop)
+	rotated := time.Now().Add(time.Hour).Truncate(time.Second)
+	writeTestKeyPair(t, dir, newTestKeyPair(t, rotated))
+	deadline := time.Now().Add(5 * time.Second)
+	for !certs.getNotAfter().Equal(rotated) {
+		assert.Assert(t, time.Now().Before(deadline), "the rotated certificate was not reloaded")
+		time.Sleep(10 * time.Millisecond)
+	}
+}
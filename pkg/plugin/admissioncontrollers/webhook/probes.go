@@ -0,0 +1,93 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// the webhook is alive as long as it serves requests
+func serveLiveness(w http.ResponseWriter, r *http.Request) {
+	writeProbe(w, http.StatusOK, "ok")
+}
+
+// the timeout of the check of the informers of the scheduler, the probe answers within the probe timeout
+const informersCheckTimeout = 2 * time.Second
+
+// readinessProbe fails once the serving certificate expired, the api-server rejects the calls to the
+// webhook until the certificate is replaced. The probe also fails while the informers of the scheduler
+// are not synced: the configurations the webhook validates are checked against the caches of the scheduler.
+// The informers are only checked when the URL of the shim web service is set, with its scheme and port.
+type readinessProbe struct {
+	// the serving certificate, the probe recovers once a rotated certificate is reloaded
+	certs *servingCertificate
+	// the informer health of the shim web service, empty when no shim URL is set
+	informersURL string
+	client       *http.Client
+}
+
+func newReadinessProbe(certs *servingCertificate, informersURL string) *readinessProbe {
+	return &readinessProbe{
+		certs:        certs,
+		informersURL: informersURL,
+		client:       &http.Client{Timeout: informersCheckTimeout},
+	}
+}
+
+func (p *readinessProbe) serve(w http.ResponseWriter, r *http.Request) {
+	if notAfter := p.certs.getNotAfter(); time.Now().After(notAfter) {
+		writeProbe(w, http.StatusServiceUnavailable, fmt.Sprintf("certificate expired at %s", notAfter.Format(time.RFC3339)))
+		return
+	}
+	if err := p.checkInformers(); err != nil {
+		writeProbe(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	writeProbe(w, http.StatusOK, "ok")
+}
+
+// returns an error when the informers of the scheduler are not synced or their health cannot be read
+func (p *readinessProbe) checkInformers() error {
+	if p.informersURL == "" {
+		return nil
+	}
+	response, err := p.client.Get(p.informersURL)
+	if err != nil {
+		return fmt.Errorf("failed to check the informers of the scheduler: %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("the informers of the scheduler are not synced: status %d", response.StatusCode)
+	}
+	return nil
+}
+
+func writeProbe(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	if _, err := w.Write([]byte(message)); err != nil {
+		log.Logger().Warn("failed to write the probe response", zap.Error(err))
+	}
+}
@@ -0,0 +1,112 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func newTestKeyPair(t *testing.T, notAfter time.Time) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    notAfter.Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NilError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func newTestServingCertificate(t *testing.T, notAfter time.Time) *servingCertificate {
+	certs := &servingCertificate{}
+	_, err := certs.set(newTestKeyPair(t, notAfter))
+	assert.NilError(t, err)
+	return certs
+}
+
+func TestProbes(t *testing.T) {
+	resp := httptest.NewRecorder()
+	serveLiveness(resp, httptest.NewRequest("GET", healthURL, nil))
+	assert.Equal(t, resp.Code, http.StatusOK)
+
+	certs := newTestServingCertificate(t, time.Now().Add(time.Hour))
+	probe := newReadinessProbe(certs, "")
+	resp = httptest.NewRecorder()
+	probe.serve(resp, httptest.NewRequest("GET", readyURL, nil))
+	assert.Equal(t, resp.Code, http.StatusOK)
+
+	expired := time.Now().Add(-time.Minute).Truncate(time.Second)
+	_, err := certs.set(newTestKeyPair(t, expired))
+	assert.NilError(t, err)
+	resp = httptest.NewRecorder()
+	probe.serve(resp, httptest.NewRequest("GET", readyURL, nil))
+	assert.Equal(t, resp.Code, http.StatusServiceUnavailable)
+	assert.Equal(t, resp.Body.String(), "certificate expired at "+expired.UTC().Format(time.RFC3339))
+
+	// the probe recovers once the rotated certificate is loaded
+	_, err = certs.set(newTestKeyPair(t, time.Now().Add(time.Hour)))
+	assert.NilError(t, err)
+	resp = httptest.NewRecorder()
+	probe.serve(resp, httptest.NewRequest("GET", readyURL, nil))
+	assert.Equal(t, resp.Code, http.StatusOK)
+}
+
+func TestReadinessInformers(t *testing.T) {
+	var synced int32
+	scheduler := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, "/ws/v1/health/informers")
+		if atomic.LoadInt32(&synced) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer scheduler.Close()
+	probe := newReadinessProbe(newTestServingCertificate(t, time.Now().Add(time.Hour)), scheduler.URL+schedulerInformersPath)
+
+	resp := httptest.NewRecorder()
+	probe.serve(resp, httptest.NewRequest("GET", readyURL, nil))
+	assert.Equal(t, resp.Code, http.StatusServiceUnavailable)
+	assert.Equal(t, resp.Body.String(), "the informers of the scheduler are not synced: status 503")
+
+	atomic.StoreInt32(&synced, 1)
+	resp = httptest.NewRecorder()
+	probe.serve(resp, httptest.NewRequest("GET", readyURL, nil))
+	assert.Equal(t, resp.Code, http.StatusOK)
+
+	// the scheduler cannot be reached
+	scheduler.Close()
+	resp = httptest.NewRecorder()
+	probe.serve(resp, httptest.NewRequest("GET", readyURL, nil))
+	assert.Equal(t, resp.Code, http.StatusServiceUnavailable)
+	assert.Assert(t, strings.HasPrefix(resp.Body.String(), "failed to check the informers of the scheduler"))
+}
@@ -26,6 +26,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 
 	"go.uber.org/zap"
@@ -41,25 +42,40 @@ const (
 	tlsKeyFile                        = `key.pem`
 	policyGroupEnvVarName             = "POLICY_GROUP"
 	schedulerServiceAddressEnvVarName = "SCHEDULER_SERVICE_ADDRESS"
+	schedulerShimURLEnvVarName        = "SCHEDULER_SHIM_URL"
 	schedulerValidateConfURLPattern   = "http://%s/ws/v1/validate-conf"
+	schedulerInformersPath            = "/ws/v1/health/informers"
 
 	// legal URLs
 	mutateURL       = "/mutate"
 	validateConfURL = "/validate-conf"
+	healthURL       = "/healthz"
+	readyURL        = "/readyz"
 )
 
 func main() {
 	certPath := filepath.Join(tlsDir, tlsCertFile)
 	keyPath := filepath.Join(tlsDir, tlsKeyFile)
-	pair, err := tls.LoadX509KeyPair(certPath, keyPath)
+	certs, err := newServingCertificate(certPath, keyPath)
 	if err != nil {
 		log.Logger().Fatal("Failed to load key pair", zap.Error(err))
 	}
+	// the webhook and the health probes are served with the certificate of the mounted Secret,
+	// the kubelet updates the files when the Secret is rotated
+	stopChan := make(chan struct{})
+	go certs.watch(certReloadInterval, stopChan)
 	policyGroup := os.Getenv(policyGroupEnvVarName)
 	if policyGroup == "" {
 		policyGroup = conf.DefaultPolicyGroup
 	}
 	schedulerServiceAddress := os.Getenv(schedulerServiceAddressEnvVarName)
+	// the informers are served by the web service of the shim, not by the REST API of the core
+	// the scheduler service address points to, the readiness only depends on them when it is set
+	informersURL := ""
+	if shimURL := os.Getenv(schedulerShimURLEnvVarName); shimURL != "" {
+		informersURL = strings.TrimSuffix(shimURL, "/") + schedulerInformersPath
+	}
+	readiness := newReadinessProbe(certs, informersURL)
 
 	webHook := admissionController{
 		configName:               fmt.Sprintf("%s.yaml", policyGroup),
@@ -68,9 +84,11 @@ func main() {
 	mux := http.NewServeMux()
 	mux.HandleFunc(mutateURL, webHook.serve)
 	mux.HandleFunc(validateConfURL, webHook.serve)
+	mux.HandleFunc(healthURL, serveLiveness)
+	mux.HandleFunc(readyURL, readiness.serve)
 	server := &http.Server{
 		Addr:      fmt.Sprintf(":%v", HTTPPort),
-		TLSConfig: &tls.Config{Certificates: []tls.Certificate{pair}},
+		TLSConfig: &tls.Config{GetCertificate: certs.getCertificate},
 		Handler:   mux,
 	}
 
@@ -89,6 +107,7 @@ func main() {
 	<-signalChan
 
 	log.Logger().Info("shutting down the admission controller...")
+	close(stopChan)
 	err = server.Shutdown(context.Background())
 	if err != nil {
 		log.Logger().Warn("failed to stop the admission controller",
@@ -21,6 +21,7 @@ package predicates
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
@@ -40,8 +41,15 @@ import (
 	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/tainttoleration"
 	fwruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
 
-	"github.com/apache/incubator-yunikorn-core/pkg/log"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+)
+
+// the extension points of the scheduler framework the predicate manager runs
+const (
+	extensionPreFilter = "PreFilter"
+	extensionFilter    = "Filter"
 )
 
 type PredicateManager interface {
@@ -104,14 +112,14 @@ func (p *predicateManagerImpl) runPreFilterPlugins(ctx context.Context, state *f
 		status = p.runPreFilterPlugin(ctx, pl, state, pod)
 		if !status.IsSuccess() {
 			if status.IsUnschedulable() {
-				return status, plugin
+				return status, pl.Name()
 			}
 			err := status.AsError()
-			log.Logger().Error("failed running PreFilter plugin",
+			log.Log(log.Predicates).Error("failed running PreFilter plugin",
 				zap.String("pluginName", pl.Name()),
 				zap.String("pod", fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)),
 				zap.Error(err))
-			return framework.AsStatus(fmt.Errorf("running PreFilter plugin %q: %w", pl.Name(), err)), plugin
+			return framework.AsStatus(fmt.Errorf("running PreFilter plugin %q: %w", pl.Name(), err)), pl.Name()
 		}
 	}
 
@@ -119,7 +127,10 @@ func (p *predicateManagerImpl) runPreFilterPlugins(ctx context.Context, state *f
 }
 
 func (p *predicateManagerImpl) runPreFilterPlugin(ctx context.Context, pl framework.PreFilterPlugin, state *framework.CycleState, pod *v1.Pod) *framework.Status {
-	return pl.PreFilter(ctx, state, pod)
+	startTime := time.Now()
+	status := pl.PreFilter(ctx, state, pod)
+	recordPluginMetrics(pl.Name(), extensionPreFilter, status, time.Since(startTime))
+	return status
 }
 
 func (p *predicateManagerImpl) runFilterPlugins(ctx context.Context, plugins []framework.FilterPlugin, state *framework.CycleState, pod *v1.Pod, nodeInfo *framework.NodeInfo) (status framework.PluginToStatus, plugin string) {
@@ -135,7 +146,7 @@ func (p *predicateManagerImpl) runFilterPlugins(ctx context.Context, plugins []f
 				// Filter plugins are not supposed to return any status other than
 				// Success or Unschedulable.
 				errStatus := framework.NewStatus(framework.Error, fmt.Sprintf("running %q filter plugin for pod %q: %v", pl.Name(), pod.Name, pluginStatus.Message()))
-				log.Logger().Error("failed running Filter plugin",
+				log.Log(log.Predicates).Error("failed running Filter plugin",
 					zap.String("pluginName", pl.Name()),
 					zap.String("pod", fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)),
 					zap.String("message", pluginStatus.Message()))
@@ -148,7 +159,18 @@ func (p *predicateManagerImpl) runFilterPlugins(ctx context.Context, plugins []f
 }
 
 func (p *predicateManagerImpl) runFilterPlugin(ctx context.Context, pl framework.FilterPlugin, state *framework.CycleState, pod *v1.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
-	return pl.Filter(ctx, state, pod, nodeInfo)
+	startTime := time.Now()
+	status := pl.Filter(ctx, state, pod, nodeInfo)
+	recordPluginMetrics(pl.Name(), extensionFilter, status, time.Since(startTime))
+	return status
+}
+
+// records the evaluation time of the plugin, and the status code when the plugin did not accept the pod
+func recordPluginMetrics(plugin, extensionPoint string, status *framework.Status, duration time.Duration) {
+	metrics.GetPredicateMetrics().ObserveEvaluation(plugin, extensionPoint, duration)
+	if !status.IsSuccess() {
+		metrics.GetPredicateMetrics().IncFailures(plugin, extensionPoint, status.Code().String())
+	}
 }
 
 func NewPredicateManager(handle framework.Handle) PredicateManager {
@@ -233,7 +255,7 @@ func newPredicateManagerInternal(
 
 	if !exist {
 		// this is unrecoverable
-		log.Logger().Fatal(fmt.Sprintf("BUG: Can't get default scheduler provider: %s", apiConfig.SchedulerDefaultProviderName))
+		log.Log(log.Predicates).Fatal(fmt.Sprintf("BUG: Can't get default scheduler provider: %s", apiConfig.SchedulerDefaultProviderName))
 	}
 
 	createdPlugins := make(map[string]framework.Plugin)
@@ -242,10 +264,10 @@ func newPredicateManagerInternal(
 	reservationFilterPlugins := &apiConfig.PluginSet{}
 	allocationFilterPlugins := &apiConfig.PluginSet{}
 
-	addPlugins("PreFilter", registeredPlugins.PreFilter, reservationPreFilterPlugins, reservationPreFilters)
-	addPlugins("PreFilter", registeredPlugins.PreFilter, allocationPreFilterPlugins, allocationPreFilters)
-	addPlugins("Filter", registeredPlugins.Filter, reservationFilterPlugins, reservationFilters)
-	addPlugins("Filter", registeredPlugins.Filter, allocationFilterPlugins, allocationFilters)
+	addPlugins(extensionPreFilter, registeredPlugins.PreFilter, reservationPreFilterPlugins, reservationPreFilters)
+	addPlugins(extensionPreFilter, registeredPlugins.PreFilter, allocationPreFilterPlugins, allocationPreFilters)
+	addPlugins(extensionFilter, registeredPlugins.Filter, reservationFilterPlugins, reservationFilters)
+	addPlugins(extensionFilter, registeredPlugins.Filter, allocationFilterPlugins, allocationFilters)
 
 	createPlugins(handle, pluginRegistry, reservationPreFilterPlugins, createdPlugins)
 	createPlugins(handle, pluginRegistry, allocationPreFilterPlugins, createdPlugins)
@@ -257,16 +279,16 @@ func newPredicateManagerInternal(
 	for _, v := range reservationPreFilterPlugins.Enabled {
 		plugin := createdPlugins[v.Name]
 		if plugin == nil {
-			log.Logger().Warn("plugin not found", zap.String("pluginName", v.Name))
+			log.Log(log.Predicates).Warn("plugin not found", zap.String("pluginName", v.Name))
 			continue
 		}
 		pfPlugin, ok := plugin.(framework.PreFilterPlugin)
 		if !ok {
-			log.Logger().Warn("plugin does not implement PreFilterPlugin", zap.String("pluginName", v.Name))
+			log.Log(log.Predicates).Warn("plugin does not implement PreFilterPlugin", zap.String("pluginName", v.Name))
 			continue
 		}
 		resPre = append(resPre, pfPlugin)
-		log.Logger().Debug("Registered reservation PreFilter plugin", zap.String("pluginName", plugin.Name()))
+		log.Log(log.Predicates).Debug("Registered reservation PreFilter plugin", zap.String("pluginName", plugin.Name()))
 	}
 
 	// assign allocation PreFilter plugins
@@ -274,16 +296,16 @@ func newPredicateManagerInternal(
 	for _, v := range allocationPreFilterPlugins.Enabled {
 		plugin := createdPlugins[v.Name]
 		if plugin == nil {
-			log.Logger().Warn("plugin not found", zap.String("pluginName", v.Name))
+			log.Log(log.Predicates).Warn("plugin not found", zap.String("pluginName", v.Name))
 			continue
 		}
 		pfPlugin, ok := plugin.(framework.PreFilterPlugin)
 		if !ok {
-			log.Logger().Warn("plugin does not implement PreFilterPlugin", zap.String("pluginName", v.Name))
+			log.Log(log.Predicates).Warn("plugin does not implement PreFilterPlugin", zap.String("pluginName", v.Name))
 			continue
 		}
 		allocPre = append(allocPre, pfPlugin)
-		log.Logger().Debug("Registered allocation PreFilter plugin", zap.String("pluginName", plugin.Name()))
+		log.Log(log.Predicates).Debug("Registered allocation PreFilter plugin", zap.String("pluginName", plugin.Name()))
 	}
 
 	// assign reservation Filter plugins
@@ -291,16 +313,16 @@ func newPredicateManagerInternal(
 	for _, v := range reservationFilterPlugins.Enabled {
 		plugin := createdPlugins[v.Name]
 		if plugin == nil {
-			log.Logger().Warn("plugin not found", zap.String("pluginName", v.Name))
+			log.Log(log.Predicates).Warn("plugin not found", zap.String("pluginName", v.Name))
 			continue
 		}
 		fPlugin, ok := plugin.(framework.FilterPlugin)
 		if !ok {
-			log.Logger().Warn("plugin does not implement FilterPlugin", zap.String("pluginName", v.Name))
+			log.Log(log.Predicates).Warn("plugin does not implement FilterPlugin", zap.String("pluginName", v.Name))
 			continue
 		}
 		resFilt = append(resFilt, fPlugin)
-		log.Logger().Debug("Registered reservation Filter plugin", zap.String("pluginName", plugin.Name()))
+		log.Log(log.Predicates).Debug("Registered reservation Filter plugin", zap.String("pluginName", plugin.Name()))
 	}
 
 	// assign allocation Filter plugins
@@ -308,16 +330,16 @@ func newPredicateManagerInternal(
 	for _, v := range allocationFilterPlugins.Enabled {
 		plugin := createdPlugins[v.Name]
 		if plugin == nil {
-			log.Logger().Warn("plugin not found", zap.String("pluginName", v.Name))
+			log.Log(log.Predicates).Warn("plugin not found", zap.String("pluginName", v.Name))
 			continue
 		}
 		fPlugin, ok := plugin.(framework.FilterPlugin)
 		if !ok {
-			log.Logger().Warn("plugin does not implement FilterPlugin", zap.String("pluginName", v.Name))
+			log.Log(log.Predicates).Warn("plugin does not implement FilterPlugin", zap.String("pluginName", v.Name))
 			continue
 		}
 		allocFilt = append(allocFilt, fPlugin)
-		log.Logger().Debug("Registered allocation Filter plugin", zap.String("pluginName", plugin.Name()))
+		log.Log(log.Predicates).Debug("Registered allocation Filter plugin", zap.String("pluginName", plugin.Name()))
 	}
 
 	pm := &predicateManagerImpl{
@@ -340,7 +362,7 @@ func addPlugins(phase string, source *apiConfig.PluginSet, dest *apiConfig.Plugi
 			}
 		}
 		if enabled {
-			log.Logger().Debug("adding plugin", zap.String("phase", phase), zap.String("pluginName", p.Name))
+			log.Log(log.Predicates).Debug("adding plugin", zap.String("phase", phase), zap.String("pluginName", p.Name))
 			dest.Enabled = append(dest.Enabled, p)
 		}
 	}
@@ -356,18 +378,18 @@ func createPlugins(handle framework.Handle, registry fwruntime.Registry, plugins
 		}
 		cfg, err := getPluginArgsOrDefault(pluginConfig, p.Name)
 		if err != nil {
-			log.Logger().Error("failed to create plugin config", zap.String("pluginName", p.Name), zap.Error(err))
+			log.Log(log.Predicates).Error("failed to create plugin config", zap.String("pluginName", p.Name), zap.Error(err))
 			continue
 		}
-		log.Logger().Debug("plugin config created", zap.String("pluginName", p.Name), zap.Any("cfg", cfg))
+		log.Log(log.Predicates).Debug("plugin config created", zap.String("pluginName", p.Name), zap.Any("cfg", cfg))
 
 		factory := registry[p.Name]
 		plugin, err := factory(cfg, handle)
 		if err != nil {
-			log.Logger().Error("failed to create plugin", zap.String("pluginName", p.Name), zap.Error(err))
+			log.Log(log.Predicates).Error("failed to create plugin", zap.String("pluginName", p.Name), zap.Error(err))
 			continue
 		}
-		log.Logger().Debug("plugin created", zap.String("pluginName", p.Name))
+		log.Log(log.Predicates).Debug("plugin created", zap.String("pluginName", p.Name))
 		createdPlugins[p.Name] = plugin
 	}
 }
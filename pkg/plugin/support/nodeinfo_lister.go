@@ -31,35 +31,15 @@ type nodeInfoListerImpl struct {
 }
 
 func (n nodeInfoListerImpl) List() ([]*framework.NodeInfo, error) {
-	nodes := n.cache.GetNodesInfoMapCopy()
-	nodeList := make([]*framework.NodeInfo, 0, len(nodes))
-	for _, node := range nodes {
-		nodeList = append(nodeList, node)
-	}
-
-	return nodeList, nil
+	return n.cache.GetNodesInfoList(), nil
 }
 
 func (n nodeInfoListerImpl) HavePodsWithAffinityList() ([]*framework.NodeInfo, error) {
-	nodes := n.cache.GetNodesInfoMapCopy()
-	result := make([]*framework.NodeInfo, 0, len(nodes))
-	for _, node := range nodes {
-		if len(node.PodsWithAffinity) > 0 {
-			result = append(result, node)
-		}
-	}
-	return result, nil
+	return n.cache.GetNodesWithAffinityList(), nil
 }
 
 func (n nodeInfoListerImpl) HavePodsWithRequiredAntiAffinityList() ([]*framework.NodeInfo, error) {
-	nodes := n.cache.GetNodesInfoMapCopy()
-	result := make([]*framework.NodeInfo, 0, len(nodes))
-	for _, node := range nodes {
-		if len(node.PodsWithRequiredAntiAffinity) > 0 {
-			result = append(result, node)
-		}
-	}
-	return result, nil
+	return n.cache.GetNodesWithRequiredAntiAffinityList(), nil
 }
 
 func (n nodeInfoListerImpl) Get(nodeName string) (*framework.NodeInfo, error) {
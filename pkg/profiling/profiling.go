@@ -0,0 +1,104 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package profiling
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// ConfigKey enables or disables the profiling endpoints in the scheduler ConfigMap
+const ConfigKey = "profiling.enabled"
+
+// the lock contention is sampled while profiling is enabled: 1 in mutexProfileFraction of the mutex
+// contention events is reported in the mutex profile, and one blocking event per blockProfileRate
+// nanoseconds spent blocked is reported in the block profile
+const (
+	mutexProfileFraction = 5
+	blockProfileRate     = 10000
+)
+
+var state struct {
+	enabled bool
+	once    sync.Once
+	sync.RWMutex
+}
+
+func initState() {
+	state.enabled = conf.GetSchedulerConf().EnableProfiling
+	if state.enabled {
+		sampleContention(true)
+	}
+}
+
+// starts or stops the sampling of the mutex and block profiles
+func sampleContention(enabled bool) {
+	if enabled {
+		runtime.SetMutexProfileFraction(mutexProfileFraction)
+		runtime.SetBlockProfileRate(blockProfileRate)
+		return
+	}
+	runtime.SetMutexProfileFraction(0)
+	runtime.SetBlockProfileRate(0)
+}
+
+// Enabled returns whether the pprof and execution trace endpoints are served,
+// the endpoints are disabled unless enabled in the configuration
+func Enabled() bool {
+	state.once.Do(initState)
+	state.RLock()
+	defer state.RUnlock()
+	return state.enabled
+}
+
+// SetEnabled enables or disables the profiling endpoints at runtime, the mutex
+// and block contention is only sampled while the endpoints are enabled
+func SetEnabled(enabled bool) {
+	state.once.Do(initState)
+	state.Lock()
+	defer state.Unlock()
+	if state.enabled == enabled {
+		return
+	}
+	state.enabled = enabled
+	sampleContention(enabled)
+	log.Logger().Info("profiling endpoints changed", zap.Bool("enabled", enabled))
+}
+
+// ApplyConfig applies the value of the profiling key of the scheduler ConfigMap,
+// the startup configuration applies again when the key is removed
+func ApplyConfig(value string) error {
+	if value == "" {
+		SetEnabled(conf.GetSchedulerConf().EnableProfiling)
+		return nil
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("invalid value %s for %s: %v", value, ConfigKey, err)
+	}
+	SetEnabled(enabled)
+	return nil
+}
@@ -0,0 +1,56 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package profiling
+
+import (
+	"runtime"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSetEnabled(t *testing.T) {
+	defer SetEnabled(false)
+	assert.Assert(t, !Enabled(), "profiling is disabled by default")
+	SetEnabled(true)
+	assert.Assert(t, Enabled())
+	SetEnabled(false)
+	assert.Assert(t, !Enabled())
+}
+
+func TestApplyConfig(t *testing.T) {
+	defer SetEnabled(false)
+	assert.NilError(t, ApplyConfig("true"))
+	assert.Assert(t, Enabled())
+	assert.ErrorContains(t, ApplyConfig("sometimes"), "invalid value sometimes for profiling.enabled")
+	assert.Assert(t, Enabled())
+
+	// back to the startup configuration
+	assert.NilError(t, ApplyConfig(""))
+	assert.Assert(t, !Enabled())
+}
+
+func TestContentionSampling(t *testing.T) {
+	defer SetEnabled(false)
+	SetEnabled(true)
+	// a negative fraction reads the current value without changing it
+	assert.Equal(t, runtime.SetMutexProfileFraction(-1), mutexProfileFraction)
+	SetEnabled(false)
+	assert.Equal(t, runtime.SetMutexProfileFraction(-1), 0)
+}
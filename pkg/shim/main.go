@@ -26,9 +26,15 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/apache/incubator-yunikorn-core/pkg/entrypoint"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/audit"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/buildinfo"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/eventsink"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/health"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/tracing"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/webservice"
 	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/api"
 )
 
@@ -39,20 +45,49 @@ var (
 
 func main() {
 	log.Logger().Info("Build info", zap.String("version", version), zap.String("date", date))
+	buildinfo.SetBuildInfo(version, date)
 	log.Logger().Info("starting scheduler",
 		zap.String("name", constants.SchedulerName))
 
+	configs := conf.GetSchedulerConf()
+	if err := configs.Validate(); err != nil {
+		log.Logger().Fatal("invalid scheduler configuration", zap.Error(err))
+	}
+	if err := eventsink.AddSinks(configs.EventSinks); err != nil {
+		log.Logger().Fatal("invalid event sink", zap.Error(err))
+	}
+	if configs.TracingEndpoint != "" {
+		tracing.Start(configs.TracingEndpoint, configs.TracingSampleRatio)
+	}
+	if configs.AuditLogPath != "" {
+		if err := audit.Start(configs.AuditLogPath, int64(configs.AuditLogMaxSize)*1024*1024, configs.AuditLogMaxBackups); err != nil {
+			log.Logger().Fatal("failed to open the audit log", zap.Error(err))
+		}
+	}
+
 	serviceContext := entrypoint.StartAllServicesWithLogger(log.Logger(), log.GetZapConfigs())
 
 	if sa, ok := serviceContext.RMProxy.(api.SchedulerAPI); ok {
-		ss := newShimScheduler(sa, conf.GetSchedulerConf())
+		ss := newShimScheduler(sa, configs)
+		health.Init(ss.GetSchedulerState, ss.context.InMaintenance)
 		ss.run()
 
+		webApp := webservice.NewWebApp(configs.WebServicePort, ss.context)
+		webApp.StartWebApp()
+
 		signalChan := make(chan os.Signal, 1)
 		signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
 		for range signalChan {
 			log.Logger().Info("Shutdown signal received, exiting...")
 			ss.stop()
+			// send the buffered lifecycle events
+			eventsink.Stop()
+			// export the buffered spans
+			tracing.Stop()
+			audit.Stop()
+			if err := webApp.StopWebApp(); err != nil {
+				log.Logger().Warn("failed to stop the shim web service", zap.Error(err))
+			}
 			os.Exit(0)
 		}
 	}
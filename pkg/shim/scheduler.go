@@ -19,6 +19,7 @@
 package main
 
 import (
+	"errors"
 	"os"
 	"sync"
 	"time"
@@ -36,6 +37,7 @@ import (
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/dispatcher"
 	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
 	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/api"
 	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
 )
@@ -121,6 +123,8 @@ func newShimSchedulerInternal(ctx *cache.Context, apiFactory client.APIProvider,
 	dispatcher.RegisterEventHandler(dispatcher.EventTypeNode, ctx.SchedulerNodeEventHandler())
 	dispatcher.RegisterEventHandler(dispatcher.EventTypeScheduler, ss.SchedulerEventHandler())
 	dispatcher.RegisterEventHandler(dispatcher.EventTypeAppStatus, am.ApplicationStateUpdateEventHandler())
+	dispatcher.RegisterPanicHandler(ctx.EventPanicHandler())
+	dispatcher.SetEventTracer(ctx.EventTracer())
 
 	return ss
 }
@@ -170,40 +174,60 @@ func (ss *KubernetesShim) recoverSchedulerState(e *fsm.Event) {
 	// do not block main thread
 	go func() {
 		log.Logger().Info("recovering scheduler states")
+		start := time.Now()
+		timeout := conf.GetSchedulerConf().RecoveryTimeout
 		// step 1: recover all applications
 		// this step, we collect all the existing allocated pods from api-server,
 		// identify the scheduling identity (aka applicationInfo) from the pod,
 		// and then add these applications to the scheduler.
-		if err := ss.appManager.WaitForRecovery(30 * time.Second); err != nil {
-			// failed
-			log.Logger().Error("scheduler recovery failed", zap.Error(err))
-			dispatcher.Dispatch(ShimSchedulerEvent{
-				event: events.RecoverSchedulerFailed,
-			})
-			return
+		recoverApps := func() error {
+			return ss.appManager.WaitForRecovery(timeout)
 		}
 
 		// step 2: recover existing allocations
 		// this step, we collect all existing allocations (allocated pods) from api-server,
 		// rerun the scheduling for these allocations in order to restore scheduler-state,
 		// the rerun is like a replay, not a actual scheduling procedure.
+		// The allocations are collected while the applications are recovered, the nodes
+		// are sent to the core once the applications are recovered.
 		recoverableAppManagers := make([]interfaces.Recoverable, 0)
 		for _, appMgr := range ss.appManager.GetAllManagers() {
 			if m, ok := appMgr.(interfaces.Recoverable); ok {
 				recoverableAppManagers = append(recoverableAppManagers, m)
 			}
 		}
-		if err := ss.context.WaitForRecovery(recoverableAppManagers, 30*time.Second); err != nil {
+		err := ss.context.WaitForRecovery(recoverableAppManagers, recoverApps, timeout)
+		var timeoutErr *interfaces.RecoveryTimeoutError
+		timedOut := errors.As(err, &timeoutErr)
+		metrics.GetRecoveryMetrics().ObserveRecovery(time.Since(start), timedOut)
+		switch {
+		case timedOut:
+			// the nodes that are not recovered yet are not used by the core until it accepts them,
+			// the applications that are not recovered yet are recovered once the core accepts them:
+			// the scheduling can start with the objects recovered so far
+			nodes := metrics.GetRecoveryMetrics().GetProgress(metrics.ObjectNode)
+			apps := metrics.GetRecoveryMetrics().GetProgress(metrics.ObjectApplication)
+			log.Logger().Warn("scheduler recovery did not finish in time, continuing with the recovered objects",
+				zap.Duration("timeout", timeout),
+				zap.Int("recoveredNodes", nodes.Recovered),
+				zap.Int("totalNodes", nodes.Total),
+				zap.Int("recoveredApps", apps.Recovered),
+				zap.Int("totalApps", apps.Total),
+				zap.Error(err))
+		case err != nil:
 			// failed
 			log.Logger().Error("scheduler recovery failed", zap.Error(err))
 			dispatcher.Dispatch(ShimSchedulerEvent{
 				event: events.RecoverSchedulerFailed,
 			})
 			return
+		default:
+			// success
+			log.Logger().Info("scheduler recovery succeed")
 		}
-
-		// success
-		log.Logger().Info("scheduler recovery succeed")
+		// the nodes and the existing allocations are known by the core,
+		// the task events held back during the recovery can be handled
+		dispatcher.ExitRecoveryMode()
 		dispatcher.Dispatch(ShimSchedulerEvent{
 			event: events.RecoverSchedulerSucceed,
 		})
@@ -218,6 +242,14 @@ func (ss *KubernetesShim) doScheduling(e *fsm.Event) {
 	go wait.Until(ss.schedule, conf.GetSchedulerConf().GetSchedulingInterval(), ss.stopChan)
 	// log a message if no outstanding requests were found for a while
 	go wait.Until(ss.checkOutstandingApps, outstandingAppLogTimeout, ss.stopChan)
+	// account the memory held by the caches, the terminal objects are removed above the limit
+	if conf.GetSchedulerConf().EnableMemoryAccounting {
+		go wait.Until(ss.context.CheckMemoryBudget, cache.MemoryCheckInterval, ss.stopChan)
+	}
+	// keep the autoscaler from removing the nodes the scheduler is about to use
+	if conf.GetSchedulerConf().ScaleDownProtection {
+		go wait.Until(ss.context.ProtectScaleDown, cache.ScaleDownProtectionInterval, ss.stopChan)
+	}
 }
 
 func (ss *KubernetesShim) registerShimLayer() error {
@@ -228,6 +260,13 @@ func (ss *KubernetesShim) registerShimLayer() error {
 		PolicyGroup: configuration.PolicyGroup,
 	}
 
+	if err := ss.context.LoadQueuesConfig(); err != nil {
+		log.Logger().Error("failed to deliver the queue configuration to the core", zap.Error(err))
+		if configuration.ConfigDelivery == conf.ConfigDeliveryDirect {
+			return err
+		}
+	}
+
 	log.Logger().Info("register RM to the scheduler",
 		zap.String("clusterID", configuration.ClusterID),
 		zap.String("clusterVersion", configuration.ClusterVersion),
@@ -263,6 +302,10 @@ func (ss *KubernetesShim) canHandle(se events.SchedulerEvent) bool {
 
 // each schedule iteration, we scan all apps and triggers app state transition
 func (ss *KubernetesShim) schedule() {
+	// no new requests are sent to the core while the scheduling is paused
+	if ss.context.InMaintenance() {
+		return
+	}
 	apps := ss.context.SelectApplications(nil)
 	for _, app := range apps {
 		if app.Schedule() {
@@ -280,6 +323,12 @@ func (ss *KubernetesShim) run() {
 	// it needs to be started at first
 	dispatcher.Start()
 
+	// hold back the task events until the recovery is done, this ensures that no asks
+	// are sent to the core before the nodes and the existing allocations are registered
+	if conf.GetSchedulerConf().SyncRecovery {
+		dispatcher.EnterRecoveryMode()
+	}
+
 	// run the placeholder manager
 	ss.phManager.Start()
 
@@ -312,12 +361,16 @@ func (ss *KubernetesShim) stop() {
 	log.Logger().Info("stopping scheduler")
 	select {
 	case ss.stopChan <- struct{}{}:
-		// stop the dispatcher
-		dispatcher.Stop()
-		// stop the app manager
+		// stop the producers of the events first: the app manager, the placeholder manager
+		// and the informers
 		ss.appManager.Stop()
-		// stop the placeholder manager
 		ss.phManager.Stop()
+		ss.apiFactory.Stop()
+		// release the task events held back when the recovery did not finish
+		dispatcher.ExitRecoveryMode()
+		// drain the dispatcher, the task and application events already dispatched are
+		// handled so that the apps are not left in a wrong state by a restart
+		dispatcher.Shutdown(conf.GetSchedulerConf().DispatcherDrainTimeout)
 	default:
 		log.Logger().Info("scheduler is already stopped")
 	}
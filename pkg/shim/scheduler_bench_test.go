@@ -0,0 +1,285 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/cache"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/callback"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/api"
+	"github.com/apache/incubator-yunikorn-scheduler-interface/lib/go/si"
+)
+
+const (
+	benchPodsPerApp         = 100
+	benchSchedulingInterval = 10 * time.Millisecond
+	benchBindTimeout        = 5 * time.Minute
+)
+
+// benchCore is a stand-in for the scheduler core: it accepts every application and node
+// and allocates each ask to the next node in a round-robin fashion. The responses are sent
+// asynchronously like the real core does, the benchmark measures the shim only.
+type benchCore struct {
+	callback api.ResourceManagerCallback
+	nodes    []string
+	next     uint64
+	lock     sync.RWMutex
+}
+
+func newBenchCore(numNodes int) *benchCore {
+	nodes := make([]string, numNodes)
+	for i := range nodes {
+		nodes[i] = fmt.Sprintf("bench-node-%d", i)
+	}
+	return &benchCore{nodes: nodes}
+}
+
+func (c *benchCore) getCallback() api.ResourceManagerCallback {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.callback
+}
+
+func (c *benchCore) RegisterResourceManager(request *si.RegisterResourceManagerRequest,
+	callback api.ResourceManagerCallback) (*si.RegisterResourceManagerResponse, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.callback = callback
+	return &si.RegisterResourceManagerResponse{}, nil
+}
+
+func (c *benchCore) UpdateAllocation(request *si.AllocationRequest) error {
+	if len(request.Asks) == 0 {
+		return nil
+	}
+	allocs := make([]*si.Allocation, 0, len(request.Asks))
+	for _, ask := range request.Asks {
+		next := atomic.AddUint64(&c.next, 1)
+		allocs = append(allocs, &si.Allocation{
+			AllocationKey:    ask.AllocationKey,
+			UUID:             fmt.Sprintf("%s-%d", ask.AllocationKey, next),
+			ResourcePerAlloc: ask.ResourceAsk,
+			NodeID:           c.nodes[next%uint64(len(c.nodes))],
+			ApplicationID:    ask.ApplicationID,
+			PartitionName:    ask.PartitionName,
+		})
+	}
+	go func() {
+		_ = c.getCallback().UpdateAllocation(&si.AllocationResponse{New: allocs})
+	}()
+	return nil
+}
+
+func (c *benchCore) UpdateApplication(request *si.ApplicationRequest) error {
+	if len(request.New) == 0 {
+		return nil
+	}
+	accepted := make([]*si.AcceptedApplication, 0, len(request.New))
+	for _, app := range request.New {
+		accepted = append(accepted, &si.AcceptedApplication{ApplicationID: app.ApplicationID})
+	}
+	go func() {
+		_ = c.getCallback().UpdateApplication(&si.ApplicationResponse{Accepted: accepted})
+	}()
+	return nil
+}
+
+func (c *benchCore) UpdateNode(request *si.NodeRequest) error {
+	accepted := make([]*si.AcceptedNode, 0, len(request.Nodes))
+	for _, node := range request.Nodes {
+		if node.Action == si.NodeInfo_CREATE {
+			accepted = append(accepted, &si.AcceptedNode{NodeID: node.NodeID})
+		}
+	}
+	if len(accepted) == 0 {
+		return nil
+	}
+	go func() {
+		_ = c.getCallback().UpdateNode(&si.NodeResponse{Accepted: accepted})
+	}()
+	return nil
+}
+
+func (c *benchCore) UpdateConfiguration(rmID string) error {
+	return nil
+}
+
+// benchRecorder keeps the time each pod was submitted and the latency until it was bound.
+type benchRecorder struct {
+	submitted sync.Map
+	latencies []time.Duration
+	done      chan struct{}
+	expected  int
+	lock      sync.Mutex
+}
+
+func newBenchRecorder(expected int) *benchRecorder {
+	return &benchRecorder{
+		latencies: make([]time.Duration, 0, expected),
+		done:      make(chan struct{}),
+		expected:  expected,
+	}
+}
+
+func (r *benchRecorder) submit(podName string) {
+	r.submitted.Store(podName, time.Now())
+}
+
+func (r *benchRecorder) bound(pod *v1.Pod, hostID string) error {
+	start, ok := r.submitted.Load(pod.Name)
+	if !ok {
+		return nil
+	}
+	latency := time.Since(start.(time.Time))
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.latencies = append(r.latencies, latency)
+	if len(r.latencies) == r.expected {
+		close(r.done)
+	}
+	return nil
+}
+
+func (r *benchRecorder) percentile(p float64) time.Duration {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if len(r.latencies) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(r.latencies))
+	copy(sorted, r.latencies)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	return sorted[int(float64(len(sorted)-1)*p)]
+}
+
+// BenchmarkScheduling drives pods through the shim with a mocked core and api-server.
+// Each op is one pod going from being added to the context until it is bound, the
+// throughput and the latency percentiles are reported as custom metrics.
+// Run it with: make bench
+func BenchmarkScheduling(b *testing.B) {
+	tests := []struct {
+		nodes int
+		rate  int // pods per second, 0 means as fast as possible
+	}{
+		{nodes: 100, rate: 0},
+		{nodes: 1000, rate: 0},
+		{nodes: 1000, rate: 1000},
+	}
+	for _, tt := range tests {
+		b.Run(fmt.Sprintf("nodes=%d/rate=%d", tt.nodes, tt.rate), func(b *testing.B) {
+			benchmarkScheduling(b, tt.nodes, tt.rate)
+		})
+	}
+}
+
+func benchmarkScheduling(b *testing.B, numNodes, rate int) {
+	schedulerConf := conf.GetSchedulerConf()
+	schedulerConf.SetTestMode(true)
+	schedulerConf.Lock()
+	interval := schedulerConf.Interval
+	schedulerConf.Interval = benchSchedulingInterval
+	schedulerConf.Unlock()
+	defer func() {
+		schedulerConf.Lock()
+		schedulerConf.Interval = interval
+		schedulerConf.Unlock()
+	}()
+
+	recorder := newBenchRecorder(b.N)
+	mockedAPIProvider := client.NewMockedAPIProvider()
+	mockedAPIProvider.GetAPIs().SchedulerAPI = newBenchCore(numNodes)
+	mockedAPIProvider.MockBindFn(recorder.bound)
+
+	ctx := cache.NewContext(mockedAPIProvider)
+	shim := newShimSchedulerInternal(ctx, mockedAPIProvider,
+		appmgmt.NewAMService(ctx, mockedAPIProvider), callback.NewAsyncRMCallback(ctx))
+	shim.run()
+	defer shim.stop()
+	if err := waitShimSchedulerState(shim, events.States().Scheduler.Running, 10*time.Second); err != nil {
+		b.Fatal(err)
+	}
+
+	var throttle <-chan time.Time
+	if rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(rate))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		appID := fmt.Sprintf("bench-app-%d", i/benchPodsPerApp)
+		if i%benchPodsPerApp == 0 {
+			ctx.AddApplication(&interfaces.AddApplicationRequest{
+				Metadata: interfaces.ApplicationMetadata{
+					ApplicationID: appID,
+					QueueName:     "root.bench",
+					User:          "bench-user",
+				},
+			})
+		}
+		if throttle != nil {
+			<-throttle
+		}
+		podName := fmt.Sprintf("bench-pod-%d", i)
+		recorder.submit(podName)
+		ctx.AddTask(&interfaces.AddTaskRequest{
+			Metadata: interfaces.TaskMetadata{
+				ApplicationID: appID,
+				TaskID:        podName,
+				Pod: &v1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      podName,
+						Namespace: "default",
+						UID:       types.UID(podName),
+					},
+				},
+			},
+		})
+	}
+	select {
+	case <-recorder.done:
+	case <-time.After(benchBindTimeout):
+		b.Fatalf("not all pods were bound within %v", benchBindTimeout)
+	}
+	elapsed := time.Since(start)
+	b.StopTimer()
+
+	b.ReportMetric(float64(b.N)/elapsed.Seconds(), "pods/s")
+	b.ReportMetric(float64(recorder.percentile(0.5).Microseconds())/1000, "p50-ms")
+	b.ReportMetric(float64(recorder.percentile(0.99).Microseconds())/1000, "p99-ms")
+}
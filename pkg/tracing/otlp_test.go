@@ -0,0 +1,83 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package tracing
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestOTLPExporter(t *testing.T) {
+	var contentType string
+	var received otlpRequest
+	var decodeErr error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		body, err := ioutil.ReadAll(r.Body)
+		if err == nil {
+			err = json.Unmarshal(body, &received)
+		}
+		decodeErr = err
+	}))
+	defer server.Close()
+
+	start := time.Unix(100, 0)
+	span := &Span{
+		SpanContext: SpanContext{
+			TraceID: [16]byte{1},
+			SpanID:  [8]byte{2},
+		},
+		ParentSpanID: [8]byte{3},
+		Name:         "pod.bind",
+		Start:        start,
+		End:          start.Add(time.Second),
+		Attributes:   map[string]string{"b": "2", "a": "1"},
+		Error:        "bind failed",
+	}
+	assert.NilError(t, newOTLPExporter(server.URL).Export([]*Span{span}))
+	assert.NilError(t, decodeErr)
+	assert.Equal(t, contentType, "application/json")
+	assert.Equal(t, len(received.ResourceSpans), 1)
+	assert.Equal(t, received.ResourceSpans[0].Resource.Attributes[0].Value.StringValue, serviceName)
+	spans := received.ResourceSpans[0].ScopeSpans[0].Spans
+	assert.Equal(t, len(spans), 1)
+	assert.Equal(t, spans[0].TraceID, "01000000000000000000000000000000")
+	assert.Equal(t, spans[0].SpanID, "0200000000000000")
+	assert.Equal(t, spans[0].ParentSpanID, "0300000000000000")
+	assert.Equal(t, spans[0].StartTimeUnixNano, "100000000000")
+	assert.Equal(t, spans[0].EndTimeUnixNano, "101000000000")
+	assert.Equal(t, spans[0].Attributes[0].Key, "a")
+	assert.Equal(t, spans[0].Status.Code, otlpStatusError)
+	assert.Equal(t, spans[0].Status.Message, "bind failed")
+}
+
+func TestOTLPExporterError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	err := newOTLPExporter(server.URL).Export([]*Span{{Name: "pod.bind"}})
+	assert.ErrorContains(t, err, "returned status 503")
+}
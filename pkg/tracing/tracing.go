@@ -0,0 +1,308 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// the name of the service in the exported traces
+const serviceName = "yunikorn-k8shim"
+
+// TraceparentTag is the tag of the asks sent to the core that carries the span context of the
+// trace of the pod, in the W3C traceparent format
+const TraceparentTag = "traceparent"
+
+const (
+	// maximum number of spans buffered for the export, new spans are dropped when the buffer is full
+	bufferSize = 10000
+	// maximum number of spans exported at once
+	batchSize = 512
+)
+
+// the buffered spans are exported at least this often
+var flushInterval = 5 * time.Second
+
+// SpanContext identifies a span within a trace. It is propagated to other components in the
+// W3C trace context format, see Traceparent.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+}
+
+// IsValid returns false for the empty span context of an object that is not traced
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{}
+}
+
+// Traceparent returns the span context as the value of a W3C traceparent header
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-01", hex.EncodeToString(sc.TraceID[:]), hex.EncodeToString(sc.SpanID[:]))
+}
+
+// ParseTraceparent reads the span context from the value of a W3C traceparent header
+func ParseTraceparent(value string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(value, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return sc, fmt.Errorf("invalid traceparent %s", value)
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, fmt.Errorf("invalid trace ID in traceparent %s: %v", value, err)
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, fmt.Errorf("invalid span ID in traceparent %s: %v", value, err)
+	}
+	if !sc.IsValid() {
+		return sc, fmt.Errorf("invalid traceparent %s: empty trace ID", value)
+	}
+	return sc, nil
+}
+
+// Span is a finished operation of a trace
+type Span struct {
+	SpanContext
+	ParentSpanID [8]byte
+	Name         string
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]string
+	// the error the operation failed with, empty when it succeeded
+	Error string
+}
+
+// Exporter sends the finished spans to the tracing backend. Export is never called concurrently,
+// the spans of a failed export are dropped.
+type Exporter interface {
+	Export(spans []*Span) error
+}
+
+type tracer struct {
+	exporter    Exporter
+	sampleRatio float64
+	buffer      chan *Span
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+var current = struct {
+	tracer *tracer
+	sync.RWMutex
+}{}
+
+// Start exports the spans to the OTLP/HTTP endpoint, the given ratio of the traces is sampled
+func Start(endpoint string, sampleRatio float64) {
+	StartWithExporter(newOTLPExporter(endpoint), sampleRatio)
+	log.Logger().Info("exporting traces",
+		zap.String("endpoint", endpoint),
+		zap.Float64("sampleRatio", sampleRatio))
+}
+
+// StartWithExporter exports the spans with the exporter, any tracer already running is stopped
+func StartWithExporter(exporter Exporter, sampleRatio float64) {
+	Stop()
+	t := &tracer{
+		exporter:    exporter,
+		sampleRatio: sampleRatio,
+		buffer:      make(chan *Span, bufferSize),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	current.Lock()
+	current.tracer = t
+	current.Unlock()
+	go t.run()
+}
+
+// Stop exports the buffered spans and disables the tracing
+func Stop() {
+	current.Lock()
+	t := current.tracer
+	current.tracer = nil
+	current.Unlock()
+	if t != nil {
+		close(t.stop)
+		<-t.done
+	}
+}
+
+// Enabled returns true when the spans are exported
+func Enabled() bool {
+	current.RLock()
+	defer current.RUnlock()
+	return current.tracer != nil
+}
+
+// NewTrace starts a trace for the object with the key, e.g. the UID of a pod. The sampling is
+// decided on the key: the same object is always either traced or not. The returned span context
+// is the root of the trace, it is not valid when the object is not traced.
+func NewTrace(key string) SpanContext {
+	current.RLock()
+	defer current.RUnlock()
+	if current.tracer == nil || !sampled(key, current.tracer.sampleRatio) {
+		return SpanContext{}
+	}
+	return SpanContext{
+		TraceID: newTraceID(),
+		SpanID:  newSpanID(),
+	}
+}
+
+// RecordSpan records a finished span, the parent is the span context of the operation it is part of.
+// Returns the span context of the span, the span is not recorded when the parent is not valid.
+func RecordSpan(name string, parent SpanContext, start, end time.Time, attributes map[string]string, err error) SpanContext {
+	if !parent.IsValid() {
+		return SpanContext{}
+	}
+	current.RLock()
+	defer current.RUnlock()
+	if current.tracer == nil {
+		return SpanContext{}
+	}
+	span := &Span{
+		SpanContext: SpanContext{
+			TraceID: parent.TraceID,
+			SpanID:  newSpanID(),
+		},
+		ParentSpanID: parent.SpanID,
+		Name:         name,
+		Start:        start,
+		End:          end,
+		Attributes:   attributes,
+	}
+	if err != nil {
+		span.Error = err.Error()
+	}
+	current.tracer.add(span)
+	return span.SpanContext
+}
+
+// RecordRootSpan records the root span of a trace started by NewTrace
+func RecordRootSpan(name string, root SpanContext, start, end time.Time, attributes map[string]string, err error) {
+	if !root.IsValid() {
+		return
+	}
+	current.RLock()
+	defer current.RUnlock()
+	if current.tracer == nil {
+		return
+	}
+	span := &Span{
+		SpanContext: root,
+		Name:        name,
+		Start:       start,
+		End:         end,
+		Attributes:  attributes,
+	}
+	if err != nil {
+		span.Error = err.Error()
+	}
+	current.tracer.add(span)
+}
+
+func (t *tracer) add(span *Span) {
+	select {
+	case t.buffer <- span:
+	default:
+		log.Logger().Debug("trace buffer is full, dropping span",
+			zap.String("span", span.Name))
+	}
+}
+
+func (t *tracer) run() {
+	defer close(t.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	batch := make([]*Span, 0, batchSize)
+	for {
+		select {
+		case span := <-t.buffer:
+			batch = append(batch, span)
+			if len(batch) >= batchSize {
+				t.export(batch)
+				batch = make([]*Span, 0, batchSize)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				t.export(batch)
+				batch = make([]*Span, 0, batchSize)
+			}
+		case <-t.stop:
+			// the tracer is no longer current, nothing is added to the buffer
+			for len(t.buffer) > 0 {
+				batch = append(batch, <-t.buffer)
+			}
+			if len(batch) > 0 {
+				t.export(batch)
+			}
+			return
+		}
+	}
+}
+
+func (t *tracer) export(batch []*Span) {
+	if err := t.exporter.Export(batch); err != nil {
+		log.Logger().Warn("failed to export spans, dropping them",
+			zap.Int("spans", len(batch)),
+			zap.Error(err))
+	}
+}
+
+// the objects are sampled on the hash of their key
+func sampled(key string, ratio float64) bool {
+	if ratio >= 1 {
+		return true
+	}
+	if ratio <= 0 {
+		return false
+	}
+	h := fnv.New64a()
+	//nolint:errcheck
+	_, _ = h.Write([]byte(key))
+	return float64(h.Sum64()%10000) < ratio*10000
+}
+
+func newTraceID() [16]byte {
+	var id [16]byte
+	for id == [16]byte{} {
+		//nolint:errcheck
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() [8]byte {
+	var id [8]byte
+	for binary.BigEndian.Uint64(id[:]) == 0 {
+		//nolint:errcheck
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
@@ -0,0 +1,114 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package tracing
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+type recordingExporter struct {
+	spans []*Span
+	sync.Mutex
+}
+
+func (e *recordingExporter) Export(spans []*Span) error {
+	e.Lock()
+	defer e.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *recordingExporter) getSpans() []*Span {
+	e.Lock()
+	defer e.Unlock()
+	return e.spans
+}
+
+func TestTraceparent(t *testing.T) {
+	sc := SpanContext{
+		TraceID: [16]byte{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:  [8]byte{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	}
+	assert.Equal(t, sc.Traceparent(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	parsed, err := ParseTraceparent(sc.Traceparent())
+	assert.NilError(t, err)
+	assert.Equal(t, parsed, sc)
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+	} {
+		_, err = ParseTraceparent(invalid)
+		assert.Assert(t, err != nil, "expected an error for %s", invalid)
+	}
+}
+
+func TestSampled(t *testing.T) {
+	assert.Assert(t, sampled("pod-1", 1))
+	assert.Assert(t, !sampled("pod-1", 0))
+	count := 0
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("pod-%d", i)
+		if sampled(key, 0.5) {
+			count++
+		}
+		// the decision is stable for a key
+		assert.Equal(t, sampled(key, 0.5), sampled(key, 0.5))
+	}
+	assert.Assert(t, count > 350 && count < 650, "unexpected number of sampled keys: %d", count)
+}
+
+func TestRecordSpans(t *testing.T) {
+	// nothing is recorded when the tracing is disabled
+	assert.Assert(t, !Enabled())
+	assert.Assert(t, !NewTrace("pod-1").IsValid())
+
+	exporter := &recordingExporter{}
+	StartWithExporter(exporter, 1)
+	assert.Assert(t, Enabled())
+	root := NewTrace("pod-1")
+	assert.Assert(t, root.IsValid())
+	start := time.Now()
+	child := RecordSpan("child", root, start, start.Add(time.Second), map[string]string{"key": "value"}, nil)
+	assert.Equal(t, child.TraceID, root.TraceID)
+	assert.Assert(t, child.SpanID != root.SpanID)
+	assert.Assert(t, !RecordSpan("orphan", SpanContext{}, start, start, nil, nil).IsValid())
+	RecordRootSpan("root", root, start, start.Add(2*time.Second), nil, fmt.Errorf("failed"))
+
+	// the buffered spans are exported on stop
+	Stop()
+	assert.Assert(t, !Enabled())
+	spans := exporter.getSpans()
+	assert.Equal(t, len(spans), 2)
+	assert.Equal(t, spans[0].Name, "child")
+	assert.Equal(t, spans[0].ParentSpanID, root.SpanID)
+	assert.Equal(t, spans[0].Attributes["key"], "value")
+	assert.Equal(t, spans[1].Name, "root")
+	assert.Equal(t, spans[1].SpanContext, root)
+	assert.Equal(t, spans[1].ParentSpanID, [8]byte{})
+	assert.Equal(t, spans[1].Error, "failed")
+}
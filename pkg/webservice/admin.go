@@ -0,0 +1,139 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package webservice
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+)
+
+type forceApplicationRequest struct {
+	Reason string `json:"reason"`
+}
+
+type forceApplicationResult struct {
+	ApplicationID string `json:"applicationID"`
+	State         string `json:"state"`
+}
+
+// forces the application to the Completed state, releasing its asks and allocations
+func forceCompleteApplication(w http.ResponseWriter, r *http.Request) {
+	forceApplicationState(w, r, events.States().Application.Completed)
+}
+
+// forces the application to the Failed state, releasing its asks and allocations
+func forceFailApplication(w http.ResponseWriter, r *http.Request) {
+	forceApplicationState(w, r, events.States().Application.Failed)
+}
+
+func forceApplicationState(w http.ResponseWriter, r *http.Request, state string) {
+	writeHeaders(w)
+	appID := mux.Vars(r)["appID"]
+	var request forceApplicationRequest
+	// the reason is optional, an empty body is accepted
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if request.Reason == "" {
+		request.Reason = "forced through the admin API"
+	}
+	if schedulerContext.GetApplication(appID) == nil {
+		http.Error(w, "application "+appID+" not found", http.StatusNotFound)
+		return
+	}
+	if err := schedulerContext.ForceApplicationState(appID, state, request.Reason, getCaller(r)); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	writeJSON(w, &forceApplicationResult{ApplicationID: appID, State: state})
+}
+
+// returns whether the scheduling is paused
+func getMaintenance(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	writeJSON(w, schedulerContext.GetMaintenance())
+}
+
+type maintenanceRequest struct {
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason"`
+}
+
+// pauses or resumes the scheduling, the maintenance mode ends when the shim restarts
+func setMaintenance(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	var request maintenanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, schedulerContext.SetMaintenance(request.Enabled, request.Reason, getCaller(r)))
+}
+
+type resyncRequest struct {
+	Reason string `json:"reason"`
+}
+
+// rebuilds the scheduler cache and the context from the api-server, without restarting the shim
+func resyncCache(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	var request resyncRequest
+	// the reason is optional, an empty body is accepted
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if request.Reason == "" {
+		request.Reason = "requested through the admin API"
+	}
+	result, err := schedulerContext.Resync(request.Reason, getCaller(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, result)
+}
+
+type namespaceRefreshRequest struct {
+	Namespace string `json:"namespace"`
+}
+
+type namespaceRefreshResult struct {
+	UpdatedApplications []string `json:"updatedApplications"`
+}
+
+// reads the namespace annotations again and updates the namespace tags of the applications,
+// all the namespaces are refreshed when no namespace is given
+func refreshNamespaces(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	var request namespaceRefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, &namespaceRefreshResult{
+		UpdatedApplications: schedulerContext.RefreshNamespaceTags(request.Namespace, getCaller(r)),
+	})
+}
@@ -0,0 +1,139 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package webservice
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/appmgmt/interfaces"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/cache"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+)
+
+func TestForceApplicationState(t *testing.T) {
+	context := cache.NewContext(client.NewMockedAPIProvider())
+	context.AddApplication(&interfaces.AddApplicationRequest{
+		Metadata: interfaces.ApplicationMetadata{
+			ApplicationID: "app01",
+			QueueName:     "root.a",
+			User:          "test-user",
+		},
+	})
+	NewWebApp(0, context)
+	post := func(path, token, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", path, strings.NewReader(body))
+		assert.NilError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp := httptest.NewRecorder()
+		newRouter().ServeHTTP(resp, req)
+		return resp
+	}
+
+	// disabled without an admin token
+	assert.Equal(t, post("/ws/v1/admin/apps/app01/fail", "secret", "").Code, http.StatusForbidden)
+
+	conf.GetSchedulerConf().UpdateSecretValues(map[string][]byte{conf.SecretAdminToken: []byte("secret")})
+	defer conf.GetSchedulerConf().UpdateSecretValues(nil)
+	assert.Equal(t, post("/ws/v1/admin/apps/app01/fail", "", "").Code, http.StatusUnauthorized)
+	assert.Equal(t, post("/ws/v1/admin/apps/app01/fail", "wrong", "").Code, http.StatusUnauthorized)
+	assert.Equal(t, post("/ws/v1/admin/apps/unknown/fail", "secret", "").Code, http.StatusNotFound)
+	assert.Equal(t, post("/ws/v1/admin/apps/app01/fail", "secret", "{").Code, http.StatusBadRequest)
+
+	resp := post("/ws/v1/admin/apps/app01/complete", "secret", `{"reason":"stuck in Accepted"}`)
+	assert.Equal(t, resp.Code, http.StatusOK)
+	assert.Equal(t, context.GetApplication("app01").GetApplicationState(), events.States().Application.Completed)
+	assert.Equal(t, strings.TrimSpace(resp.Body.String()), `{"applicationID":"app01","state":"Completed"}`)
+
+	// already completed
+	assert.Equal(t, post("/ws/v1/admin/apps/app01/complete", "secret", "").Code, http.StatusConflict)
+}
+
+func TestMaintenance(t *testing.T) {
+	NewWebApp(0, cache.NewContext(client.NewMockedAPIProvider()))
+	conf.GetSchedulerConf().UpdateSecretValues(map[string][]byte{conf.SecretAdminToken: []byte("secret")})
+	defer conf.GetSchedulerConf().UpdateSecretValues(nil)
+
+	req, err := http.NewRequest("POST", "/ws/v1/admin/maintenance", strings.NewReader(`{"enabled":true,"reason":"core upgrade"}`))
+	assert.NilError(t, err)
+	resp := httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusUnauthorized)
+
+	req.Header.Set("Authorization", "Bearer secret")
+	resp = httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusOK)
+
+	req, err = http.NewRequest("GET", "/ws/v1/maintenance", nil)
+	assert.NilError(t, err)
+	resp = httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusOK)
+	var state cache.MaintenanceState
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &state))
+	assert.Assert(t, state.Enabled)
+	assert.Equal(t, state.Reason, "core upgrade")
+	assert.Equal(t, state.By, adminTokenCaller)
+	assert.Assert(t, schedulerContext.InMaintenance())
+}
+
+func TestResyncCache(t *testing.T) {
+	NewWebApp(0, cache.NewContext(client.NewMockedAPIProvider()))
+	conf.GetSchedulerConf().UpdateSecretValues(map[string][]byte{conf.SecretAdminToken: []byte("secret")})
+	defer conf.GetSchedulerConf().UpdateSecretValues(nil)
+
+	req, err := http.NewRequest("POST", "/ws/v1/admin/resync", strings.NewReader(`{"reason":"cache corruption"}`))
+	assert.NilError(t, err)
+	resp := httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusUnauthorized)
+
+	req.Header.Set("Authorization", "Bearer secret")
+	resp = httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusOK)
+	var result cache.ResyncResult
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+	assert.Equal(t, result.NodesAdded, 0)
+	assert.Equal(t, result.TasksCompleted, 0)
+}
+
+func TestRefreshNamespaces(t *testing.T) {
+	NewWebApp(0, cache.NewContext(client.NewMockedAPIProvider()))
+	conf.GetSchedulerConf().UpdateSecretValues(map[string][]byte{conf.SecretAdminToken: []byte("secret")})
+	defer conf.GetSchedulerConf().UpdateSecretValues(nil)
+
+	req, err := http.NewRequest("POST", "/ws/v1/admin/namespaces/refresh", strings.NewReader(`{"namespace":"default"}`))
+	assert.NilError(t, err)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp := httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusOK)
+	assert.Equal(t, strings.TrimSpace(resp.Body.String()), `{"updatedApplications":[]}`)
+}
@@ -0,0 +1,162 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package webservice
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+const bearerPrefix = "Bearer "
+
+// the caller identity recorded for the requests authenticated with the admin token of the config Secret,
+// and for the client certificates without a common name
+const (
+	adminTokenCaller        = "admin-token"
+	clientCertificateCaller = "client-certificate"
+)
+
+// the key of the caller identity in the context of an authenticated request
+type callerKey struct{}
+
+// returns the identity of the caller that was authenticated for the request, empty when the endpoint
+// is not authenticated
+func getCaller(r *http.Request) string {
+	caller, _ := r.Context().Value(callerKey{}).(string)
+	return caller
+}
+
+// the admin operations require an authenticated and authorized caller, the operations are disabled
+// when no caller can be authenticated
+func adminOnly(handler http.HandlerFunc) http.HandlerFunc {
+	return authorized(handler, "admin operations")
+}
+
+// the debug endpoints expose the internal state of the shim, the callers are authenticated and
+// authorized like the callers of the admin operations when webServiceAuthDebug is set
+func debugOnly(handler http.HandlerFunc) http.HandlerFunc {
+	protected := authorized(handler, "debug endpoints")
+	return func(w http.ResponseWriter, r *http.Request) {
+		if conf.GetSchedulerConf().WebServiceAuthDebug {
+			protected(w, r)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func authorized(handler http.HandlerFunc, endpoints string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		caller, status, err := authenticate(r, endpoints)
+		if err != nil {
+			log.Logger().Warn("web service request rejected",
+				zap.String("uri", r.RequestURI),
+				zap.String("remoteAddr", r.RemoteAddr),
+				zap.Int("status", status),
+				zap.Error(err))
+			if status == http.StatusUnauthorized {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		handler(w, r.WithContext(context.WithValue(r.Context(), callerKey{}, caller)))
+	}
+}
+
+// returns the identity of the caller of the request. The client certificates are verified against the
+// tlsClientCA by the TLS handshake. A bearer token is compared to the admin token of the config Secret,
+// with the kubernetes authentication the other tokens are reviewed by the api-server and the caller
+// must be allowed the method on the path of the request. Returns the status to reply with on failure.
+func authenticate(r *http.Request, endpoints string) (string, int, error) {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		if name := r.TLS.VerifiedChains[0][0].Subject.CommonName; name != "" {
+			return name, 0, nil
+		}
+		return clientCertificateCaller, 0, nil
+	}
+	kubernetesAuth := conf.GetSchedulerConf().WebServiceAuth == conf.WebServiceAuthKubernetes
+	adminToken, ok := conf.GetSchedulerConf().GetSecretValue(conf.SecretAdminToken)
+	if !kubernetesAuth && (!ok || len(adminToken) == 0) {
+		return "", http.StatusForbidden, fmt.Errorf("%s are disabled, no admin token is set", endpoints)
+	}
+	header := r.Header.Get("Authorization")
+	token := strings.TrimPrefix(header, bearerPrefix)
+	if !strings.HasPrefix(header, bearerPrefix) || token == "" {
+		return "", http.StatusUnauthorized, fmt.Errorf("no bearer token")
+	}
+	if len(adminToken) > 0 && subtle.ConstantTimeCompare([]byte(token), adminToken) == 1 {
+		return adminTokenCaller, 0, nil
+	}
+	if !kubernetesAuth {
+		return "", http.StatusUnauthorized, fmt.Errorf("invalid admin token")
+	}
+	return reviewToken(r, token)
+}
+
+// authenticates the token with a TokenReview, and authorizes the user with a SubjectAccessReview of the
+// request path and the lower case method: the callers are granted access with the nonResourceURLs of a
+// ClusterRole, e.g. the post verb on /ws/v1/admin/*
+func reviewToken(r *http.Request, token string) (string, int, error) {
+	clientSet := schedulerContext.GetKubeClient().GetClientSet()
+	review, err := clientSet.AuthenticationV1().TokenReviews().Create(r.Context(), &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, apis.CreateOptions{})
+	if err != nil {
+		return "", http.StatusInternalServerError, fmt.Errorf("failed to review the token: %v", err)
+	}
+	if !review.Status.Authenticated {
+		return "", http.StatusUnauthorized, fmt.Errorf("invalid token: %s", review.Status.Error)
+	}
+	user := review.Status.User
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for key, value := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	access, err := clientSet.AuthorizationV1().SubjectAccessReviews().Create(r.Context(), &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			NonResourceAttributes: &authorizationv1.NonResourceAttributes{
+				Path: r.URL.Path,
+				Verb: strings.ToLower(r.Method),
+			},
+			User:   user.Username,
+			Groups: user.Groups,
+			UID:    user.UID,
+			Extra:  extra,
+		},
+	}, apis.CreateOptions{})
+	if err != nil {
+		return "", http.StatusInternalServerError, fmt.Errorf("failed to review the access of %s: %v", user.Username, err)
+	}
+	if !access.Status.Allowed {
+		return "", http.StatusForbidden, fmt.Errorf("%s is not allowed to %s %s", user.Username, r.Method, r.URL.Path)
+	}
+	return user.Username, 0, nil
+}
@@ -0,0 +1,153 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package webservice
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/cache"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+)
+
+func TestKubernetesAuth(t *testing.T) {
+	NewWebApp(0, cache.NewContext(client.NewMockedAPIProvider()))
+	conf.GetSchedulerConf().WebServiceAuth = conf.WebServiceAuthKubernetes
+	defer func() { conf.GetSchedulerConf().WebServiceAuth = conf.WebServiceAuthToken }()
+	clientSet, ok := schedulerContext.GetKubeClient().GetClientSet().(*fake.Clientset)
+	assert.Assert(t, ok)
+	// alice is allowed the admin operations, bob only has a valid token
+	clientSet.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review, ok := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		assert.Assert(t, ok)
+		switch review.Spec.Token {
+		case "alice-token":
+			review.Status.Authenticated = true
+			review.Status.User = authenticationv1.UserInfo{Username: "alice", Groups: []string{"admins"}}
+		case "bob-token":
+			review.Status.Authenticated = true
+			review.Status.User = authenticationv1.UserInfo{Username: "bob"}
+		}
+		return true, review, nil
+	})
+	var reviewed []authorizationv1.NonResourceAttributes
+	clientSet.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review, ok := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		assert.Assert(t, ok)
+		reviewed = append(reviewed, *review.Spec.NonResourceAttributes)
+		review.Status.Allowed = review.Spec.User == "alice" && review.Spec.Groups[0] == "admins"
+		return true, review, nil
+	})
+	post := func(token string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", "/ws/v1/admin/maintenance", strings.NewReader(`{"enabled":true}`))
+		assert.NilError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp := httptest.NewRecorder()
+		newRouter().ServeHTTP(resp, req)
+		return resp
+	}
+
+	// no admin token is needed, the tokens are reviewed by the api-server
+	assert.Equal(t, post("").Code, http.StatusUnauthorized)
+	assert.Equal(t, post("unknown-token").Code, http.StatusUnauthorized)
+	assert.Equal(t, post("bob-token").Code, http.StatusForbidden)
+	assert.DeepEqual(t, reviewed, []authorizationv1.NonResourceAttributes{{Path: "/ws/v1/admin/maintenance", Verb: "post"}})
+	resp := post("alice-token")
+	assert.Equal(t, resp.Code, http.StatusOK)
+	var state cache.MaintenanceState
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &state))
+	assert.Assert(t, state.Enabled)
+	assert.Equal(t, state.By, "alice")
+	schedulerContext.SetMaintenance(false, "", "")
+
+	// the admin token is still accepted
+	conf.GetSchedulerConf().UpdateSecretValues(map[string][]byte{conf.SecretAdminToken: []byte("secret")})
+	defer conf.GetSchedulerConf().UpdateSecretValues(nil)
+	reviewed = nil
+	resp = post("secret")
+	assert.Equal(t, resp.Code, http.StatusOK)
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &state))
+	assert.Equal(t, state.By, adminTokenCaller)
+	assert.Equal(t, len(reviewed), 0)
+}
+
+func TestDebugOnly(t *testing.T) {
+	NewWebApp(0, cache.NewContext(client.NewMockedAPIProvider()))
+	get := func(token string) int {
+		req, err := http.NewRequest("GET", "/ws/v1/debug/nodes", nil)
+		assert.NilError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp := httptest.NewRecorder()
+		newRouter().ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	// the debug endpoints are open by default
+	assert.Equal(t, get(""), http.StatusOK)
+
+	conf.GetSchedulerConf().WebServiceAuthDebug = true
+	defer func() { conf.GetSchedulerConf().WebServiceAuthDebug = false }()
+	assert.Equal(t, get(""), http.StatusForbidden)
+	conf.GetSchedulerConf().UpdateSecretValues(map[string][]byte{conf.SecretAdminToken: []byte("secret")})
+	defer conf.GetSchedulerConf().UpdateSecretValues(nil)
+	assert.Equal(t, get(""), http.StatusUnauthorized)
+	assert.Equal(t, get("wrong"), http.StatusUnauthorized)
+	assert.Equal(t, get("secret"), http.StatusOK)
+}
+
+func TestRoutesAuthenticated(t *testing.T) {
+	NewWebApp(0, cache.NewContext(client.NewMockedAPIProvider()))
+	conf.GetSchedulerConf().WebServiceAuthDebug = true
+	defer func() { conf.GetSchedulerConf().WebServiceAuthDebug = false }()
+	// the routes that do not change the state of the shim
+	readOnly := map[string]bool{"ValidateConf": true}
+
+	for _, r := range append(append(routes{}, webRoutes...), profilingRoutes...) {
+		mutating := r.Method != "GET" && !readOnly[r.Name]
+		debug := strings.Contains(r.Pattern, "/debug/") || strings.Contains(r.Pattern, "/admin/") ||
+			strings.Contains(r.Pattern, "/events/")
+		if !mutating && !debug {
+			continue
+		}
+		t.Run(r.Name, func(t *testing.T) {
+			req, err := http.NewRequest(r.Method, r.Pattern, strings.NewReader("{}"))
+			assert.NilError(t, err)
+			resp := httptest.NewRecorder()
+			r.HandlerFunc(resp, req)
+			// no admin token is set, the authenticated routes are disabled
+			assert.Equal(t, resp.Code, http.StatusForbidden)
+			assert.Assert(t, strings.Contains(resp.Body.String(), "no admin token is set"), resp.Body.String())
+		})
+	}
+}
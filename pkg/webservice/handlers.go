@@ -0,0 +1,310 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package webservice
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/buildinfo"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/cache"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/dispatcher"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/health"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+func writeHeaders(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Credentials", "true")
+	w.Header().Set("Access-Control-Allow-Methods", "GET,POST,HEAD,OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "X-Requested-With,Content-Type,Accept,Origin")
+}
+
+func writeJSON(w http.ResponseWriter, obj interface{}) {
+	if err := json.NewEncoder(w).Encode(obj); err != nil {
+		log.Logger().Error("failed to encode web service response", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// returns the build versions and the enabled features of the shim
+func getVersion(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	writeJSON(w, buildinfo.Get())
+}
+
+// returns the effective shim configuration, after all the configuration sources are applied
+func getShimConfig(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	configs := conf.GetSchedulerConf()
+	configs.RLock()
+	defer configs.RUnlock()
+	writeJSON(w, configs)
+}
+
+type effectiveConfig struct {
+	ShimConfig *conf.SchedulerConf `json:"shimConfig"`
+	Queues     *cache.ConfigState  `json:"queues"`
+}
+
+// returns the configuration the shim is running with, and the scheduler ConfigMap version
+// the queue configuration came from. Drift is reported when a newer ConfigMap is not applied.
+func getEffectiveConfig(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	configs := conf.GetSchedulerConf()
+	configs.RLock()
+	defer configs.RUnlock()
+	writeJSON(w, &effectiveConfig{
+		ShimConfig: configs,
+		Queues:     schedulerContext.GetConfigState(),
+	})
+}
+
+// liveness probe of the shim, the status is 503 when the shim is broken and must be restarted
+func getLiveness(w http.ResponseWriter, r *http.Request) {
+	writeProbe(w, health.Liveness())
+}
+
+// readiness probe of the shim, the status is 503 while the shim is starting and cannot schedule yet
+func getReadiness(w http.ResponseWriter, r *http.Request) {
+	writeProbe(w, health.Readiness())
+}
+
+func writeProbe(w http.ResponseWriter, probe *health.Probe) {
+	writeHeaders(w)
+	if !probe.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	writeJSON(w, probe)
+}
+
+// returns the composite health of the scheduler, the status is 503 only when the scheduler is unhealthy:
+// a degraded scheduler is still alive and reported with a score below 1
+func getSchedulerHealth(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	report := health.GetReport()
+	if report.Status == health.StatusUnhealthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	writeJSON(w, report)
+}
+
+// returns whether the api-server throttled the shim recently
+func getAPIServerHealth(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	writeJSON(w, client.GetThrottleState())
+}
+
+// returns the health of the informers, the status is 503 while an informer is failing or recovering
+func getInformerHealth(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	health := client.GetInformerHealth()
+	if !health.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	writeJSON(w, health)
+}
+
+// returns the state of the dispatcher, the status is 503 when the dispatcher is not running
+func getDispatcherHealth(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	health := dispatcher.GetHealth()
+	if !health.Running {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	writeJSON(w, health)
+}
+
+// returns the events the handlers failed to handle
+func getDeadLetters(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	writeJSON(w, dispatcher.GetDeadLetters())
+}
+
+// returns the scheduling statistics of the nodes, the nodes with the most bind failures first
+func getNodeStats(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	writeJSON(w, schedulerContext.GetNodeStats())
+}
+
+// returns the pods placed on the node as seen by the scheduler cache
+func getNodePods(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	nodeName := mux.Vars(r)["node"]
+	pods := schedulerContext.GetNodePods(nodeName)
+	if pods == nil {
+		http.Error(w, "node "+nodeName+" not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, pods)
+}
+
+// returns the allocations the pending pod would preempt, nothing is evicted
+func getPodPreemptionSimulation(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	vars := mux.Vars(r)
+	simulation := schedulerContext.SimulatePreemption(vars["namespace"], vars["name"])
+	if simulation == nil {
+		http.Error(w, "pod "+vars["namespace"]+"/"+vars["name"]+" not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, simulation)
+}
+
+// returns the allocations the pending pods of the application would preempt, nothing is evicted
+func getAppPreemptionSimulation(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	appID := mux.Vars(r)["appID"]
+	simulations := schedulerContext.SimulateApplicationPreemption(appID)
+	if simulations == nil {
+		http.Error(w, "application "+appID+" not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, simulations)
+}
+
+// returns the events most recently accepted by the dispatcher, oldest first
+func getRecentEvents(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	writeJSON(w, dispatcher.GetRecentEvents())
+}
+
+// returns the queues of the applications with the resources of their pods as seen by the shim
+func getQueues(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	writeJSON(w, schedulerContext.GetQueueSummaries())
+}
+
+// returns the resources requested by the pods waiting for an allocation by queue and namespace
+func getPendingDemand(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	writeJSON(w, schedulerContext.GetPendingDemand())
+}
+
+// returns all the applications of the shim with the number of tasks in each state
+func getApplications(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	writeJSON(w, schedulerContext.GetApplicationsInfo())
+}
+
+// returns the application with its tasks
+func getApplication(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	appID := mux.Vars(r)["appID"]
+	info := schedulerContext.GetApplicationInfo(appID)
+	if info == nil {
+		http.Error(w, "application "+appID+" not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, info)
+}
+
+// returns the task of the pod, with the reason it cannot be scheduled when it is pending
+func getPod(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	vars := mux.Vars(r)
+	info := schedulerContext.GetPodInfo(vars["namespace"], vars["name"])
+	if info == nil {
+		http.Error(w, "pod "+vars["namespace"]+"/"+vars["name"]+" not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, info)
+}
+
+// returns the pods that cannot be scheduled grouped by reason, computed on each request
+func getUnschedulablePods(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	writeJSON(w, schedulerContext.GetUnschedulableSummary())
+}
+
+// returns the pods allocated by the core that are not running after the threshold, 5m by default
+func getStuckBindings(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	threshold := cache.DefaultStuckBindingThreshold
+	if value := r.URL.Query().Get("threshold"); value != "" {
+		var err error
+		if threshold, err = time.ParseDuration(value); err != nil {
+			http.Error(w, "invalid threshold: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	writeJSON(w, schedulerContext.GetStuckBindings(threshold))
+}
+
+type replayResult struct {
+	Replayed int    `json:"replayed"`
+	Error    string `json:"error,omitempty"`
+}
+
+// dispatches the recorded events of the request body again, only allowed in test mode
+func replayEvents(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	var recorded []*dispatcher.RecordedEvent
+	if err := json.NewDecoder(r.Body).Decode(&recorded); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !conf.GetSchedulerConf().IsTestMode() {
+		http.Error(w, "events can only be replayed in test mode", http.StatusForbidden)
+		return
+	}
+	result := &replayResult{}
+	var err error
+	if result.Replayed, err = dispatcher.Replay(recorded); err != nil {
+		result.Error = err.Error()
+	}
+	writeJSON(w, result)
+}
+
+// returns the log levels of the subsystems of the shim
+func getLogLevels(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	writeJSON(w, log.GetLevels())
+}
+
+type logLevelRequest struct {
+	Subsystem string `json:"subsystem"`
+	// an empty level makes the subsystem follow the level of the shim again
+	Level string `json:"level"`
+}
+
+// changes the log level of a subsystem, the change is lost on restart
+func setLogLevel(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	var request logLevelRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := log.SetLevel(request.Subsystem, request.Level); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Logger().Info("log level changed",
+		zap.String("subsystem", request.Subsystem),
+		zap.String("level", request.Level))
+	writeJSON(w, log.GetLevels())
+}
@@ -0,0 +1,436 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package webservice
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/buildinfo"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/cache"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/dispatcher"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/health"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/metrics"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/profiling"
+)
+
+func TestGetVersion(t *testing.T) {
+	buildinfo.SetBuildInfo("0.12.0", "")
+	defer buildinfo.SetBuildInfo("", "")
+	req, err := http.NewRequest("GET", "/ws/v1/version", nil)
+	assert.NilError(t, err)
+	resp := httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusOK)
+
+	var info buildinfo.Info
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &info))
+	assert.Equal(t, info.Version, "0.12.0")
+	assert.Assert(t, info.SchedulerInterfaceVersion != "")
+	assert.Equal(t, info.Features["gangScheduling"], !conf.GetSchedulerConf().DisableGangScheduling)
+}
+
+func TestGetShimConfig(t *testing.T) {
+	req, err := http.NewRequest("GET", "/ws/v1/config", nil)
+	assert.NilError(t, err)
+	resp := httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusOK)
+
+	var configs map[string]interface{}
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &configs))
+	assert.Equal(t, configs["clusterId"], conf.GetSchedulerConf().ClusterID)
+	assert.Equal(t, configs["policyGroup"], conf.GetSchedulerConf().PolicyGroup)
+}
+
+func TestGetEffectiveConfig(t *testing.T) {
+	NewWebApp(0, cache.NewContext(client.NewMockedAPIProvider()))
+	req, err := http.NewRequest("GET", "/ws/v1/config/effective", nil)
+	assert.NilError(t, err)
+	resp := httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusOK)
+
+	var effective struct {
+		ShimConfig map[string]interface{} `json:"shimConfig"`
+		Queues     cache.ConfigState      `json:"queues"`
+	}
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &effective))
+	assert.Equal(t, effective.ShimConfig["clusterId"], conf.GetSchedulerConf().ClusterID)
+	assert.Equal(t, effective.Queues.ConfigMap, "yunikorn-configs")
+	assert.Assert(t, effective.Queues.AppliedTime == nil)
+}
+
+func TestProbes(t *testing.T) {
+	// no scheduler state and no informers outside of a running shim: alive but not ready
+	req, err := http.NewRequest("GET", "/healthz", nil)
+	assert.NilError(t, err)
+	resp := httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusOK)
+	var probe health.Probe
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &probe))
+	assert.Assert(t, probe.Healthy)
+
+	req, err = http.NewRequest("GET", "/readyz", nil)
+	assert.NilError(t, err)
+	resp = httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusServiceUnavailable)
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &probe))
+	assert.Assert(t, !probe.Healthy)
+}
+
+func TestGetMetrics(t *testing.T) {
+	// the metrics of the default registry are served by the shim web service, over TLS when it is enabled
+	req, err := http.NewRequest("GET", "/ws/v1/metrics", nil)
+	assert.NilError(t, err)
+	resp := httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusOK)
+	assert.Assert(t, strings.Contains(resp.Body.String(), "go_goroutines"), resp.Body.String())
+}
+
+func TestGetSchedulerHealth(t *testing.T) {
+	req, err := http.NewRequest("GET", "/ws/v1/health", nil)
+	assert.NilError(t, err)
+	resp := httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	// the scheduler is not registered with the core in the tests
+	assert.Equal(t, resp.Code, http.StatusServiceUnavailable)
+
+	var report health.Report
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &report))
+	assert.Equal(t, report.Status, health.StatusUnhealthy)
+	assert.Equal(t, len(report.Checks), 5)
+	assert.Assert(t, report.Score < 1)
+}
+
+func TestGetAPIServerHealth(t *testing.T) {
+	req, err := http.NewRequest("GET", "/ws/v1/health/apiserver", nil)
+	assert.NilError(t, err)
+	resp := httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusOK)
+
+	var state client.ThrottleState
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &state))
+	assert.Assert(t, !state.Throttled)
+	assert.Equal(t, state.ThrottleWindowMs, int64(60000))
+}
+
+func TestGetInformerHealth(t *testing.T) {
+	req, err := http.NewRequest("GET", "/ws/v1/health/informers", nil)
+	assert.NilError(t, err)
+	resp := httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	// no informers are watched outside of a running shim
+	assert.Equal(t, resp.Code, http.StatusOK)
+
+	var health client.InformersHealth
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &health))
+	assert.Assert(t, health.Healthy)
+	assert.Equal(t, len(health.Informers), 0)
+}
+
+func TestGetDispatcherHealth(t *testing.T) {
+	req, err := http.NewRequest("GET", "/ws/v1/health/dispatcher", nil)
+	assert.NilError(t, err)
+	resp := httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	// the dispatcher is not started in the tests
+	assert.Equal(t, resp.Code, http.StatusServiceUnavailable)
+
+	var health dispatcher.Health
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &health))
+	assert.Assert(t, !health.Running)
+	assert.Equal(t, health.RecoveredPanics, dispatcher.GetRecoveredPanics())
+}
+
+func TestGetDeadLetters(t *testing.T) {
+	dispatcher.AddDeadLetter(&dispatcher.DeadLetter{
+		EventType:     "app",
+		Event:         "RunApplication",
+		ApplicationID: "app-webservice",
+		Error:         "application not exist",
+	})
+	req, err := http.NewRequest("GET", "/ws/v1/debug/deadletters", nil)
+	assert.NilError(t, err)
+	resp := httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusOK)
+
+	var letters []*dispatcher.DeadLetter
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &letters))
+	found := false
+	for _, letter := range letters {
+		if letter.ApplicationID == "app-webservice" {
+			found = true
+			assert.Equal(t, letter.Error, "application not exist")
+			assert.Equal(t, letter.Failures, 1)
+		}
+	}
+	assert.Assert(t, found, "dead letter not returned")
+}
+
+func TestRecentEvents(t *testing.T) {
+	req, err := http.NewRequest("GET", "/ws/v1/debug/events", nil)
+	assert.NilError(t, err)
+	resp := httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusOK)
+	var recorded []*dispatcher.RecordedEvent
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &recorded))
+}
+
+func TestReplayEvents(t *testing.T) {
+	backup := conf.GetSchedulerConf().IsTestMode()
+	defer conf.GetSchedulerConf().SetTestMode(backup)
+	conf.GetSchedulerConf().SetTestMode(false)
+
+	req, err := http.NewRequest("POST", "/ws/v1/debug/events/replay", strings.NewReader("not json"))
+	assert.NilError(t, err)
+	resp := httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusBadRequest)
+
+	// replaying is only allowed in test mode
+	req, err = http.NewRequest("POST", "/ws/v1/debug/events/replay", strings.NewReader("[]"))
+	assert.NilError(t, err)
+	resp = httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusForbidden)
+
+	conf.GetSchedulerConf().SetTestMode(true)
+	req, err = http.NewRequest("POST", "/ws/v1/debug/events/replay", strings.NewReader("[]"))
+	assert.NilError(t, err)
+	resp = httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusOK)
+	var result replayResult
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+	assert.Equal(t, result.Replayed, 0)
+}
+
+func TestProfiling(t *testing.T) {
+	defer profiling.SetEnabled(false)
+	get := func(path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", path, nil)
+		assert.NilError(t, err)
+		req.Header.Set("Authorization", "Bearer secret")
+		resp := httptest.NewRecorder()
+		newRouter().ServeHTTP(resp, req)
+		return resp
+	}
+	enable := func(token string) int {
+		req, err := http.NewRequest("POST", "/ws/v1/debug/profiling", strings.NewReader(`{"enabled":true}`))
+		assert.NilError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp := httptest.NewRecorder()
+		newRouter().ServeHTTP(resp, req)
+		return resp.Code
+	}
+	// the toggle is an admin operation, it is disabled without an admin token
+	assert.Equal(t, enable(""), http.StatusForbidden)
+	assert.Assert(t, !profiling.Enabled())
+	conf.GetSchedulerConf().UpdateSecretValues(map[string][]byte{conf.SecretAdminToken: []byte("secret")})
+	defer conf.GetSchedulerConf().UpdateSecretValues(nil)
+	// the pprof endpoints are served to the admins only while profiling is enabled
+	assert.Equal(t, get("/debug/pprof/heap").Code, http.StatusForbidden)
+	assert.Equal(t, enable(""), http.StatusUnauthorized)
+	assert.Equal(t, enable("secret"), http.StatusOK)
+	assert.Assert(t, profiling.Enabled())
+
+	assert.Equal(t, get("/debug/pprof/heap").Code, http.StatusOK)
+	req, err := http.NewRequest("GET", "/debug/pprof/heap", nil)
+	assert.NilError(t, err)
+	resp := httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusUnauthorized)
+	assert.Equal(t, get("/debug/pprof/goroutine?debug=1").Code, http.StatusOK)
+	assert.Equal(t, get("/debug/pprof/").Code, http.StatusOK)
+	resp = get("/ws/v1/debug/profiling")
+	assert.Equal(t, resp.Code, http.StatusOK)
+	assert.Equal(t, strings.TrimSpace(resp.Body.String()), `{"enabled":true}`)
+}
+
+func TestLogLevels(t *testing.T) {
+	defer func() {
+		assert.NilError(t, log.SetLevel(log.Cache, ""))
+	}()
+	getLevels := func() map[string]*log.LevelInfo {
+		req, err := http.NewRequest("GET", "/ws/v1/loglevels", nil)
+		assert.NilError(t, err)
+		resp := httptest.NewRecorder()
+		newRouter().ServeHTTP(resp, req)
+		assert.Equal(t, resp.Code, http.StatusOK)
+		var levels []*log.LevelInfo
+		assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &levels))
+		byName := make(map[string]*log.LevelInfo)
+		for _, level := range levels {
+			byName[level.Subsystem] = level
+		}
+		return byName
+	}
+	conf.GetSchedulerConf().UpdateSecretValues(map[string][]byte{conf.SecretAdminToken: []byte("secret")})
+	defer conf.GetSchedulerConf().UpdateSecretValues(nil)
+	setLevel := func(body string) int {
+		req, err := http.NewRequest("POST", "/ws/v1/loglevels", strings.NewReader(body))
+		assert.NilError(t, err)
+		req.Header.Set("Authorization", "Bearer secret")
+		resp := httptest.NewRecorder()
+		newRouter().ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	assert.Assert(t, getLevels()[log.Cache].Inherited)
+	assert.Equal(t, setLevel(`{"subsystem":"cache","level":"debug"}`), http.StatusOK)
+	cacheLevel := getLevels()[log.Cache]
+	assert.Equal(t, cacheLevel.Level, "debug")
+	assert.Assert(t, !cacheLevel.Inherited)
+	assert.Assert(t, getLevels()[log.Dispatcher].Inherited)
+
+	assert.Equal(t, setLevel(`{"subsystem":"cache","level":"verbose"}`), http.StatusBadRequest)
+	assert.Equal(t, setLevel(`{"subsystem":"scheduler","level":"debug"}`), http.StatusBadRequest)
+	assert.Equal(t, setLevel(`not json`), http.StatusBadRequest)
+	assert.Equal(t, getLevels()[log.Cache].Level, "debug")
+
+	assert.Equal(t, setLevel(`{"subsystem":"cache","level":""}`), http.StatusOK)
+	assert.Assert(t, getLevels()[log.Cache].Inherited)
+}
+
+func TestGetNodeStats(t *testing.T) {
+	NewWebApp(0, cache.NewContext(client.NewMockedAPIProvider()))
+	req, err := http.NewRequest("GET", "/ws/v1/debug/nodes", nil)
+	assert.NilError(t, err)
+	resp := httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusOK)
+
+	var stats []*cache.NodeStats
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &stats))
+	assert.Equal(t, len(stats), 0)
+}
+
+func TestGetNodePods(t *testing.T) {
+	NewWebApp(0, cache.NewContext(client.NewMockedAPIProvider()))
+	req, err := http.NewRequest("GET", "/ws/v1/debug/nodes/unknown/pods", nil)
+	assert.NilError(t, err)
+	resp := httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusNotFound)
+}
+
+func TestGetPreemptionSimulation(t *testing.T) {
+	NewWebApp(0, cache.NewContext(client.NewMockedAPIProvider()))
+	for _, url := range []string{"/ws/v1/debug/preemption/pods/default/unknown", "/ws/v1/debug/preemption/apps/unknown"} {
+		req, err := http.NewRequest("GET", url, nil)
+		assert.NilError(t, err)
+		resp := httptest.NewRecorder()
+		newRouter().ServeHTTP(resp, req)
+		assert.Equal(t, resp.Code, http.StatusNotFound, url)
+	}
+}
+
+func TestGetUnschedulablePods(t *testing.T) {
+	NewWebApp(0, cache.NewContext(client.NewMockedAPIProvider()))
+	req, err := http.NewRequest("GET", "/ws/v1/pods/unschedulable", nil)
+	assert.NilError(t, err)
+	resp := httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusOK)
+
+	var summary cache.UnschedulableSummary
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &summary))
+	assert.Equal(t, summary.Total, 0)
+	assert.Equal(t, len(summary.Reasons), 0)
+}
+
+func TestGetApplications(t *testing.T) {
+	NewWebApp(0, cache.NewContext(client.NewMockedAPIProvider()))
+	req, err := http.NewRequest("GET", "/ws/v1/apps", nil)
+	assert.NilError(t, err)
+	resp := httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusOK)
+
+	var apps []*cache.ApplicationInfo
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &apps))
+	assert.Equal(t, len(apps), 0)
+
+	for _, path := range []string{"/ws/v1/apps/app01", "/ws/v1/pods/default/pod01"} {
+		req, err = http.NewRequest("GET", path, nil)
+		assert.NilError(t, err)
+		resp = httptest.NewRecorder()
+		newRouter().ServeHTTP(resp, req)
+		assert.Equal(t, resp.Code, http.StatusNotFound, path)
+	}
+}
+
+func TestGetStuckBindings(t *testing.T) {
+	NewWebApp(0, cache.NewContext(client.NewMockedAPIProvider()))
+	req, err := http.NewRequest("GET", "/ws/v1/pods/stuck-bindings?threshold=1m", nil)
+	assert.NilError(t, err)
+	resp := httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusOK)
+	assert.Equal(t, strings.TrimSpace(resp.Body.String()), "[]")
+
+	req, err = http.NewRequest("GET", "/ws/v1/pods/stuck-bindings?threshold=soon", nil)
+	assert.NilError(t, err)
+	resp = httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusBadRequest)
+}
+
+func TestGetQueues(t *testing.T) {
+	NewWebApp(0, cache.NewContext(client.NewMockedAPIProvider()))
+	req, err := http.NewRequest("GET", "/ws/v1/queues", nil)
+	assert.NilError(t, err)
+	resp := httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusOK)
+
+	var queues []*cache.QueueSummary
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &queues))
+	assert.Equal(t, len(queues), 0)
+}
+
+func TestGetPendingDemand(t *testing.T) {
+	NewWebApp(0, cache.NewContext(client.NewMockedAPIProvider()))
+	req, err := http.NewRequest("GET", "/ws/v1/demand", nil)
+	assert.NilError(t, err)
+	resp := httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusOK)
+
+	var demand []*metrics.PendingDemand
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &demand))
+	assert.Equal(t, len(demand), 0)
+}
@@ -0,0 +1,101 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package webservice
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/profiling"
+)
+
+// the pprof and execution trace endpoints, served only while profiling is enabled and only to the admins:
+// the profiles expose the memory and the command line of the shim
+var profilingRoutes = routes{
+	route{
+		"PprofIndex",
+		"GET",
+		"/debug/pprof/",
+		adminOnly(whenProfiling(pprof.Index)),
+	},
+	route{
+		"PprofCmdline",
+		"GET",
+		"/debug/pprof/cmdline",
+		adminOnly(whenProfiling(pprof.Cmdline)),
+	},
+	route{
+		"PprofCPU",
+		"GET",
+		"/debug/pprof/profile",
+		adminOnly(whenProfiling(pprof.Profile)),
+	},
+	route{
+		"PprofSymbol",
+		"GET",
+		"/debug/pprof/symbol",
+		adminOnly(whenProfiling(pprof.Symbol)),
+	},
+	route{
+		"PprofTrace",
+		"GET",
+		"/debug/pprof/trace",
+		adminOnly(whenProfiling(pprof.Trace)),
+	},
+	// the heap, goroutine, mutex, block, allocs and threadcreate profiles
+	route{
+		"PprofNamed",
+		"GET",
+		"/debug/pprof/{profile}",
+		adminOnly(whenProfiling(pprof.Index)),
+	},
+}
+
+func whenProfiling(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !profiling.Enabled() {
+			http.Error(w, "profiling is disabled", http.StatusForbidden)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+type profilingState struct {
+	Enabled bool `json:"enabled"`
+}
+
+// returns whether the profiling endpoints are enabled
+func getProfiling(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	writeJSON(w, &profilingState{Enabled: profiling.Enabled()})
+}
+
+// enables or disables the profiling endpoints, the change is lost on restart
+func setProfiling(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	var request profilingState
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	profiling.SetEnabled(request.Enabled)
+	writeJSON(w, &profilingState{Enabled: profiling.Enabled()})
+}
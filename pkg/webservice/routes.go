@@ -0,0 +1,253 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package webservice
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type route struct {
+	Name        string
+	Method      string
+	Pattern     string
+	HandlerFunc http.HandlerFunc
+}
+
+type routes []route
+
+var webRoutes = routes{
+	route{
+		"Version",
+		"GET",
+		"/ws/v1/version",
+		getVersion,
+	},
+	route{
+		"Config",
+		"GET",
+		"/ws/v1/config",
+		getShimConfig,
+	},
+	route{
+		"EffectiveConfig",
+		"GET",
+		"/ws/v1/config/effective",
+		getEffectiveConfig,
+	},
+	route{
+		"ValidateConf",
+		"POST",
+		"/ws/v1/validate-conf",
+		validateConf,
+	},
+	route{
+		"Liveness",
+		"GET",
+		"/healthz",
+		getLiveness,
+	},
+	route{
+		"Readiness",
+		"GET",
+		"/readyz",
+		getReadiness,
+	},
+	route{
+		"SchedulerHealth",
+		"GET",
+		"/ws/v1/health",
+		getSchedulerHealth,
+	},
+	route{
+		"APIServerHealth",
+		"GET",
+		"/ws/v1/health/apiserver",
+		getAPIServerHealth,
+	},
+	route{
+		"InformerHealth",
+		"GET",
+		"/ws/v1/health/informers",
+		getInformerHealth,
+	},
+	route{
+		"DispatcherHealth",
+		"GET",
+		"/ws/v1/health/dispatcher",
+		getDispatcherHealth,
+	},
+	route{
+		"Metrics",
+		"GET",
+		"/ws/v1/metrics",
+		promhttp.Handler().ServeHTTP,
+	},
+	route{
+		"DeadLetters",
+		"GET",
+		"/ws/v1/debug/deadletters",
+		debugOnly(getDeadLetters),
+	},
+	route{
+		"NodeStats",
+		"GET",
+		"/ws/v1/debug/nodes",
+		debugOnly(getNodeStats),
+	},
+	route{
+		"NodePods",
+		"GET",
+		"/ws/v1/debug/nodes/{node}/pods",
+		debugOnly(getNodePods),
+	},
+	route{
+		"PodPreemptionSimulation",
+		"GET",
+		"/ws/v1/debug/preemption/pods/{namespace}/{name}",
+		debugOnly(getPodPreemptionSimulation),
+	},
+	route{
+		"AppPreemptionSimulation",
+		"GET",
+		"/ws/v1/debug/preemption/apps/{appID}",
+		debugOnly(getAppPreemptionSimulation),
+	},
+	route{
+		"Profiling",
+		"GET",
+		"/ws/v1/debug/profiling",
+		debugOnly(getProfiling),
+	},
+	route{
+		"SetProfiling",
+		"POST",
+		"/ws/v1/debug/profiling",
+		adminOnly(setProfiling),
+	},
+	route{
+		"RecentEvents",
+		"GET",
+		"/ws/v1/debug/events",
+		debugOnly(getRecentEvents),
+	},
+	route{
+		"EventStream",
+		"GET",
+		"/ws/v1/events/stream",
+		debugOnly(streamEvents),
+	},
+	route{
+		"Queues",
+		"GET",
+		"/ws/v1/queues",
+		getQueues,
+	},
+	route{
+		"PendingDemand",
+		"GET",
+		"/ws/v1/demand",
+		getPendingDemand,
+	},
+	route{
+		"Applications",
+		"GET",
+		"/ws/v1/apps",
+		getApplications,
+	},
+	route{
+		"Application",
+		"GET",
+		"/ws/v1/apps/{appID}",
+		getApplication,
+	},
+	route{
+		"Pod",
+		"GET",
+		"/ws/v1/pods/{namespace}/{name}",
+		getPod,
+	},
+	route{
+		"UnschedulablePods",
+		"GET",
+		"/ws/v1/pods/unschedulable",
+		getUnschedulablePods,
+	},
+	route{
+		"StuckBindings",
+		"GET",
+		"/ws/v1/pods/stuck-bindings",
+		getStuckBindings,
+	},
+	route{
+		"ReplayEvents",
+		"POST",
+		"/ws/v1/debug/events/replay",
+		debugOnly(replayEvents),
+	},
+	route{
+		"ForceCompleteApplication",
+		"POST",
+		"/ws/v1/admin/apps/{appID}/complete",
+		adminOnly(forceCompleteApplication),
+	},
+	route{
+		"ForceFailApplication",
+		"POST",
+		"/ws/v1/admin/apps/{appID}/fail",
+		adminOnly(forceFailApplication),
+	},
+	route{
+		"Maintenance",
+		"GET",
+		"/ws/v1/maintenance",
+		getMaintenance,
+	},
+	route{
+		"SetMaintenance",
+		"POST",
+		"/ws/v1/admin/maintenance",
+		adminOnly(setMaintenance),
+	},
+	route{
+		"ResyncCache",
+		"POST",
+		"/ws/v1/admin/resync",
+		adminOnly(resyncCache),
+	},
+	route{
+		"RefreshNamespaces",
+		"POST",
+		"/ws/v1/admin/namespaces/refresh",
+		adminOnly(refreshNamespaces),
+	},
+	route{
+		"LogLevels",
+		"GET",
+		"/ws/v1/loglevels",
+		getLogLevels,
+	},
+	route{
+		"SetLogLevel",
+		"POST",
+		"/ws/v1/loglevels",
+		adminOnly(setLogLevel),
+	},
+}
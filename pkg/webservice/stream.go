@@ -0,0 +1,104 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package webservice
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/eventsink"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// maximum number of events buffered for a client of the event stream, the events are dropped
+// for a client that does not keep up
+const streamBufferSize = 1000
+
+// a comment is sent this often on an idle stream, to keep the proxies from closing the connection
+var streamKeepAliveInterval = 15 * time.Second
+
+var streamKinds = map[string]bool{
+	eventsink.KindApplication: true,
+	eventsink.KindTask:        true,
+	eventsink.KindNode:        true,
+}
+
+// streams the application, task and node lifecycle events as server-sent events until the
+// client disconnects. The kind and the applicationID query parameters filter the events,
+// the kind is a comma-separated list.
+func streamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	kinds := make(map[string]bool)
+	if kindParam := r.URL.Query().Get("kind"); kindParam != "" {
+		for _, kind := range strings.Split(kindParam, ",") {
+			kind = strings.TrimSpace(kind)
+			if !streamKinds[kind] {
+				http.Error(w, fmt.Sprintf("unknown event kind %s, expected application, task or node", kind),
+					http.StatusBadRequest)
+				return
+			}
+			kinds[kind] = true
+		}
+	}
+	appID := r.URL.Query().Get("applicationID")
+
+	subscription := eventsink.Subscribe(streamBufferSize)
+	defer subscription.Close()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	fmt.Fprint(w, ": streaming lifecycle events\n\n")
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return
+			}
+			if (len(kinds) > 0 && !kinds[event.Kind]) || (appID != "" && event.ApplicationID != appID) {
+				continue
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				log.Logger().Warn("failed to encode lifecycle event", zap.Error(err))
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Kind, data)
+			flusher.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,76 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package webservice
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/eventsink"
+)
+
+func TestStreamEvents(t *testing.T) {
+	server := httptest.NewServer(newRouter())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/ws/v1/events/stream?kind=pod")
+	assert.NilError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusBadRequest)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL+"/ws/v1/events/stream?kind=task,application&applicationID=app01", nil)
+	assert.NilError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	assert.NilError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+	assert.Equal(t, resp.Header.Get("Content-Type"), "text/event-stream")
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() string {
+		var lines []string
+		for {
+			line, err := reader.ReadString('\n')
+			assert.NilError(t, err)
+			line = strings.TrimSuffix(line, "\n")
+			if line == "" {
+				return strings.Join(lines, "\n")
+			}
+			lines = append(lines, line)
+		}
+	}
+	// subscribed once the stream has started
+	assert.Equal(t, readEvent(), ": streaming lifecycle events")
+
+	eventsink.Publish(&eventsink.LifecycleEvent{Kind: eventsink.KindNode, NodeID: "node01", Event: "NodeAccepted"})
+	eventsink.Publish(&eventsink.LifecycleEvent{Kind: eventsink.KindTask, ApplicationID: "app02", TaskID: "task02"})
+	eventsink.Publish(&eventsink.LifecycleEvent{Kind: eventsink.KindTask, ApplicationID: "app01", TaskID: "task01",
+		Event: "InitTask", From: "New", To: "Pending"})
+	event := readEvent()
+	assert.Assert(t, strings.HasPrefix(event, "event: task\ndata: {"), event)
+	assert.Assert(t, strings.Contains(event, `"taskID":"task01"`), event)
+	assert.Assert(t, strings.Contains(event, `"to":"Pending"`), event)
+}
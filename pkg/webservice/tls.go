@@ -0,0 +1,122 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package webservice
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+// certificates holds the serving certificate and the client CAs of the web service, these are loaded
+// from the config Secret and replaced when the Secret is rotated. The connections already open keep
+// the certificate they were established with.
+// The metrics are served by the shim web service as well so that they can be scraped over TLS.
+type certificates struct {
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	sync.RWMutex
+}
+
+// loads the certificates of the config Secret and reloads them each time the TLS keys of the Secret change
+func newCertificates(schedulerConf *conf.SchedulerConf) *certificates {
+	certs := &certificates{}
+	if err := certs.load(schedulerConf); err != nil {
+		log.Logger().Error("failed to load the web service certificate", zap.Error(err))
+	}
+	schedulerConf.AddSecretListener(func(changed []string) {
+		for _, key := range changed {
+			switch key {
+			case conf.SecretTLSCert, conf.SecretTLSKey, conf.SecretTLSClientCA:
+				if err := certs.load(schedulerConf); err != nil {
+					log.Logger().Error("failed to reload the web service certificate, the previous certificate is kept",
+						zap.Error(err))
+				} else {
+					log.Logger().Info("web service certificate reloaded")
+				}
+				return
+			}
+		}
+	})
+	return certs
+}
+
+// the certificates are only replaced when the certificate, the key and the CAs are all valid:
+// a Secret updated one key at a time does not break the web service in between
+func (c *certificates) load(schedulerConf *conf.SchedulerConf) error {
+	certPEM, certOK := schedulerConf.GetSecretValue(conf.SecretTLSCert)
+	keyPEM, keyOK := schedulerConf.GetSecretValue(conf.SecretTLSKey)
+	if !certOK || !keyOK {
+		return fmt.Errorf("the config Secret must set both %s and %s", conf.SecretTLSCert, conf.SecretTLSKey)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return err
+	}
+	var clientCAs *x509.CertPool
+	if caPEM, ok := schedulerConf.GetSecretValue(conf.SecretTLSClientCA); ok && len(caPEM) > 0 {
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(caPEM) {
+			return fmt.Errorf("no certificate found in %s", conf.SecretTLSClientCA)
+		}
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.cert = &cert
+	c.clientCAs = clientCAs
+	return nil
+}
+
+func (c *certificates) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if c.cert == nil {
+		return nil, fmt.Errorf("no web service certificate loaded")
+	}
+	return c.cert, nil
+}
+
+// the client certificates are verified when given, the admin operations decide whether they are required
+func (c *certificates) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	c.RLock()
+	defer c.RUnlock()
+	config := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: c.getCertificate,
+	}
+	if c.clientCAs != nil {
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+		config.ClientCAs = c.clientCAs
+	}
+	return config, nil
+}
+
+func (c *certificates) tlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetCertificate:     c.getCertificate,
+		GetConfigForClient: c.getConfigForClient,
+	}
+}
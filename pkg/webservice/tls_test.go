@@ -0,0 +1,163 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package webservice
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/cache"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/client"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+)
+
+// returns a PEM certificate and key for the name, signed by the parent or self-signed without a parent
+func newTestCertificate(t *testing.T, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (
+	certPEM, keyPEM []byte, cert *x509.Certificate, key *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+	if ip := net.ParseIP(name); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	assert.NilError(t, err)
+	cert, err = x509.ParseCertificate(der)
+	assert.NilError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NilError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), cert, key
+}
+
+// returns the common name of the certificate the listener serves
+func servedName(t *testing.T, certs *certificates) string {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", certs.tlsConfig())
+	assert.NilError(t, err)
+	defer listener.Close()
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			_ = conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{InsecureSkipVerify: true}) // nolint: gosec
+	assert.NilError(t, err)
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+}
+
+func TestCertificatesReload(t *testing.T) {
+	schedulerConf := conf.GetSchedulerConf()
+	defer schedulerConf.UpdateSecretValues(nil)
+	certs := newCertificates(schedulerConf)
+	_, err := certs.getCertificate(nil)
+	assert.ErrorContains(t, err, "no web service certificate loaded")
+
+	certPEM, keyPEM, _, _ := newTestCertificate(t, "first", nil, nil)
+	schedulerConf.UpdateSecretValues(map[string][]byte{conf.SecretTLSCert: certPEM, conf.SecretTLSKey: keyPEM})
+	assert.Equal(t, servedName(t, certs), "first")
+
+	// the certificate is replaced on rotation
+	certPEM, keyPEM, _, _ = newTestCertificate(t, "second", nil, nil)
+	schedulerConf.UpdateSecretValues(map[string][]byte{conf.SecretTLSCert: certPEM, conf.SecretTLSKey: keyPEM})
+	assert.Equal(t, servedName(t, certs), "second")
+
+	// an invalid update keeps the previous certificate
+	schedulerConf.UpdateSecretValues(map[string][]byte{conf.SecretTLSCert: certPEM, conf.SecretTLSKey: []byte("invalid")})
+	assert.Equal(t, servedName(t, certs), "second")
+
+	// the client certificates are verified with a client CA
+	caPEM, _, _, _ := newTestCertificate(t, "ca", nil, nil)
+	schedulerConf.UpdateSecretValues(map[string][]byte{conf.SecretTLSCert: certPEM, conf.SecretTLSKey: keyPEM,
+		conf.SecretTLSClientCA: caPEM})
+	config, err := certs.getConfigForClient(nil)
+	assert.NilError(t, err)
+	assert.Equal(t, config.ClientAuth, tls.VerifyClientCertIfGiven)
+	assert.Assert(t, config.ClientCAs != nil)
+}
+
+func TestAdminClientCertificate(t *testing.T) {
+	NewWebApp(0, cache.NewContext(client.NewMockedAPIProvider()))
+	caPEM, _, caCert, caKey := newTestCertificate(t, "ca", nil, nil)
+	serverPEM, serverKey, _, _ := newTestCertificate(t, "127.0.0.1", caCert, caKey)
+	clientPEM, clientKey, _, _ := newTestCertificate(t, "admin", caCert, caKey)
+	_, _, otherCA, otherKey := newTestCertificate(t, "other", nil, nil)
+	otherPEM, otherClientKey, _, _ := newTestCertificate(t, "intruder", otherCA, otherKey)
+
+	schedulerConf := conf.GetSchedulerConf()
+	schedulerConf.UpdateSecretValues(map[string][]byte{
+		conf.SecretTLSCert:     serverPEM,
+		conf.SecretTLSKey:      serverKey,
+		conf.SecretTLSClientCA: caPEM,
+	})
+	defer schedulerConf.UpdateSecretValues(nil)
+	server := httptest.NewUnstartedServer(newRouter())
+	server.TLS = newCertificates(schedulerConf).tlsConfig()
+	server.StartTLS()
+	defer server.Close()
+
+	post := func(certPEM, keyPEM []byte) int {
+		roots := x509.NewCertPool()
+		roots.AppendCertsFromPEM(caPEM)
+		tlsConfig := &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+		if certPEM != nil {
+			cert, err := tls.X509KeyPair(certPEM, keyPEM)
+			assert.NilError(t, err)
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+		resp, err := httpClient.Post(server.URL+"/ws/v1/admin/resync", "application/json", nil)
+		assert.NilError(t, err)
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+	// no admin token is set, the operations are only allowed with a client certificate of the CA
+	assert.Equal(t, post(nil, nil), http.StatusForbidden)
+	// the server only accepts the certificates of its CA, the certificate of another CA is not sent
+	assert.Equal(t, post(otherPEM, otherClientKey), http.StatusForbidden)
+	code := post(clientPEM, clientKey)
+	assert.Assert(t, code != http.StatusForbidden && code != http.StatusUnauthorized, "status %d", code)
+}
@@ -0,0 +1,143 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package webservice
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-yunikorn-core/pkg/common/configs"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+)
+
+const (
+	coreValidateConfPath = "/ws/v1/validate-conf"
+	validatorEmbedded    = "embedded"
+	validatorRemote      = "remote"
+)
+
+// the time allowed to the core that runs separately to validate a configuration
+var coreValidateTimeout = 10 * time.Second
+
+// matches the YAML errors that point to a line, e.g. "yaml: line 3: found a tab character"
+var lineErrorPattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// ConfigError is a problem of a validated configuration, the line is only set for the YAML errors
+type ConfigError struct {
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+// the response is compatible with the validation response of the core, the errors are the reason
+// split into the separate problems
+type validateConfResponse struct {
+	Allowed   bool          `json:"allowed"`
+	Reason    string        `json:"reason"`
+	Errors    []ConfigError `json:"errors,omitempty"`
+	Validator string        `json:"validator"`
+}
+
+// the response of the validation endpoint of the core
+type coreValidateConfResponse struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason"`
+}
+
+// validates the configuration with the core that runs in the same process
+var validateWithEmbeddedCore = func(content []byte) error {
+	_, err := configs.ParseAndValidateConfig(content)
+	return err
+}
+
+// validates the configuration with the core that runs separately, returns the validation result of the core
+func validateWithRemoteCore(coreURL string, content []byte) (*coreValidateConfResponse, error) {
+	httpClient := &http.Client{Timeout: coreValidateTimeout}
+	response, err := httpClient.Post(strings.TrimSuffix(coreURL, "/")+coreValidateConfPath,
+		"application/json", bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("core validation failed with status %s", response.Status)
+	}
+	var result coreValidateConfResponse
+	if err = json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid core validation response: %v", err)
+	}
+	return &result, nil
+}
+
+// splits the reason of a failed validation into the separate problems
+func parseConfigErrors(reason string) []ConfigError {
+	var errs []ConfigError
+	for _, msg := range strings.Split(reason, "\n") {
+		msg = strings.TrimSpace(msg)
+		// the header of the YAML unmarshal errors, the errors follow on the next lines
+		if msg == "" || msg == "yaml: unmarshal errors:" {
+			continue
+		}
+		configErr := ConfigError{Message: msg}
+		if match := lineErrorPattern.FindStringSubmatch(msg); match != nil {
+			if line, err := strconv.Atoi(match[1]); err == nil {
+				configErr.Line = line
+				configErr.Message = match[2]
+			}
+		}
+		errs = append(errs, configErr)
+	}
+	return errs
+}
+
+// validates the scheduler configuration in the request body, with the embedded core or, when
+// configured, with the core that runs separately
+func validateConf(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w)
+	content, err := ioutil.ReadAll(r.Body)
+	if err != nil || len(content) == 0 {
+		http.Error(w, "empty or invalid configuration", http.StatusBadRequest)
+		return
+	}
+	result := &validateConfResponse{Allowed: true, Validator: validatorEmbedded}
+	if coreURL := conf.GetSchedulerConf().CoreServiceURL; coreURL != "" {
+		result.Validator = validatorRemote
+		coreResult, err := validateWithRemoteCore(coreURL, content)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		result.Allowed = coreResult.Allowed
+		result.Reason = coreResult.Reason
+	} else if err = validateWithEmbeddedCore(content); err != nil {
+		result.Allowed = false
+		result.Reason = err.Error()
+	}
+	if !result.Allowed {
+		result.Errors = parseConfigErrors(result.Reason)
+	}
+	writeJSON(w, result)
+}
@@ -0,0 +1,106 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package webservice
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+)
+
+func postConf(t *testing.T, body string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest("POST", "/ws/v1/validate-conf", strings.NewReader(body))
+	assert.NilError(t, err)
+	resp := httptest.NewRecorder()
+	newRouter().ServeHTTP(resp, req)
+	return resp
+}
+
+func TestValidateConfEmbedded(t *testing.T) {
+	resp := postConf(t, "")
+	assert.Equal(t, resp.Code, http.StatusBadRequest)
+
+	resp = postConf(t, "partitions:\n  - name: default\n    queues:\n      - name: root\n")
+	assert.Equal(t, resp.Code, http.StatusOK)
+	var result validateConfResponse
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+	assert.Assert(t, result.Allowed)
+	assert.Equal(t, result.Validator, validatorEmbedded)
+	assert.Equal(t, len(result.Errors), 0)
+
+	validateFn := validateWithEmbeddedCore
+	validateWithEmbeddedCore = func(content []byte) error {
+		return errors.New("yaml: line 3: found a tab character that violates indentation")
+	}
+	defer func() { validateWithEmbeddedCore = validateFn }()
+	resp = postConf(t, "partitions:\n  - name: default\n\tqueues:\n")
+	assert.Equal(t, resp.Code, http.StatusOK)
+	result = validateConfResponse{}
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+	assert.Assert(t, !result.Allowed)
+	assert.DeepEqual(t, result.Errors, []ConfigError{{Line: 3, Message: "found a tab character that violates indentation"}})
+}
+
+func TestValidateConfRemote(t *testing.T) {
+	var received string
+	core := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, coreValidateConfPath)
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NilError(t, err)
+		received = string(body)
+		writeJSON(w, &coreValidateConfResponse{Allowed: false, Reason: "duplicate queue name found: root.a"})
+	}))
+	defer core.Close()
+	conf.GetSchedulerConf().CoreServiceURL = core.URL + "/"
+	defer func() { conf.GetSchedulerConf().CoreServiceURL = "" }()
+
+	resp := postConf(t, "partitions: []")
+	assert.Equal(t, resp.Code, http.StatusOK)
+	assert.Equal(t, received, "partitions: []")
+	var result validateConfResponse
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+	assert.Assert(t, !result.Allowed)
+	assert.Equal(t, result.Validator, validatorRemote)
+	assert.Equal(t, result.Reason, "duplicate queue name found: root.a")
+	assert.DeepEqual(t, result.Errors, []ConfigError{{Message: "duplicate queue name found: root.a"}})
+
+	// the core cannot be reached
+	core.Close()
+	assert.Equal(t, postConf(t, "partitions: []").Code, http.StatusBadGateway)
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	assert.Equal(t, len(parseConfigErrors("")), 0)
+	errs := parseConfigErrors("yaml: unmarshal errors:\n  line 2: field queue not found in type configs.PartitionConfig\n" +
+		"  line 5: cannot unmarshal !!str `many` into uint64")
+	assert.DeepEqual(t, errs, []ConfigError{
+		{Line: 2, Message: "field queue not found in type configs.PartitionConfig"},
+		{Line: 5, Message: "cannot unmarshal !!str `many` into uint64"},
+	})
+	assert.DeepEqual(t, parseConfigErrors("placement rule must have a name"),
+		[]ConfigError{{Message: "placement rule must have a name"}})
+}
@@ -0,0 +1,109 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package webservice
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/cache"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/conf"
+	"github.com/apache/incubator-yunikorn-k8shim/pkg/log"
+)
+
+var schedulerContext *cache.Context
+
+// WebService serves the shim REST API, it exposes shim internal state
+// which is not visible through the scheduler core REST API.
+type WebService struct {
+	httpServer *http.Server
+	port       int
+}
+
+func NewWebApp(port int, context *cache.Context) *WebService {
+	schedulerContext = context
+	return &WebService{
+		port: port,
+	}
+}
+
+func newRouter() *mux.Router {
+	router := mux.NewRouter().StrictSlash(true)
+	for _, group := range []routes{webRoutes, profilingRoutes} {
+		for _, webRoute := range group {
+			handler := loggingHandler(webRoute.HandlerFunc, webRoute.Name)
+			router.Methods(webRoute.Method).Path(webRoute.Pattern).Name(webRoute.Name).Handler(handler)
+		}
+	}
+	return router
+}
+
+func loggingHandler(inner http.Handler, name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		inner.ServeHTTP(w, r)
+		log.Logger().Debug("web service request",
+			zap.String("method", r.Method),
+			zap.String("uri", r.RequestURI),
+			zap.String("name", name),
+			zap.Duration("duration", time.Since(start)))
+	})
+}
+
+// StartWebApp starts the web service in the background,
+// the web service is disabled when the port is set to 0.
+func (m *WebService) StartWebApp() {
+	if m.port == 0 {
+		log.Logger().Info("shim web service is disabled")
+		return
+	}
+	m.httpServer = &http.Server{Addr: fmt.Sprintf(":%d", m.port), Handler: newRouter()}
+	schedulerConf := conf.GetSchedulerConf()
+	useTLS := schedulerConf.WebServiceTLS
+	if useTLS {
+		m.httpServer.TLSConfig = newCertificates(schedulerConf).tlsConfig()
+	}
+	log.Logger().Info("shim web service started", zap.Int("port", m.port), zap.Bool("tls", useTLS))
+	go func() {
+		var httpError error
+		if useTLS {
+			// the certificate comes from the TLS config, not from files
+			httpError = m.httpServer.ListenAndServeTLS("", "")
+		} else {
+			httpError = m.httpServer.ListenAndServe()
+		}
+		if httpError != nil && httpError != http.ErrServerClosed {
+			log.Logger().Error("failed to start shim web service", zap.Error(httpError))
+		}
+	}()
+}
+
+func (m *WebService) StopWebApp() error {
+	if m.httpServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return m.httpServer.Shutdown(ctx)
+	}
+	return nil
+}